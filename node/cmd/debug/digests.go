@@ -0,0 +1,39 @@
+package debug
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/certusone/wormhole/node/pkg/conformance"
+	"github.com/spf13/cobra"
+)
+
+var verifyDigestsCmd = &cobra.Command{
+	Use:   "verify-digests [VECTORS_FILE]",
+	Short: "Check the digests computed for observations against a file of test vectors",
+	Long: `Check the digests computed for observations against a file of test vectors, such as
+node/pkg/conformance/testdata/digests.json. Exits with status 1 if any vector does not match.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		vectors, err := conformance.LoadDigestVectors(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		failed := 0
+		for _, d := range vectors.Vectors {
+			if err := d.Check(); err != nil {
+				fmt.Printf("FAIL %s: %v\n", d.Name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("ok   %s\n", d.Name)
+		}
+
+		fmt.Printf("%d/%d vectors passed\n", len(vectors.Vectors)-failed, len(vectors.Vectors))
+		if failed != 0 {
+			os.Exit(1)
+		}
+	},
+}
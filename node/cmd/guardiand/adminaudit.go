@@ -0,0 +1,123 @@
+package guardiand
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+)
+
+var AdminClientExportSigningAuditLogCmd = &cobra.Command{
+	Use:   "signing-audit-export [FILENAME]",
+	Short: "Exports the signing audit log of the running node to a file and verifies it",
+	Run:   runExportSigningAuditLog,
+	Args:  cobra.ExactArgs(1),
+}
+
+var AdminClientVerifySigningAuditLogCmd = &cobra.Command{
+	Use:   "signing-audit-verify",
+	Short: "Verifies the hash chain of the signing audit log of the running node",
+	Run:   runVerifySigningAuditLog,
+	Args:  cobra.NoArgs,
+}
+
+var AdminClientVerifySigningAuditLogFileCmd = &cobra.Command{
+	Use:   "signing-audit-verify-file [FILENAME]",
+	Short: "Verifies the hash chain of an exported signing audit log",
+	Run:   runVerifySigningAuditLogFile,
+	Args:  cobra.ExactArgs(1),
+}
+
+func runExportSigningAuditLog(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("failed to create file: %v", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := c.ExportSigningAuditLog(ctx, &nodev1.ExportSigningAuditLogRequest{})
+	if err != nil {
+		log.Fatalf("failed to run ExportSigningAuditLog RPC: %s", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive signing audit log: %s", err)
+		}
+		if _, err := w.Write(resp.Data); err != nil {
+			log.Fatalf("failed to write signing audit log: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write signing audit log: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("failed to write signing audit log: %v", err)
+	}
+
+	verifySigningAuditLogFile(args[0])
+}
+
+func runVerifySigningAuditLog(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.VerifySigningAuditLog(ctx, &nodev1.VerifySigningAuditLogRequest{})
+	if err != nil {
+		log.Fatalf("failed to run VerifySigningAuditLog RPC: %s", err)
+	}
+
+	fmt.Printf("entries: %d\n", resp.Entries)
+	fmt.Printf("last hash: %s\n", resp.LastHash)
+	if resp.Error != "" {
+		log.Fatalf("signing audit log is INVALID: %s", resp.Error)
+	}
+	log.Printf("signing audit log is valid")
+}
+
+func runVerifySigningAuditLogFile(cmd *cobra.Command, args []string) {
+	verifySigningAuditLogFile(args[0])
+}
+
+func verifySigningAuditLogFile(filename string) {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("failed to open signing audit log: %v", err)
+	}
+	defer f.Close()
+
+	res, err := guardiansigner.VerifyAuditLog(f)
+	fmt.Printf("entries: %d\n", res.Entries)
+	fmt.Printf("last hash: %s\n", res.LastHash)
+	if err != nil {
+		log.Fatalf("signing audit log is INVALID: %v", err)
+	}
+	log.Printf("signing audit log %s is valid", filename)
+}
@@ -0,0 +1,252 @@
+package guardiand
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// The admin service can optionally be exposed on a TCP listener for remote ops tooling. Unlike the UNIX socket, which is
+// only protected by filesystem permissions, the TCP listener requires mutual TLS. Clients are identified by the common
+// name of their certificate, which must be issued by the configured client CA and be listed in the authorization
+// config, along with the roles granted to the client:
+//
+//	{
+//	  "clients": [
+//	    { "commonName": "dashboard", "roles": ["readonly"] },
+//	    { "commonName": "oncall", "roles": ["operator"] },
+//	    { "commonName": "governance-signer", "roles": ["governance"] }
+//	  ]
+//	}
+//
+// Each method requires a single role. The readonly role is implied by any other role.
+
+type adminRole string
+
+const (
+	adminRoleReadOnly   adminRole = "readonly"
+	adminRoleOperator   adminRole = "operator"
+	adminRoleGovernance adminRole = "governance"
+)
+
+const (
+	nodePrivilegedServicePrefix = "/node.v1.NodePrivilegedService/"
+	publicRPCServicePrefix      = "/publicrpc.v1.PublicRPCService/"
+)
+
+// adminMethodRoles maps the methods of the NodePrivilegedService to the role they require. All methods of the
+// PublicRPCService only require the readonly role.
+var adminMethodRoles = map[string]adminRole{
+	"InjectGovernanceVAA":            adminRoleGovernance,
+	"InjectSignedGovernanceVAA":      adminRoleGovernance,
+	"FindMissingMessages":            adminRoleOperator,
+	"SendObservationRequest":         adminRoleOperator,
+	"ReobserveMessage":               adminRoleOperator,
+	"ChainGovernorStatus":            adminRoleReadOnly,
+	"ChainGovernorReload":            adminRoleOperator,
+	"ChainGovernorDropPendingVAA":    adminRoleOperator,
+	"ChainGovernorReleasePendingVAA": adminRoleOperator,
+	"ChainGovernorEmergencyRelease":  adminRoleOperator,
+	"ChainGovernorResetReleaseTimer": adminRoleOperator,
+	"ChainGovernorListPendingVAAs":   adminRoleReadOnly,
+	"ChainGovernorMovePendingVAA":    adminRoleOperator,
+	"AccountantReconcile":            adminRoleReadOnly,
+	"AccountantModifyBalanceDryRun":  adminRoleReadOnly,
+	"WatcherPause":                   adminRoleOperator,
+	"WatcherResume":                  adminRoleOperator,
+	"WatcherSetEndpoint":             adminRoleOperator,
+	"WatcherStatus":                  adminRoleReadOnly,
+	"ListSignedVAAs":                 adminRoleReadOnly,
+	"CountSignedVAAs":                adminRoleReadOnly,
+	"ExportSignedVAAs":               adminRoleReadOnly,
+	"NodeStatus":                     adminRoleReadOnly,
+	"CompareChainHeights":            adminRoleReadOnly,
+	"BackupDatabase":                 adminRoleOperator,
+	"SupervisorTree":                 adminRoleReadOnly,
+	"Profile":                        adminRoleOperator,
+	"ExportSigningAuditLog":          adminRoleReadOnly,
+	"VerifySigningAuditLog":          adminRoleReadOnly,
+	"SigningRateLimitStatus":         adminRoleReadOnly,
+	"CircuitBreakerStatus":           adminRoleReadOnly,
+	"ResumeSigning":                  adminRoleOperator,
+	"GuardianAvailability":           adminRoleReadOnly,
+	"DrainShutdown":                  adminRoleOperator,
+	"GetMessageProvenance":           adminRoleReadOnly,
+	"PurgeAndResignVAA":              adminRoleOperator,
+	"SetFaultInjection":              adminRoleOperator,
+	"RescanBlockRange":               adminRoleOperator,
+	"GetFeatureFlags":                adminRoleReadOnly,
+	"ListStuckMessages":              adminRoleReadOnly,
+}
+
+// requiredAdminRole returns the role required to call a method, identified by its full gRPC name.
+func requiredAdminRole(fullMethod string) (adminRole, error) {
+	if strings.HasPrefix(fullMethod, publicRPCServicePrefix) {
+		return adminRoleReadOnly, nil
+	}
+
+	if strings.HasPrefix(fullMethod, nodePrivilegedServicePrefix) {
+		if role, ok := adminMethodRoles[strings.TrimPrefix(fullMethod, nodePrivilegedServicePrefix)]; ok {
+			return role, nil
+		}
+	}
+
+	// Deny methods that have not been assigned a role, so new methods are not exposed by accident.
+	return "", fmt.Errorf("method %s is not available on the admin TCP listener", fullMethod)
+}
+
+type adminAuthzConfig struct {
+	Clients []struct {
+		CommonName string   `json:"commonName"`
+		Roles      []string `json:"roles"`
+	} `json:"clients"`
+}
+
+// adminAuthorizer checks that the client certificate of a call grants the role required by the method.
+type adminAuthorizer struct {
+	// Roles granted to each client, by certificate common name.
+	clients map[string]map[adminRole]bool
+}
+
+// loadAdminAuthorizer reads the authorization config of the admin TCP listener.
+func loadAdminAuthorizer(path string) (*adminAuthorizer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin authorization config: %w", err)
+	}
+
+	var cfg adminAuthzConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse admin authorization config: %w", err)
+	}
+
+	a := &adminAuthorizer{clients: make(map[string]map[adminRole]bool)}
+	for _, c := range cfg.Clients {
+		if c.CommonName == "" {
+			return nil, errors.New("admin authorization config: client without a common name")
+		}
+		if _, exists := a.clients[c.CommonName]; exists {
+			return nil, fmt.Errorf("admin authorization config: client %s is listed more than once", c.CommonName)
+		}
+
+		roles := map[adminRole]bool{adminRoleReadOnly: true}
+		for _, r := range c.Roles {
+			switch role := adminRole(r); role {
+			case adminRoleReadOnly, adminRoleOperator, adminRoleGovernance:
+				roles[role] = true
+			default:
+				return nil, fmt.Errorf("admin authorization config: client %s has unknown role %s", c.CommonName, r)
+			}
+		}
+		a.clients[c.CommonName] = roles
+	}
+
+	return a, nil
+}
+
+func (a *adminAuthorizer) authorize(ctx context.Context, fullMethod string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer information")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+	commonName := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+
+	roles, ok := a.clients[commonName]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "client %s is not allowed", commonName)
+	}
+
+	role, err := requiredAdminRole(fullMethod)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if !roles[role] {
+		return status.Errorf(codes.PermissionDenied, "client %s does not have the %s role required by %s", commonName, role, fullMethod)
+	}
+
+	return nil
+}
+
+func (a *adminAuthorizer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *adminAuthorizer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// adminServerTLSConfig returns a TLS config that requires clients to present a certificate issued by the client CA.
+func adminServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin TLS certificate: %w", err)
+	}
+
+	pool, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// adminClientTLSConfig returns a TLS config presenting the client certificate and verifying the server against the CA.
+func adminClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client TLS certificate: %w", err)
+	}
+
+	pool, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no CA certificates found in %s", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,177 @@
+package guardiand
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/watchercontrol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const testAuthzConfig = `{
+  "clients": [
+    { "commonName": "dashboard", "roles": ["readonly"] },
+    { "commonName": "oncall", "roles": ["operator"] },
+    { "commonName": "signer", "roles": ["governance"] }
+  ]
+}`
+
+func writeTestFile(t *testing.T, name string, b []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, b, 0600))
+	return path
+}
+
+func TestAdminMethodRolesCoverService(t *testing.T) {
+	for _, m := range nodev1.NodePrivilegedService_ServiceDesc.Methods {
+		assert.Contains(t, adminMethodRoles, m.MethodName)
+	}
+	for _, s := range nodev1.NodePrivilegedService_ServiceDesc.Streams {
+		assert.Contains(t, adminMethodRoles, s.StreamName)
+	}
+}
+
+func TestAdminAuthorizer(t *testing.T) {
+	authz, err := loadAdminAuthorizer(writeTestFile(t, "authz.json", []byte(testAuthzConfig)))
+	require.NoError(t, err)
+
+	ctxFor := func(commonName string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+		})
+	}
+
+	tests := []struct {
+		commonName string
+		method     string
+		code       codes.Code
+	}{
+		{"dashboard", "/node.v1.NodePrivilegedService/NodeStatus", codes.OK},
+		{"dashboard", "/publicrpc.v1.PublicRPCService/GetLastHeartbeats", codes.OK},
+		{"dashboard", "/node.v1.NodePrivilegedService/WatcherPause", codes.PermissionDenied},
+		{"oncall", "/node.v1.NodePrivilegedService/WatcherPause", codes.OK},
+		{"oncall", "/node.v1.NodePrivilegedService/NodeStatus", codes.OK},
+		{"oncall", "/node.v1.NodePrivilegedService/InjectGovernanceVAA", codes.PermissionDenied},
+		{"signer", "/node.v1.NodePrivilegedService/InjectGovernanceVAA", codes.OK},
+		{"signer", "/node.v1.NodePrivilegedService/WatcherPause", codes.PermissionDenied},
+		{"signer", "/node.v1.NodePrivilegedService/NotAMethod", codes.PermissionDenied},
+		{"stranger", "/node.v1.NodePrivilegedService/NodeStatus", codes.PermissionDenied},
+	}
+
+	for _, tc := range tests {
+		err := authz.authorize(ctxFor(tc.commonName), tc.method)
+		assert.Equal(t, tc.code, status.Code(err), "%s calling %s", tc.commonName, tc.method)
+	}
+
+	err = authz.authorize(context.Background(), "/node.v1.NodePrivilegedService/NodeStatus")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = loadAdminAuthorizer(writeTestFile(t, "authz.json", []byte(`{"clients": [{"commonName": "a", "roles": ["root"]}]}`)))
+	assert.Error(t, err)
+
+	_, err = loadAdminAuthorizer(writeTestFile(t, "authz.json", []byte(`{"clients": [{"commonName": "a"}, {"commonName": "a"}]}`)))
+	assert.Error(t, err)
+}
+
+// Creates a certificate for the common name, signed by the parent, or self-signed if the parent is nil.
+func createTestCert(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestAdminTCPListenerRequiresClientCertificate(t *testing.T) {
+	ca, caKey, caPEM, _ := createTestCert(t, "ca", nil, nil)
+	_, _, serverPEM, serverKeyPEM := createTestCert(t, "guardian", ca, caKey)
+	_, _, clientPEM, clientKeyPEM := createTestCert(t, "dashboard", ca, caKey)
+
+	caFile := writeTestFile(t, "ca.pem", caPEM)
+	serverTLS, err := adminServerTLSConfig(writeTestFile(t, "server.pem", serverPEM), writeTestFile(t, "server.key", serverKeyPEM), caFile)
+	require.NoError(t, err)
+	authz, err := loadAdminAuthorizer(writeTestFile(t, "authz.json", []byte(testAuthzConfig)))
+	require.NoError(t, err)
+
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(serverTLS)),
+		grpc.UnaryInterceptor(authz.unaryInterceptor),
+	)
+	nodev1.RegisterNodePrivilegedServiceServer(server, &nodePrivilegedService{watchers: watchercontrol.NewController(zap.NewNop())})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(l) }()
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clientTLS, err := adminClientTLSConfig(writeTestFile(t, "client.pem", clientPEM), writeTestFile(t, "client.key", clientKeyPEM), caFile)
+	require.NoError(t, err)
+	conn, err := grpc.DialContext(ctx, l.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := nodev1.NewNodePrivilegedServiceClient(conn)
+
+	_, err = c.WatcherStatus(ctx, &nodev1.WatcherStatusRequest{})
+	assert.NoError(t, err)
+
+	_, err = c.WatcherPause(ctx, &nodev1.WatcherPauseRequest{ChainId: 2})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Without a client certificate, the handshake fails.
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	anonymous, err := grpc.DialContext(ctx, l.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})))
+	require.NoError(t, err)
+	defer anonymous.Close()
+
+	_, err = nodev1.NewNodePrivilegedServiceClient(anonymous).WatcherStatus(ctx, &nodev1.WatcherStatusRequest{})
+	assert.Error(t, err)
+}
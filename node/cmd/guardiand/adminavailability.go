@@ -0,0 +1,70 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var guardianAvailabilityDays *uint32
+var guardianAvailabilityJSON *bool
+
+func init() {
+	guardianAvailabilityDays = AdminClientGuardianAvailabilityCmd.Flags().Uint32("days", 30, "Number of days to aggregate, including today (UTC)")
+	guardianAvailabilityJSON = AdminClientGuardianAvailabilityCmd.Flags().Bool("json", false, "Print the availability as JSON")
+}
+
+var AdminClientGuardianAvailabilityCmd = &cobra.Command{
+	Use:   "guardian-availability",
+	Short: "Prints how often each guardian was available on each chain, as sampled from the heartbeats received by the node",
+	Run:   runGuardianAvailability,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runGuardianAvailability(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.GuardianAvailability(ctx, &nodev1.GuardianAvailabilityRequest{Days: *guardianAvailabilityDays})
+	if err != nil {
+		log.Fatalf("failed to run GuardianAvailability RPC: %s", err)
+	}
+
+	if *guardianAvailabilityJSON {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			log.Fatalf("failed to marshal availability: %v", err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "guardian\tchain\tavailability\tsamples\t")
+	for _, e := range resp.Entries {
+		chain := "heartbeats"
+		if e.ChainId != 0 {
+			chain = vaa.ChainID(e.ChainId).String()
+		}
+		availability := "-"
+		if e.Samples != 0 {
+			availability = fmt.Sprintf("%.2f%%", 100*float64(e.Available)/float64(e.Samples))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t\n", e.GuardianAddr, chain, availability, e.Samples)
+	}
+	w.Flush()
+}
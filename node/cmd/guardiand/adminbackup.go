@@ -0,0 +1,262 @@
+package guardiand
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreDataDir  *string
+	restoreVAAStore *string
+)
+
+func init() {
+	restoreDataDir = AdminClientRestoreDatabaseCmd.Flags().String("dataDir", "", "Data directory of the node to restore (required)")
+	restoreVAAStore = AdminClientRestoreDatabaseCmd.Flags().String("vaaStore", "badger", "Where the node stores signed VAAs: badger or sqlite")
+}
+
+var AdminClientBackupDatabaseCmd = &cobra.Command{
+	Use:   "db-backup [FILENAME|URL]",
+	Short: "Backs up the database of the running node to a file, or uploads it to an HTTP(S) URL such as a presigned S3 URL",
+	Run:   runBackupDatabase,
+	Args:  cobra.ExactArgs(1),
+}
+
+var AdminClientRestoreDatabaseCmd = &cobra.Command{
+	Use:   "db-restore [FILENAME|URL]",
+	Short: "Verifies a database backup and restores it into the data directory of a stopped node",
+	Run:   runRestoreDatabase,
+	Args:  cobra.ExactArgs(1),
+}
+
+func isBackupURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+func printBackupManifest(manifest *db.BackupManifest) {
+	fmt.Printf("created at: %s\n", manifest.CreatedAt)
+	fmt.Printf("signed VAAs: %d\n", manifest.SignedVAAs)
+	for _, s := range manifest.Sections {
+		fmt.Printf("section %s: %d bytes, sha256 %s\n", s.Name, s.Size, s.SHA256)
+	}
+}
+
+func verifyBackupFile(filename string) (*db.BackupManifest, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return db.VerifyBackup(f)
+}
+
+// uploadBackup uploads a backup file with a PUT request. S3 requires the content length of uploads to presigned URLs to
+// be known, which is why the backup is written to a file first.
+func uploadBackup(ctx context.Context, filename string, url string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload failed with status %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// downloadBackup downloads a backup to a temporary file, which the caller must remove.
+func downloadBackup(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed with status %s", resp.Status)
+	}
+
+	f, err := ioutil.TempFile("", "guardiand-backup-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func runBackupDatabase(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	target := args[0]
+	filename := target
+	if isBackupURL(target) {
+		f, err := ioutil.TempFile("", "guardiand-backup-*")
+		if err != nil {
+			log.Fatalf("failed to create temporary file: %v", err)
+		}
+		f.Close()
+		filename = f.Name()
+		defer os.Remove(filename)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if filename != target {
+		flags = os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(filename, flags, 0600)
+	if err != nil {
+		log.Fatalf("failed to create backup file: %v", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := c.BackupDatabase(ctx, &nodev1.BackupDatabaseRequest{})
+	if err != nil {
+		log.Fatalf("failed to run BackupDatabase RPC: %s", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive backup: %s", err)
+		}
+		if _, err := w.Write(resp.Data); err != nil {
+			log.Fatalf("failed to write backup: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write backup: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("failed to write backup: %v", err)
+	}
+
+	manifest, err := verifyBackupFile(filename)
+	if err != nil {
+		log.Fatalf("failed to verify backup: %v", err)
+	}
+	printBackupManifest(manifest)
+
+	if filename != target {
+		if err := uploadBackup(ctx, filename, target); err != nil {
+			log.Fatalf("failed to upload backup: %v", err)
+		}
+		log.Printf("uploaded verified backup to %s", redactEndpoint(target))
+		return
+	}
+	log.Printf("wrote verified backup to %s", target)
+}
+
+func runRestoreDatabase(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *restoreDataDir == "" {
+		log.Fatalf("Please specify --dataDir")
+	}
+	if *restoreVAAStore != "badger" && *restoreVAAStore != "sqlite" {
+		log.Fatalf("--vaaStore must be badger or sqlite")
+	}
+
+	filename := args[0]
+	if isBackupURL(filename) {
+		var err error
+		filename, err = downloadBackup(ctx, args[0])
+		if err != nil {
+			log.Fatalf("failed to download backup: %v", err)
+		}
+		defer os.Remove(filename)
+	}
+
+	manifest, err := verifyBackupFile(filename)
+	if err != nil {
+		log.Fatalf("failed to verify backup: %v", err)
+	}
+	printBackupManifest(manifest)
+
+	// Restoring into an existing database would mix its state with the backup.
+	dbPath := path.Join(*restoreDataDir, "db")
+	if entries, err := os.ReadDir(dbPath); err == nil && len(entries) != 0 {
+		log.Fatalf("refusing to restore into the existing database %s, move it away first", dbPath)
+	}
+	var sqlitePath string
+	if *restoreVAAStore == "sqlite" {
+		sqlitePath = path.Join(*restoreDataDir, "vaas.sqlite")
+		if _, err := os.Stat(sqlitePath); err == nil {
+			log.Fatalf("refusing to restore into the existing database %s, move it away first", sqlitePath)
+		}
+	}
+
+	if err := os.MkdirAll(dbPath, 0700); err != nil {
+		log.Fatalf("failed to create database directory: %v", err)
+	}
+	d, err := openDatabase(dbPath, sqlitePath)
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+	defer d.Close()
+
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("failed to open backup: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := d.Restore(f); err != nil {
+		log.Fatalf("failed to restore backup: %v", err)
+	}
+
+	log.Printf("restored %d signed VAAs into %s", manifest.SignedVAAs, *restoreDataDir)
+}
@@ -0,0 +1,89 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+)
+
+var AdminClientCircuitBreakerStatusCmd = &cobra.Command{
+	Use:   "circuit-breaker-status",
+	Short: "Prints whether signing is paused on each chain by the circuit breaker, and why",
+	Run:   runCircuitBreakerStatus,
+	Args:  cobra.ExactArgs(0),
+}
+
+var AdminClientResumeSigningCmd = &cobra.Command{
+	Use:   "resume-signing [CHAIN]",
+	Short: "Resumes signing on a chain paused by the circuit breaker, accepting the anomaly which tripped it",
+	Run:   runResumeSigning,
+	Args:  cobra.ExactArgs(1),
+}
+
+func runCircuitBreakerStatus(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.CircuitBreakerStatus(ctx, &nodev1.CircuitBreakerStatusRequest{})
+	if err != nil {
+		log.Fatalf("failed to run CircuitBreakerStatus RPC: %s", err)
+	}
+
+	if !resp.Enabled {
+		fmt.Println("The circuit breaker is disabled")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "chain\tstatus\tbaseline/min\tcurrent minute\tdropped\tlast trip\t")
+	for _, e := range resp.Entries {
+		state := "signing"
+		if e.Paused {
+			state = "PAUSED"
+		}
+		baseline := "measuring"
+		if e.BaselinePerMinute != 0 {
+			baseline = fmt.Sprintf("%.1f", e.BaselinePerMinute)
+		}
+		lastTrip := "-"
+		if e.LastTripTime != 0 {
+			lastTrip = fmt.Sprintf("%s ago: %s (%s)", time.Since(time.Unix(e.LastTripTime, 0)).Truncate(time.Second), e.LastTripReason, e.LastTripDetail)
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\t%d\t%d\t%s\t\n", vaa.ChainID(e.ChainId), state, baseline, e.CurrentMinute, e.Dropped, lastTrip)
+	}
+	w.Flush()
+}
+
+func runResumeSigning(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := c.ResumeSigning(ctx, &nodev1.ResumeSigningRequest{ChainId: uint32(chainID)}); err != nil {
+		log.Fatalf("failed to run ResumeSigning RPC: %s", err)
+	}
+	fmt.Printf("Signing resumed on %v\n", chainID)
+}
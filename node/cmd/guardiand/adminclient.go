@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/status-im/keycard-go/hexutils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/encoding/prototext"
 
@@ -30,43 +31,121 @@ import (
 
 var (
 	clientSocketPath *string
+	clientTLSCert    *string
+	clientTLSKey     *string
+	clientTLSCA      *string
 	shouldBackfill   *bool
+	purgeTxHash      *string
 )
 
 func init() {
 	// Shared flags for all admin commands
 	pf := pflag.NewFlagSet("commonAdminFlags", pflag.ContinueOnError)
-	clientSocketPath = pf.String("socket", "", "gRPC admin server socket to connect to")
+	clientSocketPath = pf.String("socket", "", "gRPC admin server socket to connect to, or tcp://host:port for the admin TCP listener")
 	err := cobra.MarkFlagRequired(pf, "socket")
 	if err != nil {
 		panic(err)
 	}
+	clientTLSCert = pf.String("tlsCert", "", "Path to the client TLS certificate, when connecting to the admin TCP listener")
+	clientTLSKey = pf.String("tlsKey", "", "Path to the client TLS key, when connecting to the admin TCP listener")
+	clientTLSCA = pf.String("tlsCA", "", "Path to the CA certificates to verify the admin TCP listener against")
 
 	shouldBackfill = AdminClientFindMissingMessagesCmd.Flags().Bool(
 		"backfill", false, "backfill missing VAAs from public RPC")
+	purgeTxHash = PurgeAndResignVAACmd.Flags().String(
+		"txHash", "", "chain-specific tx hash of the message as hex, if this node no longer has it")
 
 	AdminClientInjectGuardianSetUpdateCmd.Flags().AddFlagSet(pf)
 	AdminClientFindMissingMessagesCmd.Flags().AddFlagSet(pf)
 	AdminClientListNodes.Flags().AddFlagSet(pf)
 	DumpVAAByMessageID.Flags().AddFlagSet(pf)
 	SendObservationRequest.Flags().AddFlagSet(pf)
+	ReobserveMessageCmd.Flags().AddFlagSet(pf)
+	MessageProvenanceCmd.Flags().AddFlagSet(pf)
+	PurgeAndResignVAACmd.Flags().AddFlagSet(pf)
 	ClientChainGovernorStatusCmd.Flags().AddFlagSet(pf)
 	ClientChainGovernorReloadCmd.Flags().AddFlagSet(pf)
 	ClientChainGovernorDropPendingVAACmd.Flags().AddFlagSet(pf)
 	ClientChainGovernorReleasePendingVAACmd.Flags().AddFlagSet(pf)
+	ClientChainGovernorEmergencyReleaseCmd.Flags().AddFlagSet(pf)
 	ClientChainGovernorResetReleaseTimerCmd.Flags().AddFlagSet(pf)
+	ClientChainGovernorListPendingVAAsCmd.Flags().AddFlagSet(pf)
+	ClientChainGovernorMovePendingVAACmd.Flags().AddFlagSet(pf)
+	ClientAccountantReconcileCmd.Flags().AddFlagSet(pf)
+	ClientAccountantModifyBalanceDryRunCmd.Flags().AddFlagSet(pf)
+	ClientWatcherPauseCmd.Flags().AddFlagSet(pf)
+	ClientWatcherResumeCmd.Flags().AddFlagSet(pf)
+	ClientWatcherSetEndpointCmd.Flags().AddFlagSet(pf)
+	ClientWatcherStatusCmd.Flags().AddFlagSet(pf)
+	AdminClientListSignedVAAsCmd.Flags().AddFlagSet(pf)
+	AdminClientCountSignedVAAsCmd.Flags().AddFlagSet(pf)
+	AdminClientExportSignedVAAsCmd.Flags().AddFlagSet(pf)
+	AdminClientExportVAATableCmd.Flags().AddFlagSet(pf)
+	AdminClientProfileCmd.Flags().AddFlagSet(pf)
+	AdminClientNodeStatusCmd.Flags().AddFlagSet(pf)
+	AdminClientInjectSignedGovernanceVAACmd.Flags().AddFlagSet(pf)
+	AdminClientCompareHeightsCmd.Flags().AddFlagSet(pf)
+	AdminClientBackupDatabaseCmd.Flags().AddFlagSet(pf)
+	AdminClientSupervisorTreeCmd.Flags().AddFlagSet(pf)
+	AdminClientExportSigningAuditLogCmd.Flags().AddFlagSet(pf)
+	AdminClientVerifySigningAuditLogCmd.Flags().AddFlagSet(pf)
+	AdminClientFaultInjectCmd.Flags().AddFlagSet(pf)
+	AdminClientSigningRateLimitStatusCmd.Flags().AddFlagSet(pf)
+	AdminClientCircuitBreakerStatusCmd.Flags().AddFlagSet(pf)
+	AdminClientResumeSigningCmd.Flags().AddFlagSet(pf)
+	AdminClientGuardianAvailabilityCmd.Flags().AddFlagSet(pf)
+	AdminClientDrainShutdownCmd.Flags().AddFlagSet(pf)
+	AdminClientRescanBlockRangeCmd.Flags().AddFlagSet(pf)
 
 	AdminCmd.AddCommand(AdminClientInjectGuardianSetUpdateCmd)
 	AdminCmd.AddCommand(AdminClientFindMissingMessagesCmd)
 	AdminCmd.AddCommand(AdminClientGovernanceVAAVerifyCmd)
+	AdminCmd.AddCommand(AdminClientDecodeVAACmd)
 	AdminCmd.AddCommand(AdminClientListNodes)
 	AdminCmd.AddCommand(DumpVAAByMessageID)
 	AdminCmd.AddCommand(SendObservationRequest)
+	AdminCmd.AddCommand(ReobserveMessageCmd)
+	AdminCmd.AddCommand(MessageProvenanceCmd)
+	AdminCmd.AddCommand(PurgeAndResignVAACmd)
 	AdminCmd.AddCommand(ClientChainGovernorStatusCmd)
 	AdminCmd.AddCommand(ClientChainGovernorReloadCmd)
 	AdminCmd.AddCommand(ClientChainGovernorDropPendingVAACmd)
 	AdminCmd.AddCommand(ClientChainGovernorReleasePendingVAACmd)
+	AdminCmd.AddCommand(ClientChainGovernorEmergencyReleaseCmd)
 	AdminCmd.AddCommand(ClientChainGovernorResetReleaseTimerCmd)
+	AdminCmd.AddCommand(ClientChainGovernorListPendingVAAsCmd)
+	AdminCmd.AddCommand(ClientChainGovernorMovePendingVAACmd)
+	AdminCmd.AddCommand(ClientAccountantReconcileCmd)
+	AdminCmd.AddCommand(ClientAccountantModifyBalanceDryRunCmd)
+	AdminCmd.AddCommand(ClientWatcherPauseCmd)
+	AdminCmd.AddCommand(ClientWatcherResumeCmd)
+	AdminCmd.AddCommand(ClientWatcherSetEndpointCmd)
+	AdminCmd.AddCommand(ClientWatcherStatusCmd)
+	AdminCmd.AddCommand(AdminClientListSignedVAAsCmd)
+	AdminCmd.AddCommand(AdminClientCountSignedVAAsCmd)
+	AdminCmd.AddCommand(AdminClientExportSignedVAAsCmd)
+	AdminCmd.AddCommand(AdminClientExportVAATableCmd)
+	AdminCmd.AddCommand(AdminClientProfileCmd)
+	AdminCmd.AddCommand(AdminClientNodeStatusCmd)
+	AdminCmd.AddCommand(AdminClientExportGovernanceVAACmd)
+	AdminCmd.AddCommand(AdminClientSignGovernanceVAACmd)
+	AdminCmd.AddCommand(AdminClientInjectSignedGovernanceVAACmd)
+	AdminCmd.AddCommand(AdminClientCompareHeightsCmd)
+	AdminCmd.AddCommand(AdminClientBackupDatabaseCmd)
+	AdminCmd.AddCommand(AdminClientRestoreDatabaseCmd)
+	AdminCmd.AddCommand(AdminClientSupervisorTreeCmd)
+	AdminCmd.AddCommand(AdminClientExportSigningAuditLogCmd)
+	AdminCmd.AddCommand(AdminClientVerifySigningAuditLogCmd)
+	AdminCmd.AddCommand(AdminClientVerifySigningAuditLogFileCmd)
+	AdminCmd.AddCommand(AdminClientFaultInjectCmd)
+	AdminCmd.AddCommand(AdminClientSigningRateLimitStatusCmd)
+	AdminCmd.AddCommand(AdminClientCircuitBreakerStatusCmd)
+	AdminCmd.AddCommand(AdminClientResumeSigningCmd)
+	AdminCmd.AddCommand(AdminClientGuardianAvailabilityCmd)
+	AdminCmd.AddCommand(AdminClientDrainShutdownCmd)
+	AdminCmd.AddCommand(AdminClientRescanBlockRangeCmd)
+	AdminCmd.AddCommand(AdminClientFeatureFlagsCmd)
+	AdminCmd.AddCommand(AdminClientStuckMessagesCmd)
 }
 
 var AdminCmd = &cobra.Command{
@@ -102,6 +181,27 @@ var SendObservationRequest = &cobra.Command{
 	Args:  cobra.ExactArgs(2),
 }
 
+var ReobserveMessageCmd = &cobra.Command{
+	Use:   "reobserve-message [CHAIN_ID|CHAIN_NAME] [EMITTER_ADDRESS_HEX] [SEQUENCE]",
+	Short: "Broadcast an observation request for a message observed by this node, identified by chain, emitter and sequence",
+	Run:   runReobserveMessage,
+	Args:  cobra.ExactArgs(3),
+}
+
+var MessageProvenanceCmd = &cobra.Command{
+	Use:   "message-provenance [CHAIN_ID|CHAIN_NAME] [EMITTER_ADDRESS_HEX] [SEQUENCE]",
+	Short: "Shows how a message observed by this node was obtained by its watcher, to debug disputed observations",
+	Run:   runMessageProvenance,
+	Args:  cobra.ExactArgs(3),
+}
+
+var PurgeAndResignVAACmd = &cobra.Command{
+	Use:   "purge-and-resign-vaa [CHAIN_ID|CHAIN_NAME] [EMITTER_ADDRESS_HEX] [SEQUENCE]",
+	Short: "Broadcasts an observation request to sign a VAA signed by an old guardian set with the current set, replacing it once signed",
+	Run:   runPurgeAndResignVAA,
+	Args:  cobra.ExactArgs(3),
+}
+
 var ClientChainGovernorStatusCmd = &cobra.Command{
 	Use:   "governor-status",
 	Short: "Displays the status of the chain governor",
@@ -130,6 +230,13 @@ var ClientChainGovernorReleasePendingVAACmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 }
 
+var ClientChainGovernorEmergencyReleaseCmd = &cobra.Command{
+	Use:   "governor-emergency-release [VAA_ID]",
+	Short: "Votes to release the specified VAA (chain/emitter/seq) held by the chain governor emergency hold, which is published once enough guardians voted",
+	Run:   runChainGovernorEmergencyRelease,
+	Args:  cobra.ExactArgs(1),
+}
+
 var ClientChainGovernorResetReleaseTimerCmd = &cobra.Command{
 	Use:   "governor-reset-release-timer [VAA_ID]",
 	Short: "Resets the release timer for a chain governor pending VAA, extending it to the configured maximum",
@@ -137,8 +244,82 @@ var ClientChainGovernorResetReleaseTimerCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 }
 
+var ClientChainGovernorListPendingVAAsCmd = &cobra.Command{
+	Use:   "governor-list-pending-vaas [CHAIN_ID|CHAIN_NAME]",
+	Short: "Lists the VAAs in the chain governor pending list, optionally for a single emitter chain, with their decoded transfer payloads",
+	Run:   runChainGovernorListPendingVAAs,
+	Args:  cobra.RangeArgs(0, 1),
+}
+
+var ClientChainGovernorMovePendingVAACmd = &cobra.Command{
+	Use:   "governor-move-pending-vaa [VAA_ID] [POSITION]",
+	Short: "Moves the specified VAA (chain/emitter/seq) to a new zero-based position in the chain governor pending list of its emitter chain",
+	Run:   runChainGovernorMovePendingVAA,
+	Args:  cobra.ExactArgs(2),
+}
+
+var ClientAccountantReconcileCmd = &cobra.Command{
+	Use:   "accountant-reconcile [CHAIN_ID|CHAIN_NAME]",
+	Short: "Compares the token bridge custody balances on chain against the accountant ledger, optionally for a single chain",
+	Run:   runAccountantReconcile,
+	Args:  cobra.RangeArgs(0, 1),
+}
+
+var ClientAccountantModifyBalanceDryRunCmd = &cobra.Command{
+	Use:   "accountant-modify-balance-dry-run [FILENAME]",
+	Short: "Shows the effect of the accountant modify balance governance messages in the specified template on the ledger of this node",
+	Run:   runAccountantModifyBalanceDryRun,
+	Args:  cobra.ExactArgs(1),
+}
+
+var ClientWatcherPauseCmd = &cobra.Command{
+	Use:   "watcher-pause [CHAIN_ID|CHAIN_NAME]",
+	Short: "Stops the watcher for the specified chain until it is resumed",
+	Run:   runWatcherPause,
+	Args:  cobra.ExactArgs(1),
+}
+
+var ClientWatcherResumeCmd = &cobra.Command{
+	Use:   "watcher-resume [CHAIN_ID|CHAIN_NAME]",
+	Short: "Restarts the watcher for the specified paused chain",
+	Run:   runWatcherResume,
+	Args:  cobra.ExactArgs(1),
+}
+
+var ClientWatcherSetEndpointCmd = &cobra.Command{
+	Use:   "watcher-set-endpoint [CHAIN_ID|CHAIN_NAME] [RPC_URL]",
+	Short: "Restarts the watcher for the specified chain using a different RPC endpoint, until the node is restarted",
+	Run:   runWatcherSetEndpoint,
+	Args:  cobra.ExactArgs(2),
+}
+
+var ClientWatcherStatusCmd = &cobra.Command{
+	Use:   "watcher-status",
+	Short: "Lists the watchers and whether they are paused",
+	Run:   runWatcherStatus,
+	Args:  cobra.ExactArgs(0),
+}
+
+// dialAdmin connects to the admin UNIX socket, or to the admin TCP listener using mutual TLS if addr starts with tcp://.
+func dialAdmin(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	if !strings.HasPrefix(addr, "tcp://") {
+		return grpc.DialContext(ctx, fmt.Sprintf("unix:///%s", addr), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if *clientTLSCert == "" || *clientTLSKey == "" || *clientTLSCA == "" {
+		return nil, fmt.Errorf("--tlsCert, --tlsKey and --tlsCA are required to connect to %s", addr)
+	}
+
+	tlsConfig, err := adminClientTLSConfig(*clientTLSCert, *clientTLSKey, *clientTLSCA)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.DialContext(ctx, strings.TrimPrefix(addr, "tcp://"), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+}
+
 func getAdminClient(ctx context.Context, addr string) (*grpc.ClientConn, nodev1.NodePrivilegedServiceClient, error) {
-	conn, err := grpc.DialContext(ctx, fmt.Sprintf("unix:///%s", addr), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialAdmin(ctx, addr)
 
 	if err != nil {
 		log.Fatalf("failed to connect to %s: %v", addr, err)
@@ -149,7 +330,7 @@ func getAdminClient(ctx context.Context, addr string) (*grpc.ClientConn, nodev1.
 }
 
 func getPublicRPCServiceClient(ctx context.Context, addr string) (*grpc.ClientConn, publicrpcv1.PublicRPCServiceClient, error) {
-	conn, err := grpc.DialContext(ctx, fmt.Sprintf("unix:///%s", addr), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := dialAdmin(ctx, addr)
 
 	if err != nil {
 		log.Fatalf("failed to connect to %s: %v", addr, err)
@@ -308,6 +489,108 @@ func runSendObservationRequest(cmd *cobra.Command, args []string) {
 	}
 }
 
+func runReobserveMessage(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	sequence, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid sequence number: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.ReobserveMessage(ctx, &nodev1.ReobserveMessageRequest{
+		EmitterChain:   uint32(chainID),
+		EmitterAddress: args[1],
+		Sequence:       sequence,
+	})
+	if err != nil {
+		log.Fatalf("failed to run ReobserveMessage RPC: %s", err)
+	}
+
+	fmt.Printf("found: %v, republished: %v, vaaExists: %v, txHash: %s\n", resp.Found, resp.Republished, resp.VaaExists, resp.TxHash)
+	fmt.Println(resp.Response)
+}
+
+func runMessageProvenance(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	sequence, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid sequence number: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.GetMessageProvenance(ctx, &nodev1.GetMessageProvenanceRequest{
+		EmitterChain:   uint32(chainID),
+		EmitterAddress: args[1],
+		Sequence:       sequence,
+	})
+	if err != nil {
+		log.Fatalf("failed to run GetMessageProvenance RPC: %s", err)
+	}
+
+	fmt.Printf("rpcEndpoint: %s\n", resp.RpcEndpoint)
+	fmt.Printf("fetchLatency: %v\n", time.Duration(resp.FetchLatencyMs)*time.Millisecond)
+	fmt.Printf("block: %s\n", resp.Block)
+	fmt.Printf("watcherVersion: %s\n", resp.WatcherVersion)
+}
+
+func runPurgeAndResignVAA(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	sequence, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid sequence number: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.PurgeAndResignVAA(ctx, &nodev1.PurgeAndResignVAARequest{
+		EmitterChain:   uint32(chainID),
+		EmitterAddress: args[1],
+		Sequence:       sequence,
+		TxHash:         *purgeTxHash,
+	})
+	if err != nil {
+		log.Fatalf("failed to run PurgeAndResignVAA RPC: %s", err)
+	}
+
+	fmt.Printf("sent observation request for tx %s to sign the VAA of guardian set %d with guardian set %d, it is kept until then\n",
+		resp.TxHash, resp.PurgedGuardianSetIndex, resp.CurrentGuardianSetIndex)
+}
+
 func runChainGovernorStatus(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -388,6 +671,27 @@ func runChainGovernorReleasePendingVAA(cmd *cobra.Command, args []string) {
 	fmt.Println(resp.Response)
 }
 
+func runChainGovernorEmergencyRelease(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.ChainGovernorEmergencyReleaseRequest{
+		VaaId: args[0],
+	}
+	resp, err := c.ChainGovernorEmergencyRelease(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run ChainGovernorEmergencyRelease RPC: %s", err)
+	}
+
+	fmt.Println(resp.Response)
+}
+
 func runChainGovernorResetReleaseTimer(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -408,3 +712,247 @@ func runChainGovernorResetReleaseTimer(cmd *cobra.Command, args []string) {
 
 	fmt.Println(resp.Response)
 }
+
+func runChainGovernorListPendingVAAs(cmd *cobra.Command, args []string) {
+	var emitterChain vaa.ChainID
+	if len(args) == 1 {
+		var err error
+		emitterChain, err = parseChainID(args[0])
+		if err != nil {
+			log.Fatalf("invalid chain ID: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.ChainGovernorListPendingVAAsRequest{
+		EmitterChain: uint32(emitterChain),
+	}
+	resp, err := c.ChainGovernorListPendingVAAs(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run ChainGovernorListPendingVAAs RPC: %s", err)
+	}
+
+	for _, e := range resp.Entries {
+		fmt.Printf("chain: %v, position: %d, vaa: %s, txHash: %s, timeStamp: %v, releaseTime: %v, value: %d, big: %v\n",
+			vaa.ChainID(e.EmitterChain), e.Position, e.VaaId, e.TxHash,
+			time.Unix(int64(e.Timestamp), 0), time.Unix(int64(e.ReleaseTime), 0), e.NotionalValue, e.BigTransaction)
+		if e.Evaluator != "" {
+			fmt.Printf("   payloadType: %d, evaluator: %s, requiresApproval: %v\n", e.PayloadType, e.Evaluator, e.RequiresApproval)
+		} else {
+			fmt.Printf("   payloadType: %d, amount: %s, token: %s (%v/%s), target: %v/%s\n",
+				e.PayloadType, e.Amount, e.TokenSymbol, vaa.ChainID(e.OriginChain), e.OriginAddress, vaa.ChainID(e.TargetChain), e.TargetAddress)
+		}
+	}
+
+	log.Printf("%d pending VAAs", len(resp.Entries))
+}
+
+func runChainGovernorMovePendingVAA(cmd *cobra.Command, args []string) {
+	position, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		log.Fatalf("invalid position: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.ChainGovernorMovePendingVAARequest{
+		VaaId:    args[0],
+		Position: uint32(position),
+	}
+	resp, err := c.ChainGovernorMovePendingVAA(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run ChainGovernorMovePendingVAA RPC: %s", err)
+	}
+
+	fmt.Println(resp.Response)
+}
+
+func runAccountantReconcile(cmd *cobra.Command, args []string) {
+	var chainID vaa.ChainID
+	if len(args) == 1 {
+		var err error
+		chainID, err = parseChainID(args[0])
+		if err != nil {
+			log.Fatalf("invalid chain ID: %v", err)
+		}
+	}
+
+	// Reconciliation queries the custody balance of every token, so allow more time than the other commands.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.AccountantReconcileRequest{
+		ChainId: uint32(chainID),
+	}
+	resp, err := c.AccountantReconcile(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run AccountantReconcile RPC: %s", err)
+	}
+
+	for _, e := range resp.Entries {
+		if e.Error != "" {
+			fmt.Printf("chain: %v, token: %v/%s, ledger: %s, error: %s\n",
+				vaa.ChainID(e.ChainId), vaa.ChainID(e.TokenChain), e.TokenAddress, e.LedgerBalance, e.Error)
+			continue
+		}
+		fmt.Printf("chain: %v, token: %v/%s, ledger: %s, custody: %s, drift: %s\n",
+			vaa.ChainID(e.ChainId), vaa.ChainID(e.TokenChain), e.TokenAddress, e.LedgerBalance, e.CustodyBalance, e.Drift)
+	}
+}
+
+func runAccountantModifyBalanceDryRun(cmd *cobra.Command, args []string) {
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		log.Fatalf("failed to read file: %v", err)
+	}
+
+	var req nodev1.InjectGovernanceVAARequest
+	if err := prototext.Unmarshal(b, &req); err != nil {
+		log.Fatalf("failed to deserialize: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	for i, message := range req.Messages {
+		payload, ok := message.Payload.(*nodev1.GovernanceMessage_AccountantModifyBalance)
+		if !ok {
+			log.Fatalf("message %d is not an accountant modify balance message", i)
+		}
+
+		m := payload.AccountantModifyBalance
+		resp, err := c.AccountantModifyBalanceDryRun(ctx, &nodev1.AccountantModifyBalanceDryRunRequest{Modification: m})
+		if err != nil {
+			log.Fatalf("failed to run AccountantModifyBalanceDryRun RPC: %s", err)
+		}
+
+		fmt.Printf("sequence: %d, chain: %v, token: %v/%s, before: %s, after: %s, already applied: %v\n",
+			m.Sequence, vaa.ChainID(m.ChainId), vaa.ChainID(m.TokenChain), m.TokenAddress, resp.BalanceBefore, resp.BalanceAfter, resp.AlreadyApplied)
+	}
+}
+
+func runWatcherPause(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.WatcherPauseRequest{
+		ChainId: uint32(chainID),
+	}
+	resp, err := c.WatcherPause(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run WatcherPause RPC: %s", err)
+	}
+
+	fmt.Println(resp.Response)
+}
+
+func runWatcherResume(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.WatcherResumeRequest{
+		ChainId: uint32(chainID),
+	}
+	resp, err := c.WatcherResume(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run WatcherResume RPC: %s", err)
+	}
+
+	fmt.Println(resp.Response)
+}
+
+func runWatcherSetEndpoint(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain ID: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.WatcherSetEndpointRequest{
+		ChainId:  uint32(chainID),
+		Endpoint: args[1],
+	}
+	resp, err := c.WatcherSetEndpoint(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run WatcherSetEndpoint RPC: %s", err)
+	}
+
+	fmt.Println(resp.Response)
+}
+
+func runWatcherStatus(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.WatcherStatus(ctx, &nodev1.WatcherStatusRequest{})
+	if err != nil {
+		log.Fatalf("failed to run WatcherStatus RPC: %s", err)
+	}
+
+	for _, e := range resp.Entries {
+		fmt.Printf("chain: %v, paused: %v, endpointConfigurable: %v\n", vaa.ChainID(e.ChainId), e.Paused, e.EndpointConfigurable)
+	}
+}
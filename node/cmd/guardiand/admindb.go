@@ -0,0 +1,216 @@
+package guardiand
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	filterEmitterChain   *string
+	filterEmitterAddress *string
+	filterFirstSequence  *uint64
+	filterLastSequence   *uint64
+	filterSince          *string
+	filterUntil          *string
+	listSignedVAAsLimit  *uint32
+)
+
+func init() {
+	filterFlagSet := pflag.NewFlagSet("signedVAAFilter", pflag.ExitOnError)
+	filterEmitterChain = filterFlagSet.String("emitterChain", "", "Only include VAAs from this emitter chain (ID or name)")
+	filterEmitterAddress = filterFlagSet.String("emitterAddress", "", "Only include VAAs from this emitter address (hex), requires --emitterChain")
+	filterFirstSequence = filterFlagSet.Uint64("firstSequence", 0, "Only include VAAs with a sequence number of at least this value")
+	filterLastSequence = filterFlagSet.Uint64("lastSequence", 0, "Only include VAAs with a sequence number of at most this value (zero means no limit)")
+	filterSince = filterFlagSet.String("since", "", "Only include VAAs with a timestamp at or after this time (RFC 3339, or a duration like 1h meaning that long ago), listed in timestamp order")
+	filterUntil = filterFlagSet.String("until", "", "Only include VAAs with a timestamp before this time (RFC 3339, or a duration like 1h meaning that long ago), listed in timestamp order")
+
+	listSignedVAAsLimit = AdminClientListSignedVAAsCmd.Flags().Uint32("limit", 100, "Maximum number of VAAs to list")
+
+	AdminClientListSignedVAAsCmd.Flags().AddFlagSet(filterFlagSet)
+	AdminClientCountSignedVAAsCmd.Flags().AddFlagSet(filterFlagSet)
+	AdminClientExportSignedVAAsCmd.Flags().AddFlagSet(filterFlagSet)
+	AdminClientExportVAATableCmd.Flags().AddFlagSet(filterFlagSet)
+}
+
+var AdminClientListSignedVAAsCmd = &cobra.Command{
+	Use:   "list-signed-vaas",
+	Short: "Lists the signed VAAs in the local database, optionally filtered by emitter, sequence range and time",
+	Run:   runListSignedVAAs,
+	Args:  cobra.ExactArgs(0),
+}
+
+var AdminClientCountSignedVAAsCmd = &cobra.Command{
+	Use:   "count-signed-vaas",
+	Short: "Counts the signed VAAs in the local database, optionally filtered by emitter, sequence range and time",
+	Run:   runCountSignedVAAs,
+	Args:  cobra.ExactArgs(0),
+}
+
+var AdminClientExportSignedVAAsCmd = &cobra.Command{
+	Use:   "export-signed-vaas [FILENAME]",
+	Short: "Exports the signed VAAs in the local database, optionally filtered by emitter, sequence range and time, to an archive file",
+	Run:   runExportSignedVAAs,
+	Args:  cobra.ExactArgs(1),
+}
+
+// parseFilterTime parses a time given as RFC 3339 or as a duration before now, returning Unix seconds.
+func parseFilterTime(s string) (uint32, error) {
+	var t time.Time
+	if d, err := time.ParseDuration(s); err == nil {
+		t = time.Now().Add(-d)
+	} else if t, err = time.Parse(time.RFC3339, s); err != nil {
+		return 0, fmt.Errorf("%s is neither an RFC 3339 time nor a duration", s)
+	}
+
+	if t.Unix() < 0 || t.Unix() > math.MaxUint32 {
+		return 0, fmt.Errorf("%s is out of range", s)
+	}
+	return uint32(t.Unix()), nil
+}
+
+func signedVAAFilterFromFlags() *nodev1.SignedVAAFilter {
+	filter := &nodev1.SignedVAAFilter{
+		EmitterAddress: *filterEmitterAddress,
+		FirstSequence:  *filterFirstSequence,
+		LastSequence:   *filterLastSequence,
+	}
+
+	if *filterEmitterChain != "" {
+		chainID, err := parseChainID(*filterEmitterChain)
+		if err != nil {
+			log.Fatalf("invalid chain ID: %v", err)
+		}
+		filter.EmitterChain = uint32(chainID)
+	}
+
+	if *filterSince != "" {
+		since, err := parseFilterTime(*filterSince)
+		if err != nil {
+			log.Fatalf("invalid --since: %v", err)
+		}
+		filter.Since = since
+	}
+	if *filterUntil != "" {
+		until, err := parseFilterTime(*filterUntil)
+		if err != nil {
+			log.Fatalf("invalid --until: %v", err)
+		}
+		filter.Until = until
+	}
+
+	return filter
+}
+
+func runListSignedVAAs(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.ListSignedVAAsRequest{
+		Filter: signedVAAFilterFromFlags(),
+		Limit:  *listSignedVAAsLimit,
+	}
+	resp, err := c.ListSignedVAAs(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run ListSignedVAAs RPC: %s", err)
+	}
+
+	for _, e := range resp.Entries {
+		fmt.Printf("%s, guardianSet: %d, signatures: %d, timestamp: %v, digest: %s\n",
+			e.MessageId, e.GuardianSetIndex, e.NumSignatures, time.Unix(int64(e.Timestamp), 0), e.Digest)
+	}
+
+	if resp.Truncated {
+		log.Printf("listed the first %d VAAs, more VAAs match the filter", len(resp.Entries))
+	} else {
+		log.Printf("%d VAAs", len(resp.Entries))
+	}
+}
+
+func runCountSignedVAAs(cmd *cobra.Command, args []string) {
+	// Counting iterates over the whole range, so allow more time than the other commands.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.CountSignedVAAsRequest{
+		Filter: signedVAAFilterFromFlags(),
+	}
+	resp, err := c.CountSignedVAAs(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run CountSignedVAAs RPC: %s", err)
+	}
+
+	fmt.Println(resp.Count)
+}
+
+func runExportSignedVAAs(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("failed to create archive: %v", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	msg := nodev1.ExportSignedVAAsRequest{
+		Filter: signedVAAFilterFromFlags(),
+	}
+	stream, err := c.ExportSignedVAAs(ctx, &msg)
+	if err != nil {
+		log.Fatalf("failed to run ExportSignedVAAs RPC: %s", err)
+	}
+
+	count := 0
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive VAAs: %s", err)
+		}
+
+		for _, b := range resp.VaaBytes {
+			if err := db.WriteVAAArchiveEntry(w, b); err != nil {
+				log.Fatalf("failed to write archive: %v", err)
+			}
+			count++
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write archive: %v", err)
+	}
+
+	log.Printf("exported %d VAAs to %s", count, args[0])
+}
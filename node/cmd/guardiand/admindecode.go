@@ -0,0 +1,394 @@
+package guardiand
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/contractregistry"
+	"github.com/certusone/wormhole/node/pkg/networkconfig"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	decodeGuardianSet     *[]string
+	decodePayloadRegistry *string
+)
+
+func init() {
+	decodeGuardianSet = AdminClientDecodeVAACmd.Flags().StringSlice("guardianSet", nil,
+		"Comma-separated guardian addresses, in guardian set order, to verify the signatures against")
+	decodePayloadRegistry = AdminClientDecodeVAACmd.Flags().String("payloadRegistry", "",
+		"Path to a payload registry config, to decode the payloads of the integrators it lists")
+}
+
+var AdminClientDecodeVAACmd = &cobra.Command{
+	Use:   "decode-vaa [VAA_HEX|VAA_BASE64]",
+	Short: "Decode a VAA, verify its signatures against a guardian set and print its contents (offline)",
+	Run:   runDecodeVAA,
+	Args:  cobra.ExactArgs(1),
+}
+
+func runDecodeVAA(cmd *cobra.Command, args []string) {
+	b, err := decodeVAAInput(args[0])
+	if err != nil {
+		log.Fatalf("failed to decode input: %v", err)
+	}
+
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		log.Fatalf("failed to unmarshal VAA: %v", err)
+	}
+
+	var guardians []ethcommon.Address
+	for _, s := range *decodeGuardianSet {
+		if !ethcommon.IsHexAddress(s) {
+			log.Fatalf("invalid guardian address: %s", s)
+		}
+		guardians = append(guardians, ethcommon.HexToAddress(s))
+	}
+
+	var registry *vaa.PayloadRegistry
+	if *decodePayloadRegistry != "" {
+		registry, err = vaa.LoadPayloadRegistry(*decodePayloadRegistry)
+		if err != nil {
+			log.Fatalf("failed to load payload registry: %v", err)
+		}
+	}
+
+	fmt.Printf("Version:          %d\n", v.Version)
+	fmt.Printf("GuardianSetIndex: %d\n", v.GuardianSetIndex)
+	fmt.Printf("Timestamp:        %v (%d)\n", v.Timestamp.UTC(), v.Timestamp.Unix())
+	fmt.Printf("Nonce:            %d\n", v.Nonce)
+	fmt.Printf("Sequence:         %d\n", v.Sequence)
+	fmt.Printf("ConsistencyLevel: %d\n", v.ConsistencyLevel)
+	fmt.Printf("EmitterChain:     %v (%d)\n", v.EmitterChain, v.EmitterChain)
+	fmt.Printf("EmitterAddress:   %v\n", v.EmitterAddress)
+	fmt.Printf("MessageID:        %s\n", v.MessageID())
+	fmt.Printf("Digest:           %s\n", v.HexDigest())
+	fmt.Printf("Payload:          %s\n", hex.EncodeToString(v.Payload))
+
+	fmt.Printf("\nSignatures (%d):\n", len(v.Signatures))
+	for _, line := range describeSignatures(v, guardians) {
+		fmt.Printf("  %s\n", line)
+	}
+
+	if len(guardians) != 0 {
+		quorum := vaa.CalculateQuorum(len(guardians))
+		valid := v.VerifySignatures(guardians)
+		fmt.Printf("\nSignatures valid: %v, quorum: %d of %d, has quorum: %v\n",
+			valid, quorum, len(guardians), valid && vaa.HasQuorum(len(v.Signatures), len(guardians)))
+	} else {
+		fmt.Printf("\nSignatures not verified, use --guardianSet to specify the guardian set\n")
+	}
+
+	fmt.Printf("\nDecoded payload:\n")
+	for _, line := range describePayload(v, registry) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// decodeVAAInput accepts a VAA encoded in hex, with or without a 0x prefix, or in base64.
+func decodeVAAInput(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(strings.TrimPrefix(s, "0x")); err == nil {
+		return b, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("input is neither hex nor base64")
+	}
+
+	return b, nil
+}
+
+// describeSignatures recovers the signer of each signature and, if a guardian set is specified, checks it against the
+// guardian at the signature's index.
+func describeSignatures(v *vaa.VAA, guardians []ethcommon.Address) []string {
+	digest := v.SigningMsg()
+
+	lines := make([]string, 0, len(v.Signatures))
+	for _, sig := range v.Signatures {
+		signer, err := recoverSigner(digest.Bytes(), sig.Signature[:])
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("index: %d, error: failed to recover signer: %v", sig.Index, err))
+			continue
+		}
+
+		status := "unverified"
+		if len(guardians) != 0 {
+			if int(sig.Index) >= len(guardians) {
+				status = "INVALID (index out of range)"
+			} else if guardians[sig.Index] != signer {
+				status = fmt.Sprintf("INVALID (expected %s)", guardians[sig.Index].Hex())
+			} else {
+				status = "valid"
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf("index: %d, signer: %s, %s", sig.Index, signer.Hex(), status))
+	}
+
+	return lines
+}
+
+var tokenBridgeModule = append(bytes.Repeat([]byte{0}, 32-len("TokenBridge")), []byte("TokenBridge")...)
+
+// describePayload decodes governance messages, the payloads of the known token bridge emitters and the integrator
+// payloads known to the registry.
+func describePayload(v *vaa.VAA, registry *vaa.PayloadRegistry) []string {
+	if v.EmitterChain == vaa.GovernanceChain && v.EmitterAddress == vaa.GovernanceEmitter {
+		lines, err := describeGovernancePayload(v.Payload)
+		if err != nil {
+			return []string{fmt.Sprintf("failed to decode governance payload: %v", err)}
+		}
+		return lines
+	}
+
+	if isKnownTokenBridgeEmitter(v.EmitterChain, v.EmitterAddress) {
+		lines, err := describeTokenBridgePayload(v.Payload)
+		if err != nil {
+			return []string{fmt.Sprintf("failed to decode token bridge payload: %v", err)}
+		}
+		return lines
+	}
+
+	p, err := registry.Decode(v.EmitterChain, v.EmitterAddress, v.Payload)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if p != nil {
+		lines := []string{fmt.Sprintf("type: %s %s", p.Format, p.Type)}
+		for _, f := range p.Fields {
+			lines = append(lines, fmt.Sprintf("%s: %s", f.Name, f.Value))
+		}
+		return lines
+	}
+
+	return []string{"unknown emitter, payload not decoded"}
+}
+
+func isKnownTokenBridgeEmitter(chainID vaa.ChainID, addr vaa.Address) bool {
+	for _, emitters := range []map[vaa.ChainID][]byte{common.KnownTokenbridgeEmitters, common.KnownTestnetTokenbridgeEmitters, common.KnownDevnetTokenbridgeEmitters} {
+		if emitter, exists := emitters[chainID]; exists && bytes.Equal(emitter, addr.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeGovernancePayload(payload []byte) ([]string, error) {
+	reader := bytes.NewReader(payload)
+
+	module := make([]byte, 32)
+	if n, err := reader.Read(module); err != nil || n != 32 {
+		return nil, fmt.Errorf("failed to read module")
+	}
+
+	var action uint8
+	if err := binary.Read(reader, binary.BigEndian, &action); err != nil {
+		return nil, fmt.Errorf("failed to read action: %w", err)
+	}
+
+	var targetChain vaa.ChainID
+	if err := binary.Read(reader, binary.BigEndian, &targetChain); err != nil {
+		return nil, fmt.Errorf("failed to read target chain: %w", err)
+	}
+
+	switch {
+	case bytes.Equal(module, vaa.CoreModule) && action == 1:
+		var newContract vaa.Address
+		if err := binary.Read(reader, binary.BigEndian, &newContract); err != nil {
+			return nil, fmt.Errorf("failed to read new contract: %w", err)
+		}
+		return []string{
+			"type: core contract upgrade",
+			fmt.Sprintf("targetChain: %v", targetChain),
+			fmt.Sprintf("newContract: %v", newContract),
+		}, nil
+
+	case bytes.Equal(module, vaa.CoreModule) && action == 2:
+		var newIndex uint32
+		if err := binary.Read(reader, binary.BigEndian, &newIndex); err != nil {
+			return nil, fmt.Errorf("failed to read new guardian set index: %w", err)
+		}
+		var numKeys uint8
+		if err := binary.Read(reader, binary.BigEndian, &numKeys); err != nil {
+			return nil, fmt.Errorf("failed to read number of keys: %w", err)
+		}
+		lines := []string{
+			"type: guardian set update",
+			fmt.Sprintf("newIndex: %d", newIndex),
+		}
+		for i := 0; i < int(numKeys); i++ {
+			var key ethcommon.Address
+			if err := binary.Read(reader, binary.BigEndian, &key); err != nil {
+				return nil, fmt.Errorf("failed to read key %d: %w", i, err)
+			}
+			lines = append(lines, fmt.Sprintf("key %d: %s", i, key.Hex()))
+		}
+		return lines, nil
+
+	case bytes.Equal(module, vaa.CoreModule) && action == 3:
+		fee := make([]byte, 32)
+		if n, err := reader.Read(fee); err != nil || n != 32 {
+			return nil, fmt.Errorf("failed to read message fee")
+		}
+		return []string{
+			"type: set message fee",
+			fmt.Sprintf("targetChain: %v", targetChain),
+			fmt.Sprintf("messageFee: %v", new(big.Int).SetBytes(fee)),
+		}, nil
+
+	case bytes.Equal(module, vaa.CoreModule) && action == 4:
+		amount := make([]byte, 32)
+		if n, err := reader.Read(amount); err != nil || n != 32 {
+			return nil, fmt.Errorf("failed to read amount")
+		}
+		var recipient vaa.Address
+		if err := binary.Read(reader, binary.BigEndian, &recipient); err != nil {
+			return nil, fmt.Errorf("failed to read recipient: %w", err)
+		}
+		return []string{
+			"type: transfer fees",
+			fmt.Sprintf("targetChain: %v", targetChain),
+			fmt.Sprintf("amount: %v", new(big.Int).SetBytes(amount)),
+			fmt.Sprintf("recipient: %v", recipient),
+		}, nil
+
+	case bytes.Equal(module, vaa.ContractRegistryModule):
+		r, err := contractregistry.ParsePayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		lines := []string{
+			"type: contract registry",
+			fmt.Sprintf("version: %d", r.Version),
+		}
+		chains := make([]vaa.ChainID, 0, len(r.Chains))
+		for chainID := range r.Chains {
+			chains = append(chains, chainID)
+		}
+		sort.Slice(chains, func(i, j int) bool { return chains[i] < chains[j] })
+		for _, chainID := range chains {
+			c := r.Chains[chainID]
+			lines = append(lines, fmt.Sprintf("%v: core: %s, tokenBridge: %s, coreCodeHash: %s", chainID, c.Core.Hex(), c.TokenBridge.Hex(), c.CoreCodeHash.Hex()))
+		}
+		return lines, nil
+
+	case bytes.Equal(module, vaa.NetworkConfigModule):
+		c, err := networkconfig.ParsePayload(payload)
+		if err != nil {
+			return nil, err
+		}
+		lines := []string{
+			"type: network config",
+			fmt.Sprintf("version: %d", c.Version),
+		}
+		features := make([]string, 0, len(c.Features))
+		for name := range c.Features {
+			features = append(features, name)
+		}
+		sort.Strings(features)
+		for _, name := range features {
+			t := c.Features[name]
+			lines = append(lines, fmt.Sprintf("%s: active from %s (%d)", name, t.UTC().Format(time.RFC3339), t.Unix()))
+		}
+		return lines, nil
+
+	case bytes.Equal(module, vaa.AccountantModule) && action == 1:
+		m, err := vaa.DeserializeAccountantModifyBalance(payload)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			"type: accountant modify balance",
+			fmt.Sprintf("sequence: %d", m.Sequence),
+			fmt.Sprintf("chain: %v", m.ChainID),
+			fmt.Sprintf("tokenChain: %v", m.TokenChain),
+			fmt.Sprintf("tokenAddress: %v", m.TokenAddress),
+			fmt.Sprintf("kind: %v", m.Kind),
+			fmt.Sprintf("amount: %v", m.Amount),
+			fmt.Sprintf("reason: %s", m.Reason),
+		}, nil
+
+	case bytes.Equal(module, tokenBridgeModule) && action == 1:
+		var chainID vaa.ChainID
+		if err := binary.Read(reader, binary.BigEndian, &chainID); err != nil {
+			return nil, fmt.Errorf("failed to read chain: %w", err)
+		}
+		var emitterAddress vaa.Address
+		if err := binary.Read(reader, binary.BigEndian, &emitterAddress); err != nil {
+			return nil, fmt.Errorf("failed to read emitter address: %w", err)
+		}
+		return []string{
+			"type: token bridge register chain",
+			fmt.Sprintf("targetChain: %v", targetChain),
+			fmt.Sprintf("chain: %v", chainID),
+			fmt.Sprintf("emitterAddress: %v", emitterAddress),
+		}, nil
+
+	case bytes.Equal(module, tokenBridgeModule) && action == 2:
+		var newContract vaa.Address
+		if err := binary.Read(reader, binary.BigEndian, &newContract); err != nil {
+			return nil, fmt.Errorf("failed to read new contract: %w", err)
+		}
+		return []string{
+			"type: token bridge contract upgrade",
+			fmt.Sprintf("targetChain: %v", targetChain),
+			fmt.Sprintf("newContract: %v", newContract),
+		}, nil
+	}
+
+	return []string{
+		fmt.Sprintf("type: unknown governance action, module: %s, action: %d", hex.EncodeToString(module), action),
+		fmt.Sprintf("targetChain: %v", targetChain),
+	}, nil
+}
+
+func describeTokenBridgePayload(payload []byte) ([]string, error) {
+	if vaa.IsTransfer(payload) {
+		hdr, err := vaa.DecodeTransferPayloadHdr(payload)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("type: token bridge transfer (payload type %d)", hdr.Type),
+			fmt.Sprintf("amount: %v", hdr.Amount),
+			fmt.Sprintf("originChain: %v", hdr.OriginChain),
+			fmt.Sprintf("originAddress: %v", hdr.OriginAddress),
+			fmt.Sprintf("targetChain: %v", hdr.TargetChain),
+			fmt.Sprintf("targetAddress: %v", hdr.TargetAddress),
+		}, nil
+	}
+
+	// Attestation: type [1], token address [32], token chain [2], decimals [1], symbol [32], name [32]
+	if len(payload) > 0 && payload[0] == 2 {
+		if len(payload) < 100 {
+			return nil, fmt.Errorf("attestation too short")
+		}
+		var tokenAddress vaa.Address
+		copy(tokenAddress[:], payload[1:33])
+		return []string{
+			"type: token bridge asset meta",
+			fmt.Sprintf("tokenChain: %v", vaa.ChainID(binary.BigEndian.Uint16(payload[33:35]))),
+			fmt.Sprintf("tokenAddress: %v", tokenAddress),
+			fmt.Sprintf("decimals: %d", payload[35]),
+			fmt.Sprintf("symbol: %s", strings.TrimRight(string(payload[36:68]), "\x00")),
+			fmt.Sprintf("name: %s", strings.TrimRight(string(payload[68:100]), "\x00")),
+		}, nil
+	}
+
+	return []string{"type: unknown token bridge payload"}, nil
+}
@@ -0,0 +1,152 @@
+package guardiand
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeVAAInput(t *testing.T) {
+	expected := []byte{0x01, 0x02, 0xfe, 0xff}
+
+	b, err := decodeVAAInput("0102feff")
+	require.NoError(t, err)
+	assert.Equal(t, expected, b)
+
+	b, err = decodeVAAInput("0x0102feff\n")
+	require.NoError(t, err)
+	assert.Equal(t, expected, b)
+
+	b, err = decodeVAAInput(base64.StdEncoding.EncodeToString(expected))
+	require.NoError(t, err)
+	assert.Equal(t, expected, b)
+
+	_, err = decodeVAAInput("not a vaa!")
+	assert.Error(t, err)
+}
+
+func TestDescribeSignatures(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	guardians := make([]ethcommon.Address, 3)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys[i] = key
+		guardians[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	v := vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyTokenBridgeRegisterChain{
+		Module:         "TokenBridge",
+		ChainID:        vaa.ChainIDAptos,
+		EmitterAddress: vaa.Address{1},
+	}.Serialize())
+	v.AddSignature(keys[0], 0)
+	v.AddSignature(keys[2], 1)
+
+	lines := describeSignatures(v, guardians)
+	require.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasSuffix(lines[0], "valid"))
+	assert.Contains(t, lines[1], "INVALID")
+
+	lines = describeSignatures(v, nil)
+	require.Equal(t, 2, len(lines))
+	assert.True(t, strings.HasSuffix(lines[0], "unverified"))
+}
+
+func TestDescribePayload(t *testing.T) {
+	v := vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyTokenBridgeRegisterChain{
+		Module:         "TokenBridge",
+		ChainID:        vaa.ChainIDAptos,
+		EmitterAddress: vaa.Address{1},
+	}.Serialize())
+
+	lines := describePayload(v, nil)
+	assert.Equal(t, "type: token bridge register chain", lines[0])
+	assert.Equal(t, "chain: aptos", lines[2])
+
+	v = vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyGuardianSetUpdate{
+		Keys:     []ethcommon.Address{{1}, {2}},
+		NewIndex: 1,
+	}.Serialize())
+
+	lines = describePayload(v, nil)
+	assert.Equal(t, "type: guardian set update", lines[0])
+	assert.Equal(t, 4, len(lines))
+
+	v = vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyTransferFees{
+		ChainID:   vaa.ChainIDEthereum,
+		Amount:    big.NewInt(1000),
+		Recipient: vaa.Address{1},
+	}.Serialize())
+
+	lines = describePayload(v, nil)
+	assert.Equal(t, []string{"type: transfer fees", "targetChain: ethereum", "amount: 1000", "recipient: " + vaa.Address{1}.String()}, lines)
+
+	v = vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyContractRegistry{
+		Version: 2,
+		Entries: []vaa.ContractRegistryEntry{{ChainID: vaa.ChainIDBSC, Core: vaa.Address{31: 1}}},
+	}.Serialize())
+
+	lines = describePayload(v, nil)
+	assert.Equal(t, []string{"type: contract registry", "version: 2",
+		"bsc: core: 0x0000000000000000000000000000000000000001, tokenBridge: 0x0000000000000000000000000000000000000000, " +
+			"coreCodeHash: 0x0000000000000000000000000000000000000000000000000000000000000000"}, lines)
+
+	v = vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyNetworkConfig{
+		Version:  3,
+		Features: []vaa.NetworkConfigFeature{{Name: "relay", ActivationTime: 1700000100}, {Name: "batch", ActivationTime: 1700000000}},
+	}.Serialize())
+
+	lines = describePayload(v, nil)
+	assert.Equal(t, []string{"type: network config", "version: 3",
+		"batch: active from 2023-11-14T22:13:20Z (1700000000)", "relay: active from 2023-11-14T22:15:00Z (1700000100)"}, lines)
+
+	v = vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodyAccountantModifyBalance{
+		Sequence: 3, ChainID: vaa.ChainIDSolana, TokenChain: vaa.ChainIDEthereum, TokenAddress: vaa.Address{31: 1},
+		Kind: vaa.ModificationKindAdd, Amount: big.NewInt(100), Reason: "missed transfer",
+	}.Serialize())
+
+	lines = describePayload(v, nil)
+	assert.Equal(t, []string{"type: accountant modify balance", "sequence: 3", "chain: solana", "tokenChain: ethereum",
+		"tokenAddress: " + vaa.Address{31: 1}.String(), "kind: add", "amount: 100", "reason: missed transfer"}, lines)
+
+	// A token bridge transfer of one token from Ethereum to Solana.
+	tokenBridge, err := vaa.StringToAddress("0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585")
+	require.NoError(t, err)
+	payload, err := hex.DecodeString("01" +
+		"0000000000000000000000000000000000000000000000000000000005f5e100" +
+		"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" + "0002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" + "0001" +
+		"0000000000000000000000000000000000000000000000000000000000000000")
+	require.NoError(t, err)
+
+	v = &vaa.VAA{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: tokenBridge, Payload: payload}
+	lines = describePayload(v, nil)
+	assert.Equal(t, "type: token bridge transfer (payload type 1)", lines[0])
+	assert.Equal(t, "amount: 100000000", lines[1])
+	assert.Equal(t, "targetChain: solana", lines[4])
+
+	v.EmitterAddress = vaa.Address{1}
+	lines = describePayload(v, nil)
+	assert.Equal(t, []string{"unknown emitter, payload not decoded"}, lines)
+
+	registry, err := vaa.ParsePayloadRegistry([]byte(`{"decoders": [{"format": "relayer", "emitters": [{"chain": 2, "address": "0100000000000000000000000000000000000000000000000000000000000000"}]}]}`))
+	require.NoError(t, err)
+	v.Payload = []byte{2}
+	lines = describePayload(v, registry)
+	assert.Equal(t, []string{"type: relayer unknown(2)"}, lines)
+
+	v.Payload = []byte{1, 0}
+	lines = describePayload(v, registry)
+	assert.Equal(t, []string{"failed to decode relayer payload: payload too short"}, lines)
+}
@@ -0,0 +1,40 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+)
+
+var AdminClientDrainShutdownCmd = &cobra.Command{
+	Use:   "drain-shutdown",
+	Short: "Shuts the node down gracefully, after its observations in flight reached quorum",
+	Run:   runDrainShutdown,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runDrainShutdown(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.DrainShutdown(ctx, &nodev1.DrainShutdownRequest{})
+	if err != nil {
+		log.Fatalf("failed to run DrainShutdown RPC: %s", err)
+	}
+
+	if resp.AlreadyDraining {
+		fmt.Println("The node is already draining.")
+		return
+	}
+	fmt.Println("The node is draining and will shut down once its observations in flight reached quorum.")
+}
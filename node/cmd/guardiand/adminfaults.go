@@ -0,0 +1,50 @@
+package guardiand
+
+import (
+	"context"
+	"log"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	faultGossipDropPercent   *uint32
+	faultRPCDelay            *time.Duration
+	faultMalformedRPCPercent *uint32
+)
+
+func init() {
+	faultGossipDropPercent = AdminClientFaultInjectCmd.Flags().Uint32("gossipDropPercent", 0, "Percentage of received gossip messages to drop")
+	faultRPCDelay = AdminClientFaultInjectCmd.Flags().Duration("rpcDelay", 0, "Delay added to every chain RPC request")
+	faultMalformedRPCPercent = AdminClientFaultInjectCmd.Flags().Uint32("malformedRpcPercent", 0, "Percentage of chain RPC responses to replace with malformed JSON")
+}
+
+var AdminClientFaultInjectCmd = &cobra.Command{
+	Use:   "fault-inject",
+	Short: "Injects faults into gossip and chain RPC clients of a devnet node built with the faultinject build tag (no flags to stop)",
+	Run:   runFaultInject,
+	Args:  cobra.NoArgs,
+}
+
+func runFaultInject(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = c.SetFaultInjection(ctx, &nodev1.SetFaultInjectionRequest{
+		GossipDropPercent:   *faultGossipDropPercent,
+		RpcDelayMs:          uint32(faultRPCDelay.Milliseconds()),
+		MalformedRpcPercent: *faultMalformedRPCPercent,
+	})
+	if err != nil {
+		log.Fatalf("failed to run SetFaultInjection RPC: %s", err)
+	}
+	log.Printf("fault injection configured")
+}
@@ -0,0 +1,58 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+)
+
+var AdminClientFeatureFlagsCmd = &cobra.Command{
+	Use:   "feature-flags",
+	Short: "Prints the feature flags enabled on this node, and how many guardians advertise each of them",
+	Run:   runFeatureFlags,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runFeatureFlags(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.GetFeatureFlags(ctx, &nodev1.GetFeatureFlagsRequest{})
+	if err != nil {
+		log.Fatalf("failed to run GetFeatureFlags RPC: %s", err)
+	}
+
+	if len(resp.Flags) == 0 {
+		fmt.Println("No feature flags are defined or advertised")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "flag\tchain\tenabled\tguardians\tdescription\t")
+	for _, f := range resp.Flags {
+		chain := "-"
+		if f.ChainId != 0 {
+			chain = vaa.ChainID(f.ChainId).String()
+		}
+		description := f.Description
+		if description == "" {
+			description = "(not defined by this node)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%d/%d\t%s\t\n", f.Name, chain, f.Enabled, len(f.Guardians), resp.GuardianSetSize, description)
+	}
+	w.Flush()
+	fmt.Printf("\nHeartbeats received from %d of %d guardians\n", resp.GuardiansSeen, resp.GuardianSetSize)
+}
@@ -0,0 +1,176 @@
+package guardiand
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// Governance signing ceremony: the unsigned governance VAAs are exported from a prototxt file, signed with the guardian
+// key on an air-gapped machine, and the detached signatures are injected into the running node, which broadcasts them.
+// Neither the prototxt file nor the commands that handle the guardian key need access to the node.
+
+var signGuardianKeyPath *string
+
+func init() {
+	signGuardianKeyPath = AdminClientSignGovernanceVAACmd.Flags().String("guardianKey", "", "Path to guardian key, or awskms://<key>, gcpkms://<key version> or a pkcs11: URI for a key in a cloud KMS or HSM (required)")
+}
+
+var AdminClientExportGovernanceVAACmd = &cobra.Command{
+	Use:   "governance-vaa-export [FILENAME]",
+	Short: "Print the unsigned governance VAAs and their digests for a prototxt file, to be signed offline (offline)",
+	Run:   runExportGovernanceVAA,
+	Args:  cobra.ExactArgs(1),
+}
+
+var AdminClientSignGovernanceVAACmd = &cobra.Command{
+	Use:   "governance-vaa-sign [UNSIGNED_VAA_HEX]",
+	Short: "Sign an unsigned governance VAA with the guardian key and print the detached signature (offline)",
+	Run:   runSignGovernanceVAA,
+	Args:  cobra.ExactArgs(1),
+}
+
+var AdminClientInjectSignedGovernanceVAACmd = &cobra.Command{
+	Use:   "governance-vaa-inject-signed [UNSIGNED_VAA_HEX] [SIGNATURE_HEX]",
+	Short: "Inject an unsigned governance VAA along with its detached signature made with this node's guardian key",
+	Run:   runInjectSignedGovernanceVAA,
+	Args:  cobra.ExactArgs(2),
+}
+
+func runExportGovernanceVAA(cmd *cobra.Command, args []string) {
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		log.Fatalf("failed to read file: %v", err)
+	}
+
+	var msg nodev1.InjectGovernanceVAARequest
+	err = prototext.Unmarshal(b, &msg)
+	if err != nil {
+		log.Fatalf("failed to deserialize: %v", err)
+	}
+
+	timestamp := time.Unix(int64(msg.Timestamp), 0)
+	for _, message := range msg.Messages {
+		v, err := governanceMessageToVAA(message, timestamp, msg.CurrentSetIndex)
+		if err != nil {
+			log.Fatalf("invalid governance message: %v", err)
+		}
+
+		b, err := v.Marshal()
+		if err != nil {
+			log.Fatalf("failed to marshal VAA: %v", err)
+		}
+
+		preview, err := vaa.PreviewGovernanceVAA(v)
+		if err != nil {
+			log.Fatalf("failed to preview governance VAA: %v", err)
+		}
+
+		fmt.Print(preview.String())
+		fmt.Printf("vaa: %s\n\n", hex.EncodeToString(b))
+	}
+}
+
+// decodeUnsignedGovernanceVAA decodes a governance VAA that has not been signed yet.
+func decodeUnsignedGovernanceVAA(s string) (*vaa.VAA, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal VAA: %w", err)
+	}
+
+	if v.EmitterChain != vaa.GovernanceChain || v.EmitterAddress != vaa.GovernanceEmitter {
+		return nil, errors.New("not a governance VAA")
+	}
+
+	if len(v.Signatures) != 0 {
+		return nil, errors.New("the VAA is already signed")
+	}
+
+	return v, nil
+}
+
+// signGovernanceVAA returns the signature of the VAA's digest by the guardian key.
+func signGovernanceVAA(ctx context.Context, v *vaa.VAA, gk guardiansigner.GuardianSigner) ([]byte, error) {
+	return gk.Sign(ctx, v.SigningMsg().Bytes())
+}
+
+func runSignGovernanceVAA(cmd *cobra.Command, args []string) {
+	if *signGuardianKeyPath == "" {
+		log.Fatalf("--guardianKey is required")
+	}
+
+	v, err := decodeUnsignedGovernanceVAA(args[0])
+	if err != nil {
+		log.Fatalf("invalid VAA: %v", err)
+	}
+
+	// Show what is being signed, so it can be checked against the proposal before signing. VAAs with a malformed payload
+	// are not signed.
+	preview, err := vaa.PreviewGovernanceVAA(v)
+	if err != nil {
+		log.Fatalf("failed to preview governance VAA: %v", err)
+	}
+	fmt.Print(preview.String())
+
+	gk, err := newGuardianSigner(context.Background(), *signGuardianKeyPath)
+	if err != nil {
+		log.Fatalf("failed to load guardian key: %v", err)
+	}
+
+	sig, err := signGovernanceVAA(context.Background(), v, gk)
+	if err != nil {
+		log.Fatalf("failed to sign: %v", err)
+	}
+
+	fmt.Printf("\nsigner: %s\n", guardiansigner.Address(gk).Hex())
+	fmt.Printf("signature: %s\n", hex.EncodeToString(sig))
+}
+
+func runInjectSignedGovernanceVAA(cmd *cobra.Command, args []string) {
+	v, err := decodeUnsignedGovernanceVAA(args[0])
+	if err != nil {
+		log.Fatalf("invalid VAA: %v", err)
+	}
+
+	b, err := v.Marshal()
+	if err != nil {
+		log.Fatalf("failed to marshal VAA: %v", err)
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(args[1]), "0x"))
+	if err != nil {
+		log.Fatalf("invalid signature: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.InjectSignedGovernanceVAA(ctx, &nodev1.InjectSignedGovernanceVAARequest{Vaa: b, Signature: sig})
+	if err != nil {
+		log.Fatalf("failed to run InjectSignedGovernanceVAA RPC: %s", err)
+	}
+
+	log.Printf("VAA successfully injected with digest %s", hex.EncodeToString(resp.Digest))
+}
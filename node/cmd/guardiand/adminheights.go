@@ -0,0 +1,306 @@
+package guardiand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// The height of each watcher can be compared against public reference endpoints, configured in a JSON file specified
+// with the --referenceRPCConfig guardiand command line argument. Each entry lists the endpoints of a chain and the
+// number of blocks the watcher may lag behind them:
+//
+//	{
+//	  "ethereum": { "urls": ["https://rpc.ankr.com/eth"], "maxLag": 20 },
+//	  "solana": { "urls": ["https://api.mainnet-beta.solana.com"], "maxLag": 150 }
+//	}
+//
+// EVM chains, Solana, PythNet, Near, Aptos and Algorand are supported. The endpoints are queried with the same API as
+// the watcher of the chain. For Aptos, the URL is that of the ledger information (ending in /v1).
+
+type referenceRPCConfig struct {
+	URLs   []string `json:"urls"`
+	MaxLag int64    `json:"maxLag"`
+}
+
+type referenceHeightFetcher func(ctx context.Context, c *http.Client, url string) (int64, error)
+
+type referenceRPC struct {
+	urls   []string
+	maxLag int64
+	fetch  referenceHeightFetcher
+}
+
+// loadReferenceRPCs reads the reference RPC configuration file.
+func loadReferenceRPCs(path string) (map[vaa.ChainID]*referenceRPC, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference RPC config: %w", err)
+	}
+
+	var configs map[string]referenceRPCConfig
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse reference RPC config: %w", err)
+	}
+
+	refs := make(map[vaa.ChainID]*referenceRPC)
+	for name, cfg := range configs {
+		chainID, err := vaa.ChainIDFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("reference RPC config: invalid chain %s: %w", name, err)
+		}
+
+		fetch := referenceHeightFetcherForChain(chainID)
+		if fetch == nil {
+			return nil, fmt.Errorf("reference RPC config: %v is not supported", chainID)
+		}
+
+		if len(cfg.URLs) == 0 {
+			return nil, fmt.Errorf("reference RPC config: no urls for %v", chainID)
+		}
+
+		if cfg.MaxLag < 0 {
+			return nil, fmt.Errorf("reference RPC config: negative maxLag for %v", chainID)
+		}
+
+		refs[chainID] = &referenceRPC{urls: cfg.URLs, maxLag: cfg.MaxLag, fetch: fetch}
+	}
+
+	return refs, nil
+}
+
+func referenceHeightFetcherForChain(chainID vaa.ChainID) referenceHeightFetcher {
+	switch {
+	case evmChains[chainID]:
+		return fetchEVMHeight
+	case chainID == vaa.ChainIDSolana || chainID == vaa.ChainIDPythNet:
+		return fetchSolanaHeight
+	case chainID == vaa.ChainIDNear:
+		return fetchNearHeight
+	case chainID == vaa.ChainIDAptos:
+		return fetchAptosHeight
+	case chainID == vaa.ChainIDAlgorand:
+		return fetchAlgorandHeight
+	default:
+		return nil
+	}
+}
+
+// referenceRequest sends a request with an optional JSON body and returns the parsed JSON response.
+func referenceRequest(ctx context.Context, c *http.Client, method string, url string, body string) (gjson.Result, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBufferString(body))
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return gjson.Result{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if !gjson.ValidBytes(b) {
+		return gjson.Result{}, errors.New("invalid JSON in response")
+	}
+
+	return gjson.ParseBytes(b), nil
+}
+
+// jsonRPCResult sends a JSON-RPC request and returns its result.
+func jsonRPCResult(ctx context.Context, c *http.Client, url string, method string, params string) (gjson.Result, error) {
+	resp, err := referenceRequest(ctx, c, http.MethodPost, url, fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "%s", "params": %s}`, method, params))
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	if e := resp.Get("error"); e.Exists() {
+		return gjson.Result{}, fmt.Errorf("%s failed: %s", method, e.Raw)
+	}
+
+	result := resp.Get("result")
+	if !result.Exists() {
+		return gjson.Result{}, fmt.Errorf("%s returned no result", method)
+	}
+
+	return result, nil
+}
+
+func fetchEVMHeight(ctx context.Context, c *http.Client, url string) (int64, error) {
+	result, err := jsonRPCResult(ctx, c, url, "eth_blockNumber", "[]")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimPrefix(result.String(), "0x"), 16, 64)
+}
+
+func fetchSolanaHeight(ctx context.Context, c *http.Client, url string) (int64, error) {
+	result, err := jsonRPCResult(ctx, c, url, "getSlot", `[{"commitment": "confirmed"}]`)
+	if err != nil {
+		return 0, err
+	}
+	return result.Int(), nil
+}
+
+func fetchNearHeight(ctx context.Context, c *http.Client, url string) (int64, error) {
+	result, err := jsonRPCResult(ctx, c, url, "block", `{"finality": "final"}`)
+	if err != nil {
+		return 0, err
+	}
+	return result.Get("header.height").Int(), nil
+}
+
+func fetchAptosHeight(ctx context.Context, c *http.Client, url string) (int64, error) {
+	resp, err := referenceRequest(ctx, c, http.MethodGet, url, "")
+	if err != nil {
+		return 0, err
+	}
+	return resp.Get("block_height").Int(), nil
+}
+
+func fetchAlgorandHeight(ctx context.Context, c *http.Client, url string) (int64, error) {
+	resp, err := referenceRequest(ctx, c, http.MethodGet, strings.TrimSuffix(url, "/")+"/v2/status", "")
+	if err != nil {
+		return 0, err
+	}
+	return resp.Get("last-round").Int(), nil
+}
+
+// redactEndpoint only keeps the scheme and host of a URL, since the path or query may contain an API key.
+func redactEndpoint(rawURL string) string {
+	if endpoint := common.RedactRPCEndpoint(rawURL); endpoint != "" {
+		return endpoint
+	}
+	return "(invalid url)"
+}
+
+// compareChainHeight compares the height of a watcher against the highest height of its reference endpoints.
+func compareChainHeight(ctx context.Context, c *http.Client, chainID vaa.ChainID, ref *referenceRPC, height int64) *nodev1.CompareChainHeightsResponse_Entry {
+	entry := &nodev1.CompareChainHeightsResponse_Entry{
+		ChainId: uint32(chainID),
+		Height:  height,
+		MaxLag:  ref.maxLag,
+	}
+
+	for _, u := range ref.urls {
+		r := &nodev1.CompareChainHeightsResponse_Reference{Endpoint: redactEndpoint(u)}
+		h, err := ref.fetch(ctx, c, u)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Height = h
+			if h > entry.ReferenceHeight {
+				entry.ReferenceHeight = h
+			}
+		}
+		entry.References = append(entry.References, r)
+	}
+
+	if entry.ReferenceHeight > height {
+		entry.Lag = entry.ReferenceHeight - height
+	}
+	entry.Lagging = height == 0 || entry.Lag > entry.MaxLag
+
+	return entry
+}
+
+var compareHeightsChain *string
+var compareHeightsJSON *bool
+
+func init() {
+	compareHeightsChain = AdminClientCompareHeightsCmd.Flags().String("chain", "", "Only compare this chain (ID or name)")
+	compareHeightsJSON = AdminClientCompareHeightsCmd.Flags().Bool("json", false, "Print the comparison as JSON")
+}
+
+var AdminClientCompareHeightsCmd = &cobra.Command{
+	Use:   "compare-heights",
+	Short: "Compares the height of each watcher against the configured reference RPC endpoints, exits with status 2 if any chain lags",
+	Run:   runCompareHeights,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runCompareHeights(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var chainID vaa.ChainID
+	if *compareHeightsChain != "" {
+		var err error
+		chainID, err = parseChainID(*compareHeightsChain)
+		if err != nil {
+			log.Fatalf("invalid chain ID: %v", err)
+		}
+	}
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.CompareChainHeights(ctx, &nodev1.CompareChainHeightsRequest{ChainId: uint32(chainID)})
+	if err != nil {
+		log.Fatalf("failed to run CompareChainHeights RPC: %s", err)
+	}
+
+	lagging := false
+	for _, e := range resp.Entries {
+		lagging = lagging || e.Lagging
+	}
+
+	if *compareHeightsJSON {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			log.Fatalf("failed to marshal comparison: %v", err)
+		}
+		fmt.Println(string(b))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "chain\theight\treference height\tlag\tmax lag\tstatus\t")
+		for _, e := range resp.Entries {
+			status := "ok"
+			if e.Lagging {
+				status = "LAGGING"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t\n", vaa.ChainID(e.ChainId), e.Height, e.ReferenceHeight, e.Lag, e.MaxLag, status)
+			for _, r := range e.References {
+				if r.Error != "" {
+					fmt.Fprintf(w, "  %s\terror: %s\t\t\t\t\t\n", r.Endpoint, r.Error)
+				}
+			}
+		}
+		w.Flush()
+	}
+
+	if lagging {
+		os.Exit(2)
+	}
+}
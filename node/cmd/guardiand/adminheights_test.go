@@ -0,0 +1,84 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Serves a fixed response to the JSON-RPC method.
+func newReferenceServer(t *testing.T, method string, response string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if !strings.Contains(string(b), fmt.Sprintf(`"method": "%s"`, method)) {
+			http.Error(w, "unexpected method", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLoadReferenceRPCs(t *testing.T) {
+	refs, err := loadReferenceRPCs(writeTestFile(t, "refs.json", []byte(`{
+		"ethereum": {"urls": ["https://eth.example.com"], "maxLag": 20},
+		"solana": {"urls": ["https://sol.example.com"], "maxLag": 150}
+	}`)))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(refs))
+	assert.Equal(t, int64(20), refs[vaa.ChainIDEthereum].maxLag)
+	assert.NotNil(t, refs[vaa.ChainIDSolana].fetch)
+
+	for _, invalid := range []string{
+		`{"notachain": {"urls": ["https://example.com"]}}`,
+		`{"terra": {"urls": ["https://example.com"]}}`,
+		`{"ethereum": {"urls": []}}`,
+		`{"ethereum": {"urls": ["https://example.com"], "maxLag": -1}}`,
+	} {
+		_, err := loadReferenceRPCs(writeTestFile(t, "refs.json", []byte(invalid)))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestCompareChainHeight(t *testing.T) {
+	ahead := newReferenceServer(t, "eth_blockNumber", `{"jsonrpc": "2.0", "id": 1, "result": "0x6e"}`)
+	behind := newReferenceServer(t, "eth_blockNumber", `{"jsonrpc": "2.0", "id": 1, "result": "0x64"}`)
+	failing := newReferenceServer(t, "eth_blockNumber", `{"jsonrpc": "2.0", "id": 1, "error": {"code": -32000, "message": "unavailable"}}`)
+
+	ref := &referenceRPC{urls: []string{behind.URL + "/secret-key", ahead.URL, failing.URL}, maxLag: 5, fetch: fetchEVMHeight}
+
+	// The highest reference height (110) is used.
+	entry := compareChainHeight(context.Background(), http.DefaultClient, vaa.ChainIDEthereum, ref, 100)
+	assert.Equal(t, int64(110), entry.ReferenceHeight)
+	assert.Equal(t, int64(10), entry.Lag)
+	assert.True(t, entry.Lagging)
+	require.Equal(t, 3, len(entry.References))
+	assert.Equal(t, behind.URL, entry.References[0].Endpoint)
+	assert.Equal(t, int64(100), entry.References[0].Height)
+	assert.Contains(t, entry.References[2].Error, "unavailable")
+
+	entry = compareChainHeight(context.Background(), http.DefaultClient, vaa.ChainIDEthereum, ref, 107)
+	assert.Equal(t, int64(3), entry.Lag)
+	assert.False(t, entry.Lagging)
+
+	// A watcher that has not reported a height is lagging.
+	entry = compareChainHeight(context.Background(), http.DefaultClient, vaa.ChainIDEthereum, ref, 0)
+	assert.True(t, entry.Lagging)
+}
+
+func TestFetchSolanaHeight(t *testing.T) {
+	srv := newReferenceServer(t, "getSlot", `{"jsonrpc": "2.0", "id": 1, "result": 172000000}`)
+	height, err := fetchSolanaHeight(context.Background(), http.DefaultClient, srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(172000000), height)
+}
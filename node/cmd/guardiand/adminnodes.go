@@ -113,6 +113,7 @@ func runListNodes(cmd *cobra.Command, args []string) {
 		{"Near", vaa.ChainIDNear},
 		{"Terra2", vaa.ChainIDTerra2},
 		{"Pythnet", vaa.ChainIDPythNet},
+		{"Wormchain", vaa.ChainIDWormchain},
 	}
 
 	if isTestnet {
@@ -154,9 +155,11 @@ func runListNodes(cmd *cobra.Command, args []string) {
 		heights := map[vaa.ChainID]int64{}
 		truncAddrs := make(map[vaa.ChainID]string)
 		errors := map[vaa.ChainID]uint64{}
+		paused := map[vaa.ChainID]bool{}
 		for _, n := range h.RawHeartbeat.Networks {
 			heights[vaa.ChainID(n.Id)] = n.Height
 			errors[vaa.ChainID(n.Id)] = n.ErrorCount
+			paused[vaa.ChainID(n.Id)] = n.Paused
 			if len(n.ContractAddress) >= 16 {
 				truncAddrs[vaa.ChainID(n.Id)] = n.ContractAddress[:16]
 			} else {
@@ -177,12 +180,16 @@ func runListNodes(cmd *cobra.Command, args []string) {
 		}
 
 		for _, n := range networks {
+			var field string
 			if showDetails {
-				fields = append(fields, fmt.Sprintf("%s %d (%d)",
-					truncAddrs[n.ChainID], heights[n.ChainID], errors[n.ChainID]))
+				field = fmt.Sprintf("%s %d (%d)", truncAddrs[n.ChainID], heights[n.ChainID], errors[n.ChainID])
 			} else {
-				fields = append(fields, fmt.Sprintf("%d", heights[n.ChainID]))
+				field = fmt.Sprintf("%d", heights[n.ChainID])
 			}
+			if paused[n.ChainID] {
+				field += " (paused)"
+			}
+			fields = append(fields, field)
 		}
 
 		for _, field := range fields {
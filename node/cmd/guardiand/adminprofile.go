@@ -0,0 +1,83 @@
+package guardiand
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileSeconds *uint32
+	profileOutput  *string
+)
+
+func init() {
+	profileSeconds = AdminClientProfileCmd.Flags().Uint32("seconds", 30, "Duration of the cpu, block and mutex profiles, at most 300 seconds")
+	profileOutput = AdminClientProfileCmd.Flags().StringP("output", "o", "", "File to write the profile to (default PROFILE.pb.gz)")
+}
+
+var AdminClientProfileCmd = &cobra.Command{
+	Use:   "profile [cpu|heap|allocs|goroutine|block|mutex]",
+	Short: "Records a runtime profile of the node in the pprof format, to be analyzed with go tool pprof",
+	Run:   runProfile,
+	Args:  cobra.ExactArgs(1),
+}
+
+func runProfile(cmd *cobra.Command, args []string) {
+	// Leave time for the node to record the profile before sending it.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*profileSeconds)*time.Second+time.Minute)
+	defer cancel()
+
+	filename := *profileOutput
+	if filename == "" {
+		filename = args[0] + ".pb.gz"
+	}
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := c.Profile(ctx, &nodev1.ProfileRequest{Profile: args[0], Seconds: *profileSeconds})
+	if err != nil {
+		log.Fatalf("failed to run Profile RPC: %s", err)
+	}
+
+	// The first message is only received once the profile is recorded, which reports errors before creating the file.
+	resp, err := stream.Recv()
+	if err != nil {
+		log.Fatalf("failed to receive profile: %s", err)
+	}
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("failed to create profile file: %v", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	for {
+		if _, err := w.Write(resp.Data); err != nil {
+			log.Fatalf("failed to write profile: %v", err)
+		}
+		resp, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive profile: %s", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write profile: %v", err)
+	}
+	log.Printf("wrote %s profile to %s, analyze it with: go tool pprof %s", args[0], filename, filename)
+}
@@ -0,0 +1,98 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultRescanBlocksPerRequest  = 1000
+	defaultRescanRequestsPerSecond = 2
+)
+
+// evmRescanTarget is the RPC endpoint and the core bridge contract of an EVM chain, as configured on the command line.
+type evmRescanTarget struct {
+	rpcURL   string
+	contract eth_common.Address
+}
+
+var (
+	rescanBlocksPerRequest  *uint64
+	rescanRequestsPerSecond *float64
+	rescanBroadcast         *bool
+)
+
+func init() {
+	rescanBlocksPerRequest = AdminClientRescanBlockRangeCmd.Flags().Uint64("blocksPerRequest", defaultRescanBlocksPerRequest, "Number of blocks covered by each eth_getLogs request")
+	rescanRequestsPerSecond = AdminClientRescanBlockRangeCmd.Flags().Float64("requestsPerSecond", defaultRescanRequestsPerSecond, "Maximum number of requests per second to the RPC endpoint")
+	rescanBroadcast = AdminClientRescanBlockRangeCmd.Flags().Bool("broadcast", false, "Send the observation requests to the other guardians too")
+}
+
+var AdminClientRescanBlockRangeCmd = &cobra.Command{
+	Use:   "rescan-block-range [CHAIN] [FROM_BLOCK] [TO_BLOCK]",
+	Short: "Scans a block range of an EVM chain for messages and requests the re-observation of their transactions",
+	Run:   runRescanBlockRange,
+	Args:  cobra.ExactArgs(3),
+}
+
+func runRescanBlockRange(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(args[0])
+	if err != nil {
+		log.Fatalf("invalid chain: %v", err)
+	}
+	from, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid first block: %v", err)
+	}
+	to, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid last block: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := c.RescanBlockRange(ctx, &nodev1.RescanBlockRangeRequest{
+		ChainId:           uint32(chainID),
+		FromBlock:         from,
+		ToBlock:           to,
+		BlocksPerRequest:  *rescanBlocksPerRequest,
+		RequestsPerSecond: *rescanRequestsPerSecond,
+		Broadcast:         *rescanBroadcast,
+	})
+	if err != nil {
+		log.Fatalf("failed to run RescanBlockRange RPC: %s", err)
+	}
+
+	var last *nodev1.RescanBlockRangeResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("rescan failed: %s", err)
+		}
+		last = resp
+		fmt.Printf("scanned to block %d (%d/%d blocks, %.1f%%), %d transactions with messages\n",
+			resp.ScannedToBlock, resp.BlocksScanned, resp.BlocksTotal,
+			100*float64(resp.BlocksScanned)/float64(resp.BlocksTotal), resp.Transactions)
+	}
+
+	if last != nil {
+		fmt.Printf("Requested the re-observation of %d transactions on %v\n", last.Transactions, chainID)
+	}
+}
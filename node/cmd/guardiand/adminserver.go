@@ -1,33 +1,57 @@
 package guardiand
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
 	"math/rand"
 	"net"
 	"net/http"
 	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/certusone/wormhole/node/pkg/accountant"
 	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/ethereum"
+	"github.com/certusone/wormhole/node/pkg/faultinject"
+	"github.com/certusone/wormhole/node/pkg/featureflags"
 	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	"github.com/certusone/wormhole/node/pkg/processor"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
 	"github.com/certusone/wormhole/node/pkg/publicrpc"
+	"github.com/certusone/wormhole/node/pkg/readiness"
+	"github.com/certusone/wormhole/node/pkg/version"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
 	"github.com/certusone/wormhole/node/pkg/common"
 	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	"github.com/certusone/wormhole/node/pkg/watchercontrol"
 )
 
 type nodePrivilegedService struct {
@@ -36,8 +60,25 @@ type nodePrivilegedService struct {
 	injectC      chan<- *vaa.VAA
 	obsvReqSendC chan *gossipv1.ObservationRequest
 	logger       *zap.Logger
-	signedInC    chan *gossipv1.SignedVAAWithQuorum
+	signedInC    chan<- *gossipv1.SignedVAAWithQuorum
 	governor     *governor.ChainGovernor
+	acct         *accountant.Accountant
+	watchers     *watchercontrol.Controller
+	gst          *common.GuardianSetState
+	references   map[vaa.ChainID]*referenceRPC
+	supervisor   *supervisor.Introspector
+	auditLog     *guardiansigner.AuditLog
+	signLimiter  *processor.SigningRateLimiter
+	breaker      *processor.CircuitBreaker
+	drain        *common.Drain
+	stuck        *processor.StuckMessages
+
+	// rescanTargets are the EVM chains whose block ranges can be rescanned.
+	rescanTargets map[vaa.ChainID]evmRescanTarget
+	// localObsvReqC delivers observation requests to the watchers of this node only, like those received from p2p.
+	localObsvReqC chan<- *gossipv1.ObservationRequest
+	// rescanRunning is 1 while a block range is rescanned.
+	rescanRunning int32
 }
 
 // adminGuardianSetUpdateToVAA converts a nodev1.GuardianSetUpdate message to its canonical VAA representation.
@@ -95,6 +136,10 @@ func adminContractUpgradeToVAA(req *nodev1.ContractUpgrade, timestamp time.Time,
 	newContractAddress := vaa.Address{}
 	copy(newContractAddress[:], b)
 
+	if err := validateChainAddress(vaa.ChainID(req.ChainId), newContractAddress); err != nil {
+		return nil, fmt.Errorf("invalid new_contract address: %w", err)
+	}
+
 	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex,
 		vaa.BodyContractUpgrade{
 			ChainID:     vaa.ChainID(req.ChainId),
@@ -107,7 +152,7 @@ func adminContractUpgradeToVAA(req *nodev1.ContractUpgrade, timestamp time.Time,
 // tokenBridgeRegisterChain converts a nodev1.TokenBridgeRegisterChain message to its canonical VAA representation.
 // Returns an error if the data is invalid.
 func tokenBridgeRegisterChain(req *nodev1.BridgeRegisterChain, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
-	if req.ChainId > math.MaxUint16 {
+	if req.ChainId == 0 || req.ChainId > math.MaxUint16 {
 		return nil, errors.New("invalid chain_id")
 	}
 
@@ -123,6 +168,10 @@ func tokenBridgeRegisterChain(req *nodev1.BridgeRegisterChain, timestamp time.Ti
 	emitterAddress := vaa.Address{}
 	copy(emitterAddress[:], b)
 
+	if err := validateChainAddress(vaa.ChainID(req.ChainId), emitterAddress); err != nil {
+		return nil, fmt.Errorf("invalid emitter address: %w", err)
+	}
+
 	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex,
 		vaa.BodyTokenBridgeRegisterChain{
 			Module:         req.Module,
@@ -152,6 +201,10 @@ func tokenBridgeUpgradeContract(req *nodev1.BridgeUpgradeContract, timestamp tim
 	newContract := vaa.Address{}
 	copy(newContract[:], b)
 
+	if err := validateChainAddress(vaa.ChainID(req.TargetChainId), newContract); err != nil {
+		return nil, fmt.Errorf("invalid new contract address: %w", err)
+	}
+
 	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex,
 		vaa.BodyTokenBridgeUpgradeContract{
 			Module:        req.Module,
@@ -162,6 +215,312 @@ func tokenBridgeUpgradeContract(req *nodev1.BridgeUpgradeContract, timestamp tim
 	return v, nil
 }
 
+// adminSetMessageFeeToVAA converts a nodev1.SetMessageFee message to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func adminSetMessageFeeToVAA(req *nodev1.SetMessageFee, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, errors.New("invalid chain_id")
+	}
+
+	fee, err := parseUint256(req.MessageFee)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message_fee: %w", err)
+	}
+
+	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex,
+		vaa.BodySetMessageFee{
+			ChainID:    vaa.ChainID(req.ChainId),
+			MessageFee: fee,
+		}.Serialize())
+
+	return v, nil
+}
+
+// adminTransferFeesToVAA converts a nodev1.TransferFees message to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func adminTransferFeesToVAA(req *nodev1.TransferFees, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, errors.New("invalid chain_id")
+	}
+
+	amount, err := parseUint256(req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	b, err := hex.DecodeString(req.Recipient)
+	if err != nil {
+		return nil, errors.New("invalid recipient address encoding (expected hex)")
+	}
+
+	if len(b) != 32 {
+		return nil, errors.New("invalid recipient address (expected 32 bytes)")
+	}
+
+	recipient := vaa.Address{}
+	copy(recipient[:], b)
+
+	if err := validateChainAddress(vaa.ChainID(req.ChainId), recipient); err != nil {
+		return nil, fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex,
+		vaa.BodyTransferFees{
+			ChainID:   vaa.ChainID(req.ChainId),
+			Amount:    amount,
+			Recipient: recipient,
+		}.Serialize())
+
+	return v, nil
+}
+
+// adminContractRegistryToVAA converts a nodev1.ContractRegistry message to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func adminContractRegistryToVAA(req *nodev1.ContractRegistry, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
+	if len(req.Entries) == 0 || len(req.Entries) > math.MaxUint8 {
+		return nil, errors.New("invalid number of entries")
+	}
+
+	body := vaa.BodyContractRegistry{Version: req.Version}
+	seen := make(map[vaa.ChainID]bool)
+	for i, e := range req.Entries {
+		if e.ChainId > math.MaxUint16 {
+			return nil, fmt.Errorf("invalid chain_id of entry %d", i)
+		}
+		chainID := vaa.ChainID(e.ChainId)
+		if !evmChains[chainID] {
+			return nil, fmt.Errorf("entry %d: %v is not an EVM chain", i, chainID)
+		}
+		if seen[chainID] {
+			return nil, fmt.Errorf("duplicate entry for %v", chainID)
+		}
+		seen[chainID] = true
+
+		core, err := parseRegistryAddress(e.Core)
+		if err != nil || core == (vaa.Address{}) {
+			return nil, fmt.Errorf("invalid core address of %v", chainID)
+		}
+
+		var tokenBridge vaa.Address
+		if e.TokenBridge != "" {
+			if tokenBridge, err = parseRegistryAddress(e.TokenBridge); err != nil {
+				return nil, fmt.Errorf("invalid token bridge address of %v", chainID)
+			}
+		}
+
+		b, err := hex.DecodeString(e.CoreCodeHash)
+		if err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("invalid core code hash of %v (expected 32 bytes hex)", chainID)
+		}
+		var codeHash [32]byte
+		copy(codeHash[:], b)
+
+		body.Entries = append(body.Entries, vaa.ContractRegistryEntry{
+			ChainID:      chainID,
+			Core:         core,
+			TokenBridge:  tokenBridge,
+			CoreCodeHash: codeHash,
+		})
+	}
+
+	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex, body.Serialize())
+
+	return v, nil
+}
+
+// adminNetworkConfigToVAA converts a nodev1.NetworkConfig message to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func adminNetworkConfigToVAA(req *nodev1.NetworkConfig, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
+	if req.Version == 0 {
+		return nil, errors.New("invalid version")
+	}
+	if len(req.Features) > math.MaxUint8 {
+		return nil, errors.New("invalid number of features")
+	}
+
+	body := vaa.BodyNetworkConfig{Version: req.Version}
+	seen := make(map[string]bool)
+	for i, f := range req.Features {
+		if f.Name == "" || len(f.Name) > 32 || strings.ContainsRune(f.Name, 0) {
+			return nil, fmt.Errorf("invalid name of feature %d", i)
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("duplicate feature %s", f.Name)
+		}
+		seen[f.Name] = true
+		if f.ActivationTime > math.MaxInt64 {
+			return nil, fmt.Errorf("invalid activation_time of feature %s", f.Name)
+		}
+		body.Features = append(body.Features, vaa.NetworkConfigFeature{Name: f.Name, ActivationTime: f.ActivationTime})
+	}
+
+	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex, body.Serialize())
+
+	return v, nil
+}
+
+// accountantModifyBalanceBody converts a nodev1.AccountantModifyBalance message to its payload.
+// Returns an error if the data is invalid.
+func accountantModifyBalanceBody(req *nodev1.AccountantModifyBalance) (*vaa.BodyAccountantModifyBalance, error) {
+	if req.ChainId == 0 || req.ChainId > math.MaxUint16 {
+		return nil, errors.New("invalid chain_id")
+	}
+
+	if req.TokenChain == 0 || req.TokenChain > math.MaxUint16 {
+		return nil, errors.New("invalid token_chain")
+	}
+
+	b, err := hex.DecodeString(req.TokenAddress)
+	if err != nil {
+		return nil, errors.New("invalid token address encoding (expected hex)")
+	}
+
+	if len(b) != 32 {
+		return nil, errors.New("invalid token address (expected 32 bytes)")
+	}
+
+	tokenAddress := vaa.Address{}
+	copy(tokenAddress[:], b)
+
+	if err := validateChainAddress(vaa.ChainID(req.TokenChain), tokenAddress); err != nil {
+		return nil, fmt.Errorf("invalid token address: %w", err)
+	}
+
+	var kind vaa.ModificationKind
+	switch req.Kind {
+	case nodev1.AccountantModifyBalance_MODIFICATION_KIND_ADD:
+		kind = vaa.ModificationKindAdd
+	case nodev1.AccountantModifyBalance_MODIFICATION_KIND_SUBTRACT:
+		kind = vaa.ModificationKindSubtract
+	default:
+		return nil, errors.New("invalid modification kind")
+	}
+
+	amount, err := parseUint256(req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	if amount.Sign() == 0 {
+		return nil, errors.New("amount must not be zero")
+	}
+
+	if req.Reason == "" || len(req.Reason) > 32 {
+		return nil, errors.New("reason must be between 1 and 32 bytes")
+	}
+
+	return &vaa.BodyAccountantModifyBalance{
+		Sequence:     req.Sequence,
+		ChainID:      vaa.ChainID(req.ChainId),
+		TokenChain:   vaa.ChainID(req.TokenChain),
+		TokenAddress: tokenAddress,
+		Kind:         kind,
+		Amount:       amount,
+		Reason:       req.Reason,
+	}, nil
+}
+
+// adminAccountantModifyBalanceToVAA converts a nodev1.AccountantModifyBalance message to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func adminAccountantModifyBalanceToVAA(req *nodev1.AccountantModifyBalance, timestamp time.Time, guardianSetIndex uint32, nonce uint32, sequence uint64) (*vaa.VAA, error) {
+	body, err := accountantModifyBalanceBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	v := vaa.CreateGovernanceVAA(timestamp, nonce, sequence, guardianSetIndex, body.Serialize())
+
+	return v, nil
+}
+
+// parseRegistryAddress parses a hex-encoded 20 byte EVM address and left-pads it to 32 bytes.
+func parseRegistryAddress(s string) (vaa.Address, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return vaa.Address{}, err
+	}
+	if len(b) != 20 {
+		return vaa.Address{}, errors.New("expected 20 bytes")
+	}
+
+	addr := vaa.Address{}
+	copy(addr[12:], b)
+	return addr, nil
+}
+
+// The chains whose addresses are 20 byte EVM addresses, left-padded to 32 bytes.
+var evmChains = map[vaa.ChainID]bool{
+	vaa.ChainIDEthereum:        true,
+	vaa.ChainIDBSC:             true,
+	vaa.ChainIDPolygon:         true,
+	vaa.ChainIDAvalanche:       true,
+	vaa.ChainIDOasis:           true,
+	vaa.ChainIDAurora:          true,
+	vaa.ChainIDFantom:          true,
+	vaa.ChainIDKarura:          true,
+	vaa.ChainIDAcala:           true,
+	vaa.ChainIDKlaytn:          true,
+	vaa.ChainIDCelo:            true,
+	vaa.ChainIDMoonbeam:        true,
+	vaa.ChainIDNeon:            true,
+	vaa.ChainIDEthereumRopsten: true,
+}
+
+// validateChainAddress checks that an address is valid on the chain it refers to. Chains that are not known to this
+// release, such as a new chain being registered, only require a non-zero address.
+func validateChainAddress(chainID vaa.ChainID, addr vaa.Address) error {
+	if addr == (vaa.Address{}) {
+		return errors.New("address must not be zero")
+	}
+
+	if evmChains[chainID] && !bytes.Equal(addr[:12], make([]byte, 12)) {
+		return fmt.Errorf("%v expects a 20 byte address, left-padded with zeros", chainID)
+	}
+
+	return nil
+}
+
+// parseUint256 parses a decimal-encoded uint256.
+func parseUint256(s string) (*big.Int, error) {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("\"%s\" is not a decimal number", s)
+	}
+
+	if i.Sign() < 0 || i.BitLen() > 256 {
+		return nil, fmt.Errorf("%s does not fit in a uint256", s)
+	}
+
+	return i, nil
+}
+
+// governanceMessageToVAA converts a nodev1.GovernanceMessage to its canonical VAA representation.
+// Returns an error if the data is invalid.
+func governanceMessageToVAA(message *nodev1.GovernanceMessage, timestamp time.Time, guardianSetIndex uint32) (*vaa.VAA, error) {
+	switch payload := message.Payload.(type) {
+	case *nodev1.GovernanceMessage_GuardianSet:
+		return adminGuardianSetUpdateToVAA(payload.GuardianSet, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_ContractUpgrade:
+		return adminContractUpgradeToVAA(payload.ContractUpgrade, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_SetMessageFee:
+		return adminSetMessageFeeToVAA(payload.SetMessageFee, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_TransferFees:
+		return adminTransferFeesToVAA(payload.TransferFees, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_ContractRegistry:
+		return adminContractRegistryToVAA(payload.ContractRegistry, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_NetworkConfig:
+		return adminNetworkConfigToVAA(payload.NetworkConfig, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_BridgeRegisterChain:
+		return tokenBridgeRegisterChain(payload.BridgeRegisterChain, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_BridgeContractUpgrade:
+		return tokenBridgeUpgradeContract(payload.BridgeContractUpgrade, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	case *nodev1.GovernanceMessage_AccountantModifyBalance:
+		return adminAccountantModifyBalanceToVAA(payload.AccountantModifyBalance, timestamp, guardianSetIndex, message.Nonce, message.Sequence)
+	default:
+		return nil, fmt.Errorf("unsupported VAA type: %T", payload)
+	}
+}
+
 func (s *nodePrivilegedService) InjectGovernanceVAA(ctx context.Context, req *nodev1.InjectGovernanceVAARequest) (*nodev1.InjectGovernanceVAAResponse, error) {
 	s.logger.Info("governance VAA injected via admin socket", zap.String("request", req.String()))
 
@@ -175,18 +534,7 @@ func (s *nodePrivilegedService) InjectGovernanceVAA(ctx context.Context, req *no
 	digests := make([][]byte, len(req.Messages))
 
 	for i, message := range req.Messages {
-		switch payload := message.Payload.(type) {
-		case *nodev1.GovernanceMessage_GuardianSet:
-			v, err = adminGuardianSetUpdateToVAA(payload.GuardianSet, timestamp, req.CurrentSetIndex, message.Nonce, message.Sequence)
-		case *nodev1.GovernanceMessage_ContractUpgrade:
-			v, err = adminContractUpgradeToVAA(payload.ContractUpgrade, timestamp, req.CurrentSetIndex, message.Nonce, message.Sequence)
-		case *nodev1.GovernanceMessage_BridgeRegisterChain:
-			v, err = tokenBridgeRegisterChain(payload.BridgeRegisterChain, timestamp, req.CurrentSetIndex, message.Nonce, message.Sequence)
-		case *nodev1.GovernanceMessage_BridgeContractUpgrade:
-			v, err = tokenBridgeUpgradeContract(payload.BridgeContractUpgrade, timestamp, req.CurrentSetIndex, message.Nonce, message.Sequence)
-		default:
-			panic(fmt.Sprintf("unsupported VAA type: %T", payload))
-		}
+		v, err = governanceMessageToVAA(message, timestamp, req.CurrentSetIndex)
 		if err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
@@ -207,6 +555,58 @@ func (s *nodePrivilegedService) InjectGovernanceVAA(ctx context.Context, req *no
 	return &nodev1.InjectGovernanceVAAResponse{Digests: digests}, nil
 }
 
+// InjectSignedGovernanceVAA injects a governance VAA signed offline. The signature must have been made with the guardian
+// key of this node, since it is broadcast as this node's observation of the VAA.
+func (s *nodePrivilegedService) InjectSignedGovernanceVAA(ctx context.Context, req *nodev1.InjectSignedGovernanceVAARequest) (*nodev1.InjectSignedGovernanceVAAResponse, error) {
+	v, err := vaa.Unmarshal(req.Vaa)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal VAA: %v", err)
+	}
+
+	if v.EmitterChain != vaa.GovernanceChain || v.EmitterAddress != vaa.GovernanceEmitter {
+		return nil, status.Error(codes.InvalidArgument, "not a governance VAA")
+	}
+
+	if len(v.Signatures) != 0 {
+		return nil, status.Error(codes.InvalidArgument, "the VAA must not be signed, the signature is passed separately")
+	}
+
+	if len(req.Signature) != 65 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature length: %d", len(req.Signature))
+	}
+
+	digest := v.SigningMsg()
+	signer, err := recoverSigner(digest.Bytes(), req.Signature)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to recover signer: %v", err)
+	}
+
+	if ourAddr := ethcommon.HexToAddress(p2p.DefaultRegistry.GuardianAddress()); signer != ourAddr {
+		return nil, status.Errorf(codes.InvalidArgument, "signed by %s, but this node's guardian key is %s", signer.Hex(), ourAddr.Hex())
+	}
+
+	s.logger.Info("governance VAA signed offline injected via admin socket",
+		zap.Any("vaa", v),
+		zap.String("digest", digest.String()),
+	)
+
+	sig := &vaa.Signature{}
+	copy(sig.Signature[:], req.Signature)
+	v.Signatures = []*vaa.Signature{sig}
+	s.injectC <- v
+
+	return &nodev1.InjectSignedGovernanceVAAResponse{Digest: digest.Bytes()}, nil
+}
+
+// recoverSigner returns the address of the key that made a signature of the digest.
+func recoverSigner(digest []byte, signature []byte) (ethcommon.Address, error) {
+	pubKey, err := ethcrypto.Ecrecover(digest, signature)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	return ethcommon.BytesToAddress(ethcrypto.Keccak256(pubKey[1:])[12:]), nil
+}
+
 // fetchMissing attempts to backfill a gap by fetching and storing missing signed VAAs from the network.
 // Returns true if the gap was filled, false otherwise.
 func (s *nodePrivilegedService) fetchMissing(
@@ -216,6 +616,20 @@ func (s *nodePrivilegedService) fetchMissing(
 	chain vaa.ChainID,
 	addr string,
 	seq uint64) (bool, error) {
+	return fetchMissingVAA(ctx, s.logger, s.signedInC, nodes, c, chain, addr, seq)
+}
+
+// fetchMissingVAA fetches a signed VAA from the public RPC endpoints of other guardians and injects it into the
+// signed VAA receive path, which verifies and stores it. Returns true if a guardian returned the VAA.
+func fetchMissingVAA(
+	ctx context.Context,
+	logger *zap.Logger,
+	signedInC chan<- *gossipv1.SignedVAAWithQuorum,
+	nodes []string,
+	c *http.Client,
+	chain vaa.ChainID,
+	addr string,
+	seq uint64) (bool, error) {
 
 	// shuffle the list of public RPC endpoints
 	rand.Shuffle(len(nodes), func(i, j int) {
@@ -234,7 +648,7 @@ func (s *nodePrivilegedService) fetchMissing(
 
 		resp, err := c.Do(req)
 		if err != nil {
-			s.logger.Warn("failed to fetch missing VAA",
+			logger.Warn("failed to fetch missing VAA",
 				zap.String("node", node),
 				zap.String("chain", chain.String()),
 				zap.String("address", addr),
@@ -255,7 +669,7 @@ func (s *nodePrivilegedService) fetchMissing(
 			var respBody getVaaResp
 			if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
 				resp.Body.Close()
-				s.logger.Warn("failed to decode VAA response",
+				logger.Warn("failed to decode VAA response",
 					zap.String("node", node),
 					zap.String("chain", chain.String()),
 					zap.String("address", addr),
@@ -269,7 +683,7 @@ func (s *nodePrivilegedService) fetchMissing(
 			vaaBytes, err := base64.StdEncoding.DecodeString(respBody.VaaBytes)
 			if err != nil {
 				resp.Body.Close()
-				s.logger.Warn("failed to decode VAA body",
+				logger.Warn("failed to decode VAA body",
 					zap.String("node", node),
 					zap.String("chain", chain.String()),
 					zap.String("address", addr),
@@ -279,7 +693,7 @@ func (s *nodePrivilegedService) fetchMissing(
 				continue
 			}
 
-			s.logger.Info("backfilled VAA",
+			logger.Info("backfilled VAA",
 				zap.Uint16("chain", uint16(chain)),
 				zap.String("address", addr),
 				zap.Uint64("sequence", seq),
@@ -289,7 +703,7 @@ func (s *nodePrivilegedService) fetchMissing(
 			// Inject into the gossip signed VAA receive path.
 			// This has the same effect as if the VAA was received from the network
 			// (verifying signature, publishing to BigTable, storing in local DB...).
-			s.signedInC <- &gossipv1.SignedVAAWithQuorum{
+			signedInC <- &gossipv1.SignedVAAWithQuorum{
 				Vaa: vaaBytes,
 			}
 
@@ -345,8 +759,20 @@ func (s *nodePrivilegedService) FindMissingMessages(ctx context.Context, req *no
 	}, nil
 }
 
-func adminServiceRunnable(logger *zap.Logger, socketPath string, injectC chan<- *vaa.VAA, signedInC chan *gossipv1.SignedVAAWithQuorum, obsvReqSendC chan *gossipv1.ObservationRequest,
-	db *db.Database, gst *common.GuardianSetState, gov *governor.ChainGovernor) (supervisor.Runnable, error) {
+// adminTCPConfig configures the optional TCP listener of the admin service, see adminauth.go.
+type adminTCPConfig struct {
+	listenAddr      string
+	certFile        string
+	keyFile         string
+	clientCAFile    string
+	authzConfigPath string
+}
+
+func adminServiceRunnable(logger *zap.Logger, socketPath string, tcpConfig *adminTCPConfig, injectC chan<- *vaa.VAA, signedInC chan<- *gossipv1.SignedVAAWithQuorum, obsvReqSendC chan *gossipv1.ObservationRequest,
+	db *db.Database, gst *common.GuardianSetState, gov *governor.ChainGovernor, acct *accountant.Accountant, watchers *watchercontrol.Controller,
+	references map[vaa.ChainID]*referenceRPC, tree *supervisor.Introspector, rl *publicrpc.RateLimiter, wd *watchdog.Watchdog, auditLog *guardiansigner.AuditLog,
+	signLimiter *processor.SigningRateLimiter, breaker *processor.CircuitBreaker, drain *common.Drain, stuck *processor.StuckMessages,
+	rescanTargets map[vaa.ChainID]evmRescanTarget, localObsvReqC chan<- *gossipv1.ObservationRequest) (supervisor.Runnable, error) {
 	// Delete existing UNIX socket, if present.
 	fi, err := os.Stat(socketPath)
 	if err == nil {
@@ -385,14 +811,76 @@ func adminServiceRunnable(logger *zap.Logger, socketPath string, injectC chan<-
 		logger:       logger.Named("adminservice"),
 		signedInC:    signedInC,
 		governor:     gov,
+		acct:         acct,
+		watchers:     watchers,
+		gst:          gst,
+		references:   references,
+		supervisor:   tree,
+		auditLog:     auditLog,
+		signLimiter:  signLimiter,
+		breaker:      breaker,
+		drain:        drain,
+		stuck:        stuck,
+
+		rescanTargets: rescanTargets,
+		localObsvReqC: localObsvReqC,
 	}
 
 	publicrpcService := publicrpc.NewPublicrpcServer(logger, db, gst, gov)
 
-	grpcServer := common.NewInstrumentedGRPCServer(logger)
+	// The public REST gateway is served through the admin socket, so its requests are shed and throttled here.
+	var opts []grpc.ServerOption
+	if wd != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(publicrpc.SheddingInterceptor(wd)))
+	}
+	if rl != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(rl.UnaryInterceptor))
+	}
+	grpcServer := common.NewInstrumentedGRPCServer(logger, opts...)
 	nodev1.RegisterNodePrivilegedServiceServer(grpcServer, nodeService)
 	publicrpcv1.RegisterPublicRPCServiceServer(grpcServer, publicrpcService)
-	return supervisor.GRPCServer(grpcServer, l, false), nil
+
+	if tcpConfig == nil {
+		return supervisor.GRPCServer(grpcServer, l, false), nil
+	}
+
+	authz, err := loadAdminAuthorizer(tcpConfig.authzConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := adminServerTLSConfig(tcpConfig.certFile, tcpConfig.keyFile, tcpConfig.clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tl, err := net.Listen("tcp", tcpConfig.listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", tcpConfig.listenAddr, err)
+	}
+
+	logger.Info("admin server listening with mutual TLS", zap.String("addr", tl.Addr().String()))
+
+	tcpServer := common.NewInstrumentedGRPCServer(logger,
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(authz.unaryInterceptor),
+		grpc.ChainStreamInterceptor(authz.streamInterceptor),
+	)
+	nodev1.RegisterNodePrivilegedServiceServer(tcpServer, nodeService)
+	publicrpcv1.RegisterPublicRPCServiceServer(tcpServer, publicrpcService)
+
+	return func(ctx context.Context) error {
+		if err := supervisor.Run(ctx, "socket", supervisor.GRPCServer(grpcServer, l, false)); err != nil {
+			return err
+		}
+		if err := supervisor.Run(ctx, "tcp", supervisor.GRPCServer(tcpServer, tl, false)); err != nil {
+			return err
+		}
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		<-ctx.Done()
+		return ctx.Err()
+	}, nil
 }
 
 func (s *nodePrivilegedService) SendObservationRequest(ctx context.Context, req *nodev1.SendObservationRequestRequest) (*nodev1.SendObservationRequestResponse, error) {
@@ -404,6 +892,151 @@ func (s *nodePrivilegedService) SendObservationRequest(ctx context.Context, req
 	return &nodev1.SendObservationRequestResponse{}, nil
 }
 
+func (s *nodePrivilegedService) ReobserveMessage(ctx context.Context, req *nodev1.ReobserveMessageRequest) (*nodev1.ReobserveMessageResponse, error) {
+	if req.EmitterChain > math.MaxUint16 {
+		return nil, fmt.Errorf("emitter chain id must be no greater than 16 bits")
+	}
+
+	emitterAddress, err := vaa.StringToAddress(req.EmitterAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid emitter address: %w", err)
+	}
+
+	chainID := vaa.ChainID(req.EmitterChain)
+	id := db.VAAID{EmitterChain: chainID, EmitterAddress: emitterAddress, Sequence: req.Sequence}
+
+	if _, err := s.db.GetSignedVAABytes(id); err == nil {
+		return &nodev1.ReobserveMessageResponse{
+			VaaExists: true,
+			Response:  "a signed VAA already exists for this message, it does not need to be reobserved",
+		}, nil
+	} else if err != db.ErrVAANotFound {
+		return nil, fmt.Errorf("failed to look up VAA: %w", err)
+	}
+
+	txHash, err := s.db.GetMessageTxHash(id)
+	if err == db.ErrTxHashNotFound {
+		return &nodev1.ReobserveMessageResponse{
+			Response: "this node has not observed the message recently, use send-observation-request with the chain-specific tx hash instead",
+		}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up tx hash: %w", err)
+	}
+
+	obsvReq := &gossipv1.ObservationRequest{
+		ChainId: uint32(chainID),
+		TxHash:  common.ObservationRequestTxHash(chainID, txHash),
+	}
+
+	if err := common.PostObservationRequest(s.obsvReqSendC, obsvReq); err != nil {
+		return &nodev1.ReobserveMessageResponse{
+			Found:    true,
+			TxHash:   hex.EncodeToString(obsvReq.TxHash),
+			Response: fmt.Sprintf("failed to send observation request: %v", err),
+		}, nil
+	}
+
+	s.logger.Info("sent observation request to reobserve message", zap.String("messageId", string(id.Bytes())), zap.Any("request", obsvReq))
+	return &nodev1.ReobserveMessageResponse{
+		Found:       true,
+		Republished: true,
+		TxHash:      hex.EncodeToString(obsvReq.TxHash),
+		Response:    "observation request sent",
+	}, nil
+}
+
+func (s *nodePrivilegedService) PurgeAndResignVAA(ctx context.Context, req *nodev1.PurgeAndResignVAARequest) (*nodev1.PurgeAndResignVAAResponse, error) {
+	if req.EmitterChain > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "emitter chain id must be no greater than 16 bits")
+	}
+
+	emitterAddress, err := vaa.StringToAddress(req.EmitterAddress)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid emitter address: %v", err)
+	}
+
+	chainID := vaa.ChainID(req.EmitterChain)
+	id := db.VAAID{EmitterChain: chainID, EmitterAddress: emitterAddress, Sequence: req.Sequence}
+
+	b, err := s.db.GetSignedVAABytes(id)
+	if err == db.ErrVAANotFound {
+		return nil, status.Error(codes.NotFound, "no signed VAA is stored for this message")
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to look up VAA: %v", err)
+	}
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unmarshal VAA: %v", err)
+	}
+
+	gs := s.gst.Get()
+	if gs == nil {
+		return nil, status.Error(codes.Unavailable, "the current guardian set is not known yet")
+	}
+	if v.GuardianSetIndex >= gs.Index {
+		return nil, status.Errorf(codes.FailedPrecondition, "the VAA is signed by guardian set %d, which is not older than the current guardian set %d", v.GuardianSetIndex, gs.Index)
+	}
+
+	// The message cannot be reobserved without its tx hash.
+	var txHash []byte
+	if req.TxHash != "" {
+		if txHash, err = hex.DecodeString(req.TxHash); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid tx hash: %v", err)
+		}
+	} else if stored, err := s.db.GetMessageTxHash(id); err == db.ErrTxHashNotFound {
+		return nil, status.Error(codes.FailedPrecondition, "this node has not observed the message recently, the chain-specific tx hash must be given")
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to look up tx hash: %v", err)
+	} else {
+		txHash = common.ObservationRequestTxHash(chainID, stored)
+	}
+
+	// The stored VAA is kept until the message is signed again: the processor doesn't ignore messages whose VAA was
+	// signed by an older guardian set, and replaces the VAA once the new one reaches quorum. If the reobservation fails,
+	// the old VAA is still there.
+	obsvReq := &gossipv1.ObservationRequest{ChainId: uint32(chainID), TxHash: txHash}
+	if err := common.PostObservationRequest(s.obsvReqSendC, obsvReq); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to send observation request: %v", err)
+	}
+
+	s.logger.Info("sent observation request to sign again a VAA signed by an old guardian set",
+		zap.String("messageId", string(id.Bytes())),
+		zap.Uint32("purgedGuardianSetIndex", v.GuardianSetIndex),
+		zap.Uint32("currentGuardianSetIndex", gs.Index),
+		zap.Any("request", obsvReq))
+	return &nodev1.PurgeAndResignVAAResponse{
+		PurgedGuardianSetIndex:  v.GuardianSetIndex,
+		CurrentGuardianSetIndex: gs.Index,
+		TxHash:                  hex.EncodeToString(txHash),
+	}, nil
+}
+
+func (s *nodePrivilegedService) GetMessageProvenance(ctx context.Context, req *nodev1.GetMessageProvenanceRequest) (*nodev1.GetMessageProvenanceResponse, error) {
+	if req.EmitterChain > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "emitter chain id must be no greater than 16 bits")
+	}
+
+	emitterAddress, err := vaa.StringToAddress(req.EmitterAddress)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid emitter address: %v", err)
+	}
+
+	id := db.VAAID{EmitterChain: vaa.ChainID(req.EmitterChain), EmitterAddress: emitterAddress, Sequence: req.Sequence}
+	p, err := s.db.GetMessageProvenance(id)
+	if err == db.ErrProvenanceNotFound {
+		return nil, status.Error(codes.NotFound, "this node has not observed the message recently, or its watcher does not record provenance")
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to look up provenance: %v", err)
+	}
+
+	return &nodev1.GetMessageProvenanceResponse{
+		RpcEndpoint:    p.RPCEndpoint,
+		FetchLatencyMs: uint64(p.FetchLatency.Milliseconds()),
+		Block:          p.Block,
+		WatcherVersion: p.WatcherVersion,
+	}, nil
+}
+
 func (s *nodePrivilegedService) ChainGovernorStatus(ctx context.Context, req *nodev1.ChainGovernorStatusRequest) (*nodev1.ChainGovernorStatusResponse, error) {
 	if s.governor == nil {
 		return nil, fmt.Errorf("chain governor is not enabled")
@@ -467,7 +1100,7 @@ func (s *nodePrivilegedService) ChainGovernorReleasePendingVAA(ctx context.Conte
 	}, nil
 }
 
-func (s *nodePrivilegedService) ChainGovernorResetReleaseTimer(ctx context.Context, req *nodev1.ChainGovernorResetReleaseTimerRequest) (*nodev1.ChainGovernorResetReleaseTimerResponse, error) {
+func (s *nodePrivilegedService) ChainGovernorEmergencyRelease(ctx context.Context, req *nodev1.ChainGovernorEmergencyReleaseRequest) (*nodev1.ChainGovernorEmergencyReleaseResponse, error) {
 	if s.governor == nil {
 		return nil, fmt.Errorf("chain governor is not enabled")
 	}
@@ -476,12 +1109,946 @@ func (s *nodePrivilegedService) ChainGovernorResetReleaseTimer(ctx context.Conte
 		return nil, fmt.Errorf("the VAA id must be specified as \"chainId/emitterAddress/seqNum\"")
 	}
 
-	resp, err := s.governor.ResetReleaseTimer(req.VaaId)
+	resp, err := s.governor.EmergencyRelease(req.VaaId)
 	if err != nil {
 		return nil, err
 	}
 
-	return &nodev1.ChainGovernorResetReleaseTimerResponse{
+	return &nodev1.ChainGovernorEmergencyReleaseResponse{
 		Response: resp,
 	}, nil
 }
+
+func (s *nodePrivilegedService) ChainGovernorResetReleaseTimer(ctx context.Context, req *nodev1.ChainGovernorResetReleaseTimerRequest) (*nodev1.ChainGovernorResetReleaseTimerResponse, error) {
+	if s.governor == nil {
+		return nil, fmt.Errorf("chain governor is not enabled")
+	}
+
+	if len(req.VaaId) == 0 {
+		return nil, fmt.Errorf("the VAA id must be specified as \"chainId/emitterAddress/seqNum\"")
+	}
+
+	resp, err := s.governor.ResetReleaseTimer(req.VaaId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.ChainGovernorResetReleaseTimerResponse{
+		Response: resp,
+	}, nil
+}
+
+func (s *nodePrivilegedService) ChainGovernorListPendingVAAs(ctx context.Context, req *nodev1.ChainGovernorListPendingVAAsRequest) (*nodev1.ChainGovernorListPendingVAAsResponse, error) {
+	if s.governor == nil {
+		return nil, fmt.Errorf("chain governor is not enabled")
+	}
+
+	if req.EmitterChain > math.MaxUint16 {
+		return nil, fmt.Errorf("emitter chain id must be no greater than 16 bits")
+	}
+
+	entries, err := s.governor.ListPendingVAAs(vaa.ChainID(req.EmitterChain))
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.ChainGovernorListPendingVAAsResponse{
+		Entries: entries,
+	}, nil
+}
+
+func (s *nodePrivilegedService) ChainGovernorMovePendingVAA(ctx context.Context, req *nodev1.ChainGovernorMovePendingVAARequest) (*nodev1.ChainGovernorMovePendingVAAResponse, error) {
+	if s.governor == nil {
+		return nil, fmt.Errorf("chain governor is not enabled")
+	}
+
+	if len(req.VaaId) == 0 {
+		return nil, fmt.Errorf("the VAA id must be specified as \"chainId/emitterAddress/seqNum\"")
+	}
+
+	resp, err := s.governor.MovePendingVAA(req.VaaId, int(req.Position))
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.ChainGovernorMovePendingVAAResponse{
+		Response: resp,
+	}, nil
+}
+
+func (s *nodePrivilegedService) AccountantReconcile(ctx context.Context, req *nodev1.AccountantReconcileRequest) (*nodev1.AccountantReconcileResponse, error) {
+	if s.acct == nil {
+		return nil, fmt.Errorf("accountant is not enabled")
+	}
+
+	if req.ChainId > math.MaxUint16 {
+		return nil, fmt.Errorf("chain id must be no greater than 16 bits")
+	}
+
+	return &nodev1.AccountantReconcileResponse{
+		Entries: s.acct.Reconcile(ctx, vaa.ChainID(req.ChainId)),
+	}, nil
+}
+
+func (s *nodePrivilegedService) AccountantModifyBalanceDryRun(ctx context.Context, req *nodev1.AccountantModifyBalanceDryRunRequest) (*nodev1.AccountantModifyBalanceDryRunResponse, error) {
+	if s.acct == nil {
+		return nil, status.Error(codes.FailedPrecondition, "accountant is not enabled")
+	}
+
+	if req.Modification == nil {
+		return nil, status.Error(codes.InvalidArgument, "no modification specified")
+	}
+
+	body, err := accountantModifyBalanceBody(req.Modification)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	preview, err := s.acct.PreviewModification(body)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &nodev1.AccountantModifyBalanceDryRunResponse{
+		BalanceBefore:  preview.BalanceBefore.String(),
+		BalanceAfter:   preview.BalanceAfter.String(),
+		AlreadyApplied: preview.AlreadyApplied,
+	}, nil
+}
+
+func (s *nodePrivilegedService) WatcherPause(ctx context.Context, req *nodev1.WatcherPauseRequest) (*nodev1.WatcherPauseResponse, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, fmt.Errorf("chain id must be no greater than 16 bits")
+	}
+
+	resp, err := s.watchers.Pause(vaa.ChainID(req.ChainId))
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.WatcherPauseResponse{
+		Response: resp,
+	}, nil
+}
+
+func (s *nodePrivilegedService) WatcherResume(ctx context.Context, req *nodev1.WatcherResumeRequest) (*nodev1.WatcherResumeResponse, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, fmt.Errorf("chain id must be no greater than 16 bits")
+	}
+
+	resp, err := s.watchers.Resume(vaa.ChainID(req.ChainId))
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.WatcherResumeResponse{
+		Response: resp,
+	}, nil
+}
+
+func (s *nodePrivilegedService) WatcherSetEndpoint(ctx context.Context, req *nodev1.WatcherSetEndpointRequest) (*nodev1.WatcherSetEndpointResponse, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, fmt.Errorf("chain id must be no greater than 16 bits")
+	}
+
+	resp, err := s.watchers.SetEndpoint(vaa.ChainID(req.ChainId), req.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.WatcherSetEndpointResponse{
+		Response: resp,
+	}, nil
+}
+
+func (s *nodePrivilegedService) WatcherStatus(ctx context.Context, req *nodev1.WatcherStatusRequest) (*nodev1.WatcherStatusResponse, error) {
+	return &nodev1.WatcherStatusResponse{
+		Entries: s.watchers.Status(),
+	}, nil
+}
+
+func (s *nodePrivilegedService) SigningRateLimitStatus(ctx context.Context, req *nodev1.SigningRateLimitStatusRequest) (*nodev1.SigningRateLimitStatusResponse, error) {
+	if s.signLimiter == nil {
+		return &nodev1.SigningRateLimitStatusResponse{}, nil
+	}
+
+	resp := &nodev1.SigningRateLimitStatusResponse{Enabled: true}
+	for _, c := range s.signLimiter.Status(time.Now()) {
+		entry := &nodev1.SigningRateLimitStatusResponse_Entry{
+			ChainId:        uint32(c.ChainID),
+			LimitPerMinute: c.LimitPerMinute,
+			LastMinute:     c.LastMinute,
+			Dropped:        c.Dropped,
+		}
+		if !c.LastDropped.IsZero() {
+			entry.LastDropped = c.LastDropped.Unix()
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) CircuitBreakerStatus(ctx context.Context, req *nodev1.CircuitBreakerStatusRequest) (*nodev1.CircuitBreakerStatusResponse, error) {
+	if s.breaker == nil {
+		return &nodev1.CircuitBreakerStatusResponse{}, nil
+	}
+
+	resp := &nodev1.CircuitBreakerStatusResponse{Enabled: true}
+	for _, c := range s.breaker.Status(time.Now()) {
+		entry := &nodev1.CircuitBreakerStatusResponse_Entry{
+			ChainId:           uint32(c.ChainID),
+			Paused:            c.Paused,
+			BaselinePerMinute: c.BaselinePerMinute,
+			CurrentMinute:     c.CurrentMinute,
+			Dropped:           c.Dropped,
+		}
+		if c.LastTrip != nil {
+			entry.LastTripReason = c.LastTrip.Reason
+			entry.LastTripDetail = c.LastTrip.Detail
+			entry.LastTripTime = c.LastTrip.Time.Unix()
+			entry.LastTripMessageId = c.LastTrip.MessageID
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) ResumeSigning(ctx context.Context, req *nodev1.ResumeSigningRequest) (*nodev1.ResumeSigningResponse, error) {
+	if s.breaker == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the circuit breaker is disabled")
+	}
+	if req.ChainId > math.MaxUint16 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid chain id %d", req.ChainId)
+	}
+
+	chainID := vaa.ChainID(req.ChainId)
+	if err := s.breaker.Resume(chainID, time.Now()); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	s.logger.Info("signing resumed by the operator", zap.Stringer("chain", chainID))
+	return &nodev1.ResumeSigningResponse{}, nil
+}
+
+func (s *nodePrivilegedService) DrainShutdown(ctx context.Context, req *nodev1.DrainShutdownRequest) (*nodev1.DrainShutdownResponse, error) {
+	if s.drain == nil {
+		return nil, status.Error(codes.Unavailable, "graceful shutdown is not supported by this node")
+	}
+
+	started := s.drain.Start()
+	if started {
+		s.logger.Info("drain requested over the admin RPC")
+	}
+	return &nodev1.DrainShutdownResponse{AlreadyDraining: !started}, nil
+}
+
+func (s *nodePrivilegedService) GuardianAvailability(ctx context.Context, req *nodev1.GuardianAvailabilityRequest) (*nodev1.GuardianAvailabilityResponse, error) {
+	days := req.Days
+	if days == 0 {
+		days = 30
+	}
+	if time.Duration(days)*24*time.Hour > p2p.AvailabilityRetention {
+		return nil, status.Errorf(codes.InvalidArgument, "availability is only kept for %v", p2p.AvailabilityRetention)
+	}
+
+	since := time.Now().AddDate(0, 0, 1-int(days))
+	aggregates, err := s.db.GetGuardianAvailability(since)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load guardian availability: %v", err)
+	}
+
+	resp := &nodev1.GuardianAvailabilityResponse{}
+	for _, a := range p2p.SumAvailability(aggregates, since) {
+		resp.Entries = append(resp.Entries, &nodev1.GuardianAvailabilityResponse_Entry{
+			GuardianAddr: a.Guardian.Hex(),
+			ChainId:      uint32(a.Chain),
+			Samples:      a.Samples,
+			Available:    a.Available,
+		})
+	}
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) SupervisorTree(ctx context.Context, req *nodev1.SupervisorTreeRequest) (*nodev1.SupervisorTreeResponse, error) {
+	if s.supervisor == nil {
+		return nil, status.Error(codes.Unavailable, "supervisor introspection is not available")
+	}
+
+	resp := &nodev1.SupervisorTreeResponse{}
+	for _, n := range s.supervisor.Status() {
+		entry := &nodev1.SupervisorTreeResponse_Node{
+			Dn:          n.DN,
+			State:       n.State,
+			Restarts:    uint32(n.Restarts),
+			LastError:   n.LastError,
+			CircuitOpen: n.CircuitOpen,
+		}
+		if !n.LastErrorTime.IsZero() {
+			entry.LastErrorTime = n.LastErrorTime.Unix()
+		}
+		if !n.Started.IsZero() {
+			entry.UptimeSeconds = int64(time.Since(n.Started).Seconds())
+		}
+		resp.Nodes = append(resp.Nodes, entry)
+	}
+	return resp, nil
+}
+
+// signedVAAFilterFromProto converts a nodev1.SignedVAAFilter to a db.VAAFilter.
+func signedVAAFilterFromProto(f *nodev1.SignedVAAFilter) (db.VAAFilter, error) {
+	filter := db.VAAFilter{LastSequence: math.MaxUint64}
+	if f == nil {
+		return filter, nil
+	}
+
+	if f.EmitterChain > math.MaxUint16 {
+		return filter, fmt.Errorf("emitter chain id must be no greater than 16 bits")
+	}
+	filter.EmitterChain = vaa.ChainID(f.EmitterChain)
+
+	if f.EmitterAddress != "" {
+		addr, err := vaa.StringToAddress(f.EmitterAddress)
+		if err != nil {
+			return filter, fmt.Errorf("invalid emitter address: %w", err)
+		}
+		filter.EmitterAddress = &addr
+	}
+
+	filter.FirstSequence = f.FirstSequence
+	if f.LastSequence != 0 {
+		filter.LastSequence = f.LastSequence
+	}
+
+	return filter, nil
+}
+
+// signedVAATimeRangeFromProto returns the time range of a nodev1.SignedVAAFilter.
+func signedVAATimeRangeFromProto(f *nodev1.SignedVAAFilter) db.TimeRange {
+	var r db.TimeRange
+	if f == nil {
+		return r
+	}
+	if f.Since != 0 {
+		r.Since = time.Unix(int64(f.Since), 0)
+	}
+	if f.Until != 0 {
+		r.Until = time.Unix(int64(f.Until), 0)
+	}
+	return r
+}
+
+// iterateSignedVAAs iterates over the signed VAAs matching a filter, using the time index if the filter sets a time range.
+func (s *nodePrivilegedService) iterateSignedVAAs(f *nodev1.SignedVAAFilter, fn func(id *db.VAAID, b []byte) error) error {
+	filter, err := signedVAAFilterFromProto(f)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if r := signedVAATimeRangeFromProto(f); !r.IsZero() {
+		return s.db.IterateSignedVAAsByTime(filter, r, fn)
+	}
+	return s.db.IterateSignedVAAs(filter, fn)
+}
+
+func (s *nodePrivilegedService) ListSignedVAAs(ctx context.Context, req *nodev1.ListSignedVAAsRequest) (*nodev1.ListSignedVAAsResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 100
+	}
+
+	resp := &nodev1.ListSignedVAAsResponse{}
+	err := s.iterateSignedVAAs(req.Filter, func(id *db.VAAID, b []byte) error {
+		if len(resp.Entries) == limit {
+			resp.Truncated = true
+			return db.ErrStopIteration
+		}
+
+		v, err := vaa.Unmarshal(b)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal VAA %s: %w", string(id.Bytes()), err)
+		}
+
+		resp.Entries = append(resp.Entries, &nodev1.ListSignedVAAsResponse_Entry{
+			MessageId:        v.MessageID(),
+			GuardianSetIndex: v.GuardianSetIndex,
+			NumSignatures:    uint32(len(v.Signatures)),
+			Timestamp:        uint32(v.Timestamp.Unix()),
+			Digest:           v.HexDigest(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) CountSignedVAAs(ctx context.Context, req *nodev1.CountSignedVAAsRequest) (*nodev1.CountSignedVAAsResponse, error) {
+	var count uint64
+	err := s.iterateSignedVAAs(req.Filter, func(id *db.VAAID, b []byte) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodev1.CountSignedVAAsResponse{
+		Count: count,
+	}, nil
+}
+
+// The number of VAAs sent per message by ExportSignedVAAs.
+const exportBatchSize = 100
+
+func (s *nodePrivilegedService) ExportSignedVAAs(req *nodev1.ExportSignedVAAsRequest, stream nodev1.NodePrivilegedService_ExportSignedVAAsServer) error {
+	batch := &nodev1.ExportSignedVAAsResponse{}
+	err := s.iterateSignedVAAs(req.Filter, func(id *db.VAAID, b []byte) error {
+		if err := stream.Context().Err(); err != nil {
+			return err
+		}
+
+		// The bytes are only valid during the callback.
+		batch.VaaBytes = append(batch.VaaBytes, append([]byte(nil), b...))
+		if req.IncludeMetadata {
+			m, err := s.observationMetadata(*id)
+			if err != nil {
+				return err
+			}
+			batch.Metadata = append(batch.Metadata, m)
+		}
+		if len(batch.VaaBytes) < exportBatchSize {
+			return nil
+		}
+
+		if err := stream.Send(batch); err != nil {
+			return err
+		}
+		batch = &nodev1.ExportSignedVAAsResponse{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch.VaaBytes) != 0 {
+		return stream.Send(batch)
+	}
+
+	return nil
+}
+
+// observationMetadata returns what this node recorded when it observed a message. Both the tx hash and the provenance
+// expire, so missing entries are left empty.
+func (s *nodePrivilegedService) observationMetadata(id db.VAAID) (*nodev1.ObservationMetadata, error) {
+	m := &nodev1.ObservationMetadata{}
+
+	txHash, err := s.db.GetMessageTxHash(id)
+	if err == nil {
+		m.TxHash = hex.EncodeToString(txHash)
+	} else if err != db.ErrTxHashNotFound {
+		return nil, status.Errorf(codes.Internal, "failed to look up tx hash: %v", err)
+	}
+
+	p, err := s.db.GetMessageProvenance(id)
+	if err == nil {
+		m.RpcEndpoint = p.RPCEndpoint
+		m.FetchLatencyMs = uint64(p.FetchLatency.Milliseconds())
+		m.Block = p.Block
+		m.WatcherVersion = p.WatcherVersion
+	} else if err != db.ErrProvenanceNotFound {
+		return nil, status.Errorf(codes.Internal, "failed to look up provenance: %v", err)
+	}
+
+	return m, nil
+}
+
+// The size of the chunks sent by BackupDatabase.
+const backupChunkSize = 1 << 20
+
+func (s *nodePrivilegedService) BackupDatabase(req *nodev1.BackupDatabaseRequest, stream nodev1.NodePrivilegedService_BackupDatabaseServer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		manifest, err := s.db.Backup(pw)
+		if err == nil {
+			s.logger.Info("database backup completed", zap.Uint64("signedVAAs", manifest.SignedVAAs))
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	buf := make([]byte, backupChunkSize)
+	for {
+		n, err := io.ReadFull(pr, buf)
+		if n != 0 {
+			if err := stream.Send(&nodev1.BackupDatabaseResponse{Data: append([]byte(nil), buf[:n]...)}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to back up database: %v", err)
+		}
+	}
+}
+
+// The default and maximum duration of the profiles recorded by Profile.
+const (
+	defaultProfileDuration = 30 * time.Second
+	maxProfileDuration     = 5 * time.Minute
+)
+
+// Set while a block or mutex profile is recorded, since their sampling rates are global.
+var recordingBlockProfile, recordingMutexProfile int32
+
+// writeProfile writes a runtime profile in the gzipped pprof format. The cpu, block and mutex profiles are recorded for
+// the given duration. The block and mutex profiles are only sampled while they are recorded, but their counts include
+// the samples of earlier recordings.
+func writeProfile(ctx context.Context, w io.Writer, profile string, duration time.Duration) error {
+	record := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(duration):
+			return nil
+		}
+	}
+
+	switch profile {
+	case "cpu":
+		if err := pprof.StartCPUProfile(w); err != nil {
+			return status.Errorf(codes.FailedPrecondition, "failed to start CPU profile: %v", err)
+		}
+		err := record()
+		pprof.StopCPUProfile()
+		return err
+	case "block":
+		if !atomic.CompareAndSwapInt32(&recordingBlockProfile, 0, 1) {
+			return status.Error(codes.FailedPrecondition, "a block profile is already being recorded")
+		}
+		defer atomic.StoreInt32(&recordingBlockProfile, 0)
+		runtime.SetBlockProfileRate(1)
+		err := record()
+		runtime.SetBlockProfileRate(0)
+		if err != nil {
+			return err
+		}
+	case "mutex":
+		if !atomic.CompareAndSwapInt32(&recordingMutexProfile, 0, 1) {
+			return status.Error(codes.FailedPrecondition, "a mutex profile is already being recorded")
+		}
+		defer atomic.StoreInt32(&recordingMutexProfile, 0)
+		previous := runtime.SetMutexProfileFraction(1)
+		err := record()
+		runtime.SetMutexProfileFraction(previous)
+		if err != nil {
+			return err
+		}
+	case "heap", "allocs", "goroutine":
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown profile %s, expected cpu, heap, allocs, goroutine, block or mutex", profile)
+	}
+
+	return pprof.Lookup(profile).WriteTo(w, 0)
+}
+
+func (s *nodePrivilegedService) Profile(req *nodev1.ProfileRequest, stream nodev1.NodePrivilegedService_ProfileServer) error {
+	duration := defaultProfileDuration
+	if req.Seconds != 0 {
+		duration = time.Duration(req.Seconds) * time.Second
+	}
+	if duration > maxProfileDuration {
+		return status.Errorf(codes.InvalidArgument, "profiles can be recorded for at most %v", maxProfileDuration)
+	}
+
+	var buf bytes.Buffer
+	if err := writeProfile(stream.Context(), &buf, req.Profile, duration); err != nil {
+		return err
+	}
+	s.logger.Info("sending runtime profile", zap.String("profile", req.Profile), zap.Int("size", buf.Len()))
+
+	for buf.Len() != 0 {
+		if err := stream.Send(&nodev1.ProfileResponse{Data: append([]byte(nil), buf.Next(backupChunkSize)...)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nodePrivilegedService) ExportSigningAuditLog(req *nodev1.ExportSigningAuditLogRequest, stream nodev1.NodePrivilegedService_ExportSigningAuditLogServer) error {
+	if s.auditLog == nil {
+		return status.Error(codes.FailedPrecondition, "the signing audit log is disabled")
+	}
+
+	r := s.auditLog.Reader()
+	buf := make([]byte, backupChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n != 0 {
+			if err := stream.Send(&nodev1.ExportSigningAuditLogResponse{Data: append([]byte(nil), buf[:n]...)}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to read signing audit log: %v", err)
+		}
+	}
+}
+
+func (s *nodePrivilegedService) VerifySigningAuditLog(ctx context.Context, req *nodev1.VerifySigningAuditLogRequest) (*nodev1.VerifySigningAuditLogResponse, error) {
+	if s.auditLog == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the signing audit log is disabled")
+	}
+
+	res, err := guardiansigner.VerifyAuditLog(s.auditLog.Reader())
+	resp := &nodev1.VerifySigningAuditLogResponse{
+		Entries:  res.Entries,
+		LastHash: res.LastHash,
+	}
+	if err != nil {
+		s.logger.Error("signing audit log is invalid", zap.Error(err))
+		resp.Error = err.Error()
+	}
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) SetFaultInjection(ctx context.Context, req *nodev1.SetFaultInjectionRequest) (*nodev1.SetFaultInjectionResponse, error) {
+	if !faultinject.Enabled {
+		return nil, status.Error(codes.FailedPrecondition, faultinject.ErrDisabled.Error())
+	}
+
+	c := faultinject.Config{
+		GossipDropPercent:   req.GossipDropPercent,
+		RPCDelay:            time.Duration(req.RpcDelayMs) * time.Millisecond,
+		MalformedRPCPercent: req.MalformedRpcPercent,
+	}
+	if err := faultinject.Set(c); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.logger.Warn("fault injection configured",
+		zap.Uint32("gossip_drop_percent", c.GossipDropPercent),
+		zap.Duration("rpc_delay", c.RPCDelay),
+		zap.Uint32("malformed_rpc_percent", c.MalformedRPCPercent))
+	return &nodev1.SetFaultInjectionResponse{}, nil
+}
+
+// watcherStatuses compares the height of each of our watchers with the highest height reported for its chain by the other guardians.
+func watcherStatuses(networks []*gossipv1.Heartbeat_Network, heartbeats map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat, ourAddr ethcommon.Address) []*nodev1.NodeStatusResponse_Watcher {
+	maxPeerHeights := make(map[uint32]int64)
+	for addr, hbs := range heartbeats {
+		if addr == ourAddr {
+			continue
+		}
+		for _, hb := range hbs {
+			for _, n := range hb.Networks {
+				if n.Height > maxPeerHeights[n.Id] {
+					maxPeerHeights[n.Id] = n.Height
+				}
+			}
+		}
+	}
+
+	watchers := make([]*nodev1.NodeStatusResponse_Watcher, 0, len(networks))
+	for _, n := range networks {
+		w := &nodev1.NodeStatusResponse_Watcher{
+			ChainId:       n.Id,
+			Height:        n.Height,
+			MaxPeerHeight: maxPeerHeights[n.Id],
+			ErrorCount:    n.ErrorCount,
+			Paused:        n.Paused,
+		}
+		if w.MaxPeerHeight > w.Height {
+			w.Lag = w.MaxPeerHeight - w.Height
+		}
+		watchers = append(watchers, w)
+	}
+
+	sort.Slice(watchers, func(i, j int) bool { return watchers[i].ChainId < watchers[j].ChainId })
+	return watchers
+}
+
+func (s *nodePrivilegedService) NodeStatus(ctx context.Context, req *nodev1.NodeStatusRequest) (*nodev1.NodeStatusResponse, error) {
+	guardianAddress := p2p.DefaultRegistry.GuardianAddress()
+	heartbeats := s.gst.GetAll()
+
+	resp := &nodev1.NodeStatusResponse{
+		GuardianAddress: guardianAddress,
+		Version:         version.Version(),
+		Watchers:        watcherStatuses(p2p.DefaultRegistry.NetworkStats(), heartbeats, ethcommon.HexToAddress(guardianAddress)),
+	}
+
+	for name, ready := range readiness.Status() {
+		resp.Readiness = append(resp.Readiness, &nodev1.NodeStatusResponse_ReadinessComponent{Name: name, Ready: ready})
+	}
+	sort.Slice(resp.Readiness, func(i, j int) bool { return resp.Readiness[i].Name < resp.Readiness[j].Name })
+
+	connected, gossip := p2p.DefaultRegistry.PeerCounts()
+	resp.P2P = &nodev1.NodeStatusResponse_P2P{
+		ConnectedPeers: uint32(connected),
+		GossipPeers:    uint32(gossip),
+	}
+	if gs := s.gst.Get(); gs != nil {
+		resp.P2P.GuardianSetIndex = gs.Index
+		resp.P2P.GuardianSetSize = uint32(len(gs.Keys))
+		for _, key := range gs.Keys {
+			if len(heartbeats[key]) != 0 {
+				resp.P2P.GuardiansSeen++
+			}
+		}
+	}
+
+	stats := s.db.Stats()
+	resp.Database = &nodev1.NodeStatusResponse_Database{
+		LsmSize:                 stats.LSMSize,
+		VlogSize:                stats.VlogSize,
+		Level0Tables:            uint32(stats.Level0Tables),
+		LevelsPendingCompaction: uint32(stats.LevelsPendingCompaction),
+	}
+
+	resp.Governor = &nodev1.NodeStatusResponse_Governor{}
+	if s.governor != nil {
+		resp.Governor.Enabled = true
+		resp.Governor.Chains = uint32(len(s.governor.GetAvailableNotionalByChain()))
+		resp.Governor.EnqueuedVaas = uint32(len(s.governor.GetEnqueuedVAAs()))
+	}
+
+	if s.acct != nil {
+		resp.Accountant = s.acct.Status()
+	} else {
+		resp.Accountant = &nodev1.NodeStatusResponse_Accountant{}
+	}
+
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) CompareChainHeights(ctx context.Context, req *nodev1.CompareChainHeightsRequest) (*nodev1.CompareChainHeightsResponse, error) {
+	if req.ChainId > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "invalid chain id")
+	}
+
+	chainID := vaa.ChainID(req.ChainId)
+	if chainID != vaa.ChainIDUnset && s.references[chainID] == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "no reference endpoints configured for %v", chainID)
+	}
+
+	heights := make(map[vaa.ChainID]int64)
+	for _, n := range p2p.DefaultRegistry.NetworkStats() {
+		heights[vaa.ChainID(n.Id)] = n.Height
+	}
+
+	// The endpoints of all chains are queried concurrently, so a slow endpoint only delays the response by its timeout.
+	c := &http.Client{Timeout: 5 * time.Second}
+	resp := &nodev1.CompareChainHeightsResponse{}
+	entries := make(chan *nodev1.CompareChainHeightsResponse_Entry)
+	count := 0
+	for id, ref := range s.references {
+		if chainID != vaa.ChainIDUnset && id != chainID {
+			continue
+		}
+		count++
+		go func(id vaa.ChainID, ref *referenceRPC) {
+			entries <- compareChainHeight(ctx, c, id, ref, heights[id])
+		}(id, ref)
+	}
+
+	for i := 0; i < count; i++ {
+		resp.Entries = append(resp.Entries, <-entries)
+	}
+	sort.Slice(resp.Entries, func(i, j int) bool { return resp.Entries[i].ChainId < resp.Entries[j].ChainId })
+
+	return resp, nil
+}
+
+func (s *nodePrivilegedService) RescanBlockRange(req *nodev1.RescanBlockRangeRequest, stream nodev1.NodePrivilegedService_RescanBlockRangeServer) error {
+	if req.ChainId > math.MaxUint16 {
+		return status.Error(codes.InvalidArgument, "invalid chain id")
+	}
+	chainID := vaa.ChainID(req.ChainId)
+	target, ok := s.rescanTargets[chainID]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "%v is not an EVM chain watched by this node", chainID)
+	}
+	if req.ToBlock < req.FromBlock {
+		return status.Error(codes.InvalidArgument, "the range must end after its start")
+	}
+
+	blocksPerRequest := req.BlocksPerRequest
+	if blocksPerRequest == 0 {
+		blocksPerRequest = defaultRescanBlocksPerRequest
+	}
+	requestsPerSecond := req.RequestsPerSecond
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultRescanRequestsPerSecond
+	}
+
+	// Rescans are not run concurrently, to spare the RPC endpoint.
+	if !atomic.CompareAndSwapInt32(&s.rescanRunning, 0, 1) {
+		return status.Error(codes.FailedPrecondition, "a rescan is already running")
+	}
+	defer atomic.StoreInt32(&s.rescanRunning, 0)
+
+	ctx := stream.Context()
+	client, err := ethereum.DialProvider(ctx, target.rpcURL, target.contract)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to connect to the RPC endpoint: %v", err)
+	}
+	defer client.Close()
+	// Requests are kept within the block range cap of the provider, so that each is paced by the limiter.
+	if m := client.Quirks.MaxLogRange; m != 0 && blocksPerRequest > m {
+		blocksPerRequest = m
+	}
+
+	obsvReqC := s.localObsvReqC
+	if req.Broadcast {
+		obsvReqC = s.obsvReqSendC
+	}
+
+	s.logger.Info("rescanning block range",
+		zap.Stringer("chain", chainID),
+		zap.Uint64("from_block", req.FromBlock),
+		zap.Uint64("to_block", req.ToBlock),
+		zap.Bool("broadcast", req.Broadcast))
+
+	err = ethereum.RescanBlockRange(ctx, client, target.contract, req.FromBlock, req.ToBlock, blocksPerRequest,
+		rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+		func(tx ethcommon.Hash) error {
+			// Waits for the watcher, rather than dropping the requests like PostObservationRequest.
+			select {
+			case obsvReqC <- &gossipv1.ObservationRequest{ChainId: uint32(chainID), TxHash: tx.Bytes()}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		func(p ethereum.RescanProgress) error {
+			return stream.Send(&nodev1.RescanBlockRangeResponse{
+				ScannedToBlock: p.ScannedTo,
+				BlocksScanned:  p.BlocksScanned,
+				BlocksTotal:    p.BlocksTotal,
+				Transactions:   p.Transactions,
+			})
+		})
+	if err != nil {
+		s.logger.Error("failed to rescan block range", zap.Stringer("chain", chainID), zap.Error(err))
+		return fmt.Errorf("failed to rescan block range: %w", err)
+	}
+
+	s.logger.Info("rescanned block range", zap.Stringer("chain", chainID), zap.Uint64("from_block", req.FromBlock), zap.Uint64("to_block", req.ToBlock))
+	return nil
+}
+
+// featureFlagStatuses lists the flags defined in r and the flags advertised in the heartbeats of the guardian set gs.
+func featureFlagStatuses(r *featureflags.Registry, gs *common.GuardianSet, heartbeats map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat) *nodev1.GetFeatureFlagsResponse {
+	resp := &nodev1.GetFeatureFlagsResponse{}
+	flags := make(map[string]*nodev1.GetFeatureFlagsResponse_Flag)
+	for _, f := range r.Flags() {
+		flags[f.Name] = &nodev1.GetFeatureFlagsResponse_Flag{
+			Name:        f.Name,
+			ChainId:     uint32(f.Chain),
+			Description: f.Description,
+			Enabled:     f.Enabled(),
+		}
+	}
+
+	if gs != nil {
+		resp.GuardianSetSize = uint32(len(gs.Keys))
+		for _, key := range gs.Keys {
+			if len(heartbeats[key]) == 0 {
+				continue
+			}
+			resp.GuardiansSeen++
+
+			// A guardian can run more than one node, which may be in the middle of a rollout.
+			advertised := make(map[string]bool)
+			for _, hb := range heartbeats[key] {
+				for _, name := range featureflags.FromHeartbeatFeatures(hb.Features) {
+					advertised[name] = true
+				}
+			}
+			for name := range advertised {
+				f, ok := flags[name]
+				if !ok {
+					f = &nodev1.GetFeatureFlagsResponse_Flag{Name: name}
+					flags[name] = f
+				}
+				f.Guardians = append(f.Guardians, key.Hex())
+			}
+		}
+	}
+
+	for _, f := range flags {
+		resp.Flags = append(resp.Flags, f)
+	}
+	sort.Slice(resp.Flags, func(i, j int) bool { return resp.Flags[i].Name < resp.Flags[j].Name })
+	return resp
+}
+
+func (s *nodePrivilegedService) GetFeatureFlags(ctx context.Context, req *nodev1.GetFeatureFlagsRequest) (*nodev1.GetFeatureFlagsResponse, error) {
+	return featureFlagStatuses(featureflags.DefaultRegistry, s.gst.Get(), s.gst.GetAll()), nil
+}
+
+// stuckMessages converts the messages waiting for quorum matching req, naming the missing guardians after their
+// heartbeats.
+func stuckMessages(messages []processor.StuckMessage, req *nodev1.ListStuckMessagesRequest, now time.Time, name func(ethcommon.Address) string) []*nodev1.ListStuckMessagesResponse_Message {
+	var res []*nodev1.ListStuckMessagesResponse_Message
+	for _, m := range messages {
+		if req.EmitterChain != 0 && uint32(m.EmitterChain) != req.EmitterChain {
+			continue
+		}
+		age := now.Sub(m.FirstObserved)
+		if age < 0 {
+			age = 0
+		}
+		if age < time.Duration(req.MinAgeSeconds)*time.Second {
+			continue
+		}
+
+		msg := &nodev1.ListStuckMessagesResponse_Message{
+			MessageId:        m.MessageID,
+			Digest:           m.Digest,
+			EmitterChain:     uint32(m.EmitterChain),
+			TxHash:           hex.EncodeToString(m.TxHash),
+			FirstObserved:    m.FirstObserved.Unix(),
+			AgeSeconds:       uint64(age / time.Second),
+			Retries:          uint32(m.Retries),
+			GuardianSetIndex: m.GuardianSetIndex,
+			Signatures:       uint32(len(m.Signed)),
+			Quorum:           uint32(m.Quorum),
+		}
+		for _, g := range m.Missing {
+			msg.Missing = append(msg.Missing, &nodev1.ListStuckMessagesResponse_Guardian{Address: g.Hex(), Name: name(g)})
+		}
+		res = append(res, msg)
+	}
+	return res
+}
+
+func (s *nodePrivilegedService) ListStuckMessages(ctx context.Context, req *nodev1.ListStuckMessagesRequest) (*nodev1.ListStuckMessagesResponse, error) {
+	if s.stuck == nil {
+		return nil, status.Error(codes.Unavailable, "the processor does not report stuck messages")
+	}
+	if req.EmitterChain > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "invalid emitter chain")
+	}
+
+	messages, updated := s.stuck.List()
+	resp := &nodev1.ListStuckMessagesResponse{
+		Messages: stuckMessages(messages, req, time.Now(), func(addr ethcommon.Address) string {
+			// Pick the first node if the guardian runs several.
+			for _, hb := range s.gst.LastHeartbeat(addr) {
+				return hb.NodeName
+			}
+			return ""
+		}),
+	}
+	if !updated.IsZero() {
+		resp.Updated = updated.Unix()
+	}
+	return resp, nil
+}
@@ -0,0 +1,688 @@
+package guardiand
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/featureflags"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	"github.com/certusone/wormhole/node/pkg/processor"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGovernanceMessageToVAAValidatesAddresses(t *testing.T) {
+	evmAddress := "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585"
+	solanaAddress := "ec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5"
+
+	registerChain := func(chainID vaa.ChainID, emitter string) *nodev1.GovernanceMessage {
+		return &nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_BridgeRegisterChain{
+				BridgeRegisterChain: &nodev1.BridgeRegisterChain{Module: "TokenBridge", ChainId: uint32(chainID), EmitterAddress: emitter},
+			},
+		}
+	}
+
+	_, err := governanceMessageToVAA(registerChain(vaa.ChainIDEthereum, evmAddress), time.Unix(0, 0), 0)
+	assert.NoError(t, err)
+
+	_, err = governanceMessageToVAA(registerChain(vaa.ChainIDSolana, solanaAddress), time.Unix(0, 0), 0)
+	assert.NoError(t, err)
+
+	// A 32 byte address is not valid on an EVM chain.
+	_, err = governanceMessageToVAA(registerChain(vaa.ChainIDEthereum, solanaAddress), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+
+	// New chain IDs can be registered, but not with a zero address.
+	_, err = governanceMessageToVAA(registerChain(vaa.ChainID(60000), solanaAddress), time.Unix(0, 0), 0)
+	assert.NoError(t, err)
+
+	_, err = governanceMessageToVAA(registerChain(vaa.ChainID(60000), "0000000000000000000000000000000000000000000000000000000000000000"), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+
+	_, err = governanceMessageToVAA(registerChain(vaa.ChainIDUnset, solanaAddress), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+}
+
+func TestGovernanceMessageToVAAFees(t *testing.T) {
+	v, err := governanceMessageToVAA(&nodev1.GovernanceMessage{
+		Payload: &nodev1.GovernanceMessage_SetMessageFee{
+			SetMessageFee: &nodev1.SetMessageFee{ChainId: uint32(vaa.ChainIDEthereum), MessageFee: "1000"},
+		},
+	}, time.Unix(0, 0), 0)
+	require.NoError(t, err)
+	assert.Equal(t, vaa.BodySetMessageFee{ChainID: vaa.ChainIDEthereum, MessageFee: big.NewInt(1000)}.Serialize(), v.Payload)
+
+	for _, fee := range []string{"", "-1", "0x10", "115792089237316195423570985008687907853269984665640564039457584007913129639936"} {
+		_, err = governanceMessageToVAA(&nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_SetMessageFee{
+				SetMessageFee: &nodev1.SetMessageFee{ChainId: uint32(vaa.ChainIDEthereum), MessageFee: fee},
+			},
+		}, time.Unix(0, 0), 0)
+		assert.Error(t, err, fee)
+	}
+
+	transferFees := func(recipient string) *nodev1.GovernanceMessage {
+		return &nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_TransferFees{
+				TransferFees: &nodev1.TransferFees{ChainId: uint32(vaa.ChainIDBSC), Amount: "1000", Recipient: recipient},
+			},
+		}
+	}
+
+	_, err = governanceMessageToVAA(transferFees("0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585"), time.Unix(0, 0), 0)
+	assert.NoError(t, err)
+
+	_, err = governanceMessageToVAA(transferFees("ec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5"), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+}
+
+func TestGovernanceMessageToVAAContractRegistry(t *testing.T) {
+	codeHash := "abababababababababababababababababababababababababababababababab"
+	registry := func(entries ...*nodev1.ContractRegistry_Entry) *nodev1.GovernanceMessage {
+		return &nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_ContractRegistry{
+				ContractRegistry: &nodev1.ContractRegistry{Version: 2, Entries: entries},
+			},
+		}
+	}
+
+	v, err := governanceMessageToVAA(registry(&nodev1.ContractRegistry_Entry{
+		ChainId:      uint32(vaa.ChainIDBSC),
+		Core:         "3ee18b2214aff97000d974cf647e7c347e8fa585",
+		CoreCodeHash: codeHash,
+	}), time.Unix(0, 0), 0)
+	require.NoError(t, err)
+	var hash [32]byte
+	copy(hash[:], ethcommon.FromHex(codeHash))
+	assert.Equal(t, vaa.BodyContractRegistry{
+		Version: 2,
+		Entries: []vaa.ContractRegistryEntry{{
+			ChainID:      vaa.ChainIDBSC,
+			Core:         vaa.Address(ethcommon.HexToHash("3ee18b2214aff97000d974cf647e7c347e8fa585")),
+			CoreCodeHash: hash,
+		}},
+	}.Serialize(), v.Payload)
+
+	for _, e := range []*nodev1.ContractRegistry_Entry{
+		// Not an EVM chain.
+		{ChainId: uint32(vaa.ChainIDSolana), Core: "3ee18b2214aff97000d974cf647e7c347e8fa585", CoreCodeHash: codeHash},
+		// 32 byte address.
+		{ChainId: uint32(vaa.ChainIDBSC), Core: "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585", CoreCodeHash: codeHash},
+		// Missing code hash.
+		{ChainId: uint32(vaa.ChainIDBSC), Core: "3ee18b2214aff97000d974cf647e7c347e8fa585"},
+	} {
+		_, err = governanceMessageToVAA(registry(e), time.Unix(0, 0), 0)
+		assert.Error(t, err, e.String())
+	}
+
+	bsc := &nodev1.ContractRegistry_Entry{ChainId: uint32(vaa.ChainIDBSC), Core: "3ee18b2214aff97000d974cf647e7c347e8fa585", CoreCodeHash: codeHash}
+	_, err = governanceMessageToVAA(registry(bsc, bsc), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+
+	_, err = governanceMessageToVAA(registry(), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+}
+
+func TestGovernanceMessageToVAANetworkConfig(t *testing.T) {
+	config := func(version uint64, features ...*nodev1.NetworkConfig_Feature) *nodev1.GovernanceMessage {
+		return &nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_NetworkConfig{
+				NetworkConfig: &nodev1.NetworkConfig{Version: version, Features: features},
+			},
+		}
+	}
+
+	v, err := governanceMessageToVAA(config(2, &nodev1.NetworkConfig_Feature{Name: "batch", ActivationTime: 1700000000}), time.Unix(0, 0), 0)
+	require.NoError(t, err)
+	assert.Equal(t, vaa.BodyNetworkConfig{
+		Version:  2,
+		Features: []vaa.NetworkConfigFeature{{Name: "batch", ActivationTime: 1700000000}},
+	}.Serialize(), v.Payload)
+
+	// A configuration without features deactivates all of them.
+	_, err = governanceMessageToVAA(config(3), time.Unix(0, 0), 0)
+	assert.NoError(t, err)
+
+	_, err = governanceMessageToVAA(config(0), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+
+	for _, f := range []*nodev1.NetworkConfig_Feature{
+		{Name: ""},
+		{Name: "a feature name longer than 32 bytes"},
+		{Name: "\x00batch"},
+		{Name: "batch", ActivationTime: math.MaxUint64},
+	} {
+		_, err = governanceMessageToVAA(config(2, f), time.Unix(0, 0), 0)
+		assert.Error(t, err, f.String())
+	}
+
+	batch := &nodev1.NetworkConfig_Feature{Name: "batch"}
+	_, err = governanceMessageToVAA(config(2, batch, batch), time.Unix(0, 0), 0)
+	assert.Error(t, err)
+}
+
+func TestGovernanceMessageToVAAAccountantModifyBalance(t *testing.T) {
+	tokenAddress := "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585"
+	modify := func(m *nodev1.AccountantModifyBalance) *nodev1.GovernanceMessage {
+		return &nodev1.GovernanceMessage{
+			Payload: &nodev1.GovernanceMessage_AccountantModifyBalance{AccountantModifyBalance: m},
+		}
+	}
+	valid := func() *nodev1.AccountantModifyBalance {
+		return &nodev1.AccountantModifyBalance{
+			Sequence:     7,
+			ChainId:      uint32(vaa.ChainIDSolana),
+			TokenChain:   uint32(vaa.ChainIDEthereum),
+			TokenAddress: tokenAddress,
+			Kind:         nodev1.AccountantModifyBalance_MODIFICATION_KIND_SUBTRACT,
+			Amount:       "1000",
+			Reason:       "invalid transfer",
+		}
+	}
+
+	v, err := governanceMessageToVAA(modify(valid()), time.Unix(0, 0), 0)
+	require.NoError(t, err)
+	assert.Equal(t, vaa.BodyAccountantModifyBalance{
+		Sequence:     7,
+		ChainID:      vaa.ChainIDSolana,
+		TokenChain:   vaa.ChainIDEthereum,
+		TokenAddress: vaa.Address(ethcommon.HexToHash(tokenAddress)),
+		Kind:         vaa.ModificationKindSubtract,
+		Amount:       big.NewInt(1000),
+		Reason:       "invalid transfer",
+	}.Serialize(), v.Payload)
+
+	for _, invalidate := range []func(m *nodev1.AccountantModifyBalance){
+		func(m *nodev1.AccountantModifyBalance) { m.ChainId = 0 },
+		func(m *nodev1.AccountantModifyBalance) { m.TokenChain = 1 << 16 },
+		func(m *nodev1.AccountantModifyBalance) { m.TokenAddress = "3ee18b2214aff97000d974cf647e7c347e8fa585" },
+		func(m *nodev1.AccountantModifyBalance) {
+			m.Kind = nodev1.AccountantModifyBalance_MODIFICATION_KIND_UNSPECIFIED
+		},
+		func(m *nodev1.AccountantModifyBalance) { m.Amount = "0" },
+		func(m *nodev1.AccountantModifyBalance) { m.Amount = "-1" },
+		func(m *nodev1.AccountantModifyBalance) { m.Reason = "" },
+		func(m *nodev1.AccountantModifyBalance) { m.Reason = "this reason is longer than 32 bytes" },
+	} {
+		m := valid()
+		invalidate(m)
+		_, err = governanceMessageToVAA(modify(m), time.Unix(0, 0), 0)
+		assert.Error(t, err, m.String())
+	}
+}
+
+func TestWatcherStatuses(t *testing.T) {
+	ourAddr := ethcommon.Address{1}
+	networks := []*gossipv1.Heartbeat_Network{
+		{Id: uint32(vaa.ChainIDSolana), Height: 90, ErrorCount: 3},
+		{Id: uint32(vaa.ChainIDEthereum), Height: 100, Paused: true},
+		{Id: uint32(vaa.ChainIDAptos), Height: 50},
+	}
+
+	heartbeats := map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat{
+		// Our own heartbeat is not compared against.
+		ourAddr: {"a": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 1000}}}},
+		{2}:     {"b": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 95}, {Id: uint32(vaa.ChainIDEthereum), Height: 99}}}},
+		{3}:     {"c": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 97}}}},
+	}
+
+	watchers := watcherStatuses(networks, heartbeats, ourAddr)
+	require.Equal(t, 3, len(watchers))
+
+	assert.Equal(t, uint32(vaa.ChainIDSolana), watchers[0].ChainId)
+	assert.Equal(t, int64(97), watchers[0].MaxPeerHeight)
+	assert.Equal(t, int64(7), watchers[0].Lag)
+	assert.Equal(t, uint64(3), watchers[0].ErrorCount)
+
+	// Being ahead of the other guardians is not a lag.
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), watchers[1].ChainId)
+	assert.Equal(t, int64(0), watchers[1].Lag)
+	assert.True(t, watchers[1].Paused)
+
+	// No other guardian reports the chain.
+	assert.Equal(t, uint32(vaa.ChainIDAptos), watchers[2].ChainId)
+	assert.Equal(t, int64(0), watchers[2].MaxPeerHeight)
+	assert.Equal(t, int64(0), watchers[2].Lag)
+}
+
+func TestPeerMedianHeights(t *testing.T) {
+	ourAddr := ethcommon.Address{1}
+	heartbeats := map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat{
+		ourAddr: {"a": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 1000}}}},
+		// A guardian running two nodes counts once, with its highest height.
+		{2}: {
+			"b": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 95}}},
+			"c": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 96}}},
+		},
+		{3}: {"d": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 97}, {Id: uint32(vaa.ChainIDEthereum), Height: 50}}}},
+		// An outlier does not move the median.
+		{4}: {"e": {Networks: []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDSolana), Height: 1000000}}}},
+	}
+
+	medians := peerMedianHeights(heartbeats, ourAddr)
+	assert.Equal(t, map[uint32]int64{uint32(vaa.ChainIDSolana): 97, uint32(vaa.ChainIDEthereum): 50}, medians)
+}
+
+func TestInjectSignedGovernanceVAA(t *testing.T) {
+	gk, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	p2p.DefaultRegistry.SetGuardianAddress(ethcrypto.PubkeyToAddress(gk.PublicKey).Hex())
+
+	injectC := make(chan *vaa.VAA, 1)
+	s := &nodePrivilegedService{injectC: injectC, logger: zap.NewNop()}
+
+	v := vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, vaa.BodySetMessageFee{ChainID: vaa.ChainIDEthereum, MessageFee: big.NewInt(1)}.Serialize())
+	b, err := v.Marshal()
+	require.NoError(t, err)
+
+	// The exported VAA is decoded and signed offline.
+	unsigned, err := decodeUnsignedGovernanceVAA(hex.EncodeToString(b))
+	require.NoError(t, err)
+	sig, err := signGovernanceVAA(context.Background(), unsigned, guardiansigner.NewLocalSigner(gk))
+	require.NoError(t, err)
+
+	otherSig, err := signGovernanceVAA(context.Background(), unsigned, guardiansigner.NewLocalSigner(otherKey))
+	require.NoError(t, err)
+	_, err = s.InjectSignedGovernanceVAA(context.Background(), &nodev1.InjectSignedGovernanceVAARequest{Vaa: b, Signature: otherSig})
+	assert.Error(t, err)
+
+	resp, err := s.InjectSignedGovernanceVAA(context.Background(), &nodev1.InjectSignedGovernanceVAARequest{Vaa: b, Signature: sig})
+	require.NoError(t, err)
+	assert.Equal(t, v.SigningMsg().Bytes(), resp.Digest)
+
+	injected := <-injectC
+	require.Equal(t, 1, len(injected.Signatures))
+	assert.Equal(t, sig, injected.Signatures[0].Signature[:])
+
+	// Signed VAAs and non-governance VAAs are refused.
+	v.AddSignature(gk, 0)
+	signed, err := v.Marshal()
+	require.NoError(t, err)
+	_, err = decodeUnsignedGovernanceVAA(hex.EncodeToString(signed))
+	assert.Error(t, err)
+	_, err = s.InjectSignedGovernanceVAA(context.Background(), &nodev1.InjectSignedGovernanceVAARequest{Vaa: signed, Signature: sig})
+	assert.Error(t, err)
+
+	other := &vaa.VAA{Version: vaa.SupportedVAAVersion, EmitterChain: vaa.ChainIDEthereum, Payload: []byte{1}}
+	b, err = other.Marshal()
+	require.NoError(t, err)
+	_, err = decodeUnsignedGovernanceVAA(hex.EncodeToString(b))
+	assert.Error(t, err)
+}
+
+func TestGuardianAvailability(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	guardian := ethcommon.HexToAddress("0xbeFA429d57cD18b7F8A4d91A2da9AB4AF05d0FBe")
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	require.NoError(t, database.StoreGuardianAvailability([]*db.GuardianAvailability{
+		{Day: today, Guardian: guardian, Chain: vaa.ChainIDEthereum, Samples: 10, Available: 9},
+		{Day: today.AddDate(0, 0, -1), Guardian: guardian, Chain: vaa.ChainIDEthereum, Samples: 10, Available: 5},
+		{Day: today.AddDate(0, 0, -2), Guardian: guardian, Chain: vaa.ChainIDEthereum, Samples: 10, Available: 0},
+	}))
+
+	s := &nodePrivilegedService{db: database}
+	resp, err := s.GuardianAvailability(context.Background(), &nodev1.GuardianAvailabilityRequest{Days: 2})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Entries))
+	assert.Equal(t, guardian.Hex(), resp.Entries[0].GuardianAddr)
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), resp.Entries[0].ChainId)
+	assert.Equal(t, uint32(20), resp.Entries[0].Samples)
+	assert.Equal(t, uint32(14), resp.Entries[0].Available)
+
+	resp, err = s.GuardianAvailability(context.Background(), &nodev1.GuardianAvailabilityRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(30), resp.Entries[0].Samples)
+
+	_, err = s.GuardianAvailability(context.Background(), &nodev1.GuardianAvailabilityRequest{Days: 365})
+	assert.Error(t, err)
+}
+
+func TestDrainShutdown(t *testing.T) {
+	_, err := (&nodePrivilegedService{}).DrainShutdown(context.Background(), &nodev1.DrainShutdownRequest{})
+	assert.Error(t, err)
+
+	drain := common.NewDrain()
+	s := &nodePrivilegedService{logger: zap.NewNop(), drain: drain}
+	resp, err := s.DrainShutdown(context.Background(), &nodev1.DrainShutdownRequest{})
+	require.NoError(t, err)
+	assert.False(t, resp.AlreadyDraining)
+	assert.True(t, drain.IsStarted())
+
+	resp, err = s.DrainShutdown(context.Background(), &nodev1.DrainShutdownRequest{})
+	require.NoError(t, err)
+	assert.True(t, resp.AlreadyDraining)
+}
+
+// fixedValuer values all transfers at the same notional value.
+type fixedValuer uint64
+
+func (v fixedValuer) TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool) {
+	return uint64(v), true
+}
+
+func TestCircuitBreakerResumeSigning(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	resp, err := (&nodePrivilegedService{}).CircuitBreakerStatus(context.Background(), &nodev1.CircuitBreakerStatusRequest{})
+	require.NoError(t, err)
+	assert.False(t, resp.Enabled)
+	_, err = (&nodePrivilegedService{}).ResumeSigning(context.Background(), &nodev1.ResumeSigningRequest{ChainId: uint32(vaa.ChainIDBSC)})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	breaker, err := processor.NewCircuitBreaker(processor.CircuitBreakerConfig{
+		NewEmitterMinValue: 1000,
+		Valuer:             fixedValuer(5000),
+		History:            database,
+	})
+	require.NoError(t, err)
+	s := &nodePrivilegedService{logger: zap.NewNop(), breaker: breaker}
+
+	payload := make([]byte, 101)
+	payload[0] = 1
+	k := &common.MessagePublication{EmitterChain: vaa.ChainIDBSC, EmitterAddress: vaa.Address{1}, Sequence: 7, Payload: payload}
+	allowed, trip := breaker.Check(k, time.Now())
+	assert.False(t, allowed)
+	require.NotNil(t, trip)
+
+	resp, err = s.CircuitBreakerStatus(context.Background(), &nodev1.CircuitBreakerStatusRequest{})
+	require.NoError(t, err)
+	require.True(t, resp.Enabled)
+	require.Equal(t, 1, len(resp.Entries))
+	assert.True(t, resp.Entries[0].Paused)
+	assert.Equal(t, processor.BreakerReasonNewEmitter, resp.Entries[0].LastTripReason)
+	assert.Equal(t, k.MessageIDString(), resp.Entries[0].LastTripMessageId)
+
+	_, err = s.ResumeSigning(context.Background(), &nodev1.ResumeSigningRequest{ChainId: uint32(vaa.ChainIDBSC)})
+	require.NoError(t, err)
+	allowed, _ = breaker.Check(k, time.Now())
+	assert.True(t, allowed)
+
+	// The chain is no longer paused.
+	_, err = s.ResumeSigning(context.Background(), &nodev1.ResumeSigningRequest{ChainId: uint32(vaa.ChainIDBSC)})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = s.ResumeSigning(context.Background(), &nodev1.ResumeSigningRequest{ChainId: math.MaxUint16 + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetMessageProvenance(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	emitter := vaa.Address{1}
+	id := db.VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: emitter, Sequence: 42}
+	require.NoError(t, database.StoreMessageProvenance(id, &common.Provenance{
+		RPCEndpoint:    "ws://eth-devnet:8545",
+		FetchLatency:   1500 * time.Millisecond,
+		Block:          "0x1234",
+		WatcherVersion: "v2.8.9",
+	}))
+
+	s := &nodePrivilegedService{db: database}
+	resp, err := s.GetMessageProvenance(context.Background(), &nodev1.GetMessageProvenanceRequest{
+		EmitterChain:   uint32(vaa.ChainIDEthereum),
+		EmitterAddress: emitter.String(),
+		Sequence:       42,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ws://eth-devnet:8545", resp.RpcEndpoint)
+	assert.Equal(t, uint64(1500), resp.FetchLatencyMs)
+	assert.Equal(t, "0x1234", resp.Block)
+	assert.Equal(t, "v2.8.9", resp.WatcherVersion)
+
+	_, err = s.GetMessageProvenance(context.Background(), &nodev1.GetMessageProvenanceRequest{
+		EmitterChain:   uint32(vaa.ChainIDEthereum),
+		EmitterAddress: emitter.String(),
+		Sequence:       43,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPurgeAndResignVAA(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	gk, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	gst := common.NewGuardianSetState()
+	obsvReqSendC := make(chan *gossipv1.ObservationRequest, 1)
+	s := &nodePrivilegedService{db: database, gst: gst, obsvReqSendC: obsvReqSendC, logger: zap.NewNop()}
+
+	emitter := vaa.Address{1}
+	store := func(sequence uint64, guardianSetIndex uint32) db.VAAID {
+		v := &vaa.VAA{Version: vaa.SupportedVAAVersion, GuardianSetIndex: guardianSetIndex, EmitterChain: vaa.ChainIDEthereum, EmitterAddress: emitter, Sequence: sequence, Payload: []byte{1}}
+		v.AddSignature(gk, 0)
+		require.NoError(t, database.StoreSignedVAA(v))
+		return db.VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: emitter, Sequence: sequence}
+	}
+	req := func(sequence uint64, txHash string) *nodev1.PurgeAndResignVAARequest {
+		return &nodev1.PurgeAndResignVAARequest{EmitterChain: uint32(vaa.ChainIDEthereum), EmitterAddress: emitter.String(), Sequence: sequence, TxHash: txHash}
+	}
+
+	old := store(1, 0)
+	current := store(2, 1)
+	txHash := ethcommon.HexToHash("0x01")
+	require.NoError(t, database.StoreMessageTxHash(old, txHash.Bytes()))
+
+	_, err = s.PurgeAndResignVAA(context.Background(), req(1, ""))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	gst.Set(&common.GuardianSet{Index: 1, Keys: []ethcommon.Address{ethcrypto.PubkeyToAddress(gk.PublicKey)}})
+
+	_, err = s.PurgeAndResignVAA(context.Background(), req(3, ""))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// VAAs signed by the current guardian set are kept.
+	_, err = s.PurgeAndResignVAA(context.Background(), req(2, ""))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = database.GetSignedVAABytes(current)
+	assert.NoError(t, err)
+
+	resp, err := s.PurgeAndResignVAA(context.Background(), req(1, ""))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), resp.PurgedGuardianSetIndex)
+	assert.Equal(t, uint32(1), resp.CurrentGuardianSetIndex)
+	assert.Equal(t, hex.EncodeToString(txHash.Bytes()), resp.TxHash)
+
+	// The old VAA is kept until the processor stores the VAA of the current guardian set.
+	_, err = database.GetSignedVAABytes(old)
+	assert.NoError(t, err)
+
+	obsvReq := <-obsvReqSendC
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), obsvReq.ChainId)
+	assert.Equal(t, txHash.Bytes(), obsvReq.TxHash)
+
+	// Without a stored tx hash, the message is only reobserved if the tx hash is given.
+	expired := store(4, 0)
+	_, err = s.PurgeAndResignVAA(context.Background(), req(4, ""))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = database.GetSignedVAABytes(expired)
+	assert.NoError(t, err)
+
+	_, err = s.PurgeAndResignVAA(context.Background(), req(4, "02"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2}, (<-obsvReqSendC).TxHash)
+
+	// The VAA is kept if the observation request cannot be sent.
+	full := store(5, 0)
+	obsvReqSendC <- &gossipv1.ObservationRequest{}
+	_, err = s.PurgeAndResignVAA(context.Background(), req(5, "05"))
+	assert.Error(t, err)
+	_, err = database.GetSignedVAABytes(full)
+	assert.NoError(t, err)
+}
+
+func TestObservationMetadata(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	id := db.VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 42}
+	require.NoError(t, database.StoreMessageTxHash(id, []byte{1, 2}))
+	require.NoError(t, database.StoreMessageProvenance(id, &common.Provenance{RPCEndpoint: "ws://eth-devnet:8545", FetchLatency: time.Second, Block: "0x1234"}))
+
+	s := &nodePrivilegedService{db: database}
+	m, err := s.observationMetadata(id)
+	require.NoError(t, err)
+	assert.Equal(t, "0102", m.TxHash)
+	assert.Equal(t, "ws://eth-devnet:8545", m.RpcEndpoint)
+	assert.Equal(t, uint64(1000), m.FetchLatencyMs)
+	assert.Equal(t, "0x1234", m.Block)
+
+	// Messages observed by other guardians, or too long ago, have no metadata.
+	id.Sequence = 43
+	m, err = s.observationMetadata(id)
+	require.NoError(t, err)
+	assert.Equal(t, &nodev1.ObservationMetadata{}, m)
+}
+
+func TestWriteProfile(t *testing.T) {
+	gzipMagic := []byte{0x1f, 0x8b}
+	for _, profile := range []string{"heap", "goroutine", "cpu", "block", "mutex"} {
+		var buf bytes.Buffer
+		require.NoError(t, writeProfile(context.Background(), &buf, profile, 10*time.Millisecond), profile)
+		assert.Equal(t, gzipMagic, buf.Bytes()[:2], profile)
+	}
+
+	err := writeProfile(context.Background(), &bytes.Buffer{}, "threadcreate", time.Millisecond)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Only one CPU profile can be recorded at a time.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- writeProfile(ctx, &bytes.Buffer{}, "cpu", time.Minute) }()
+	require.Eventually(t, func() bool {
+		err := writeProfile(context.Background(), &bytes.Buffer{}, "cpu", time.Millisecond)
+		return status.Code(err) == codes.FailedPrecondition
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRescanBlockRangeInvalidArguments(t *testing.T) {
+	s := &nodePrivilegedService{
+		logger:        zap.NewNop(),
+		rescanTargets: map[vaa.ChainID]evmRescanTarget{vaa.ChainIDEthereum: {rpcURL: "http://127.0.0.1:1"}},
+	}
+
+	for _, req := range []*nodev1.RescanBlockRangeRequest{
+		{ChainId: math.MaxUint16 + 1, FromBlock: 1, ToBlock: 2},
+		{ChainId: uint32(vaa.ChainIDSolana), FromBlock: 1, ToBlock: 2},
+		{ChainId: uint32(vaa.ChainIDEthereum), FromBlock: 2, ToBlock: 1},
+	} {
+		err := s.RescanBlockRange(req, nil)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+	}
+}
+
+func TestFeatureFlagStatuses(t *testing.T) {
+	r := featureflags.NewRegistry()
+	r.Define("aptos_streaming", vaa.ChainIDAptos, "Stream Aptos events")
+	r.Define("batch_vaa", vaa.ChainIDUnset, "Sign batch VAAs")
+	require.NoError(t, r.Enable([]string{"aptos_streaming"}))
+
+	gs := &common.GuardianSet{Keys: []ethcommon.Address{{1}, {2}, {3}, {4}}}
+	heartbeats := map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat{
+		{1}: {"a": {Features: []string{"governor", "flag:aptos_streaming"}}},
+		// Two nodes of the same guardian, one of them upgraded already.
+		{2}: {
+			"b1": {Features: []string{"flag:aptos_streaming", "flag:new_flag"}},
+			"b2": {Features: []string{"flag:aptos_streaming"}},
+		},
+		{3}: {"c": {Features: []string{"degraded:memory"}}},
+		// Not a member of the guardian set.
+		{9}: {"z": {Features: []string{"flag:batch_vaa"}}},
+	}
+
+	resp := featureFlagStatuses(r, gs, heartbeats)
+	assert.Equal(t, uint32(4), resp.GuardianSetSize)
+	assert.Equal(t, uint32(3), resp.GuardiansSeen)
+	require.Len(t, resp.Flags, 3)
+
+	assert.Equal(t, "aptos_streaming", resp.Flags[0].Name)
+	assert.Equal(t, uint32(vaa.ChainIDAptos), resp.Flags[0].ChainId)
+	assert.True(t, resp.Flags[0].Enabled)
+	assert.Equal(t, []string{ethcommon.Address{1}.Hex(), ethcommon.Address{2}.Hex()}, resp.Flags[0].Guardians)
+
+	assert.Equal(t, "batch_vaa", resp.Flags[1].Name)
+	assert.False(t, resp.Flags[1].Enabled)
+	assert.Empty(t, resp.Flags[1].Guardians)
+
+	// Advertised by another guardian, but unknown to this node.
+	assert.Equal(t, "new_flag", resp.Flags[2].Name)
+	assert.Empty(t, resp.Flags[2].Description)
+	assert.Equal(t, []string{ethcommon.Address{2}.Hex()}, resp.Flags[2].Guardians)
+}
+
+func TestStuckMessages(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	messages := []processor.StuckMessage{
+		{
+			MessageID:     "2/0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585/1",
+			EmitterChain:  vaa.ChainIDEthereum,
+			TxHash:        []byte{0xab},
+			FirstObserved: now.Add(-10 * time.Minute),
+			Retries:       19,
+			Signed:        []ethcommon.Address{{1}, {2}},
+			Missing:       []ethcommon.Address{{3}, {4}},
+			Quorum:        3,
+		},
+		{
+			MessageID:     "1/ec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5/7",
+			EmitterChain:  vaa.ChainIDSolana,
+			FirstObserved: now.Add(-time.Minute),
+			Missing:       []ethcommon.Address{{1}},
+			Quorum:        1,
+		},
+	}
+	names := func(addr ethcommon.Address) string {
+		if addr == (ethcommon.Address{3}) {
+			return "guardian-3"
+		}
+		return ""
+	}
+
+	res := stuckMessages(messages, &nodev1.ListStuckMessagesRequest{}, now, names)
+	require.Len(t, res, 2)
+	assert.Equal(t, uint64(600), res[0].AgeSeconds)
+	assert.Equal(t, "ab", res[0].TxHash)
+	assert.Equal(t, uint32(2), res[0].Signatures)
+	assert.Equal(t, []*nodev1.ListStuckMessagesResponse_Guardian{
+		{Address: ethcommon.Address{3}.Hex(), Name: "guardian-3"},
+		{Address: ethcommon.Address{4}.Hex()},
+	}, res[0].Missing)
+
+	res = stuckMessages(messages, &nodev1.ListStuckMessagesRequest{MinAgeSeconds: 300}, now, names)
+	require.Len(t, res, 1)
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), res[0].EmitterChain)
+
+	res = stuckMessages(messages, &nodev1.ListStuckMessagesRequest{EmitterChain: uint32(vaa.ChainIDSolana)}, now, names)
+	require.Len(t, res, 1)
+	assert.Equal(t, uint32(vaa.ChainIDSolana), res[0].EmitterChain)
+}
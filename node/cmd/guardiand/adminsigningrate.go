@@ -0,0 +1,101 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/processor"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+)
+
+var AdminClientSigningRateLimitStatusCmd = &cobra.Command{
+	Use:   "signing-rate-limit-status",
+	Short: "Prints the signing rate of each chain, its limit and the messages dropped over it",
+	Run:   runSigningRateLimitStatus,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runSigningRateLimitStatus(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.SigningRateLimitStatus(ctx, &nodev1.SigningRateLimitStatusRequest{})
+	if err != nil {
+		log.Fatalf("failed to run SigningRateLimitStatus RPC: %s", err)
+	}
+
+	if !resp.Enabled {
+		fmt.Println("The signing rate limiter is disabled")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "chain\tlimit/min\tlast minute\tdropped\tlast dropped\t")
+	for _, e := range resp.Entries {
+		limit := "unlimited"
+		if e.LimitPerMinute != 0 {
+			limit = strconv.FormatUint(e.LimitPerMinute, 10)
+		}
+		lastDropped := "-"
+		if e.LastDropped != 0 {
+			lastDropped = fmt.Sprintf("%s ago", time.Since(time.Unix(e.LastDropped, 0)).Truncate(time.Second))
+		}
+		fmt.Fprintf(w, "%v\t%s\t%d\t%d\t%s\t\n", vaa.ChainID(e.ChainId), limit, e.LastMinute, e.Dropped, lastDropped)
+	}
+	w.Flush()
+}
+
+// parseSigningRateLimits builds the signing rate limits from the node flags. Overrides are specified as chain=limit,
+// and bypassed emitters as chain/emitter, in addition to the known emitters of the network.
+func parseSigningRateLimits(perMinute uint64, overrides []string, bypass []string, knownEmitters []common.EmitterInfo) (processor.SigningRateLimits, error) {
+	limits := processor.SigningRateLimits{
+		DefaultPerMinute: perMinute,
+		PerMinute:        make(map[vaa.ChainID]uint64),
+		Bypass:           append([]common.EmitterInfo{}, knownEmitters...),
+	}
+
+	for _, o := range overrides {
+		parts := strings.Split(o, "=")
+		if len(parts) != 2 {
+			return limits, fmt.Errorf("invalid override %s, expected chain=limit", o)
+		}
+		chainID, err := parseChainID(parts[0])
+		if err != nil {
+			return limits, fmt.Errorf("invalid chain in override %s: %w", o, err)
+		}
+		n, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid limit in override %s: %w", o, err)
+		}
+		limits.PerMinute[chainID] = n
+	}
+
+	for _, b := range bypass {
+		parts := strings.Split(b, "/")
+		if len(parts) != 2 {
+			return limits, fmt.Errorf("invalid bypass emitter %s, expected chain/emitter", b)
+		}
+		chainID, err := parseChainID(parts[0])
+		if err != nil {
+			return limits, fmt.Errorf("invalid chain in bypass emitter %s: %w", b, err)
+		}
+		limits.Bypass = append(limits.Bypass, common.EmitterInfo{ChainID: chainID, Emitter: strings.TrimPrefix(parts[1], "0x")})
+	}
+
+	return limits, nil
+}
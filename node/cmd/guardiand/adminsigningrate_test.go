@@ -0,0 +1,53 @@
+package guardiand
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/processor"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSigningRateLimits(t *testing.T) {
+	emitter := "0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16"
+	limits, err := parseSigningRateLimits(100, []string{"bsc=10", "1=0"}, []string{"ethereum/0x" + emitter}, common.KnownDevnetEmitters)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), limits.DefaultPerMinute)
+	assert.Equal(t, map[vaa.ChainID]uint64{vaa.ChainIDBSC: 10, vaa.ChainIDSolana: 0}, limits.PerMinute)
+	assert.Equal(t, len(common.KnownDevnetEmitters)+1, len(limits.Bypass))
+	assert.Equal(t, common.EmitterInfo{ChainID: vaa.ChainIDEthereum, Emitter: emitter}, limits.Bypass[len(limits.Bypass)-1])
+
+	for _, overrides := range [][]string{{"bsc"}, {"nope=1"}, {"bsc=-1"}} {
+		_, err := parseSigningRateLimits(0, overrides, nil, nil)
+		assert.Error(t, err, overrides)
+	}
+	_, err = parseSigningRateLimits(0, nil, []string{"bsc"}, nil)
+	assert.Error(t, err)
+}
+
+func TestSigningRateLimitStatus(t *testing.T) {
+	s := &nodePrivilegedService{}
+	resp, err := s.SigningRateLimitStatus(context.Background(), &nodev1.SigningRateLimitStatusRequest{})
+	require.NoError(t, err)
+	assert.False(t, resp.Enabled)
+
+	s.signLimiter, err = processor.NewSigningRateLimiter(processor.SigningRateLimits{DefaultPerMinute: 1})
+	require.NoError(t, err)
+	k := &common.MessagePublication{EmitterChain: vaa.ChainIDBSC}
+	assert.True(t, s.signLimiter.Allow(k, time.Now()))
+	assert.False(t, s.signLimiter.Allow(k, time.Now()))
+
+	resp, err = s.SigningRateLimitStatus(context.Background(), &nodev1.SigningRateLimitStatusRequest{})
+	require.NoError(t, err)
+	assert.True(t, resp.Enabled)
+	require.Equal(t, 1, len(resp.Entries))
+	assert.Equal(t, uint32(vaa.ChainIDBSC), resp.Entries[0].ChainId)
+	assert.Equal(t, uint64(1), resp.Entries[0].LimitPerMinute)
+	assert.Equal(t, uint64(1), resp.Entries[0].Dropped)
+	assert.NotZero(t, resp.Entries[0].LastDropped)
+}
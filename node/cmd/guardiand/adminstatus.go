@@ -0,0 +1,87 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var nodeStatusJSON *bool
+
+func init() {
+	nodeStatusJSON = AdminClientNodeStatusCmd.Flags().Bool("json", false, "Print the report as JSON")
+}
+
+var AdminClientNodeStatusCmd = &cobra.Command{
+	Use:   "node-status",
+	Short: "Prints a report of the state of the node's watchers, p2p connectivity, database, governor and accountant",
+	Run:   runNodeStatus,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runNodeStatus(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.NodeStatus(ctx, &nodev1.NodeStatusRequest{})
+	if err != nil {
+		log.Fatalf("failed to run NodeStatus RPC: %s", err)
+	}
+
+	if *nodeStatusJSON {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			log.Fatalf("failed to marshal report: %v", err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	fmt.Printf("guardian address: %s\n", resp.GuardianAddress)
+	fmt.Printf("version: %s\n\n", resp.Version)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "chain\theight\tmax peer height\tlag\terrors\tpaused\t")
+	for _, n := range resp.Watchers {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%v\t\n", vaa.ChainID(n.ChainId), n.Height, n.MaxPeerHeight, n.Lag, n.ErrorCount, n.Paused)
+	}
+	w.Flush()
+
+	fmt.Println("\nreadiness:")
+	for _, r := range resp.Readiness {
+		fmt.Printf("  %s: %v\n", r.Name, r.Ready)
+	}
+
+	fmt.Printf("\np2p: %d connected peers, %d gossip peers, heartbeats from %d/%d guardians in set %d\n",
+		resp.P2P.ConnectedPeers, resp.P2P.GossipPeers, resp.P2P.GuardiansSeen, resp.P2P.GuardianSetSize, resp.P2P.GuardianSetIndex)
+
+	fmt.Printf("database: lsm %d bytes, vlog %d bytes, %d level 0 tables, %d levels pending compaction\n",
+		resp.Database.LsmSize, resp.Database.VlogSize, resp.Database.Level0Tables, resp.Database.LevelsPendingCompaction)
+
+	if resp.Governor.Enabled {
+		fmt.Printf("governor: %d chains, %d enqueued VAAs\n", resp.Governor.Chains, resp.Governor.EnqueuedVaas)
+	} else {
+		fmt.Println("governor: disabled")
+	}
+
+	if resp.Accountant.Enabled {
+		fmt.Printf("accountant: logOnly: %v, %d ledgers, %d balances, %d refused transfers\n",
+			resp.Accountant.LogOnly, resp.Accountant.Ledgers, resp.Accountant.Balances, resp.Accountant.RefusedTransfers)
+	} else {
+		fmt.Println("accountant: disabled")
+	}
+}
@@ -0,0 +1,89 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stuckMessagesChain  *string
+	stuckMessagesMinAge *time.Duration
+)
+
+func init() {
+	stuckMessagesChain = AdminClientStuckMessagesCmd.Flags().String("chain", "", "Only list the messages of this chain (name or ID)")
+	stuckMessagesMinAge = AdminClientStuckMessagesCmd.Flags().Duration("minAge", 0, "Only list the messages first observed at least this long ago")
+}
+
+var AdminClientStuckMessagesCmd = &cobra.Command{
+	Use:   "stuck-messages",
+	Short: "Lists the messages signed by this node which haven't reached quorum, with the guardians whose signatures are missing",
+	Run:   runStuckMessages,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runStuckMessages(cmd *cobra.Command, args []string) {
+	req := &nodev1.ListStuckMessagesRequest{MinAgeSeconds: uint64(*stuckMessagesMinAge / time.Second)}
+	if *stuckMessagesChain != "" {
+		chainID, err := parseChainID(*stuckMessagesChain)
+		if err != nil {
+			log.Fatalf("invalid chain: %v", err)
+		}
+		req.EmitterChain = uint32(chainID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.ListStuckMessages(ctx, req)
+	if err != nil {
+		log.Fatalf("failed to run ListStuckMessages RPC: %s", err)
+	}
+
+	if resp.Updated == 0 {
+		fmt.Println("The processor has not reported its messages yet")
+		return
+	}
+	fmt.Printf("As of %s\n\n", time.Unix(resp.Updated, 0).Format(time.RFC3339))
+	if len(resp.Messages) == 0 {
+		fmt.Println("No message is waiting for quorum")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "message\tchain\tage\tsignatures\tretries\ttx\tmissing\t")
+	for _, m := range resp.Messages {
+		missing := make([]string, 0, len(m.Missing))
+		for _, g := range m.Missing {
+			if g.Name != "" {
+				missing = append(missing, g.Name)
+			} else {
+				missing = append(missing, g.Address)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%d\t%s\t%s\t\n",
+			m.MessageId,
+			vaa.ChainID(m.EmitterChain),
+			time.Duration(m.AgeSeconds)*time.Second,
+			m.Signatures, m.Quorum,
+			m.Retries,
+			m.TxHash,
+			strings.Join(missing, ", "))
+	}
+	w.Flush()
+}
@@ -0,0 +1,71 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var supervisorTreeJSON *bool
+
+func init() {
+	supervisorTreeJSON = AdminClientSupervisorTreeCmd.Flags().Bool("json", false, "Print the tree as JSON")
+}
+
+var AdminClientSupervisorTreeCmd = &cobra.Command{
+	Use:   "supervisor-tree",
+	Short: "Prints the state, restart count, last error and uptime of each runnable supervised by the node",
+	Run:   runSupervisorTree,
+	Args:  cobra.ExactArgs(0),
+}
+
+func runSupervisorTree(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := c.SupervisorTree(ctx, &nodev1.SupervisorTreeRequest{})
+	if err != nil {
+		log.Fatalf("failed to run SupervisorTree RPC: %s", err)
+	}
+
+	if *supervisorTreeJSON {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(resp)
+		if err != nil {
+			log.Fatalf("failed to marshal tree: %v", err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "runnable\tstate\trestarts\tuptime\tcircuit\tlast error\t")
+	for _, n := range resp.Nodes {
+		uptime := "-"
+		if n.UptimeSeconds != 0 {
+			uptime = (time.Duration(n.UptimeSeconds) * time.Second).String()
+		}
+		circuit := "closed"
+		if n.CircuitOpen {
+			circuit = "open"
+		}
+		lastError := "-"
+		if n.LastErrorTime != 0 {
+			lastError = fmt.Sprintf("%s ago: %s", time.Since(time.Unix(n.LastErrorTime, 0)).Truncate(time.Second), n.LastError)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t\n", n.Dn, n.State, n.Restarts, uptime, circuit, lastError)
+	}
+	w.Flush()
+}
@@ -0,0 +1,257 @@
+package guardiand
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/parquet"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vaaTableFormat  *string
+	vaaTableColumns *[]string
+)
+
+func init() {
+	vaaTableFormat = AdminClientExportVAATableCmd.Flags().String("format", "csv", "Format of the table: csv or parquet")
+	vaaTableColumns = AdminClientExportVAATableCmd.Flags().StringSlice("columns", nil,
+		"Comma-separated columns to export, in order (default all): "+strings.Join(vaaTableColumnNames(), ", "))
+}
+
+var AdminClientExportVAATableCmd = &cobra.Command{
+	Use:   "export-vaa-table [FILENAME]",
+	Short: "Exports the signed VAAs in the local database and their observation metadata as a CSV or Parquet table, optionally filtered by emitter, sequence range and time",
+	Run:   runExportVAATable,
+	Args:  cobra.ExactArgs(1),
+}
+
+// vaaTableColumn is a column of the table exported by export-vaa-table.
+type vaaTableColumn struct {
+	name string
+	typ  parquet.Type
+	// Set for the columns which need the observation metadata of the VAA.
+	metadata bool
+	// value returns the value of the column for a VAA: a string, an int64 or a time.Time depending on the type.
+	value func(v *vaa.VAA, m *nodev1.ObservationMetadata) interface{}
+}
+
+var allVAATableColumns = []vaaTableColumn{
+	{name: "message_id", typ: parquet.String, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return v.MessageID() }},
+	{name: "emitter_chain", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(v.EmitterChain) }},
+	{name: "emitter_address", typ: parquet.String, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return v.EmitterAddress.String() }},
+	// Sequence numbers are far below 2^63 in practice.
+	{name: "sequence", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(v.Sequence) }},
+	{name: "timestamp", typ: parquet.Timestamp, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return v.Timestamp.UTC() }},
+	{name: "nonce", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(v.Nonce) }},
+	{name: "consistency_level", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(v.ConsistencyLevel) }},
+	{name: "guardian_set_index", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(v.GuardianSetIndex) }},
+	{name: "num_signatures", typ: parquet.Int64, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return int64(len(v.Signatures)) }},
+	{name: "digest", typ: parquet.String, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} {
+		return hex.EncodeToString(v.SigningMsg().Bytes())
+	}},
+	{name: "payload", typ: parquet.String, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} { return hex.EncodeToString(v.Payload) }},
+	{name: "vaa", typ: parquet.String, value: func(v *vaa.VAA, _ *nodev1.ObservationMetadata) interface{} {
+		b, _ := v.Marshal()
+		return hex.EncodeToString(b)
+	}},
+	{name: "tx_hash", typ: parquet.String, metadata: true, value: func(_ *vaa.VAA, m *nodev1.ObservationMetadata) interface{} { return m.TxHash }},
+	{name: "rpc_endpoint", typ: parquet.String, metadata: true, value: func(_ *vaa.VAA, m *nodev1.ObservationMetadata) interface{} { return m.RpcEndpoint }},
+	{name: "fetch_latency_ms", typ: parquet.Int64, metadata: true, value: func(_ *vaa.VAA, m *nodev1.ObservationMetadata) interface{} { return int64(m.FetchLatencyMs) }},
+	{name: "block", typ: parquet.String, metadata: true, value: func(_ *vaa.VAA, m *nodev1.ObservationMetadata) interface{} { return m.Block }},
+	{name: "watcher_version", typ: parquet.String, metadata: true, value: func(_ *vaa.VAA, m *nodev1.ObservationMetadata) interface{} { return m.WatcherVersion }},
+}
+
+func vaaTableColumnNames() []string {
+	var names []string
+	for _, c := range allVAATableColumns {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// selectVAATableColumns returns the columns with the given names, in the given order, or all columns if none are
+// given.
+func selectVAATableColumns(names []string) ([]vaaTableColumn, error) {
+	if len(names) == 0 {
+		return allVAATableColumns, nil
+	}
+
+	var columns []vaaTableColumn
+	for _, name := range names {
+		found := false
+		for _, c := range allVAATableColumns {
+			if c.name == strings.TrimSpace(name) {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+	}
+	return columns, nil
+}
+
+// tableWriter writes the rows of a table, with one value per column.
+type tableWriter interface {
+	Write(row ...interface{}) error
+	Close() error
+}
+
+// csvTableWriter writes a table as CSV, with a header row. Times are written as RFC 3339.
+type csvTableWriter struct {
+	w *csv.Writer
+}
+
+func newCSVTableWriter(w io.Writer, columns []vaaTableColumn) (*csvTableWriter, error) {
+	cw := &csvTableWriter{w: csv.NewWriter(w)}
+	var header []string
+	for _, c := range columns {
+		header = append(header, c.name)
+	}
+	if err := cw.w.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvTableWriter) Write(row ...interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		default:
+			return fmt.Errorf("unsupported value %T", v)
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvTableWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func newTableWriter(format string, w io.Writer, columns []vaaTableColumn) (tableWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVTableWriter(w, columns)
+	case "parquet":
+		var parquetColumns []parquet.Column
+		for _, c := range columns {
+			parquetColumns = append(parquetColumns, parquet.Column{Name: c.name, Type: c.typ})
+		}
+		return parquet.NewWriter(w, parquetColumns)
+	default:
+		return nil, fmt.Errorf("unknown format %s, expected csv or parquet", format)
+	}
+}
+
+// writeVAATableRow writes the row of a signed VAA. m is only used by metadata columns.
+func writeVAATableRow(w tableWriter, columns []vaaTableColumn, b []byte, m *nodev1.ObservationMetadata) error {
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal VAA: %w", err)
+	}
+
+	row := make([]interface{}, len(columns))
+	for i, c := range columns {
+		row[i] = c.value(v, m)
+	}
+	return w.Write(row...)
+}
+
+func runExportVAATable(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	columns, err := selectVAATableColumns(*vaaTableColumns)
+	if err != nil {
+		log.Fatalf("invalid --columns: %v", err)
+	}
+	includeMetadata := false
+	for _, c := range columns {
+		includeMetadata = includeMetadata || c.metadata
+	}
+	if *vaaTableFormat != "csv" && *vaaTableFormat != "parquet" {
+		log.Fatalf("invalid --format %s, expected csv or parquet", *vaaTableFormat)
+	}
+
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Fatalf("failed to create table: %v", err)
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+
+	w, err := newTableWriter(*vaaTableFormat, bw, columns)
+	if err != nil {
+		log.Fatalf("failed to write table: %v", err)
+	}
+
+	conn, c, err := getAdminClient(ctx, *clientSocketPath)
+	if err != nil {
+		log.Fatalf("failed to get admin client: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := c.ExportSignedVAAs(ctx, &nodev1.ExportSignedVAAsRequest{
+		Filter:          signedVAAFilterFromFlags(),
+		IncludeMetadata: includeMetadata,
+	})
+	if err != nil {
+		log.Fatalf("failed to run ExportSignedVAAs RPC: %s", err)
+	}
+
+	count := 0
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("failed to receive VAAs: %s", err)
+		}
+		if includeMetadata && len(resp.Metadata) != len(resp.VaaBytes) {
+			log.Fatalf("the node did not send the observation metadata, it may need to be upgraded")
+		}
+
+		for i, b := range resp.VaaBytes {
+			var m *nodev1.ObservationMetadata
+			if includeMetadata {
+				m = resp.Metadata[i]
+			}
+			if err := writeVAATableRow(w, columns, b, m); err != nil {
+				log.Fatalf("failed to write table: %v", err)
+			}
+			count++
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		log.Fatalf("failed to write table: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		log.Fatalf("failed to write table: %v", err)
+	}
+
+	log.Printf("exported %d VAAs to %s", count, args[0])
+}
@@ -0,0 +1,65 @@
+package guardiand
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectVAATableColumns(t *testing.T) {
+	columns, err := selectVAATableColumns(nil)
+	require.NoError(t, err)
+	assert.Equal(t, len(allVAATableColumns), len(columns))
+
+	columns, err = selectVAATableColumns([]string{"sequence", " tx_hash"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(columns))
+	assert.Equal(t, "sequence", columns[0].name)
+	assert.False(t, columns[0].metadata)
+	assert.Equal(t, "tx_hash", columns[1].name)
+	assert.True(t, columns[1].metadata)
+
+	_, err = selectVAATableColumns([]string{"sequence", "unknown"})
+	assert.Error(t, err)
+}
+
+func TestWriteVAATableCSV(t *testing.T) {
+	gk, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	v := &vaa.VAA{
+		Version:          vaa.SupportedVAAVersion,
+		GuardianSetIndex: 2,
+		Timestamp:        time.Unix(1660000000, 0),
+		EmitterChain:     vaa.ChainIDEthereum,
+		EmitterAddress:   vaa.Address{1},
+		Sequence:         42,
+		Payload:          []byte{0xab},
+	}
+	v.AddSignature(gk, 0)
+	b, err := v.Marshal()
+	require.NoError(t, err)
+
+	columns, err := selectVAATableColumns([]string{"message_id", "timestamp", "guardian_set_index", "payload", "tx_hash", "fetch_latency_ms"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := newTableWriter("csv", &buf, columns)
+	require.NoError(t, err)
+	require.NoError(t, writeVAATableRow(w, columns, b, &nodev1.ObservationMetadata{TxHash: "0102", FetchLatencyMs: 1500}))
+	// Expired metadata is left empty.
+	require.NoError(t, writeVAATableRow(w, columns, b, &nodev1.ObservationMetadata{}))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "message_id,timestamp,guardian_set_index,payload,tx_hash,fetch_latency_ms\n"+
+		v.MessageID()+",2022-08-08T23:06:40Z,2,ab,0102,1500\n"+
+		v.MessageID()+",2022-08-08T23:06:40Z,2,ab,,0\n", buf.String())
+
+	_, err = newTableWriter("json", &buf, columns)
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/btcsuite/btcutil/bech32"
 	"github.com/certusone/wormhole/node/pkg/vaa"
@@ -28,6 +29,17 @@ var templateGuardianIndex *int
 var chainID *string
 var address *string
 var module *string
+var messageFee *string
+var feeAmount *string
+var feeRecipient *string
+var modifyChainID *string
+var modifyTokenChain *string
+var modifyTokenAddress *string
+var modifyKind *string
+var modifyAmount *string
+var modifyReason *string
+var networkConfigVersion *uint64
+var networkConfigFeatures *[]string
 var shutdownGuardianKey *string
 var shutdownPubKey *string
 
@@ -36,9 +48,28 @@ func init() {
 	chainID = governanceFlagSet.String("chain-id", "", "Chain ID")
 	address = governanceFlagSet.String("new-address", "", "New address (hex, base58 or bech32)")
 
+	messageFeeFlagSet := pflag.NewFlagSet("message-fee", pflag.ExitOnError)
+	messageFee = messageFeeFlagSet.String("message-fee", "", "New message fee (decimal, in the smallest unit of the chain's native token)")
+
+	transferFeesFlagSet := pflag.NewFlagSet("transfer-fees", pflag.ExitOnError)
+	feeAmount = transferFeesFlagSet.String("amount", "", "Amount of fees to transfer (decimal, in the smallest unit of the chain's native token)")
+	feeRecipient = transferFeesFlagSet.String("recipient", "", "Recipient address (hex, base58 or bech32)")
+
 	moduleFlagSet := pflag.NewFlagSet("module", pflag.ExitOnError)
 	module = moduleFlagSet.String("module", "", "Module name")
 
+	modifyBalanceFlagSet := pflag.NewFlagSet("modify-balance", pflag.ExitOnError)
+	modifyChainID = modifyBalanceFlagSet.String("chain-id", "", "Chain ID of the balance to modify")
+	modifyTokenChain = modifyBalanceFlagSet.String("token-chain", "", "Chain ID the token originates from")
+	modifyTokenAddress = modifyBalanceFlagSet.String("token-address", "", "Address of the token on its origin chain (hex, base58 or bech32)")
+	modifyKind = modifyBalanceFlagSet.String("kind", "", "Whether to \"add\" to or \"subtract\" from the balance")
+	modifyAmount = modifyBalanceFlagSet.String("amount", "", "Amount to add or subtract (decimal, normalized to eight decimals)")
+	modifyReason = modifyBalanceFlagSet.String("reason", "", "Reason for the modification, at most 32 bytes")
+
+	networkConfigFlagSet := pflag.NewFlagSet("network-config", pflag.ExitOnError)
+	networkConfigVersion = networkConfigFlagSet.Uint64("version", 0, "Version of the configuration, higher than the version applied by the guardians")
+	networkConfigFeatures = networkConfigFlagSet.StringArray("feature", nil, "Feature to activate, as NAME=TIME where TIME is a unix timestamp or RFC 3339 (repeatable)")
+
 	authProofFlagSet := pflag.NewFlagSet("auth-proof", pflag.ExitOnError)
 	shutdownGuardianKey = authProofFlagSet.String("guardian-key", "", "Guardian key to sign proof. File path or hex string")
 	shutdownPubKey = authProofFlagSet.String("proof-pub-key", "", "Public key to encode in proof")
@@ -51,6 +82,14 @@ func init() {
 	AdminClientContractUpgradeTemplateCmd.Flags().AddFlagSet(governanceFlagSet)
 	TemplateCmd.AddCommand(AdminClientContractUpgradeTemplateCmd)
 
+	AdminClientSetMessageFeeTemplateCmd.Flags().AddFlagSet(governanceFlagSet)
+	AdminClientSetMessageFeeTemplateCmd.Flags().AddFlagSet(messageFeeFlagSet)
+	TemplateCmd.AddCommand(AdminClientSetMessageFeeTemplateCmd)
+
+	AdminClientTransferFeesTemplateCmd.Flags().AddFlagSet(governanceFlagSet)
+	AdminClientTransferFeesTemplateCmd.Flags().AddFlagSet(transferFeesFlagSet)
+	TemplateCmd.AddCommand(AdminClientTransferFeesTemplateCmd)
+
 	AdminClientTokenBridgeRegisterChainCmd.Flags().AddFlagSet(governanceFlagSet)
 	AdminClientTokenBridgeRegisterChainCmd.Flags().AddFlagSet(moduleFlagSet)
 	TemplateCmd.AddCommand(AdminClientTokenBridgeRegisterChainCmd)
@@ -59,6 +98,12 @@ func init() {
 	AdminClientTokenBridgeUpgradeContractCmd.Flags().AddFlagSet(moduleFlagSet)
 	TemplateCmd.AddCommand(AdminClientTokenBridgeUpgradeContractCmd)
 
+	AdminClientAccountantModifyBalanceCmd.Flags().AddFlagSet(modifyBalanceFlagSet)
+	TemplateCmd.AddCommand(AdminClientAccountantModifyBalanceCmd)
+
+	AdminClientNetworkConfigTemplateCmd.Flags().AddFlagSet(networkConfigFlagSet)
+	TemplateCmd.AddCommand(AdminClientNetworkConfigTemplateCmd)
+
 	AdminClientShutdownProofCmd.Flags().AddFlagSet(authProofFlagSet)
 	TemplateCmd.AddCommand(AdminClientShutdownProofCmd)
 }
@@ -80,6 +125,18 @@ var AdminClientContractUpgradeTemplateCmd = &cobra.Command{
 	Run:   runContractUpgradeTemplate,
 }
 
+var AdminClientSetMessageFeeTemplateCmd = &cobra.Command{
+	Use:   "set-message-fee",
+	Short: "Generate a template to set the message fee of the core contract on a chain",
+	Run:   runSetMessageFeeTemplate,
+}
+
+var AdminClientTransferFeesTemplateCmd = &cobra.Command{
+	Use:   "transfer-fees",
+	Short: "Generate a template to transfer the fees collected by the core contract on a chain to a recipient",
+	Run:   runTransferFeesTemplate,
+}
+
 var AdminClientTokenBridgeRegisterChainCmd = &cobra.Command{
 	Use:   "token-bridge-register-chain",
 	Short: "Generate an empty token bridge chain registration template at specified path",
@@ -91,6 +148,19 @@ var AdminClientTokenBridgeUpgradeContractCmd = &cobra.Command{
 	Short: "Generate an empty token bridge contract upgrade template at specified path",
 	Run:   runTokenBridgeUpgradeContractTemplate,
 }
+
+var AdminClientAccountantModifyBalanceCmd = &cobra.Command{
+	Use:   "accountant-modify-balance",
+	Short: "Generate a template to modify the balance of a token in the accountant ledger",
+	Run:   runAccountantModifyBalanceTemplate,
+}
+
+var AdminClientNetworkConfigTemplateCmd = &cobra.Command{
+	Use:   "network-config",
+	Short: "Generate a template for a network configuration scheduling the activation of guardian features",
+	Run:   runNetworkConfigTemplate,
+}
+
 var AdminClientShutdownProofCmd = &cobra.Command{
 	Use:   "shutdown-proof",
 	Short: "Generate an auth proof for shutdown voting on behalf of the guardian.",
@@ -121,11 +191,7 @@ func runGuardianSetTemplate(cmd *cobra.Command, args []string) {
 		},
 	}
 
-	b, err := prototext.MarshalOptions{Multiline: true}.Marshal(m)
-	if err != nil {
-		panic(err)
-	}
-	fmt.Print(string(b))
+	printGovernanceTemplate(m)
 }
 
 func runContractUpgradeTemplate(cmd *cobra.Command, args []string) {
@@ -154,12 +220,159 @@ func runContractUpgradeTemplate(cmd *cobra.Command, args []string) {
 		},
 	}
 
-	b, err := prototext.MarshalOptions{Multiline: true}.Marshal(m)
+	printGovernanceTemplate(m)
+}
+
+func runSetMessageFeeTemplate(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(*chainID)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	fmt.Print(string(b))
+
+	m := &nodev1.InjectGovernanceVAARequest{
+		CurrentSetIndex: uint32(*templateGuardianIndex),
+		Messages: []*nodev1.GovernanceMessage{
+			{
+				Sequence: rand.Uint64(),
+				Nonce:    rand.Uint32(),
+				Payload: &nodev1.GovernanceMessage_SetMessageFee{
+					SetMessageFee: &nodev1.SetMessageFee{
+						ChainId:    uint32(chainID),
+						MessageFee: *messageFee,
+					},
+				},
+			},
+		},
+	}
+
+	printGovernanceTemplate(m)
 }
+
+func runTransferFeesTemplate(cmd *cobra.Command, args []string) {
+	recipient, err := parseAddress(*feeRecipient)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chainID, err := parseChainID(*chainID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := &nodev1.InjectGovernanceVAARequest{
+		CurrentSetIndex: uint32(*templateGuardianIndex),
+		Messages: []*nodev1.GovernanceMessage{
+			{
+				Sequence: rand.Uint64(),
+				Nonce:    rand.Uint32(),
+				Payload: &nodev1.GovernanceMessage_TransferFees{
+					TransferFees: &nodev1.TransferFees{
+						ChainId:   uint32(chainID),
+						Amount:    *feeAmount,
+						Recipient: recipient,
+					},
+				},
+			},
+		},
+	}
+
+	printGovernanceTemplate(m)
+}
+
+func runAccountantModifyBalanceTemplate(cmd *cobra.Command, args []string) {
+	chainID, err := parseChainID(*modifyChainID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokenChain, err := parseChainID(*modifyTokenChain)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokenAddress, err := parseAddress(*modifyTokenAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var kind nodev1.AccountantModifyBalance_ModificationKind
+	switch *modifyKind {
+	case "add":
+		kind = nodev1.AccountantModifyBalance_MODIFICATION_KIND_ADD
+	case "subtract":
+		kind = nodev1.AccountantModifyBalance_MODIFICATION_KIND_SUBTRACT
+	default:
+		log.Fatalf("invalid kind %q, expected \"add\" or \"subtract\"", *modifyKind)
+	}
+
+	m := &nodev1.InjectGovernanceVAARequest{
+		CurrentSetIndex: uint32(*templateGuardianIndex),
+		Messages: []*nodev1.GovernanceMessage{
+			{
+				Sequence: rand.Uint64(),
+				Nonce:    rand.Uint32(),
+				Payload: &nodev1.GovernanceMessage_AccountantModifyBalance{
+					AccountantModifyBalance: &nodev1.AccountantModifyBalance{
+						Sequence:     rand.Uint64(),
+						ChainId:      uint32(chainID),
+						TokenChain:   uint32(tokenChain),
+						TokenAddress: tokenAddress,
+						Kind:         kind,
+						Amount:       *modifyAmount,
+						Reason:       *modifyReason,
+					},
+				},
+			},
+		},
+	}
+
+	printGovernanceTemplate(m)
+}
+
+// parseNetworkConfigFeature parses a NAME=TIME feature activation, where TIME is a unix timestamp or RFC 3339.
+func parseNetworkConfigFeature(s string) (*nodev1.NetworkConfig_Feature, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid feature %q, expected NAME=TIME", s)
+	}
+
+	if ts, err := strconv.ParseUint(parts[1], 10, 64); err == nil {
+		return &nodev1.NetworkConfig_Feature{Name: parts[0], ActivationTime: ts}, nil
+	}
+	t, err := time.Parse(time.RFC3339, parts[1])
+	if err != nil || t.Unix() < 0 {
+		return nil, fmt.Errorf("invalid activation time of feature %s: expected a unix timestamp or RFC 3339", parts[0])
+	}
+	return &nodev1.NetworkConfig_Feature{Name: parts[0], ActivationTime: uint64(t.Unix())}, nil
+}
+
+func runNetworkConfigTemplate(cmd *cobra.Command, args []string) {
+	if *networkConfigVersion == 0 {
+		log.Fatal("--version is required")
+	}
+
+	config := &nodev1.NetworkConfig{Version: *networkConfigVersion}
+	for _, s := range *networkConfigFeatures {
+		f, err := parseNetworkConfigFeature(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.Features = append(config.Features, f)
+	}
+
+	m := &nodev1.InjectGovernanceVAARequest{
+		CurrentSetIndex: uint32(*templateGuardianIndex),
+		Messages: []*nodev1.GovernanceMessage{
+			{
+				Sequence: rand.Uint64(),
+				Nonce:    rand.Uint32(),
+				Payload: &nodev1.GovernanceMessage_NetworkConfig{
+					NetworkConfig: config,
+				},
+			},
+		},
+	}
+
+	printGovernanceTemplate(m)
+}
+
 func runTokenBridgeRegisterChainTemplate(cmd *cobra.Command, args []string) {
 	address, err := parseAddress(*address)
 	if err != nil {
@@ -187,11 +400,7 @@ func runTokenBridgeRegisterChainTemplate(cmd *cobra.Command, args []string) {
 		},
 	}
 
-	b, err := prototext.MarshalOptions{Multiline: true}.Marshal(m)
-	if err != nil {
-		panic(err)
-	}
-	fmt.Print(string(b))
+	printGovernanceTemplate(m)
 }
 
 func runTokenBridgeUpgradeContractTemplate(cmd *cobra.Command, args []string) {
@@ -221,6 +430,17 @@ func runTokenBridgeUpgradeContractTemplate(cmd *cobra.Command, args []string) {
 		},
 	}
 
+	printGovernanceTemplate(m)
+}
+
+// printGovernanceTemplate validates the messages of a template, the same way they are validated on injection, and prints it.
+func printGovernanceTemplate(m *nodev1.InjectGovernanceVAARequest) {
+	for _, message := range m.Messages {
+		if _, err := governanceMessageToVAA(message, time.Unix(int64(m.Timestamp), 0), m.CurrentSetIndex); err != nil {
+			log.Fatalf("invalid template: %v", err)
+		}
+	}
+
 	b, err := prototext.MarshalOptions{Multiline: true}.Marshal(m)
 	if err != nil {
 		panic(err)
@@ -314,10 +534,10 @@ func parseChainID(name string) (vaa.ChainID, error) {
 		return s, nil
 	}
 
-	// parse as uint32
-	i, err := strconv.ParseUint(name, 10, 32)
+	// parse as uint16
+	i, err := strconv.ParseUint(name, 10, 16)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse as name or uint32: %v", err)
+		return 0, fmt.Errorf("failed to parse as name or uint16: %v", err)
 	}
 
 	return vaa.ChainID(i), nil
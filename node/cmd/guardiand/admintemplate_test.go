@@ -0,0 +1,24 @@
+package guardiand
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworkConfigFeature(t *testing.T) {
+	f, err := parseNetworkConfigFeature("batch=1700000000")
+	require.NoError(t, err)
+	assert.Equal(t, "batch", f.Name)
+	assert.Equal(t, uint64(1700000000), f.ActivationTime)
+
+	f, err = parseNetworkConfigFeature("batch=2023-11-14T22:13:20Z")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1700000000), f.ActivationTime)
+
+	for _, s := range []string{"batch", "=1700000000", "batch=tomorrow", "batch=1969-12-31T00:00:00Z"} {
+		_, err = parseNetworkConfigFeature(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,118 @@
+package guardiand
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// The node flags can also be set in a YAML or TOML config file, passed with the global --config flag, using the flag
+// names as keys:
+//
+//	nodeName: my-guardian
+//	guardianKey: /path/to/guardian.key
+//	ethRPC: ws://eth-node:8545
+//	ethContract: "0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B"
+//
+// Each flag can also be set with an environment variable named GUARDIAND_ followed by the upper case flag name, such as
+// GUARDIAND_ETHRPC. Flags specified on the command line take precedence over environment variables, which take
+// precedence over the config file.
+
+const ConfigEnvPrefix = "GUARDIAND"
+
+// configEnvVar returns the name of the environment variable that sets a flag.
+func configEnvVar(flag string) string {
+	return ConfigEnvPrefix + "_" + strings.ToUpper(flag)
+}
+
+// configValue converts a value read from the config file to its flag representation.
+func configValue(value interface{}) string {
+	if values, ok := value.([]interface{}); ok {
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = fmt.Sprint(v)
+		}
+		return strings.Join(s, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// applyConfig sets the flags that were not specified on the command line from the environment and the config file.
+// Keys in the config file that do not match a flag, and values that are invalid for their flag, are errors.
+func applyConfig(v *viper.Viper, flags *pflag.FlagSet) error {
+	// Viper keys are case insensitive.
+	byKey := make(map[string]*pflag.Flag)
+	flags.VisitAll(func(f *pflag.Flag) {
+		byKey[strings.ToLower(f.Name)] = f
+	})
+
+	for _, key := range v.AllKeys() {
+		if _, ok := byKey[key]; !ok {
+			return fmt.Errorf("config file: unknown option %s", key)
+		}
+	}
+
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+
+		if value, ok := os.LookupEnv(configEnvVar(f.Name)); ok {
+			if setErr := flags.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value for %s: %w", configEnvVar(f.Name), setErr)
+			}
+			return
+		}
+
+		key := strings.ToLower(f.Name)
+		if v.InConfig(key) {
+			if setErr := flags.Set(f.Name, configValue(v.Get(key))); setErr != nil {
+				err = fmt.Errorf("config file: invalid value for %s: %w", f.Name, setErr)
+			}
+		}
+	})
+
+	return err
+}
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Guardian node configuration commands",
+}
+
+var ConfigValidateCmd = &cobra.Command{
+	Use:   "validate [FILENAME]",
+	Short: "Validate a node config file, along with the GUARDIAND_ environment variables",
+	Run:   runConfigValidate,
+	Args:  cobra.ExactArgs(1),
+}
+
+func init() {
+	ConfigCmd.AddCommand(ConfigValidateCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) {
+	v := viper.New()
+	v.SetConfigFile(args[0])
+	if err := v.ReadInConfig(); err != nil {
+		fmt.Printf("failed to read config file: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := applyConfig(v, NodeCmd.Flags()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := verifyNodeFlags(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("config is valid")
+}
@@ -0,0 +1,81 @@
+package guardiand
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFlags struct {
+	flags    *pflag.FlagSet
+	nodeName *string
+	ethRPC   *string
+	port     *uint
+	testnet  *bool
+	peers    *[]string
+}
+
+func newTestFlags(t *testing.T, args ...string) *testFlags {
+	f := &testFlags{flags: pflag.NewFlagSet("test", pflag.ContinueOnError)}
+	f.nodeName = f.flags.String("nodeName", "", "")
+	f.ethRPC = f.flags.String("ethRPC", "", "")
+	f.port = f.flags.Uint("port", 8999, "")
+	f.testnet = f.flags.Bool("testnet", false, "")
+	f.peers = f.flags.StringSlice("peers", nil, "")
+	require.NoError(t, f.flags.Parse(args))
+	return f
+}
+
+func readTestConfig(t *testing.T, name string, content string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigFile(writeTestFile(t, name, []byte(content)))
+	require.NoError(t, v.ReadInConfig())
+	return v
+}
+
+func TestApplyConfigFormats(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "nodeName: from-file\nethRPC: ws://eth\nport: 9000\ntestnet: true\npeers: [a, b]\n",
+		"config.toml": "nodeName = \"from-file\"\nethRPC = \"ws://eth\"\nport = 9000\ntestnet = true\npeers = [\"a\", \"b\"]\n",
+	} {
+		f := newTestFlags(t)
+		require.NoError(t, applyConfig(readTestConfig(t, name, content), f.flags), name)
+		assert.Equal(t, "from-file", *f.nodeName, name)
+		assert.Equal(t, "ws://eth", *f.ethRPC, name)
+		assert.Equal(t, uint(9000), *f.port, name)
+		assert.True(t, *f.testnet, name)
+		assert.Equal(t, []string{"a", "b"}, *f.peers, name)
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	t.Setenv("GUARDIAND_NODENAME", "from-env")
+	t.Setenv("GUARDIAND_ETHRPC", "ws://env")
+
+	// The command line overrides the environment, which overrides the config file.
+	f := newTestFlags(t, "--ethRPC", "ws://cli")
+	v := readTestConfig(t, "config.yaml", "nodeName: from-file\nethRPC: ws://file\nport: 9000\n")
+	require.NoError(t, applyConfig(v, f.flags))
+
+	assert.Equal(t, "from-env", *f.nodeName)
+	assert.Equal(t, "ws://cli", *f.ethRPC)
+	assert.Equal(t, uint(9000), *f.port)
+}
+
+func TestApplyConfigValidation(t *testing.T) {
+	f := newTestFlags(t)
+	err := applyConfig(readTestConfig(t, "config.yaml", "nodeName: a\nnotAFlag: b\n"), f.flags)
+	assert.ErrorContains(t, err, "unknown option notaflag")
+
+	f = newTestFlags(t)
+	err = applyConfig(readTestConfig(t, "config.yaml", "port: not-a-number\n"), f.flags)
+	assert.ErrorContains(t, err, "invalid value for port")
+
+	t.Setenv("GUARDIAND_TESTNET", "maybe")
+	f = newTestFlags(t)
+	err = applyConfig(viper.New(), f.flags)
+	assert.ErrorContains(t, err, "GUARDIAND_TESTNET")
+}
@@ -0,0 +1,186 @@
+package guardiand
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/certusone/wormhole/node/pkg/contractregistry"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/tendermint/tendermint/libs/rand"
+)
+
+var contractRegistryFile *string
+
+func init() {
+	contractRegistryFile = AdminClientContractRegistryTemplateCmd.Flags().String("file", "", "JSON file listing the contracts of each chain")
+	TemplateCmd.AddCommand(AdminClientContractRegistryTemplateCmd)
+}
+
+var AdminClientContractRegistryTemplateCmd = &cobra.Command{
+	Use:   "contract-registry",
+	Short: "Generate a contract registry template from a JSON file listing the contracts of each chain",
+	Run:   runContractRegistryTemplate,
+}
+
+type (
+	// registryFile is the human-readable source of a contract registry template.
+	registryFile struct {
+		Version uint64 `json:"version"`
+		// Contracts keyed by chain name or ID.
+		Chains map[string]registryFileChain `json:"chains"`
+	}
+
+	registryFileChain struct {
+		// Either the address, or the CREATE2 parameters the address is computed from.
+		Core               string         `json:"core"`
+		CoreCreate2        *create2Params `json:"coreCreate2"`
+		TokenBridge        string         `json:"tokenBridge"`
+		TokenBridgeCreate2 *create2Params `json:"tokenBridgeCreate2"`
+		// Hex-encoded keccak256 hash of the deployed core bridge bytecode.
+		CoreCodeHash string `json:"coreCodeHash"`
+	}
+
+	create2Params struct {
+		Deployer     string `json:"deployer"`
+		Salt         string `json:"salt"`
+		InitCodeHash string `json:"initCodeHash"`
+	}
+)
+
+// resolveContractAddress returns the address, or computes it from the CREATE2 parameters. An empty result means the
+// contract is not listed.
+func resolveContractAddress(address string, params *create2Params) (string, error) {
+	if address != "" && params != nil {
+		return "", fmt.Errorf("both address and CREATE2 parameters are specified")
+	}
+
+	if params == nil {
+		if address == "" {
+			return "", nil
+		}
+		if !eth_common.IsHexAddress(address) {
+			return "", fmt.Errorf("invalid address %s", address)
+		}
+		return strings.ToLower(eth_common.HexToAddress(address).Hex()[2:]), nil
+	}
+
+	if !eth_common.IsHexAddress(params.Deployer) {
+		return "", fmt.Errorf("invalid deployer address %s", params.Deployer)
+	}
+	salt, err := parseHash(params.Salt)
+	if err != nil {
+		return "", fmt.Errorf("invalid salt: %w", err)
+	}
+	initCodeHash, err := parseHash(params.InitCodeHash)
+	if err != nil {
+		return "", fmt.Errorf("invalid init code hash: %w", err)
+	}
+
+	addr := crypto.CreateAddress2(eth_common.HexToAddress(params.Deployer), salt, initCodeHash.Bytes())
+	return strings.ToLower(addr.Hex()[2:]), nil
+}
+
+// parseHash parses a hex-encoded 32 byte hash, with or without a 0x prefix.
+func parseHash(s string) (eth_common.Hash, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return eth_common.Hash{}, err
+	}
+	if len(b) != 32 {
+		return eth_common.Hash{}, fmt.Errorf("expected 32 bytes, got %d", len(b))
+	}
+	return eth_common.BytesToHash(b), nil
+}
+
+// contractRegistryMessage converts a registry file to a governance message.
+func contractRegistryMessage(f registryFile) (*nodev1.ContractRegistry, error) {
+	m := &nodev1.ContractRegistry{Version: f.Version}
+	for name, c := range f.Chains {
+		chainID, err := parseChainID(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain %s: %w", name, err)
+		}
+		core, err := resolveContractAddress(c.Core, c.CoreCreate2)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid core contract: %w", name, err)
+		}
+		tokenBridge, err := resolveContractAddress(c.TokenBridge, c.TokenBridgeCreate2)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid token bridge contract: %w", name, err)
+		}
+		codeHash, err := parseHash(c.CoreCodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid core code hash: %w", name, err)
+		}
+
+		m.Entries = append(m.Entries, &nodev1.ContractRegistry_Entry{
+			ChainId:      uint32(chainID),
+			Core:         core,
+			TokenBridge:  tokenBridge,
+			CoreCodeHash: strings.TrimPrefix(codeHash.Hex(), "0x"),
+		})
+	}
+
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].ChainId < m.Entries[j].ChainId })
+	return m, nil
+}
+
+func runContractRegistryTemplate(cmd *cobra.Command, args []string) {
+	b, err := ioutil.ReadFile(*contractRegistryFile)
+	if err != nil {
+		log.Fatalf("failed to read registry file: %v", err)
+	}
+
+	var f registryFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		log.Fatalf("failed to parse registry file: %v", err)
+	}
+
+	registry, err := contractRegistryMessage(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := &nodev1.InjectGovernanceVAARequest{
+		CurrentSetIndex: uint32(*templateGuardianIndex),
+		Messages: []*nodev1.GovernanceMessage{
+			{
+				Sequence: rand.Uint64(),
+				Nonce:    rand.Uint32(),
+				Payload: &nodev1.GovernanceMessage_ContractRegistry{
+					ContractRegistry: registry,
+				},
+			},
+		},
+	}
+
+	printGovernanceTemplate(m)
+}
+
+// applyContractRegistry sets the contract address flags of the chains listed in the registry. A flag that is already
+// set must match the registry.
+func applyContractRegistry(r *contractregistry.Registry, contracts map[vaa.ChainID]*string) error {
+	for chainID, flag := range contracts {
+		c, ok := r.Contract(chainID)
+		if !ok {
+			continue
+		}
+		if *flag == "" {
+			*flag = c.Core.Hex()
+			continue
+		}
+		if eth_common.HexToAddress(*flag) != c.Core {
+			return fmt.Errorf("contract address of %v is %s, but the contract registry lists %s", chainID, *flag, c.Core.Hex())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package guardiand
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/contractregistry"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveContractAddress(t *testing.T) {
+	// Example 0 of EIP-1014.
+	addr, err := resolveContractAddress("", &create2Params{
+		Deployer:     "0x0000000000000000000000000000000000000000",
+		Salt:         "0x0000000000000000000000000000000000000000000000000000000000000000",
+		InitCodeHash: crypto.Keccak256Hash([]byte{0x00}).Hex(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "4d1a2e2bb4f88f0250f26ffff098b0b30b26bf38", addr)
+
+	addr, err = resolveContractAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "c89ce4735882c9f0f0fe26686c53074e09b0d550", addr)
+
+	addr, err = resolveContractAddress("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", addr)
+
+	_, err = resolveContractAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550", &create2Params{})
+	assert.Error(t, err)
+
+	_, err = resolveContractAddress("", &create2Params{Deployer: "0x0000000000000000000000000000000000000000", Salt: "0x00"})
+	assert.Error(t, err)
+}
+
+func TestContractRegistryMessage(t *testing.T) {
+	m, err := contractRegistryMessage(registryFile{
+		Version: 1,
+		Chains: map[string]registryFileChain{
+			"polygon": {Core: "0x7A4B5a56256163F07b2C80A7cA55aBE66c4ec4d7", CoreCodeHash: "abababababababababababababababababababababababababababababababab"},
+			"bsc":     {Core: "0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B", CoreCodeHash: "0xabababababababababababababababababababababababababababababababab"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(m.Entries))
+	assert.Equal(t, uint32(vaa.ChainIDBSC), m.Entries[0].ChainId)
+	assert.Equal(t, uint32(vaa.ChainIDPolygon), m.Entries[1].ChainId)
+
+	// The template passes the same validation as an injected message.
+	_, err = adminContractRegistryToVAA(m, time.Unix(0, 0), 0, 0, 0)
+	assert.NoError(t, err)
+
+	_, err = contractRegistryMessage(registryFile{Chains: map[string]registryFileChain{"nope": {}}})
+	assert.Error(t, err)
+}
+
+func TestApplyContractRegistry(t *testing.T) {
+	bscCore := eth_common.HexToAddress("0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B")
+	r := &contractregistry.Registry{
+		Version: 1,
+		Chains:  map[vaa.ChainID]contractregistry.Contracts{vaa.ChainIDBSC: {Core: bscCore}},
+	}
+
+	bscContract, polygonContract := "", ""
+	require.NoError(t, applyContractRegistry(r, map[vaa.ChainID]*string{vaa.ChainIDBSC: &bscContract, vaa.ChainIDPolygon: &polygonContract}))
+	assert.Equal(t, bscCore.Hex(), bscContract)
+	assert.Equal(t, "", polygonContract)
+
+	// Matching flags are accepted, conflicting ones are not.
+	bscContract = "0x98f3c9e6e3face36baad05fe09d375ef1464288b"
+	assert.NoError(t, applyContractRegistry(r, map[vaa.ChainID]*string{vaa.ChainIDBSC: &bscContract}))
+	bscContract = "0xC89Ce4735882C9F0f0FE26686c53074E09B0D550"
+	assert.Error(t, applyContractRegistry(r, map[vaa.ChainID]*string{vaa.ChainIDBSC: &bscContract}))
+
+	// Without a registry, flags are left alone.
+	bscContract = ""
+	assert.NoError(t, applyContractRegistry(nil, map[vaa.ChainID]*string{vaa.ChainIDBSC: &bscContract}))
+	assert.Equal(t, "", bscContract)
+}
@@ -0,0 +1,91 @@
+package guardiand
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"go.uber.org/zap"
+)
+
+// How often to try fetching quarantined VAAs from other guardians until they are stored again. The processor drops
+// them until it knows the guardian set, so the first attempts after startup may fail.
+const dbHealInterval = 5 * time.Minute
+
+// dbIntegrityRunnable checks the integrity of the database at startup and then every interval, and fetches the
+// quarantined VAAs from the public RPC endpoints of other guardians until they are stored again.
+func dbIntegrityRunnable(logger *zap.Logger, d *db.Database, interval time.Duration, peers []string, signedInC chan<- *gossipv1.SignedVAAWithQuorum) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		check := func() {
+			start := time.Now()
+			report, err := d.CheckIntegrity(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error("failed to check database integrity", zap.Error(err))
+				}
+				return
+			}
+
+			for _, id := range report.Corrupt {
+				logger.Error("quarantined corrupt signed VAA", zap.String("message_id", string(id.Bytes())))
+			}
+			logger.Info("checked database integrity",
+				zap.Uint64("checked", report.Checked),
+				zap.Uint64("checksumsAdded", report.ChecksumsAdded),
+				zap.Int("corrupt", len(report.Corrupt)),
+				zap.Duration("duration", time.Since(start)))
+		}
+
+		c := &http.Client{}
+		heal := func() {
+			ids, err := d.UnhealedVAAs()
+			if err != nil {
+				logger.Error("failed to list quarantined VAAs", zap.Error(err))
+				return
+			}
+			if len(ids) == 0 {
+				return
+			}
+			if len(peers) == 0 {
+				logger.Warn("cannot fetch quarantined VAAs without --dbIntegrityPeers", zap.Int("unhealed", len(ids)))
+				return
+			}
+
+			for _, id := range ids {
+				// fetchMissingVAA shuffles the endpoints in place.
+				nodes := append([]string(nil), peers...)
+				ok, err := fetchMissingVAA(ctx, logger, signedInC, nodes, c, id.EmitterChain, id.EmitterAddress.String(), id.Sequence)
+				if err != nil {
+					logger.Warn("failed to fetch quarantined VAA", zap.String("message_id", string(id.Bytes())), zap.Error(err))
+				} else if !ok {
+					logger.Warn("no guardian returned quarantined VAA", zap.String("message_id", string(id.Bytes())))
+				}
+			}
+		}
+
+		check()
+		heal()
+
+		checkTicker := time.NewTicker(interval)
+		defer checkTicker.Stop()
+		healTicker := time.NewTicker(dbHealInterval)
+		defer healTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-checkTicker.C:
+				check()
+				heal()
+			case <-healTicker.C:
+				heal()
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package guardiand
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	"go.uber.org/zap"
+)
+
+// How long to wait for the heartbeat announcing that the node is going offline to be published, in addition to the
+// drain timeout.
+const drainHeartbeatTimeout = 5 * time.Second
+
+// handleShutdown drains the node once a signal is received or the drain is started over the admin RPC, and then
+// cancels the root context. A second signal cancels it immediately.
+func handleShutdown(logger *zap.Logger, sigC <-chan os.Signal, drain *common.Drain, database *db.Database, timeout time.Duration, cancel context.CancelFunc, done <-chan struct{}) {
+	select {
+	case sig := <-sigC:
+		logger.Info("received signal, draining the node before shutting down", zap.Stringer("signal", sig))
+	case <-drain.Started():
+		logger.Info("drain requested, draining the node before shutting down")
+	case <-done:
+		return
+	}
+
+	go func() {
+		select {
+		case sig := <-sigC:
+			logger.Warn("received signal while draining, shutting down immediately", zap.Stringer("signal", sig))
+			cancel()
+		case <-done:
+		}
+	}()
+
+	drainNode(logger, drain, database, timeout, p2p.DefaultRegistry.SetGoingOffline)
+	cancel()
+}
+
+// drainNode stops the node from taking on new work, waits for the work in flight to finish or the timeout to expire,
+// flushes the database and announces that the node is going offline.
+func drainNode(logger *zap.Logger, drain *common.Drain, database *db.Database, timeout time.Duration, announce func() <-chan struct{}) {
+	start := time.Now()
+	drain.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := drain.Wait(ctx); err != nil {
+		logger.Warn("drain timed out, shutting down with work in flight", zap.Strings("pending", drain.Pending()), zap.Duration("timeout", timeout))
+	}
+
+	if database != nil {
+		if err := database.Sync(); err != nil {
+			logger.Error("failed to flush the database", zap.Error(err))
+		}
+	}
+
+	select {
+	case <-announce():
+	case <-time.After(drainHeartbeatTimeout):
+		logger.Warn("timed out announcing that the node is going offline")
+	}
+
+	logger.Info("drained, shutting down", zap.Duration("duration", time.Since(start)))
+}
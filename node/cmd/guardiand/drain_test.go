@@ -0,0 +1,51 @@
+package guardiand
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDrainNode(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	drain := common.NewDrain()
+	drain.Add("processor")
+
+	// The node only announces that it is going offline once the processor finished.
+	announced := false
+	announce := func() <-chan struct{} {
+		assert.Empty(t, drain.Pending())
+		announced = true
+		c := make(chan struct{})
+		close(c)
+		return c
+	}
+
+	go func() {
+		<-drain.Started()
+		drain.Finish("processor")
+	}()
+	drainNode(zap.NewNop(), drain, database, time.Minute, announce)
+	assert.True(t, announced)
+}
+
+func TestDrainNodeTimeout(t *testing.T) {
+	drain := common.NewDrain()
+	drain.Add("processor")
+
+	announced := make(chan struct{})
+	close(announced)
+	start := time.Now()
+	drainNode(zap.NewNop(), drain, nil, 10*time.Millisecond, func() <-chan struct{} { return announced })
+	assert.True(t, drain.IsStarted())
+	assert.Less(t, time.Since(start), drainHeartbeatTimeout)
+	assert.Equal(t, []string{"processor"}, drain.Pending())
+}
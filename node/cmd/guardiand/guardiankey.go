@@ -1,6 +1,7 @@
 package guardiand
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/certusone/wormhole/node/pkg/common"
 
@@ -17,10 +19,16 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/certusone/wormhole/node/pkg/devnet"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
 )
 
-var keyDescription *string
+var (
+	keyDescription *string
+	keyEncrypt     *bool
+	keyFrom        *string
+	keyPassphrase  *string
+)
 
 const (
 	GuardianKeyArmoredBlock = "WORMHOLE GUARDIAN PRIVATE KEY"
@@ -28,6 +36,9 @@ const (
 
 func init() {
 	keyDescription = KeygenCmd.Flags().String("desc", "", "Human-readable key description (optional)")
+	keyEncrypt = KeygenCmd.Flags().Bool("encrypt", false, "Encrypt the key with a passphrase")
+	keyFrom = KeygenCmd.Flags().String("from", "", "Existing key file to write to KEYFILE instead of generating a new key, e.g. to encrypt a plaintext key (optional)")
+	keyPassphrase = KeygenCmd.Flags().String("passphrase", "", "Source of the passphrase for --encrypt, like --guardianKeyPassphrase of the node (default: prompt)")
 }
 
 var KeygenCmd = &cobra.Command{
@@ -41,16 +52,82 @@ func runKeygen(cmd *cobra.Command, args []string) {
 	common.LockMemory()
 	common.SetRestrictiveUmask()
 
-	log.Print("Creating new key at ", args[0])
-
-	gk, err := ecdsa.GenerateKey(ethcrypto.S256(), rand.Reader)
+	var gk *ecdsa.PrivateKey
+	var err error
+	if *keyFrom != "" {
+		log.Printf("Copying key from %s to %s", *keyFrom, args[0])
+		gk, err = loadGuardianKey(*keyFrom)
+		if err != nil {
+			log.Fatalf("failed to load key: %v", err)
+		}
+	} else {
+		log.Print("Creating new key at ", args[0])
+		gk, err = ecdsa.GenerateKey(ethcrypto.S256(), rand.Reader)
+		if err != nil {
+			log.Fatalf("failed to generate key: %v", err)
+		}
+	}
+
+	var passphrase string
+	if *keyEncrypt {
+		if *keyPassphrase != "" {
+			passphrase, err = readPassphrase(*keyPassphrase)
+		} else {
+			passphrase, err = promptPassphrase(true)
+		}
+		if err != nil {
+			log.Fatalf("failed to read passphrase: %v", err)
+		}
+	}
+
+	err = writeGuardianKey(gk, *keyDescription, args[0], false, passphrase)
 	if err != nil {
-		log.Fatalf("failed to generate key: %v", err)
+		log.Fatalf("failed to write key: %v", err)
 	}
 
-	err = writeGuardianKey(gk, *keyDescription, args[0], false)
-	if err != nil {
-		log.Fatalf("failed to write key: %v", err)
+	if *keyFrom != "" {
+		log.Printf("Verify that %s can be loaded, then securely delete %s", args[0], *keyFrom)
+	}
+}
+
+// isExternalGuardianKey returns whether location refers to a guardian key in a cloud KMS or an HSM rather than a key file.
+func isExternalGuardianKey(location string) bool {
+	return strings.HasPrefix(location, "awskms://") || strings.HasPrefix(location, "gcpkms://") || strings.HasPrefix(location, "pkcs11:") ||
+		isTSSGuardianKey(location)
+}
+
+// isTSSGuardianKey returns whether location refers to a guardian key split across the parties of a threshold
+// signature scheme.
+func isTSSGuardianKey(location string) bool {
+	return strings.HasPrefix(location, "tss://")
+}
+
+// newGuardianSigner returns a signer for the guardian key at location, which is either the path of a key file,
+// awskms://<key ID, ARN or alias>, gcpkms://<key version resource name>, a PKCS#11 URI or a tss:// URI.
+func newGuardianSigner(ctx context.Context, location string) (guardiansigner.GuardianSigner, error) {
+	switch {
+	case strings.HasPrefix(location, "awskms://"):
+		return guardiansigner.NewAWSKMSSigner(ctx, strings.TrimPrefix(location, "awskms://"))
+	case strings.HasPrefix(location, "gcpkms://"):
+		return guardiansigner.NewGCPKMSSigner(ctx, strings.TrimPrefix(location, "gcpkms://"))
+	case strings.HasPrefix(location, "pkcs11:"):
+		c, err := guardiansigner.ParsePKCS11URI(location)
+		if err != nil {
+			return nil, err
+		}
+		return guardiansigner.NewPKCS11Signer(c)
+	case isTSSGuardianKey(location):
+		c, err := guardiansigner.ParseTSSURI(location)
+		if err != nil {
+			return nil, err
+		}
+		return guardiansigner.NewTSSSigner(ctx, c)
+	default:
+		gk, err := loadGuardianKey(location)
+		if err != nil {
+			return nil, err
+		}
+		return guardiansigner.NewLocalSigner(gk), nil
 	}
 }
 
@@ -66,7 +143,7 @@ func loadGuardianKey(filename string) (*ecdsa.PrivateKey, error) {
 		return nil, fmt.Errorf("failed to read armored file: %w", err)
 	}
 
-	if p.Type != GuardianKeyArmoredBlock {
+	if p.Type != GuardianKeyArmoredBlock && p.Type != GuardianKeyEncryptedArmoredBlock {
 		return nil, fmt.Errorf("invalid block type: %s", p.Type)
 	}
 
@@ -75,6 +152,16 @@ func loadGuardianKey(filename string) (*ecdsa.PrivateKey, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	if p.Type == GuardianKeyEncryptedArmoredBlock {
+		passphrase, err := guardianKeyPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		if b, err = decryptGuardianKey(b, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
 	var m nodev1.GuardianKey
 	err = proto.Unmarshal(b, &m)
 	if err != nil {
@@ -93,8 +180,8 @@ func loadGuardianKey(filename string) (*ecdsa.PrivateKey, error) {
 	return gk, nil
 }
 
-// writeGuardianKey serializes a guardian key and writes it to disk.
-func writeGuardianKey(key *ecdsa.PrivateKey, description string, filename string, unsafe bool) error {
+// writeGuardianKey serializes a guardian key and writes it to disk, encrypted if a passphrase is given.
+func writeGuardianKey(key *ecdsa.PrivateKey, description string, filename string, unsafe bool, passphrase string) error {
 	if _, err := os.Stat(filename); !os.IsNotExist(err) {
 		return errors.New("refusing to override existing key")
 	}
@@ -111,6 +198,14 @@ func writeGuardianKey(key *ecdsa.PrivateKey, description string, filename string
 		panic(err)
 	}
 
+	blockType := GuardianKeyArmoredBlock
+	if passphrase != "" {
+		blockType = GuardianKeyEncryptedArmoredBlock
+		if b, err = encryptGuardianKey(b, passphrase); err != nil {
+			return fmt.Errorf("failed to encrypt key: %w", err)
+		}
+	}
+
 	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
@@ -122,7 +217,7 @@ func writeGuardianKey(key *ecdsa.PrivateKey, description string, filename string
 	if description != "" {
 		headers["Description"] = description
 	}
-	a, err := armor.Encode(f, GuardianKeyArmoredBlock, headers)
+	a, err := armor.Encode(f, blockType, headers)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,167 @@
+package guardiand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"golang.org/x/term"
+)
+
+const (
+	GuardianKeyEncryptedArmoredBlock = "WORMHOLE ENCRYPTED GUARDIAN PRIVATE KEY"
+
+	// defaultPassphraseCredential is the systemd credential used if no passphrase source is configured.
+	defaultPassphraseCredential = "guardian-key-passphrase"
+)
+
+// keystoreScryptN is the scrypt cost of newly encrypted keys (256 MB of memory and about a second of CPU time).
+var keystoreScryptN = keystore.StandardScryptN
+
+var passphraseCache struct {
+	sync.Mutex
+	read       bool
+	passphrase string
+}
+
+// guardianKeyPassphrase returns the passphrase of encrypted guardian keys from --guardianKeyPassphrase. It is read
+// only once, since file descriptors and environment variables are consumed by reading them.
+func guardianKeyPassphrase() (string, error) {
+	passphraseCache.Lock()
+	defer passphraseCache.Unlock()
+
+	if !passphraseCache.read {
+		p, err := readPassphrase(*guardianKeyPassphraseSource)
+		if err != nil {
+			return "", err
+		}
+		passphraseCache.passphrase = p
+		passphraseCache.read = true
+	}
+	return passphraseCache.passphrase, nil
+}
+
+// readPassphrase reads a passphrase from source, which is one of
+//
+//	env:<variable>        an environment variable, which is cleared after reading
+//	fd:<n>                a file descriptor, e.g. a pipe set up by the service manager
+//	file:<path>           a file
+//	systemd:<credential>  a systemd credential (LoadCredential= or LoadCredentialEncrypted=)
+//
+// If source is empty, the systemd credential guardian-key-passphrase is used if present, and otherwise the
+// passphrase is prompted for on the terminal.
+func readPassphrase(source string) (string, error) {
+	kind, arg := source, ""
+	if i := strings.IndexByte(source, ':'); i >= 0 {
+		kind, arg = source[:i], source[i+1:]
+	}
+
+	var b []byte
+	var err error
+	switch kind {
+	case "":
+		if dir := os.Getenv("CREDENTIALS_DIRECTORY"); dir != "" {
+			if b, err = ioutil.ReadFile(filepath.Join(dir, defaultPassphraseCredential)); err == nil {
+				break
+			}
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", errors.New("the guardian key is encrypted, but no passphrase source is configured (see --guardianKeyPassphrase)")
+		}
+		return promptPassphrase(false)
+	case "env":
+		v, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", arg)
+		}
+		// Don't leak the passphrase to child processes or crash dumps of the environment.
+		os.Unsetenv(arg)
+		b = []byte(v)
+	case "fd":
+		fd, err := strconv.Atoi(arg)
+		if err != nil || fd < 0 {
+			return "", fmt.Errorf("invalid file descriptor %q", arg)
+		}
+		f := os.NewFile(uintptr(fd), "passphrase")
+		b, err = ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase from file descriptor %d: %w", fd, err)
+		}
+	case "file":
+		if b, err = ioutil.ReadFile(arg); err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+	case "systemd":
+		dir := os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", errors.New("no systemd credentials available (CREDENTIALS_DIRECTORY is not set)")
+		}
+		if b, err = ioutil.ReadFile(filepath.Join(dir, arg)); err != nil {
+			return "", fmt.Errorf("failed to read systemd credential: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("invalid passphrase source %q", source)
+	}
+
+	p := strings.TrimRight(string(b), "\r\n")
+	if p == "" {
+		return "", errors.New("empty passphrase")
+	}
+	return p, nil
+}
+
+// promptPassphrase reads a passphrase from the terminal, twice if confirm is set.
+func promptPassphrase(confirm bool) (string, error) {
+	fmt.Fprint(os.Stderr, "Guardian key passphrase: ")
+	p, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(p) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		r, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if string(r) != string(p) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(p), nil
+}
+
+// encryptGuardianKey encrypts a serialized guardian key with scrypt and AES-128-CTR (Web3 Secret Storage).
+func encryptGuardianKey(b []byte, passphrase string) ([]byte, error) {
+	c, err := keystore.EncryptDataV3(b, []byte(passphrase), keystoreScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// decryptGuardianKey decrypts a serialized guardian key encrypted by encryptGuardianKey.
+func decryptGuardianKey(b []byte, passphrase string) ([]byte, error) {
+	var c keystore.CryptoJSON
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted key: %w", err)
+	}
+	d, err := keystore.DecryptDataV3(c, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key (wrong passphrase?): %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,95 @@
+package guardiand
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetPassphraseCache() {
+	passphraseCache.Lock()
+	passphraseCache.read = false
+	passphraseCache.passphrase = ""
+	passphraseCache.Unlock()
+}
+
+func TestEncryptedGuardianKey(t *testing.T) {
+	keystoreScryptN = keystore.LightScryptN
+	defer func() { keystoreScryptN = keystore.StandardScryptN }()
+	defer resetPassphraseCache()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "guardian.key")
+	require.NoError(t, writeGuardianKey(key, "test", path, false, "correct horse"))
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), GuardianKeyEncryptedArmoredBlock)
+
+	source := "env:TEST_GUARDIAN_KEY_PASSPHRASE"
+	defer func(old *string) { guardianKeyPassphraseSource = old }(guardianKeyPassphraseSource)
+	guardianKeyPassphraseSource = &source
+
+	resetPassphraseCache()
+	t.Setenv("TEST_GUARDIAN_KEY_PASSPHRASE", "wrong")
+	_, err = loadGuardianKey(path)
+	assert.Error(t, err)
+
+	resetPassphraseCache()
+	t.Setenv("TEST_GUARDIAN_KEY_PASSPHRASE", "correct horse")
+	loaded, err := loadGuardianKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key.D, loaded.D)
+
+	// The variable is cleared after reading.
+	_, ok := os.LookupEnv("TEST_GUARDIAN_KEY_PASSPHRASE")
+	assert.False(t, ok)
+
+	// The passphrase is cached.
+	loaded, err = loadGuardianKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key.D, loaded.D)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pass"), []byte("from file\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, defaultPassphraseCredential), []byte("from systemd"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "empty"), []byte("\n"), 0600))
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	p, err := readPassphrase("file:" + filepath.Join(dir, "pass"))
+	require.NoError(t, err)
+	assert.Equal(t, "from file", p)
+
+	p, err = readPassphrase("systemd:" + defaultPassphraseCredential)
+	require.NoError(t, err)
+	assert.Equal(t, "from systemd", p)
+
+	// The default systemd credential is used if no source is configured.
+	p, err = readPassphrase("")
+	require.NoError(t, err)
+	assert.Equal(t, "from systemd", p)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("from fd\n"))
+	require.NoError(t, err)
+	w.Close()
+	p, err = readPassphrase("fd:" + strconv.Itoa(int(r.Fd())))
+	require.NoError(t, err)
+	assert.Equal(t, "from fd", p)
+
+	for _, source := range []string{"file:" + filepath.Join(dir, "empty"), "env:TEST_UNSET_PASSPHRASE", "fd:x", "systemd:missing", "vault:secret"} {
+		_, err := readPassphrase(source)
+		assert.Error(t, err, source)
+	}
+}
@@ -0,0 +1,258 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// A multinode config file runs a guardian node for each of several networks, such as mainnet and testnet, from a single
+// service. The options under common apply to every network, and each network overrides them with its own:
+//
+//	common:
+//	  nodeName: my-guardian
+//	  dataDir: /var/lib/guardiand
+//	  adminSocket: /run/guardiand/admin.sock
+//	networks:
+//	  mainnet:
+//	    network: /wormhole/mainnet/2
+//	    guardianKey: /var/lib/guardiand/mainnet.key
+//	  testnet:
+//	    network: /wormhole/testnet/2/1
+//	    testnetMode: true
+//	    port: 8998
+//	    statusAddr: "[::]:6061"
+//
+// Each network runs as a child node process, since the metrics, the p2p registry and the flags of a node are global to
+// its process. This only saves running a service per network: each child is a full node, so the memory and CPU used
+// are those of separate nodes.
+
+// Options inherited from common which are namespaced by network, so that the networks do not share their state or their
+// p2p identity.
+var multiNodeNamespaced = []string{"dataDir", "adminSocket", "signingAuditLog", "nodeKey"}
+
+// Options which must differ between networks, unless disabled. Each network must be given its own guardian key.
+var multiNodeExclusive = []string{"network", "guardianKey", "port", "statusAddr", "adminListenAddr", "publicRPC", "publicWeb"}
+
+// How long to wait before restarting the node of a network which exited.
+const multiNodeRestartDelay = 5 * time.Second
+
+var MultiNodeCmd = &cobra.Command{
+	Use:   "multinode [FILENAME]",
+	Short: "Run a guardian node for each network in a multinode config file",
+	Run:   runMultiNode,
+	Args:  cobra.ExactArgs(1),
+}
+
+// networkNode is the node of one network, run as `guardiand node` with args.
+type networkNode struct {
+	name string
+	args []string
+}
+
+// namespacedPath returns the path of a file or directory inherited from common for the given network. Files get the
+// network name before their extension, directories get a subdirectory.
+func namespacedPath(option string, p string, network string) string {
+	if option == "dataDir" {
+		return path.Join(p, network)
+	}
+	ext := filepath.Ext(p)
+	return strings.TrimSuffix(p, ext) + "." + network + ext
+}
+
+// loadMultiNodeConfig returns the nodes of the networks in a multinode config file, sorted by name.
+func loadMultiNodeConfig(v *viper.Viper, flags *pflag.FlagSet) ([]networkNode, error) {
+	// Viper keys are case insensitive.
+	byKey := make(map[string]*pflag.Flag)
+	flags.VisitAll(func(f *pflag.Flag) {
+		byKey[strings.ToLower(f.Name)] = f
+	})
+
+	for _, key := range v.AllKeys() {
+		if !strings.HasPrefix(key, "common.") && !strings.HasPrefix(key, "networks.") {
+			return nil, fmt.Errorf("unknown section %s", key)
+		}
+	}
+
+	common := v.GetStringMap("common")
+	networks := v.GetStringMap("networks")
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no networks configured")
+	}
+
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make([]networkNode, 0, len(names))
+	// Values of the exclusive options, by option and value, to the network using them.
+	used := make(map[string]map[string]string)
+	for _, name := range names {
+		options, ok := networks[name].(map[string]interface{})
+		if !ok && networks[name] != nil {
+			return nil, fmt.Errorf("network %s: expected a map of options", name)
+		}
+
+		values := make(map[string]string)
+		for key, value := range common {
+			if _, ok := byKey[key]; !ok {
+				return nil, fmt.Errorf("common: unknown option %s", key)
+			}
+			values[key] = configValue(value)
+		}
+		for _, option := range multiNodeNamespaced {
+			key := strings.ToLower(option)
+			if p, ok := values[key]; ok && p != "" {
+				values[key] = namespacedPath(option, p, name)
+			}
+		}
+		for key, value := range options {
+			if _, ok := byKey[key]; !ok {
+				return nil, fmt.Errorf("network %s: unknown option %s", name, key)
+			}
+			values[key] = configValue(value)
+		}
+
+		for _, option := range multiNodeExclusive {
+			f := byKey[strings.ToLower(option)]
+			value, ok := values[strings.ToLower(option)]
+			if !ok {
+				value = f.DefValue
+			}
+			if value == "" {
+				continue
+			}
+			if used[option] == nil {
+				used[option] = make(map[string]string)
+			}
+			if other, ok := used[option][value]; ok {
+				return nil, fmt.Errorf("networks %s and %s both use %s %s", other, name, option, value)
+			}
+			used[option][value] = name
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		args := make([]string, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, fmt.Sprintf("--%s=%s", byKey[key].Name, values[key]))
+		}
+		nodes = append(nodes, networkNode{name: name, args: args})
+	}
+
+	return nodes, nil
+}
+
+func runMultiNode(cmd *cobra.Command, args []string) {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	logger = logger.Named("multinode")
+
+	v := viper.New()
+	v.SetConfigFile(args[0])
+	if err := v.ReadInConfig(); err != nil {
+		logger.Fatal("failed to read multinode config file", zap.Error(err))
+	}
+	nodes, err := loadMultiNodeConfig(v, NodeCmd.Flags())
+	if err != nil {
+		logger.Fatal("invalid multinode config file", zap.Error(err))
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		logger.Fatal("failed to locate the guardiand binary", zap.Error(err))
+	}
+
+	// Signals are forwarded to every node, which drains on the first and exits immediately on the second.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigC := make(chan os.Signal, 2)
+	signal.Notify(sigC, syscall.SIGTERM, syscall.SIGINT)
+
+	var mu sync.Mutex
+	running := make(map[string]*exec.Cmd)
+	go func() {
+		for sig := range sigC {
+			logger.Info("forwarding signal to the nodes", zap.Stringer("signal", sig))
+			cancel()
+			mu.Lock()
+			for name, c := range running {
+				if err := c.Process.Signal(sig); err != nil {
+					logger.Warn("failed to forward signal", zap.String("network", name), zap.Error(err))
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n networkNode) {
+			defer wg.Done()
+			for {
+				c := exec.Command(executable, append([]string{"node"}, n.args...)...) // #nosec G204 the arguments come from the operator's config file
+				c.Stdout = os.Stdout
+				c.Stderr = os.Stderr
+				// The nodes only get the signals forwarded to them, not those sent to the terminal's process group.
+				c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+				mu.Lock()
+				if ctx.Err() != nil {
+					mu.Unlock()
+					return
+				}
+				logger.Info("starting node", zap.String("network", n.name))
+				err := c.Start()
+				if err == nil {
+					running[n.name] = c
+				}
+				mu.Unlock()
+
+				if err == nil {
+					err = c.Wait()
+					mu.Lock()
+					delete(running, n.name)
+					mu.Unlock()
+				}
+
+				if ctx.Err() != nil {
+					logger.Info("node exited", zap.String("network", n.name), zap.Error(err))
+					return
+				}
+				logger.Error("node exited unexpectedly, restarting", zap.String("network", n.name), zap.Error(err), zap.Duration("delay", multiNodeRestartDelay))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(multiNodeRestartDelay):
+				}
+			}
+		}(n)
+	}
+
+	wg.Wait()
+	logger.Info("all nodes exited")
+}
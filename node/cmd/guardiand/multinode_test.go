@@ -0,0 +1,96 @@
+package guardiand
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultiNodeTestFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("nodeName", "", "")
+	flags.String("dataDir", "", "")
+	flags.String("adminSocket", "", "")
+	flags.String("signingAuditLog", "", "")
+	flags.String("nodeKey", "", "")
+	flags.String("guardianKey", "", "")
+	flags.String("network", "/wormhole/dev", "")
+	flags.Uint("port", 8999, "")
+	flags.String("statusAddr", "[::]:6060", "")
+	flags.String("adminListenAddr", "", "")
+	flags.String("publicRPC", "", "")
+	flags.String("publicWeb", "", "")
+	flags.Bool("testnetMode", false, "")
+	flags.StringSlice("peers", nil, "")
+	return flags
+}
+
+const testMultiNodeConfig = `
+common:
+  nodeName: my-guardian
+  dataDir: /var/lib/guardiand
+  adminSocket: /run/guardiand/admin.sock
+  nodeKey: /var/lib/guardiand/node.key
+  peers: [a, b]
+networks:
+  mainnet:
+    network: /wormhole/mainnet/2
+    guardianKey: /var/lib/guardiand/mainnet.key
+  testnet:
+    network: /wormhole/testnet/2/1
+    guardianKey: /var/lib/guardiand/testnet.key
+    testnetMode: true
+    port: 8998
+    statusAddr: "[::]:6061"
+    adminSocket: /run/guardiand/testnet.sock
+`
+
+func TestLoadMultiNodeConfig(t *testing.T) {
+	nodes, err := loadMultiNodeConfig(readTestConfig(t, "multinode.yaml", testMultiNodeConfig), newMultiNodeTestFlags())
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	assert.Equal(t, "mainnet", nodes[0].name)
+	assert.Equal(t, []string{
+		"--adminSocket=/run/guardiand/admin.mainnet.sock",
+		"--dataDir=/var/lib/guardiand/mainnet",
+		"--guardianKey=/var/lib/guardiand/mainnet.key",
+		"--network=/wormhole/mainnet/2",
+		"--nodeKey=/var/lib/guardiand/node.mainnet.key",
+		"--nodeName=my-guardian",
+		"--peers=a,b",
+	}, nodes[0].args)
+
+	// Options set for the network itself are not namespaced.
+	assert.Equal(t, "testnet", nodes[1].name)
+	assert.Equal(t, []string{
+		"--adminSocket=/run/guardiand/testnet.sock",
+		"--dataDir=/var/lib/guardiand/testnet",
+		"--guardianKey=/var/lib/guardiand/testnet.key",
+		"--network=/wormhole/testnet/2/1",
+		"--nodeKey=/var/lib/guardiand/node.testnet.key",
+		"--nodeName=my-guardian",
+		"--peers=a,b",
+		"--port=8998",
+		"--statusAddr=[::]:6061",
+		"--testnetMode=true",
+	}, nodes[1].args)
+}
+
+func TestLoadMultiNodeConfigValidation(t *testing.T) {
+	for config, expected := range map[string]string{
+		"common:\n  nodeName: a\n":                                                                                            "no networks configured",
+		"nodeName: a\nnetworks:\n  mainnet:\n    network: /a\n":                                                               "unknown section nodename",
+		"common:\n  notAFlag: a\nnetworks:\n  mainnet:\n    network: /a\n":                                                    "common: unknown option notaflag",
+		"networks:\n  mainnet:\n    notAFlag: a\n":                                                                            "network mainnet: unknown option notaflag",
+		"networks:\n  mainnet:\n    network: /a\n  testnet:\n    network: /b\n":                                               "networks mainnet and testnet both use port 8999",
+		"networks:\n  mainnet:\n    network: /a\n    port: 1\n  testnet:\n    network: /a\n":                                  "networks mainnet and testnet both use network /a",
+		"common:\n  guardianKey: /k\nnetworks:\n  mainnet:\n    port: 1\n    statusAddr: \"\"\n  testnet:\n    network: /b\n": "networks mainnet and testnet both use guardianKey /k",
+		"common:\n  publicRPC: a\nnetworks:\n  mainnet:\n    port: 1\n    statusAddr: \"\"\n  testnet:\n    network: /b\n":    "networks mainnet and testnet both use publicRPC a",
+	} {
+		_, err := loadMultiNodeConfig(readTestConfig(t, "multinode.yaml", config), newMultiNodeTestFlags())
+		assert.ErrorContains(t, err, expected, config)
+	}
+}
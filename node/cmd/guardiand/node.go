@@ -3,18 +3,24 @@ package guardiand
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // #nosec G108 we are using a custom router (`router := mux.NewRouter()`) and thus not automatically expose pprof.
 	"os"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/benbjohnson/clock"
 	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/networkconfig"
 	"github.com/certusone/wormhole/node/pkg/notify/discord"
 	"github.com/certusone/wormhole/node/pkg/telemetry"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/version"
 	"github.com/gagliardetto/solana-go/rpc"
 	"go.uber.org/zap/zapcore"
@@ -23,23 +29,36 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/certusone/wormhole/node/pkg/accountant"
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/contractregistry"
 	"github.com/certusone/wormhole/node/pkg/devnet"
 	"github.com/certusone/wormhole/node/pkg/ethereum"
+	"github.com/certusone/wormhole/node/pkg/eventbus"
+	"github.com/certusone/wormhole/node/pkg/faultinject"
+	"github.com/certusone/wormhole/node/pkg/featureflags"
 	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	"github.com/certusone/wormhole/node/pkg/processor"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/publicrpc"
 	"github.com/certusone/wormhole/node/pkg/readiness"
+	"github.com/certusone/wormhole/node/pkg/redemption"
 	"github.com/certusone/wormhole/node/pkg/reporter"
 	solana "github.com/certusone/wormhole/node/pkg/solana"
+	"github.com/certusone/wormhole/node/pkg/statuspage"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	"github.com/certusone/wormhole/node/pkg/watchercontrol"
 	eth_common "github.com/ethereum/go-ethereum/common"
-	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	cosmwasm "github.com/certusone/wormhole/node/pkg/terra"
@@ -52,20 +71,54 @@ import (
 )
 
 var (
-	p2pNetworkID *string
-	p2pPort      *uint
-	p2pBootstrap *string
+	p2pNetworkID  *string
+	p2pPort       *uint
+	p2pListen     *[]string
+	p2pAnnounce   *[]string
+	p2pNoAnnounce *[]string
+	p2pBootstrap  *string
 
 	nodeKeyPath *string
 
 	adminSocketPath *string
 
-	dataDir *string
+	adminListenAddr      *string
+	adminTLSCert         *string
+	adminTLSKey          *string
+	adminTLSClientCA     *string
+	adminAuthzConfigPath *string
 
-	statusAddr *string
+	referenceRPCConfigPath *string
 
-	guardianKeyPath *string
-	solanaContract  *string
+	readinessConfigPath *string
+
+	dataDir             *string
+	vaaStore            *string
+	retentionConfigPath *string
+
+	dbIntegrityInterval *time.Duration
+	dbIntegrityPeers    *[]string
+
+	watchdogMaxMemoryMB   *uint64
+	watchdogMaxOpenFiles  *uint64
+	watchdogMaxDiskMB     *uint64
+	watchdogMaxGoroutines *uint64
+	watchdogInterval      *time.Duration
+
+	statusAddr           *string
+	statusSupervisorTree *bool
+	statusPage           *bool
+
+	guardianKeyPath             *string
+	guardianKeyFallbackPath     *string
+	guardianKeyPassphraseSource *string
+	nextGuardianKeyPath         *string
+	gossipKeyPath               *string
+	gossipKeyDelegationValidity *time.Duration
+	signingAuditLogPath         *string
+	signingAnomalyConfigPath    *string
+	experimentalTSS             *bool
+	solanaContract              *string
 
 	ethRPC      *string
 	ethContract *string
@@ -121,6 +174,10 @@ var (
 	injectiveLCD      *string
 	injectiveContract *string
 
+	wormchainWS       *string
+	wormchainLCD      *string
+	wormchainContract *string
+
 	algorandIndexerRPC   *string
 	algorandIndexerToken *string
 	algorandAlgodRPC     *string
@@ -134,9 +191,15 @@ var (
 	aptosAccount *string
 	aptosHandle  *string
 
+	aptosReconcileInterval *time.Duration
+	aptosAutoBackfill      *bool
+
 	solanaWsRPC *string
 	solanaRPC   *string
 
+	solanaSecondaryRPC  *string
+	pythnetSecondaryRPC *string
+
 	pythnetContract *string
 	pythnetWsRPC    *string
 	pythnetRPC      *string
@@ -151,14 +214,30 @@ var (
 	publicRPC *string
 	publicWeb *string
 
+	publicRPCRateLimit      *float64
+	publicRPCRateBurst      *int
+	publicRPCAPIKeysPath    *string
+	publicRPCMaxRequestSize *int
+	publicWebCORSOrigins    *[]string
+
 	tlsHostname *string
 	tlsProdEnv  *bool
 
 	disableHeartbeatVerify *bool
 	disableTelemetry       *bool
 
+	observerMode *bool
+
+	featureFlags *[]string
+
 	telemetryKey *string
 
+	logShippingConfig *string
+
+	otlpEndpoint     *string
+	otlpInsecure     *bool
+	traceSampleRatio *float64
+
 	discordToken   *string
 	discordChannel *string
 
@@ -169,23 +248,94 @@ var (
 	bigTableTopicName          *string
 	bigTableKeyPath            *string
 
-	chainGovernorEnabled *bool
+	chainGovernorEnabled          *bool
+	chainGovernorNFTNotional      *uint64
+	chainGovernorNFTApproval      *bool
+	chainGovernorPayload3Notional *uint64
+	chainGovernorPayload3Approval *bool
+	chainGovernorFlowCancel       *bool
+
+	chainGovernorPayloadRegistry         *string
+	chainGovernorPayloadRegistryApproval *bool
+
+	chainGovernorEmergencyHoldThreshold *uint64
+	chainGovernorEmergencyReleaseQuorum *int
+
+	accountantEnabled *bool
+	accountantLogOnly *bool
+
+	accountantReconcileEnabled *bool
+	accountantEmitterConfig    *string
+
+	redemptionTrackerEnabled    *bool
+	redemptionTrackerMinValue   *uint64
+	redemptionTrackerStuckAfter *time.Duration
+	redemptionTrackerMaxAge     *time.Duration
+
+	traceVerificationChains   *[]string
+	traceVerificationMinValue *uint64
+
+	contractRegistryPath *string
+
+	signingRateLimit          *uint64
+	signingRateLimitOverrides *[]string
+	signingRateLimitBypass    *[]string
+
+	circuitBreakerSpikeFactor        *float64
+	circuitBreakerSpikeMinMessages   *uint64
+	circuitBreakerNewEmitterMinValue *uint64
+
+	drainTimeout *time.Duration
 )
 
 func init() {
 	p2pNetworkID = NodeCmd.Flags().String("network", "/wormhole/dev", "P2P network identifier")
 	p2pPort = NodeCmd.Flags().Uint("port", 8999, "P2P UDP listener port")
+	p2pListen = NodeCmd.Flags().StringSlice("p2pListen", nil, "P2P QUIC multiaddrs to listen on, e.g. /ip4/0.0.0.0/udp/8999/quic,/ip6/::/udp/8998/quic (defaults to --port on all IPv4 and IPv6 interfaces)")
+	p2pAnnounce = NodeCmd.Flags().StringSlice("p2pAnnounce", nil, "P2P multiaddrs advertised to peers instead of the listen addresses, e.g. the public address behind a NAT (optional)")
+	p2pNoAnnounce = NodeCmd.Flags().StringSlice("p2pNoAnnounce", nil, "P2P multiaddrs, or IP ranges like /ip4/10.0.0.0/ipcidr/8, never advertised to peers (optional)")
 	p2pBootstrap = NodeCmd.Flags().String("bootstrap", "", "P2P bootstrap peers (comma-separated)")
 
 	statusAddr = NodeCmd.Flags().String("statusAddr", "[::]:6060", "Listen address for status server (disabled if blank)")
+	statusSupervisorTree = NodeCmd.Flags().Bool("statusSupervisorTree", false, "Expose the state of the supervised runnables, including their last errors, at /debug/supervisor on the status server")
+	statusPage = NodeCmd.Flags().Bool("statusPage", false, "Serve a read-only status page at /status on the status server")
 
 	nodeKeyPath = NodeCmd.Flags().String("nodeKey", "", "Path to node key (will be generated if it doesn't exist)")
 
 	adminSocketPath = NodeCmd.Flags().String("adminSocket", "", "Admin gRPC service UNIX domain socket path")
 
-	dataDir = NodeCmd.Flags().String("dataDir", "", "Data directory")
+	adminListenAddr = NodeCmd.Flags().String("adminListenAddr", "", "Listen address for the admin gRPC service over mutual TLS (disabled if blank)")
+	adminTLSCert = NodeCmd.Flags().String("adminTLSCert", "", "Path to the TLS certificate of the admin TCP listener")
+	adminTLSKey = NodeCmd.Flags().String("adminTLSKey", "", "Path to the TLS key of the admin TCP listener")
+	adminTLSClientCA = NodeCmd.Flags().String("adminTLSClientCA", "", "Path to the CA certificates that issue the client certificates of the admin TCP listener")
+	adminAuthzConfigPath = NodeCmd.Flags().String("adminAuthzConfig", "", "Path to a JSON file listing the clients allowed on the admin TCP listener and their roles")
 
-	guardianKeyPath = NodeCmd.Flags().String("guardianKey", "", "Path to guardian key (required)")
+	referenceRPCConfigPath = NodeCmd.Flags().String("referenceRPCConfig", "", "Path to a JSON file listing public reference RPC endpoints to compare the height of the watchers against")
+
+	readinessConfigPath = NodeCmd.Flags().String("readinessConfig", "", "Path to a JSON file configuring how recent and how far behind the other guardians each watcher may be while ready (optional)")
+
+	dataDir = NodeCmd.Flags().String("dataDir", "", "Data directory")
+	vaaStore = NodeCmd.Flags().String("vaaStore", "badger", "Where to store signed VAAs: badger (in the node's database) or sqlite (in vaas.sqlite in the data directory)")
+	retentionConfigPath = NodeCmd.Flags().String("retentionConfig", "", "Path to a JSON file configuring how long signed VAAs are kept in the database (optional, all VAAs are kept by default)")
+
+	dbIntegrityInterval = NodeCmd.Flags().Duration("dbIntegrityInterval", 24*time.Hour, "How often to check the signed VAAs in the database for corruption, in addition to at startup")
+	watchdogMaxMemoryMB = NodeCmd.Flags().Uint64("watchdogMaxMemoryMB", 0, "Memory used by the node beyond which the public RPC is shed (0 = unlimited)")
+	watchdogMaxOpenFiles = NodeCmd.Flags().Uint64("watchdogMaxOpenFiles", 0, "Open file descriptors beyond which the public RPC is shed (0 = unlimited)")
+	watchdogMaxDiskMB = NodeCmd.Flags().Uint64("watchdogMaxDiskMB", 0, "Disk usage of the data directory beyond which the public RPC is shed (0 = unlimited)")
+	watchdogMaxGoroutines = NodeCmd.Flags().Uint64("watchdogMaxGoroutines", 0, "Goroutines beyond which the public RPC is shed (0 = unlimited)")
+	watchdogInterval = NodeCmd.Flags().Duration("watchdogInterval", 10*time.Second, "How often the watchdog samples the resource usage")
+
+	dbIntegrityPeers = NodeCmd.Flags().StringSlice("dbIntegrityPeers", nil, "Public RPC endpoints of other guardians to fetch corrupt signed VAAs from (defaults to the known mainnet endpoints on mainnet)")
+
+	guardianKeyPath = NodeCmd.Flags().String("guardianKey", "", "Path to guardian key, or awskms://<key>, gcpkms://<key version> or a pkcs11: URI for a key in a cloud KMS or HSM (required)")
+	guardianKeyFallbackPath = NodeCmd.Flags().String("guardianKeyFallback", "", "Path to a local copy of the KMS or HSM guardian key, used to sign if the KMS or HSM fails (optional)")
+	guardianKeyPassphraseSource = NodeCmd.Flags().String("guardianKeyPassphrase", "", "Source of the passphrase of encrypted guardian keys: env:<variable>, fd:<n>, file:<path> or systemd:<credential> (default: systemd credential guardian-key-passphrase, or prompt)")
+	nextGuardianKeyPath = NodeCmd.Flags().String("nextGuardianKey", "", "Path to the guardian key replacing --guardianKey in an upcoming guardian set, used once that set is active, or a KMS key like --guardianKey (optional)")
+	gossipKeyPath = NodeCmd.Flags().String("gossipKey", "", "Path to a key signing heartbeats and observation requests on behalf of the guardian key, which then only signs a periodic delegation to it (optional)")
+	gossipKeyDelegationValidity = NodeCmd.Flags().Duration("gossipKeyDelegationValidity", 30*24*time.Hour, "How long the delegations of --gossipKey signed by the guardian key are valid, they are renewed once half of it has elapsed")
+	experimentalTSS = NodeCmd.Flags().Bool("experimentalTSS", false, "Allow tss:// guardian keys, which sign through an external threshold signature coordinator (experimental)")
+	signingAuditLogPath = NodeCmd.Flags().String("signingAuditLog", "", "Path to an append-only log of every signature made with the guardian keys (optional)")
+	signingAnomalyConfigPath = NodeCmd.Flags().String("signingAnomalyConfig", "", "Path to the configuration of the detector of unexpected signing patterns in the signing audit log (optional, requires --signingAuditLog)")
 	solanaContract = NodeCmd.Flags().String("solanaContract", "", "Address of the Solana program (required)")
 
 	ethRPC = NodeCmd.Flags().String("ethRPC", "", "Ethereum RPC URL")
@@ -242,6 +392,10 @@ func init() {
 	injectiveLCD = NodeCmd.Flags().String("injectiveLCD", "", "Path to LCD service root for Injective http calls")
 	injectiveContract = NodeCmd.Flags().String("injectiveContract", "", "Wormhole contract address on Injective blockchain")
 
+	wormchainWS = NodeCmd.Flags().String("wormchainWS", "", "Path to wormchaind root for websocket connection (optional)")
+	wormchainLCD = NodeCmd.Flags().String("wormchainLCD", "", "Path to LCD service root for wormchain http calls")
+	wormchainContract = NodeCmd.Flags().String("wormchainContract", "", "Wormhole contract address on wormchain, which the gateway contracts publish their messages with")
+
 	algorandIndexerRPC = NodeCmd.Flags().String("algorandIndexerRPC", "", "Algorand Indexer RPC URL")
 	algorandIndexerToken = NodeCmd.Flags().String("algorandIndexerToken", "", "Algorand Indexer access token")
 	algorandAlgodRPC = NodeCmd.Flags().String("algorandAlgodRPC", "", "Algorand Algod RPC URL")
@@ -254,13 +408,17 @@ func init() {
 	aptosRPC = NodeCmd.Flags().String("aptosRPC", "", "aptos RPC URL")
 	aptosAccount = NodeCmd.Flags().String("aptosAccount", "", "aptos account")
 	aptosHandle = NodeCmd.Flags().String("aptosHandle", "", "aptos handle")
+	aptosReconcileInterval = NodeCmd.Flags().Duration("aptosReconcileInterval", time.Minute, "Interval at which the Aptos watcher compares its cursor with the on-chain event counter (disabled if zero)")
+	aptosAutoBackfill = NodeCmd.Flags().Bool("aptosAutoBackfill", false, "Let the Aptos watcher backfill the events it missed, and rewind after a rollback, when its cursor doesn't match the on-chain event counter")
 
 	solanaWsRPC = NodeCmd.Flags().String("solanaWS", "", "Solana Websocket URL (required")
 	solanaRPC = NodeCmd.Flags().String("solanaRPC", "", "Solana RPC URL (required")
+	solanaSecondaryRPC = NodeCmd.Flags().String("solanaSecondaryRPC", "", "Solana RPC URL of a second provider, which message accounts are checked against before they are observed")
 
 	pythnetContract = NodeCmd.Flags().String("pythnetContract", "", "Address of the PythNet program (required)")
 	pythnetWsRPC = NodeCmd.Flags().String("pythnetWS", "", "PythNet Websocket URL (required")
 	pythnetRPC = NodeCmd.Flags().String("pythnetRPC", "", "PythNet RPC URL (required")
+	pythnetSecondaryRPC = NodeCmd.Flags().String("pythnetSecondaryRPC", "", "PythNet RPC URL of a second provider, which message accounts are checked against before they are observed")
 
 	logLevel = NodeCmd.Flags().String("logLevel", "info", "Logging level (debug, info, warn, error, dpanic, panic, fatal)")
 
@@ -272,6 +430,12 @@ func init() {
 	publicRPC = NodeCmd.Flags().String("publicRPC", "", "Listen address for public gRPC interface")
 	publicWeb = NodeCmd.Flags().String("publicWeb", "", "Listen address for public REST and gRPC Web interface")
 
+	publicRPCRateLimit = NodeCmd.Flags().Float64("publicRpcRateLimit", 0, "Sustained public RPC requests per second allowed per client IP (0 = unlimited)")
+	publicRPCRateBurst = NodeCmd.Flags().Int("publicRpcRateBurst", 20, "Public RPC request burst allowed per client IP")
+	publicRPCAPIKeysPath = NodeCmd.Flags().String("publicRpcApiKeys", "", "Path to JSON file with API keys and their per-client public RPC quotas")
+	publicWebCORSOrigins = NodeCmd.Flags().StringSlice("publicWebCorsOrigins", nil, "Origins allowed to make cross-origin requests to publicWeb (default: any origin)")
+	publicRPCMaxRequestSize = NodeCmd.Flags().Int("publicRpcMaxRequestSize", 64*1024, "Maximum size in bytes of public RPC requests")
+
 	tlsHostname = NodeCmd.Flags().String("tlsHostname", "", "If set, serve publicWeb as TLS with this hostname using Let's Encrypt")
 	tlsProdEnv = NodeCmd.Flags().Bool("tlsProdEnv", false,
 		"Use the production Let's Encrypt environment instead of staging")
@@ -281,9 +445,22 @@ func init() {
 	disableTelemetry = NodeCmd.Flags().Bool("disableTelemetry", false,
 		"Disable telemetry")
 
+	observerMode = NodeCmd.Flags().Bool("observerMode", false,
+		"Run all watchers and follow gossip without signing or publishing anything, comparing our observations to the network's quorum VAAs")
+
+	featureFlags = NodeCmd.Flags().StringSlice("featureFlags", nil,
+		"Feature flags to enable, advertised in heartbeats (comma-separated, see `guardiand admin feature-flags` for the defined flags)")
+
 	telemetryKey = NodeCmd.Flags().String("telemetryKey", "",
 		"Telemetry write key")
 
+	logShippingConfig = NodeCmd.Flags().String("logShippingConfig", "",
+		"Path to a JSON file configuring additional log sinks, sampling and redaction (optional)")
+
+	otlpEndpoint = NodeCmd.Flags().String("otlpEndpoint", "", "OTLP/HTTP endpoint (host:port) to export traces of the messages to (disabled if blank)")
+	otlpInsecure = NodeCmd.Flags().Bool("otlpInsecure", false, "Export traces over plain HTTP instead of HTTPS")
+	traceSampleRatio = NodeCmd.Flags().Float64("traceSampleRatio", 1, "Ratio of messages to trace, between 0 and 1")
+
 	discordToken = NodeCmd.Flags().String("discordToken", "", "Discord bot token (optional)")
 	discordChannel = NodeCmd.Flags().String("discordChannel", "", "Discord channel name (optional)")
 
@@ -295,6 +472,40 @@ func init() {
 	bigTableKeyPath = NodeCmd.Flags().String("bigTableKeyPath", "", "Path to json Service Account key")
 
 	chainGovernorEnabled = NodeCmd.Flags().Bool("chainGovernorEnabled", false, "Run the chain governor")
+	chainGovernorNFTNotional = NodeCmd.Flags().Uint64("chainGovernorNFTNotional", 0, "Notional value counted towards the daily limit for each NFT bridge transfer (NFT transfers are not governed if zero)")
+	chainGovernorNFTApproval = NodeCmd.Flags().Bool("chainGovernorNFTApproval", false, "Hold NFT bridge transfers in the chain governor approval queue")
+	chainGovernorPayload3Notional = NodeCmd.Flags().Uint64("chainGovernorPayload3Notional", 0, "Fixed notional value for each payload three token bridge transfer, instead of its token value (disabled if zero)")
+	chainGovernorPayload3Approval = NodeCmd.Flags().Bool("chainGovernorPayload3Approval", false, "Hold payload three token bridge transfers in the chain governor approval queue")
+	chainGovernorPayloadRegistry = NodeCmd.Flags().String("chainGovernorPayloadRegistry", "", "Path to a payload registry config, whose decoded integrator transfers are governed at their token value (disabled if empty)")
+	chainGovernorPayloadRegistryApproval = NodeCmd.Flags().Bool("chainGovernorPayloadRegistryApproval", false, "Hold the integrator transfers decoded by the payload registry in the chain governor approval queue")
+	chainGovernorFlowCancel = NodeCmd.Flags().Bool("chainGovernorFlowCancel", false, "Let inbound transfers of the flow cancel tokens free the outbound capacity of their target chain in the chain governor")
+	chainGovernorEmergencyHoldThreshold = NodeCmd.Flags().Uint64("chainGovernorEmergencyHoldThreshold", 0, "Hold transfers worth at least this notional value in the chain governor until enough guardians voted to release them (disabled if zero)")
+	chainGovernorEmergencyReleaseQuorum = NodeCmd.Flags().Int("chainGovernorEmergencyReleaseQuorum", 0, "Number of guardians which must vote to release a transfer held by the chain governor emergency hold")
+
+	accountantEnabled = NodeCmd.Flags().Bool("accountantEnabled", false, "Run the accountant, which refuses to sign token bridge transfers that would overdraw a chain")
+	accountantLogOnly = NodeCmd.Flags().Bool("accountantLogOnly", false, "Only log the token bridge transfers the accountant would refuse to sign")
+	accountantEmitterConfig = NodeCmd.Flags().String("accountantEmitterConfig", "", "Path to a JSON file registering additional emitters (such as NTT managers) for the accountant to track in separate ledgers")
+	accountantReconcileEnabled = NodeCmd.Flags().Bool("accountantReconcileEnabled", false, "Periodically reconcile the accountant ledger against the token bridge custody balances on the EVM chains with an RPC configured")
+
+	redemptionTrackerEnabled = NodeCmd.Flags().Bool("redemptionTrackerEnabled", false, "Track the redemption of token bridge transfers on the EVM chains with an RPC configured, and report transfers which are not redeemed")
+	redemptionTrackerMinValue = NodeCmd.Flags().Uint64("redemptionTrackerMinValue", 0, "Only track token bridge transfers with at least this notional value, as priced by the chain governor (all transfers are tracked if zero)")
+	redemptionTrackerStuckAfter = NodeCmd.Flags().Duration("redemptionTrackerStuckAfter", 6*time.Hour, "Report token bridge transfers which are not redeemed this long after they were sent")
+	redemptionTrackerMaxAge = NodeCmd.Flags().Duration("redemptionTrackerMaxAge", 72*time.Hour, "Stop tracking token bridge transfers this long after they were sent")
+
+	traceVerificationChains = NodeCmd.Flags().StringSlice("traceVerificationChains", nil, "EVM chains whose messages are verified against the call trace of their transaction before they are observed, which requires debug_traceTransaction on their RPC (comma-separated chain names)")
+	traceVerificationMinValue = NodeCmd.Flags().Uint64("traceVerificationMinValue", 0, "Only verify the call trace of token bridge transfers with at least this notional value, as priced by the chain governor (all messages are verified if zero)")
+
+	signingRateLimit = NodeCmd.Flags().Uint64("signingRateLimit", 0, "Maximum number of messages signed per minute on each chain, dropping the rest until they are reobserved (unlimited if zero)")
+	signingRateLimitOverrides = NodeCmd.Flags().StringSlice("signingRateLimitOverrides", nil, "Per-chain overrides of --signingRateLimit, as chain=limit (comma-separated, zero means unlimited)")
+	signingRateLimitBypass = NodeCmd.Flags().StringSlice("signingRateLimitBypass", nil, "Emitters exempt from the signing rate limit in addition to the token and NFT bridges, as chain/emitter (comma-separated, hex emitter address)")
+
+	circuitBreakerSpikeFactor = NodeCmd.Flags().Float64("circuitBreakerSpikeFactor", 0, "Pause signing on a chain until resumed by the operator when its messages in a minute exceed this many times its baseline rate (disabled if zero)")
+	circuitBreakerSpikeMinMessages = NodeCmd.Flags().Uint64("circuitBreakerSpikeMinMessages", 100, "Minimum number of messages in a minute for --circuitBreakerSpikeFactor to apply")
+	circuitBreakerNewEmitterMinValue = NodeCmd.Flags().Uint64("circuitBreakerNewEmitterMinValue", 0, "Pause signing on a chain until resumed by the operator when an emitter which never had a message signed sends a token transfer worth at least this notional value in USD (disabled if zero)")
+
+	drainTimeout = NodeCmd.Flags().Duration("drainTimeout", 30*time.Second, "Maximum time to wait for the observations in flight to reach quorum when shutting down gracefully")
+
+	contractRegistryPath = NodeCmd.Flags().String("contractRegistry", "", "Path to a signed contract registry VAA (hex), used to resolve the contract addresses of the EVM chains not set by flag and to verify their bytecode")
 }
 
 var (
@@ -328,382 +539,317 @@ var NodeCmd = &cobra.Command{
 // guardians to reduce risk from a compromised builder.
 var Build = "prod"
 
-func runNode(cmd *cobra.Command, args []string) {
-	if Build == "dev" && !*unsafeDevMode {
-		fmt.Println("This is a development build. --unsafeDevMode must be enabled.")
-		os.Exit(1)
-	}
-
-	if *unsafeDevMode {
-		fmt.Print(devwarning)
-	}
-
-	common.LockMemory()
-	common.SetRestrictiveUmask()
-
-	// Refuse to run as root in production mode.
-	if !*unsafeDevMode && os.Geteuid() == 0 {
-		fmt.Println("can't run as uid 0")
-		os.Exit(1)
-	}
-
-	// Set up logging. The go-log zap wrapper that libp2p uses is compatible with our
-	// usage of zap in supervisor, which is nice.
-	lvl, err := ipfslog.LevelFromString(*logLevel)
-	if err != nil {
-		fmt.Println("Invalid log level")
-		os.Exit(1)
-	}
-
-	logger := zap.New(zapcore.NewCore(
-		consoleEncoder{zapcore.NewConsoleEncoder(
-			zap.NewDevelopmentEncoderConfig())},
-		zapcore.AddSync(zapcore.Lock(os.Stderr)),
-		zap.NewAtomicLevelAt(zapcore.Level(lvl))))
-
-	if *unsafeDevMode {
-		// Use the hostname as nodeName. For production, we don't want to do this to
-		// prevent accidentally leaking sensitive hostnames.
-		hostname, err := os.Hostname()
-		if err != nil {
-			panic(err)
-		}
-		*nodeName = hostname
-
-		// Put node name into the log for development.
-		logger = logger.Named(*nodeName)
+// openDatabase opens the node's database, storing signed VAAs in the SQLite database at sqlitePath if it is set.
+func openDatabase(path string, sqlitePath string) (*db.Database, error) {
+	if sqlitePath != "" {
+		return db.OpenWithSQLiteVAAStore(path, sqlitePath)
 	}
+	return db.Open(path)
+}
 
-	// Override the default go-log config, which uses a magic environment variable.
-	ipfslog.SetAllLoggers(lvl)
-
-	// Register components for readiness checks.
-	readiness.RegisterComponent(common.ReadinessEthSyncing)
-	if *solanaWsRPC != "" {
-		readiness.RegisterComponent(common.ReadinessSolanaSyncing)
+// verifyNodeFlags checks that the flags required by the node are specified and consistent with each other.
+func verifyNodeFlags() error {
+	if *nodeKeyPath == "" && !*unsafeDevMode { // In devnet mode, keys are deterministically generated.
+		return errors.New("Please specify --nodeKey")
 	}
-	if *pythnetWsRPC != "" {
-		readiness.RegisterComponent(common.ReadinessPythNetSyncing)
+	if *guardianKeyPath == "" {
+		return errors.New("Please specify --guardianKey")
 	}
-	if *terraWS != "" {
-		readiness.RegisterComponent(common.ReadinessTerraSyncing)
+	if *unsafeDevMode && isExternalGuardianKey(*guardianKeyPath) {
+		return errors.New("--guardianKey must be a key file in unsafeDevMode")
 	}
-	if *terra2WS != "" {
-		readiness.RegisterComponent(common.ReadinessTerra2Syncing)
+	if !*experimentalTSS && (isTSSGuardianKey(*guardianKeyPath) || isTSSGuardianKey(*nextGuardianKeyPath)) {
+		return errors.New("threshold signing is experimental, set --experimentalTSS to use a tss:// guardian key")
 	}
-	if *algorandIndexerRPC != "" {
-		readiness.RegisterComponent(common.ReadinessAlgorandSyncing)
+	if *guardianKeyFallbackPath != "" && !isExternalGuardianKey(*guardianKeyPath) {
+		return errors.New("--guardianKeyFallback can only be used with a KMS or HSM --guardianKey")
 	}
-	if *nearRPC != "" {
-		readiness.RegisterComponent(common.ReadinessNearSyncing)
+	if *adminSocketPath == "" {
+		return errors.New("Please specify --adminSocket")
 	}
-	if *aptosRPC != "" {
-		readiness.RegisterComponent(common.ReadinessAptosSyncing)
+	if *adminListenAddr != "" && (*adminTLSCert == "" || *adminTLSKey == "" || *adminTLSClientCA == "" || *adminAuthzConfigPath == "") {
+		return errors.New("If --adminListenAddr is specified, then --adminTLSCert, --adminTLSKey, --adminTLSClientCA and --adminAuthzConfig must be specified")
 	}
-	readiness.RegisterComponent(common.ReadinessBSCSyncing)
-	readiness.RegisterComponent(common.ReadinessPolygonSyncing)
-	readiness.RegisterComponent(common.ReadinessAvalancheSyncing)
-	readiness.RegisterComponent(common.ReadinessOasisSyncing)
-	readiness.RegisterComponent(common.ReadinessAuroraSyncing)
-	readiness.RegisterComponent(common.ReadinessFantomSyncing)
-	readiness.RegisterComponent(common.ReadinessKaruraSyncing)
-	readiness.RegisterComponent(common.ReadinessAcalaSyncing)
-	readiness.RegisterComponent(common.ReadinessKlaytnSyncing)
-	readiness.RegisterComponent(common.ReadinessCeloSyncing)
-
-	if *testnetMode {
-		readiness.RegisterComponent(common.ReadinessEthRopstenSyncing)
-		readiness.RegisterComponent(common.ReadinessMoonbeamSyncing)
-		readiness.RegisterComponent(common.ReadinessNeonSyncing)
-		readiness.RegisterComponent(common.ReadinessInjectiveSyncing)
+	if *dataDir == "" {
+		return errors.New("Please specify --dataDir")
 	}
-
-	if *statusAddr != "" {
-		// Use a custom routing instead of using http.DefaultServeMux directly to avoid accidentally exposing packages
-		// that register themselves with it by default (like pprof).
-		router := mux.NewRouter()
-
-		// pprof server. NOT necessarily safe to expose publicly - only enable it in dev mode to avoid exposing it by
-		// accident. There's benefit to having pprof enabled on production nodes, but we would likely want to expose it
-		// via a dedicated port listening on localhost, or via the admin UNIX socket.
-		if *unsafeDevMode {
-			// Pass requests to http.DefaultServeMux, which pprof automatically registers with as an import side-effect.
-			router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
-		}
-
-		// Simple endpoint exposing node readiness (safe to expose to untrusted clients)
-		router.HandleFunc("/readyz", readiness.Handler)
-
-		// Prometheus metrics (safe to expose to untrusted clients)
-		router.Handle("/metrics", promhttp.Handler())
-
-		go func() {
-			logger.Info("status server listening on [::]:6060")
-			// SECURITY: If making changes, ensure that we always do `router := mux.NewRouter()` before this to avoid accidentally exposing pprof
-			logger.Error("status server crashed", zap.Error(http.ListenAndServe(*statusAddr, router)))
-		}()
+	if *vaaStore != "badger" && *vaaStore != "sqlite" {
+		return errors.New("--vaaStore must be badger or sqlite")
 	}
-
-	// In devnet mode, we automatically set a number of flags that rely on deterministic keys.
-	if *unsafeDevMode {
-		g0key, err := peer.IDFromPrivateKey(devnet.DeterministicP2PPrivKeyByIndex(0))
-		if err != nil {
-			panic(err)
-		}
-
-		// Use the first guardian node as bootstrap
-		*p2pBootstrap = fmt.Sprintf("/dns4/guardian-0.guardian/udp/%d/quic/p2p/%s", *p2pPort, g0key.String())
-
-		// Deterministic ganache ETH devnet address.
-		*ethContract = devnet.GanacheWormholeContractAddress.Hex()
-		*bscContract = devnet.GanacheWormholeContractAddress.Hex()
-		*polygonContract = devnet.GanacheWormholeContractAddress.Hex()
-		*avalancheContract = devnet.GanacheWormholeContractAddress.Hex()
-		*oasisContract = devnet.GanacheWormholeContractAddress.Hex()
-		*auroraContract = devnet.GanacheWormholeContractAddress.Hex()
-		*fantomContract = devnet.GanacheWormholeContractAddress.Hex()
-		*karuraContract = devnet.GanacheWormholeContractAddress.Hex()
-		*acalaContract = devnet.GanacheWormholeContractAddress.Hex()
-		*klaytnContract = devnet.GanacheWormholeContractAddress.Hex()
-		*celoContract = devnet.GanacheWormholeContractAddress.Hex()
-		*moonbeamContract = devnet.GanacheWormholeContractAddress.Hex()
-		*neonContract = devnet.GanacheWormholeContractAddress.Hex()
+	if *dbIntegrityInterval <= 0 {
+		return errors.New("--dbIntegrityInterval must be positive")
 	}
-
-	// Verify flags
-
-	if *nodeKeyPath == "" && !*unsafeDevMode { // In devnet mode, keys are deterministically generated.
-		logger.Fatal("Please specify --nodeKey")
+	if *watchdogInterval <= 0 {
+		return errors.New("--watchdogInterval must be positive")
 	}
-	if *guardianKeyPath == "" {
-		logger.Fatal("Please specify --guardianKey")
+	if *publicRPCRateLimit < 0 || *publicRPCRateBurst < 0 {
+		return errors.New("--publicRpcRateLimit and --publicRpcRateBurst must not be negative")
 	}
-	if *adminSocketPath == "" {
-		logger.Fatal("Please specify --adminSocket")
+	if *publicRPCMaxRequestSize <= 0 {
+		return errors.New("--publicRpcMaxRequestSize must be positive")
 	}
-	if *dataDir == "" {
-		logger.Fatal("Please specify --dataDir")
+	if *traceSampleRatio < 0 || *traceSampleRatio > 1 {
+		return errors.New("--traceSampleRatio must be between 0 and 1")
 	}
 	if *ethRPC == "" {
-		logger.Fatal("Please specify --ethRPC")
+		return errors.New("Please specify --ethRPC")
 	}
 	if *ethContract == "" {
-		logger.Fatal("Please specify --ethContract")
+		return errors.New("Please specify --ethContract")
 	}
 	if *bscRPC == "" {
-		logger.Fatal("Please specify --bscRPC")
+		return errors.New("Please specify --bscRPC")
 	}
 	if *bscContract == "" {
-		logger.Fatal("Please specify --bscContract")
+		return errors.New("Please specify --bscContract")
 	}
 	if *polygonRPC == "" {
-		logger.Fatal("Please specify --polygonRPC")
+		return errors.New("Please specify --polygonRPC")
 	}
 	if *polygonContract == "" {
-		logger.Fatal("Please specify --polygonContract")
+		return errors.New("Please specify --polygonContract")
 	}
 	if *avalancheRPC == "" {
-		logger.Fatal("Please specify --avalancheRPC")
+		return errors.New("Please specify --avalancheRPC")
 	}
 	if *oasisRPC == "" {
-		logger.Fatal("Please specify --oasisRPC")
+		return errors.New("Please specify --oasisRPC")
 	}
 	if *fantomRPC == "" {
-		logger.Fatal("Please specify --fantomRPC")
+		return errors.New("Please specify --fantomRPC")
 	}
 	if *fantomContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --fantomContract")
+		return errors.New("Please specify --fantomContract")
 	}
 	if *auroraRPC == "" {
-		logger.Fatal("Please specify --auroraRPC")
+		return errors.New("Please specify --auroraRPC")
 	}
 	if *auroraContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --auroraContract")
+		return errors.New("Please specify --auroraContract")
 	}
 	if *karuraRPC == "" {
-		logger.Fatal("Please specify --karuraRPC")
+		return errors.New("Please specify --karuraRPC")
 	}
 	if *karuraContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --karuraContract")
+		return errors.New("Please specify --karuraContract")
 	}
 	if *acalaRPC == "" {
-		logger.Fatal("Please specify --acalaRPC")
+		return errors.New("Please specify --acalaRPC")
 	}
 	if *acalaContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --acalaContract")
+		return errors.New("Please specify --acalaContract")
 	}
 	if *klaytnRPC == "" {
-		logger.Fatal("Please specify --klaytnRPC")
+		return errors.New("Please specify --klaytnRPC")
 	}
 	if *klaytnContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --klaytnContract")
+		return errors.New("Please specify --klaytnContract")
 	}
 	if *celoRPC == "" {
-		logger.Fatal("Please specify --celoRPC")
+		return errors.New("Please specify --celoRPC")
 	}
 	if *celoContract == "" && !*unsafeDevMode {
-		logger.Fatal("Please specify --celoContract")
+		return errors.New("Please specify --celoContract")
 	}
 	if *nearRPC != "" {
 		if *nearContract == "" {
-			logger.Fatal("If --nearRPC is specified, then --nearContract must be specified")
+			return errors.New("If --nearRPC is specified, then --nearContract must be specified")
 		}
 	} else if *nearContract != "" {
-		logger.Fatal("If --nearContract is specified, then --nearRPC must be specified")
+		return errors.New("If --nearContract is specified, then --nearRPC must be specified")
+	}
+	if *wormchainWS != "" {
+		if *wormchainLCD == "" {
+			return errors.New("If --wormchainWS is specified, then --wormchainLCD must be specified")
+		}
+		if *wormchainContract == "" {
+			return errors.New("If --wormchainWS is specified, then --wormchainContract must be specified")
+		}
+	} else if *wormchainLCD != "" || *wormchainContract != "" {
+		return errors.New("If --wormchainLCD or --wormchainContract is specified, then --wormchainWS must be specified")
 	}
 
 	if *unsafeDevMode {
 		if *aptosRPC != "" {
 			if *aptosAccount == "" {
-				logger.Fatal("If --aptosRPC is specified, then --aptosAccount must be specified")
+				return errors.New("If --aptosRPC is specified, then --aptosAccount must be specified")
 			}
 			if *aptosHandle == "" {
-				logger.Fatal("If --aptosRPC is specified, then --aptosHandle must be specified")
+				return errors.New("If --aptosRPC is specified, then --aptosHandle must be specified")
 			}
 		}
 	}
 
 	if *testnetMode {
 		if *ethRopstenRPC == "" {
-			logger.Fatal("Please specify --ethRopstenRPC")
+			return errors.New("Please specify --ethRopstenRPC")
 		}
 		if *ethRopstenContract == "" {
-			logger.Fatal("Please specify --ethRopstenContract")
+			return errors.New("Please specify --ethRopstenContract")
 		}
 		if *moonbeamRPC == "" {
-			logger.Fatal("Please specify --moonbeamRPC")
+			return errors.New("Please specify --moonbeamRPC")
 		}
 		if *moonbeamContract == "" {
-			logger.Fatal("Please specify --moonbeamContract")
+			return errors.New("Please specify --moonbeamContract")
 		}
 		if *neonRPC == "" {
-			logger.Fatal("Please specify --neonRPC")
+			return errors.New("Please specify --neonRPC")
 		}
 		if *neonContract == "" {
-			logger.Fatal("Please specify --neonContract")
+			return errors.New("Please specify --neonContract")
 		}
 		if *injectiveWS == "" {
-			logger.Fatal("Please specify --injectiveWS")
+			return errors.New("Please specify --injectiveWS")
 		}
 		if *injectiveLCD == "" {
-			logger.Fatal("Please specify --injectiveLCD")
+			return errors.New("Please specify --injectiveLCD")
 		}
 		if *injectiveContract == "" {
-			logger.Fatal("Please specify --injectiveContract")
+			return errors.New("Please specify --injectiveContract")
 		}
 	} else {
 		if *ethRopstenRPC != "" {
-			logger.Fatal("Please do not specify --ethRopstenRPC in non-testnet mode")
+			return errors.New("Please do not specify --ethRopstenRPC in non-testnet mode")
 		}
 		if *ethRopstenContract != "" {
-			logger.Fatal("Please do not specify --ethRopstenContract in non-testnet mode")
+			return errors.New("Please do not specify --ethRopstenContract in non-testnet mode")
 		}
 		if *moonbeamRPC != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --moonbeamRPC")
+			return errors.New("Please do not specify --moonbeamRPC")
 		}
 		if *moonbeamContract != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --moonbeamContract")
+			return errors.New("Please do not specify --moonbeamContract")
 		}
 		if *neonRPC != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --neonRPC")
+			return errors.New("Please do not specify --neonRPC")
 		}
 		if *neonContract != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --neonContract")
+			return errors.New("Please do not specify --neonContract")
 		}
 		if *injectiveWS != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --injectiveWS")
+			return errors.New("Please do not specify --injectiveWS")
 		}
 		if *injectiveLCD != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --injectiveLCD")
+			return errors.New("Please do not specify --injectiveLCD")
 		}
 		if *injectiveContract != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --injectiveContract")
+			return errors.New("Please do not specify --injectiveContract")
 		}
 		if *aptosRPC != "" && !*unsafeDevMode {
-			logger.Fatal("Please do not specify --aptosRPC")
+			return errors.New("Please do not specify --aptosRPC")
 		}
 	}
 	if *nodeName == "" {
-		logger.Fatal("Please specify --nodeName")
+		return errors.New("Please specify --nodeName")
 	}
 
 	// Solana, Terra Classic, Terra 2, and Algorand are optional in devnet
 	if !*unsafeDevMode {
 
 		if *solanaContract == "" {
-			logger.Fatal("Please specify --solanaContract")
+			return errors.New("Please specify --solanaContract")
 		}
 		if *solanaWsRPC == "" {
-			logger.Fatal("Please specify --solanaWS")
+			return errors.New("Please specify --solanaWS")
 		}
 		if *solanaRPC == "" {
-			logger.Fatal("Please specify --solanaRPC")
+			return errors.New("Please specify --solanaRPC")
 		}
 
 		if *terraWS == "" {
-			logger.Fatal("Please specify --terraWS")
+			return errors.New("Please specify --terraWS")
 		}
 		if *terraLCD == "" {
-			logger.Fatal("Please specify --terraLCD")
+			return errors.New("Please specify --terraLCD")
 		}
 		if *terraContract == "" {
-			logger.Fatal("Please specify --terraContract")
+			return errors.New("Please specify --terraContract")
 		}
 
 		if *terra2WS == "" {
-			logger.Fatal("Please specify --terra2WS")
+			return errors.New("Please specify --terra2WS")
 		}
 		if *terra2LCD == "" {
-			logger.Fatal("Please specify --terra2LCD")
+			return errors.New("Please specify --terra2LCD")
 		}
 		if *terra2Contract == "" {
-			logger.Fatal("Please specify --terra2Contract")
+			return errors.New("Please specify --terra2Contract")
 		}
 
 		if *algorandIndexerRPC == "" {
-			logger.Fatal("Please specify --algorandIndexerRPC")
+			return errors.New("Please specify --algorandIndexerRPC")
 		}
 		if *algorandIndexerToken == "" {
-			logger.Fatal("Please specify --algorandIndexerToken")
+			return errors.New("Please specify --algorandIndexerToken")
 		}
 		if *algorandAlgodRPC == "" {
-			logger.Fatal("Please specify --algorandAlgodRPC")
+			return errors.New("Please specify --algorandAlgodRPC")
 		}
 		if *algorandAlgodToken == "" {
-			logger.Fatal("Please specify --algorandAlgodToken")
+			return errors.New("Please specify --algorandAlgodToken")
 		}
 		if *algorandAppID == 0 {
-			logger.Fatal("Please specify --algorandAppID")
+			return errors.New("Please specify --algorandAppID")
 		}
 
 		if *pythnetContract == "" {
-			logger.Fatal("Please specify --pythnetContract")
+			return errors.New("Please specify --pythnetContract")
 		}
 		if *pythnetWsRPC == "" {
-			logger.Fatal("Please specify --pythnetWS")
+			return errors.New("Please specify --pythnetWS")
 		}
 		if *pythnetRPC == "" {
-			logger.Fatal("Please specify --pythnetRPC")
+			return errors.New("Please specify --pythnetRPC")
 		}
 	}
 
 	if *bigTablePersistenceEnabled {
 		if *bigTableGCPProject == "" {
-			logger.Fatal("Please specify --bigTableGCPProject")
+			return errors.New("Please specify --bigTableGCPProject")
 		}
 		if *bigTableInstanceName == "" {
-			logger.Fatal("Please specify --bigTableInstanceName")
+			return errors.New("Please specify --bigTableInstanceName")
 		}
 		if *bigTableTableName == "" {
-			logger.Fatal("Please specify --bigTableTableName")
+			return errors.New("Please specify --bigTableTableName")
 		}
 		if *bigTableTopicName == "" {
-			logger.Fatal("Please specify --bigTableTopicName")
+			return errors.New("Please specify --bigTableTopicName")
 		}
 		if *bigTableKeyPath == "" {
-			logger.Fatal("Please specify --bigTableKeyPath")
+			return errors.New("Please specify --bigTableKeyPath")
+		}
+	}
+
+	if *redemptionTrackerEnabled {
+		if *redemptionTrackerMinValue != 0 && !*chainGovernorEnabled {
+			return errors.New("--redemptionTrackerMinValue requires --chainGovernorEnabled to price the transfers")
+		}
+		if *redemptionTrackerStuckAfter >= *redemptionTrackerMaxAge {
+			return errors.New("--redemptionTrackerMaxAge must be longer than --redemptionTrackerStuckAfter")
+		}
+	}
+
+	for _, name := range *traceVerificationChains {
+		if _, err := vaa.ChainIDFromString(name); err != nil {
+			return fmt.Errorf("--traceVerificationChains: %w", err)
+		}
+	}
+	if *traceVerificationMinValue != 0 && !*chainGovernorEnabled {
+		return errors.New("--traceVerificationMinValue requires --chainGovernorEnabled to price the transfers")
+	}
+	if *chainGovernorEmergencyHoldThreshold != 0 {
+		if !*chainGovernorEnabled {
+			return errors.New("--chainGovernorEmergencyHoldThreshold requires --chainGovernorEnabled")
+		}
+		if *chainGovernorEmergencyReleaseQuorum < 1 {
+			return errors.New("--chainGovernorEmergencyHoldThreshold requires --chainGovernorEmergencyReleaseQuorum to be at least one")
 		}
 	}
+	if *circuitBreakerNewEmitterMinValue != 0 && !*chainGovernorEnabled {
+		return errors.New("--circuitBreakerNewEmitterMinValue requires --chainGovernorEnabled to price the transfers")
+	}
 
 	// Complain about Infura on mainnet.
 	//
@@ -725,75 +871,380 @@ func runNode(cmd *cobra.Command, args []string) {
 	//
 	if strings.Contains(*ethRPC, "mainnet.infura.io") ||
 		strings.Contains(*polygonRPC, "polygon-mainnet.infura.io") {
-		logger.Fatal("Infura is known to send incorrect blocks - please use your own nodes")
+		return errors.New("Infura is known to send incorrect blocks - please use your own nodes")
 	}
 
-	ethContractAddr := eth_common.HexToAddress(*ethContract)
-	bscContractAddr := eth_common.HexToAddress(*bscContract)
-	polygonContractAddr := eth_common.HexToAddress(*polygonContract)
-	ethRopstenContractAddr := eth_common.HexToAddress(*ethRopstenContract)
-	avalancheContractAddr := eth_common.HexToAddress(*avalancheContract)
-	oasisContractAddr := eth_common.HexToAddress(*oasisContract)
-	auroraContractAddr := eth_common.HexToAddress(*auroraContract)
-	fantomContractAddr := eth_common.HexToAddress(*fantomContract)
-	karuraContractAddr := eth_common.HexToAddress(*karuraContract)
-	acalaContractAddr := eth_common.HexToAddress(*acalaContract)
-	klaytnContractAddr := eth_common.HexToAddress(*klaytnContract)
-	celoContractAddr := eth_common.HexToAddress(*celoContract)
-	moonbeamContractAddr := eth_common.HexToAddress(*moonbeamContract)
-	neonContractAddr := eth_common.HexToAddress(*neonContract)
-	solAddress, err := solana_types.PublicKeyFromBase58(*solanaContract)
-	if err != nil {
-		logger.Fatal("invalid Solana contract address", zap.Error(err))
-	}
-	var pythnetAddress solana_types.PublicKey
-	pythnetAddress, err = solana_types.PublicKeyFromBase58(*pythnetContract)
-	if err != nil {
-		logger.Fatal("invalid PythNet contract address", zap.Error(err))
+	return nil
+}
+
+func runNode(cmd *cobra.Command, args []string) {
+	if err := applyConfig(viper.GetViper(), cmd.Flags()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	// In devnet mode, we generate a deterministic guardian key and write it to disk.
-	if *unsafeDevMode {
-		gk, err := generateDevnetGuardianKey()
-		if err != nil {
-			logger.Fatal("failed to generate devnet guardian key", zap.Error(err))
-		}
+	if Build == "dev" && !*unsafeDevMode {
+		fmt.Println("This is a development build. --unsafeDevMode must be enabled.")
+		os.Exit(1)
+	}
 
-		err = writeGuardianKey(gk, "auto-generated deterministic devnet key", *guardianKeyPath, true)
-		if err != nil {
-			logger.Fatal("failed to write devnet guardian key", zap.Error(err))
-		}
+	if faultinject.Enabled && !*unsafeDevMode {
+		fmt.Println("This build can inject faults (faultinject build tag). --unsafeDevMode must be enabled.")
+		os.Exit(1)
 	}
 
-	// Database
-	dbPath := path.Join(*dataDir, "db")
-	if err := os.MkdirAll(dbPath, 0700); err != nil {
-		logger.Fatal("failed to create database directory", zap.Error(err))
+	if *unsafeDevMode {
+		fmt.Print(devwarning)
 	}
-	db, err := db.Open(dbPath)
-	if err != nil {
-		logger.Fatal("failed to open database", zap.Error(err))
+
+	common.LockMemory()
+	common.SetRestrictiveUmask()
+
+	// Refuse to run as root in production mode.
+	if !*unsafeDevMode && os.Geteuid() == 0 {
+		fmt.Println("can't run as uid 0")
+		os.Exit(1)
 	}
-	defer db.Close()
 
-	// Guardian key
-	gk, err := loadGuardianKey(*guardianKeyPath)
+	// Set up logging. The go-log zap wrapper that libp2p uses is compatible with our
+	// usage of zap in supervisor, which is nice.
+	lvl, err := ipfslog.LevelFromString(*logLevel)
 	if err != nil {
-		logger.Fatal("failed to load guardian key", zap.Error(err))
+		fmt.Println("Invalid log level")
+		os.Exit(1)
+	}
+
+	logger := zap.New(zapcore.NewCore(
+		consoleEncoder{zapcore.NewConsoleEncoder(
+			zap.NewDevelopmentEncoderConfig())},
+		zapcore.AddSync(zapcore.Lock(os.Stderr)),
+		zap.NewAtomicLevelAt(zapcore.Level(lvl))))
+
+	if *unsafeDevMode {
+		// Use the hostname as nodeName. For production, we don't want to do this to
+		// prevent accidentally leaking sensitive hostnames.
+		hostname, err := os.Hostname()
+		if err != nil {
+			panic(err)
+		}
+		*nodeName = hostname
+
+		// Put node name into the log for development.
+		logger = logger.Named(*nodeName)
+	}
+
+	// Override the default go-log config, which uses a magic environment variable.
+	ipfslog.SetAllLoggers(lvl)
+
+	// Register components for readiness checks.
+	readiness.RegisterComponent(common.ReadinessEthSyncing)
+	if *solanaWsRPC != "" {
+		readiness.RegisterComponent(common.ReadinessSolanaSyncing)
+	}
+	if *pythnetWsRPC != "" {
+		readiness.RegisterComponent(common.ReadinessPythNetSyncing)
+	}
+	if *terraWS != "" {
+		readiness.RegisterComponent(common.ReadinessTerraSyncing)
+	}
+	if *terra2WS != "" {
+		readiness.RegisterComponent(common.ReadinessTerra2Syncing)
+	}
+	if *algorandIndexerRPC != "" {
+		readiness.RegisterComponent(common.ReadinessAlgorandSyncing)
+	}
+	if *nearRPC != "" {
+		readiness.RegisterComponent(common.ReadinessNearSyncing)
+	}
+	if *aptosRPC != "" {
+		readiness.RegisterComponent(common.ReadinessAptosSyncing)
+	}
+	if *wormchainWS != "" {
+		readiness.RegisterComponent(common.ReadinessWormchainSyncing)
+	}
+	readiness.RegisterComponent(common.ReadinessBSCSyncing)
+	readiness.RegisterComponent(common.ReadinessPolygonSyncing)
+	readiness.RegisterComponent(common.ReadinessAvalancheSyncing)
+	readiness.RegisterComponent(common.ReadinessOasisSyncing)
+	readiness.RegisterComponent(common.ReadinessAuroraSyncing)
+	readiness.RegisterComponent(common.ReadinessFantomSyncing)
+	readiness.RegisterComponent(common.ReadinessKaruraSyncing)
+	readiness.RegisterComponent(common.ReadinessAcalaSyncing)
+	readiness.RegisterComponent(common.ReadinessKlaytnSyncing)
+	readiness.RegisterComponent(common.ReadinessCeloSyncing)
+
+	if *testnetMode {
+		readiness.RegisterComponent(common.ReadinessEthRopstenSyncing)
+		readiness.RegisterComponent(common.ReadinessMoonbeamSyncing)
+		readiness.RegisterComponent(common.ReadinessNeonSyncing)
+		readiness.RegisterComponent(common.ReadinessInjectiveSyncing)
+	}
+
+	if *readinessConfigPath != "" {
+		if err := readiness.LoadConfig(*readinessConfigPath); err != nil {
+			logger.Fatal("failed to load readiness config", zap.Error(err))
+		}
+	}
+
+	// Reports the state of the supervised runnables, such as the watchers.
+	tree := supervisor.NewIntrospector()
+
+	// Read-only status page, fed once the governor and the event bus are set up.
+	var page *statuspage.Page
+	if *statusPage && *statusAddr != "" {
+		page = statuspage.New()
+	}
+
+	if *statusAddr != "" {
+		// Use a custom routing instead of using http.DefaultServeMux directly to avoid accidentally exposing packages
+		// that register themselves with it by default (like pprof).
+		router := mux.NewRouter()
+
+		// pprof server. NOT necessarily safe to expose publicly - only enable it in dev mode to avoid exposing it by
+		// accident. Production nodes are profiled via the admin UNIX socket instead (guardiand admin profile).
+		if *unsafeDevMode {
+			// Pass requests to http.DefaultServeMux, which pprof automatically registers with as an import side-effect.
+			router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
+		}
+
+		// Simple endpoint exposing node readiness (safe to expose to untrusted clients)
+		router.HandleFunc("/readyz", readiness.Handler)
+
+		// Prometheus metrics (safe to expose to untrusted clients)
+		router.Handle("/metrics", promhttp.Handler())
+
+		// Supervision tree. The errors of the watchers may include their RPC endpoints, so it is only exposed if enabled.
+		if *statusSupervisorTree {
+			router.Handle("/debug/supervisor", tree)
+		}
+
+		// Status page (safe to expose to untrusted clients)
+		if page != nil {
+			router.Handle("/status", page)
+		}
+
+		go func() {
+			logger.Info("status server listening on [::]:6060")
+			// SECURITY: If making changes, ensure that we always do `router := mux.NewRouter()` before this to avoid accidentally exposing pprof
+			logger.Error("status server crashed", zap.Error(http.ListenAndServe(*statusAddr, router)))
+		}()
+	}
+
+	// In devnet mode, we automatically set a number of flags that rely on deterministic keys.
+	if *unsafeDevMode {
+		g0key, err := peer.IDFromPrivateKey(devnet.DeterministicP2PPrivKeyByIndex(0))
+		if err != nil {
+			panic(err)
+		}
+
+		// Use the first guardian node as bootstrap
+		*p2pBootstrap = fmt.Sprintf("/dns4/guardian-0.guardian/udp/%d/quic/p2p/%s", *p2pPort, g0key.String())
+
+		// Deterministic ganache ETH devnet address.
+		*ethContract = devnet.GanacheWormholeContractAddress.Hex()
+		*bscContract = devnet.GanacheWormholeContractAddress.Hex()
+		*polygonContract = devnet.GanacheWormholeContractAddress.Hex()
+		*avalancheContract = devnet.GanacheWormholeContractAddress.Hex()
+		*oasisContract = devnet.GanacheWormholeContractAddress.Hex()
+		*auroraContract = devnet.GanacheWormholeContractAddress.Hex()
+		*fantomContract = devnet.GanacheWormholeContractAddress.Hex()
+		*karuraContract = devnet.GanacheWormholeContractAddress.Hex()
+		*acalaContract = devnet.GanacheWormholeContractAddress.Hex()
+		*klaytnContract = devnet.GanacheWormholeContractAddress.Hex()
+		*celoContract = devnet.GanacheWormholeContractAddress.Hex()
+		*moonbeamContract = devnet.GanacheWormholeContractAddress.Hex()
+		*neonContract = devnet.GanacheWormholeContractAddress.Hex()
+	}
+
+	// Resolve the EVM contract addresses from the contract registry. Its signatures are verified against the guardian
+	// set of the Ethereum core bridge, so that one remains configured by flag.
+	var contracts *contractregistry.Registry
+	if *contractRegistryPath != "" {
+		if *ethRPC == "" || *ethContract == "" {
+			logger.Fatal("--contractRegistry requires --ethRPC and --ethContract to verify the registry")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		contracts, err = contractregistry.Load(ctx, *contractRegistryPath, *ethRPC, eth_common.HexToAddress(*ethContract))
+		cancel()
+		if err != nil {
+			logger.Fatal("failed to load contract registry", zap.Error(err))
+		}
+
+		if err := applyContractRegistry(contracts, map[vaa.ChainID]*string{
+			vaa.ChainIDBSC:             bscContract,
+			vaa.ChainIDPolygon:         polygonContract,
+			vaa.ChainIDEthereumRopsten: ethRopstenContract,
+			vaa.ChainIDAvalanche:       avalancheContract,
+			vaa.ChainIDOasis:           oasisContract,
+			vaa.ChainIDAurora:          auroraContract,
+			vaa.ChainIDFantom:          fantomContract,
+			vaa.ChainIDKarura:          karuraContract,
+			vaa.ChainIDAcala:           acalaContract,
+			vaa.ChainIDKlaytn:          klaytnContract,
+			vaa.ChainIDCelo:            celoContract,
+			vaa.ChainIDMoonbeam:        moonbeamContract,
+			vaa.ChainIDNeon:            neonContract,
+		}); err != nil {
+			logger.Fatal("invalid contract registry", zap.Error(err))
+		}
+
+		logger.Info("loaded contract registry", zap.Uint64("version", contracts.Version), zap.Int("chains", len(contracts.Chains)))
+	}
+
+	// Verify flags
+	if err := verifyNodeFlags(); err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	ethContractAddr := eth_common.HexToAddress(*ethContract)
+	bscContractAddr := eth_common.HexToAddress(*bscContract)
+	polygonContractAddr := eth_common.HexToAddress(*polygonContract)
+	ethRopstenContractAddr := eth_common.HexToAddress(*ethRopstenContract)
+	avalancheContractAddr := eth_common.HexToAddress(*avalancheContract)
+	oasisContractAddr := eth_common.HexToAddress(*oasisContract)
+	auroraContractAddr := eth_common.HexToAddress(*auroraContract)
+	fantomContractAddr := eth_common.HexToAddress(*fantomContract)
+	karuraContractAddr := eth_common.HexToAddress(*karuraContract)
+	acalaContractAddr := eth_common.HexToAddress(*acalaContract)
+	klaytnContractAddr := eth_common.HexToAddress(*klaytnContract)
+	celoContractAddr := eth_common.HexToAddress(*celoContract)
+	moonbeamContractAddr := eth_common.HexToAddress(*moonbeamContract)
+	neonContractAddr := eth_common.HexToAddress(*neonContract)
+	solAddress, err := solana_types.PublicKeyFromBase58(*solanaContract)
+	if err != nil {
+		logger.Fatal("invalid Solana contract address", zap.Error(err))
+	}
+	var pythnetAddress solana_types.PublicKey
+	pythnetAddress, err = solana_types.PublicKeyFromBase58(*pythnetContract)
+	if err != nil {
+		logger.Fatal("invalid PythNet contract address", zap.Error(err))
+	}
+
+	// In devnet mode, we generate a deterministic guardian key and write it to disk.
+	if *unsafeDevMode {
+		gk, err := generateDevnetGuardianKey()
+		if err != nil {
+			logger.Fatal("failed to generate devnet guardian key", zap.Error(err))
+		}
+
+		err = writeGuardianKey(gk, "auto-generated deterministic devnet key", *guardianKeyPath, true, "")
+		if err != nil {
+			logger.Fatal("failed to write devnet guardian key", zap.Error(err))
+		}
+	}
+
+	// Database
+	var retentionPolicy *db.RetentionPolicy
+	if *retentionConfigPath != "" {
+		retentionPolicy, err = db.LoadRetentionPolicy(*retentionConfigPath)
+		if err != nil {
+			logger.Fatal("failed to load retention config", zap.Error(err))
+		}
+	}
+
+	dbPath := path.Join(*dataDir, "db")
+	if err := os.MkdirAll(dbPath, 0700); err != nil {
+		logger.Fatal("failed to create database directory", zap.Error(err))
+	}
+	var sqlitePath string
+	if *vaaStore == "sqlite" {
+		sqlitePath = path.Join(*dataDir, "vaas.sqlite")
+	}
+	db, err := openDatabase(dbPath, sqlitePath)
+	if err != nil {
+		logger.Fatal("failed to open database", zap.Error(err))
+	}
+	defer db.Close()
+
+	// Guardian key
+	gk, err := newGuardianSigner(context.Background(), *guardianKeyPath)
+	if err != nil {
+		logger.Fatal("failed to load guardian key", zap.Error(err))
+	}
+	if *guardianKeyFallbackPath != "" {
+		fallback, err := loadGuardianKey(*guardianKeyFallbackPath)
+		if err != nil {
+			logger.Fatal("failed to load fallback guardian key", zap.Error(err))
+		}
+		gk, err = guardiansigner.NewFallbackSigner(gk, guardiansigner.NewLocalSigner(fallback))
+		if err != nil {
+			logger.Fatal("invalid fallback guardian key", zap.Error(err))
+		}
+	}
+
+	guardianAddr := guardiansigner.Address(gk).String()
+	logger.Info("Loaded guardian key", zap.String(
+		"address", guardianAddr))
+
+	p2p.DefaultRegistry.SetGuardianAddress(guardianAddr)
+
+	var nextGk guardiansigner.GuardianSigner
+	if *nextGuardianKeyPath != "" {
+		nextGk, err = newGuardianSigner(context.Background(), *nextGuardianKeyPath)
+		if err != nil {
+			logger.Fatal("failed to load next guardian key", zap.Error(err))
+		}
+
+		nextGuardianAddr := guardiansigner.Address(nextGk).String()
+		if nextGuardianAddr == guardianAddr {
+			logger.Fatal("the next guardian key must differ from the current guardian key")
+		}
+		logger.Info("Loaded next guardian key", zap.String(
+			"address", nextGuardianAddr))
+	}
+
+	var gossipSigner *p2p.GossipSigner
+	if *gossipKeyPath != "" {
+		gossipKey, err := newGuardianSigner(context.Background(), *gossipKeyPath)
+		if err != nil {
+			logger.Fatal("failed to load gossip key", zap.Error(err))
+		}
+
+		gossipAddr := guardiansigner.Address(gossipKey)
+		if gossipAddr == guardiansigner.Address(gk) || (nextGk != nil && gossipAddr == guardiansigner.Address(nextGk)) {
+			logger.Fatal("the gossip key must differ from the guardian keys")
+		}
+		if *gossipKeyDelegationValidity <= 0 {
+			logger.Fatal("--gossipKeyDelegationValidity must be positive")
+		}
+		gossipSigner = p2p.NewGossipSigner(gossipKey, *gossipKeyDelegationValidity)
+		logger.Info("Loaded gossip key", zap.String("address", gossipAddr.String()))
+	}
+
+	var auditLog *guardiansigner.AuditLog
+	if *signingAuditLogPath != "" {
+		auditLog, err = guardiansigner.OpenAuditLog(*signingAuditLogPath)
+		if err != nil {
+			logger.Fatal("failed to open signing audit log", zap.Error(err))
+		}
+		defer auditLog.Close()
+		if n := auditLog.Truncated(); n != 0 {
+			logger.Warn("removed the partial entry at the end of the signing audit log, which was interrupted by a crash",
+				zap.String("path", *signingAuditLogPath), zap.Int64("bytes", n))
+		}
+
+		gk = guardiansigner.NewAuditedSigner(gk, auditLog)
+		if nextGk != nil {
+			nextGk = guardiansigner.NewAuditedSigner(nextGk, auditLog)
+		}
+		logger.Info("Recording guardian key signatures in the signing audit log", zap.String("path", *signingAuditLogPath))
+	}
+
+	if *observerMode {
+		logger.Warn("running in observer mode, the guardian key will not be used to sign anything")
 	}
 
-	guardianAddr := ethcrypto.PubkeyToAddress(gk.PublicKey).String()
-	logger.Info("Loaded guardian key", zap.String(
-		"address", guardianAddr))
-
-	p2p.DefaultRegistry.SetGuardianAddress(guardianAddr)
-
 	// Node's main lifecycle context.
 	rootCtx, rootCtxCancel = context.WithCancel(context.Background())
 	defer rootCtxCancel()
 
-	// Ethereum lock event channel
-	lockC := make(chan *common.MessagePublication)
+	// Observed messages, inbound observations, signed VAAs and observation requests. The watchers and p2p publish to
+	// the bus, and the processor and the other consumers subscribe to it.
+	bus := eventbus.New()
+
+	// Messages observed by the watchers
+	lockC := bus.MessagePublications().C()
 
 	// Ethereum incoming guardian set updates
 	setC := make(chan *common.GuardianSet)
@@ -801,14 +1252,10 @@ func runNode(cmd *cobra.Command, args []string) {
 	// Outbound gossip message queue
 	sendC := make(chan []byte)
 
-	// Inbound observations
-	obsvC := make(chan *gossipv1.SignedObservation, 50)
-
-	// Inbound signed VAAs
-	signedInC := make(chan *gossipv1.SignedVAAWithQuorum, 50)
-
-	// Inbound observation requests from the p2p service (for all chains)
-	obsvReqC := make(chan *gossipv1.ObservationRequest, common.ObsvReqChannelSize)
+	integrityPeers := *dbIntegrityPeers
+	if len(integrityPeers) == 0 && !*unsafeDevMode && !*testnetMode {
+		integrityPeers = common.PublicRPCEndpoints
+	}
 
 	// Outbound observation requests
 	obsvReqSendC := make(chan *gossipv1.ObservationRequest, common.ObsvReqChannelSize)
@@ -838,6 +1285,9 @@ func runNode(cmd *cobra.Command, args []string) {
 	if *aptosRPC != "" {
 		chainObsvReqC[vaa.ChainIDAptos] = make(chan *gossipv1.ObservationRequest)
 	}
+	if *wormchainWS != "" {
+		chainObsvReqC[vaa.ChainIDWormchain] = make(chan *gossipv1.ObservationRequest)
+	}
 	chainObsvReqC[vaa.ChainIDAurora] = make(chan *gossipv1.ObservationRequest)
 	chainObsvReqC[vaa.ChainIDFantom] = make(chan *gossipv1.ObservationRequest)
 	chainObsvReqC[vaa.ChainIDKarura] = make(chan *gossipv1.ObservationRequest)
@@ -851,7 +1301,10 @@ func runNode(cmd *cobra.Command, args []string) {
 		chainObsvReqC[vaa.ChainIDEthereumRopsten] = make(chan *gossipv1.ObservationRequest)
 		chainObsvReqC[vaa.ChainIDInjective] = make(chan *gossipv1.ObservationRequest)
 	}
-	go handleReobservationRequests(rootCtx, clock.New(), logger, obsvReqC, chainObsvReqC)
+	// Allows the watchers to be paused, resumed and pointed at a different endpoint using admin commands.
+	watchers := watchercontrol.NewController(logger)
+
+	go handleReobservationRequests(rootCtx, clock.New(), logger, bus.ObservationRequests().Subscribe("reobservation", eventbus.Options{}), chainObsvReqC, watchers.IsPaused)
 
 	var notifier *discord.DiscordNotifier
 	if *discordToken != "" {
@@ -861,6 +1314,27 @@ func runNode(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	var anomalyDetector *guardiansigner.AnomalyDetector
+	if *signingAnomalyConfigPath != "" {
+		if auditLog == nil {
+			logger.Fatal("--signingAnomalyConfig requires --signingAuditLog")
+		}
+		cfg, err := guardiansigner.LoadAnomalyConfig(*signingAnomalyConfigPath)
+		if err != nil {
+			logger.Fatal("invalid signing anomaly config", zap.Error(err))
+		}
+		var watched []uint16
+		for chainID := range chainObsvReqC {
+			watched = append(watched, uint16(chainID))
+		}
+		anomalyDetector = guardiansigner.NewAnomalyDetector(logger.With(zap.String("component", "signinganomalies")), cfg, watched)
+		if notifier != nil {
+			anomalyDetector.AddSink(notifier)
+		}
+		auditLog.OnAppend(anomalyDetector.Observe)
+		logger.Info("signing anomaly detection is enabled", zap.String("config", *signingAnomalyConfigPath))
+	}
+
 	// Load p2p private key
 	var priv crypto.PrivKey
 	if *unsafeDevMode {
@@ -876,6 +1350,35 @@ func runNode(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	// Get libp2p peer ID from private key
+	peerID, err := peer.IDFromPublicKey(priv.GetPublic())
+	if err != nil {
+		logger.Fatal("Failed to get peer ID from private key", zap.Error(err))
+	}
+
+	p2pListenConfig, err := p2p.ParseListenConfig(*p2pListen, *p2pAnnounce, *p2pNoAnnounce, *p2pPort)
+	if err != nil {
+		logger.Fatal("invalid p2p listen configuration", zap.Error(err))
+	}
+
+	if err := featureflags.DefaultRegistry.Enable(*featureFlags); err != nil {
+		logger.Fatal("invalid feature flags", zap.Error(err))
+	}
+	if len(*featureFlags) != 0 {
+		logger.Info("feature flags are enabled", zap.Strings("flags", *featureFlags))
+	}
+
+	logLabels := map[string]string{
+		"node_name":     *nodeName,
+		"node_key":      peerID.Pretty(),
+		"guardian_addr": guardianAddr,
+		"network":       *p2pNetworkID,
+		"version":       version.Version(),
+	}
+
+	var logSinks []telemetry.Sink
+	logOpts := telemetry.Options{Level: zapcore.InfoLevel, Redact: logRedactions(cmd.Flags())}
+
 	// Enable unless it is disabled. For devnet, only when --telemetryKey is set.
 	if !*disableTelemetry && (!*unsafeDevMode || *unsafeDevMode && *telemetryKey != "") {
 		logger.Info("Telemetry enabled")
@@ -889,32 +1392,60 @@ func runNode(cmd *cobra.Command, args []string) {
 			logger.Fatal("Failed to decrypt telemetry service account", zap.Error(err))
 		}
 
-		// Get libp2p peer ID from private key
-		pk := priv.GetPublic()
-		peerID, err := peer.IDFromPublicKey(pk)
+		sink, err := telemetry.NewGoogleCloudSink(context.Background(), telemetryProject, creds, logLabels)
+		if err != nil {
+			logger.Fatal("Failed to initialize telemetry", zap.Error(err))
+		}
+		logSinks = append(logSinks, sink)
+	} else {
+		logger.Info("Telemetry disabled")
+	}
+
+	if *logShippingConfig != "" {
+		c, err := telemetry.LoadConfig(*logShippingConfig)
 		if err != nil {
-			logger.Fatal("Failed to get peer ID from private key", zap.Error(err))
+			logger.Fatal("Failed to load log shipping config", zap.Error(err))
 		}
+		sinks, err := c.NewSinks(logLabels)
+		if err != nil {
+			logger.Fatal("Failed to create log sinks", zap.Error(err))
+		}
+		logSinks = append(logSinks, sinks...)
+		logOpts = c.Options(logOpts.Redact)
+		logger.Info("Log shipping enabled", zap.Int("sinks", len(sinks)))
+	}
 
-		tm, err := telemetry.New(context.Background(), telemetryProject, creds, map[string]string{
-			"node_name":     *nodeName,
-			"node_key":      peerID.Pretty(),
-			"guardian_addr": guardianAddr,
-			"network":       *p2pNetworkID,
-			"version":       version.Version(),
-		})
+	if len(logSinks) != 0 {
+		tm, err := telemetry.New(logSinks, logOpts)
 		if err != nil {
-			logger.Fatal("Failed to initialize telemetry", zap.Error(err))
+			logger.Fatal("Failed to initialize log shipping", zap.Error(err))
 		}
 		defer tm.Close()
 		logger = tm.WrapLogger(logger)
-	} else {
-		logger.Info("Telemetry disabled")
 	}
 
 	// Redirect ipfs logs to plain zap
 	ipfslog.SetPrimaryCore(logger.Core())
 
+	if *otlpEndpoint != "" {
+		shutdown, err := tracing.Init(rootCtx, *otlpEndpoint, *otlpInsecure, *traceSampleRatio,
+			attribute.String("node_name", *nodeName),
+			attribute.String("guardian_addr", guardianAddr),
+			attribute.String("network", *p2pNetworkID),
+			attribute.String("version", version.Version()))
+		if err != nil {
+			logger.Fatal("failed to initialize tracing", zap.Error(err))
+		}
+		logger.Info("exporting traces", zap.String("endpoint", *otlpEndpoint), zap.Float64("sample_ratio", *traceSampleRatio))
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				logger.Error("failed to flush traces", zap.Error(err))
+			}
+		}()
+	}
+
 	// provides methods for reporting progress toward message attestation, and channels for receiving attestation lifecyclye events.
 	attestationEvents := reporter.EventListener(logger)
 
@@ -928,24 +1459,242 @@ func runNode(cmd *cobra.Command, args []string) {
 			env = governor.DevNetMode
 		}
 		gov = governor.NewChainGovernor(logger, db, env)
+
+		if *chainGovernorNFTNotional != 0 || *chainGovernorNFTApproval {
+			e, err := governor.NewNFTTransferEvaluator(env, *chainGovernorNFTNotional, *chainGovernorNFTApproval)
+			if err != nil {
+				logger.Fatal("failed to create NFT transfer evaluator", zap.Error(err))
+			}
+			gov.AddPayloadEvaluator(e)
+		}
+
+		if *chainGovernorPayload3Notional != 0 || *chainGovernorPayload3Approval {
+			e, err := gov.NewPayload3Evaluator(env, *chainGovernorPayload3Notional, *chainGovernorPayload3Approval)
+			if err != nil {
+				logger.Fatal("failed to create payload three evaluator", zap.Error(err))
+			}
+			gov.AddPayloadEvaluator(e)
+		}
+
+		if *chainGovernorPayloadRegistry != "" {
+			registry, err := vaa.LoadPayloadRegistry(*chainGovernorPayloadRegistry)
+			if err != nil {
+				logger.Fatal("failed to load payload registry", zap.Error(err))
+			}
+			gov.AddPayloadEvaluator(gov.NewPayloadRegistryEvaluator(registry, *chainGovernorPayloadRegistryApproval))
+		}
+
+		if *chainGovernorFlowCancel {
+			logger.Info("chain governor flow cancel is enabled")
+			gov.EnableFlowCancel()
+		}
+
+		if *chainGovernorEmergencyHoldThreshold != 0 {
+			logger.Info("chain governor emergency hold is enabled",
+				zap.Uint64("threshold", *chainGovernorEmergencyHoldThreshold),
+				zap.Int("quorum", *chainGovernorEmergencyReleaseQuorum))
+			if err := gov.EnableEmergencyHold(*chainGovernorEmergencyHoldThreshold, *chainGovernorEmergencyReleaseQuorum, gst); err != nil {
+				logger.Fatal("failed to enable the chain governor emergency hold", zap.Error(err))
+			}
+		}
 	} else {
 		logger.Info("chain governor is disabled")
 	}
+	if page != nil {
+		page.SetGovernor(gov)
+	}
+
+	// Trace verification of the EVM watchers, by chain. Chains without it are not in the map.
+	traceVerifications := make(map[vaa.ChainID]*ethereum.TraceVerification)
+	if len(*traceVerificationChains) != 0 {
+		emitters := common.KnownTokenbridgeEmitters
+		if *testnetMode {
+			emitters = common.KnownTestnetTokenbridgeEmitters
+		} else if *unsafeDevMode {
+			emitters = common.KnownDevnetTokenbridgeEmitters
+		}
+		for _, name := range *traceVerificationChains {
+			chainID, _ := vaa.ChainIDFromString(name)
+			v := &ethereum.TraceVerification{MinValue: *traceVerificationMinValue}
+			if emitter, exists := emitters[chainID]; exists {
+				copy(v.TokenBridge[:], emitter)
+			}
+			if gov != nil {
+				v.Valuer = gov
+			}
+			traceVerifications[chainID] = v
+		}
+		logger.Info("trace verification is enabled", zap.Strings("chains", *traceVerificationChains), zap.Uint64("minValue", *traceVerificationMinValue))
+	}
+
+	var acct *accountant.Accountant
+	if *accountantEnabled {
+		logger.Info("accountant is enabled", zap.Bool("logOnly", *accountantLogOnly))
+		env := accountant.MainNetMode
+		if *testnetMode {
+			env = accountant.TestNetMode
+		} else if *unsafeDevMode {
+			env = accountant.DevNetMode
+		}
+		acct = accountant.NewAccountant(logger, db, env, *accountantLogOnly)
+
+		if *accountantEmitterConfig != "" {
+			configs, err := accountant.LoadEmitterConfigs(*accountantEmitterConfig)
+			if err != nil {
+				logger.Fatal("failed to load accountant emitter config", zap.Error(err))
+			}
+			for _, cfg := range configs {
+				if err := acct.AddLedger(cfg); err != nil {
+					logger.Fatal("failed to add accountant ledger", zap.Error(err))
+				}
+			}
+		}
+	} else {
+		logger.Info("accountant is disabled")
+	}
+
+	var signLimiter *processor.SigningRateLimiter
+	if *signingRateLimit != 0 || len(*signingRateLimitOverrides) != 0 {
+		knownEmitters := common.KnownEmitters
+		if *testnetMode {
+			knownEmitters = common.KnownTestnetEmitters
+		} else if *unsafeDevMode {
+			knownEmitters = common.KnownDevnetEmitters
+		}
+		limits, err := parseSigningRateLimits(*signingRateLimit, *signingRateLimitOverrides, *signingRateLimitBypass, knownEmitters)
+		if err != nil {
+			logger.Fatal("invalid signing rate limit", zap.Error(err))
+		}
+		signLimiter, err = processor.NewSigningRateLimiter(limits)
+		if err != nil {
+			logger.Fatal("invalid signing rate limit", zap.Error(err))
+		}
+		logger.Info("signing rate limiter is enabled", zap.Uint64("perMinute", *signingRateLimit), zap.Strings("overrides", *signingRateLimitOverrides))
+	}
+
+	var breaker *processor.CircuitBreaker
+	if *circuitBreakerSpikeFactor != 0 || *circuitBreakerNewEmitterMinValue != 0 {
+		knownEmitters := common.KnownEmitters
+		if *testnetMode {
+			knownEmitters = common.KnownTestnetEmitters
+		} else if *unsafeDevMode {
+			knownEmitters = common.KnownDevnetEmitters
+		}
+		cfg := processor.CircuitBreakerConfig{
+			SpikeFactor:        *circuitBreakerSpikeFactor,
+			SpikeMinMessages:   *circuitBreakerSpikeMinMessages,
+			NewEmitterMinValue: *circuitBreakerNewEmitterMinValue,
+			History:            db,
+			KnownEmitters:      knownEmitters,
+		}
+		if gov != nil {
+			cfg.Valuer = gov
+		}
+		breaker, err = processor.NewCircuitBreaker(cfg)
+		if err != nil {
+			logger.Fatal("invalid circuit breaker configuration", zap.Error(err))
+		}
+		logger.Info("circuit breaker is enabled",
+			zap.Float64("spikeFactor", *circuitBreakerSpikeFactor),
+			zap.Uint64("newEmitterMinValue", *circuitBreakerNewEmitterMinValue))
+	}
+
+	// Features are activated across the network by a network configuration VAA, applied by the processor.
+	netConfig := networkconfig.NewState(logger.With(zap.String("component", "networkconfig")), db)
+	if err := netConfig.Load(); err != nil {
+		logger.Fatal("failed to load network config", zap.Error(err))
+	}
 
-	publicrpcService, publicrpcServer, err := publicrpcServiceRunnable(logger, *publicRPC, db, gst, gov)
+	// On SIGTERM or SIGINT, or when requested over the admin RPC, the node stops observing new messages and waits for
+	// its observations in flight to reach quorum before it shuts down.
+	drain := common.NewDrain()
+	drain.Add(processor.DrainComponent)
+	sigC := make(chan os.Signal, 2)
+	signal.Notify(sigC, syscall.SIGTERM, syscall.SIGINT)
+	go handleShutdown(logger, sigC, drain, db, *drainTimeout, rootCtxCancel, rootCtx.Done())
+
+	// Our observations waiting for quorum, listed over the admin RPC.
+	stuckMessages := processor.NewStuckMessages()
+
+	var rateLimiter *publicrpc.RateLimiter
+	if *publicRPCRateLimit > 0 || *publicRPCAPIKeysPath != "" {
+		limits := publicrpc.RateLimits{
+			Anonymous: publicrpc.Quota{RequestsPerSecond: *publicRPCRateLimit, Burst: *publicRPCRateBurst},
+		}
+		if *publicRPCAPIKeysPath != "" {
+			limits.APIKeys, err = publicrpc.LoadAPIKeys(*publicRPCAPIKeysPath)
+			if err != nil {
+				logger.Fatal("failed to load public RPC API keys", zap.Error(err))
+			}
+		}
+		rateLimiter = publicrpc.NewRateLimiter(limits)
+	}
+
+	// Sheds the public RPC when the node runs short of resources, nil if no limit is set.
+	var wd *watchdog.Watchdog
+	watchdogLimits := watchdog.Limits{
+		MemoryBytes: *watchdogMaxMemoryMB << 20,
+		OpenFiles:   *watchdogMaxOpenFiles,
+		DiskBytes:   *watchdogMaxDiskMB << 20,
+		Goroutines:  *watchdogMaxGoroutines,
+	}
+	if watchdogLimits.Enabled() {
+		wd = watchdog.New(logger, watchdogLimits, *dataDir, *watchdogInterval)
+	}
+
+	publicrpcService, publicrpcServer, err := publicrpcServiceRunnable(logger, *publicRPC, db, gst, gov, *publicRPCMaxRequestSize, rateLimiter, wd)
 
 	if err != nil {
 		log.Fatal("failed to create publicrpc service socket", zap.Error(err))
 	}
 
 	// local admin service socket
-	adminService, err := adminServiceRunnable(logger, *adminSocketPath, injectC, signedInC, obsvReqSendC, db, gst, gov)
+	var adminTCP *adminTCPConfig
+	if *adminListenAddr != "" {
+		adminTCP = &adminTCPConfig{
+			listenAddr:      *adminListenAddr,
+			certFile:        *adminTLSCert,
+			keyFile:         *adminTLSKey,
+			clientCAFile:    *adminTLSClientCA,
+			authzConfigPath: *adminAuthzConfigPath,
+		}
+	}
+	var references map[vaa.ChainID]*referenceRPC
+	if *referenceRPCConfigPath != "" {
+		references, err = loadReferenceRPCs(*referenceRPCConfigPath)
+		if err != nil {
+			logger.Fatal("failed to load reference RPC config", zap.Error(err))
+		}
+	}
+
+	// EVM chains whose block ranges can be rescanned with the rescan-block-range admin command.
+	rescanTargets := map[vaa.ChainID]evmRescanTarget{
+		vaa.ChainIDEthereum:  {*ethRPC, ethContractAddr},
+		vaa.ChainIDBSC:       {*bscRPC, bscContractAddr},
+		vaa.ChainIDPolygon:   {*polygonRPC, polygonContractAddr},
+		vaa.ChainIDAvalanche: {*avalancheRPC, avalancheContractAddr},
+		vaa.ChainIDOasis:     {*oasisRPC, oasisContractAddr},
+		vaa.ChainIDAurora:    {*auroraRPC, auroraContractAddr},
+		vaa.ChainIDFantom:    {*fantomRPC, fantomContractAddr},
+		vaa.ChainIDKarura:    {*karuraRPC, karuraContractAddr},
+		vaa.ChainIDAcala:     {*acalaRPC, acalaContractAddr},
+		vaa.ChainIDKlaytn:    {*klaytnRPC, klaytnContractAddr},
+		vaa.ChainIDCelo:      {*celoRPC, celoContractAddr},
+	}
+	if *testnetMode {
+		rescanTargets[vaa.ChainIDEthereumRopsten] = evmRescanTarget{*ethRopstenRPC, ethRopstenContractAddr}
+		rescanTargets[vaa.ChainIDMoonbeam] = evmRescanTarget{*moonbeamRPC, moonbeamContractAddr}
+		rescanTargets[vaa.ChainIDNeon] = evmRescanTarget{*neonRPC, neonContractAddr}
+	}
+
+	adminService, err := adminServiceRunnable(logger, *adminSocketPath, adminTCP, injectC, bus.SignedVAAs().C(), obsvReqSendC, db, gst, gov, acct, watchers, references, tree, rateLimiter, wd, auditLog, signLimiter, breaker, drain,
+		stuckMessages, rescanTargets, bus.ObservationRequests().C())
 	if err != nil {
 		logger.Fatal("failed to create admin service socket", zap.Error(err))
 	}
 
 	publicwebService, err := publicwebServiceRunnable(logger, *publicWeb, *adminSocketPath, publicrpcServer,
-		*tlsHostname, *tlsProdEnv, path.Join(*dataDir, "autocert"))
+		*tlsHostname, *tlsProdEnv, path.Join(*dataDir, "autocert"), int64(*publicRPCMaxRequestSize), *publicWebCORSOrigins)
 	if err != nil {
 		log.Fatal("failed to create publicrpc service socket", zap.Error(err))
 	}
@@ -953,17 +1702,21 @@ func runNode(cmd *cobra.Command, args []string) {
 	// Run supervisor.
 	supervisor.New(rootCtx, logger, func(ctx context.Context) error {
 		if err := supervisor.Run(ctx, "p2p", p2p.Run(
-			obsvC, obsvReqC, obsvReqSendC, sendC, signedInC, priv, gk, gst, *p2pPort, *p2pNetworkID, *p2pBootstrap, *nodeName, *disableHeartbeatVerify, rootCtxCancel, gov)); err != nil {
+			bus.SignedObservations().C(), bus.ObservationRequests().C(), obsvReqSendC, sendC, bus.SignedVAAs().C(), priv, gk, nextGk, gossipSigner, gst, p2pListenConfig, *p2pNetworkID, *p2pBootstrap, *nodeName, *disableHeartbeatVerify, *observerMode, rootCtxCancel, gov)); err != nil {
 			return err
 		}
 
 		if err := supervisor.Run(ctx, "ethwatch",
-			ethereum.NewEthWatcher(*ethRPC, ethContractAddr, "eth", common.ReadinessEthSyncing, vaa.ChainIDEthereum, lockC, setC, 1, chainObsvReqC[vaa.ChainIDEthereum], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDEthereum, *ethRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, ethContractAddr, "eth", common.ReadinessEthSyncing, vaa.ChainIDEthereum, lockC, setC, 1, chainObsvReqC[vaa.ChainIDEthereum], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDEthereum)).WithTraceVerification(traceVerifications[vaa.ChainIDEthereum]).Run
+			})); err != nil {
 			return err
 		}
 
 		if err := supervisor.Run(ctx, "bscwatch",
-			ethereum.NewEthWatcher(*bscRPC, bscContractAddr, "bsc", common.ReadinessBSCSyncing, vaa.ChainIDBSC, lockC, nil, 1, chainObsvReqC[vaa.ChainIDBSC], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDBSC, *bscRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, bscContractAddr, "bsc", common.ReadinessBSCSyncing, vaa.ChainIDBSC, lockC, nil, 1, chainObsvReqC[vaa.ChainIDBSC], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDBSC)).WithTraceVerification(traceVerifications[vaa.ChainIDBSC]).Run
+			})); err != nil {
 			return err
 		}
 
@@ -973,7 +1726,9 @@ func runNode(cmd *cobra.Command, args []string) {
 		}
 
 		if err := supervisor.Run(ctx, "polygonwatch",
-			ethereum.NewEthWatcher(*polygonRPC, polygonContractAddr, "polygon", common.ReadinessPolygonSyncing, vaa.ChainIDPolygon, lockC, nil, polygonMinConfirmations, chainObsvReqC[vaa.ChainIDPolygon], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDPolygon, *polygonRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, polygonContractAddr, "polygon", common.ReadinessPolygonSyncing, vaa.ChainIDPolygon, lockC, nil, polygonMinConfirmations, chainObsvReqC[vaa.ChainIDPolygon], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDPolygon)).WithTraceVerification(traceVerifications[vaa.ChainIDPolygon]).Run
+			})); err != nil {
 			// Special case: Polygon can fork like PoW Ethereum, and it's not clear what the safe number of blocks is
 			//
 			// Hardcode the minimum number of confirmations to 512 regardless of what the smart contract specifies to protect
@@ -982,49 +1737,71 @@ func runNode(cmd *cobra.Command, args []string) {
 			return err
 		}
 		if err := supervisor.Run(ctx, "avalanchewatch",
-			ethereum.NewEthWatcher(*avalancheRPC, avalancheContractAddr, "avalanche", common.ReadinessAvalancheSyncing, vaa.ChainIDAvalanche, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAvalanche], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDAvalanche, *avalancheRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, avalancheContractAddr, "avalanche", common.ReadinessAvalancheSyncing, vaa.ChainIDAvalanche, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAvalanche], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDAvalanche)).WithTraceVerification(traceVerifications[vaa.ChainIDAvalanche]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "oasiswatch",
-			ethereum.NewEthWatcher(*oasisRPC, oasisContractAddr, "oasis", common.ReadinessOasisSyncing, vaa.ChainIDOasis, lockC, nil, 1, chainObsvReqC[vaa.ChainIDOasis], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDOasis, *oasisRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, oasisContractAddr, "oasis", common.ReadinessOasisSyncing, vaa.ChainIDOasis, lockC, nil, 1, chainObsvReqC[vaa.ChainIDOasis], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDOasis)).WithTraceVerification(traceVerifications[vaa.ChainIDOasis]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "aurorawatch",
-			ethereum.NewEthWatcher(*auroraRPC, auroraContractAddr, "aurora", common.ReadinessAuroraSyncing, vaa.ChainIDAurora, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAurora], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDAurora, *auroraRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, auroraContractAddr, "aurora", common.ReadinessAuroraSyncing, vaa.ChainIDAurora, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAurora], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDAurora)).WithTraceVerification(traceVerifications[vaa.ChainIDAurora]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "fantomwatch",
-			ethereum.NewEthWatcher(*fantomRPC, fantomContractAddr, "fantom", common.ReadinessFantomSyncing, vaa.ChainIDFantom, lockC, nil, 1, chainObsvReqC[vaa.ChainIDFantom], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDFantom, *fantomRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, fantomContractAddr, "fantom", common.ReadinessFantomSyncing, vaa.ChainIDFantom, lockC, nil, 1, chainObsvReqC[vaa.ChainIDFantom], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDFantom)).WithTraceVerification(traceVerifications[vaa.ChainIDFantom]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "karurawatch",
-			ethereum.NewEthWatcher(*karuraRPC, karuraContractAddr, "karura", common.ReadinessKaruraSyncing, vaa.ChainIDKarura, lockC, nil, 1, chainObsvReqC[vaa.ChainIDKarura], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDKarura, *karuraRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, karuraContractAddr, "karura", common.ReadinessKaruraSyncing, vaa.ChainIDKarura, lockC, nil, 1, chainObsvReqC[vaa.ChainIDKarura], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDKarura)).WithTraceVerification(traceVerifications[vaa.ChainIDKarura]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "acalawatch",
-			ethereum.NewEthWatcher(*acalaRPC, acalaContractAddr, "acala", common.ReadinessAcalaSyncing, vaa.ChainIDAcala, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAcala], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDAcala, *acalaRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, acalaContractAddr, "acala", common.ReadinessAcalaSyncing, vaa.ChainIDAcala, lockC, nil, 1, chainObsvReqC[vaa.ChainIDAcala], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDAcala)).WithTraceVerification(traceVerifications[vaa.ChainIDAcala]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "klaytnwatch",
-			ethereum.NewEthWatcher(*klaytnRPC, klaytnContractAddr, "klaytn", common.ReadinessKlaytnSyncing, vaa.ChainIDKlaytn, lockC, nil, 1, chainObsvReqC[vaa.ChainIDKlaytn], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDKlaytn, *klaytnRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, klaytnContractAddr, "klaytn", common.ReadinessKlaytnSyncing, vaa.ChainIDKlaytn, lockC, nil, 1, chainObsvReqC[vaa.ChainIDKlaytn], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDKlaytn)).WithTraceVerification(traceVerifications[vaa.ChainIDKlaytn]).Run
+			})); err != nil {
 			return err
 		}
 		if err := supervisor.Run(ctx, "celowatch",
-			ethereum.NewEthWatcher(*celoRPC, celoContractAddr, "celo", common.ReadinessCeloSyncing, vaa.ChainIDCelo, lockC, nil, 1, chainObsvReqC[vaa.ChainIDCelo], *unsafeDevMode).Run); err != nil {
+			watchers.Register(vaa.ChainIDCelo, *celoRPC, func(rpcURL string) supervisor.Runnable {
+				return ethereum.NewEthWatcher(rpcURL, celoContractAddr, "celo", common.ReadinessCeloSyncing, vaa.ChainIDCelo, lockC, nil, 1, chainObsvReqC[vaa.ChainIDCelo], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDCelo)).WithTraceVerification(traceVerifications[vaa.ChainIDCelo]).Run
+			})); err != nil {
 			return err
 		}
 
 		if *testnetMode {
 			if err := supervisor.Run(ctx, "ethropstenwatch",
-				ethereum.NewEthWatcher(*ethRopstenRPC, ethRopstenContractAddr, "ethropsten", common.ReadinessEthRopstenSyncing, vaa.ChainIDEthereumRopsten, lockC, nil, 1, chainObsvReqC[vaa.ChainIDEthereumRopsten], *unsafeDevMode).Run); err != nil {
+				watchers.Register(vaa.ChainIDEthereumRopsten, *ethRopstenRPC, func(rpcURL string) supervisor.Runnable {
+					return ethereum.NewEthWatcher(rpcURL, ethRopstenContractAddr, "ethropsten", common.ReadinessEthRopstenSyncing, vaa.ChainIDEthereumRopsten, lockC, nil, 1, chainObsvReqC[vaa.ChainIDEthereumRopsten], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDEthereumRopsten)).WithTraceVerification(traceVerifications[vaa.ChainIDEthereumRopsten]).Run
+				})); err != nil {
 				return err
 			}
 			if err := supervisor.Run(ctx, "moonbeamwatch",
-				ethereum.NewEthWatcher(*moonbeamRPC, moonbeamContractAddr, "moonbeam", common.ReadinessMoonbeamSyncing, vaa.ChainIDMoonbeam, lockC, nil, 1, chainObsvReqC[vaa.ChainIDMoonbeam], *unsafeDevMode).Run); err != nil {
+				watchers.Register(vaa.ChainIDMoonbeam, *moonbeamRPC, func(rpcURL string) supervisor.Runnable {
+					return ethereum.NewEthWatcher(rpcURL, moonbeamContractAddr, "moonbeam", common.ReadinessMoonbeamSyncing, vaa.ChainIDMoonbeam, lockC, nil, 1, chainObsvReqC[vaa.ChainIDMoonbeam], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDMoonbeam)).WithTraceVerification(traceVerifications[vaa.ChainIDMoonbeam]).Run
+				})); err != nil {
 				return err
 			}
 			if err := supervisor.Run(ctx, "neonwatch",
-				ethereum.NewEthWatcher(*neonRPC, neonContractAddr, "neon", common.ReadinessNeonSyncing, vaa.ChainIDNeon, lockC, nil, 32, chainObsvReqC[vaa.ChainIDNeon], *unsafeDevMode).Run); err != nil {
+				watchers.Register(vaa.ChainIDNeon, *neonRPC, func(rpcURL string) supervisor.Runnable {
+					return ethereum.NewEthWatcher(rpcURL, neonContractAddr, "neon", common.ReadinessNeonSyncing, vaa.ChainIDNeon, lockC, nil, 32, chainObsvReqC[vaa.ChainIDNeon], *unsafeDevMode).WithCodeHash(contracts.CodeHash(vaa.ChainIDNeon)).WithTraceVerification(traceVerifications[vaa.ChainIDNeon]).Run
+				})); err != nil {
 				return err
 			}
 		}
@@ -1032,7 +1809,7 @@ func runNode(cmd *cobra.Command, args []string) {
 		if *terraWS != "" {
 			logger.Info("Starting Terra watcher")
 			if err := supervisor.Run(ctx, "terrawatch",
-				cosmwasm.NewWatcher(*terraWS, *terraLCD, *terraContract, lockC, chainObsvReqC[vaa.ChainIDTerra], common.ReadinessTerraSyncing, vaa.ChainIDTerra).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDTerra, cosmwasm.NewWatcher(*terraWS, *terraLCD, *terraContract, lockC, chainObsvReqC[vaa.ChainIDTerra], common.ReadinessTerraSyncing, vaa.ChainIDTerra).Run)); err != nil {
 				return err
 			}
 		}
@@ -1040,7 +1817,15 @@ func runNode(cmd *cobra.Command, args []string) {
 		if *terra2WS != "" {
 			logger.Info("Starting Terra 2 watcher")
 			if err := supervisor.Run(ctx, "terra2watch",
-				cosmwasm.NewWatcher(*terra2WS, *terra2LCD, *terra2Contract, lockC, chainObsvReqC[vaa.ChainIDTerra2], common.ReadinessTerra2Syncing, vaa.ChainIDTerra2).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDTerra2, cosmwasm.NewWatcher(*terra2WS, *terra2LCD, *terra2Contract, lockC, chainObsvReqC[vaa.ChainIDTerra2], common.ReadinessTerra2Syncing, vaa.ChainIDTerra2).Run)); err != nil {
+				return err
+			}
+		}
+
+		if *wormchainWS != "" {
+			logger.Info("Starting Wormchain watcher")
+			if err := supervisor.Run(ctx, "wormchainwatch",
+				watchers.RegisterFixed(vaa.ChainIDWormchain, cosmwasm.NewWatcher(*wormchainWS, *wormchainLCD, *wormchainContract, lockC, chainObsvReqC[vaa.ChainIDWormchain], common.ReadinessWormchainSyncing, vaa.ChainIDWormchain).Run)); err != nil {
 				return err
 			}
 		}
@@ -1048,50 +1833,56 @@ func runNode(cmd *cobra.Command, args []string) {
 		if *testnetMode {
 			logger.Info("Starting Injective watcher")
 			if err := supervisor.Run(ctx, "injectivewatch",
-				cosmwasm.NewWatcher(*injectiveWS, *injectiveLCD, *injectiveContract, lockC, chainObsvReqC[vaa.ChainIDInjective], common.ReadinessInjectiveSyncing, vaa.ChainIDInjective).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDInjective, cosmwasm.NewWatcher(*injectiveWS, *injectiveLCD, *injectiveContract, lockC, chainObsvReqC[vaa.ChainIDInjective], common.ReadinessInjectiveSyncing, vaa.ChainIDInjective).Run)); err != nil {
 				return err
 			}
 		}
 
 		if *algorandIndexerRPC != "" {
 			if err := supervisor.Run(ctx, "algorandwatch",
-				algorand.NewWatcher(*algorandIndexerRPC, *algorandIndexerToken, *algorandAlgodRPC, *algorandAlgodToken, *algorandAppID, lockC, setC, chainObsvReqC[vaa.ChainIDAlgorand]).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDAlgorand, algorand.NewWatcher(*algorandIndexerRPC, *algorandIndexerToken, *algorandAlgodRPC, *algorandAlgodToken, *algorandAppID, lockC, setC, chainObsvReqC[vaa.ChainIDAlgorand]).Run)); err != nil {
 				return err
 			}
 		}
 		if *nearRPC != "" {
 			if err := supervisor.Run(ctx, "nearwatch",
-				near.NewWatcher(*nearRPC, *nearContract, lockC, chainObsvReqC[vaa.ChainIDNear]).Run); err != nil {
+				watchers.Register(vaa.ChainIDNear, *nearRPC, func(rpcURL string) supervisor.Runnable {
+					return near.NewWatcher(rpcURL, *nearContract, lockC, chainObsvReqC[vaa.ChainIDNear]).Run
+				})); err != nil {
 				return err
 			}
 		}
 		if *aptosRPC != "" {
 			if err := supervisor.Run(ctx, "aptoswatch",
-				aptos.NewWatcher(*aptosRPC, *aptosAccount, *aptosHandle, lockC, chainObsvReqC[vaa.ChainIDAptos]).Run); err != nil {
+				watchers.Register(vaa.ChainIDAptos, *aptosRPC, func(rpcURL string) supervisor.Runnable {
+					w := aptos.NewWatcher(rpcURL, *aptosAccount, *aptosHandle, lockC, chainObsvReqC[vaa.ChainIDAptos])
+					w.SetReconcile(*aptosReconcileInterval, *aptosAutoBackfill)
+					return w.Run
+				})); err != nil {
 				return err
 			}
 		}
 
 		if *solanaWsRPC != "" {
 			if err := supervisor.Run(ctx, "solwatch-confirmed",
-				solana.NewSolanaWatcher(*solanaWsRPC, *solanaRPC, solAddress, lockC, nil, rpc.CommitmentConfirmed, common.ReadinessSolanaSyncing, vaa.ChainIDSolana).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDSolana, solana.NewSolanaWatcher(*solanaWsRPC, *solanaRPC, solAddress, lockC, nil, rpc.CommitmentConfirmed, common.ReadinessSolanaSyncing, vaa.ChainIDSolana).WithSecondaryRPC(*solanaSecondaryRPC).Run)); err != nil {
 				return err
 			}
 
 			if err := supervisor.Run(ctx, "solwatch-finalized",
-				solana.NewSolanaWatcher(*solanaWsRPC, *solanaRPC, solAddress, lockC, chainObsvReqC[vaa.ChainIDSolana], rpc.CommitmentFinalized, common.ReadinessSolanaSyncing, vaa.ChainIDSolana).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDSolana, solana.NewSolanaWatcher(*solanaWsRPC, *solanaRPC, solAddress, lockC, chainObsvReqC[vaa.ChainIDSolana], rpc.CommitmentFinalized, common.ReadinessSolanaSyncing, vaa.ChainIDSolana).WithSecondaryRPC(*solanaSecondaryRPC).Run)); err != nil {
 				return err
 			}
 		}
 
 		if *pythnetWsRPC != "" {
 			if err := supervisor.Run(ctx, "pythwatch-confirmed",
-				solana.NewSolanaWatcher(*pythnetWsRPC, *pythnetRPC, pythnetAddress, lockC, nil, rpc.CommitmentConfirmed, common.ReadinessPythNetSyncing, vaa.ChainIDPythNet).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDPythNet, solana.NewSolanaWatcher(*pythnetWsRPC, *pythnetRPC, pythnetAddress, lockC, nil, rpc.CommitmentConfirmed, common.ReadinessPythNetSyncing, vaa.ChainIDPythNet).WithSecondaryRPC(*pythnetSecondaryRPC).Run)); err != nil {
 				return err
 			}
 
 			if err := supervisor.Run(ctx, "pythwatch-finalized",
-				solana.NewSolanaWatcher(*pythnetWsRPC, *pythnetRPC, pythnetAddress, lockC, chainObsvReqC[vaa.ChainIDPythNet], rpc.CommitmentFinalized, common.ReadinessPythNetSyncing, vaa.ChainIDPythNet).Run); err != nil {
+				watchers.RegisterFixed(vaa.ChainIDPythNet, solana.NewSolanaWatcher(*pythnetWsRPC, *pythnetRPC, pythnetAddress, lockC, chainObsvReqC[vaa.ChainIDPythNet], rpc.CommitmentFinalized, common.ReadinessPythNetSyncing, vaa.ChainIDPythNet).WithSecondaryRPC(*pythnetSecondaryRPC).Run)); err != nil {
 				return err
 			}
 		}
@@ -1103,28 +1894,116 @@ func runNode(cmd *cobra.Command, args []string) {
 			}
 		}
 
+		if acct != nil {
+			err := acct.Run(ctx)
+			if err != nil {
+				log.Fatal("failed to create accountant", zap.Error(err))
+			}
+
+			if *accountantReconcileEnabled {
+				evmRPCs := map[vaa.ChainID]string{
+					vaa.ChainIDEthereum:  *ethRPC,
+					vaa.ChainIDBSC:       *bscRPC,
+					vaa.ChainIDPolygon:   *polygonRPC,
+					vaa.ChainIDAvalanche: *avalancheRPC,
+					vaa.ChainIDOasis:     *oasisRPC,
+					vaa.ChainIDAurora:    *auroraRPC,
+					vaa.ChainIDFantom:    *fantomRPC,
+					vaa.ChainIDKarura:    *karuraRPC,
+					vaa.ChainIDAcala:     *acalaRPC,
+					vaa.ChainIDKlaytn:    *klaytnRPC,
+					vaa.ChainIDCelo:      *celoRPC,
+				}
+				for chainID, rpcURL := range evmRPCs {
+					if rpcURL == "" {
+						continue
+					}
+					if err := acct.AddEvmCustodyQuerier(chainID, rpcURL); err != nil {
+						logger.Info("accountant will not reconcile chain", zap.Stringer("chain", chainID), zap.Error(err))
+					}
+				}
+
+				if err := supervisor.Run(ctx, "accountant-reconcile", acct.RunReconciliation); err != nil {
+					return err
+				}
+			}
+		}
+
 		p := processor.NewProcessor(ctx,
 			db,
-			lockC,
+			bus,
 			setC,
 			sendC,
-			obsvC,
 			obsvReqSendC,
 			injectC,
-			signedInC,
 			gk,
+			nextGk,
 			gst,
 			*unsafeDevMode,
 			*devNumGuardians,
 			*ethRPC,
+			*observerMode,
 			attestationEvents,
 			notifier,
 			gov,
+			acct,
+			signLimiter,
+			breaker,
+			drain,
+			netConfig,
+			stuckMessages,
 		)
 		if err := supervisor.Run(ctx, "processor", p.Run); err != nil {
 			return err
 		}
 
+		if page != nil {
+			// Lossy, so that the page never holds back the processor.
+			msgC := bus.MessagePublications().Subscribe("statuspage", eventbus.Options{Priority: eventbus.PriorityLow, Buffer: 100, Lossy: true})
+			if err := supervisor.Run(ctx, "statuspage", page.Run(msgC)); err != nil {
+				return err
+			}
+		}
+
+		// Started once the processor and the status page subscribed, so that it receives every event.
+		if err := supervisor.Run(ctx, "eventbus", bus.Run); err != nil {
+			return err
+		}
+
+		if anomalyDetector != nil {
+			if err := supervisor.Run(ctx, "signinganomalies", anomalyDetector.Run); err != nil {
+				return err
+			}
+		}
+
+		if retentionPolicy != nil {
+			if err := supervisor.Run(ctx, "db-retention", db.RunRetention(logger, retentionPolicy)); err != nil {
+				return err
+			}
+		}
+
+		if err := supervisor.Run(ctx, "db-integrity", dbIntegrityRunnable(logger, db, *dbIntegrityInterval, integrityPeers, bus.SignedVAAs().C())); err != nil {
+			return err
+		}
+
+		if err := supervisor.Run(ctx, "readiness-lag", readinessLagRunnable(gst)); err != nil {
+			return err
+		}
+
+		if wd != nil {
+			if err := supervisor.Run(ctx, "watchdog", wd.Runnable()); err != nil {
+				return err
+			}
+		}
+
+		if err := supervisor.Run(ctx, "network-overview", p2p.NetworkOverviewRunnable(gst)); err != nil {
+			return err
+		}
+
+		if err := supervisor.Run(ctx, "guardian-availability", p2p.AvailabilityRunnable(logger, db, gst)); err != nil {
+			return err
+		}
+
 		if err := supervisor.Run(ctx, "admin", adminService); err != nil {
 			return err
 		}
@@ -1152,6 +2031,56 @@ func runNode(cmd *cobra.Command, args []string) {
 			}
 		}
 
+		if *redemptionTrackerEnabled {
+			emitters := common.KnownTokenbridgeEmitters
+			if *testnetMode {
+				emitters = common.KnownTestnetTokenbridgeEmitters
+			} else if *unsafeDevMode {
+				emitters = common.KnownDevnetTokenbridgeEmitters
+			}
+
+			evmRPCs := map[vaa.ChainID]string{
+				vaa.ChainIDEthereum:  *ethRPC,
+				vaa.ChainIDBSC:       *bscRPC,
+				vaa.ChainIDPolygon:   *polygonRPC,
+				vaa.ChainIDAvalanche: *avalancheRPC,
+				vaa.ChainIDOasis:     *oasisRPC,
+				vaa.ChainIDAurora:    *auroraRPC,
+				vaa.ChainIDFantom:    *fantomRPC,
+				vaa.ChainIDKarura:    *karuraRPC,
+				vaa.ChainIDAcala:     *acalaRPC,
+				vaa.ChainIDKlaytn:    *klaytnRPC,
+				vaa.ChainIDCelo:      *celoRPC,
+			}
+			checkers := make(map[vaa.ChainID]redemption.RedemptionChecker)
+			for chainID, rpcURL := range evmRPCs {
+				emitter, exists := emitters[chainID]
+				if rpcURL == "" || !exists {
+					continue
+				}
+				tokenBridge := eth_common.BytesToAddress(emitter[12:])
+				if c, ok := contracts.Contract(chainID); ok && c.TokenBridge != (eth_common.Address{}) {
+					tokenBridge = c.TokenBridge
+				}
+				checkers[chainID] = redemption.NewEvmRedemptionChecker(rpcURL, tokenBridge)
+			}
+
+			cfg := redemption.Config{
+				Emitters:   emitters,
+				Checkers:   checkers,
+				MinValue:   *redemptionTrackerMinValue,
+				StuckAfter: *redemptionTrackerStuckAfter,
+				MaxAge:     *redemptionTrackerMaxAge,
+			}
+			if gov != nil {
+				cfg.Valuer = gov
+			}
+			logger.Info("redemption tracker is enabled", zap.Int("numChains", len(checkers)))
+			if err := supervisor.Run(ctx, "redemption-tracker", redemption.NewTracker(logger, cfg).Run(attestationEvents)); err != nil {
+				return err
+			}
+		}
+
 		logger.Info("Started internal services")
 
 		<-ctx.Done()
@@ -1159,13 +2088,33 @@ func runNode(cmd *cobra.Command, args []string) {
 	},
 		// It's safer to crash and restart the process in case we encounter a panic,
 		// rather than attempting to reschedule the runnable.
-		supervisor.WithPropagatePanic)
+		supervisor.WithPropagatePanic,
+		supervisor.WithIntrospector(tree))
 
 	<-rootCtx.Done()
 	logger.Info("root context cancelled, exiting...")
 	// TODO: wait for things to shut down gracefully
 }
 
+// logRedactions returns the secrets which must not be shipped with the logs: the RPC endpoints, which often include API
+// keys, and the access tokens.
+func logRedactions(flags *pflag.FlagSet) []string {
+	var redact []string
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "publicRPC" {
+			// A listen address, not an endpoint.
+			return
+		}
+		for _, suffix := range []string{"RPC", "WS", "LCD", "Token", "telemetryKey"} {
+			if strings.HasSuffix(f.Name, suffix) && f.Value.String() != "" {
+				redact = append(redact, f.Value.String())
+				return
+			}
+		}
+	})
+	return redact
+}
+
 func decryptTelemetryServiceAccount() ([]byte, error) {
 	// Decrypt service account credentials
 	key, err := base64.StdEncoding.DecodeString(*telemetryKey)
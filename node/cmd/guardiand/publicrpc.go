@@ -10,11 +10,12 @@ import (
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
 	"github.com/certusone/wormhole/node/pkg/publicrpc"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/watchdog"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
 
-func publicrpcServiceRunnable(logger *zap.Logger, listenAddr string, db *db.Database, gst *common.GuardianSetState, gov *governor.ChainGovernor) (supervisor.Runnable, *grpc.Server, error) {
+func publicrpcServiceRunnable(logger *zap.Logger, listenAddr string, db *db.Database, gst *common.GuardianSetState, gov *governor.ChainGovernor, maxRequestSize int, rl *publicrpc.RateLimiter, wd *watchdog.Watchdog) (supervisor.Runnable, *grpc.Server, error) {
 	l, err := net.Listen("tcp", listenAddr)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to listen: %w", err)
@@ -23,7 +24,14 @@ func publicrpcServiceRunnable(logger *zap.Logger, listenAddr string, db *db.Data
 	logger.Info("publicrpc server listening", zap.String("addr", l.Addr().String()))
 
 	rpcServer := publicrpc.NewPublicrpcServer(logger, db, gst, gov)
-	grpcServer := common.NewInstrumentedGRPCServer(logger)
+	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(maxRequestSize)}
+	if wd != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(publicrpc.SheddingInterceptor(wd)))
+	}
+	if rl != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(rl.UnaryInterceptor))
+	}
+	grpcServer := common.NewInstrumentedGRPCServer(logger, opts...)
 	publicrpcv1.RegisterPublicRPCServiceServer(grpcServer, rpcServer)
 
 	return supervisor.GRPCServer(grpcServer, l, false), grpcServer, nil
@@ -8,20 +8,32 @@ import (
 	"strings"
 
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	"github.com/certusone/wormhole/node/pkg/publicrpc"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/acme"
 	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-func allowCORSWrapper(h http.Handler) http.Handler {
+// corsWrapper allows cross-origin requests from the given origins. If no origins are given, any origin is allowed.
+func corsWrapper(origins []string, h http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[o] = true
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if origin := r.Header.Get("Origin"); origin != "" {
+		if origin := r.Header.Get("Origin"); origin != "" && (len(allowed) == 0 || allowed["*"] || allowed[origin]) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			// Browsers need to read the trailers of gRPC-Web responses.
+			w.Header().Set("Access-Control-Expose-Headers", "grpc-status,grpc-message")
 			if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
 				corsPreflightHandler(w, r)
 				return
@@ -37,8 +49,10 @@ func corsPreflightHandler(w http.ResponseWriter, r *http.Request) {
 		"accept",
 		"x-user-agent",
 		"x-grpc-web",
+		publicrpc.APIKeyHeader,
 		"grpc-status",
 		"grpc-message",
+		"grpc-timeout",
 		"authorization",
 	}
 	w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ","))
@@ -46,6 +60,23 @@ func corsPreflightHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ","))
 }
 
+// publicwebHandler serves the public RPC service as gRPC-Web, native gRPC and JSON over HTTP on the same listener.
+func publicwebHandler(grpcServer *grpc.Server, gwmux http.Handler, corsOrigins []string, maxRequestSize int64) http.Handler {
+	grpcWebServer := grpcweb.WrapServer(grpcServer)
+
+	return corsWrapper(corsOrigins, http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		req.Body = http.MaxBytesReader(resp, req.Body, maxRequestSize)
+		switch {
+		case grpcWebServer.IsGrpcWebRequest(req):
+			grpcWebServer.ServeHTTP(resp, req)
+		case req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc"):
+			grpcServer.ServeHTTP(resp, req)
+		default:
+			gwmux.ServeHTTP(resp, req)
+		}
+	}))
+}
+
 func publicwebServiceRunnable(
 	logger *zap.Logger,
 	listenAddr string,
@@ -54,6 +85,8 @@ func publicwebServiceRunnable(
 	tlsHostname string,
 	tlsProd bool,
 	tlsCacheDir string,
+	maxRequestSize int64,
+	corsOrigins []string,
 ) (supervisor.Runnable, error) {
 	return func(ctx context.Context) error {
 		conn, err := grpc.DialContext(
@@ -65,24 +98,23 @@ func publicwebServiceRunnable(
 			return fmt.Errorf("failed to dial upstream: %s", err)
 		}
 
-		gwmux := runtime.NewServeMux()
+		gwmux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if strings.EqualFold(key, publicrpc.APIKeyHeader) {
+				return publicrpc.APIKeyHeader, true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}))
 		err = publicrpcv1.RegisterPublicRPCServiceHandler(ctx, gwmux, conn)
 		if err != nil {
 			panic(err)
 		}
 
 		mux := http.NewServeMux()
-		grpcWebServer := grpcweb.WrapServer(grpcServer)
-		mux.Handle("/", allowCORSWrapper(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-			if grpcWebServer.IsGrpcWebRequest(req) {
-				grpcWebServer.ServeHTTP(resp, req)
-			} else {
-				gwmux.ServeHTTP(resp, req)
-			}
-		})))
+		mux.Handle("/", publicwebHandler(grpcServer, gwmux, corsOrigins, maxRequestSize))
 
 		srv := &http.Server{
-			Handler: mux,
+			// Native gRPC clients speak HTTP/2 without TLS (h2c) if no TLS hostname is configured.
+			Handler: h2c.NewHandler(mux, &http2.Server{}),
 		}
 
 		// TLS setup
@@ -0,0 +1,82 @@
+package guardiand
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	"github.com/certusone/wormhole/node/pkg/publicrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestCORSWrapper(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		allowed bool
+	}{
+		{"any origin by default", nil, "https://explorer.example.com", true},
+		{"listed origin", []string{"https://explorer.example.com"}, "https://explorer.example.com", true},
+		{"unlisted origin", []string{"https://explorer.example.com"}, "https://evil.example.com", false},
+		{"wildcard", []string{"*"}, "https://evil.example.com", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/v1/guardianset/current", nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			rec := httptest.NewRecorder()
+			corsWrapper(tc.origins, ok).ServeHTTP(rec, req)
+
+			if tc.allowed {
+				assert.Equal(t, tc.origin, rec.Header().Get("Access-Control-Allow-Origin"))
+				assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), publicrpc.APIKeyHeader)
+			} else {
+				assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, rec.Header().Get("Access-Control-Allow-Headers"))
+			}
+		})
+	}
+}
+
+func TestPublicwebHandler(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	publicrpcv1.RegisterPublicRPCServiceServer(grpcServer, publicrpc.NewPublicrpcServer(zap.NewNop(), nil, common.NewGuardianSetState(), nil))
+
+	gwmux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	srv := httptest.NewServer(h2c.NewHandler(publicwebHandler(grpcServer, gwmux, nil, 1024), &http2.Server{}))
+	defer srv.Close()
+
+	// JSON requests are passed to the gateway.
+	resp, err := http.Get(srv.URL + "/v1/heartbeats")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	// Native gRPC requests are served by the gRPC server on the same listener.
+	conn, err := grpc.Dial(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = publicrpcv1.NewPublicRPCServiceClient(conn).GetLastHeartbeats(context.Background(), &publicrpcv1.GetLastHeartbeatsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
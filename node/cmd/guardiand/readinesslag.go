@@ -0,0 +1,83 @@
+package guardiand
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/readiness"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const readinessLagInterval = 15 * time.Second
+
+// peerMedianHeights returns the median of the heights reported for each chain by the other guardians. Unlike the
+// highest height, the median cannot be skewed by a single guardian reporting a wrong height.
+func peerMedianHeights(heartbeats map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat, ourAddr ethcommon.Address) map[uint32]int64 {
+	heights := make(map[uint32][]int64)
+	for addr, hbs := range heartbeats {
+		if addr == ourAddr {
+			continue
+		}
+
+		// Count each guardian once, with the highest height of its nodes.
+		highest := make(map[uint32]int64)
+		for _, hb := range hbs {
+			for _, n := range hb.Networks {
+				if n.Height > highest[n.Id] {
+					highest[n.Id] = n.Height
+				}
+			}
+		}
+		for id, h := range highest {
+			heights[id] = append(heights[id], h)
+		}
+	}
+
+	medians := make(map[uint32]int64, len(heights))
+	for id, hs := range heights {
+		sort.Slice(hs, func(i, j int) bool { return hs[i] < hs[j] })
+		medians[id] = hs[len(hs)/2]
+	}
+	return medians
+}
+
+// readinessLagRunnable periodically sets the lag of each watcher's readiness component to the number of blocks it is
+// behind the median height reported by the other guardians.
+func readinessLagRunnable(gst *common.GuardianSetState) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		ticker := time.NewTicker(readinessLagInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				ourAddr := ethcommon.HexToAddress(p2p.DefaultRegistry.GuardianAddress())
+				medians := peerMedianHeights(gst.GetAll(), ourAddr)
+
+				for _, n := range p2p.DefaultRegistry.NetworkStats() {
+					component, ok := common.ReadinessByChain[vaa.ChainID(n.Id)]
+					if !ok {
+						continue
+					}
+
+					var lag int64
+					if median, ok := medians[n.Id]; ok && median > n.Height {
+						lag = median - n.Height
+					}
+					readiness.SetLag(component, lag)
+				}
+			}
+		}
+	}
+}
@@ -18,6 +18,7 @@ func handleReobservationRequests(
 	logger *zap.Logger,
 	obsvReqC <-chan *gossipv1.ObservationRequest,
 	chainObsvReqC map[vaa.ChainID]chan *gossipv1.ObservationRequest,
+	isPaused func(vaa.ChainID) bool,
 ) {
 	// Due to the automatic re-observation requests sent out by the processor we may end
 	// up getting multiple requests to re-observe the same tx. Keep a cache of the
@@ -57,6 +58,15 @@ func handleReobservationRequests(
 				continue
 			}
 
+			// A paused watcher does not read its channel, which would block the requests for all other chains.
+			if isPaused(r.chainId) {
+				logger.Info("skipping re-observation request for paused chain",
+					zap.Stringer("chain", r.chainId),
+					zap.String("tx_hash", r.txHash),
+				)
+				continue
+			}
+
 			cache[r] = clock.Now()
 
 			if channel, ok := chainObsvReqC[r.chainId]; ok {
@@ -20,6 +20,9 @@ type reobservationTestContext struct {
 	chainObsvReqC map[vaa.ChainID]chan *gossipv1.ObservationRequest
 }
 
+// The watcher for this chain is treated as paused.
+const pausedChainID = vaa.ChainID(2)
+
 func setUpReobservationTest() (reobservationTestContext, func()) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 
@@ -32,7 +35,7 @@ func setUpReobservationTest() (reobservationTestContext, func()) {
 		chainObsvReqC[vaa.ChainID(i)] = make(chan *gossipv1.ObservationRequest)
 	}
 
-	go handleReobservationRequests(ctx, clock, zap.NewNop(), obsvReqC, chainObsvReqC)
+	go handleReobservationRequests(ctx, clock, zap.NewNop(), obsvReqC, chainObsvReqC, func(chainID vaa.ChainID) bool { return chainID == pausedChainID })
 
 	tc := reobservationTestContext{
 		Context:       ctx,
@@ -142,6 +145,33 @@ func TestReobserveUnknownChainId(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestReobservePausedChain(t *testing.T) {
+	ctx, cancel := setUpReobservationTest()
+	defer cancel()
+
+	req := &gossipv1.ObservationRequest{
+		ChainId: uint32(pausedChainID),
+		TxHash:  []byte{0xe5, 0x9c, 0x1b, 0xe5, 0x0b, 0xe7, 0xe4, 0x7e},
+	}
+
+	ctx.obsvReqC <- req
+
+	_, ok := readFromChannel(ctx, ctx.chainObsvReqC[vaa.ChainID(req.ChainId)])
+	assert.False(t, ok)
+
+	// Requests for other chains should not be blocked by the paused chain.
+	req = &gossipv1.ObservationRequest{
+		ChainId: 1,
+		TxHash:  []byte{0xe5, 0x9c, 0x1b, 0xe5, 0x0b, 0xe7, 0xe4, 0x7e},
+	}
+
+	ctx.obsvReqC <- req
+
+	actual, ok := readFromChannel(ctx, ctx.chainObsvReqC[vaa.ChainID(req.ChainId)])
+	require.True(t, ok)
+	assert.Equal(t, req, actual)
+}
+
 func TestReobservationCacheEviction(t *testing.T) {
 	ctx, cancel := setUpReobservationTest()
 	defer cancel()
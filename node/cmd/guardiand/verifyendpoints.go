@@ -0,0 +1,570 @@
+package guardiand
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/algod"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethRpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// The verify-endpoints command checks the RPC endpoints configured for the node before it is started, using the same
+// flags, config file and environment variables as the node command. For each endpoint, it checks that the endpoint
+// serves the expected network, supports the methods used by the watcher of its chain and keeps enough history for
+// reobservation requests, and prints a compatibility report.
+
+var (
+	verifyArchiveDepth *uint64
+	verifyGetLogsRange *uint64
+	verifyTimeout      *time.Duration
+)
+
+func init() {
+	// The node flags are registered by node.go, whose init function runs first.
+	VerifyEndpointsCmd.Flags().AddFlagSet(NodeCmd.Flags())
+	verifyArchiveDepth = VerifyEndpointsCmd.Flags().Uint64("archiveDepth", 10000,
+		"Minimum history each endpoint must serve: blocks on EVM chains, Near and the Cosmos chains, slots on Solana and PythNet, and ledger versions on Aptos")
+	verifyGetLogsRange = VerifyEndpointsCmd.Flags().Uint64("getLogsRange", 100, "Number of blocks each EVM endpoint must serve in a single eth_getLogs request")
+	verifyTimeout = VerifyEndpointsCmd.Flags().Duration("verifyTimeout", 30*time.Second, "Timeout of the checks of each endpoint")
+}
+
+var VerifyEndpointsCmd = &cobra.Command{
+	Use:   "verify-endpoints",
+	Short: "Checks the compatibility of the configured chain RPC endpoints with the watchers, exits with status 1 if any check fails",
+	Run:   runVerifyEndpoints,
+	Args:  cobra.ExactArgs(0),
+}
+
+type endpointCheckStatus string
+
+const (
+	endpointCheckOK   endpointCheckStatus = "ok"
+	endpointCheckWarn endpointCheckStatus = "warn"
+	endpointCheckFail endpointCheckStatus = "FAIL"
+)
+
+type endpointCheck struct {
+	name   string
+	status endpointCheckStatus
+	detail string
+}
+
+// endpointReport holds the results of the checks of an endpoint.
+type endpointReport struct {
+	chain  vaa.ChainID
+	flag   string
+	url    string
+	checks []endpointCheck
+}
+
+func (r *endpointReport) add(status endpointCheckStatus, name string, format string, args ...interface{}) {
+	r.checks = append(r.checks, endpointCheck{name: name, status: status, detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *endpointReport) ok(name string, format string, args ...interface{}) {
+	r.add(endpointCheckOK, name, format, args...)
+}
+
+func (r *endpointReport) warn(name string, format string, args ...interface{}) {
+	r.add(endpointCheckWarn, name, format, args...)
+}
+
+func (r *endpointReport) fail(name string, format string, args ...interface{}) {
+	r.add(endpointCheckFail, name, format, args...)
+}
+
+func (r *endpointReport) failed() bool {
+	for _, c := range r.checks {
+		if c.status == endpointCheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDepth checks that the endpoint serves at least the required history.
+func (r *endpointReport) checkDepth(depth uint64, required uint64, unit string) {
+	if depth < required {
+		r.fail("archive depth", "%d %s available, %d required", depth, unit, required)
+	} else {
+		r.ok("archive depth", "%d %s available", depth, unit)
+	}
+}
+
+// endpointNetwork holds the network the endpoints are expected to serve, which is not checked in devnet mode.
+type endpointNetwork struct {
+	devnet  bool
+	testnet bool
+}
+
+// expected returns the expected value for the network, or "" if it is not checked.
+func (n endpointNetwork) expected(mainnet string, testnet string) string {
+	switch {
+	case n.devnet:
+		return ""
+	case n.testnet:
+		return testnet
+	default:
+		return mainnet
+	}
+}
+
+// checkNetwork compares the network served by the endpoint to the expected one.
+func (r *endpointReport) checkNetwork(name string, actual string, expected string) {
+	if expected == "" {
+		r.ok(name, "%s", actual)
+	} else if actual != expected {
+		r.fail(name, "%s, expected %s", actual, expected)
+	} else {
+		r.ok(name, "%s", actual)
+	}
+}
+
+// evmChainIDs are the EIP-155 chain IDs of the EVM chains on mainnet and testnet.
+var evmChainIDs = map[vaa.ChainID][2]string{
+	vaa.ChainIDEthereum:        {"1", "5"},
+	vaa.ChainIDBSC:             {"56", "97"},
+	vaa.ChainIDPolygon:         {"137", "80001"},
+	vaa.ChainIDAvalanche:       {"43114", "43113"},
+	vaa.ChainIDOasis:           {"42262", "42261"},
+	vaa.ChainIDAurora:          {"1313161554", "1313161555"},
+	vaa.ChainIDFantom:          {"250", "4002"},
+	vaa.ChainIDKarura:          {"686", "596"},
+	vaa.ChainIDAcala:           {"787", "597"},
+	vaa.ChainIDKlaytn:          {"8217", "1001"},
+	vaa.ChainIDCelo:            {"42220", "44787"},
+	vaa.ChainIDMoonbeam:        {"1284", "1287"},
+	vaa.ChainIDNeon:            {"245022934", "245022926"},
+	vaa.ChainIDEthereumRopsten: {"3", "3"},
+}
+
+// verifyEVMEndpoint checks an EVM endpoint, over HTTP or websocket.
+func verifyEVMEndpoint(ctx context.Context, r *endpointReport, network endpointNetwork, contract string) {
+	client, err := ethRpc.DialContext(ctx, r.url)
+	if err != nil {
+		r.fail("connect", "%v", err)
+		return
+	}
+	defer client.Close()
+
+	var clientVersion string
+	if err := client.CallContext(ctx, &clientVersion, "web3_clientVersion"); err != nil {
+		r.warn("client version", "web3_clientVersion failed: %v", err)
+	} else {
+		r.ok("client version", "%s", clientVersion)
+	}
+
+	var chainID hexutil.Big
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		r.fail("chain id", "eth_chainId failed: %v", err)
+	} else {
+		ids := evmChainIDs[r.chain]
+		r.checkNetwork("chain id", chainID.ToInt().String(), network.expected(ids[0], ids[1]))
+	}
+
+	var head hexutil.Uint64
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		r.fail("block number", "eth_blockNumber failed: %v", err)
+		return
+	}
+	r.ok("block number", "%d", uint64(head))
+
+	var finalized map[string]interface{}
+	if err := client.CallContext(ctx, &finalized, "eth_getBlockByNumber", "finalized", false); err != nil || finalized == nil {
+		r.warn("finalized tag", "the finalized block tag is not supported, the watcher polls for finality instead")
+	} else {
+		r.ok("finalized tag", "supported")
+	}
+
+	from := uint64(0)
+	if uint64(head) > *verifyGetLogsRange {
+		from = uint64(head) - *verifyGetLogsRange
+	}
+	address := ethCommon.HexToAddress(contract)
+	var logs []interface{}
+	if err := client.CallContext(ctx, &logs, "eth_getLogs", map[string]interface{}{
+		"fromBlock": hexutil.Uint64(from),
+		"toBlock":   head,
+		"address":   address,
+	}); err != nil {
+		r.fail("eth_getLogs", "%d blocks: %v", uint64(head)-from, err)
+	} else {
+		r.ok("eth_getLogs", "%d blocks, %d logs", uint64(head)-from, len(logs))
+	}
+
+	if uint64(head) > *verifyArchiveDepth {
+		var block map[string]interface{}
+		oldest := uint64(head) - *verifyArchiveDepth
+		if err := client.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.Uint64(oldest), false); err != nil || block == nil {
+			r.fail("archive depth", "block %d (%d blocks ago) is not available", oldest, *verifyArchiveDepth)
+		} else {
+			r.ok("archive depth", "block %d (%d blocks ago) is available", oldest, *verifyArchiveDepth)
+		}
+	}
+
+	if strings.HasPrefix(r.url, "ws") {
+		ch := make(chan map[string]interface{})
+		sub, err := client.EthSubscribe(ctx, ch, "newHeads")
+		if err != nil {
+			r.fail("subscriptions", "newHeads subscription failed: %v", err)
+		} else {
+			sub.Unsubscribe()
+			r.ok("subscriptions", "newHeads")
+		}
+	}
+}
+
+var solanaGenesisHashes = [2]string{"5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dxa5cG", "EtWTRABZaYq6iMfeYKouRu166VU2xqa1wcaWoxPkrZBG"}
+
+// verifySolanaEndpoint checks a Solana or PythNet endpoint.
+func verifySolanaEndpoint(ctx context.Context, c *http.Client, r *endpointReport, network endpointNetwork, contract string) {
+	version, err := jsonRPCResult(ctx, c, r.url, "getVersion", "[]")
+	if err != nil {
+		r.fail("version", "%v", err)
+		return
+	}
+	r.ok("version", "solana-core %s", version.Get("solana-core").String())
+
+	genesis, err := jsonRPCResult(ctx, c, r.url, "getGenesisHash", "[]")
+	if err != nil {
+		r.fail("genesis hash", "%v", err)
+	} else if r.chain == vaa.ChainIDSolana {
+		r.checkNetwork("genesis hash", genesis.String(), network.expected(solanaGenesisHashes[0], solanaGenesisHashes[1]))
+	} else {
+		r.ok("genesis hash", "%s", genesis.String())
+	}
+
+	slot, err := jsonRPCResult(ctx, c, r.url, "getSlot", `[{"commitment": "finalized"}]`)
+	if err != nil {
+		r.fail("slot", "%v", err)
+		return
+	}
+	r.ok("slot", "%d", slot.Uint())
+
+	if _, err := jsonRPCResult(ctx, c, r.url, "getBlock",
+		fmt.Sprintf(`[%d, {"commitment": "finalized", "maxSupportedTransactionVersion": 0, "transactionDetails": "signatures", "rewards": false}]`, slot.Uint())); err != nil {
+		r.fail("getBlock", "%v", err)
+	} else {
+		r.ok("getBlock", "supported")
+	}
+
+	if contract != "" {
+		account, err := jsonRPCResult(ctx, c, r.url, "getAccountInfo", fmt.Sprintf(`["%s", {"encoding": "base64"}]`, contract))
+		if err != nil {
+			r.fail("program", "getAccountInfo failed: %v", err)
+		} else if !account.Get("value.executable").Bool() {
+			r.fail("program", "%s is not an executable account", contract)
+		} else {
+			r.ok("program", "%s", contract)
+		}
+	}
+
+	first, err := jsonRPCResult(ctx, c, r.url, "getFirstAvailableBlock", "[]")
+	if err != nil {
+		r.fail("archive depth", "getFirstAvailableBlock failed: %v", err)
+	} else if first.Uint() > slot.Uint() {
+		r.checkDepth(0, *verifyArchiveDepth, "slots")
+	} else {
+		r.checkDepth(slot.Uint()-first.Uint(), *verifyArchiveDepth, "slots")
+	}
+}
+
+// verifyAptosEndpoint checks an Aptos endpoint and its events API.
+func verifyAptosEndpoint(ctx context.Context, c *http.Client, r *endpointReport, network endpointNetwork, account string, handle string) {
+	ledger, err := referenceRequest(ctx, c, http.MethodGet, strings.TrimSuffix(r.url, "/")+"/v1", "")
+	if err != nil {
+		r.fail("ledger info", "%v", err)
+		return
+	}
+	r.ok("ledger info", "node role %s", ledger.Get("node_role").String())
+	r.checkNetwork("chain id", ledger.Get("chain_id").String(), network.expected("1", "2"))
+
+	version, oldest := ledger.Get("ledger_version").Uint(), ledger.Get("oldest_ledger_version").Uint()
+	if oldest > version {
+		r.checkDepth(0, *verifyArchiveDepth, "versions")
+	} else {
+		r.checkDepth(version-oldest, *verifyArchiveDepth, "versions")
+	}
+
+	if account == "" || handle == "" {
+		return
+	}
+	events, err := referenceRequest(ctx, c, http.MethodGet,
+		fmt.Sprintf("%s/v1/accounts/%s/events/%s/event?limit=1", strings.TrimSuffix(r.url, "/"), account, handle), "")
+	if err != nil {
+		r.fail("events API", "%v", err)
+	} else if !events.IsArray() {
+		r.fail("events API", "unexpected response")
+	} else {
+		r.ok("events API", "supported")
+	}
+}
+
+// verifyNearEndpoint checks a Near endpoint.
+func verifyNearEndpoint(ctx context.Context, c *http.Client, r *endpointReport, network endpointNetwork) {
+	status, err := jsonRPCResult(ctx, c, r.url, "status", "[]")
+	if err != nil {
+		r.fail("status", "%v", err)
+		return
+	}
+	r.ok("version", "%s", status.Get("version.version").String())
+	r.checkNetwork("chain id", status.Get("chain_id").String(), network.expected("mainnet", "testnet"))
+
+	block, err := jsonRPCResult(ctx, c, r.url, "block", `{"finality": "final"}`)
+	if err != nil {
+		r.fail("block", "%v", err)
+		return
+	}
+	height := block.Get("header.height").Uint()
+	r.ok("block", "%d", height)
+
+	if hash := block.Get("chunks.0.chunk_hash").String(); hash != "" {
+		if _, err := jsonRPCResult(ctx, c, r.url, "chunk", fmt.Sprintf(`{"chunk_id": "%s"}`, hash)); err != nil {
+			r.fail("chunk", "%v", err)
+		} else {
+			r.ok("chunk", "supported")
+		}
+	}
+
+	if height > *verifyArchiveDepth {
+		oldest := height - *verifyArchiveDepth
+		if _, err := jsonRPCResult(ctx, c, r.url, "block", fmt.Sprintf(`{"block_id": %d}`, oldest)); err != nil {
+			r.fail("archive depth", "block %d (%d blocks ago) is not available: %v", oldest, *verifyArchiveDepth, err)
+		} else {
+			r.ok("archive depth", "block %d (%d blocks ago) is available", oldest, *verifyArchiveDepth)
+		}
+	}
+}
+
+// cosmosNetworks are the chain IDs of the Cosmos chains on mainnet and testnet.
+var cosmosNetworks = map[vaa.ChainID][2]string{
+	vaa.ChainIDTerra:     {"columbus-5", "bombay-12"},
+	vaa.ChainIDTerra2:    {"phoenix-1", "pisco-1"},
+	vaa.ChainIDInjective: {"injective-1", "injective-888"},
+	vaa.ChainIDWormchain: {"wormchain", "wormchain-testnet-0"},
+}
+
+// verifyCosmosEndpoint checks the LCD endpoint of a Cosmos chain.
+func verifyCosmosEndpoint(ctx context.Context, c *http.Client, r *endpointReport, network endpointNetwork) {
+	lcd := strings.TrimSuffix(r.url, "/")
+	info, err := referenceRequest(ctx, c, http.MethodGet, lcd+"/cosmos/base/tendermint/v1beta1/node_info", "")
+	if err != nil {
+		r.fail("node info", "%v", err)
+		return
+	}
+	r.ok("version", "%s %s", info.Get("application_version.app_name").String(), info.Get("application_version.version").String())
+	networks := cosmosNetworks[r.chain]
+	r.checkNetwork("chain id", info.Get("default_node_info.network").String(), network.expected(networks[0], networks[1]))
+
+	latest, err := referenceRequest(ctx, c, http.MethodGet, lcd+"/cosmos/base/tendermint/v1beta1/blocks/latest", "")
+	if err != nil {
+		r.fail("block", "%v", err)
+		return
+	}
+	height := latest.Get("block.header.height").Uint()
+	r.ok("block", "%d", height)
+
+	if height > *verifyArchiveDepth {
+		oldest := height - *verifyArchiveDepth
+		if _, err := referenceRequest(ctx, c, http.MethodGet, fmt.Sprintf("%s/cosmos/base/tendermint/v1beta1/blocks/%d", lcd, oldest), ""); err != nil {
+			r.fail("archive depth", "block %d (%d blocks ago) is not available: %v", oldest, *verifyArchiveDepth, err)
+		} else {
+			r.ok("archive depth", "block %d (%d blocks ago) is available", oldest, *verifyArchiveDepth)
+		}
+	}
+}
+
+// verifyAlgodEndpoint checks an Algorand algod endpoint and the application of the Wormhole contract.
+func verifyAlgodEndpoint(ctx context.Context, r *endpointReport, network endpointNetwork, token string, appID uint64) {
+	client, err := algod.MakeClient(r.url, token)
+	if err != nil {
+		r.fail("connect", "%v", err)
+		return
+	}
+
+	versions, err := client.Versions().Do(ctx)
+	if err != nil {
+		r.fail("version", "%v", err)
+		return
+	}
+	r.ok("version", "%d.%d.%d", versions.Build.Major, versions.Build.Minor, versions.Build.BuildNumber)
+	r.checkNetwork("genesis id", versions.GenesisID, network.expected("mainnet-v1.0", "testnet-v1.0"))
+
+	status, err := client.Status().Do(ctx)
+	if err != nil {
+		r.fail("status", "%v", err)
+		return
+	}
+	r.ok("status", "round %d", status.LastRound)
+
+	if appID != 0 {
+		if _, err := client.GetApplicationByID(appID).Do(ctx); err != nil {
+			r.fail("application", "application %d: %v", appID, err)
+		} else {
+			r.ok("application", "%d", appID)
+		}
+	}
+}
+
+// verifyAlgorandIndexerEndpoint checks an Algorand indexer endpoint, which serves the whole history.
+func verifyAlgorandIndexerEndpoint(ctx context.Context, r *endpointReport, token string) {
+	client, err := indexer.MakeClient(r.url, token)
+	if err != nil {
+		r.fail("connect", "%v", err)
+		return
+	}
+
+	health, err := client.HealthCheck().Do(ctx)
+	if err != nil {
+		r.fail("health", "%v", err)
+		return
+	}
+	r.ok("version", "%s", health.Version)
+	if !health.DbAvailable || len(health.Errors) != 0 {
+		r.fail("health", "database available: %v, errors: %s", health.DbAvailable, strings.Join(health.Errors, "; "))
+	} else {
+		r.ok("health", "round %d", health.Round)
+	}
+}
+
+// endpointVerifier checks an endpoint and records the results in its report.
+type endpointVerifier func(ctx context.Context, c *http.Client, r *endpointReport)
+
+type configuredEndpoint struct {
+	report *endpointReport
+	verify endpointVerifier
+}
+
+// configuredEndpoints returns the endpoints configured by the node flags, along with their checks.
+func configuredEndpoints(network endpointNetwork) []configuredEndpoint {
+	var endpoints []configuredEndpoint
+	add := func(chainID vaa.ChainID, flag string, u string, verify endpointVerifier) {
+		if u == "" {
+			return
+		}
+		endpoints = append(endpoints, configuredEndpoint{report: &endpointReport{chain: chainID, flag: flag, url: u}, verify: verify})
+	}
+
+	evm := func(contract *string) endpointVerifier {
+		return func(ctx context.Context, _ *http.Client, r *endpointReport) {
+			verifyEVMEndpoint(ctx, r, network, *contract)
+		}
+	}
+	add(vaa.ChainIDEthereum, "ethRPC", *ethRPC, evm(ethContract))
+	add(vaa.ChainIDBSC, "bscRPC", *bscRPC, evm(bscContract))
+	add(vaa.ChainIDPolygon, "polygonRPC", *polygonRPC, evm(polygonContract))
+	add(vaa.ChainIDEthereumRopsten, "ethRopstenRPC", *ethRopstenRPC, evm(ethRopstenContract))
+	add(vaa.ChainIDAvalanche, "avalancheRPC", *avalancheRPC, evm(avalancheContract))
+	add(vaa.ChainIDOasis, "oasisRPC", *oasisRPC, evm(oasisContract))
+	add(vaa.ChainIDAurora, "auroraRPC", *auroraRPC, evm(auroraContract))
+	add(vaa.ChainIDFantom, "fantomRPC", *fantomRPC, evm(fantomContract))
+	add(vaa.ChainIDKarura, "karuraRPC", *karuraRPC, evm(karuraContract))
+	add(vaa.ChainIDAcala, "acalaRPC", *acalaRPC, evm(acalaContract))
+	add(vaa.ChainIDKlaytn, "klaytnRPC", *klaytnRPC, evm(klaytnContract))
+	add(vaa.ChainIDCelo, "celoRPC", *celoRPC, evm(celoContract))
+	add(vaa.ChainIDMoonbeam, "moonbeamRPC", *moonbeamRPC, evm(moonbeamContract))
+	add(vaa.ChainIDNeon, "neonRPC", *neonRPC, evm(neonContract))
+
+	solana := func(contract *string) endpointVerifier {
+		return func(ctx context.Context, c *http.Client, r *endpointReport) {
+			verifySolanaEndpoint(ctx, c, r, network, *contract)
+		}
+	}
+	add(vaa.ChainIDSolana, "solanaRPC", *solanaRPC, solana(solanaContract))
+	add(vaa.ChainIDSolana, "solanaSecondaryRPC", *solanaSecondaryRPC, solana(solanaContract))
+	add(vaa.ChainIDPythNet, "pythnetRPC", *pythnetRPC, solana(pythnetContract))
+	add(vaa.ChainIDPythNet, "pythnetSecondaryRPC", *pythnetSecondaryRPC, solana(pythnetContract))
+
+	cosmos := func(ctx context.Context, c *http.Client, r *endpointReport) {
+		verifyCosmosEndpoint(ctx, c, r, network)
+	}
+	add(vaa.ChainIDTerra, "terraLCD", *terraLCD, cosmos)
+	add(vaa.ChainIDTerra2, "terra2LCD", *terra2LCD, cosmos)
+	add(vaa.ChainIDInjective, "injectiveLCD", *injectiveLCD, cosmos)
+	add(vaa.ChainIDWormchain, "wormchainLCD", *wormchainLCD, cosmos)
+
+	add(vaa.ChainIDNear, "nearRPC", *nearRPC, func(ctx context.Context, c *http.Client, r *endpointReport) {
+		verifyNearEndpoint(ctx, c, r, network)
+	})
+	add(vaa.ChainIDAptos, "aptosRPC", *aptosRPC, func(ctx context.Context, c *http.Client, r *endpointReport) {
+		verifyAptosEndpoint(ctx, c, r, network, *aptosAccount, *aptosHandle)
+	})
+	add(vaa.ChainIDAlgorand, "algorandAlgodRPC", *algorandAlgodRPC, func(ctx context.Context, _ *http.Client, r *endpointReport) {
+		verifyAlgodEndpoint(ctx, r, network, *algorandAlgodToken, *algorandAppID)
+	})
+	add(vaa.ChainIDAlgorand, "algorandIndexerRPC", *algorandIndexerRPC, func(ctx context.Context, _ *http.Client, r *endpointReport) {
+		verifyAlgorandIndexerEndpoint(ctx, r, *algorandIndexerToken)
+	})
+
+	return endpoints
+}
+
+// verifyEndpoints runs the checks of all endpoints concurrently, each with its own timeout.
+func verifyEndpoints(endpoints []configuredEndpoint, timeout time.Duration) {
+	c := &http.Client{}
+	var wg sync.WaitGroup
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func(e configuredEndpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			e.verify(ctx, c, e.report)
+		}(e)
+	}
+	wg.Wait()
+}
+
+func printEndpointReports(reports []*endpointReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tFLAG\tENDPOINT\tCHECK\tSTATUS\tDETAIL")
+	for _, r := range reports {
+		for _, c := range r.checks {
+			fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\t%s\n", r.chain, r.flag, redactEndpoint(r.url), c.name, c.status, c.detail)
+		}
+	}
+	w.Flush()
+}
+
+func runVerifyEndpoints(cmd *cobra.Command, args []string) {
+	if err := applyConfig(viper.GetViper(), cmd.Flags()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	endpoints := configuredEndpoints(endpointNetwork{devnet: *unsafeDevMode, testnet: *testnetMode})
+	if len(endpoints) == 0 {
+		fmt.Println("no chain endpoints are configured")
+		os.Exit(1)
+	}
+
+	verifyEndpoints(endpoints, *verifyTimeout)
+
+	var reports []*endpointReport
+	failed := 0
+	for _, e := range endpoints {
+		reports = append(reports, e.report)
+		if e.report.failed() {
+			failed++
+		}
+	}
+	printEndpointReports(reports)
+
+	if failed != 0 {
+		fmt.Printf("\n%d of %d endpoints failed the checks\n", failed, len(endpoints))
+		os.Exit(1)
+	}
+	fmt.Printf("\nall %d endpoints passed the checks\n", len(endpoints))
+}
@@ -0,0 +1,128 @@
+package guardiand
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonRPCServer serves the given results by method. Other methods fail.
+func jsonRPCServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var r struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&r))
+		w.Header().Set("Content-Type", "application/json")
+		if result, ok := results[r.Method]; ok {
+			fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": %s, "result": %s}`, r.ID, result)
+		} else {
+			fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": %s, "error": {"code": -32601, "message": "method not found"}}`, r.ID)
+		}
+	}))
+}
+
+// checkStatuses returns the status of each check of a report by name.
+func checkStatuses(r *endpointReport) map[string]endpointCheckStatus {
+	statuses := make(map[string]endpointCheckStatus)
+	for _, c := range r.checks {
+		statuses[c.name] = c.status
+	}
+	return statuses
+}
+
+func TestVerifyEVMEndpoint(t *testing.T) {
+	server := jsonRPCServer(t, map[string]string{
+		"web3_clientVersion":   `"Geth/v1.10.26"`,
+		"eth_chainId":          `"0x38"`,
+		"eth_blockNumber":      `"0x100000"`,
+		"eth_getLogs":          `[]`,
+		"eth_getBlockByNumber": `{"number": "0x1"}`,
+	})
+	defer server.Close()
+
+	r := &endpointReport{chain: vaa.ChainIDBSC, url: server.URL}
+	verifyEVMEndpoint(context.Background(), r, endpointNetwork{}, "0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B")
+	assert.False(t, r.failed())
+	assert.Equal(t, map[string]endpointCheckStatus{
+		"client version": endpointCheckOK,
+		"chain id":       endpointCheckOK,
+		"block number":   endpointCheckOK,
+		"finalized tag":  endpointCheckOK,
+		"eth_getLogs":    endpointCheckOK,
+		"archive depth":  endpointCheckOK,
+	}, checkStatuses(r))
+
+	// A BSC endpoint is not a testnet endpoint.
+	r = &endpointReport{chain: vaa.ChainIDBSC, url: server.URL}
+	verifyEVMEndpoint(context.Background(), r, endpointNetwork{testnet: true}, "")
+	assert.Equal(t, endpointCheckFail, checkStatuses(r)["chain id"])
+
+	// The chain ID is not checked in devnet mode, and eth_getLogs is required.
+	server = jsonRPCServer(t, map[string]string{
+		"eth_chainId":     `"0x539"`,
+		"eth_blockNumber": `"0x10"`,
+	})
+	defer server.Close()
+	r = &endpointReport{chain: vaa.ChainIDEthereum, url: server.URL}
+	verifyEVMEndpoint(context.Background(), r, endpointNetwork{devnet: true}, "")
+	assert.Equal(t, map[string]endpointCheckStatus{
+		"client version": endpointCheckWarn,
+		"chain id":       endpointCheckOK,
+		"block number":   endpointCheckOK,
+		"finalized tag":  endpointCheckWarn,
+		"eth_getLogs":    endpointCheckFail,
+	}, checkStatuses(r))
+}
+
+func TestVerifySolanaEndpoint(t *testing.T) {
+	server := jsonRPCServer(t, map[string]string{
+		"getVersion":             `{"solana-core": "1.14.16"}`,
+		"getGenesisHash":         `"5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dxa5cG"`,
+		"getSlot":                `200000`,
+		"getBlock":               `{"blockhash": "x"}`,
+		"getAccountInfo":         `{"value": {"executable": true}}`,
+		"getFirstAvailableBlock": `195000`,
+	})
+	defer server.Close()
+
+	r := &endpointReport{chain: vaa.ChainIDSolana, url: server.URL}
+	verifySolanaEndpoint(context.Background(), &http.Client{}, r, endpointNetwork{}, "worm2ZoG2kUd4vFXhvjh93UUH596ayRfgQ2MgjNMTth")
+	statuses := checkStatuses(r)
+	assert.Equal(t, endpointCheckOK, statuses["genesis hash"])
+	assert.Equal(t, endpointCheckOK, statuses["program"])
+	// 5000 slots of history, less than the default of 10000.
+	assert.Equal(t, endpointCheckFail, statuses["archive depth"])
+}
+
+func TestVerifyAptosEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1":
+			fmt.Fprint(w, `{"chain_id": 1, "ledger_version": "50000", "oldest_ledger_version": "0", "node_role": "full_node"}`)
+		case "/v1/accounts/0x1/events/0x1::state::WormholeMessageHandle/event":
+			fmt.Fprint(w, `[]`)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	r := &endpointReport{chain: vaa.ChainIDAptos, url: server.URL}
+	verifyAptosEndpoint(context.Background(), &http.Client{}, r, endpointNetwork{}, "0x1", "0x1::state::WormholeMessageHandle")
+	assert.False(t, r.failed())
+	assert.Equal(t, endpointCheckOK, checkStatuses(r)["events API"])
+
+	// The events API is required.
+	r = &endpointReport{chain: vaa.ChainIDAptos, url: server.URL}
+	verifyAptosEndpoint(context.Background(), &http.Client{}, r, endpointNetwork{}, "0x2", "0x1::state::WormholeMessageHandle")
+	assert.Equal(t, endpointCheckFail, checkStatuses(r)["events API"])
+}
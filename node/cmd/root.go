@@ -45,12 +45,15 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.guardiand.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file in YAML or TOML format (default is $HOME/.guardiand.yaml)")
 	rootCmd.AddCommand(guardiand.NodeCmd)
+	rootCmd.AddCommand(guardiand.MultiNodeCmd)
 	rootCmd.AddCommand(spy.SpyCmd)
 	rootCmd.AddCommand(guardiand.KeygenCmd)
 	rootCmd.AddCommand(guardiand.AdminCmd)
 	rootCmd.AddCommand(guardiand.TemplateCmd)
+	rootCmd.AddCommand(guardiand.ConfigCmd)
+	rootCmd.AddCommand(guardiand.VerifyEndpointsCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(debug.DebugCmd)
 }
@@ -73,10 +76,14 @@ func initConfig() {
 		viper.SetConfigName(".guardiand.yaml")
 	}
 
+	viper.SetEnvPrefix(guardiand.ConfigEnvPrefix)
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in. A config file specified on the command line must exist and be valid.
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	} else if cfgFile != "" {
+		fmt.Println("Failed to read config file:", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,322 @@
+package spy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// In archive mode, the spy is a VAA mirror: it verifies that the VAAs it receives reached quorum in a known guardian
+// set before storing and publishing them, and serves its store over paginated listings. The spy starts from a guardian
+// set given on the command line, and follows the guardian set upgrades signed by the latest guardian set it knows.
+// Previous guardian sets are kept, so that the VAAs they signed can still be archived.
+
+var (
+	archiveVAAs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_archive_vaas_total",
+			Help: "Total number of VAAs received in archive mode, by result (verified or rejected)",
+		}, []string{"result"})
+	archiveLatestGuardianSet = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_spy_archive_guardian_set_index",
+			Help: "Index of the latest guardian set known to the spy in archive mode",
+		})
+)
+
+const (
+	defaultArchivePageSize = 100
+	maxArchivePageSize     = 1000
+)
+
+// archive holds the guardian sets known to a spy running in archive mode. It is not safe for concurrent use, VAAs are
+// verified by Publish only.
+type archive struct {
+	logger *zap.Logger
+	// Keys of the known guardian sets by index.
+	guardianSets map[uint32][]eth_common.Address
+	// Index of the latest known guardian set, the only one whose upgrades are followed.
+	latest uint32
+}
+
+func newArchive(logger *zap.Logger, index uint32, keys []eth_common.Address) *archive {
+	archiveLatestGuardianSet.Set(float64(index))
+	return &archive{
+		logger:       logger.Named("archive"),
+		guardianSets: map[uint32][]eth_common.Address{index: keys},
+		latest:       index,
+	}
+}
+
+// parseGuardianSetKeys parses a comma-separated list of hex-encoded guardian addresses.
+func parseGuardianSetKeys(s string) ([]eth_common.Address, error) {
+	var keys []eth_common.Address
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if !eth_common.IsHexAddress(k) {
+			return nil, fmt.Errorf("invalid guardian address %q", k)
+		}
+		keys = append(keys, eth_common.HexToAddress(k))
+	}
+	return keys, nil
+}
+
+// load follows the guardian set upgrades stored in d, which the spy learned before restarting.
+func (a *archive) load(d *db.Database) error {
+	var sequences []uint64
+	emitter := vaa.GovernanceEmitter
+	if err := d.IterateSignedVAAs(db.VAAFilter{EmitterChain: vaa.GovernanceChain, EmitterAddress: &emitter, LastSequence: math.MaxUint64}, func(id *db.VAAID, b []byte) error {
+		sequences = append(sequences, id.Sequence)
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	for _, seq := range sequences {
+		b, err := d.GetSignedVAABytes(db.VAAID{EmitterChain: vaa.GovernanceChain, EmitterAddress: emitter, Sequence: seq})
+		if err == db.ErrVAANotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		v, err := vaa.Unmarshal(b)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal stored governance VAA %d: %w", seq, err)
+		}
+		// Governance VAAs signed by unknown guardian sets were not stored by the archive.
+		_ = a.verify(v)
+	}
+	return nil
+}
+
+// verify returns an error unless v reached quorum in a known guardian set. A verified upgrade of the latest guardian
+// set adds the new guardian set.
+func (a *archive) verify(v *vaa.VAA) error {
+	keys, ok := a.guardianSets[v.GuardianSetIndex]
+	if !ok {
+		return fmt.Errorf("unknown guardian set %d", v.GuardianSetIndex)
+	}
+	if !vaa.HasQuorum(len(v.Signatures), len(keys)) {
+		return fmt.Errorf("%d signatures, less than the quorum of %d in guardian set %d", len(v.Signatures), vaa.CalculateQuorum(len(keys)), v.GuardianSetIndex)
+	}
+	if !v.VerifySignatures(keys) {
+		return fmt.Errorf("invalid signatures for guardian set %d", v.GuardianSetIndex)
+	}
+
+	if v.GuardianSetIndex == a.latest && v.EmitterChain == vaa.GovernanceChain && v.EmitterAddress == vaa.GovernanceEmitter {
+		if u, err := vaa.DeserializeGuardianSetUpdate(v.Payload); err == nil && u.NewIndex == a.latest+1 && len(u.Keys) != 0 {
+			a.guardianSets[u.NewIndex] = u.Keys
+			a.latest = u.NewIndex
+			archiveLatestGuardianSet.Set(float64(u.NewIndex))
+			a.logger.Info("following guardian set upgrade", zap.Uint32("index", u.NewIndex), zap.Int("guardians", len(u.Keys)))
+		}
+	}
+	return nil
+}
+
+func archivePageSize(n uint32) (int, error) {
+	if n == 0 {
+		return defaultArchivePageSize, nil
+	}
+	if n > maxArchivePageSize {
+		return 0, status.Error(codes.InvalidArgument, fmt.Sprintf("page size %d is too large, at most %d are allowed", n, maxArchivePageSize))
+	}
+	return int(n), nil
+}
+
+func (s *spyServer) checkArchive() error {
+	if s.archive == nil {
+		return status.Error(codes.FailedPrecondition, "listing requires the spy to run in archive mode (--archiveGuardianSet)")
+	}
+	return nil
+}
+
+// ListArchivedVAAsByEmitter lists the VAAs of an emitter in sequence order. The page token is the sequence of the first
+// VAA of the next page.
+func (s *spyServer) ListArchivedVAAsByEmitter(ctx context.Context, req *spyv1.ListArchivedVAAsByEmitterRequest) (*spyv1.ListArchivedVAAsResponse, error) {
+	if err := s.checkArchive(); err != nil {
+		return nil, err
+	}
+	pageSize, err := archivePageSize(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeEmitterFilter(&spyv1.EmitterFilter{ChainId: req.ChainId, EmitterAddress: req.EmitterAddress})
+	if err != nil {
+		return nil, err
+	}
+	from := req.FromSequence
+	if req.PageToken != "" {
+		if from, err = strconv.ParseUint(req.PageToken, 10, 64); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	// Keys are not ordered numerically, so keep the smallest sequences, and one more to know whether there is a next page.
+	var sequences []uint64
+	filter := db.VAAFilter{EmitterChain: e.chainId, EmitterAddress: &e.emitterAddr, FirstSequence: from, LastSequence: math.MaxUint64}
+	if err := s.db.IterateSignedVAAs(filter, func(id *db.VAAID, b []byte) error {
+		i := sort.Search(len(sequences), func(i int) bool { return sequences[i] > id.Sequence })
+		if i > pageSize {
+			return nil
+		}
+		sequences = append(sequences, 0)
+		copy(sequences[i+1:], sequences[i:])
+		sequences[i] = id.Sequence
+		if len(sequences) > pageSize+1 {
+			sequences = sequences[:pageSize+1]
+		}
+		return nil
+	}); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to read stored VAAs: %v", err))
+	}
+
+	resp := &spyv1.ListArchivedVAAsResponse{}
+	if len(sequences) > pageSize {
+		resp.NextPageToken = strconv.FormatUint(sequences[pageSize], 10)
+		sequences = sequences[:pageSize]
+	}
+	for _, seq := range sequences {
+		b, err := s.db.GetSignedVAABytes(db.VAAID{EmitterChain: e.chainId, EmitterAddress: e.emitterAddr, Sequence: seq})
+		if err == db.ErrVAANotFound {
+			// Pruned in the meantime.
+			continue
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to read stored VAA: %v", err))
+		}
+		resp.VaaBytes = append(resp.VaaBytes, b)
+	}
+	return resp, nil
+}
+
+// The page token of a listing by time is the timestamp and the ID of the first VAA of the next page, such as
+// 1660000000/2/0000...abcd/42.
+type timePageToken struct {
+	timestamp int64
+	id        *db.VAAID
+}
+
+func parseTimePageToken(s string) (*timePageToken, error) {
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid page token")
+	}
+	timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token timestamp: %w", err)
+	}
+	id, err := db.VaaIDFromString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token ID: %w", err)
+	}
+	return &timePageToken{timestamp: timestamp, id: id}, nil
+}
+
+func (t *timePageToken) String() string {
+	return fmt.Sprintf("%d/%d/%s/%d", t.timestamp, t.id.EmitterChain, t.id.EmitterAddress, t.id.Sequence)
+}
+
+// ListArchivedVAAsByTime lists the VAAs in a time range in timestamp order. VAAs of the same timestamp are ordered as
+// in the store.
+func (s *spyServer) ListArchivedVAAsByTime(ctx context.Context, req *spyv1.ListArchivedVAAsByTimeRequest) (*spyv1.ListArchivedVAAsResponse, error) {
+	if err := s.checkArchive(); err != nil {
+		return nil, err
+	}
+	pageSize, err := archivePageSize(req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if req.Since < 0 || req.Until < 0 {
+		return nil, status.Error(codes.InvalidArgument, "the time range must not be negative")
+	}
+	filter := db.VAAFilter{EmitterChain: vaa.ChainID(req.ChainId), LastSequence: math.MaxUint64}
+	if req.EmitterAddress != "" {
+		if req.ChainId == 0 {
+			return nil, status.Error(codes.InvalidArgument, "the chain must be specified when the emitter address is")
+		}
+		addr, err := decodeEmitterAddr(req.EmitterAddress)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode emitter address: %v", err))
+		}
+		filter.EmitterAddress = &addr
+	}
+	var r db.TimeRange
+	if req.Since != 0 {
+		r.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until != 0 {
+		r.Until = time.Unix(req.Until, 0)
+	}
+
+	var token *timePageToken
+	if req.PageToken != "" {
+		if token, err = parseTimePageToken(req.PageToken); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if token.timestamp < req.Since {
+			return nil, status.Error(codes.InvalidArgument, "the page token is outside of the time range")
+		}
+		r.Since = time.Unix(token.timestamp, 0)
+	}
+
+	resp, found, err := s.listByTime(filter, r, token, pageSize)
+	if err == nil && token != nil && !found {
+		// The first VAA of the page was pruned in the meantime, so list its second again. Clients deduplicate anyway,
+		// since the same VAA is usually received from several guardians.
+		resp, _, err = s.listByTime(filter, r, nil, pageSize)
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to read stored VAAs: %v", err))
+	}
+	return resp, nil
+}
+
+// listByTime lists a page from the start of r, skipping the VAAs before the one of the token if any, and returns
+// whether it was found.
+func (s *spyServer) listByTime(filter db.VAAFilter, r db.TimeRange, token *timePageToken, pageSize int) (*spyv1.ListArchivedVAAsResponse, bool, error) {
+	resp := &spyv1.ListArchivedVAAsResponse{}
+	found := token == nil
+	err := s.db.IterateSignedVAAsByTime(filter, r, func(id *db.VAAID, b []byte) error {
+		if !found {
+			if *id != *token.id {
+				v, err := vaa.Unmarshal(b)
+				if err != nil {
+					return err
+				}
+				if v.Timestamp.Unix() > token.timestamp {
+					return db.ErrStopIteration
+				}
+				return nil
+			}
+			found = true
+		}
+
+		if len(resp.VaaBytes) == pageSize {
+			v, err := vaa.Unmarshal(b)
+			if err != nil {
+				return err
+			}
+			next := *id
+			resp.NextPageToken = (&timePageToken{timestamp: v.Timestamp.Unix(), id: &next}).String()
+			return db.ErrStopIteration
+		}
+		resp.VaaBytes = append(resp.VaaBytes, append([]byte(nil), b...))
+		return nil
+	})
+	return resp, found, err
+}
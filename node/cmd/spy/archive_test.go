@@ -0,0 +1,169 @@
+package spy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testGuardianSet(t *testing.T, n int) ([]*ecdsa.PrivateKey, []eth_common.Address) {
+	var keys []*ecdsa.PrivateKey
+	var addrs []eth_common.Address
+	for i := 0; i < n; i++ {
+		k, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, k)
+		addrs = append(addrs, crypto.PubkeyToAddress(k.PublicKey))
+	}
+	return keys, addrs
+}
+
+func signArchiveVAA(v *vaa.VAA, index uint32, keys []*ecdsa.PrivateKey, signers int) *vaa.VAA {
+	v.GuardianSetIndex = index
+	for i := 0; i < signers; i++ {
+		v.AddSignature(keys[i], uint8(i))
+	}
+	return v
+}
+
+func TestArchiveVerify(t *testing.T) {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer d.Close()
+
+	keys0, addrs0 := testGuardianSet(t, 4)
+	keys1, addrs1 := testGuardianSet(t, 4)
+
+	s := newSpyServer(zap.NewNop())
+	s.db = d
+	s.archive = newArchive(zap.NewNop(), 0, addrs0)
+
+	stored := func(seq uint64) bool {
+		_, err := d.GetSignedVAABytes(db.VAAID{EmitterChain: vaa.ChainIDSolana, EmitterAddress: testEmitterA, Sequence: seq})
+		return err == nil
+	}
+
+	// The quorum of four guardians is three.
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 1, []byte{1}), 0, keys0, 3))))
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 2, []byte{1}), 0, keys0, 2))))
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 3, []byte{1}), 0, keys1, 3))))
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 4, []byte{1}), 1, keys1, 3))))
+	assert.True(t, stored(1))
+	assert.False(t, stored(2))
+	assert.False(t, stored(3))
+	assert.False(t, stored(4))
+
+	// Upgrade to the second guardian set.
+	upgrade := vaa.CreateGovernanceVAA(time.Unix(1660000000, 0), 1, 1, 0, vaa.BodyGuardianSetUpdate{Keys: addrs1, NewIndex: 1}.Serialize())
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(upgrade, 0, keys0, 3))))
+	assert.Equal(t, uint32(1), s.archive.latest)
+
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 4, []byte{1}), 1, keys1, 3))))
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterA, 5, []byte{1}), 0, keys0, 3))))
+	assert.True(t, stored(4))
+	assert.True(t, stored(5))
+
+	// The upgrade is followed again after a restart.
+	a := newArchive(zap.NewNop(), 0, addrs0)
+	require.NoError(t, a.load(d))
+	assert.Equal(t, uint32(1), a.latest)
+	assert.Equal(t, addrs1, a.guardianSets[1])
+}
+
+func TestListArchivedVAAs(t *testing.T) {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer d.Close()
+
+	keys, addrs := testGuardianSet(t, 1)
+	s := newSpyServer(zap.NewNop())
+	s.db = d
+
+	_, err = s.ListArchivedVAAsByEmitter(context.Background(), &spyv1.ListArchivedVAAsByEmitterRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	s.archive = newArchive(zap.NewNop(), 0, addrs)
+	for _, seq := range []uint64{1, 2, 10, 3, 20} {
+		v := testVAA(vaa.ChainIDSolana, testEmitterA, seq, []byte{1})
+		// Two VAAs per second, in the reverse order of their sequences.
+		v.Timestamp = time.Unix(1660000000-int64(seq/2), 0)
+		require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(v, 0, keys, 1))))
+	}
+	require.NoError(t, s.Publish(marshalTestVAA(t, signArchiveVAA(testVAA(vaa.ChainIDSolana, testEmitterB, 5, []byte{1}), 0, keys, 1))))
+
+	sequences := func(resp *spyv1.ListArchivedVAAsResponse) []uint64 {
+		var res []uint64
+		for _, b := range resp.VaaBytes {
+			v, err := vaa.Unmarshal(b)
+			require.NoError(t, err)
+			res = append(res, v.Sequence)
+		}
+		return res
+	}
+
+	// By emitter, in sequence order.
+	var listed []uint64
+	req := &spyv1.ListArchivedVAAsByEmitterRequest{
+		ChainId:        publicrpcv1.ChainID_CHAIN_ID_SOLANA,
+		EmitterAddress: hex.EncodeToString(testEmitterA[:]),
+		FromSequence:   2,
+		PageSize:       2,
+	}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		resp, err := s.ListArchivedVAAsByEmitter(context.Background(), req)
+		require.NoError(t, err)
+		listed = append(listed, sequences(resp)...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	assert.Equal(t, []uint64{2, 3, 10, 20}, listed)
+
+	// By time, in timestamp order.
+	listed = nil
+	timeReq := &spyv1.ListArchivedVAAsByTimeRequest{
+		Since:          1660000000 - 10,
+		ChainId:        publicrpcv1.ChainID_CHAIN_ID_SOLANA,
+		EmitterAddress: hex.EncodeToString(testEmitterA[:]),
+		PageSize:       1,
+	}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		resp, err := s.ListArchivedVAAsByTime(context.Background(), timeReq)
+		require.NoError(t, err)
+		listed = append(listed, sequences(resp)...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		timeReq.PageToken = resp.NextPageToken
+	}
+	assert.ElementsMatch(t, []uint64{20, 10, 2, 3, 1}, listed)
+	assert.Equal(t, uint64(20), listed[0])
+	assert.Equal(t, uint64(1), listed[4])
+
+	// The token of a pruned VAA lists again from the start of its second.
+	resp, err := s.ListArchivedVAAsByTime(context.Background(), &spyv1.ListArchivedVAAsByTimeRequest{PageToken: "1659999999/1/" + hex.EncodeToString(testEmitterA[:]) + "/99"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{2, 3, 1, 5}, sequences(resp))
+
+	_, err = s.ListArchivedVAAsByTime(context.Background(), &spyv1.ListArchivedVAAsByTimeRequest{PageSize: maxArchivePageSize + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.ListArchivedVAAsByTime(context.Background(), &spyv1.ListArchivedVAAsByTimeRequest{EmitterAddress: hex.EncodeToString(testEmitterA[:])})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
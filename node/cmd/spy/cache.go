@@ -0,0 +1,49 @@
+package spy
+
+import (
+	"sync"
+)
+
+// vaaCache keeps the most recently received signed VAAs by message ID, evicting the oldest once full.
+type vaaCache struct {
+	mu    sync.Mutex
+	size  int
+	vaas  map[string][]byte
+	order []string // ring buffer of message IDs in insertion order
+	next  int
+}
+
+func newVAACache(size int) *vaaCache {
+	return &vaaCache{
+		size:  size,
+		vaas:  make(map[string][]byte, size),
+		order: make([]string, 0, size),
+	}
+}
+
+func (c *vaaCache) add(messageID string, vaaBytes []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.vaas[messageID]; ok {
+		// Keep the first VAA received, like the guardians do.
+		return
+	}
+
+	if len(c.order) < c.size {
+		c.order = append(c.order, messageID)
+	} else {
+		delete(c.vaas, c.order[c.next])
+		c.order[c.next] = messageID
+		c.next = (c.next + 1) % c.size
+	}
+	c.vaas[messageID] = vaaBytes
+}
+
+func (c *vaaCache) get(messageID string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.vaas[messageID]
+	return b, ok
+}
@@ -0,0 +1,175 @@
+package spy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
+
+// Subscriptions can request the payloads of the token bridge and NFT bridge VAAs to be decoded. The spy does not know
+// which network it is connected to, so the well-known emitters of all networks are recognized. The payloads of other
+// emitters are decoded by the payload registry configured with --payloadRegistry, if any.
+
+var (
+	tokenBridgeEmitters = []map[vaa.ChainID][]byte{common.KnownTokenbridgeEmitters, common.KnownTestnetTokenbridgeEmitters, common.KnownDevnetTokenbridgeEmitters}
+	nftBridgeEmitters   = []map[vaa.ChainID][]byte{common.KnownNFTBridgeEmitters, common.KnownTestnetNFTBridgeEmitters, common.KnownDevnetNFTBridgeEmitters}
+
+	// The registry decoding integrator payloads, set before the servers are started.
+	payloadRegistry *vaa.PayloadRegistry
+)
+
+func isKnownEmitter(known []map[vaa.ChainID][]byte, chainID vaa.ChainID, addr vaa.Address) bool {
+	for _, emitters := range known {
+		if emitter, exists := emitters[chainID]; exists && bytes.Equal(emitter, addr.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// signedVAAResponse builds the message streamed for a VAA, decoding its payload if requested. VAAs which cannot be
+// decoded are streamed without a decoded payload.
+func signedVAAResponse(vaaBytes []byte, decode bool) *spyv1.SubscribeSignedVAAResponse {
+	resp := &spyv1.SubscribeSignedVAAResponse{VaaBytes: vaaBytes}
+	if !decode {
+		return resp
+	}
+
+	v, err := vaa.Unmarshal(vaaBytes)
+	if err != nil {
+		return resp
+	}
+
+	switch {
+	case isKnownEmitter(tokenBridgeEmitters, v.EmitterChain, v.EmitterAddress):
+		resp.DecodedPayload, _ = decodeTokenBridgePayload(v.Payload)
+	case isKnownEmitter(nftBridgeEmitters, v.EmitterChain, v.EmitterAddress):
+		resp.DecodedPayload, _ = decodeNFTBridgePayload(v.Payload)
+	default:
+		resp.DecodedPayload, _ = decodeIntegratorPayload(payloadRegistry, v)
+	}
+	return resp
+}
+
+// payloadReader reads the fixed size fields of a payload, remembering the first error.
+type payloadReader struct {
+	b   []byte
+	err error
+}
+
+var errPayloadTooShort = errors.New("payload too short")
+
+func (r *payloadReader) next(n int) []byte {
+	if r.err != nil || len(r.b) < n {
+		r.err = errPayloadTooShort
+		return make([]byte, n)
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *payloadReader) uint8() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *payloadReader) chain() publicrpcv1.ChainID {
+	return publicrpcv1.ChainID(binary.BigEndian.Uint16(r.next(2)))
+}
+
+func (r *payloadReader) address() string {
+	return hex.EncodeToString(r.next(32))
+}
+
+func (r *payloadReader) uint256() string {
+	return new(big.Int).SetBytes(r.next(32)).String()
+}
+
+func (r *payloadReader) string32() string {
+	return string(bytes.TrimRight(r.next(32), "\x00"))
+}
+
+// decodeTokenBridgePayload decodes transfers, transfers with payload and attestations.
+func decodeTokenBridgePayload(payload []byte) (*spyv1.DecodedPayload, error) {
+	r := &payloadReader{b: payload}
+	switch payloadType := r.uint8(); payloadType {
+	case 1, 3:
+		t := &spyv1.TokenTransfer{
+			PayloadType:    uint32(payloadType),
+			Amount:         r.uint256(),
+			TokenAddress:   r.address(),
+			TokenChain:     r.chain(),
+			Recipient:      r.address(),
+			RecipientChain: r.chain(),
+		}
+		if payloadType == 1 {
+			t.Fee = r.uint256()
+		} else {
+			t.Sender = r.address()
+			t.Payload = r.b
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &spyv1.DecodedPayload{Payload: &spyv1.DecodedPayload_TokenTransfer{TokenTransfer: t}}, nil
+
+	case 2:
+		m := &spyv1.AssetMeta{
+			TokenAddress: r.address(),
+			TokenChain:   r.chain(),
+			Decimals:     uint32(r.uint8()),
+			Symbol:       r.string32(),
+			Name:         r.string32(),
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &spyv1.DecodedPayload{Payload: &spyv1.DecodedPayload_AssetMeta{AssetMeta: m}}, nil
+	}
+
+	return nil, errors.New("unsupported token bridge payload")
+}
+
+// decodeNFTBridgePayload decodes NFT transfers.
+func decodeNFTBridgePayload(payload []byte) (*spyv1.DecodedPayload, error) {
+	r := &payloadReader{b: payload}
+	if r.uint8() != 1 {
+		return nil, errors.New("unsupported NFT bridge payload")
+	}
+
+	t := &spyv1.NFTTransfer{
+		TokenAddress: r.address(),
+		TokenChain:   r.chain(),
+		Symbol:       r.string32(),
+		Name:         r.string32(),
+		TokenId:      r.uint256(),
+	}
+	t.Uri = string(r.next(int(r.uint8())))
+	t.Recipient = r.address()
+	t.RecipientChain = r.chain()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &spyv1.DecodedPayload{Payload: &spyv1.DecodedPayload_NftTransfer{NftTransfer: t}}, nil
+}
+
+// decodeIntegratorPayload decodes the payloads known to the registry, returning nil for the others.
+func decodeIntegratorPayload(r *vaa.PayloadRegistry, v *vaa.VAA) (*spyv1.DecodedPayload, error) {
+	p, err := r.Decode(v.EmitterChain, v.EmitterAddress, v.Payload)
+	if p == nil || err != nil {
+		return nil, err
+	}
+
+	i := &spyv1.IntegratorPayload{Format: p.Format, Type: p.Type}
+	for _, f := range p.Fields {
+		i.Fields = append(i.Fields, &spyv1.PayloadField{Name: f.Name, Value: f.Value})
+	}
+	return &spyv1.DecodedPayload{Payload: &spyv1.DecodedPayload_IntegratorPayload{IntegratorPayload: i}}, nil
+}
@@ -0,0 +1,137 @@
+package spy
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestSignedVAAResponseDecodesTokenTransfers(t *testing.T) {
+	emitter, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+
+	// A transfer of one WETH from Ethereum to Solana with a fee of 0.01.
+	payload := mustDecodeHex(t, "01"+
+		"0000000000000000000000000000000000000000000000000000000005f5e100"+
+		"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"+"0002"+
+		"ec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5"+"0001"+
+		"00000000000000000000000000000000000000000000000000000000000f4240")
+	b, err := testVAA(vaa.ChainIDEthereum, emitter, 1, payload).Marshal()
+	require.NoError(t, err)
+
+	resp := signedVAAResponse(b, false)
+	assert.Equal(t, b, resp.VaaBytes)
+	assert.Nil(t, resp.DecodedPayload)
+
+	resp = signedVAAResponse(b, true)
+	assert.Equal(t, b, resp.VaaBytes)
+	transfer := resp.DecodedPayload.GetTokenTransfer()
+	require.NotNil(t, transfer)
+	assert.Equal(t, uint32(1), transfer.PayloadType)
+	assert.Equal(t, "100000000", transfer.Amount)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, transfer.TokenChain)
+	assert.Equal(t, "000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", transfer.TokenAddress)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_SOLANA, transfer.RecipientChain)
+	assert.Equal(t, "ec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5", transfer.Recipient)
+	assert.Equal(t, "1000000", transfer.Fee)
+	assert.Empty(t, transfer.Sender)
+
+	// A transfer with payload replaces the fee with the sender, followed by the payload.
+	payload[0] = 3
+	payload = append(payload, 0xab)
+	b, err = testVAA(vaa.ChainIDEthereum, emitter, 2, payload).Marshal()
+	require.NoError(t, err)
+	transfer = signedVAAResponse(b, true).DecodedPayload.GetTokenTransfer()
+	require.NotNil(t, transfer)
+	assert.Equal(t, uint32(3), transfer.PayloadType)
+	assert.Empty(t, transfer.Fee)
+	assert.Equal(t, "00000000000000000000000000000000000000000000000000000000000f4240", transfer.Sender)
+	assert.Equal(t, []byte{0xab}, transfer.Payload)
+
+	// Truncated payloads and unknown emitters are not decoded.
+	b, err = testVAA(vaa.ChainIDEthereum, emitter, 3, payload[:100]).Marshal()
+	require.NoError(t, err)
+	assert.Nil(t, signedVAAResponse(b, true).DecodedPayload)
+
+	b, err = testVAA(vaa.ChainIDEthereum, testEmitterA, 1, payload).Marshal()
+	require.NoError(t, err)
+	assert.Nil(t, signedVAAResponse(b, true).DecodedPayload)
+}
+
+func TestSignedVAAResponseDecodesAssetMeta(t *testing.T) {
+	emitter, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+
+	payload := append(mustDecodeHex(t, "02"+
+		"000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"+"0002"+"12"),
+		append([]byte("WETH"), make([]byte, 28)...)...)
+	payload = append(payload, append([]byte("Wrapped Ether"), make([]byte, 19)...)...)
+	b, err := testVAA(vaa.ChainIDEthereum, emitter, 1, payload).Marshal()
+	require.NoError(t, err)
+
+	meta := signedVAAResponse(b, true).DecodedPayload.GetAssetMeta()
+	require.NotNil(t, meta)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, meta.TokenChain)
+	assert.Equal(t, uint32(18), meta.Decimals)
+	assert.Equal(t, "WETH", meta.Symbol)
+	assert.Equal(t, "Wrapped Ether", meta.Name)
+}
+
+func TestSignedVAAResponseDecodesNFTTransfers(t *testing.T) {
+	emitter, err := vaa.BytesToAddress(common.KnownNFTBridgeEmitters[vaa.ChainIDSolana])
+	require.NoError(t, err)
+
+	payload := mustDecodeHex(t, "01"+
+		"0000000000000000000000006ffd7ede62328b3af38fcd61461bbfc52f5651fe"+"0002")
+	payload = append(payload, append([]byte("APE"), make([]byte, 29)...)...)
+	payload = append(payload, append([]byte("Apes"), make([]byte, 28)...)...)
+	payload = append(payload, mustDecodeHex(t, "000000000000000000000000000000000000000000000000000000000000002a")...)
+	payload = append(payload, 11)
+	payload = append(payload, []byte("ipfs://apes")...)
+	payload = append(payload, mustDecodeHex(t, "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585"+"0002")...)
+	b, err := testVAA(vaa.ChainIDSolana, emitter, 1, payload).Marshal()
+	require.NoError(t, err)
+
+	transfer := signedVAAResponse(b, true).DecodedPayload.GetNftTransfer()
+	require.NotNil(t, transfer)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, transfer.TokenChain)
+	assert.Equal(t, "APE", transfer.Symbol)
+	assert.Equal(t, "Apes", transfer.Name)
+	assert.Equal(t, "42", transfer.TokenId)
+	assert.Equal(t, "ipfs://apes", transfer.Uri)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, transfer.RecipientChain)
+	assert.Equal(t, "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585", transfer.Recipient)
+}
+
+func TestSignedVAAResponseDecodesIntegratorPayloads(t *testing.T) {
+	b, err := testVAA(vaa.ChainIDSolana, testEmitterA, 1, []byte{2, 0xab}).Marshal()
+	require.NoError(t, err)
+	assert.Nil(t, signedVAAResponse(b, true).DecodedPayload)
+
+	registry, err := vaa.ParsePayloadRegistry([]byte(`{"decoders": [{"format": "relayer", "emitters": [{"chain": 1, "address": "` + hex.EncodeToString(testEmitterA[:]) + `"}]}]}`))
+	require.NoError(t, err)
+	payloadRegistry = registry
+	defer func() { payloadRegistry = nil }()
+
+	p := signedVAAResponse(b, true).DecodedPayload.GetIntegratorPayload()
+	require.NotNil(t, p)
+	assert.Equal(t, "relayer", p.Format)
+	assert.Equal(t, "unknown(2)", p.Type)
+
+	// Other emitters are not decoded.
+	b, err = testVAA(vaa.ChainIDSolana, testEmitterB, 1, []byte{2, 0xab}).Marshal()
+	require.NoError(t, err)
+	assert.Nil(t, signedVAAResponse(b, true).DecodedPayload)
+}
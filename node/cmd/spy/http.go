@@ -0,0 +1,442 @@
+package spy
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// The HTTP gateway serves the signed VAAs to web clients without requiring gRPC-web. Messages are encoded like the
+// gRPC-gateway does, for instance {"vaaBytes": "<base64>"}. Signed VAAs are public, so any origin is allowed.
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// filtersFromQuery builds a single message filter from the query parameters of a stream request:
+//
+//	chain=2&chain=4&emitter=2:0000...abcd&min_sequence=10&max_sequence=20&payload_prefix=01
+//
+// Without any parameters, all VAAs are streamed.
+func filtersFromQuery(q map[string][]string) ([]*spyv1.FilterEntry, error) {
+	mf := &spyv1.MessageFilter{}
+	set := false
+
+	for _, c := range q["chain"] {
+		id, err := strconv.ParseUint(c, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain %q", c)
+		}
+		mf.ChainIds = append(mf.ChainIds, publicrpcv1.ChainID(id))
+		set = true
+	}
+	for _, e := range q["emitter"] {
+		parts := strings.SplitN(e, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid emitter %q, expected <chain>:<hex address>", e)
+		}
+		id, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emitter chain %q", parts[0])
+		}
+		mf.Emitters = append(mf.Emitters, &spyv1.EmitterFilter{ChainId: publicrpcv1.ChainID(id), EmitterAddress: parts[1]})
+		set = true
+	}
+	for _, p := range []struct {
+		name string
+		dst  *uint64
+	}{{"min_sequence", &mf.MinSequence}, {"max_sequence", &mf.MaxSequence}} {
+		if v := q[p.name]; len(v) != 0 {
+			n, err := strconv.ParseUint(v[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", p.name, v[0])
+			}
+			*p.dst = n
+			set = true
+		}
+	}
+	for _, p := range q["payload_prefix"] {
+		b, err := hex.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload prefix %q", p)
+		}
+		mf.PayloadPrefixes = append(mf.PayloadPrefixes, b)
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return []*spyv1.FilterEntry{{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: mf}}}, nil
+}
+
+// replayFromQuery parses the replay requests of a stream request, such as replay_from=2:0000...abcd:10.
+func replayFromQuery(q map[string][]string) ([]*spyv1.ReplayFrom, error) {
+	var res []*spyv1.ReplayFrom
+	for _, r := range q["replay_from"] {
+		parts := strings.Split(r, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid replay_from %q, expected <chain>:<hex address>:<sequence>", r)
+		}
+		chain, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay_from chain %q", parts[0])
+		}
+		seq, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay_from sequence %q", parts[2])
+		}
+		res = append(res, &spyv1.ReplayFrom{ChainId: publicrpcv1.ChainID(chain), EmitterAddress: parts[1], Sequence: seq})
+	}
+	return res, nil
+}
+
+// subscribeHTTP creates a subscription for the filters in the query of r and returns it with the requested replays,
+// or writes an error response.
+func (s *spyServer) subscribeHTTP(w http.ResponseWriter, r *http.Request, transport string) (*subscription, []replayFrom) {
+	q := r.URL.Query()
+	entries, err := filtersFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	fi, conditions, err := decodeFilters(entries)
+	if err != nil {
+		http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		return nil, nil
+	}
+	replayEntries, err := replayFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	from, err := decodeReplayFrom(replayEntries)
+	if err != nil {
+		http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		return nil, nil
+	}
+	if len(from) != 0 && s.db == nil {
+		http.Error(w, "replay requires the spy to run with a persistent store", http.StatusBadRequest)
+		return nil, nil
+	}
+	if err := s.checkDegraded(); err != nil {
+		http.Error(w, status.Convert(err).Message(), http.StatusServiceUnavailable)
+		return nil, nil
+	}
+	sub := s.subscribe(fi, conditions, transport)
+	sub.decodePayloads = q.Get("decode_payloads") == "true"
+	return sub, from
+}
+
+func encodeVAAMessage(vaaBytes []byte, decode bool) ([]byte, error) {
+	return protojson.Marshal(signedVAAResponse(vaaBytes, decode))
+}
+
+// handleWebSocket streams the VAAs as text messages.
+func (s *spyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub, from := s.subscribeHTTP(w, r, "websocket")
+	if sub == nil {
+		return
+	}
+	defer s.unsubscribe(sub)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded.
+		return
+	}
+	defer conn.Close()
+
+	// Read until the client goes away. Clients are not expected to send anything else than control messages.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(vaaBytes []byte) error {
+		b, err := encodeVAAMessage(vaaBytes, sub.decodePayloads)
+		if err != nil {
+			return err
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteMessage(websocket.TextMessage, b)
+	}
+
+	if err := s.replay(sub, from, write); err != nil {
+		msg := websocket.FormatCloseMessage(websocket.CloseInternalServerErr, status.Convert(err).Message())
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+		return
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-sub.shed:
+			msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, status.Convert(errShed).Message())
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case msg := <-sub.ch:
+			if err := write(msg.vaaBytes); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleSSE streams the VAAs as server-sent events.
+func (s *spyServer) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, from := s.subscribeHTTP(w, r, "sse")
+	if sub == nil {
+		return
+	}
+	defer s.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	write := func(vaaBytes []byte) error {
+		b, err := encodeVAAMessage(vaaBytes, sub.decodePayloads)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: vaa\ndata: %s\n\n", b); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := s.replay(sub, from, write); err != nil {
+		_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", status.Convert(err).Message())
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.shed:
+			_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", status.Convert(errShed).Message())
+			return
+		case msg := <-sub.ch:
+			if err := write(msg.vaaBytes); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleGetSignedVAA looks up a VAA received by the spy, mirroring the GetSignedVAA endpoint of the public API.
+func (s *spyServer) handleGetSignedVAA(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	chain, err := strconv.ParseUint(vars["chain"], 10, 16)
+	if err != nil {
+		http.Error(w, "invalid chain", http.StatusBadRequest)
+		return
+	}
+	addr, err := vaa.StringToAddress(vars["emitter"])
+	if err != nil {
+		http.Error(w, "invalid emitter address", http.StatusBadRequest)
+		return
+	}
+	seq, err := strconv.ParseUint(vars["sequence"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid sequence", http.StatusBadRequest)
+		return
+	}
+
+	id := fmt.Sprintf("%d/%s/%d", chain, addr, seq)
+	b, ok := s.cache.get(id)
+	if !ok && s.db != nil {
+		b, err = s.db.GetSignedVAABytes(db.VAAID{EmitterChain: vaa.ChainID(chain), EmitterAddress: addr, Sequence: seq})
+		if err == nil {
+			ok = true
+		} else if err != db.ErrVAANotFound {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !ok {
+		http.Error(w, "requested VAA not found", http.StatusNotFound)
+		return
+	}
+
+	resp, err := protojson.Marshal(&publicrpcv1.GetSignedVAAResponse{VaaBytes: b})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+// uintQueryParam parses an optional unsigned integer query parameter into dst.
+func uintQueryParam(q url.Values, name string, bitSize int, dst func(n uint64)) error {
+	if v := q.Get(name); v != "" {
+		n, err := strconv.ParseUint(v, 10, bitSize)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", name, v)
+		}
+		dst(n)
+	}
+	return nil
+}
+
+// handleListArchivedVAAsByEmitter lists the VAAs of an emitter in archive mode, like ListArchivedVAAsByEmitter:
+//
+//	/v1/archive/vaas/2/0000...abcd?from_sequence=10&page_size=100&page_token=110
+func (s *spyServer) handleListArchivedVAAsByEmitter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	vars := mux.Vars(r)
+	chain, err := strconv.ParseUint(vars["chain"], 10, 16)
+	if err != nil {
+		http.Error(w, "invalid chain", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	req := &spyv1.ListArchivedVAAsByEmitterRequest{
+		ChainId:        publicrpcv1.ChainID(chain),
+		EmitterAddress: vars["emitter"],
+		PageToken:      q.Get("page_token"),
+	}
+	if err := uintQueryParam(q, "from_sequence", 64, func(n uint64) { req.FromSequence = n }); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := uintQueryParam(q, "page_size", 32, func(n uint64) { req.PageSize = uint32(n) }); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.ListArchivedVAAsByEmitter(r.Context(), req)
+	writeArchivedVAAs(w, resp, err)
+}
+
+// handleListArchivedVAAsByTime lists the VAAs in a time range in archive mode, like ListArchivedVAAsByTime. The time
+// range is in Unix seconds and all parameters are optional:
+//
+//	/v1/archive/vaas?since=1660000000&until=1660086400&chain=2&emitter=0000...abcd&page_size=100&page_token=...
+func (s *spyServer) handleListArchivedVAAsByTime(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	q := r.URL.Query()
+	req := &spyv1.ListArchivedVAAsByTimeRequest{
+		EmitterAddress: q.Get("emitter"),
+		PageToken:      q.Get("page_token"),
+	}
+	for _, p := range []struct {
+		name    string
+		bitSize int
+		dst     func(n uint64)
+	}{
+		{"since", 63, func(n uint64) { req.Since = int64(n) }},
+		{"until", 63, func(n uint64) { req.Until = int64(n) }},
+		{"chain", 16, func(n uint64) { req.ChainId = publicrpcv1.ChainID(n) }},
+		{"page_size", 32, func(n uint64) { req.PageSize = uint32(n) }},
+	} {
+		if err := uintQueryParam(q, p.name, p.bitSize, p.dst); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp, err := s.ListArchivedVAAsByTime(r.Context(), req)
+	writeArchivedVAAs(w, resp, err)
+}
+
+func writeArchivedVAAs(w http.ResponseWriter, resp *spyv1.ListArchivedVAAsResponse, err error) {
+	if err != nil {
+		st := status.Convert(err)
+		http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+		return
+	}
+	b, err := protojson.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func spyHTTPRunnable(s *spyServer, logger *zap.Logger, listenAddr string) supervisor.Runnable {
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/subscribe_signed_vaa/ws", s.handleWebSocket).Methods(http.MethodGet)
+	router.HandleFunc("/v1/subscribe_signed_vaa/sse", s.handleSSE).Methods(http.MethodGet)
+	router.HandleFunc("/v1/signed_vaa/{chain}/{emitter}/{sequence}", s.handleGetSignedVAA).Methods(http.MethodGet)
+	router.HandleFunc("/v1/archive/vaas/{chain}/{emitter}", s.handleListArchivedVAAsByEmitter).Methods(http.MethodGet)
+	router.HandleFunc("/v1/archive/vaas", s.handleListArchivedVAAsByTime).Methods(http.MethodGet)
+
+	return func(ctx context.Context) error {
+		l, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		srv := &http.Server{Handler: router}
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		errC := make(chan error)
+		go func() {
+			logger.Info("spy HTTP server listening", zap.String("addr", l.Addr().String()))
+			errC <- srv.Serve(l)
+		}()
+		select {
+		case <-ctx.Done():
+			// non-graceful shutdown
+			if err := srv.Close(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case err := <-errC:
+			return err
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package spy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestVAACache(t *testing.T) {
+	c := newVAACache(2)
+	c.add("a", []byte{1})
+	c.add("b", []byte{2})
+	c.add("a", []byte{3})
+	c.add("c", []byte{4})
+
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	b, ok := c.get("b")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, b)
+	b, ok = c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{4}, b)
+}
+
+func testHTTPServer(t *testing.T) (*spyServer, *httptest.Server) {
+	s := newSpyServer(zap.NewNop())
+	s.cache = newVAACache(10)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/subscribe_signed_vaa/ws", s.handleWebSocket)
+	router.HandleFunc("/v1/subscribe_signed_vaa/sse", s.handleSSE)
+	router.HandleFunc("/v1/signed_vaa/{chain}/{emitter}/{sequence}", s.handleGetSignedVAA)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func marshalTestVAA(t *testing.T, v *vaa.VAA) []byte {
+	b, err := v.Marshal()
+	require.NoError(t, err)
+	return b
+}
+
+// waitForSubscription waits until the spy has a subscription, so that published VAAs are not missed.
+func waitForSubscription(t *testing.T, s *spyServer) {
+	require.Eventually(t, func() bool {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		return len(s.subs) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPGetSignedVAA(t *testing.T) {
+	s, srv := testHTTPServer(t)
+	v := marshalTestVAA(t, testVAA(vaa.ChainIDEthereum, testEmitterA, 42, []byte{1}))
+	require.NoError(t, s.Publish(v))
+
+	resp, err := http.Get(fmt.Sprintf("%s/v1/signed_vaa/2/%s/42", srv.URL, testEmitterA))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct{ VaaBytes []byte }
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, v, body.VaaBytes)
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/signed_vaa/2/%s/43", srv.URL, testEmitterA))
+	require.NoError(t, err)
+	_, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHTTPSubscribeSSE(t *testing.T) {
+	s, srv := testHTTPServer(t)
+
+	resp, err := http.Get(srv.URL + "/v1/subscribe_signed_vaa/sse?chain=2&min_sequence=10")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	waitForSubscription(t, s)
+
+	skipped := marshalTestVAA(t, testVAA(vaa.ChainIDEthereum, testEmitterA, 9, []byte{1}))
+	matched := marshalTestVAA(t, testVAA(vaa.ChainIDEthereum, testEmitterA, 10, []byte{1}))
+	require.NoError(t, s.Publish(skipped))
+	require.NoError(t, s.Publish(matched))
+
+	r := bufio.NewReader(resp.Body)
+	event, err := r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: vaa\n", event)
+	data, err := r.ReadString('\n')
+	require.NoError(t, err)
+
+	var body struct{ VaaBytes []byte }
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &body))
+	assert.Equal(t, matched, body.VaaBytes)
+}
+
+func TestHTTPSubscribeWebSocket(t *testing.T) {
+	s, srv := testHTTPServer(t)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/subscribe_signed_vaa/ws?chain=abc", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/subscribe_signed_vaa/ws?payload_prefix=01", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	waitForSubscription(t, s)
+
+	skipped := marshalTestVAA(t, testVAA(vaa.ChainIDSolana, testEmitterA, 1, []byte{2}))
+	matched := marshalTestVAA(t, testVAA(vaa.ChainIDSolana, testEmitterA, 2, []byte{1, 2}))
+	require.NoError(t, s.Publish(skipped))
+	require.NoError(t, s.Publish(matched))
+
+	var body struct{ VaaBytes []byte }
+	require.NoError(t, conn.ReadJSON(&body))
+	assert.Equal(t, matched, body.VaaBytes)
+
+	// The subscription is removed once the client goes away.
+	conn.Close()
+	require.Eventually(t, func() bool {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		return len(s.subs) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPSubscriptionsShed(t *testing.T) {
+	s, srv := testHTTPServer(t)
+	// The number of goroutines of the test exceeds this limit once it is checked.
+	s.watchdog = watchdog.New(zap.NewNop(), watchdog.Limits{Goroutines: 1}, "", time.Minute)
+
+	resp, err := http.Get(srv.URL + "/v1/subscribe_signed_vaa/sse")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	waitForSubscription(t, s)
+
+	// Streams are dropped once the spy is degraded.
+	s.watchdog.Check()
+	r := bufio.NewReader(resp.Body)
+	event, err := r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: error\n", event)
+
+	// New subscriptions are refused.
+	resp, err = http.Get(srv.URL + "/v1/subscribe_signed_vaa/sse")
+	require.NoError(t, err)
+	_, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,181 @@
+package spy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Observations are far more frequent than VAAs, so they are dropped for subscribers that cannot keep up instead of
+// blocking the other subscribers.
+const observationBufferSize = 1000
+
+var (
+	observationSubscriptionsActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_spy_observation_subscriptions",
+			Help: "Number of active SubscribeSignedObservations subscriptions",
+		})
+	observationsInvalidTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_observations_invalid_total",
+			Help: "Total number of observations dropped because their signature does not match their guardian address",
+		})
+	observationsDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_subscription_observations_dropped_total",
+			Help: "Total number of observations dropped because a subscription could not keep up",
+		}, []string{"subscription"})
+)
+
+type observationSubscription struct {
+	id string
+	// Message ID prefixes of the emitters, such as "1/<address>/". Any emitter if empty.
+	emitterPrefixes []string
+	// Any guardian if empty.
+	guardians map[ethcommon.Address]bool
+	ch        chan *gossipv1.SignedObservation
+	dropped   prometheus.Counter
+}
+
+func (sub *observationSubscription) matches(o *gossipv1.SignedObservation) bool {
+	if len(sub.guardians) != 0 && !sub.guardians[ethcommon.BytesToAddress(o.Addr)] {
+		return false
+	}
+	if len(sub.emitterPrefixes) == 0 {
+		return true
+	}
+	for _, p := range sub.emitterPrefixes {
+		if strings.HasPrefix(o.MessageId, p) {
+			return true
+		}
+	}
+	return false
+}
+
+type observationSubscriptions struct {
+	mu   sync.Mutex
+	subs map[string]*observationSubscription
+}
+
+// verifyObservation checks that the observation was signed by the guardian it claims to be from.
+func verifyObservation(o *gossipv1.SignedObservation) bool {
+	pk, err := crypto.Ecrecover(o.Hash, o.Signature)
+	if err != nil {
+		return false
+	}
+	signer := crypto.Keccak256(pk[1:])[12:]
+	return len(o.Addr) == len(signer) && bytes.Equal(o.Addr, signer)
+}
+
+// PublishObservation sends a verified observation to the matching subscriptions and to the sink.
+func (s *spyServer) PublishObservation(o *gossipv1.SignedObservation) {
+	s.obsSubs.mu.Lock()
+	defer s.obsSubs.mu.Unlock()
+
+	if len(s.obsSubs.subs) == 0 && !s.sink.wantsObservations() {
+		return
+	}
+	if !verifyObservation(o) {
+		observationsInvalidTotal.Inc()
+		return
+	}
+	s.sink.publishObservation(o)
+
+	for _, sub := range s.obsSubs.subs {
+		if !sub.matches(o) {
+			continue
+		}
+		select {
+		case sub.ch <- o:
+		default:
+			sub.dropped.Inc()
+		}
+	}
+}
+
+func decodeObservationRequest(req *spyv1.SubscribeSignedObservationsRequest) (*observationSubscription, error) {
+	sub := &observationSubscription{}
+	for _, f := range req.Emitters {
+		e, err := decodeEmitterFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		sub.emitterPrefixes = append(sub.emitterPrefixes, fmt.Sprintf("%d/%s/", e.chainId, e.emitterAddr))
+	}
+	if len(req.GuardianAddresses) != 0 {
+		sub.guardians = make(map[ethcommon.Address]bool)
+		for _, a := range req.GuardianAddresses {
+			b, err := hex.DecodeString(strings.TrimPrefix(a, "0x"))
+			if err != nil || len(b) != ethcommon.AddressLength {
+				return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid guardian address %q", a))
+			}
+			sub.guardians[ethcommon.BytesToAddress(b)] = true
+		}
+	}
+	if len(sub.emitterPrefixes)+len(sub.guardians) > maxFilterConditions {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("too many filter conditions, at most %d are allowed", maxFilterConditions))
+	}
+	return sub, nil
+}
+
+func (s *spyServer) SubscribeSignedObservations(req *spyv1.SubscribeSignedObservationsRequest, resp spyv1.SpyRPCService_SubscribeSignedObservationsServer) error {
+	sub, err := decodeObservationRequest(req)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDegraded(); err != nil {
+		return err
+	}
+	shed := s.watchdog.Shedding()
+
+	sub.id = subscriptionId()
+	sub.ch = make(chan *gossipv1.SignedObservation, observationBufferSize)
+	sub.dropped = observationsDroppedTotal.WithLabelValues(sub.id)
+
+	s.obsSubs.mu.Lock()
+	s.obsSubs.subs[sub.id] = sub
+	s.obsSubs.mu.Unlock()
+	observationSubscriptionsActive.Inc()
+
+	s.logger.Info("new observation subscription",
+		zap.String("subscription", sub.id),
+		zap.Int("emitters", len(sub.emitterPrefixes)),
+		zap.Int("guardians", len(sub.guardians)))
+
+	defer func() {
+		s.obsSubs.mu.Lock()
+		defer s.obsSubs.mu.Unlock()
+		delete(s.obsSubs.subs, sub.id)
+		observationSubscriptionsActive.Dec()
+		observationsDroppedTotal.DeleteLabelValues(sub.id)
+	}()
+
+	for {
+		select {
+		case <-resp.Context().Done():
+			return resp.Context().Err()
+		case <-shed:
+			return errShed
+		case o := <-sub.ch:
+			if err := resp.Send(&spyv1.SubscribeSignedObservationsResponse{
+				Observation:     o,
+				GuardianAddress: ethcommon.BytesToAddress(o.Addr).Hex(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
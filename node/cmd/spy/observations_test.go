@@ -0,0 +1,51 @@
+package spy
+
+import (
+	"testing"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPublishObservation(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	observation := func(messageID string) *gossipv1.SignedObservation {
+		hash := crypto.Keccak256([]byte(messageID))
+		sig, err := crypto.Sign(hash, key)
+		require.NoError(t, err)
+		return &gossipv1.SignedObservation{Addr: addr.Bytes(), Hash: hash, Signature: sig, MessageId: messageID}
+	}
+
+	s := newSpyServer(zap.NewNop())
+	sub, err := decodeObservationRequest(&spyv1.SubscribeSignedObservationsRequest{
+		Emitters:          []*spyv1.EmitterFilter{{ChainId: publicrpcv1.ChainID_CHAIN_ID_SOLANA, EmitterAddress: testEmitterA.String()}},
+		GuardianAddresses: []string{addr.Hex()},
+	})
+	require.NoError(t, err)
+	sub.ch = make(chan *gossipv1.SignedObservation, 10)
+	s.obsSubs.subs["test"] = sub
+
+	matching := observation("1/" + testEmitterA.String() + "/1")
+	s.PublishObservation(matching)
+	s.PublishObservation(observation("1/" + testEmitterB.String() + "/1"))
+	s.PublishObservation(observation("2/" + testEmitterA.String() + "/1"))
+
+	// The signature does not match the hash, so it was not made by the guardian.
+	forged := observation("1/" + testEmitterA.String() + "/2")
+	forged.Hash = crypto.Keccak256([]byte("forged"))
+	s.PublishObservation(forged)
+
+	require.Len(t, sub.ch, 1)
+	assert.Equal(t, matching, <-sub.ch)
+
+	_, err = decodeObservationRequest(&spyv1.SubscribeSignedObservationsRequest{GuardianAddresses: []string{"0x1234"}})
+	assert.Error(t, err)
+}
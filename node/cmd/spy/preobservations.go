@@ -0,0 +1,132 @@
+package spy
+
+import (
+	"sync"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Pre-observations are messages of pending transactions simulated by an EVM pre-observation watcher, see
+// ethereum.PreObservationWatcher. Like observations, they are dropped for subscribers that cannot keep up.
+
+var (
+	preObservationSubscriptionsActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_spy_pre_observation_subscriptions",
+			Help: "Number of active SubscribePreObservations subscriptions",
+		})
+	preObservationsDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_subscription_pre_observations_dropped_total",
+			Help: "Total number of pre-observations dropped because a subscription could not keep up",
+		}, []string{"subscription"})
+)
+
+type preObservationSubscription struct {
+	id string
+	// Any emitter if empty.
+	emitters map[emitter]bool
+	ch       chan *common.MessagePublication
+	dropped  prometheus.Counter
+}
+
+type preObservationSubscriptions struct {
+	mu   sync.Mutex
+	subs map[string]*preObservationSubscription
+}
+
+// PublishPreObservation sends a pre-observation to the matching subscriptions.
+func (s *spyServer) PublishPreObservation(m *common.MessagePublication) {
+	s.preObsSubs.mu.Lock()
+	defer s.preObsSubs.mu.Unlock()
+
+	for _, sub := range s.preObsSubs.subs {
+		if len(sub.emitters) != 0 && !sub.emitters[emitter{m.EmitterChain, m.EmitterAddress}] {
+			continue
+		}
+		select {
+		case sub.ch <- m:
+		default:
+			sub.dropped.Inc()
+		}
+	}
+}
+
+func preObservationResponse(m *common.MessagePublication) *spyv1.SubscribePreObservationsResponse {
+	return &spyv1.SubscribePreObservationsResponse{PreObservation: &spyv1.PreObservation{
+		TxHash:           m.TxHash.Hex(),
+		EmitterChain:     publicrpcv1.ChainID(m.EmitterChain),
+		EmitterAddress:   m.EmitterAddress.String(),
+		Sequence:         m.Sequence,
+		Nonce:            m.Nonce,
+		ConsistencyLevel: uint32(m.ConsistencyLevel),
+		Payload:          m.Payload,
+		SeenAt:           m.Timestamp.Unix(),
+	}}
+}
+
+func (s *spyServer) SubscribePreObservations(req *spyv1.SubscribePreObservationsRequest, resp spyv1.SpyRPCService_SubscribePreObservationsServer) error {
+	if !s.preObservations {
+		return status.Error(codes.FailedPrecondition, "pre-observations require the spy to run with --preObservationRPC")
+	}
+	if len(req.Emitters) > maxFilterConditions {
+		return status.Errorf(codes.InvalidArgument, "too many filter conditions, at most %d are allowed", maxFilterConditions)
+	}
+
+	sub := &preObservationSubscription{
+		id: subscriptionId(),
+		ch: make(chan *common.MessagePublication, observationBufferSize),
+	}
+	if len(req.Emitters) != 0 {
+		sub.emitters = make(map[emitter]bool, len(req.Emitters))
+		for _, f := range req.Emitters {
+			e, err := decodeEmitterFilter(f)
+			if err != nil {
+				return err
+			}
+			sub.emitters[e] = true
+		}
+	}
+	if err := s.checkDegraded(); err != nil {
+		return err
+	}
+	shed := s.watchdog.Shedding()
+	sub.dropped = preObservationsDroppedTotal.WithLabelValues(sub.id)
+
+	s.preObsSubs.mu.Lock()
+	s.preObsSubs.subs[sub.id] = sub
+	s.preObsSubs.mu.Unlock()
+	preObservationSubscriptionsActive.Inc()
+
+	s.logger.Info("new pre-observation subscription",
+		zap.String("subscription", sub.id),
+		zap.Int("emitters", len(sub.emitters)))
+
+	defer func() {
+		s.preObsSubs.mu.Lock()
+		defer s.preObsSubs.mu.Unlock()
+		delete(s.preObsSubs.subs, sub.id)
+		preObservationSubscriptionsActive.Dec()
+		preObservationsDroppedTotal.DeleteLabelValues(sub.id)
+	}()
+
+	for {
+		select {
+		case <-resp.Context().Done():
+			return resp.Context().Err()
+		case <-shed:
+			return errShed
+		case m := <-sub.ch:
+			if err := resp.Send(preObservationResponse(m)); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package spy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPublishPreObservation(t *testing.T) {
+	s := newSpyServer(zap.NewNop())
+	sub := &preObservationSubscription{
+		emitters: map[emitter]bool{{vaa.ChainIDEthereum, testEmitterA}: true},
+		ch:       make(chan *common.MessagePublication, 1),
+		dropped:  preObservationsDroppedTotal.WithLabelValues("test"),
+	}
+	s.preObsSubs.subs["test"] = sub
+
+	matching := &common.MessagePublication{
+		TxHash:           eth_common.HexToHash("0x01"),
+		Timestamp:        time.Unix(1660000000, 0),
+		EmitterChain:     vaa.ChainIDEthereum,
+		EmitterAddress:   testEmitterA,
+		Sequence:         42,
+		ConsistencyLevel: 15,
+		Payload:          []byte{1},
+	}
+	s.PublishPreObservation(&common.MessagePublication{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: testEmitterB})
+	s.PublishPreObservation(matching)
+	// Dropped since the subscription cannot keep up.
+	s.PublishPreObservation(matching)
+
+	require.Len(t, sub.ch, 1)
+	resp := preObservationResponse(<-sub.ch)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", resp.PreObservation.TxHash)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, resp.PreObservation.EmitterChain)
+	assert.Equal(t, testEmitterA.String(), resp.PreObservation.EmitterAddress)
+	assert.Equal(t, uint64(42), resp.PreObservation.Sequence)
+	assert.Equal(t, uint32(15), resp.PreObservation.ConsistencyLevel)
+	assert.Equal(t, int64(1660000000), resp.PreObservation.SeenAt)
+}
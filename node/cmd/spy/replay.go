@@ -0,0 +1,159 @@
+package spy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Maximum number of live VAAs buffered for a subscription while its replay is sent.
+const maxReplayBuffer = 10000
+
+type replayFrom struct {
+	emitter  emitter
+	sequence uint64
+}
+
+func decodeReplayFrom(entries []*spyv1.ReplayFrom) ([]replayFrom, error) {
+	var res []replayFrom
+	for _, r := range entries {
+		e, err := decodeEmitterFilter(&spyv1.EmitterFilter{ChainId: r.ChainId, EmitterAddress: r.EmitterAddress})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, replayFrom{emitter: e, sequence: r.Sequence})
+	}
+	if len(res) > maxFilterConditions {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("too many replay requests (%d), at most %d are allowed", len(res), maxFilterConditions))
+	}
+	return res, nil
+}
+
+// replay sends the stored VAAs requested by from which match the filters of sub, then the live VAAs received in the
+// meantime. The subscription must be registered before calling replay, so that no VAA is missed: VAAs are stored
+// before they are published, so a VAA is either part of the replay or published to the subscription afterwards. VAAs
+// that are both are only sent once, except in the rare case that they are published right after the replay. Clients
+// must deduplicate anyway, since the same VAA is usually received from several guardians.
+func (s *spyServer) replay(sub *subscription, from []replayFrom, send func(b []byte) error) error {
+	if len(from) == 0 {
+		return nil
+	}
+	if s.db == nil {
+		return status.Error(codes.FailedPrecondition, "replay requires the spy to run with a persistent store (--dataDir)")
+	}
+
+	// Buffer the live VAAs while replaying, so that Publish is not blocked.
+	var (
+		liveMu   sync.Mutex
+		live     [][]byte
+		overflow bool
+	)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				return
+			case msg := <-sub.ch:
+				liveMu.Lock()
+				if len(live) >= maxReplayBuffer {
+					overflow = true
+				} else {
+					live = append(live, msg.vaaBytes)
+				}
+				liveMu.Unlock()
+			}
+		}
+	}()
+
+	replayed := make(map[string]bool)
+	err := s.replayStored(sub, from, func(id string, b []byte) error {
+		replayed[id] = true
+		return send(b)
+	})
+
+	close(stop)
+	<-stopped
+	for drained := false; !drained; {
+		select {
+		case msg := <-sub.ch:
+			live = append(live, msg.vaaBytes)
+		default:
+			drained = true
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	if overflow {
+		return status.Error(codes.ResourceExhausted, "too many live VAAs received during the replay, retry from a later sequence")
+	}
+
+	for _, b := range live {
+		v, err := vaa.Unmarshal(b)
+		if err != nil {
+			return err
+		}
+		if replayed[v.MessageID()] {
+			continue
+		}
+		if err := send(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *spyServer) replayStored(sub *subscription, from []replayFrom, send func(id string, b []byte) error) error {
+	for _, r := range from {
+		addr := r.emitter.emitterAddr
+		filter := db.VAAFilter{
+			EmitterChain:   r.emitter.chainId,
+			EmitterAddress: &addr,
+			FirstSequence:  r.sequence,
+			LastSequence:   math.MaxUint64,
+		}
+
+		// Keys are not ordered numerically, so collect the sequences first.
+		var sequences []uint64
+		if err := s.db.IterateSignedVAAs(filter, func(id *db.VAAID, b []byte) error {
+			sequences = append(sequences, id.Sequence)
+			return nil
+		}); err != nil {
+			return status.Error(codes.Internal, fmt.Sprintf("failed to read stored VAAs: %v", err))
+		}
+		sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+		for _, seq := range sequences {
+			b, err := s.db.GetSignedVAABytes(db.VAAID{EmitterChain: r.emitter.chainId, EmitterAddress: addr, Sequence: seq})
+			if err == db.ErrVAANotFound {
+				// Pruned in the meantime.
+				continue
+			} else if err != nil {
+				return status.Error(codes.Internal, fmt.Sprintf("failed to read stored VAA: %v", err))
+			}
+
+			v, err := vaa.Unmarshal(b)
+			if err != nil {
+				return status.Error(codes.Internal, fmt.Sprintf("failed to unmarshal stored VAA: %v", err))
+			}
+			if !sub.matches(v) {
+				continue
+			}
+			if err := send(v.MessageID(), b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
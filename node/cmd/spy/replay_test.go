@@ -0,0 +1,50 @@
+package spy
+
+import (
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func signedTestVAA(emitter vaa.Address, seq uint64) *vaa.VAA {
+	v := testVAA(vaa.ChainIDSolana, emitter, seq, []byte{1})
+	v.Signatures = []*vaa.Signature{{Index: 0}}
+	return v
+}
+
+func TestReplay(t *testing.T) {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer d.Close()
+
+	s := newSpyServer(zap.NewNop())
+	s.db = d
+
+	for _, seq := range []uint64{1, 2, 10, 3} {
+		require.NoError(t, s.Publish(marshalTestVAA(t, signedTestVAA(testEmitterA, seq))))
+	}
+	require.NoError(t, s.Publish(marshalTestVAA(t, signedTestVAA(testEmitterB, 5))))
+
+	sub := s.subscribe(nil, 0, "test")
+	defer s.unsubscribe(sub)
+
+	// Published after the subscription, so both stored and buffered for the subscription.
+	require.NoError(t, s.Publish(marshalTestVAA(t, signedTestVAA(testEmitterA, 11))))
+
+	var sequences []uint64
+	err = s.replay(sub, []replayFrom{{emitter: emitter{vaa.ChainIDSolana, testEmitterA}, sequence: 2}}, func(b []byte) error {
+		v, err := vaa.Unmarshal(b)
+		require.NoError(t, err)
+		sequences = append(sequences, v.Sequence)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 3, 10, 11}, sequences)
+
+	s.db = nil
+	assert.Error(t, s.replay(sub, []replayFrom{{}}, func(b []byte) error { return nil }))
+}
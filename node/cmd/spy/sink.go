@@ -0,0 +1,279 @@
+package spy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The spy can publish the VAAs and observations it receives to Kafka or NATS, so indexers can consume them from a
+// broker instead of a gRPC subscription. Messages are queued and published in the background. They are dropped if the
+// broker cannot keep up, rather than slowing down the other subscribers.
+
+// sinkBufferSize is the number of messages queued for the broker before new ones are dropped.
+const sinkBufferSize = 10000
+
+var (
+	sinkPublishedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_sink_published_total",
+			Help: "Total number of messages published to the broker, by topic",
+		}, []string{"topic"})
+	sinkDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_sink_dropped_total",
+			Help: "Total number of messages dropped because the broker could not keep up, by topic",
+		}, []string{"topic"})
+	sinkErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_sink_errors_total",
+			Help: "Total number of messages that could not be encoded or published, by topic",
+		}, []string{"topic"})
+)
+
+// sinkFormat is the serialization of the messages published to the broker.
+type sinkFormat string
+
+const (
+	// VAAs are published as their bytes, and observations as gossip SignedObservation messages.
+	sinkFormatRaw sinkFormat = "raw"
+	// VAAs are published as SubscribeSignedVAAResponse messages with decoded payloads, and observations as
+	// SubscribeSignedObservationsResponse messages, in protobuf JSON.
+	sinkFormatJSON sinkFormat = "json"
+	// Same messages as sinkFormatJSON, in protobuf binary.
+	sinkFormatProto sinkFormat = "proto"
+)
+
+func parseSinkFormat(s string) (sinkFormat, error) {
+	switch f := sinkFormat(s); f {
+	case sinkFormatRaw, sinkFormatJSON, sinkFormatProto:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown sink format %q, expected raw, json or proto", s)
+	}
+}
+
+// sinkPublisher publishes messages to a broker. Publish may return before the message is delivered, in which case
+// the publisher reports the delivery errors itself.
+type sinkPublisher interface {
+	Publish(ctx context.Context, topic string, key []byte, value []byte) error
+	Close() error
+}
+
+// sinkMessage is a VAA or an observation queued for the broker.
+type sinkMessage struct {
+	vaaBytes    []byte
+	observation *gossipv1.SignedObservation
+}
+
+// sink publishes VAAs and observations to a broker. Its methods can be called on a nil sink, which is disabled.
+type sink struct {
+	logger           *zap.Logger
+	format           sinkFormat
+	vaaTopic         string
+	observationTopic string
+	// Connects to the broker each time the runnable starts.
+	connect func() (sinkPublisher, error)
+	ch      chan sinkMessage
+}
+
+func newSink(logger *zap.Logger, format sinkFormat, vaaTopic string, observationTopic string, connect func() (sinkPublisher, error)) *sink {
+	return &sink{
+		logger:           logger.Named("sink"),
+		format:           format,
+		vaaTopic:         vaaTopic,
+		observationTopic: observationTopic,
+		connect:          connect,
+		ch:               make(chan sinkMessage, sinkBufferSize),
+	}
+}
+
+// wantsObservations returns whether observations are published.
+func (k *sink) wantsObservations() bool {
+	return k != nil && k.observationTopic != ""
+}
+
+// topic returns the topic a message is published to.
+func (k *sink) topic(m sinkMessage) string {
+	if m.observation != nil {
+		return k.observationTopic
+	}
+	return k.vaaTopic
+}
+
+func (k *sink) enqueue(m sinkMessage) {
+	select {
+	case k.ch <- m:
+	default:
+		sinkDroppedTotal.WithLabelValues(k.topic(m)).Inc()
+	}
+}
+
+// publishVAA queues a signed VAA for the broker.
+func (k *sink) publishVAA(vaaBytes []byte) {
+	if k == nil || k.vaaTopic == "" {
+		return
+	}
+	k.enqueue(sinkMessage{vaaBytes: vaaBytes})
+}
+
+// publishObservation queues a verified observation for the broker.
+func (k *sink) publishObservation(o *gossipv1.SignedObservation) {
+	if !k.wantsObservations() {
+		return
+	}
+	k.enqueue(sinkMessage{observation: o})
+}
+
+// encode returns the key and the value of a message. The key is the emitter, so a Kafka topic keeps the messages of an
+// emitter in order.
+func (k *sink) encode(m sinkMessage) ([]byte, []byte, error) {
+	if m.observation != nil {
+		o := m.observation
+		var key []byte
+		if parts := strings.SplitN(o.MessageId, "/", 3); len(parts) == 3 {
+			key = []byte(parts[0] + "/" + parts[1])
+		}
+
+		var value []byte
+		var err error
+		resp := &spyv1.SubscribeSignedObservationsResponse{Observation: o, GuardianAddress: ethcommon.BytesToAddress(o.Addr).Hex()}
+		switch k.format {
+		case sinkFormatRaw:
+			value, err = proto.Marshal(o)
+		case sinkFormatJSON:
+			value, err = protojson.Marshal(resp)
+		case sinkFormatProto:
+			value, err = proto.Marshal(resp)
+		}
+		return key, value, err
+	}
+
+	v, err := vaa.Unmarshal(m.vaaBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal VAA: %w", err)
+	}
+	key := []byte(fmt.Sprintf("%d/%s", v.EmitterChain, v.EmitterAddress))
+
+	var value []byte
+	switch k.format {
+	case sinkFormatRaw:
+		value = m.vaaBytes
+	case sinkFormatJSON:
+		value, err = protojson.Marshal(signedVAAResponse(m.vaaBytes, true))
+	case sinkFormatProto:
+		value, err = proto.Marshal(signedVAAResponse(m.vaaBytes, true))
+	}
+	return key, value, err
+}
+
+// run publishes the queued messages until the context is cancelled.
+func (k *sink) run(ctx context.Context) error {
+	pub, err := k.connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the broker: %w", err)
+	}
+	defer func() {
+		if err := pub.Close(); err != nil {
+			k.logger.Error("failed to close the broker connection", zap.Error(err))
+		}
+	}()
+
+	supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m := <-k.ch:
+			topic := k.topic(m)
+			key, value, err := k.encode(m)
+			if err != nil {
+				sinkErrorsTotal.WithLabelValues(topic).Inc()
+				k.logger.Error("failed to encode message", zap.String("topic", topic), zap.Error(err))
+				continue
+			}
+			if err := pub.Publish(ctx, topic, key, value); err != nil {
+				sinkErrorsTotal.WithLabelValues(topic).Inc()
+				k.logger.Error("failed to publish message", zap.String("topic", topic), zap.Error(err))
+				continue
+			}
+			sinkPublishedTotal.WithLabelValues(topic).Inc()
+		}
+	}
+}
+
+// kafkaPublisher publishes to Kafka. Messages are batched and written asynchronously.
+type kafkaPublisher struct {
+	w *kafka.Writer
+}
+
+func newKafkaPublisher(logger *zap.Logger, brokers []string) *kafkaPublisher {
+	return &kafkaPublisher{w: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 50 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+		Async:        true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				for _, m := range messages {
+					sinkErrorsTotal.WithLabelValues(m.Topic).Inc()
+				}
+				logger.Error("failed to write messages to Kafka", zap.Int("messages", len(messages)), zap.Error(err))
+			}
+		},
+	}}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, topic string, key []byte, value []byte) error {
+	return p.w.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.w.Close()
+}
+
+// natsPublisher publishes to NATS subjects. The key is not used.
+type natsPublisher struct {
+	nc *nats.Conn
+}
+
+func newNATSPublisher(logger *zap.Logger, url string) (*natsPublisher, error) {
+	nc, err := nats.Connect(url,
+		nats.Name("wormhole-spy"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn("disconnected from NATS", zap.Error(err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Info("reconnected to NATS", zap.String("url", nc.ConnectedUrl()))
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return &natsPublisher{nc: nc}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, topic string, key []byte, value []byte) error {
+	return p.nc.Publish(topic, value)
+}
+
+func (p *natsPublisher) Close() error {
+	// Flushes the pending messages before closing.
+	return p.nc.Drain()
+}
@@ -0,0 +1,152 @@
+package spy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type publishedMessage struct {
+	topic string
+	key   string
+	value []byte
+}
+
+// fakePublisher records the published messages.
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []publishedMessage
+	closed   bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, topic string, key []byte, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, publishedMessage{topic, string(key), value})
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *fakePublisher) published() []publishedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]publishedMessage(nil), p.messages...)
+}
+
+func TestParseSinkFormat(t *testing.T) {
+	f, err := parseSinkFormat("json")
+	require.NoError(t, err)
+	assert.Equal(t, sinkFormatJSON, f)
+	_, err = parseSinkFormat("avro")
+	assert.Error(t, err)
+}
+
+func TestSinkEncode(t *testing.T) {
+	vaaBytes, err := testVAA(vaa.ChainIDSolana, testEmitterA, 1, []byte{1, 2, 3}).Marshal()
+	require.NoError(t, err)
+	o := &gossipv1.SignedObservation{Addr: []byte{1}, MessageId: "1/" + testEmitterA.String() + "/1"}
+
+	for _, format := range []sinkFormat{sinkFormatRaw, sinkFormatJSON, sinkFormatProto} {
+		k := newSink(zap.NewNop(), format, "vaas", "observations", nil)
+
+		key, value, err := k.encode(sinkMessage{vaaBytes: vaaBytes})
+		require.NoError(t, err)
+		assert.Equal(t, "1/"+testEmitterA.String(), string(key))
+		switch format {
+		case sinkFormatRaw:
+			assert.Equal(t, vaaBytes, value)
+		case sinkFormatJSON:
+			var resp spyv1.SubscribeSignedVAAResponse
+			require.NoError(t, protojson.Unmarshal(value, &resp))
+			assert.Equal(t, vaaBytes, resp.VaaBytes)
+		case sinkFormatProto:
+			var resp spyv1.SubscribeSignedVAAResponse
+			require.NoError(t, proto.Unmarshal(value, &resp))
+			assert.Equal(t, vaaBytes, resp.VaaBytes)
+		}
+
+		key, value, err = k.encode(sinkMessage{observation: o})
+		require.NoError(t, err)
+		assert.Equal(t, "1/"+testEmitterA.String(), string(key))
+		switch format {
+		case sinkFormatRaw:
+			var decoded gossipv1.SignedObservation
+			require.NoError(t, proto.Unmarshal(value, &decoded))
+			assert.Equal(t, o.MessageId, decoded.MessageId)
+		case sinkFormatJSON:
+			var resp spyv1.SubscribeSignedObservationsResponse
+			require.NoError(t, protojson.Unmarshal(value, &resp))
+			assert.Equal(t, o.MessageId, resp.Observation.MessageId)
+		case sinkFormatProto:
+			var resp spyv1.SubscribeSignedObservationsResponse
+			require.NoError(t, proto.Unmarshal(value, &resp))
+			assert.Equal(t, "0x0000000000000000000000000000000000000001", resp.GuardianAddress)
+		}
+	}
+
+	_, _, err = newSink(zap.NewNop(), sinkFormatRaw, "vaas", "", nil).encode(sinkMessage{vaaBytes: []byte{1}})
+	assert.Error(t, err)
+}
+
+func TestSinkEnqueue(t *testing.T) {
+	// A nil sink is disabled.
+	var disabled *sink
+	disabled.publishVAA([]byte{1})
+	disabled.publishObservation(&gossipv1.SignedObservation{})
+	assert.False(t, disabled.wantsObservations())
+
+	// Observations are not published without a topic.
+	k := newSink(zap.NewNop(), sinkFormatRaw, "vaas", "", nil)
+	assert.False(t, k.wantsObservations())
+	k.publishObservation(&gossipv1.SignedObservation{})
+	assert.Len(t, k.ch, 0)
+
+	// Messages are dropped once the queue is full.
+	for i := 0; i < sinkBufferSize+1; i++ {
+		k.publishVAA([]byte{1})
+	}
+	assert.Len(t, k.ch, sinkBufferSize)
+}
+
+func TestSinkRun(t *testing.T) {
+	pub := &fakePublisher{}
+	k := newSink(zap.NewNop(), sinkFormatRaw, "vaas", "", func() (sinkPublisher, error) {
+		return pub, nil
+	})
+	s := newSpyServer(zap.NewNop())
+	s.sink = k
+
+	vaaBytes, err := testVAA(vaa.ChainIDSolana, testEmitterA, 1, []byte{1, 2, 3}).Marshal()
+	require.NoError(t, err)
+	require.NoError(t, s.Publish(vaaBytes))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	supervisor.New(ctx, zap.NewNop(), k.run)
+
+	require.Eventually(t, func() bool { return len(pub.published()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, publishedMessage{"vaas", "1/" + testEmitterA.String(), vaaBytes}, pub.published()[0])
+
+	cancel()
+	require.Eventually(t, func() bool {
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		return pub.closed
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -1,24 +1,33 @@
 package spy
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"sync"
+	"time"
 
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/ethereum"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	ipfslog "github.com/ipfs/go-log/v2"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -44,6 +53,33 @@ var (
 	logLevel *string
 
 	spyRPC *string
+
+	spyHTTP      *string
+	vaaCacheSize *int
+
+	dataDir             *string
+	retentionConfigPath *string
+
+	archiveGuardianSet      *string
+	archiveGuardianSetIndex *uint32
+
+	payloadRegistryPath *string
+
+	watchdogMaxMemoryMB   *uint64
+	watchdogMaxOpenFiles  *uint64
+	watchdogMaxGoroutines *uint64
+	watchdogInterval      *time.Duration
+
+	preObservationRPC      *string
+	preObservationChainID  *uint
+	preObservationContract *string
+	preObservationTargets  *[]string
+
+	sinkKafkaBrokers      *[]string
+	sinkNATSURL           *string
+	sinkFormatName        *string
+	sinkVAATopic          *string
+	sinkObservationsTopic *string
 )
 
 func init() {
@@ -58,6 +94,33 @@ func init() {
 	logLevel = SpyCmd.Flags().String("logLevel", "info", "Logging level (debug, info, warn, error, dpanic, panic, fatal)")
 
 	spyRPC = SpyCmd.Flags().String("spyRPC", "", "Listen address for gRPC interface")
+
+	spyHTTP = SpyCmd.Flags().String("spyHTTP", "", "Listen address for the HTTP interface serving VAAs over WebSocket, SSE and REST (disabled if blank)")
+	vaaCacheSize = SpyCmd.Flags().Int("vaaCacheSize", 10000, "Number of recent VAAs kept for lookups over HTTP")
+
+	dataDir = SpyCmd.Flags().String("dataDir", "", "Data directory of the persistent VAA store used for replays (disabled if blank)")
+	watchdogMaxMemoryMB = SpyCmd.Flags().Uint64("watchdogMaxMemoryMB", 0, "Memory used by the spy beyond which subscriptions are shed (0 = unlimited)")
+	watchdogMaxOpenFiles = SpyCmd.Flags().Uint64("watchdogMaxOpenFiles", 0, "Open file descriptors beyond which subscriptions are shed (0 = unlimited)")
+	watchdogMaxGoroutines = SpyCmd.Flags().Uint64("watchdogMaxGoroutines", 0, "Goroutines beyond which subscriptions are shed (0 = unlimited)")
+	watchdogInterval = SpyCmd.Flags().Duration("watchdogInterval", 10*time.Second, "How often the watchdog samples the resource usage")
+
+	preObservationRPC = SpyCmd.Flags().String("preObservationRPC", "", "EVM websocket RPC whose pending transactions are simulated for SubscribePreObservations (disabled if blank)")
+	preObservationChainID = SpyCmd.Flags().Uint("preObservationChainID", uint(vaa.ChainIDEthereum), "Wormhole chain ID of the chain of --preObservationRPC")
+	preObservationContract = SpyCmd.Flags().String("preObservationContract", "", "Core bridge contract address on the chain of --preObservationRPC")
+	preObservationTargets = SpyCmd.Flags().StringSlice("preObservationTargets", nil, "Other contracts whose pending transactions are simulated, such as the token bridge (comma-separated)")
+
+	sinkKafkaBrokers = SpyCmd.Flags().StringSlice("sinkKafkaBrokers", nil, "Kafka brokers to publish signed VAAs and observations to (comma-separated, disabled if empty)")
+	sinkNATSURL = SpyCmd.Flags().String("sinkNATSURL", "", "NATS server URL to publish signed VAAs and observations to (disabled if blank)")
+	sinkFormatName = SpyCmd.Flags().String("sinkFormat", "raw", "Serialization of the messages published to Kafka or NATS (raw, json or proto)")
+	sinkVAATopic = SpyCmd.Flags().String("sinkVAATopic", "wormhole.vaas", "Kafka topic or NATS subject of the signed VAAs (not published if blank)")
+	sinkObservationsTopic = SpyCmd.Flags().String("sinkObservationsTopic", "", "Kafka topic or NATS subject of the signed observations (not published if blank)")
+
+	retentionConfigPath = SpyCmd.Flags().String("retentionConfig", "", "Path to a JSON file configuring how long VAAs are kept in the persistent store (optional, all VAAs are kept by default)")
+
+	archiveGuardianSet = SpyCmd.Flags().String("archiveGuardianSet", "", "Guardian addresses of the initial guardian set (comma-separated). Enables the archive mode, in which only VAAs reaching quorum are stored and the store is listed over the API (disabled if blank)")
+	archiveGuardianSetIndex = SpyCmd.Flags().Uint32("archiveGuardianSetIndex", 0, "Index of the guardian set of --archiveGuardianSet")
+
+	payloadRegistryPath = SpyCmd.Flags().String("payloadRegistry", "", "Path to a JSON file configuring the integrator payloads decoded for the subscriptions requesting decoded payloads (optional)")
 }
 
 // SpyCmd represents the node command
@@ -72,20 +135,111 @@ type spyServer struct {
 	logger *zap.Logger
 	subs   map[string]*subscription
 	subsMu sync.Mutex
+	// Recently received VAAs for lookups over HTTP, nil if disabled.
+	cache *vaaCache
+	// Persistent store for replays and lookups, nil if disabled.
+	db *db.Database
+	// Verifies the VAAs before they are stored and published in archive mode, nil if disabled.
+	archive *archive
+
+	obsSubs observationSubscriptions
+
+	// Whether pending transactions are simulated for SubscribePreObservations.
+	preObservations bool
+	preObsSubs      preObservationSubscriptions
+
+	// Sheds the subscriptions when the spy runs short of resources, nil if disabled.
+	watchdog *watchdog.Watchdog
+
+	// Publishes to Kafka or NATS, nil if disabled.
+	sink *sink
 }
 
 type message struct {
 	vaaBytes []byte
 }
 
-type filter struct {
+// Limits the cost of evaluating the filters of a subscription for each VAA.
+const maxFilterConditions = 1000
+
+var (
+	subscriptionsActive = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_spy_subscriptions",
+			Help: "Number of active SubscribeSignedVAA subscriptions",
+		})
+	subscriptionVAAsMatched = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_subscription_vaas_matched_total",
+			Help: "Total number of VAAs that matched the filters of a subscription and were sent to it",
+		}, []string{"subscription"})
+	subscriptionVAAsFiltered = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_spy_subscription_vaas_filtered_total",
+			Help: "Total number of VAAs that did not match the filters of a subscription",
+		}, []string{"subscription"})
+)
+
+type emitter struct {
 	chainId     vaa.ChainID
 	emitterAddr vaa.Address
 }
 
+// filter matches VAAs satisfying all of its conditions. Conditions left empty match all VAAs.
+type filter struct {
+	chainIds        map[vaa.ChainID]bool
+	emitters        map[emitter]bool
+	minSequence     uint64
+	maxSequence     uint64 // unbounded if 0
+	payloadPrefixes [][]byte
+}
+
+func (f *filter) matches(v *vaa.VAA) bool {
+	if len(f.chainIds) != 0 && !f.chainIds[v.EmitterChain] {
+		return false
+	}
+	if len(f.emitters) != 0 && !f.emitters[emitter{v.EmitterChain, v.EmitterAddress}] {
+		return false
+	}
+	if v.Sequence < f.minSequence || f.maxSequence != 0 && v.Sequence > f.maxSequence {
+		return false
+	}
+	if len(f.payloadPrefixes) != 0 {
+		for _, p := range f.payloadPrefixes {
+			if bytes.HasPrefix(v.Payload, p) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 type subscription struct {
+	id string
+	// A VAA is sent if it matches any of the filters, or if there are none.
 	filters []filter
-	ch      chan message
+	// Whether the payloads of the VAAs sent to the client are decoded, see signedVAAResponse.
+	decodePayloads bool
+	ch             chan message
+	// Closed once the client went away.
+	done chan struct{}
+	// Closed once the subscription must be dropped because the spy is degraded.
+	shed    <-chan struct{}
+	matched prometheus.Counter
+	skipped prometheus.Counter
+}
+
+func (sub *subscription) matches(v *vaa.VAA) bool {
+	if len(sub.filters) == 0 {
+		return true
+	}
+	for i := range sub.filters {
+		if sub.filters[i].matches(v) {
+			return true
+		}
+	}
+	return false
 }
 
 func subscriptionId() string {
@@ -107,78 +261,209 @@ func decodeEmitterAddr(hexAddr string) (vaa.Address, error) {
 	return addr, nil
 }
 
-func (s *spyServer) Publish(vaaBytes []byte) error {
-	s.subsMu.Lock()
-	defer s.subsMu.Unlock()
+func decodeEmitterFilter(f *spyv1.EmitterFilter) (emitter, error) {
+	addr, err := decodeEmitterAddr(f.EmitterAddress)
+	if err != nil {
+		return emitter{}, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode emitter address: %v", err))
+	}
+	return emitter{chainId: vaa.ChainID(f.ChainId), emitterAddr: addr}, nil
+}
 
-	var v *vaa.VAA
+// decodeFilters converts the filters of a request and returns the number of conditions they contain.
+func decodeFilters(entries []*spyv1.FilterEntry) ([]filter, int, error) {
+	var fi []filter
+	conditions := 0
+	for _, f := range entries {
+		switch t := f.Filter.(type) {
+		case *spyv1.FilterEntry_EmitterFilter:
+			e, err := decodeEmitterFilter(t.EmitterFilter)
+			if err != nil {
+				return nil, 0, err
+			}
+			fi = append(fi, filter{emitters: map[emitter]bool{e: true}})
+			conditions++
+		case *spyv1.FilterEntry_MessageFilter:
+			mf := t.MessageFilter
+			if mf.MaxSequence != 0 && mf.MaxSequence < mf.MinSequence {
+				return nil, 0, status.Error(codes.InvalidArgument, "max_sequence must not be less than min_sequence")
+			}
 
-	for _, sub := range s.subs {
-		if len(sub.filters) == 0 {
-			sub.ch <- message{vaaBytes: vaaBytes}
-		} else {
-			if v == nil {
-				var err error
-				v, err = vaa.Unmarshal(vaaBytes)
-				if err != nil {
-					return err
+			nf := filter{
+				minSequence: mf.MinSequence,
+				maxSequence: mf.MaxSequence,
+			}
+			if len(mf.ChainIds) != 0 {
+				nf.chainIds = make(map[vaa.ChainID]bool, len(mf.ChainIds))
+				for _, c := range mf.ChainIds {
+					nf.chainIds[vaa.ChainID(c)] = true
 				}
 			}
-
-			for _, fi := range sub.filters {
-				if fi.chainId == v.EmitterChain && fi.emitterAddr == v.EmitterAddress {
-					sub.ch <- message{vaaBytes: vaaBytes}
+			if len(mf.Emitters) != 0 {
+				nf.emitters = make(map[emitter]bool, len(mf.Emitters))
+				for _, ef := range mf.Emitters {
+					e, err := decodeEmitterFilter(ef)
+					if err != nil {
+						return nil, 0, err
+					}
+					nf.emitters[e] = true
+				}
+			}
+			for _, p := range mf.PayloadPrefixes {
+				if len(p) == 0 {
+					return nil, 0, status.Error(codes.InvalidArgument, "payload prefixes must not be empty")
 				}
+				nf.payloadPrefixes = append(nf.payloadPrefixes, p)
 			}
+
+			fi = append(fi, nf)
+			conditions += 1 + len(mf.ChainIds) + len(mf.Emitters) + len(mf.PayloadPrefixes)
+		default:
+			return nil, 0, status.Error(codes.InvalidArgument, "unsupported filter type")
 		}
 	}
 
-	return nil
+	if conditions > maxFilterConditions {
+		return nil, 0, status.Error(codes.InvalidArgument, fmt.Sprintf("too many filter conditions (%d), at most %d are allowed", conditions, maxFilterConditions))
+	}
+	return fi, conditions, nil
 }
 
-func (s *spyServer) SubscribeSignedVAA(req *spyv1.SubscribeSignedVAARequest, resp spyv1.SpyRPCService_SubscribeSignedVAAServer) error {
-	var fi []filter
-	if req.Filters != nil {
-		for _, f := range req.Filters {
-			switch t := f.Filter.(type) {
-			case *spyv1.FilterEntry_EmitterFilter:
-				addr, err := decodeEmitterAddr(t.EmitterFilter.EmitterAddress)
-				if err != nil {
-					return status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode emitter address: %v", err))
-				}
-				fi = append(fi, filter{
-					chainId:     vaa.ChainID(t.EmitterFilter.ChainId),
-					emitterAddr: addr,
-				})
-			default:
-				return status.Error(codes.InvalidArgument, "unsupported filter type")
+func (s *spyServer) Publish(vaaBytes []byte) error {
+	var v *vaa.VAA
+	if s.cache != nil || s.db != nil {
+		var err error
+		v, err = vaa.Unmarshal(vaaBytes)
+		if err != nil {
+			return err
+		}
+	}
+	// The archive mode requires the persistent store, so the VAA was unmarshaled.
+	if s.archive != nil {
+		if err := s.archive.verify(v); err != nil {
+			archiveVAAs.WithLabelValues("rejected").Inc()
+			s.logger.Debug("rejecting unverified VAA", zap.String("message_id", v.MessageID()), zap.Error(err))
+			return nil
+		}
+		archiveVAAs.WithLabelValues("verified").Inc()
+	}
+	if s.cache != nil {
+		s.cache.add(v.MessageID(), vaaBytes)
+	}
+	// Store before publishing, so that replays do not miss VAAs, see replay. The spy only verifies the signatures in
+	// archive mode, but VAAs without any cannot be stored.
+	if s.db != nil && len(v.Signatures) != 0 {
+		if err := s.db.StoreSignedVAA(v); err != nil {
+			s.logger.Error("failed to store signed VAA", zap.String("message_id", v.MessageID()), zap.Error(err))
+		}
+	}
+	s.sink.publishVAA(vaaBytes)
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	for _, sub := range s.subs {
+		if len(sub.filters) != 0 && v == nil {
+			var err error
+			v, err = vaa.Unmarshal(vaaBytes)
+			if err != nil {
+				return err
 			}
 		}
+
+		if sub.matches(v) {
+			sub.matched.Inc()
+			select {
+			case sub.ch <- message{vaaBytes: vaaBytes}:
+			case <-sub.done:
+			}
+		} else {
+			sub.skipped.Inc()
+		}
 	}
 
+	return nil
+}
+
+// subscribe registers a subscription, which must be removed with unsubscribe once the client went away.
+func (s *spyServer) subscribe(fi []filter, conditions int, transport string) *subscription {
 	s.subsMu.Lock()
 	id := subscriptionId()
 	sub := &subscription{
+		id:      id,
 		ch:      make(chan message, 1),
+		done:    make(chan struct{}),
+		shed:    s.watchdog.Shedding(),
 		filters: fi,
+		matched: subscriptionVAAsMatched.WithLabelValues(id),
+		skipped: subscriptionVAAsFiltered.WithLabelValues(id),
 	}
 	s.subs[id] = sub
 	s.subsMu.Unlock()
+	subscriptionsActive.Inc()
 
-	defer func() {
-		s.subsMu.Lock()
-		defer s.subsMu.Unlock()
-		delete(s.subs, id)
-	}()
+	s.logger.Info("new subscription",
+		zap.String("subscription", id),
+		zap.String("transport", transport),
+		zap.Int("filters", len(fi)),
+		zap.Int("conditions", conditions))
+
+	return sub
+}
+
+// errShed is returned to the clients whose subscriptions were refused or dropped because the spy is degraded.
+var errShed = status.Error(codes.Unavailable, "spy is degraded, retry later")
+
+// checkDegraded returns errShed if new subscriptions must be refused.
+func (s *spyServer) checkDegraded() error {
+	if s.watchdog.Degraded() {
+		return errShed
+	}
+	return nil
+}
+
+func (s *spyServer) unsubscribe(sub *subscription) {
+	// Unblock Publish if it is waiting for us, since it holds the lock.
+	close(sub.done)
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	delete(s.subs, sub.id)
+	subscriptionsActive.Dec()
+	subscriptionVAAsMatched.DeleteLabelValues(sub.id)
+	subscriptionVAAsFiltered.DeleteLabelValues(sub.id)
+}
+
+func (s *spyServer) SubscribeSignedVAA(req *spyv1.SubscribeSignedVAARequest, resp spyv1.SpyRPCService_SubscribeSignedVAAServer) error {
+	fi, conditions, err := decodeFilters(req.Filters)
+	if err != nil {
+		return err
+	}
+	from, err := decodeReplayFrom(req.ReplayFrom)
+	if err != nil {
+		return err
+	}
+	if err := s.checkDegraded(); err != nil {
+		return err
+	}
+
+	sub := s.subscribe(fi, conditions, "grpc")
+	defer s.unsubscribe(sub)
+	sub.decodePayloads = req.DecodePayloads
+
+	if err := s.replay(sub, from, func(b []byte) error {
+		return resp.Send(signedVAAResponse(b, sub.decodePayloads))
+	}); err != nil {
+		return err
+	}
 
 	for {
 		select {
 		case <-resp.Context().Done():
 			return resp.Context().Err()
+		case <-sub.shed:
+			return errShed
 		case msg := <-sub.ch:
-			if err := resp.Send(&spyv1.SubscribeSignedVAAResponse{
-				VaaBytes: msg.vaaBytes,
-			}); err != nil {
+			if err := resp.Send(signedVAAResponse(msg.vaaBytes, sub.decodePayloads)); err != nil {
 				return err
 			}
 		}
@@ -187,8 +472,10 @@ func (s *spyServer) SubscribeSignedVAA(req *spyv1.SubscribeSignedVAARequest, res
 
 func newSpyServer(logger *zap.Logger) *spyServer {
 	return &spyServer{
-		logger: logger.Named("spyserver"),
-		subs:   make(map[string]*subscription),
+		logger:     logger.Named("spyserver"),
+		subs:       make(map[string]*subscription),
+		obsSubs:    observationSubscriptions{subs: make(map[string]*observationSubscription)},
+		preObsSubs: preObservationSubscriptions{subs: make(map[string]*preObservationSubscription)},
 	}
 }
 
@@ -239,6 +526,56 @@ func runSpy(cmd *cobra.Command, args []string) {
 	if *p2pBootstrap == "" {
 		logger.Fatal("Please specify --bootstrap")
 	}
+	if *spyHTTP != "" && *vaaCacheSize <= 0 {
+		logger.Fatal("--vaaCacheSize must be positive")
+	}
+	if *retentionConfigPath != "" && *dataDir == "" {
+		logger.Fatal("--retentionConfig requires --dataDir")
+	}
+	var archiveKeys []eth_common.Address
+	if *archiveGuardianSet != "" {
+		if *dataDir == "" {
+			logger.Fatal("--archiveGuardianSet requires --dataDir")
+		}
+		archiveKeys, err = parseGuardianSetKeys(*archiveGuardianSet)
+		if err != nil {
+			logger.Fatal("invalid --archiveGuardianSet", zap.Error(err))
+		}
+	}
+	if *watchdogInterval <= 0 {
+		logger.Fatal("--watchdogInterval must be positive")
+	}
+	if *preObservationRPC != "" && !eth_common.IsHexAddress(*preObservationContract) {
+		logger.Fatal("--preObservationRPC requires a valid --preObservationContract")
+	}
+	var preObservationTargetAddrs []eth_common.Address
+	for _, t := range *preObservationTargets {
+		if !eth_common.IsHexAddress(t) {
+			logger.Fatal("invalid --preObservationTargets address", zap.String("address", t))
+		}
+		preObservationTargetAddrs = append(preObservationTargetAddrs, eth_common.HexToAddress(t))
+	}
+	if len(*sinkKafkaBrokers) != 0 && *sinkNATSURL != "" {
+		logger.Fatal("--sinkKafkaBrokers and --sinkNATSURL are mutually exclusive")
+	}
+	sinkFormat, err := parseSinkFormat(*sinkFormatName)
+	if err != nil {
+		logger.Fatal("invalid --sinkFormat", zap.Error(err))
+	}
+
+	var retentionPolicy *db.RetentionPolicy
+	if *retentionConfigPath != "" {
+		retentionPolicy, err = db.LoadRetentionPolicy(*retentionConfigPath)
+		if err != nil {
+			logger.Fatal("failed to load retention config", zap.Error(err))
+		}
+	}
+	if *payloadRegistryPath != "" {
+		payloadRegistry, err = vaa.LoadPayloadRegistry(*payloadRegistryPath)
+		if err != nil {
+			logger.Fatal("failed to load payload registry", zap.Error(err))
+		}
+	}
 
 	// Node's main lifecycle context.
 	rootCtx, rootCtxCancel = context.WithCancel(context.Background())
@@ -258,22 +595,91 @@ func runSpy(cmd *cobra.Command, args []string) {
 
 	// RPC server
 	s := newSpyServer(logger)
+
+	// Persistent store
+	if *dataDir != "" {
+		d, err := db.Open(path.Join(*dataDir, "db"))
+		if err != nil {
+			logger.Fatal("failed to open database", zap.Error(err))
+		}
+		defer d.Close()
+		s.db = d
+	}
+	if archiveKeys != nil {
+		s.archive = newArchive(logger, *archiveGuardianSetIndex, archiveKeys)
+		if err := s.archive.load(s.db); err != nil {
+			logger.Fatal("failed to load the guardian set upgrades", zap.Error(err))
+		}
+		logger.Info("archive mode enabled", zap.Uint32("guardian_set_index", s.archive.latest))
+	}
+	watchdogLimits := watchdog.Limits{
+		MemoryBytes: *watchdogMaxMemoryMB << 20,
+		OpenFiles:   *watchdogMaxOpenFiles,
+		Goroutines:  *watchdogMaxGoroutines,
+	}
+	if watchdogLimits.Enabled() {
+		s.watchdog = watchdog.New(logger, watchdogLimits, *dataDir, *watchdogInterval)
+	}
+
 	rpcSvc, _, err := spyServerRunnable(s, logger, *spyRPC)
 	if err != nil {
 		logger.Fatal("failed to start RPC server", zap.Error(err))
 	}
 
-	// Ignore observations
+	// Broker sink
+	switch {
+	case len(*sinkKafkaBrokers) != 0:
+		brokers := *sinkKafkaBrokers
+		s.sink = newSink(logger, sinkFormat, *sinkVAATopic, *sinkObservationsTopic, func() (sinkPublisher, error) {
+			return newKafkaPublisher(logger, brokers), nil
+		})
+	case *sinkNATSURL != "":
+		url := *sinkNATSURL
+		s.sink = newSink(logger, sinkFormat, *sinkVAATopic, *sinkObservationsTopic, func() (sinkPublisher, error) {
+			return newNATSPublisher(logger, url)
+		})
+	}
+
+	// HTTP server
+	var httpSvc supervisor.Runnable
+	if *spyHTTP != "" {
+		s.cache = newVAACache(*vaaCacheSize)
+		httpSvc = spyHTTPRunnable(s, logger, *spyHTTP)
+	}
+
+	// Stream observations
 	go func() {
 		for {
 			select {
 			case <-rootCtx.Done():
 				return
-			case <-obsvC:
+			case o := <-obsvC:
+				s.PublishObservation(o)
 			}
 		}
 	}()
 
+	// Stream pre-observations
+	var preObservationWatcher *ethereum.PreObservationWatcher
+	if *preObservationRPC != "" {
+		chainID := vaa.ChainID(*preObservationChainID)
+		preObsvC := make(chan *common.MessagePublication, 1000)
+		preObservationWatcher = ethereum.NewPreObservationWatcher(*preObservationRPC, eth_common.HexToAddress(*preObservationContract),
+			chainID.String(), chainID, preObservationTargetAddrs, preObsvC)
+		s.preObservations = true
+
+		go func() {
+			for {
+				select {
+				case <-rootCtx.Done():
+					return
+				case m := <-preObsvC:
+					s.PublishPreObservation(m)
+				}
+			}
+		}()
+	}
+
 	// Log signed VAAs
 	go func() {
 		for {
@@ -299,7 +705,7 @@ func runSpy(cmd *cobra.Command, args []string) {
 
 	// Run supervisor.
 	supervisor.New(rootCtx, logger, func(ctx context.Context) error {
-		if err := supervisor.Run(ctx, "p2p", p2p.Run(obsvC, nil, nil, sendC, signedInC, priv, nil, gst, *p2pPort, *p2pNetworkID, *p2pBootstrap, "", false, rootCtxCancel, nil)); err != nil {
+		if err := supervisor.Run(ctx, "p2p", p2p.Run(obsvC, nil, nil, sendC, signedInC, priv, nil, nil, nil, gst, p2p.DefaultListenConfig(*p2pPort), *p2pNetworkID, *p2pBootstrap, "", false, false, rootCtxCancel, nil)); err != nil {
 			return err
 		}
 
@@ -307,6 +713,36 @@ func runSpy(cmd *cobra.Command, args []string) {
 			return err
 		}
 
+		if retentionPolicy != nil {
+			if err := supervisor.Run(ctx, "db-retention", s.db.RunRetention(logger, retentionPolicy)); err != nil {
+				return err
+			}
+		}
+
+		if httpSvc != nil {
+			if err := supervisor.Run(ctx, "spyhttp", httpSvc); err != nil {
+				return err
+			}
+		}
+
+		if preObservationWatcher != nil {
+			if err := supervisor.Run(ctx, "preobservations", preObservationWatcher.Run); err != nil {
+				return err
+			}
+		}
+
+		if s.sink != nil {
+			if err := supervisor.Run(ctx, "sink", s.sink.run); err != nil {
+				return err
+			}
+		}
+
+		if s.watchdog != nil {
+			if err := supervisor.Run(ctx, "watchdog", s.watchdog.Runnable()); err != nil {
+				return err
+			}
+		}
+
 		logger.Info("Started internal services")
 
 		<-ctx.Done()
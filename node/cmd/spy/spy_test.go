@@ -0,0 +1,88 @@
+package spy
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testEmitterA = vaa.Address{1}
+	testEmitterB = vaa.Address{2}
+)
+
+func testVAA(chain vaa.ChainID, emitter vaa.Address, seq uint64, payload []byte) *vaa.VAA {
+	return &vaa.VAA{
+		Version:        vaa.SupportedVAAVersion,
+		Timestamp:      time.Unix(1660000000, 0),
+		EmitterChain:   chain,
+		EmitterAddress: emitter,
+		Sequence:       seq,
+		Payload:        payload,
+	}
+}
+
+func TestFilters(t *testing.T) {
+	fi, conditions, err := decodeFilters([]*spyv1.FilterEntry{
+		{Filter: &spyv1.FilterEntry_EmitterFilter{EmitterFilter: &spyv1.EmitterFilter{
+			ChainId:        publicrpcv1.ChainID_CHAIN_ID_SOLANA,
+			EmitterAddress: hex.EncodeToString(testEmitterA[:]),
+		}}},
+		{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: &spyv1.MessageFilter{
+			ChainIds:        []publicrpcv1.ChainID{publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, publicrpcv1.ChainID_CHAIN_ID_BSC},
+			MinSequence:     10,
+			MaxSequence:     20,
+			PayloadPrefixes: [][]byte{{0x01}, {0x03}},
+		}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 6, conditions)
+	sub := &subscription{filters: fi}
+
+	tests := []struct {
+		name string
+		v    *vaa.VAA
+		want bool
+	}{
+		{"emitter", testVAA(vaa.ChainIDSolana, testEmitterA, 1, nil), true},
+		{"other emitter", testVAA(vaa.ChainIDSolana, testEmitterB, 1, nil), false},
+		{"chain, sequence and prefix", testVAA(vaa.ChainIDBSC, testEmitterB, 15, []byte{0x03, 0xff}), true},
+		{"sequence bounds are inclusive", testVAA(vaa.ChainIDEthereum, testEmitterB, 20, []byte{0x01}), true},
+		{"other chain", testVAA(vaa.ChainIDTerra, testEmitterB, 15, []byte{0x01}), false},
+		{"sequence too low", testVAA(vaa.ChainIDEthereum, testEmitterB, 9, []byte{0x01}), false},
+		{"sequence too high", testVAA(vaa.ChainIDEthereum, testEmitterB, 21, []byte{0x01}), false},
+		{"other prefix", testVAA(vaa.ChainIDEthereum, testEmitterB, 15, []byte{0x02}), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, sub.matches(tc.v))
+		})
+	}
+
+	assert.True(t, (&subscription{}).matches(testVAA(vaa.ChainIDSolana, testEmitterB, 1, nil)))
+}
+
+func TestDecodeFiltersInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*spyv1.FilterEntry
+	}{
+		{"bad emitter", []*spyv1.FilterEntry{{Filter: &spyv1.FilterEntry_EmitterFilter{EmitterFilter: &spyv1.EmitterFilter{EmitterAddress: "00"}}}}},
+		{"inverted sequence range", []*spyv1.FilterEntry{{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: &spyv1.MessageFilter{MinSequence: 2, MaxSequence: 1}}}}},
+		{"empty prefix", []*spyv1.FilterEntry{{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: &spyv1.MessageFilter{PayloadPrefixes: [][]byte{{}}}}}}},
+		{"too many conditions", []*spyv1.FilterEntry{{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: &spyv1.MessageFilter{ChainIds: make([]publicrpcv1.ChainID, maxFilterConditions)}}}}},
+		{"no filter", []*spyv1.FilterEntry{{}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := decodeFilters(tc.entries)
+			assert.Error(t, err)
+		})
+	}
+}
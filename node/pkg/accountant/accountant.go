@@ -0,0 +1,339 @@
+// The purpose of the accountant is to make sure the token bridge can never release more of a token on a chain than it holds.
+// It works by tracking the balance of each token on each chain, based on the token bridge transfers (types one and three) observed by this guardian.
+//
+// On the origin chain of a token, the balance is the amount locked in custody. A transfer out of the origin chain increases it,
+// and a transfer back to the origin chain decreases it. On any other chain, the balance is the wrapped supply. A transfer to that chain
+// increases it, and a transfer out of that chain decreases it.
+//
+// Before this guardian signs a transfer, the accountant computes the resulting balances. If any of them would become negative, the
+// transfer is refused and will not be signed. If the accountant is running in log only mode, the transfer is logged but still signed.
+//
+// The accountant runs entirely locally. It does not submit observations to wormchain, which makes it usable by forks and private
+// deployments that do not run wormchain. Because the balances are based on the transfers observed by this guardian, rather than on the
+// transfers that reached quorum, they should be seen as an upper bound on the actual balances.
+//
+// Each transfer is only accounted for once. The message ID and a digest of the payload are persisted in the Badger DB along with the
+// updated balances, so a re-observation of the same message is signed again without being counted twice, while a different payload
+// for an already accounted message ID is refused.
+//
+// Transfers from emitters other than the token bridge, such as NTT managers, can be accounted for in separate ledgers, see accountant_ledgers.go.
+//
+// The ledger can be reconciled against the custody balances on chain, see accountant_reconciliation.go, and corrected by governance,
+// see accountant_modify_balance.go.
+//
+// To enable the accountant, you must specify the --accountantEnabled guardiand command line argument.
+
+package accountant
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"go.uber.org/zap"
+)
+
+const (
+	MainNetMode = 1
+	TestNetMode = 2
+	DevNetMode  = 3
+	GoTestMode  = 4
+)
+
+// Key to the map of balances.
+type balanceKey struct {
+	ledger       string
+	chain        vaa.ChainID
+	tokenChain   vaa.ChainID
+	tokenAddress vaa.Address
+}
+
+type Accountant struct {
+	db          db.AccountantDB
+	logger      *zap.Logger
+	mutex       sync.Mutex
+	ledgers     map[emitterKey]*ledger
+	tokenBridge *ledger // The ledger for the token bridge emitters, which is the only one that is reconciled.
+	balances    map[balanceKey]*big.Int
+	queriers    map[vaa.ChainID]CustodyQuerier
+	logOnly     bool
+	env         int
+	refused     uint64 // Number of transfers refused since startup.
+}
+
+var (
+	// guardian_accountant_transfers_approved_total 0
+	metricTransfersApproved = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "guardian_accountant_transfers_approved_total",
+			Help: "Accountant number of token bridge transfers accounted for and approved for signing",
+		})
+
+	// guardian_accountant_transfers_rejected_total{reason="insufficient_balance"} 0
+	metricTransfersRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "guardian_accountant_transfers_rejected_total",
+			Help: "Accountant number of token bridge transfers that were refused (or would have been refused in log only mode)",
+		}, []string{"reason"})
+)
+
+func NewAccountant(
+	logger *zap.Logger,
+	db db.AccountantDB,
+	env int,
+	logOnly bool,
+) *Accountant {
+	return &Accountant{
+		db:       db,
+		logger:   logger,
+		ledgers:  make(map[emitterKey]*ledger),
+		balances: make(map[balanceKey]*big.Int),
+		queriers: make(map[vaa.ChainID]CustodyQuerier),
+		logOnly:  logOnly,
+		env:      env,
+	}
+}
+
+func (acct *Accountant) Run(ctx context.Context) error {
+	acct.logger.Info("acct: starting accountant", zap.Bool("logOnly", acct.logOnly))
+
+	if err := acct.initConfig(); err != nil {
+		return err
+	}
+
+	if acct.env != GoTestMode {
+		if err := acct.loadFromDB(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (acct *Accountant) initConfig() error {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	emitterMap := common.KnownTokenbridgeEmitters
+	if acct.env == TestNetMode {
+		emitterMap = common.KnownTestnetTokenbridgeEmitters
+	} else if acct.env == DevNetMode {
+		emitterMap = common.KnownDevnetTokenbridgeEmitters
+	}
+
+	l := &ledger{name: TokenBridgeLedger, format: FormatTokenBridge, emitters: make(map[vaa.ChainID]vaa.Address)}
+	for chainID, emitterAddrBytes := range emitterMap {
+		emitterAddr, err := vaa.BytesToAddress(emitterAddrBytes)
+		if err != nil {
+			return fmt.Errorf("failed to convert token bridge emitter address for chain: %v", chainID)
+		}
+
+		l.emitters[chainID] = emitterAddr
+	}
+
+	acct.tokenBridge = l
+	return acct.addLedgerAlreadyLocked(l)
+}
+
+func (acct *Accountant) loadFromDB() error {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	balances, err := acct.db.GetAccountantBalances()
+	if err != nil {
+		acct.logger.Error("acct: failed to reload balances from db", zap.Error(err))
+		return err
+	}
+
+	for _, b := range balances {
+		acct.balances[balanceKey{ledger: ledgerFromDB(b.Ledger), chain: b.Chain, tokenChain: b.TokenChain, tokenAddress: b.TokenAddress}] = b.Amount
+	}
+
+	acct.logger.Info("acct: reloaded balances from db", zap.Int("numBalances", len(balances)))
+	return nil
+}
+
+// Returns true if the message can be signed, false if it should be dropped.
+func (acct *Accountant) ProcessMsg(msg *common.MessagePublication) bool {
+	ok, err := acct.processMsg(msg)
+	if err != nil {
+		acct.logger.Error("acct: failed to process message", zap.String("msgID", string(msg.MessageID())), zap.Error(err))
+		ok = acct.logOnly
+	}
+
+	if !ok {
+		acct.mutex.Lock()
+		acct.refused++
+		acct.mutex.Unlock()
+	}
+
+	return ok
+}
+
+// Status returns a summary of the state of the accountant.
+func (acct *Accountant) Status() *nodev1.NodeStatusResponse_Accountant {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	ledgers := make(map[string]struct{})
+	for _, l := range acct.ledgers {
+		ledgers[l.name] = struct{}{}
+	}
+
+	return &nodev1.NodeStatusResponse_Accountant{
+		Enabled:          true,
+		LogOnly:          acct.logOnly,
+		Ledgers:          uint32(len(ledgers)),
+		Balances:         uint32(len(acct.balances)),
+		RefusedTransfers: acct.refused,
+	}
+}
+
+func (acct *Accountant) processMsg(msg *common.MessagePublication) (bool, error) {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	// Only transfers from the registered emitters are accounted for.
+	l, exists := acct.ledgers[emitterKey{chain: msg.EmitterChain, addr: msg.EmitterAddress}]
+	if !exists {
+		return true, nil
+	}
+
+	payload, err := l.decodeTransfer(msg.Payload)
+	if err != nil {
+		metricTransfersRejected.WithLabelValues("invalid_payload").Inc()
+		return false, err
+	}
+
+	if payload == nil {
+		return true, nil
+	}
+
+	msgID := string(msg.MessageID())
+	digest := crypto.Keccak256(msg.Payload)
+
+	prevDigest, err := acct.db.GetAccountantTransferDigest(msgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up transfer: %w", err)
+	}
+
+	if prevDigest != nil {
+		if bytes.Equal(prevDigest, digest) {
+			acct.logger.Info("acct: transfer has already been accounted for", zap.String("msgID", msgID))
+			return true, nil
+		}
+
+		metricTransfersRejected.WithLabelValues("digest_mismatch").Inc()
+		acct.logger.Error("acct: transfer has already been accounted for with a different payload",
+			zap.String("msgID", msgID),
+			zap.String("prevDigest", fmt.Sprintf("%x", prevDigest)),
+			zap.String("digest", fmt.Sprintf("%x", digest)),
+		)
+		return acct.logOnly, nil
+	}
+
+	srcKey := balanceKey{ledger: l.name, chain: msg.EmitterChain, tokenChain: payload.tokenChain, tokenAddress: payload.tokenAddress}
+	dstKey := balanceKey{ledger: l.name, chain: payload.targetChain, tokenChain: payload.tokenChain, tokenAddress: payload.tokenAddress}
+
+	newBalances := make(map[balanceKey]*big.Int)
+	overdrawn := false
+
+	// On the emitter chain, tokens either get locked in custody or the wrapped tokens get burned.
+	newBalances[srcKey] = new(big.Int).Set(acct.balance(srcKey))
+	if msg.EmitterChain == payload.tokenChain {
+		newBalances[srcKey].Add(newBalances[srcKey], payload.amount)
+	} else {
+		newBalances[srcKey].Sub(newBalances[srcKey], payload.amount)
+		overdrawn = overdrawn || newBalances[srcKey].Sign() < 0
+	}
+
+	// On the target chain, tokens either get released from custody or wrapped tokens get minted.
+	if _, exists := newBalances[dstKey]; !exists {
+		newBalances[dstKey] = new(big.Int).Set(acct.balance(dstKey))
+	}
+	if payload.targetChain == payload.tokenChain {
+		newBalances[dstKey].Sub(newBalances[dstKey], payload.amount)
+		overdrawn = overdrawn || newBalances[dstKey].Sign() < 0
+	} else {
+		newBalances[dstKey].Add(newBalances[dstKey], payload.amount)
+	}
+
+	if overdrawn {
+		metricTransfersRejected.WithLabelValues("insufficient_balance").Inc()
+		acct.logger.Error("acct: transfer would overdraw the balance of a chain",
+			zap.String("msgID", msgID),
+			zap.String("ledger", l.name),
+			zap.Stringer("emitterChain", msg.EmitterChain),
+			zap.Stringer("targetChain", payload.targetChain),
+			zap.Stringer("tokenChain", payload.tokenChain),
+			zap.Stringer("tokenAddress", payload.tokenAddress),
+			zap.Stringer("amount", payload.amount),
+			zap.Stringer("srcBalance", acct.balance(srcKey)),
+			zap.Stringer("dstBalance", acct.balance(dstKey)),
+			zap.Bool("logOnly", acct.logOnly),
+		)
+
+		if !acct.logOnly {
+			return false, nil
+		}
+	}
+
+	dbBalances := make([]*db.AccountantBalance, 0, len(newBalances))
+	for key, amount := range newBalances {
+		dbBalances = append(dbBalances, &db.AccountantBalance{Ledger: ledgerToDB(key.ledger), Chain: key.chain, TokenChain: key.tokenChain, TokenAddress: key.tokenAddress, Amount: amount})
+	}
+
+	if err := acct.db.StoreAccountantTransfer(msgID, digest, dbBalances); err != nil {
+		return false, err
+	}
+
+	for key, amount := range newBalances {
+		acct.balances[key] = amount
+	}
+
+	metricTransfersApproved.Inc()
+	acct.logger.Info("acct: transfer accounted for",
+		zap.String("msgID", msgID),
+		zap.String("ledger", l.name),
+		zap.Stringer("emitterChain", msg.EmitterChain),
+		zap.Stringer("targetChain", payload.targetChain),
+		zap.Stringer("tokenChain", payload.tokenChain),
+		zap.Stringer("tokenAddress", payload.tokenAddress),
+		zap.Stringer("amount", payload.amount),
+	)
+
+	return true, nil
+}
+
+// Returns the current balance, which is zero if the token has never been seen on the chain. Assumes the lock is held.
+func (acct *Accountant) balance(key balanceKey) *big.Int {
+	if amount, exists := acct.balances[key]; exists {
+		return amount
+	}
+	return big.NewInt(0)
+}
+
+// The token bridge ledger is stored without a name, so balances persisted before other ledgers were supported are still valid.
+func ledgerToDB(name string) string {
+	if name == TokenBridgeLedger {
+		return ""
+	}
+	return name
+}
+
+func ledgerFromDB(name string) string {
+	if name == "" {
+		return TokenBridgeLedger
+	}
+	return name
+}
@@ -0,0 +1,276 @@
+// This file contains the code to account for transfers from emitters other than the token bridge.
+//
+// The accountant keeps a separate ledger per group of emitters. The token bridge emitters always make up the tokenbridge ledger.
+// Additional ledgers can be registered using a JSON configuration file, specified with the --accountantEmitterConfig guardiand
+// command line argument. Each entry in the file defines a ledger, the payload format to decode and the emitter on each chain:
+//
+//	[
+//	  {
+//	    "ledger": "example-ntt",
+//	    "format": "ntt",
+//	    "hubChain": "ethereum",
+//	    "emitters": {
+//	      "ethereum": "0x000000000000000000000000aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+//	      "bsc": "0x000000000000000000000000bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+//	    }
+//	  }
+//	]
+//
+// The following payload formats are supported:
+//   - tokenbridge: token bridge transfers (payload types one and three), for example from a second token bridge deployment.
+//     The origin chain of each token is taken from the payload.
+//   - ntt: native token transfers sent through the Wormhole transceiver of an NTT manager. An NTT deployment manages a single
+//     token, which is locked on the hub chain and minted and burned on all other chains. Each chain is identified by its
+//     transceiver emitter, and the token is identified by the hub chain emitter.
+//
+// Messages from a registered emitter that are not transfers in the configured format are not accounted for and are signed as usual.
+
+package accountant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// The name of the ledger for the token bridge emitters.
+	TokenBridgeLedger = "tokenbridge"
+
+	FormatTokenBridge = "tokenbridge"
+	FormatNTT         = "ntt"
+)
+
+type (
+	// EmitterConfig is the layout of an entry in the emitter configuration file.
+	EmitterConfig struct {
+		Ledger   string            `json:"ledger"`
+		Format   string            `json:"format"`
+		HubChain string            `json:"hubChain"`
+		Emitters map[string]string `json:"emitters"`
+	}
+
+	// Key to the map of ledgers by emitter.
+	emitterKey struct {
+		chain vaa.ChainID
+		addr  vaa.Address
+	}
+
+	// A group of emitters whose transfers are accounted for together.
+	ledger struct {
+		name     string
+		format   string
+		hubChain vaa.ChainID
+		emitters map[vaa.ChainID]vaa.Address
+	}
+
+	// A message that moves tokens between chains, decoded from one of the supported payload formats.
+	transfer struct {
+		tokenChain   vaa.ChainID
+		tokenAddress vaa.Address
+		targetChain  vaa.ChainID
+		amount       *big.Int
+	}
+)
+
+// LoadEmitterConfigs reads the emitter configuration file.
+func LoadEmitterConfigs(path string) ([]EmitterConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read emitter config: %w", err)
+	}
+
+	var configs []EmitterConfig
+	if err := json.Unmarshal(b, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse emitter config: %w", err)
+	}
+
+	return configs, nil
+}
+
+// AddLedger registers a ledger for additional emitters. It should be called before the accountant is started.
+func (acct *Accountant) AddLedger(cfg EmitterConfig) error {
+	if cfg.Ledger == "" || cfg.Ledger == TokenBridgeLedger {
+		return fmt.Errorf("invalid ledger name: \"%s\"", cfg.Ledger)
+	}
+
+	if cfg.Format != FormatTokenBridge && cfg.Format != FormatNTT {
+		return fmt.Errorf("ledger %s has unsupported payload format: \"%s\"", cfg.Ledger, cfg.Format)
+	}
+
+	if len(cfg.Emitters) == 0 {
+		return fmt.Errorf("ledger %s has no emitters", cfg.Ledger)
+	}
+
+	l := &ledger{name: cfg.Ledger, format: cfg.Format, emitters: make(map[vaa.ChainID]vaa.Address)}
+	for chainStr, addrStr := range cfg.Emitters {
+		chainID, err := parseChainID(chainStr)
+		if err != nil {
+			return fmt.Errorf("ledger %s has invalid emitter chain \"%s\": %w", cfg.Ledger, chainStr, err)
+		}
+
+		addr, err := vaa.StringToAddress(addrStr)
+		if err != nil {
+			return fmt.Errorf("ledger %s has invalid emitter address \"%s\": %w", cfg.Ledger, addrStr, err)
+		}
+
+		l.emitters[chainID] = addr
+	}
+
+	if cfg.Format == FormatNTT {
+		hubChain, err := parseChainID(cfg.HubChain)
+		if err != nil {
+			return fmt.Errorf("ledger %s has invalid hub chain \"%s\": %w", cfg.Ledger, cfg.HubChain, err)
+		}
+
+		if _, exists := l.emitters[hubChain]; !exists {
+			return fmt.Errorf("ledger %s has no emitter on its hub chain %v", cfg.Ledger, hubChain)
+		}
+
+		l.hubChain = hubChain
+	}
+
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+	return acct.addLedgerAlreadyLocked(l)
+}
+
+// Adds a ledger to the map of ledgers by emitter. Assumes the lock is held.
+func (acct *Accountant) addLedgerAlreadyLocked(l *ledger) error {
+	for chainID, addr := range l.emitters {
+		key := emitterKey{chain: chainID, addr: addr}
+		if existing, exists := acct.ledgers[key]; exists {
+			return fmt.Errorf("emitter %v/%v is already registered for ledger %s", chainID, addr, existing.name)
+		}
+	}
+
+	for chainID, addr := range l.emitters {
+		acct.ledgers[emitterKey{chain: chainID, addr: addr}] = l
+		acct.logger.Info("acct: will monitor emitter", zap.String("ledger", l.name), zap.String("format", l.format), zap.Stringer("emitterChain", chainID), zap.Stringer("emitterAddr", addr))
+	}
+
+	return nil
+}
+
+func parseChainID(s string) (vaa.ChainID, error) {
+	if chainID, err := vaa.ChainIDFromString(s); err == nil {
+		return chainID, nil
+	}
+
+	i, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return vaa.ChainIDUnset, fmt.Errorf("failed to parse as name or uint16: %v", err)
+	}
+
+	return vaa.ChainID(i), nil
+}
+
+// Decodes a transfer from the payload, using the format of the ledger. Returns nil if the payload is not a transfer.
+func (l *ledger) decodeTransfer(payload []byte) (*transfer, error) {
+	if l.format == FormatNTT {
+		return l.decodeNTTTransfer(payload)
+	}
+
+	if !vaa.IsTransfer(payload) {
+		return nil, nil
+	}
+
+	hdr, err := vaa.DecodeTransferPayloadHdr(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode transfer payload: %w", err)
+	}
+
+	return &transfer{tokenChain: hdr.OriginChain, tokenAddress: hdr.OriginAddress, targetChain: hdr.TargetChain, amount: hdr.Amount}, nil
+}
+
+var (
+	// The prefix of a message sent by the Wormhole transceiver of an NTT manager.
+	nttTransceiverPrefix = []byte{0x99, 0x45, 0xFF, 0x10}
+
+	// The prefix of a native token transfer within an NTT manager message.
+	nttTransferPrefix = []byte{0x99, 0x4E, 0x54, 0x54}
+)
+
+// Decodes a native token transfer. The payload is a transceiver message wrapping an NTT manager message, which in turn wraps the transfer:
+//
+//	transceiver message: prefix [4], source manager [32], recipient manager [32], manager payload length [2], manager payload, ...
+//	manager message:     id [32], sender [32], payload length [2], payload
+//	transfer:            prefix [4], decimals [1], amount [8], source token [32], recipient [32], recipient chain [2]
+func (l *ledger) decodeNTTTransfer(payload []byte) (*transfer, error) {
+	reader := bytes.NewReader(payload)
+
+	if !readPrefix(reader, nttTransceiverPrefix) {
+		return nil, nil
+	}
+
+	// Skip the source and recipient manager addresses.
+	if _, err := reader.Seek(64, 1); err != nil {
+		return nil, fmt.Errorf("failed to skip manager addresses: %w", err)
+	}
+
+	var managerPayloadLen uint16
+	if err := binary.Read(reader, binary.BigEndian, &managerPayloadLen); err != nil {
+		return nil, fmt.Errorf("failed to read manager payload length: %w", err)
+	}
+
+	// Skip the message id and sender.
+	if _, err := reader.Seek(64, 1); err != nil {
+		return nil, fmt.Errorf("failed to skip manager message header: %w", err)
+	}
+
+	var transferLen uint16
+	if err := binary.Read(reader, binary.BigEndian, &transferLen); err != nil {
+		return nil, fmt.Errorf("failed to read manager message payload length: %w", err)
+	}
+
+	if !readPrefix(reader, nttTransferPrefix) {
+		return nil, nil
+	}
+
+	var decimals uint8
+	if err := binary.Read(reader, binary.BigEndian, &decimals); err != nil {
+		return nil, fmt.Errorf("failed to read decimals: %w", err)
+	}
+
+	var amount uint64
+	if err := binary.Read(reader, binary.BigEndian, &amount); err != nil {
+		return nil, fmt.Errorf("failed to read amount: %w", err)
+	}
+
+	// Skip the source token and the recipient.
+	if _, err := reader.Seek(64, 1); err != nil {
+		return nil, fmt.Errorf("failed to skip token and recipient: %w", err)
+	}
+
+	var targetChain vaa.ChainID
+	if err := binary.Read(reader, binary.BigEndian, &targetChain); err != nil {
+		return nil, fmt.Errorf("failed to read recipient chain: %w", err)
+	}
+
+	// Amounts are tracked with eight decimals, the same as the token bridge.
+	normalized := new(big.Int).SetUint64(amount)
+	if decimals < 8 {
+		normalized.Mul(normalized, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(8-decimals)), nil))
+	} else {
+		normalized = normalizeAmount(normalized, decimals)
+	}
+
+	return &transfer{tokenChain: l.hubChain, tokenAddress: l.emitters[l.hubChain], targetChain: targetChain, amount: normalized}, nil
+}
+
+// Returns true if the reader starts with the prefix, consuming it.
+func readPrefix(reader *bytes.Reader, prefix []byte) bool {
+	buf := make([]byte, len(prefix))
+	if n, err := reader.Read(buf); err != nil || n != len(prefix) {
+		return false
+	}
+	return bytes.Equal(buf, prefix)
+}
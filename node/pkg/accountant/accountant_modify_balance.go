@@ -0,0 +1,141 @@
+// This file contains the support for the accountant modify balance governance message, which corrects the balance of a token
+// in the token bridge ledger, for instance after a transfer was missed or accounted for with an invalid payload.
+//
+// A modification is applied once the governance VAA reaches quorum, whether it was signed by this guardian or received over
+// gossip. Like transfers, each modification is only applied once. Its sequence number and a digest of the payload are persisted
+// along with the updated balance, so a modification with a sequence number that has already been applied is ignored.
+//
+// A modification that would make a balance negative is refused, even in log only mode.
+
+package accountant
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"go.uber.org/zap"
+)
+
+// ModificationPreview is the effect a modify balance governance message would have on the token bridge ledger.
+type ModificationPreview struct {
+	BalanceBefore  *big.Int
+	BalanceAfter   *big.Int
+	AlreadyApplied bool
+}
+
+// The message ID under which a modification is persisted, so that it cannot collide with the ID of a transfer.
+func modificationMsgID(sequence uint64) string {
+	return fmt.Sprintf("modify-balance/%d", sequence)
+}
+
+// Returns the balance resulting from a modification. Assumes the lock is held.
+func (acct *Accountant) modifiedBalance(key balanceKey, m *vaa.BodyAccountantModifyBalance) (*big.Int, error) {
+	balance := new(big.Int).Set(acct.balance(key))
+	switch m.Kind {
+	case vaa.ModificationKindAdd:
+		balance.Add(balance, m.Amount)
+	case vaa.ModificationKindSubtract:
+		balance.Sub(balance, m.Amount)
+	default:
+		return nil, fmt.Errorf("invalid modification kind: %v", m.Kind)
+	}
+	return balance, nil
+}
+
+// PreviewModification returns the effect a modification would have on the token bridge ledger, without applying it.
+func (acct *Accountant) PreviewModification(m *vaa.BodyAccountantModifyBalance) (*ModificationPreview, error) {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	key := balanceKey{ledger: TokenBridgeLedger, chain: m.ChainID, tokenChain: m.TokenChain, tokenAddress: m.TokenAddress}
+	after, err := acct.modifiedBalance(key, m)
+	if err != nil {
+		return nil, err
+	}
+
+	prevDigest, err := acct.db.GetAccountantTransferDigest(modificationMsgID(m.Sequence))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up modification: %w", err)
+	}
+
+	return &ModificationPreview{
+		BalanceBefore:  new(big.Int).Set(acct.balance(key)),
+		BalanceAfter:   after,
+		AlreadyApplied: prevDigest != nil,
+	}, nil
+}
+
+// ProcessGovernanceVAA applies the modify balance governance messages that reached quorum. Other VAAs are ignored.
+func (acct *Accountant) ProcessGovernanceVAA(v *vaa.VAA) {
+	if v.EmitterChain != vaa.GovernanceChain || v.EmitterAddress != vaa.GovernanceEmitter {
+		return
+	}
+
+	if len(v.Payload) < len(vaa.AccountantModule) || !bytes.Equal(v.Payload[:len(vaa.AccountantModule)], vaa.AccountantModule) {
+		return
+	}
+
+	m, err := vaa.DeserializeAccountantModifyBalance(v.Payload)
+	if err != nil {
+		acct.logger.Error("acct: failed to decode governance message", zap.String("msgID", v.MessageID()), zap.Error(err))
+		return
+	}
+
+	if err := acct.applyModification(m, crypto.Keccak256(v.Payload)); err != nil {
+		acct.logger.Error("acct: failed to apply balance modification", zap.Uint64("sequence", m.Sequence), zap.Error(err))
+	}
+}
+
+func (acct *Accountant) applyModification(m *vaa.BodyAccountantModifyBalance, digest []byte) error {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+
+	msgID := modificationMsgID(m.Sequence)
+	prevDigest, err := acct.db.GetAccountantTransferDigest(msgID)
+	if err != nil {
+		return fmt.Errorf("failed to look up modification: %w", err)
+	}
+
+	if prevDigest != nil {
+		if !bytes.Equal(prevDigest, digest) {
+			return fmt.Errorf("a different modification with sequence %d has already been applied", m.Sequence)
+		}
+		acct.logger.Info("acct: balance modification has already been applied", zap.Uint64("sequence", m.Sequence))
+		return nil
+	}
+
+	key := balanceKey{ledger: TokenBridgeLedger, chain: m.ChainID, tokenChain: m.TokenChain, tokenAddress: m.TokenAddress}
+	balance, err := acct.modifiedBalance(key, m)
+	if err != nil {
+		return err
+	}
+
+	if balance.Sign() < 0 {
+		return fmt.Errorf("modification would make the balance negative: %v - %v", acct.balance(key), m.Amount)
+	}
+
+	dbBalance := &db.AccountantBalance{Ledger: ledgerToDB(key.ledger), Chain: key.chain, TokenChain: key.tokenChain, TokenAddress: key.tokenAddress, Amount: balance}
+	if err := acct.db.StoreAccountantTransfer(msgID, digest, []*db.AccountantBalance{dbBalance}); err != nil {
+		return err
+	}
+
+	acct.logger.Info("acct: applied balance modification",
+		zap.Uint64("sequence", m.Sequence),
+		zap.Stringer("chain", m.ChainID),
+		zap.Stringer("tokenChain", m.TokenChain),
+		zap.Stringer("tokenAddress", m.TokenAddress),
+		zap.Stringer("kind", m.Kind),
+		zap.Stringer("amount", m.Amount),
+		zap.Stringer("before", acct.balance(key)),
+		zap.Stringer("after", balance),
+		zap.String("reason", m.Reason),
+	)
+
+	acct.balances[key] = balance
+	return nil
+}
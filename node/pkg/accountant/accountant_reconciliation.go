@@ -0,0 +1,303 @@
+// This file contains the code to reconcile the accountant ledger against the token bridge custody balances on chain.
+//
+// Only the token bridge ledger is reconciled. For each chain with a configured custody querier, the balance of every token in the ledger is compared against the balance
+// held by the token bridge on that chain. For tokens that originate on the chain, this is the token bridge balance of the token.
+// For tokens that originate elsewhere, this is the total supply of the wrapped token. On chain balances are normalized to eight
+// decimals, the same as the amounts in token bridge transfers.
+//
+// Reconciliation runs periodically and can also be triggered using the accountant-reconcile admin command. The drift per token
+// (custody balance minus ledger balance) is exported as a Prometheus metric.
+//
+// Note that the drift is not expected to be zero. The ledger only includes transfers observed since the accountant was enabled,
+// and transfers are accounted for when they are signed rather than when they are redeemed.
+
+package accountant
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/ethereum/erc20"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"go.uber.org/zap"
+)
+
+const reconcileInterval = time.Hour
+const custodyQueryTimeout = 10 * time.Second
+
+// CustodyQuerier returns the amount of a token held by the token bridge on a chain, normalized to eight decimals.
+type CustodyQuerier interface {
+	QueryCustody(ctx context.Context, tokenChain vaa.ChainID, tokenAddress vaa.Address) (*big.Int, error)
+}
+
+var (
+	// guardian_accountant_balance_drift{chain_id="2",chain_name="ethereum",token_chain="2",token_address="000..."} 0
+	metricBalanceDrift = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "guardian_accountant_balance_drift",
+			Help: "Accountant custody balance minus ledger balance per chain and token, normalized to eight decimals",
+		}, []string{"chain_id", "chain_name", "token_chain", "token_address"})
+
+	// guardian_accountant_reconcile_errors_total{chain_id="2",chain_name="ethereum"} 0
+	metricReconcileErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "guardian_accountant_reconcile_errors_total",
+			Help: "Accountant number of custody balances that could not be queried during reconciliation",
+		}, []string{"chain_id", "chain_name"})
+)
+
+// AddCustodyQuerier sets the querier used to reconcile the balances on a chain. It should be called before the accountant is started.
+func (acct *Accountant) AddCustodyQuerier(chainID vaa.ChainID, q CustodyQuerier) {
+	acct.mutex.Lock()
+	defer acct.mutex.Unlock()
+	acct.queriers[chainID] = q
+}
+
+// AddEvmCustodyQuerier sets up reconciliation for an EVM chain using the token bridge contract for that chain. It should be called after the accountant is started.
+func (acct *Accountant) AddEvmCustodyQuerier(chainID vaa.ChainID, rpcURL string) error {
+	acct.mutex.Lock()
+	if acct.tokenBridge == nil {
+		acct.mutex.Unlock()
+		return fmt.Errorf("the accountant has not been started")
+	}
+	emitterAddr, exists := acct.tokenBridge.emitters[chainID]
+	acct.mutex.Unlock()
+	if !exists {
+		return fmt.Errorf("no token bridge is configured for chain %v", chainID)
+	}
+
+	acct.AddCustodyQuerier(chainID, NewEvmCustodyQuerier(chainID, rpcURL, ethCommon.BytesToAddress(emitterAddr[12:])))
+	return nil
+}
+
+// RunReconciliation periodically reconciles the ledger against the custody balances. It is meant to be run by the supervisor.
+func (acct *Accountant) RunReconciliation(ctx context.Context) error {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			entries := acct.Reconcile(ctx, vaa.ChainIDUnset)
+			numErrors := 0
+			for _, e := range entries {
+				if e.Error != "" {
+					numErrors++
+				}
+			}
+			acct.logger.Info("acct: reconciled balances", zap.Int("numBalances", len(entries)), zap.Int("numErrors", numErrors))
+		}
+	}
+}
+
+// Reconcile compares the custody balances on chain against the ledger, for the specified chain or for all chains if it is unset.
+func (acct *Accountant) Reconcile(ctx context.Context, chainID vaa.ChainID) []*nodev1.AccountantReconcileResponse_Entry {
+	// Query the chains without holding the lock, so transfers are not blocked while waiting on RPCs.
+	acct.mutex.Lock()
+	type snapshotEntry struct {
+		key    balanceKey
+		amount *big.Int
+		q      CustodyQuerier
+	}
+	snapshot := make([]snapshotEntry, 0)
+	for key, amount := range acct.balances {
+		if key.ledger != TokenBridgeLedger {
+			continue
+		}
+		if chainID != vaa.ChainIDUnset && key.chain != chainID {
+			continue
+		}
+		q, exists := acct.queriers[key.chain]
+		if !exists {
+			continue
+		}
+		snapshot = append(snapshot, snapshotEntry{key: key, amount: new(big.Int).Set(amount), q: q})
+	}
+	acct.mutex.Unlock()
+
+	sort.SliceStable(snapshot, func(i, j int) bool {
+		if snapshot[i].key.chain != snapshot[j].key.chain {
+			return snapshot[i].key.chain < snapshot[j].key.chain
+		}
+		if snapshot[i].key.tokenChain != snapshot[j].key.tokenChain {
+			return snapshot[i].key.tokenChain < snapshot[j].key.tokenChain
+		}
+		return snapshot[i].key.tokenAddress.String() < snapshot[j].key.tokenAddress.String()
+	})
+
+	resp := make([]*nodev1.AccountantReconcileResponse_Entry, 0, len(snapshot))
+	for _, s := range snapshot {
+		entry := &nodev1.AccountantReconcileResponse_Entry{
+			ChainId:       uint32(s.key.chain),
+			TokenChain:    uint32(s.key.tokenChain),
+			TokenAddress:  s.key.tokenAddress.String(),
+			LedgerBalance: s.amount.String(),
+		}
+
+		qctx, cancel := context.WithTimeout(ctx, custodyQueryTimeout)
+		custody, err := s.q.QueryCustody(qctx, s.key.tokenChain, s.key.tokenAddress)
+		cancel()
+		if err != nil {
+			metricReconcileErrors.WithLabelValues(chainLabel(s.key.chain), s.key.chain.String()).Inc()
+			acct.logger.Error("acct: failed to query custody balance",
+				zap.Stringer("chain", s.key.chain),
+				zap.Stringer("tokenChain", s.key.tokenChain),
+				zap.Stringer("tokenAddress", s.key.tokenAddress),
+				zap.Error(err),
+			)
+			entry.Error = err.Error()
+			resp = append(resp, entry)
+			continue
+		}
+
+		drift := new(big.Int).Sub(custody, s.amount)
+		entry.CustodyBalance = custody.String()
+		entry.Drift = drift.String()
+
+		driftFloat, _ := new(big.Float).SetInt(drift).Float64()
+		metricBalanceDrift.WithLabelValues(chainLabel(s.key.chain), s.key.chain.String(), chainLabel(s.key.tokenChain), s.key.tokenAddress.String()).Set(driftFloat)
+
+		if drift.Sign() < 0 {
+			acct.logger.Warn("acct: custody balance is less than the ledger balance",
+				zap.Stringer("chain", s.key.chain),
+				zap.Stringer("tokenChain", s.key.tokenChain),
+				zap.Stringer("tokenAddress", s.key.tokenAddress),
+				zap.Stringer("ledgerBalance", s.amount),
+				zap.Stringer("custodyBalance", custody),
+			)
+		}
+
+		resp = append(resp, entry)
+	}
+
+	return resp
+}
+
+func chainLabel(chainID vaa.ChainID) string {
+	return strconv.Itoa(int(chainID))
+}
+
+// evmCustodyQuerier queries custody balances using the token bridge contract on an EVM chain.
+type evmCustodyQuerier struct {
+	chainID     vaa.ChainID
+	rpcURL      string
+	tokenBridge ethCommon.Address
+
+	mutex  sync.Mutex
+	client *ethclient.Client
+	bridge *bind.BoundContract
+}
+
+// The token bridge method used to look up the address of a wrapped token.
+const tokenBridgeWrappedAssetABI = `[{"inputs":[{"internalType":"uint16","name":"tokenChainId","type":"uint16"},{"internalType":"bytes32","name":"tokenAddress","type":"bytes32"}],"name":"wrappedAsset","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"}]`
+
+// NewEvmCustodyQuerier creates a custody querier for the token bridge at the specified address. The connection is established on first use.
+func NewEvmCustodyQuerier(chainID vaa.ChainID, rpcURL string, tokenBridge ethCommon.Address) CustodyQuerier {
+	return &evmCustodyQuerier{chainID: chainID, rpcURL: rpcURL, tokenBridge: tokenBridge}
+}
+
+func (q *evmCustodyQuerier) connect(ctx context.Context) (*ethclient.Client, *bind.BoundContract, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.client == nil {
+		parsed, err := ethAbi.JSON(strings.NewReader(tokenBridgeWrappedAssetABI))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse token bridge abi: %w", err)
+		}
+
+		client, err := ethclient.DialContext(ctx, q.rpcURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("dialing eth client failed: %w", err)
+		}
+
+		q.client = client
+		q.bridge = bind.NewBoundContract(q.tokenBridge, parsed, client, nil, nil)
+	}
+
+	return q.client, q.bridge, nil
+}
+
+func (q *evmCustodyQuerier) QueryCustody(ctx context.Context, tokenChain vaa.ChainID, tokenAddress vaa.Address) (*big.Int, error) {
+	client, bridge, err := q.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+
+	// Tokens originating on this chain are locked in the token bridge.
+	if tokenChain == q.chainID {
+		token, err := erc20.NewErc20Caller(ethCommon.BytesToAddress(tokenAddress[12:]), client)
+		if err != nil {
+			return nil, err
+		}
+
+		balance, err := token.BalanceOf(opts, q.tokenBridge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query token bridge balance: %w", err)
+		}
+
+		decimals, err := token.Decimals(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query token decimals: %w", err)
+		}
+
+		return normalizeAmount(balance, decimals), nil
+	}
+
+	// Tokens originating elsewhere are minted by the token bridge, so the custody balance is the wrapped supply.
+	var out []interface{}
+	if err := bridge.Call(opts, &out, "wrappedAsset", uint16(tokenChain), [32]byte(tokenAddress)); err != nil {
+		return nil, fmt.Errorf("failed to look up wrapped asset: %w", err)
+	}
+
+	wrappedAddr := *ethAbi.ConvertType(out[0], new(ethCommon.Address)).(*ethCommon.Address)
+	if wrappedAddr == (ethCommon.Address{}) {
+		// The token has never been attested on this chain.
+		return big.NewInt(0), nil
+	}
+
+	token, err := erc20.NewErc20Caller(wrappedAddr, client)
+	if err != nil {
+		return nil, err
+	}
+
+	supply, err := token.TotalSupply(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wrapped supply: %w", err)
+	}
+
+	decimals, err := token.Decimals(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query token decimals: %w", err)
+	}
+
+	return normalizeAmount(supply, decimals), nil
+}
+
+// Token bridge transfers have a maximum of eight decimal places, so on chain amounts are truncated to match.
+func normalizeAmount(amount *big.Int, decimals uint8) *big.Int {
+	if decimals <= 8 {
+		return amount
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-8)), nil)
+	return new(big.Int).Div(amount, divisor)
+}
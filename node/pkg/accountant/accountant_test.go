@@ -0,0 +1,358 @@
+package accountant
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/zap"
+)
+
+func newAccountantForTest(t *testing.T, logOnly bool) (*Accountant, *db.Database) {
+	t.Helper()
+
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	acct := NewAccountant(zap.NewNop(), database, GoTestMode, logOnly)
+	require.NoError(t, acct.Run(context.Background()))
+	return acct, database
+}
+
+func buildMockTransferPayloadBytes(tokenChainID vaa.ChainID, tokenAddrStr string, toChainID vaa.ChainID, amount int64) []byte {
+	bytes := make([]byte, 101)
+	bytes[0] = 1
+
+	amtBytes := big.NewInt(amount).Bytes()
+	copy(bytes[33-len(amtBytes):33], amtBytes)
+
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+	copy(bytes[33:65], tokenAddr.Bytes())
+	binary.BigEndian.PutUint16(bytes[65:67], uint16(tokenChainID))
+	binary.BigEndian.PutUint16(bytes[99:101], uint16(toChainID))
+	return bytes
+}
+
+func newTransferMsg(t *testing.T, emitterChain vaa.ChainID, sequence uint64, payload []byte) *common.MessagePublication {
+	t.Helper()
+
+	emitterAddr, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[emitterChain])
+	require.NoError(t, err)
+
+	return &common.MessagePublication{
+		TxHash:         [32]byte{},
+		Timestamp:      time.Unix(int64(1654543099), 0),
+		Nonce:          uint32(1),
+		Sequence:       sequence,
+		EmitterChain:   emitterChain,
+		EmitterAddress: emitterAddr,
+		Payload:        payload,
+	}
+}
+
+const tokenAddrStr = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" //nolint:gosec
+
+func TestTransfersUpdateBalances(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+
+	// Lock 1000 on Ethereum, mint 1000 on Solana.
+	ok, err := acct.processMsg(newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 1000)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ethKey := balanceKey{ledger: TokenBridgeLedger, chain: vaa.ChainIDEthereum, tokenChain: vaa.ChainIDEthereum, tokenAddress: tokenAddr}
+	solKey := balanceKey{ledger: TokenBridgeLedger, chain: vaa.ChainIDSolana, tokenChain: vaa.ChainIDEthereum, tokenAddress: tokenAddr}
+	assert.Equal(t, int64(1000), acct.balance(ethKey).Int64())
+	assert.Equal(t, int64(1000), acct.balance(solKey).Int64())
+
+	// Burn 400 on Solana, release 400 on Ethereum.
+	ok, err = acct.processMsg(newTransferMsg(t, vaa.ChainIDSolana, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDEthereum, 400)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(600), acct.balance(ethKey).Int64())
+	assert.Equal(t, int64(600), acct.balance(solKey).Int64())
+
+	// Burning more than the wrapped supply on Solana is refused and does not change the balances.
+	ok, err = acct.processMsg(newTransferMsg(t, vaa.ChainIDSolana, 2, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDEthereum, 601)))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(600), acct.balance(ethKey).Int64())
+	assert.Equal(t, int64(600), acct.balance(solKey).Int64())
+
+	// Messages from other emitters are not accounted for.
+	msg := newTransferMsg(t, vaa.ChainIDSolana, 3, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDEthereum, 10000))
+	msg.EmitterAddress = vaa.Address{1}
+	ok, err = acct.processMsg(msg)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(600), acct.balance(solKey).Int64())
+}
+
+func TestReobservationIsOnlyCountedOnce(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+	ethKey := balanceKey{ledger: TokenBridgeLedger, chain: vaa.ChainIDEthereum, tokenChain: vaa.ChainIDEthereum, tokenAddress: tokenAddr}
+
+	msg := newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 1000))
+	for i := 0; i < 2; i++ {
+		ok, err := acct.processMsg(msg)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(1000), acct.balance(ethKey).Int64())
+	}
+
+	// The same message ID with a different payload is refused.
+	msg = newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 2000))
+	ok, err := acct.processMsg(msg)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(1000), acct.balance(ethKey).Int64())
+}
+
+func TestStatusCountsRefusedTransfers(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+
+	assert.True(t, acct.ProcessMsg(newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 1000))))
+	assert.False(t, acct.ProcessMsg(newTransferMsg(t, vaa.ChainIDSolana, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDEthereum, 1001))))
+
+	status := acct.Status()
+	assert.True(t, status.Enabled)
+	assert.False(t, status.LogOnly)
+	assert.Equal(t, uint32(1), status.Ledgers)
+	assert.Equal(t, uint32(2), status.Balances)
+	assert.Equal(t, uint64(1), status.RefusedTransfers)
+}
+
+func TestLogOnlyModeSignsOverdraws(t *testing.T) {
+	acct, database := newAccountantForTest(t, true)
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+	solKey := balanceKey{ledger: TokenBridgeLedger, chain: vaa.ChainIDSolana, tokenChain: vaa.ChainIDEthereum, tokenAddress: tokenAddr}
+
+	ok, err := acct.processMsg(newTransferMsg(t, vaa.ChainIDSolana, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDEthereum, 500)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-500), acct.balance(solKey).Int64())
+
+	// The balances are reloaded from the database on start up.
+	acct2 := NewAccountant(zap.NewNop(), database, GoTestMode, true)
+	require.NoError(t, acct2.initConfig())
+	require.NoError(t, acct2.loadFromDB())
+	assert.Equal(t, int64(-500), acct2.balance(solKey).Int64())
+}
+
+type mockCustodyQuerier struct {
+	balances map[vaa.Address]*big.Int
+}
+
+func (q *mockCustodyQuerier) QueryCustody(ctx context.Context, tokenChain vaa.ChainID, tokenAddress vaa.Address) (*big.Int, error) {
+	if balance, exists := q.balances[tokenAddress]; exists {
+		return balance, nil
+	}
+	return nil, fmt.Errorf("unknown token")
+}
+
+func TestReconcile(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+	otherTokenAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	otherTokenAddr, _ := vaa.StringToAddress(otherTokenAddrStr)
+
+	ok, err := acct.processMsg(newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 1000)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = acct.processMsg(newTransferMsg(t, vaa.ChainIDEthereum, 2, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, otherTokenAddrStr, vaa.ChainIDSolana, 500)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Only the chains with a querier are reconciled.
+	acct.AddCustodyQuerier(vaa.ChainIDEthereum, &mockCustodyQuerier{balances: map[vaa.Address]*big.Int{tokenAddr: big.NewInt(1200)}})
+
+	entries := acct.Reconcile(context.Background(), vaa.ChainIDUnset)
+	require.Equal(t, 2, len(entries))
+
+	byToken := make(map[string]*nodev1.AccountantReconcileResponse_Entry)
+	for _, e := range entries {
+		assert.Equal(t, uint32(vaa.ChainIDEthereum), e.ChainId)
+		byToken[e.TokenAddress] = e
+	}
+
+	e := byToken[tokenAddr.String()]
+	require.NotNil(t, e)
+	assert.Equal(t, "1000", e.LedgerBalance)
+	assert.Equal(t, "1200", e.CustodyBalance)
+	assert.Equal(t, "200", e.Drift)
+	assert.Equal(t, "", e.Error)
+
+	e = byToken[otherTokenAddr.String()]
+	require.NotNil(t, e)
+	assert.Equal(t, "500", e.LedgerBalance)
+	assert.Equal(t, "unknown token", e.Error)
+
+	assert.Equal(t, 0, len(acct.Reconcile(context.Background(), vaa.ChainIDSolana)))
+}
+
+func TestNormalizeAmount(t *testing.T) {
+	assert.Equal(t, int64(123456789), normalizeAmount(big.NewInt(123456789), 8).Int64())
+	assert.Equal(t, int64(1234), normalizeAmount(big.NewInt(1234), 6).Int64())
+	assert.Equal(t, int64(12345678), normalizeAmount(new(big.Int).Mul(big.NewInt(123456789), big.NewInt(1000000000)), 18).Int64())
+}
+
+func buildMockNTTTransferPayloadBytes(decimals uint8, amount uint64, toChainID vaa.ChainID) []byte {
+	transfer := new(bytes.Buffer)
+	transfer.Write(nttTransferPrefix)
+	transfer.WriteByte(decimals)
+	_ = binary.Write(transfer, binary.BigEndian, amount)
+	transfer.Write(make([]byte, 64))
+	_ = binary.Write(transfer, binary.BigEndian, uint16(toChainID))
+
+	manager := new(bytes.Buffer)
+	manager.Write(make([]byte, 64))
+	_ = binary.Write(manager, binary.BigEndian, uint16(transfer.Len()))
+	manager.Write(transfer.Bytes())
+
+	payload := new(bytes.Buffer)
+	payload.Write(nttTransceiverPrefix)
+	payload.Write(make([]byte, 64))
+	_ = binary.Write(payload, binary.BigEndian, uint16(manager.Len()))
+	payload.Write(manager.Bytes())
+	_ = binary.Write(payload, binary.BigEndian, uint16(0))
+	return payload.Bytes()
+}
+
+func TestNTTLedger(t *testing.T) {
+	acct, database := newAccountantForTest(t, false)
+
+	ethEmitter := vaa.Address{0xee}
+	bscEmitter := vaa.Address{0xbb}
+	err := acct.AddLedger(EmitterConfig{
+		Ledger:   "test-ntt",
+		Format:   FormatNTT,
+		HubChain: "ethereum",
+		Emitters: map[string]string{"ethereum": ethEmitter.String(), "4": bscEmitter.String()},
+	})
+	require.NoError(t, err)
+
+	newMsg := func(emitterChain vaa.ChainID, emitterAddr vaa.Address, sequence uint64, payload []byte) *common.MessagePublication {
+		msg := newTransferMsg(t, vaa.ChainIDEthereum, sequence, payload)
+		msg.EmitterChain = emitterChain
+		msg.EmitterAddress = emitterAddr
+		return msg
+	}
+
+	hubKey := balanceKey{ledger: "test-ntt", chain: vaa.ChainIDEthereum, tokenChain: vaa.ChainIDEthereum, tokenAddress: ethEmitter}
+	bscKey := balanceKey{ledger: "test-ntt", chain: vaa.ChainIDBSC, tokenChain: vaa.ChainIDEthereum, tokenAddress: ethEmitter}
+
+	// Lock 10 tokens (with six decimals) on the hub, mint them on BSC.
+	ok, err := acct.processMsg(newMsg(vaa.ChainIDEthereum, ethEmitter, 1, buildMockNTTTransferPayloadBytes(6, 10000000, vaa.ChainIDBSC)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1000000000), acct.balance(hubKey).Int64())
+	assert.Equal(t, int64(1000000000), acct.balance(bscKey).Int64())
+
+	// Sending more than was minted back from BSC is refused.
+	ok, err = acct.processMsg(newMsg(vaa.ChainIDBSC, bscEmitter, 1, buildMockNTTTransferPayloadBytes(8, 1000000001, vaa.ChainIDEthereum)))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = acct.processMsg(newMsg(vaa.ChainIDBSC, bscEmitter, 2, buildMockNTTTransferPayloadBytes(8, 400000000, vaa.ChainIDEthereum)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(600000000), acct.balance(hubKey).Int64())
+	assert.Equal(t, int64(600000000), acct.balance(bscKey).Int64())
+
+	// Other messages from the emitter are not accounted for.
+	ok, err = acct.processMsg(newMsg(vaa.ChainIDBSC, bscEmitter, 3, []byte{0x01, 0x02}))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// The ledger balances are reloaded from the database, along with the token bridge balances.
+	ok, err = acct.processMsg(newTransferMsg(t, vaa.ChainIDEthereum, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDSolana, 1000)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	acct2 := NewAccountant(zap.NewNop(), database, GoTestMode, false)
+	require.NoError(t, acct2.loadFromDB())
+	assert.Equal(t, 4, len(acct2.balances))
+	assert.Equal(t, int64(600000000), acct2.balance(bscKey).Int64())
+}
+
+func TestAddLedgerValidation(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+
+	assert.Error(t, acct.AddLedger(EmitterConfig{Ledger: TokenBridgeLedger, Format: FormatTokenBridge, Emitters: map[string]string{"ethereum": "0x01"}}))
+	assert.Error(t, acct.AddLedger(EmitterConfig{Ledger: "test", Format: "unknown", Emitters: map[string]string{"ethereum": "0x01"}}))
+	assert.Error(t, acct.AddLedger(EmitterConfig{Ledger: "test", Format: FormatTokenBridge}))
+	assert.Error(t, acct.AddLedger(EmitterConfig{Ledger: "test", Format: FormatNTT, HubChain: "solana", Emitters: map[string]string{"ethereum": "0x01"}}))
+
+	// Emitters can only belong to one ledger.
+	tokenBridgeEmitter, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+	assert.Error(t, acct.AddLedger(EmitterConfig{Ledger: "test", Format: FormatTokenBridge, Emitters: map[string]string{"ethereum": tokenBridgeEmitter.String()}}))
+
+	assert.NoError(t, acct.AddLedger(EmitterConfig{Ledger: "test", Format: FormatTokenBridge, Emitters: map[string]string{"ethereum": "0x01"}}))
+}
+
+func TestModifyBalance(t *testing.T) {
+	acct, _ := newAccountantForTest(t, false)
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+	solKey := balanceKey{ledger: TokenBridgeLedger, chain: vaa.ChainIDSolana, tokenChain: vaa.ChainIDEthereum, tokenAddress: tokenAddr}
+
+	modify := func(sequence uint64, kind vaa.ModificationKind, amount int64) *vaa.VAA {
+		return vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, sequence, 0, vaa.BodyAccountantModifyBalance{
+			Sequence:     sequence,
+			ChainID:      vaa.ChainIDSolana,
+			TokenChain:   vaa.ChainIDEthereum,
+			TokenAddress: tokenAddr,
+			Kind:         kind,
+			Amount:       big.NewInt(amount),
+			Reason:       "missed transfer",
+		}.Serialize())
+	}
+
+	preview, err := acct.PreviewModification(&vaa.BodyAccountantModifyBalance{Sequence: 1, ChainID: vaa.ChainIDSolana, TokenChain: vaa.ChainIDEthereum, TokenAddress: tokenAddr, Kind: vaa.ModificationKindAdd, Amount: big.NewInt(500)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), preview.BalanceBefore.Int64())
+	assert.Equal(t, int64(500), preview.BalanceAfter.Int64())
+	assert.False(t, preview.AlreadyApplied)
+
+	acct.ProcessGovernanceVAA(modify(1, vaa.ModificationKindAdd, 500))
+	assert.Equal(t, int64(500), acct.balance(solKey).Int64())
+
+	// A modification is only applied once.
+	acct.ProcessGovernanceVAA(modify(1, vaa.ModificationKindAdd, 500))
+	assert.Equal(t, int64(500), acct.balance(solKey).Int64())
+	preview, err = acct.PreviewModification(&vaa.BodyAccountantModifyBalance{Sequence: 1, ChainID: vaa.ChainIDSolana, TokenChain: vaa.ChainIDEthereum, TokenAddress: tokenAddr, Kind: vaa.ModificationKindAdd, Amount: big.NewInt(500)})
+	require.NoError(t, err)
+	assert.True(t, preview.AlreadyApplied)
+
+	// A modification which would make the balance negative is refused.
+	acct.ProcessGovernanceVAA(modify(2, vaa.ModificationKindSubtract, 501))
+	assert.Equal(t, int64(500), acct.balance(solKey).Int64())
+
+	acct.ProcessGovernanceVAA(modify(3, vaa.ModificationKindSubtract, 200))
+	assert.Equal(t, int64(300), acct.balance(solKey).Int64())
+
+	// The modified balance allows burning the wrapped tokens on Solana.
+	ok, err := acct.processMsg(newTransferMsg(t, vaa.ChainIDSolana, 1, buildMockTransferPayloadBytes(vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, 300)))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Governance VAAs from other emitters are ignored.
+	v := modify(4, vaa.ModificationKindAdd, 1000)
+	v.EmitterAddress = vaa.Address{1}
+	acct.ProcessGovernanceVAA(v)
+	assert.Equal(t, int64(0), acct.balance(solKey).Int64())
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
 	"github.com/algorand/go-algorand-sdk/crypto"
 	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/certusone/wormhole/node/pkg/catchup"
 	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
@@ -34,12 +36,12 @@ type (
 		algodToken   string
 		appid        uint64
 
-		msgChan  chan *common.MessagePublication
+		msgChan  chan<- *common.MessagePublication
 		setChan  chan *common.GuardianSet
 		obsvReqC chan *gossipv1.ObservationRequest
 
-		next_round uint64
-		debug      bool
+		catchup *catchup.Scheduler
+		debug   bool
 	}
 )
 
@@ -56,6 +58,9 @@ var (
 		})
 )
 
+// errBlockUnavailable is returned while algod returns an empty block for a round.
+var errBlockUnavailable = errors.New("block is not available yet")
+
 // NewWatcher creates a new Algorand appid watcher
 func NewWatcher(
 	indexerRPC string,
@@ -63,7 +68,7 @@ func NewWatcher(
 	algodRPC string,
 	algodToken string,
 	appid uint64,
-	lockEvents chan *common.MessagePublication,
+	lockEvents chan<- *common.MessagePublication,
 	setEvents chan *common.GuardianSet,
 	obsvReqC chan *gossipv1.ObservationRequest,
 ) *Watcher {
@@ -76,7 +81,6 @@ func NewWatcher(
 		msgChan:      lockEvents,
 		setChan:      setEvents,
 		obsvReqC:     obsvReqC,
-		next_round:   0,
 		debug:        true,
 	}
 }
@@ -192,7 +196,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 			return
 		}
 
-		if e.next_round == 0 {
+		if e.catchup == nil {
 			status, err := algodClient.StatusAfterBlock(0).Do(context.Background())
 			if err != nil {
 				logger.Error("StatusAfterBlock", zap.Error(err))
@@ -201,7 +205,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 				return
 			}
 
-			e.next_round = status.NextVersionRound
+			e.catchup = catchup.NewScheduler(logger, vaa.ChainIDAlgorand.String(), status.NextVersionRound-1, catchup.Config{})
 		}
 
 		for {
@@ -251,41 +255,44 @@ func (e *Watcher) Run(ctx context.Context) error {
 					return
 				}
 
-				if e.next_round > status.NextVersionRound {
-					e.next_round = status.NextVersionRound
-					logger.Info(fmt.Sprintf("Algorand rollback to %d", e.next_round))
-					return
+				// The blocks up to the last round have been committed.
+				lastRound := status.NextVersionRound - 1
+				if e.catchup.Processed() > lastRound {
+					e.catchup.Reset(lastRound)
+					logger.Info(fmt.Sprintf("Algorand rollback to %d", status.NextVersionRound))
 				}
 
-				if e.next_round < status.NextVersionRound {
-					for {
-						logger.Info(fmt.Sprintf("inspecting block %d", e.next_round))
-						block, err := algodClient.Block(e.next_round).Do(context.Background())
+				err = e.catchup.CatchUp(ctx, lastRound, func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+					for round := from; round <= to; round++ {
+						logger.Info(fmt.Sprintf("inspecting block %d", round))
+						block, err := algodClient.Block(round).Do(ctx)
 						if err != nil {
-							logger.Error(fmt.Sprintf("algodClient.Block %d: %s", e.next_round, err.Error()))
-
-							p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAlgorand, 1)
-							errC <- err
-							return
+							return round - 1, fmt.Errorf("algodClient.Block %d: %w", round, err)
 						}
 
 						if block.Round == 0 {
-							break
+							return round - 1, errBlockUnavailable
 						}
 
 						for _, element := range block.Payset {
 							lookAtTxn(e, element, block, logger)
 						}
-						e.next_round = e.next_round + 1
-						if e.next_round == status.NextVersionRound {
-							break
-						}
 					}
+					return to, nil
+				})
+				// Blocks which are not available yet are retried on the next tick.
+				if err != nil && !errors.Is(err, errBlockUnavailable) {
+					logger.Error(err.Error())
+
+					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAlgorand, 1)
+					errC <- err
+					return
 				}
+
 				readiness.SetReady(common.ReadinessAlgorandSyncing)
-				currentAlgorandHeight.Set(float64(e.next_round))
+				currentAlgorandHeight.Set(float64(e.catchup.Processed() + 1))
 				p2p.DefaultRegistry.SetNetworkStats(vaa.ChainIDAlgorand, &gossipv1.Heartbeat_Network{
-					Height:          int64(e.next_round),
+					Height:          int64(e.catchup.Processed() + 1),
 					ContractAddress: fmt.Sprintf("%d", e.appid),
 				})
 			}
@@ -0,0 +1,55 @@
+package aptos
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/testutils/consistency"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestConsistencyLevelConformance checks that messages are observed as soon as they are emitted, since Aptos has
+// instant finality, and keep the consistency level requested by the emitter.
+func TestConsistencyLevelConformance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := mockchain.NewAptosServer()
+	defer node.Close()
+	node.SetBlockHeight(100)
+	// Messages emitted before the watcher started are not observed.
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("0")))
+
+	msgC := make(chan *common.MessagePublication, 10)
+	w := NewWatcher(node.URL, testAccount, testHandle, msgC, make(chan *gossipv1.ObservationRequest))
+	supervisor.New(ctx, zap.NewNop(), w.Run)
+
+	eventsPath := "/v1/accounts/" + testAccount + "/events/" + testHandle + "/event"
+	require.Eventually(t, func() bool { return node.Requests(eventsPath) >= 2 }, 10*time.Second, 10*time.Millisecond)
+
+	for i, c := range consistency.Aptos() {
+		require.Equal(t, consistency.Instant, c.Finality)
+
+		sequence := uint64(i + 1)
+		msg := testMessage(strconv.FormatUint(sequence, 10))
+		msg["consistency_level"] = strconv.Itoa(int(c.Level))
+		// The block height does not change: the message is observed without waiting for more blocks.
+		require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, msg))
+
+		select {
+		case obsv := <-msgC:
+			assert.Equal(t, sequence, obsv.Sequence)
+			assert.Equal(t, c.ObservedLevel, obsv.ConsistencyLevel, "level %d", c.Level)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("message with level %d not observed", c.Level)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package aptos
+
+import (
+	"fmt"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// The watcher follows the events of the core bridge by sequence number, so it silently stops observing if its cursor
+// gets stuck, for instance on a fullnode missing events, and it skips the events re-emitted after a rollback. The
+// reconciliation periodically compares the cursor with the counter of the event handle in the core bridge resource,
+// which is the sequence number of the next event emitted on chain.
+
+var (
+	aptosSequenceDrift = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_aptos_sequence_drift",
+			Help: "On-chain event counter of the Aptos core bridge minus the next sequence number of the watcher, as of the last reconciliation",
+		})
+	aptosSequenceMismatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_aptos_sequence_mismatches_total",
+			Help: "Total number of reconciliations finding the on-chain event counter of the Aptos core bridge ahead of a stalled watcher or behind it",
+		}, []string{"kind"})
+	aptosBackfilledEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_aptos_backfilled_events_total",
+			Help: "Total number of Aptos events backfilled after a mismatch, by result (observed or missing)",
+		}, []string{"result"})
+)
+
+// Maximum number of events backfilled per reconciliation, each one being fetched on its own.
+const maxBackfillEvents = 1000
+
+// fetchEventCounter returns the counter of the event handle of the core bridge, from the resource of its account.
+func (e *Watcher) fetchEventCounter() (uint64, error) {
+	body, err := e.retrievePayload(e.aptosResource)
+	if err != nil {
+		return 0, err
+	}
+
+	if !gjson.Valid(string(body)) {
+		return 0, &retryableError{fmt.Errorf("invalid JSON in resource response: %s", body)}
+	}
+	counter := gjson.GetBytes(body, "data.event.counter")
+	if !counter.Exists() {
+		return 0, fmt.Errorf("resource without event counter: %s", body)
+	}
+	return counter.Uint(), nil
+}
+
+// reconcile compares next_sequence with the on-chain event counter. A counter ahead of the cursor is expected while new
+// events are being polled, so it is only a mismatch once the cursor didn't even reach the counter of the previous
+// reconciliation. A counter behind the cursor means that the node rolled back, or that it lags behind the node which
+// served the events, and is reported right away.
+//
+// With autoBackfill, the events between a stalled cursor and the previous counter are fetched one by one, and the
+// cursor is rewound to a counter found behind it by two reconciliations in a row, so that the re-emitted events are
+// observed.
+func (e *Watcher) reconcile(logger *zap.Logger) error {
+	counter, err := e.fetchEventCounter()
+	if err != nil {
+		return err
+	}
+
+	// The cursor isn't initialized until the first event is seen.
+	if e.next_sequence == 0 {
+		return nil
+	}
+
+	aptosSequenceDrift.Set(float64(counter) - float64(e.next_sequence))
+
+	previousAhead, previousBehind := e.reconcileAhead, e.reconcileBehind
+	e.reconcileAhead, e.reconcileBehind = 0, false
+
+	switch {
+	case counter > e.next_sequence:
+		e.reconcileAhead = counter
+		if previousAhead == 0 || e.next_sequence >= previousAhead {
+			return nil
+		}
+
+		aptosSequenceMismatches.WithLabelValues("ahead").Inc()
+		logger.Error("the Aptos watcher is stalled behind the on-chain event counter, events may have been missed",
+			zap.Uint64("next_sequence", e.next_sequence),
+			zap.Uint64("counter", counter),
+			zap.Uint64("previous_counter", previousAhead),
+			zap.Bool("auto_backfill", e.autoBackfill))
+		if e.autoBackfill {
+			return e.backfill(logger, previousAhead)
+		}
+
+	case counter < e.next_sequence:
+		e.reconcileBehind = true
+		aptosSequenceMismatches.WithLabelValues("behind").Inc()
+		logger.Error("the on-chain event counter is behind the Aptos watcher, the node may have rolled back",
+			zap.Uint64("next_sequence", e.next_sequence),
+			zap.Uint64("counter", counter),
+			zap.Bool("auto_backfill", e.autoBackfill))
+		if e.autoBackfill && previousBehind {
+			logger.Warn("rewinding the Aptos watcher to the on-chain event counter", zap.Uint64("counter", counter))
+			e.next_sequence = counter
+			e.reconcileBehind = false
+		}
+	}
+
+	return nil
+}
+
+// backfill observes the events from next_sequence until end, fetching them one by one, and moves the cursor past them.
+// Events the node doesn't return are logged and skipped. If a request fails, the cursor stays at the failed event.
+func (e *Watcher) backfill(logger *zap.Logger, end uint64) error {
+	if end-e.next_sequence > maxBackfillEvents {
+		end = e.next_sequence + maxBackfillEvents
+	}
+
+	for seq := e.next_sequence; seq < end; seq++ {
+		body, err := e.retrievePayload(fmt.Sprintf(`%s?start=%d&limit=1`, e.aptosQuery, seq))
+		if err != nil {
+			return err
+		}
+
+		found := false
+		if gjson.Valid(string(body)) {
+			for _, chunk := range gjson.ParseBytes(body).Array() {
+				native_seq := chunk.Get("sequence_number")
+				if !native_seq.Exists() || native_seq.Uint() != seq {
+					continue
+				}
+				found = true
+
+				if !e.checkEventType(logger, chunk) {
+					continue
+				}
+				data := chunk.Get("data")
+				if !data.Exists() {
+					continue
+				}
+				e.observeData(logger, data, seq, common.NewProvenance(e.aptosRPC, 0, chunk.Get("version").String()))
+			}
+		}
+
+		if found {
+			aptosBackfilledEvents.WithLabelValues("observed").Inc()
+		} else {
+			aptosBackfilledEvents.WithLabelValues("missing").Inc()
+			logger.Error("Aptos event not returned by the node, skipping it", zap.Uint64("sequence_number", seq))
+		}
+		e.next_sequence = seq + 1
+	}
+
+	logger.Info("backfilled Aptos events", zap.Uint64("next_sequence", e.next_sequence))
+	return nil
+}
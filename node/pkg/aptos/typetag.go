@@ -0,0 +1,78 @@
+package aptos
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// structTag is a Move struct type tag of the form address::module::name.
+type structTag struct {
+	address [32]byte
+	module  string
+	name    string
+}
+
+// parseStructTag parses a Move struct type tag. Addresses are normalized, since the node may return them without
+// leading zeros. Generic struct types are refused, as the watcher only expects the non-generic WormholeMessage struct.
+func parseStructTag(s string) (structTag, error) {
+	if strings.ContainsAny(s, "<> ") {
+		return structTag{}, fmt.Errorf("unexpected generic type %s", s)
+	}
+
+	parts := strings.Split(s, "::")
+	if len(parts) != 3 {
+		return structTag{}, fmt.Errorf("invalid type %s", s)
+	}
+
+	addr, err := parseAccountAddress(parts[0])
+	if err != nil {
+		return structTag{}, fmt.Errorf("invalid type %s: %w", s, err)
+	}
+	if !isIdentifier(parts[1]) || !isIdentifier(parts[2]) {
+		return structTag{}, fmt.Errorf("invalid type %s", s)
+	}
+
+	return structTag{address: addr, module: parts[1], name: parts[2]}, nil
+}
+
+func (t structTag) String() string {
+	return fmt.Sprintf("0x%s::%s::%s", hex.EncodeToString(t.address[:]), t.module, t.name)
+}
+
+// parseAccountAddress parses a hex-encoded account address of up to 32 bytes, with a 0x prefix.
+func parseAccountAddress(s string) ([32]byte, error) {
+	var addr [32]byte
+	if !strings.HasPrefix(s, "0x") {
+		return addr, fmt.Errorf("address %s has no 0x prefix", s)
+	}
+	s = s[2:]
+	if len(s) == 0 || len(s) > 64 {
+		return addr, fmt.Errorf("invalid address length")
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return addr, err
+	}
+	copy(addr[32-len(b):], b)
+	return addr, nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/faultinject"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	"github.com/certusone/wormhole/node/pkg/readiness"
@@ -25,16 +31,29 @@ import (
 type (
 	// Watcher is responsible for looking over Aptos blockchain and reporting new transactions to the wormhole contract
 	Watcher struct {
-		aptosRPC     string
-		aptosAccount string
-		aptosHandle  string
-		aptosQuery   string
-		aptosHealth  string
-
-		msgChan  chan *common.MessagePublication
+		aptosRPC      string
+		aptosAccount  string
+		aptosHandle   string
+		aptosQuery    string
+		aptosHealth   string
+		aptosResource string
+
+		msgChan  chan<- *common.MessagePublication
 		obsvReqC chan *gossipv1.ObservationRequest
 
 		next_sequence uint64 // aptos native sequence number for wormhole contract
+
+		// Type of the message events of the wormhole package, derived from aptosAccount.
+		messageType structTag
+
+		// Reconciliation of next_sequence with the on-chain event counter, see reconcile.go. Disabled if the interval
+		// is zero.
+		reconcileInterval time.Duration
+		autoBackfill      bool
+		lastReconcile     time.Time
+		// The counter found ahead of next_sequence by the last reconciliation, or zero, and whether it was behind.
+		reconcileAhead  uint64
+		reconcileBehind bool
 	}
 )
 
@@ -49,14 +68,75 @@ var (
 			Name: "wormhole_aptos_current_height",
 			Help: "Current Aptos block height",
 		})
+	aptosEventsRejected = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_aptos_events_rejected_total",
+			Help: "Total number of Aptos events rejected because their type is not the expected message type",
+		})
+	aptosEventPagesPerTick = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "wormhole_aptos_event_pages_per_tick",
+			Help:    "Number of pages of Aptos events fetched per polling tick",
+			Buckets: []float64{1, 2, 3, 5, 10, 20},
+		})
+	aptosFetchErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_aptos_fetch_errors_total",
+			Help: "Total number of failed requests to the Aptos RPC node, by class (retryable or fatal)",
+		}, []string{"class"})
 )
 
+// Classes of the errors of requests to the RPC node.
+const (
+	// Network errors, server errors and rate limiting are retried by the watcher with a backoff.
+	errorClassRetryable = "retryable"
+	// Other errors, such as a rejected API key or an unknown account, do not go away without a config change. They
+	// stop the watcher, which is restarted by its supervisor.
+	errorClassFatal = "fatal"
+)
+
+const (
+	// Number of events requested per page. Fullnodes cap it to their max_events_page_size, 100 by default.
+	eventsPageLimit = 100
+	// Maximum number of pages of events fetched per tick, so that observation requests are still handled while the
+	// watcher catches up with a large burst of messages.
+	maxEventPagesPerTick = 20
+)
+
+// retryableError is a failed request which is worth retrying.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func errorClass(err error) string {
+	var r *retryableError
+	if errors.As(err, &r) {
+		return errorClassRetryable
+	}
+	return errorClassFatal
+}
+
+// isRetryableRequestError returns whether an error of an HTTP client is caused by the network or the server, rather
+// than by an invalid URL.
+func isRetryableRequestError(err error) bool {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return false
+	}
+	// url.Error implements net.Error itself, so the error it wraps is checked.
+	var netErr net.Error
+	return errors.As(urlErr.Err, &netErr) || errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)
+}
+
 // NewWatcher creates a new Aptos appid watcher
 func NewWatcher(
 	aptosRPC string,
 	aptosAccount string,
 	aptosHandle string,
-	lockEvents chan *common.MessagePublication,
+	lockEvents chan<- *common.MessagePublication,
 	obsvReqC chan *gossipv1.ObservationRequest,
 ) *Watcher {
 	return &Watcher{
@@ -71,19 +151,75 @@ func NewWatcher(
 	}
 }
 
+// SetReconcile makes the watcher compare its cursor with the on-chain event counter at the given interval, and
+// backfill or rewind it on a mismatch if autoBackfill is set. It must be called before Run.
+func (e *Watcher) SetReconcile(interval time.Duration, autoBackfill bool) {
+	e.reconcileInterval = interval
+	e.autoBackfill = autoBackfill
+}
+
+// retrievePayload fetches s. Errors which are worth retrying are returned as a retryableError.
 func (e *Watcher) retrievePayload(s string) ([]byte, error) {
-	res, err := http.Get(s) // nolint
+	res, err := faultinject.HTTPClient.Get(s) // nolint
 	if err != nil {
+		if isRetryableRequestError(err) {
+			return nil, &retryableError{err}
+		}
 		return nil, err
 	}
+	defer res.Body.Close()
+
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
+		return nil, &retryableError{err}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err := fmt.Errorf("unexpected status %s: %s", res.Status, body)
+		if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout {
+			return nil, &retryableError{err}
+		}
 		return nil, err
 	}
-	return body, err
+	return body, nil
+}
+
+// fetchFailed records a failed request to the RPC node and returns whether the error is fatal.
+func fetchFailed(logger *zap.Logger, request string, err error) bool {
+	class := errorClass(err)
+	aptosFetchErrors.WithLabelValues(class).Inc()
+	p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
+	logger.Error("request to the Aptos RPC node failed", zap.String("request", request), zap.String("class", class), zap.Error(err))
+	return class == errorClassFatal
+}
+
+// checkEventType validates that an event has the type of the WormholeMessage struct of the configured package. Events
+// of any other type exposed through the same handle are rejected.
+func (e *Watcher) checkEventType(logger *zap.Logger, chunk gjson.Result) bool {
+	t := chunk.Get("type")
+	if !t.Exists() {
+		logger.Error("event without type", zap.String("sequence_number", chunk.Get("sequence_number").String()))
+		aptosEventsRejected.Inc()
+		return false
+	}
+
+	tag, err := parseStructTag(t.String())
+	if err != nil || tag != e.messageType {
+		logger.Error("rejecting event of unexpected type",
+			zap.String("sequence_number", chunk.Get("sequence_number").String()),
+			zap.String("type", t.String()),
+			zap.Stringer("expected", e.messageType),
+			zap.Error(err))
+		aptosEventsRejected.Inc()
+		return false
+	}
+
+	return true
 }
 
-func (e *Watcher) observeData(logger *zap.Logger, data gjson.Result, native_seq uint64) {
+// observeData publishes the message in the data of an event. The block of its provenance is the ledger version of the
+// event.
+func (e *Watcher) observeData(logger *zap.Logger, data gjson.Result, native_seq uint64, provenance *common.Provenance) {
 	em := data.Get("sender")
 	if !em.Exists() {
 		logger.Info("sender")
@@ -162,6 +298,7 @@ func (e *Watcher) observeData(logger *zap.Logger, data gjson.Result, native_seq
 		EmitterAddress:   a,
 		Payload:          pl,
 		ConsistencyLevel: uint8(consistency_level.Uint()),
+		Provenance:       provenance,
 	}
 
 	aptosMessagesConfirmed.Inc()
@@ -180,23 +317,109 @@ func (e *Watcher) observeData(logger *zap.Logger, data gjson.Result, native_seq
 	e.msgChan <- observation
 }
 
+// pollEvents observes the events from next_sequence on, fetching pages until it is caught up or maxEventPagesPerTick
+// pages were fetched, and returns the number of pages fetched. Since fullnodes may return fewer events than requested,
+// a short page does not mean the watcher is caught up: it stops at the first empty page instead.
+//
+// Until the first event is seen, only the latest event is fetched, to start from the next one.
+func (e *Watcher) pollEvents(logger *zap.Logger) (int, error) {
+	pages := 0
+	for pages < maxEventPagesPerTick {
+		s := ""
+		if e.next_sequence == 0 {
+			s = fmt.Sprintf(`%s?limit=1`, e.aptosQuery)
+		} else {
+			s = fmt.Sprintf(`%s?start=%d&limit=%d`, e.aptosQuery, e.next_sequence, eventsPageLimit)
+		}
+
+		fetchStart := time.Now()
+		body, err := e.retrievePayload(s)
+		fetchLatency := time.Since(fetchStart)
+		if err != nil {
+			return pages, err
+		}
+		pages++
+
+		// data doesn't exist yet. skip, and try again later
+		if string(body) == "" {
+			return pages, nil
+		}
+
+		if !gjson.Valid(string(body)) {
+			logger.Error("InvalidJson: " + string(body))
+			p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
+			return pages, nil
+		}
+
+		outcomes := gjson.ParseBytes(body).Array()
+		if len(outcomes) == 0 {
+			return pages, nil
+		}
+		previous := e.next_sequence
+
+		for _, chunk := range outcomes {
+			native_seq := chunk.Get("sequence_number")
+			if !native_seq.Exists() {
+				continue
+			}
+			if e.next_sequence == 0 {
+				e.next_sequence = native_seq.Uint() + 1
+				return pages, nil
+			} else {
+				e.next_sequence = native_seq.Uint() + 1
+			}
+
+			if !e.checkEventType(logger, chunk) {
+				continue
+			}
+
+			data := chunk.Get("data")
+			if !data.Exists() {
+				continue
+			}
+			e.observeData(logger, data, native_seq.Uint(), common.NewProvenance(e.aptosRPC, fetchLatency, chunk.Get("version").String()))
+		}
+
+		// Do not request the same page again if it had no valid events.
+		if e.next_sequence == previous {
+			return pages, nil
+		}
+	}
+
+	logger.Warn("Aptos events not caught up, continuing at the next tick", zap.Uint64("next_sequence", e.next_sequence))
+	return pages, nil
+}
+
 func (e *Watcher) Run(ctx context.Context) error {
 	p2p.DefaultRegistry.SetNetworkStats(vaa.ChainIDAptos, &gossipv1.Heartbeat_Network{
 		ContractAddress: e.aptosAccount,
 	})
 
 	logger := supervisor.Logger(ctx)
-	errC := make(chan error)
+	errC := make(chan error, 1)
+
+	account, err := parseAccountAddress(e.aptosAccount)
+	if err != nil {
+		return fmt.Errorf("invalid aptos account %s: %w", e.aptosAccount, err)
+	}
+	e.messageType = structTag{address: account, module: "state", name: "WormholeMessage"}
 
 	logger.Info("Aptos watcher connecting to RPC node ", zap.String("url", e.aptosRPC))
 
 	e.aptosQuery = fmt.Sprintf(`%s/v1/accounts/%s/events/%s/event`, e.aptosRPC, e.aptosAccount, e.aptosHandle)
 	e.aptosHealth = fmt.Sprintf(`%s/v1`, e.aptosRPC)
+	e.aptosResource = fmt.Sprintf(`%s/v1/accounts/%s/resource/%s`, e.aptosRPC, e.aptosAccount, e.aptosHandle)
 
 	go func() {
 		timer := time.NewTicker(time.Second * 1)
 		defer timer.Stop()
 
+		// Polling is delayed after retryable errors, until retryAt.
+		bo := backoff.NewExponentialBackOff()
+		bo.MaxInterval = time.Minute
+		bo.MaxElapsedTime = 0
+		var retryAt time.Time
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -212,11 +435,15 @@ func (e *Watcher) Run(ctx context.Context) error {
 
 				s := fmt.Sprintf(`%s?start=%d&limit=1`, e.aptosQuery, native_seq)
 
+				fetchStart := time.Now()
 				body, err := e.retrievePayload(s)
+				fetchLatency := time.Since(fetchStart)
 				if err != nil {
-					logger.Error("retrievePayload", zap.Error(err))
-					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
-					errC <- err
+					// A retryable error drops the request, which is retried by the network.
+					if fetchFailed(logger, "reobservation", err) {
+						errC <- err
+						return
+					}
 					break
 				}
 
@@ -241,69 +468,51 @@ func (e *Watcher) Run(ctx context.Context) error {
 
 					}
 
+					if !e.checkEventType(logger, chunk) {
+						break
+					}
+
 					data := chunk.Get("data")
 					if !data.Exists() {
 						break
 					}
-					e.observeData(logger, data, native_seq)
+					e.observeData(logger, data, native_seq, common.NewProvenance(e.aptosRPC, fetchLatency, chunk.Get("version").String()))
 				}
 
 			case <-timer.C:
-				s := ""
-				if e.next_sequence == 0 {
-					s = fmt.Sprintf(`%s?limit=1`, e.aptosQuery)
-				} else {
-					s = fmt.Sprintf(`%s?start=%d`, e.aptosQuery, e.next_sequence)
-				}
-
-				body, err := e.retrievePayload(s)
-				if err != nil {
-					logger.Error("retrievePayload", zap.Error(err))
-					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
-					errC <- err
-					break
-				}
-
-				// data doesn't exist yet. skip, and try again later
-				if string(body) == "" {
+				if time.Now().Before(retryAt) {
 					continue
 				}
 
-				if !gjson.Valid(string(body)) {
-					logger.Error("InvalidJson: " + string(body))
-					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
+				pages, err := e.pollEvents(logger)
+				aptosEventPagesPerTick.Observe(float64(pages))
+				if err != nil {
+					if fetchFailed(logger, "events", err) {
+						errC <- err
+						return
+					}
+					retryAt = time.Now().Add(bo.NextBackOff())
 					break
-
 				}
 
-				outcomes := gjson.ParseBytes(body)
-
-				for _, chunk := range outcomes.Array() {
-					native_seq := chunk.Get("sequence_number")
-					if !native_seq.Exists() {
-						continue
+				// Reconciliation failures are only logged, since polling is unaffected.
+				if e.reconcileInterval != 0 && time.Since(e.lastReconcile) >= e.reconcileInterval {
+					e.lastReconcile = time.Now()
+					if err := e.reconcile(logger); err != nil {
+						fetchFailed(logger, "reconcile", err)
 					}
-					if e.next_sequence == 0 {
-						e.next_sequence = native_seq.Uint() + 1
-						break
-					} else {
-						e.next_sequence = native_seq.Uint() + 1
-					}
-
-					data := chunk.Get("data")
-					if !data.Exists() {
-						continue
-					}
-					e.observeData(logger, data, native_seq.Uint())
 				}
 
 				health, err := e.retrievePayload(e.aptosHealth)
 				if err != nil {
-					logger.Error("health", zap.Error(err))
-					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDAptos, 1)
-					errC <- err
+					if fetchFailed(logger, "health", err) {
+						errC <- err
+						return
+					}
+					retryAt = time.Now().Add(bo.NextBackOff())
 					break
 				}
+				bo.Reset()
 
 				if !gjson.Valid(string(health)) {
 					logger.Error("Invalid JSON in health response: " + string(health))
@@ -312,7 +521,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 
 				}
 
-				logger.Info(string(health) + string(body))
+				logger.Info(string(health))
 
 				phealth := gjson.ParseBytes(health)
 
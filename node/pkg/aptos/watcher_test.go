@@ -0,0 +1,272 @@
+package aptos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	testAccount = "0xde0036a9600559e295d5f6802ef6f3f802f510366e0c23912b0655d972166017"
+	testHandle  = "0xde0036a9600559e295d5f6802ef6f3f802f510366e0c23912b0655d972166017::state::WormholeMessageHandle"
+	testType    = "0xde0036a9600559e295d5f6802ef6f3f802f510366e0c23912b0655d972166017::state::WormholeMessage"
+)
+
+func testMessage(sequence string) map[string]string {
+	return map[string]string{
+		"sender":            "1",
+		"payload":           "0x68656c6c6f",
+		"timestamp":         "1650000000",
+		"nonce":             "7",
+		"sequence":          sequence,
+		"consistency_level": "0",
+	}
+}
+
+func TestWatcherObservesNewMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := mockchain.NewAptosServer()
+	defer node.Close()
+	node.SetBlockHeight(100)
+	// Messages emitted before the watcher started are not observed.
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("0")))
+
+	msgC := make(chan *common.MessagePublication, 10)
+	w := NewWatcher(node.URL, testAccount, testHandle, msgC, make(chan *gossipv1.ObservationRequest))
+	supervisor.New(ctx, zap.NewNop(), w.Run)
+
+	eventsPath := "/v1/accounts/" + testAccount + "/events/" + testHandle + "/event"
+	require.Eventually(t, func() bool { return node.Requests(eventsPath) >= 2 }, 10*time.Second, 10*time.Millisecond)
+
+	// A malformed response is skipped.
+	node.FailNext(1, mockchain.Fault{Body: `{"sequence_number":`})
+	// Events of another type exposed through the same handle are rejected.
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, "0x1::state::WormholeMessage", testMessage("1")))
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType+"<u8>", testMessage("2")))
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("3")))
+
+	select {
+	case msg := <-msgC:
+		assert.Equal(t, vaa.ChainIDAptos, msg.EmitterChain)
+		assert.Equal(t, uint64(3), msg.Sequence)
+		assert.Equal(t, uint32(7), msg.Nonce)
+		assert.Equal(t, []byte("hello"), msg.Payload)
+		assert.Equal(t, vaa.Address{31: 1}, msg.EmitterAddress)
+	case <-time.After(10 * time.Second):
+		t.Fatal("message not observed")
+	}
+
+	select {
+	case msg := <-msgC:
+		t.Fatalf("unexpected message %v", msg)
+	default:
+	}
+}
+
+func TestWatcherRetriesTransientErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := mockchain.NewAptosServer()
+	defer node.Close()
+	node.SetBlockHeight(100)
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("0")))
+
+	msgC := make(chan *common.MessagePublication, 10)
+	w := NewWatcher(node.URL, testAccount, testHandle, msgC, make(chan *gossipv1.ObservationRequest))
+	errC := make(chan error, 1)
+	supervisor.New(ctx, zap.NewNop(), func(ctx context.Context) error {
+		err := w.Run(ctx)
+		errC <- err
+		return err
+	})
+
+	eventsPath := "/v1/accounts/" + testAccount + "/events/" + testHandle + "/event"
+	require.Eventually(t, func() bool { return node.Requests(eventsPath) >= 2 }, 10*time.Second, 10*time.Millisecond)
+
+	// A message emitted while the node is unavailable is observed once it recovers, since the watcher keeps its cursor.
+	retryable := testutil.ToFloat64(aptosFetchErrors.WithLabelValues(errorClassRetryable))
+	node.FailNext(2, mockchain.Fault{Status: http.StatusServiceUnavailable})
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("1")))
+
+	select {
+	case msg := <-msgC:
+		assert.Equal(t, uint64(1), msg.Sequence)
+	case err := <-errC:
+		t.Fatalf("watcher stopped: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("message not observed")
+	}
+	assert.Equal(t, retryable+2, testutil.ToFloat64(aptosFetchErrors.WithLabelValues(errorClassRetryable)))
+
+	// A rejected request stops the watcher.
+	fatal := testutil.ToFloat64(aptosFetchErrors.WithLabelValues(errorClassFatal))
+	node.FailNext(1, mockchain.Fault{Status: http.StatusUnauthorized, Body: `{"message":"invalid API key"}`})
+	select {
+	case err := <-errC:
+		assert.ErrorContains(t, err, "invalid API key")
+	case <-time.After(10 * time.Second):
+		t.Fatal("watcher not stopped")
+	}
+	assert.Equal(t, fatal+1, testutil.ToFloat64(aptosFetchErrors.WithLabelValues(errorClassFatal)))
+}
+
+func TestPollEventsPaginates(t *testing.T) {
+	node := mockchain.NewAptosServer()
+	defer node.Close()
+	node.SetMaxPageSize(30)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage(strconv.Itoa(i))))
+	}
+
+	msgC := make(chan *common.MessagePublication, 1000)
+	w := NewWatcher(node.URL, testAccount, testHandle, msgC, make(chan *gossipv1.ObservationRequest))
+	account, err := parseAccountAddress(testAccount)
+	require.NoError(t, err)
+	w.messageType = structTag{address: account, module: "state", name: "WormholeMessage"}
+	w.aptosQuery = node.URL + "/v1/accounts/" + testAccount + "/events/" + testHandle + "/event"
+	w.next_sequence = 900
+
+	// The 100 pending events are observed at once, although the node returns fewer events than requested per page.
+	pages, err := w.pollEvents(zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 5, pages)
+	assert.Equal(t, 100, len(msgC))
+	assert.Equal(t, uint64(1000), w.next_sequence)
+
+	pages, err = w.pollEvents(zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	// A larger backlog is fetched over several ticks.
+	for len(msgC) > 0 {
+		<-msgC
+	}
+	w.next_sequence = 1
+	pages, err = w.pollEvents(zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, maxEventPagesPerTick, pages)
+	assert.Equal(t, uint64(1+30*maxEventPagesPerTick), w.next_sequence)
+	assert.Equal(t, 30*maxEventPagesPerTick, len(msgC))
+}
+
+func TestRetrievePayloadErrorClass(t *testing.T) {
+	node := mockchain.NewAptosServer()
+	w := &Watcher{}
+
+	for status, class := range map[int]string{
+		http.StatusInternalServerError: errorClassRetryable,
+		http.StatusBadGateway:          errorClassRetryable,
+		http.StatusTooManyRequests:     errorClassRetryable,
+		http.StatusBadRequest:          errorClassFatal,
+		http.StatusForbidden:           errorClassFatal,
+		http.StatusNotFound:            errorClassFatal,
+	} {
+		node.FailNext(1, mockchain.Fault{Status: status})
+		_, err := w.retrievePayload(node.URL + "/v1")
+		require.Error(t, err)
+		assert.Equal(t, class, errorClass(err), "status %d", status)
+	}
+
+	_, err := w.retrievePayload(node.URL + "/v1")
+	assert.NoError(t, err)
+
+	// The node is unreachable once closed.
+	node.Close()
+	_, err = w.retrievePayload(node.URL + "/v1")
+	assert.Equal(t, errorClassRetryable, errorClass(err))
+
+	_, err = w.retrievePayload("aptos://node/v1")
+	assert.Equal(t, errorClassFatal, errorClass(err))
+	assert.Equal(t, errorClassFatal, errorClass(errors.New("invalid account")))
+}
+
+func TestParseStructTag(t *testing.T) {
+	tag, err := parseStructTag("0x1::coin::CoinInfo")
+	require.NoError(t, err)
+	assert.Equal(t, structTag{address: [32]byte{31: 1}, module: "coin", name: "CoinInfo"}, tag)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001::coin::CoinInfo", tag.String())
+
+	same, err := parseStructTag(tag.String())
+	require.NoError(t, err)
+	assert.Equal(t, tag, same)
+
+	for _, s := range []string{
+		"",
+		"0x1::coin",
+		"1::coin::CoinInfo",
+		"0x::coin::CoinInfo",
+		"0x1::coin::CoinStore<0x1::aptos_coin::AptosCoin>",
+		"0x1::coin::Coin::Info",
+		"0x1::1coin::CoinInfo",
+		"0xzz::coin::CoinInfo",
+	} {
+		_, err := parseStructTag(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	node := mockchain.NewAptosServer()
+	defer node.Close()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage(strconv.Itoa(i))))
+	}
+
+	msgC := make(chan *common.MessagePublication, 100)
+	w := NewWatcher(node.URL, testAccount, testHandle, msgC, make(chan *gossipv1.ObservationRequest))
+	account, err := parseAccountAddress(testAccount)
+	require.NoError(t, err)
+	w.messageType = structTag{address: account, module: "state", name: "WormholeMessage"}
+	w.aptosQuery = node.URL + "/v1/accounts/" + testAccount + "/events/" + testHandle + "/event"
+	w.aptosResource = node.URL + "/v1/accounts/" + testAccount + "/resource/" + testHandle
+	w.SetReconcile(time.Minute, false)
+	w.next_sequence = 10
+
+	ahead := testutil.ToFloat64(aptosSequenceMismatches.WithLabelValues("ahead"))
+	behind := testutil.ToFloat64(aptosSequenceMismatches.WithLabelValues("behind"))
+
+	// The cursor is in sync with the counter.
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, float64(0), testutil.ToFloat64(aptosSequenceDrift))
+
+	// A counter ahead of the cursor is only a mismatch once the cursor stalled for a whole interval.
+	node.SetEventCounter(testAccount, testHandle, 13)
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, float64(3), testutil.ToFloat64(aptosSequenceDrift))
+	assert.Equal(t, ahead, testutil.ToFloat64(aptosSequenceMismatches.WithLabelValues("ahead")))
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, ahead+1, testutil.ToFloat64(aptosSequenceMismatches.WithLabelValues("ahead")))
+	assert.Equal(t, uint64(10), w.next_sequence)
+
+	// With auto backfill, the events the node has are observed and the missing ones skipped.
+	w.SetReconcile(time.Minute, true)
+	require.NoError(t, node.AddTypedEvent(testAccount, testHandle, testType, testMessage("10")))
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, uint64(13), w.next_sequence)
+	require.Len(t, msgC, 1)
+	assert.Equal(t, uint64(10), (<-msgC).Sequence)
+
+	// A counter behind the cursor is reported at once, and the cursor rewound once it is confirmed.
+	node.SetEventCounter(testAccount, testHandle, 11)
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, behind+1, testutil.ToFloat64(aptosSequenceMismatches.WithLabelValues("behind")))
+	assert.Equal(t, uint64(13), w.next_sequence)
+	require.NoError(t, w.reconcile(zap.NewNop()))
+	assert.Equal(t, uint64(11), w.next_sequence)
+}
@@ -0,0 +1,221 @@
+// Package catchup schedules the processing of the blocks a watcher has fallen behind on.
+//
+// Watchers which process the blocks of a chain in order keep track of the last processed height, and poll the current
+// height of the chain. A Scheduler processes the blocks in between in batches. The batch size starts small, so a
+// watcher that is only a block or two behind is not delayed, and doubles after every successful batch up to a maximum,
+// so a watcher that is far behind catches up quickly. A failed batch resets the batch size, and the next call to CatchUp
+// resumes from the block which failed, so the blocks processed before it are not processed again.
+//
+// While a watcher is catching up, the scheduler exports how far behind it is, and periodically logs its progress with
+// an estimate of the time until it is caught up.
+package catchup
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	processedHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_watcher_processed_height",
+			Help: "Last height processed by the watcher",
+		}, []string{"chain"})
+	blocksBehind = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_watcher_catchup_blocks_behind",
+			Help: "Number of blocks between the last height processed by the watcher and the current height of the chain",
+		}, []string{"chain"})
+	blocksProcessed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_watcher_catchup_blocks_processed_total",
+			Help: "Total number of blocks processed by the watcher",
+		}, []string{"chain"})
+	batchesFailed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_watcher_catchup_batches_failed_total",
+			Help: "Total number of batches of blocks the watcher failed to process",
+		}, []string{"chain"})
+)
+
+// ProcessFunc processes the blocks from the height from to the height to, inclusive, in order. It returns the last height
+// it finished processing, which is from-1 if it failed on the first block, and is ignored if it succeeded.
+type ProcessFunc func(ctx context.Context, from uint64, to uint64) (uint64, error)
+
+// Config configures a Scheduler.
+type Config struct {
+	// Number of blocks of the first batch, and after a failure. Defaults to 1.
+	MinBatch uint64
+	// Maximum number of blocks of a batch. Defaults to 100.
+	MaxBatch uint64
+	// Interval between progress logs while catching up. Defaults to 30s.
+	LogInterval time.Duration
+}
+
+// Scheduler schedules the processing of the blocks of a chain. It is not safe for concurrent use.
+type Scheduler struct {
+	logger *zap.Logger
+	chain  string
+	cfg    Config
+
+	processed uint64
+	current   uint64
+	batch     uint64
+
+	// Progress of the current catch-up, for the time estimate.
+	catchingUp     bool
+	startedAt      time.Time
+	startProcessed uint64
+	lastLog        time.Time
+}
+
+// NewScheduler creates a scheduler for a chain whose blocks up to processed, inclusive, have been processed. The chain
+// name is used in the logs and as the chain label of the metrics, so it must be unique among the watchers.
+func NewScheduler(logger *zap.Logger, chain string, processed uint64, cfg Config) *Scheduler {
+	if cfg.MinBatch == 0 {
+		cfg.MinBatch = 1
+	}
+	if cfg.MaxBatch == 0 {
+		cfg.MaxBatch = 100
+	}
+	if cfg.MaxBatch < cfg.MinBatch {
+		cfg.MaxBatch = cfg.MinBatch
+	}
+	if cfg.LogInterval == 0 {
+		cfg.LogInterval = 30 * time.Second
+	}
+
+	s := &Scheduler{
+		logger:    logger,
+		chain:     chain,
+		cfg:       cfg,
+		processed: processed,
+		current:   processed,
+		batch:     cfg.MinBatch,
+	}
+	processedHeight.WithLabelValues(chain).Set(float64(processed))
+	return s
+}
+
+// Processed returns the last processed height.
+func (s *Scheduler) Processed() uint64 {
+	return s.processed
+}
+
+// Behind returns the number of blocks between the last processed height and the last known current height.
+func (s *Scheduler) Behind() uint64 {
+	if s.current <= s.processed {
+		return 0
+	}
+	return s.current - s.processed
+}
+
+// Reset sets the last processed height, for instance after a rollback of the chain.
+func (s *Scheduler) Reset(processed uint64) {
+	s.processed = processed
+	s.batch = s.cfg.MinBatch
+	s.catchingUp = false
+	s.updateMetrics()
+}
+
+// CatchUp processes the blocks up to the current height, inclusive, in batches. If a batch fails, the blocks finished
+// before the failure are recorded as processed, the batch size is reset and the error is returned. The next call resumes
+// from the block which failed, which is processed again in full. The processor does not deduplicate messages, so the
+// messages of that block published before the failure are published again.
+func (s *Scheduler) CatchUp(ctx context.Context, current uint64, process ProcessFunc) error {
+	s.current = current
+	s.updateMetrics()
+
+	for s.processed < s.current {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		from := s.processed + 1
+		to := s.current
+		if to-from+1 > s.batch {
+			to = from + s.batch - 1
+		}
+
+		if !s.catchingUp && to < s.current {
+			s.catchingUp = true
+			s.startedAt = time.Now()
+			s.startProcessed = s.processed
+			s.lastLog = s.startedAt
+			s.logger.Info("catching up", zap.String("chain", s.chain), zap.Uint64("processed", s.processed), zap.Uint64("current", s.current))
+		}
+
+		done, err := process(ctx, from, to)
+		if err == nil {
+			done = to
+		}
+		if done >= from && done <= to {
+			blocksProcessed.WithLabelValues(s.chain).Add(float64(done - from + 1))
+			s.processed = done
+			s.updateMetrics()
+		}
+		if err != nil {
+			batchesFailed.WithLabelValues(s.chain).Inc()
+			s.batch = s.cfg.MinBatch
+			return err
+		}
+
+		if s.batch < s.cfg.MaxBatch {
+			s.batch *= 2
+			if s.batch > s.cfg.MaxBatch {
+				s.batch = s.cfg.MaxBatch
+			}
+		}
+
+		if s.catchingUp {
+			s.logProgress(time.Now())
+		}
+	}
+
+	if s.catchingUp {
+		s.catchingUp = false
+		s.logger.Info("caught up", zap.String("chain", s.chain), zap.Uint64("processed", s.processed), zap.Duration("took", time.Since(s.startedAt)))
+	}
+	s.batch = s.cfg.MinBatch
+	return nil
+}
+
+// logProgress logs the progress of the catch-up and the estimated time until it is caught up, at most once per log
+// interval.
+func (s *Scheduler) logProgress(now time.Time) {
+	if now.Sub(s.lastLog) < s.cfg.LogInterval {
+		return
+	}
+	s.lastLog = now
+
+	elapsed := now.Sub(s.startedAt)
+	done := s.processed - s.startProcessed
+	fields := []zap.Field{
+		zap.String("chain", s.chain),
+		zap.Uint64("processed", s.processed),
+		zap.Uint64("current", s.current),
+		zap.Uint64("behind", s.Behind()),
+		zap.Uint64("batch", s.batch),
+	}
+	if eta, ok := estimate(done, elapsed, s.Behind()); ok {
+		fields = append(fields, zap.Float64("blocksPerSecond", float64(done)/elapsed.Seconds()), zap.Duration("eta", eta))
+	}
+	s.logger.Info("catching up", fields...)
+}
+
+// estimate returns the time needed to process the remaining blocks at the rate done blocks were processed in elapsed.
+func estimate(done uint64, elapsed time.Duration, remaining uint64) (time.Duration, bool) {
+	if done == 0 || elapsed <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) * float64(remaining) / float64(done)), true
+}
+
+func (s *Scheduler) updateMetrics() {
+	processedHeight.WithLabelValues(s.chain).Set(float64(s.processed))
+	blocksBehind.WithLabelValues(s.chain).Set(float64(s.Behind()))
+}
@@ -0,0 +1,110 @@
+package catchup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type batch struct {
+	from uint64
+	to   uint64
+}
+
+func recordBatches(batches *[]batch) ProcessFunc {
+	return func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+		*batches = append(*batches, batch{from, to})
+		return to, nil
+	}
+}
+
+func TestCatchUpGrowsBatches(t *testing.T) {
+	s := NewScheduler(zap.NewNop(), "test_grow", 10, Config{MaxBatch: 8})
+
+	var batches []batch
+	require.NoError(t, s.CatchUp(context.Background(), 40, recordBatches(&batches)))
+	assert.Equal(t, []batch{{11, 11}, {12, 13}, {14, 17}, {18, 25}, {26, 33}, {34, 40}}, batches)
+	assert.Equal(t, uint64(40), s.Processed())
+	assert.Equal(t, uint64(0), s.Behind())
+	assert.Equal(t, float64(40), testutil.ToFloat64(processedHeight.WithLabelValues("test_grow")))
+	assert.Equal(t, float64(30), testutil.ToFloat64(blocksProcessed.WithLabelValues("test_grow")))
+
+	// Once caught up, the next blocks start with a small batch again.
+	batches = nil
+	require.NoError(t, s.CatchUp(context.Background(), 43, recordBatches(&batches)))
+	assert.Equal(t, []batch{{41, 41}, {42, 43}}, batches)
+
+	// Nothing to do.
+	batches = nil
+	require.NoError(t, s.CatchUp(context.Background(), 43, recordBatches(&batches)))
+	assert.Empty(t, batches)
+}
+
+func TestCatchUpRetriesFailedBatch(t *testing.T) {
+	s := NewScheduler(zap.NewNop(), "test_retry", 0, Config{})
+
+	fail := errors.New("rpc failed")
+	var batches []batch
+	var processed []uint64
+	err := s.CatchUp(context.Background(), 10, func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+		batches = append(batches, batch{from, to})
+		for height := from; height <= to; height++ {
+			if height == 5 {
+				return height - 1, fail
+			}
+			processed = append(processed, height)
+		}
+		return to, nil
+	})
+	assert.Equal(t, fail, err)
+	assert.Equal(t, []batch{{1, 1}, {2, 3}, {4, 7}}, batches)
+	assert.Equal(t, uint64(4), s.Processed())
+	assert.Equal(t, uint64(6), s.Behind())
+	assert.Equal(t, float64(6), testutil.ToFloat64(blocksBehind.WithLabelValues("test_retry")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(batchesFailed.WithLabelValues("test_retry")))
+	assert.Equal(t, float64(4), testutil.ToFloat64(blocksProcessed.WithLabelValues("test_retry")))
+
+	// Only the blocks from the failed one are retried, with the minimum batch size.
+	batches = nil
+	require.NoError(t, s.CatchUp(context.Background(), 10, recordBatches(&batches)))
+	assert.Equal(t, batch{5, 5}, batches[0])
+	assert.Equal(t, uint64(10), s.Processed())
+	assert.Equal(t, []uint64{1, 2, 3, 4}, processed)
+}
+
+func TestCatchUpStopsWhenCanceled(t *testing.T) {
+	s := NewScheduler(zap.NewNop(), "test_cancel", 0, Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := s.CatchUp(ctx, 100, func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+		cancel()
+		return to, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, uint64(1), s.Processed())
+}
+
+func TestReset(t *testing.T) {
+	s := NewScheduler(zap.NewNop(), "test_reset", 0, Config{})
+	require.NoError(t, s.CatchUp(context.Background(), 20, recordBatches(&[]batch{})))
+
+	s.Reset(15)
+	var batches []batch
+	require.NoError(t, s.CatchUp(context.Background(), 18, recordBatches(&batches)))
+	assert.Equal(t, []batch{{16, 16}, {17, 18}}, batches)
+}
+
+func TestEstimate(t *testing.T) {
+	eta, ok := estimate(100, 10*time.Second, 250)
+	assert.True(t, ok)
+	assert.Equal(t, 25*time.Second, eta)
+
+	_, ok = estimate(0, 10*time.Second, 250)
+	assert.False(t, ok)
+}
@@ -21,6 +21,9 @@ type MessagePublication struct {
 	EmitterChain     vaa.ChainID
 	EmitterAddress   vaa.Address
 	Payload          []byte
+
+	// Set by the watchers which record where the message came from. Not serialized by Marshal.
+	Provenance *Provenance
 }
 
 func (msg *MessagePublication) MessageID() []byte {
@@ -31,6 +34,23 @@ func (msg *MessagePublication) MessageIDString() string {
 	return fmt.Sprintf("%v/%v/%v", uint16(msg.EmitterChain), msg.EmitterAddress, msg.Sequence)
 }
 
+// CreateVAA returns the unsigned VAA of the message in a guardian set. All guardians create the exact same VAA for a
+// message and sign its digest, which is how consensus is established.
+func (msg *MessagePublication) CreateVAA(guardianSetIndex uint32) *vaa.VAA {
+	return &vaa.VAA{
+		Version:          vaa.SupportedVAAVersion,
+		GuardianSetIndex: guardianSetIndex,
+		Signatures:       nil,
+		Timestamp:        msg.Timestamp,
+		Nonce:            msg.Nonce,
+		EmitterChain:     msg.EmitterChain,
+		EmitterAddress:   msg.EmitterAddress,
+		Payload:          msg.Payload,
+		Sequence:         msg.Sequence,
+		ConsistencyLevel: msg.ConsistencyLevel,
+	}
+}
+
 const minMsgLength = 88
 
 func (msg *MessagePublication) Marshal() ([]byte, error) {
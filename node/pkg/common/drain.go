@@ -0,0 +1,103 @@
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+// Drain coordinates the graceful shutdown of the node. Once it is started, components stop taking on new work, and
+// each component registered with Add calls Finish once the work it already took on is done.
+type Drain struct {
+	mu      sync.Mutex
+	started chan struct{}
+	pending map[string]bool
+	// Closed once the drain is started and every registered component finished.
+	done chan struct{}
+}
+
+func NewDrain() *Drain {
+	return &Drain{
+		started: make(chan struct{}),
+		pending: make(map[string]bool),
+		done:    make(chan struct{}),
+	}
+}
+
+// Add registers a component which must finish before the node shuts down. It must be called before the drain starts.
+func (d *Drain) Add(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[name] = true
+}
+
+// Start starts the drain. It returns false if it was already started.
+func (d *Drain) Start() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	select {
+	case <-d.started:
+		return false
+	default:
+	}
+
+	close(d.started)
+	d.checkDone()
+	return true
+}
+
+// Started returns a channel which is closed once the drain is started.
+func (d *Drain) Started() <-chan struct{} {
+	return d.started
+}
+
+// IsStarted returns whether the drain is started.
+func (d *Drain) IsStarted() bool {
+	select {
+	case <-d.started:
+		return true
+	default:
+		return false
+	}
+}
+
+// Finish reports that a component finished its work. It may be called more than once.
+func (d *Drain) Finish(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.pending, name)
+	d.checkDone()
+}
+
+// Pending returns the components which did not finish yet.
+func (d *Drain) Pending() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.pending))
+	for name := range d.pending {
+		names = append(names, name)
+	}
+	return names
+}
+
+// checkDone must be called with the mutex held.
+func (d *Drain) checkDone() {
+	if len(d.pending) != 0 || !d.IsStarted() {
+		return
+	}
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+}
+
+// Wait blocks until the drain is started and every registered component finished, or until ctx is done.
+func (d *Drain) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
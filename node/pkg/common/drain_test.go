@@ -0,0 +1,44 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	d := NewDrain()
+	d.Add("processor")
+	d.Add("accountant")
+	assert.False(t, d.IsStarted())
+
+	// Finishing before the drain starts is remembered.
+	d.Finish("accountant")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, d.Wait(ctx))
+
+	assert.True(t, d.Start())
+	assert.False(t, d.Start())
+	assert.True(t, d.IsStarted())
+	<-d.Started()
+	assert.Equal(t, []string{"processor"}, d.Pending())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, d.Wait(ctx))
+
+	d.Finish("processor")
+	d.Finish("processor")
+	assert.NoError(t, d.Wait(context.Background()))
+	assert.Empty(t, d.Pending())
+}
+
+func TestDrainWithoutComponents(t *testing.T) {
+	d := NewDrain()
+	d.Start()
+	assert.NoError(t, d.Wait(context.Background()))
+}
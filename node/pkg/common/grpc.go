@@ -9,8 +9,8 @@ import (
 	"google.golang.org/grpc"
 )
 
-func NewInstrumentedGRPCServer(logger *zap.Logger) *grpc.Server {
-	server := grpc.NewServer(
+func NewInstrumentedGRPCServer(logger *zap.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
 		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
 			grpc_ctxtags.StreamServerInterceptor(),
 			grpc_prometheus.StreamServerInterceptor,
@@ -21,7 +21,8 @@ func NewInstrumentedGRPCServer(logger *zap.Logger) *grpc.Server {
 			grpc_prometheus.UnaryServerInterceptor,
 			grpc_zap.UnaryServerInterceptor(logger),
 		)),
-	)
+	}, opts...)
+	server := grpc.NewServer(opts...)
 
 	grpc_prometheus.EnableHandlingTimeHistogram()
 	grpc_prometheus.Register(server)
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -51,6 +52,8 @@ type GuardianSet struct {
 	Keys []common.Address
 	// On-chain set index
 	Index uint32
+	// Unix time at which the set expires, zero if it does not. Only set for guardian sets fetched from Ethereum.
+	ExpirationTime uint32
 }
 
 func (g *GuardianSet) KeysAsHexStrings() []string {
@@ -75,6 +78,24 @@ func (g *GuardianSet) KeyIndex(addr common.Address) (int, bool) {
 	return -1, false
 }
 
+// SigningKey returns the first of the given guardian key signers whose address is a member of the guardian set, which
+// selects the key matching the active set during a key rotation. Nil signers are skipped. If no key is a member, or the
+// guardian set is nil, the first signer is returned.
+func (g *GuardianSet) SigningKey(keys ...guardiansigner.GuardianSigner) guardiansigner.GuardianSigner {
+	if g != nil {
+		for _, k := range keys {
+			if k == nil {
+				continue
+			}
+			if _, ok := g.KeyIndex(guardiansigner.Address(k)); ok {
+				return k
+			}
+		}
+	}
+
+	return keys[0]
+}
+
 type GuardianSetState struct {
 	mu      sync.Mutex
 	current *GuardianSet
@@ -3,8 +3,11 @@ package common
 import (
 	"testing"
 
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKeyIndex(t *testing.T) {
@@ -92,3 +95,24 @@ func TestGet(t *testing.T) {
 	gss.Set(&gs)
 	assert.Equal(t, gss.Get(), &gs)
 }
+
+func TestSigningKey(t *testing.T) {
+	currentKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	nextKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	current := guardiansigner.NewLocalSigner(currentKey)
+	next := guardiansigner.NewLocalSigner(nextKey)
+
+	oldSet := &GuardianSet{Keys: []common.Address{crypto.PubkeyToAddress(currentKey.PublicKey)}, Index: 0}
+	newSet := &GuardianSet{Keys: []common.Address{crypto.PubkeyToAddress(nextKey.PublicKey)}, Index: 1}
+	otherSet := &GuardianSet{Keys: []common.Address{{1}}, Index: 2}
+
+	assert.Equal(t, current, oldSet.SigningKey(current, next))
+	assert.Equal(t, next, newSet.SigningKey(current, next))
+	assert.Equal(t, current, otherSet.SigningKey(current, next))
+	assert.Equal(t, current, newSet.SigningKey(current, nil))
+
+	var noSet *GuardianSet
+	assert.Equal(t, current, noSet.SigningKey(current, next))
+}
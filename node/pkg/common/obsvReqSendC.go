@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
 )
 
 const ObsvReqChannelSize = 50
@@ -18,3 +19,13 @@ func PostObservationRequest(obsvReqSendC chan<- *gossipv1.ObservationRequest, re
 		return ErrChanFull
 	}
 }
+
+// ObservationRequestTxHash converts the tx hash of a message publication to the encoding expected in an observation request for the chain.
+// Most watchers use the tx hash as is. The Aptos watcher expects the big-endian event sequence number, which it stores in the last
+// eight bytes of the tx hash.
+func ObservationRequestTxHash(chainID vaa.ChainID, txHash []byte) []byte {
+	if chainID == vaa.ChainIDAptos && len(txHash) > 8 {
+		return txHash[len(txHash)-8:]
+	}
+	return txHash
+}
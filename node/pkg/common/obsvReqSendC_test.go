@@ -40,3 +40,12 @@ func TestObsvReqSendLimitEnforced(t *testing.T) {
 	// Make sure we didn't hang.
 	assert.Equal(t, true, done)
 }
+
+func TestObservationRequestTxHash(t *testing.T) {
+	txHash := make([]byte, 32)
+	txHash[31] = 42
+	txHash[0] = 1
+
+	assert.Equal(t, txHash, ObservationRequestTxHash(vaa.ChainIDEthereum, txHash))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 42}, ObservationRequestTxHash(vaa.ChainIDAptos, txHash))
+}
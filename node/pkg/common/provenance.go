@@ -0,0 +1,40 @@
+package common
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/version"
+)
+
+// Provenance records how a watcher obtained a message, to debug disputed observations. It is only kept by this node
+// and is not part of the observation.
+type Provenance struct {
+	// RPC endpoint the message was fetched from, reduced to its scheme and host so that API keys are not kept.
+	RPCEndpoint string `json:"rpcEndpoint"`
+	// Time it took to fetch the message from the RPC endpoint.
+	FetchLatency time.Duration `json:"fetchLatency"`
+	// Chain-specific reference to the block containing the message: its hash, the slot on Solana or the ledger version
+	// on Aptos.
+	Block string `json:"block"`
+	// Version of the node which observed the message.
+	WatcherVersion string `json:"watcherVersion"`
+}
+
+func NewProvenance(rpcEndpoint string, fetchLatency time.Duration, block string) *Provenance {
+	return &Provenance{
+		RPCEndpoint:    RedactRPCEndpoint(rpcEndpoint),
+		FetchLatency:   fetchLatency,
+		Block:          block,
+		WatcherVersion: version.Version(),
+	}
+}
+
+// RedactRPCEndpoint strips the credentials, path and query from an RPC URL, which providers use to carry API keys.
+func RedactRPCEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
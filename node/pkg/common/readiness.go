@@ -1,6 +1,9 @@
 package common
 
-import "github.com/certusone/wormhole/node/pkg/readiness"
+import (
+	"github.com/certusone/wormhole/node/pkg/readiness"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
 
 const (
 	ReadinessEthSyncing        readiness.Component = "ethSyncing"
@@ -25,4 +28,32 @@ const (
 	ReadinessTerra2Syncing     readiness.Component = "terra2Syncing"
 	ReadinessInjectiveSyncing  readiness.Component = "injectiveSyncing"
 	ReadinessPythNetSyncing    readiness.Component = "pythnetSyncing"
+	ReadinessWormchainSyncing  readiness.Component = "wormchainSyncing"
 )
+
+// ReadinessByChain maps each chain to the readiness component of its watcher.
+var ReadinessByChain = map[vaa.ChainID]readiness.Component{
+	vaa.ChainIDSolana:          ReadinessSolanaSyncing,
+	vaa.ChainIDEthereum:        ReadinessEthSyncing,
+	vaa.ChainIDTerra:           ReadinessTerraSyncing,
+	vaa.ChainIDBSC:             ReadinessBSCSyncing,
+	vaa.ChainIDPolygon:         ReadinessPolygonSyncing,
+	vaa.ChainIDAvalanche:       ReadinessAvalancheSyncing,
+	vaa.ChainIDOasis:           ReadinessOasisSyncing,
+	vaa.ChainIDAlgorand:        ReadinessAlgorandSyncing,
+	vaa.ChainIDAurora:          ReadinessAuroraSyncing,
+	vaa.ChainIDFantom:          ReadinessFantomSyncing,
+	vaa.ChainIDKarura:          ReadinessKaruraSyncing,
+	vaa.ChainIDAcala:           ReadinessAcalaSyncing,
+	vaa.ChainIDKlaytn:          ReadinessKlaytnSyncing,
+	vaa.ChainIDCelo:            ReadinessCeloSyncing,
+	vaa.ChainIDNear:            ReadinessNearSyncing,
+	vaa.ChainIDMoonbeam:        ReadinessMoonbeamSyncing,
+	vaa.ChainIDNeon:            ReadinessNeonSyncing,
+	vaa.ChainIDTerra2:          ReadinessTerra2Syncing,
+	vaa.ChainIDInjective:       ReadinessInjectiveSyncing,
+	vaa.ChainIDAptos:           ReadinessAptosSyncing,
+	vaa.ChainIDPythNet:         ReadinessPythNetSyncing,
+	vaa.ChainIDWormchain:       ReadinessWormchainSyncing,
+	vaa.ChainIDEthereumRopsten: ReadinessEthRopstenSyncing,
+}
@@ -0,0 +1,137 @@
+// Package conformance checks the node against golden test vectors, which other guardian implementations can check
+// themselves against too.
+//
+// The digest vectors pin the digest each guardian signs for an observation. Guardians only reach quorum on a message if
+// all of them compute the same digest, so a change of the VAA body serialization, however small, splits the network.
+// The vectors cover the messages observed by the watchers, including the messages of a batch (published with the same
+// nonce by a single transaction), and the governance VAAs injected by the guardians.
+package conformance
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+)
+
+// Kinds of digest vectors.
+const (
+	// A message observed by a watcher, signed by the processor.
+	KindMessage = "message"
+	// A governance VAA, injected by the guardians. Its emitter and consistency level are fixed.
+	KindGovernance = "governance"
+)
+
+// DigestVector is the expected signing body and digest of an observation. Binary fields are hex-encoded, without
+// leading 0x.
+type DigestVector struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Kind        string `json:"kind"`
+
+	GuardianSetIndex uint32 `json:"guardian_set_index"`
+	// Unix time in seconds.
+	Timestamp uint32 `json:"timestamp"`
+	Nonce     uint32 `json:"nonce"`
+	Sequence  uint64 `json:"sequence"`
+	Payload   string `json:"payload"`
+
+	// Only set for messages, governance VAAs having a fixed emitter and consistency level.
+	EmitterChain     uint16 `json:"emitter_chain,omitempty"`
+	EmitterAddress   string `json:"emitter_address,omitempty"`
+	ConsistencyLevel uint8  `json:"consistency_level,omitempty"`
+	// Hash of the transaction which published the message. It must not change the digest.
+	TxHash string `json:"tx_hash,omitempty"`
+
+	// Expected signing body and digest.
+	Body   string `json:"body"`
+	Digest string `json:"digest"`
+}
+
+// DigestVectors is a file of digest vectors.
+type DigestVectors struct {
+	Vectors []*DigestVector `json:"vectors"`
+}
+
+// LoadDigestVectors reads a file of digest vectors.
+func LoadDigestVectors(path string) (*DigestVectors, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectors DigestVectors
+	if err := json.Unmarshal(b, &vectors); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(vectors.Vectors) == 0 {
+		return nil, fmt.Errorf("%s has no vectors", path)
+	}
+	return &vectors, nil
+}
+
+// VAA returns the unsigned VAA the node creates for the observation of the vector, in the same way as the processor.
+func (d *DigestVector) VAA() (*vaa.VAA, error) {
+	payload, err := hex.DecodeString(d.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	timestamp := time.Unix(int64(d.Timestamp), 0)
+
+	switch d.Kind {
+	case KindMessage:
+		emitter, err := vaa.StringToAddress(d.EmitterAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emitter address: %w", err)
+		}
+		var txHash eth_common.Hash
+		if d.TxHash != "" {
+			b, err := hex.DecodeString(d.TxHash)
+			if err != nil || len(b) != len(txHash) {
+				return nil, fmt.Errorf("invalid tx hash: %s", d.TxHash)
+			}
+			txHash = eth_common.BytesToHash(b)
+		}
+		msg := &common.MessagePublication{
+			TxHash:           txHash,
+			Timestamp:        timestamp,
+			Nonce:            d.Nonce,
+			Sequence:         d.Sequence,
+			ConsistencyLevel: d.ConsistencyLevel,
+			EmitterChain:     vaa.ChainID(d.EmitterChain),
+			EmitterAddress:   emitter,
+			Payload:          payload,
+		}
+		return msg.CreateVAA(d.GuardianSetIndex), nil
+	case KindGovernance:
+		return vaa.CreateGovernanceVAA(timestamp, d.Nonce, d.Sequence, d.GuardianSetIndex, payload), nil
+	default:
+		return nil, fmt.Errorf("unknown kind %q", d.Kind)
+	}
+}
+
+// Check returns an error if the signing body or the digest computed for the vector are not the expected ones.
+func (d *DigestVector) Check() error {
+	v, err := d.VAA()
+	if err != nil {
+		return err
+	}
+
+	// The body is the VAA without its header: version, guardian set index and signatures.
+	b, err := v.Marshal()
+	if err != nil {
+		return err
+	}
+	if body := hex.EncodeToString(b[6:]); body != d.Body {
+		return fmt.Errorf("signing body is %s instead of %s", body, d.Body)
+	}
+	if digest := hex.EncodeToString(v.SigningMsg().Bytes()); digest != d.Digest {
+		return fmt.Errorf("digest is %s instead of %s", digest, d.Digest)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package conformance
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestVectors(t *testing.T) map[string]*DigestVector {
+	vectors, err := LoadDigestVectors("testdata/digests.json")
+	require.NoError(t, err)
+
+	byName := make(map[string]*DigestVector)
+	for _, d := range vectors.Vectors {
+		require.NotContains(t, byName, d.Name)
+		byName[d.Name] = d
+	}
+	return byName
+}
+
+func TestDigestVectors(t *testing.T) {
+	for name, d := range loadTestVectors(t) {
+		// The vectors are consistent by themselves: the digest is the double keccak256 of the body.
+		body, err := hex.DecodeString(d.Body)
+		require.NoError(t, err, name)
+		assert.Equal(t, d.Digest, hex.EncodeToString(crypto.Keccak256(crypto.Keccak256(body))), name)
+
+		assert.NoError(t, d.Check(), name)
+	}
+}
+
+func TestDigestVectorsBatch(t *testing.T) {
+	vectors := loadTestVectors(t)
+
+	// The messages of a batch, even with the same payload, have distinct digests.
+	digests := make(map[string]bool)
+	for _, name := range []string{"batch/1", "batch/2", "batch/3"} {
+		d := vectors[name]
+		require.NotNil(t, d, name)
+		assert.False(t, digests[d.Digest], name)
+		digests[d.Digest] = true
+	}
+
+	// The transaction of a message is not part of its digest.
+	d := *vectors["batch/1"]
+	d.TxHash = ""
+	assert.NoError(t, d.Check())
+}
+
+func TestDigestVectorsGovernance(t *testing.T) {
+	vectors := loadTestVectors(t)
+
+	// The payloads are those produced by the serialization of the governance bodies.
+	for name, body := range map[string]interface{ Serialize() []byte }{
+		"governance/guardian-set-update": vaa.BodyGuardianSetUpdate{
+			Keys: []eth_common.Address{
+				eth_common.HexToAddress("58cc3ae5c097b213ce3c81979e1b9f9570746aa5"),
+				eth_common.HexToAddress("ff6cb952589bde862c25ef4392132fb9d4a42157"),
+			},
+			NewIndex: 4,
+		},
+		"governance/contract-upgrade": vaa.BodyContractUpgrade{
+			ChainID:     vaa.ChainIDEthereum,
+			NewContract: vaa.Address(eth_common.HexToHash("3ee18b2214aff97000d974cf647e7c347e8fa585")),
+		},
+		"governance/set-message-fee": vaa.BodySetMessageFee{
+			ChainID:    vaa.ChainIDUnset,
+			MessageFee: big.NewInt(100),
+		},
+	} {
+		d := vectors[name]
+		require.NotNil(t, d, name)
+		assert.Equal(t, d.Payload, hex.EncodeToString(body.Serialize()), name)
+	}
+}
+
+func TestDigestVectorCheckFails(t *testing.T) {
+	d := *loadTestVectors(t)["message/token-transfer"]
+	d.Sequence++
+	assert.Error(t, d.Check())
+
+	d = *loadTestVectors(t)["message/token-transfer"]
+	d.Digest = d.Digest[2:] + "00"
+	assert.Error(t, d.Check())
+
+	d.Kind = "batch"
+	assert.Error(t, d.Check())
+}
@@ -0,0 +1,219 @@
+// Package contractregistry resolves the addresses of the Wormhole contracts on EVM chains from a signed registry.
+//
+// The registry is a governance VAA published by the guardians, which lists the core bridge and token bridge address of
+// each chain along with the keccak256 hash of the deployed core bridge bytecode. Since the contracts are deployed with
+// CREATE2, their addresses are identical for every guardian and can be computed ahead of the deployment.
+//
+// The signatures of the registry are verified against the guardian set of the Ethereum core bridge, which remains
+// configured by flag as the root of trust. The watchers verify the bytecode hash before trusting an address.
+package contractregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	ethAbi "github.com/certusone/wormhole/node/pkg/ethereum/abi"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Contracts holds the contract addresses of a chain.
+type Contracts struct {
+	Core         ethCommon.Address
+	TokenBridge  ethCommon.Address
+	CoreCodeHash ethCommon.Hash
+}
+
+// Registry holds the contract addresses of the chains listed in a registry VAA.
+type Registry struct {
+	Version uint64
+	Chains  map[vaa.ChainID]Contracts
+}
+
+// ParsePayload parses the payload of a contract registry governance VAA.
+func ParsePayload(payload []byte) (*Registry, error) {
+	reader := bytes.NewReader(payload)
+
+	module := make([]byte, 32)
+	if n, err := reader.Read(module); err != nil || n != 32 {
+		return nil, errors.New("failed to read module")
+	}
+	if !bytes.Equal(module, vaa.ContractRegistryModule) {
+		return nil, errors.New("not a contract registry payload")
+	}
+
+	var action uint8
+	if err := binary.Read(reader, binary.BigEndian, &action); err != nil {
+		return nil, fmt.Errorf("failed to read action: %w", err)
+	}
+	if action != 1 {
+		return nil, fmt.Errorf("unknown action %d", action)
+	}
+
+	var targetChain vaa.ChainID
+	if err := binary.Read(reader, binary.BigEndian, &targetChain); err != nil {
+		return nil, fmt.Errorf("failed to read target chain: %w", err)
+	}
+	if targetChain != 0 {
+		return nil, fmt.Errorf("unexpected target chain %v", targetChain)
+	}
+
+	r := &Registry{Chains: make(map[vaa.ChainID]Contracts)}
+	if err := binary.Read(reader, binary.BigEndian, &r.Version); err != nil {
+		return nil, fmt.Errorf("failed to read version: %w", err)
+	}
+
+	var numEntries uint8
+	if err := binary.Read(reader, binary.BigEndian, &numEntries); err != nil {
+		return nil, fmt.Errorf("failed to read number of entries: %w", err)
+	}
+
+	for i := 0; i < int(numEntries); i++ {
+		var e vaa.ContractRegistryEntry
+		if err := binary.Read(reader, binary.BigEndian, &e); err != nil {
+			return nil, fmt.Errorf("failed to read entry %d: %w", i, err)
+		}
+		if _, exists := r.Chains[e.ChainID]; exists {
+			return nil, fmt.Errorf("duplicate entry for %v", e.ChainID)
+		}
+		core, err := evmAddress(e.Core)
+		if err != nil {
+			return nil, fmt.Errorf("invalid core address of %v: %w", e.ChainID, err)
+		}
+		tokenBridge, err := evmAddress(e.TokenBridge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token bridge address of %v: %w", e.ChainID, err)
+		}
+		r.Chains[e.ChainID] = Contracts{
+			Core:         core,
+			TokenBridge:  tokenBridge,
+			CoreCodeHash: e.CoreCodeHash,
+		}
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", reader.Len())
+	}
+
+	return r, nil
+}
+
+// evmAddress converts a left-padded 32 byte address to an EVM address.
+func evmAddress(addr vaa.Address) (ethCommon.Address, error) {
+	if !bytes.Equal(addr[:12], make([]byte, 12)) {
+		return ethCommon.Address{}, errors.New("expected a 20 byte address, left-padded with zeros")
+	}
+	return ethCommon.BytesToAddress(addr[12:]), nil
+}
+
+// ParseVAA parses a contract registry governance VAA. The signatures are not verified.
+func ParseVAA(v *vaa.VAA) (*Registry, error) {
+	if v.EmitterChain != vaa.GovernanceChain || v.EmitterAddress != vaa.GovernanceEmitter {
+		return nil, fmt.Errorf("not a governance VAA (emitter %v:%v)", v.EmitterChain, v.EmitterAddress)
+	}
+	return ParsePayload(v.Payload)
+}
+
+// ReadVAA reads a hex-encoded signed VAA from a file.
+func ReadVAA(path string) (*vaa.VAA, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(b)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode hex: %w", err)
+	}
+
+	return vaa.Unmarshal(b)
+}
+
+// Verify checks that the VAA carries a quorum of valid signatures of the guardian set it refers to. The guardian set
+// must be the current guardian set, or a previous one which has not expired yet.
+func Verify(v *vaa.VAA, gs ethAbi.StructsGuardianSet, current uint32, now time.Time) error {
+	if v.GuardianSetIndex != current && (gs.ExpirationTime == 0 || now.Unix() >= int64(gs.ExpirationTime)) {
+		return fmt.Errorf("guardian set %d has expired", v.GuardianSetIndex)
+	}
+
+	if len(gs.Keys) == 0 {
+		return fmt.Errorf("guardian set %d is empty", v.GuardianSetIndex)
+	}
+
+	if !v.VerifySignatures(gs.Keys) {
+		return errors.New("invalid signatures")
+	}
+
+	if !vaa.HasQuorum(len(v.Signatures), len(gs.Keys)) {
+		return fmt.Errorf("not enough signatures (%d, quorum is %d)", len(v.Signatures), vaa.CalculateQuorum(len(gs.Keys)))
+	}
+
+	return nil
+}
+
+// Load reads the registry VAA from a file and verifies its signatures against the guardian sets of the Ethereum core
+// bridge at ethContract.
+func Load(ctx context.Context, path string, ethRPC string, ethContract ethCommon.Address) (*Registry, error) {
+	v, err := ReadVAA(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry VAA: %w", err)
+	}
+
+	r, err := ParseVAA(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry VAA: %w", err)
+	}
+
+	client, err := ethclient.DialContext(ctx, ethRPC)
+	if err != nil {
+		return nil, fmt.Errorf("dialing eth client failed: %w", err)
+	}
+	defer client.Close()
+
+	caller, err := ethAbi.NewAbiCaller(ethContract, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create eth caller: %w", err)
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	current, err := caller.GetCurrentGuardianSetIndex(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current guardian set index: %w", err)
+	}
+
+	gs, err := caller.GetGuardianSet(opts, v.GuardianSetIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardian set %d: %w", v.GuardianSetIndex, err)
+	}
+
+	if err := Verify(v, gs, current, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to verify registry VAA: %w", err)
+	}
+
+	return r, nil
+}
+
+// Contract returns the contracts of a chain, if the registry lists them. It is safe to call on a nil registry.
+func (r *Registry) Contract(chainID vaa.ChainID) (Contracts, bool) {
+	if r == nil {
+		return Contracts{}, false
+	}
+	c, ok := r.Chains[chainID]
+	return c, ok
+}
+
+// CodeHash returns the hash of the core bridge bytecode of a chain, or the zero hash if the registry does not list the
+// chain. It is safe to call on a nil registry.
+func (r *Registry) CodeHash(chainID vaa.ChainID) ethCommon.Hash {
+	c, _ := r.Contract(chainID)
+	return c.CoreCodeHash
+}
@@ -0,0 +1,147 @@
+package contractregistry
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ethAbi "github.com/certusone/wormhole/node/pkg/ethereum/abi"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	bscCore        = ethCommon.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550")
+	bscTokenBridge = ethCommon.HexToAddress("0x0290FB167208Af455bB137780163b7B7a9a10C16")
+	bscCodeHash    = crypto.Keccak256Hash([]byte("core bridge bytecode"))
+)
+
+func registryVAA() *vaa.VAA {
+	return vaa.CreateGovernanceVAA(time.Unix(1_700_000_000, 0), 1, 1, 0, vaa.BodyContractRegistry{
+		Version: 3,
+		Entries: []vaa.ContractRegistryEntry{{
+			ChainID:      vaa.ChainIDBSC,
+			Core:         vaa.Address(ethCommon.BytesToHash(bscCore.Bytes())),
+			TokenBridge:  vaa.Address(ethCommon.BytesToHash(bscTokenBridge.Bytes())),
+			CoreCodeHash: bscCodeHash,
+		}},
+	}.Serialize())
+}
+
+func guardianKeys(t *testing.T, n int) ([]*ecdsa.PrivateKey, []ethCommon.Address) {
+	t.Helper()
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]ethCommon.Address, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys[i] = key
+		addrs[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addrs
+}
+
+func TestParseVAA(t *testing.T) {
+	r, err := ParseVAA(registryVAA())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), r.Version)
+	assert.Equal(t, map[vaa.ChainID]Contracts{
+		vaa.ChainIDBSC: {Core: bscCore, TokenBridge: bscTokenBridge, CoreCodeHash: bscCodeHash},
+	}, r.Chains)
+
+	// Not from the governance emitter.
+	v := registryVAA()
+	v.EmitterAddress = vaa.Address{1}
+	_, err = ParseVAA(v)
+	assert.Error(t, err)
+
+	// Not a registry.
+	v = registryVAA()
+	v.Payload = vaa.BodySetMessageFee{ChainID: vaa.ChainIDBSC, MessageFee: big.NewInt(1)}.Serialize()
+	_, err = ParseVAA(v)
+	assert.Error(t, err)
+
+	// Truncated.
+	v = registryVAA()
+	v.Payload = v.Payload[:len(v.Payload)-1]
+	_, err = ParseVAA(v)
+	assert.Error(t, err)
+
+	// Not an EVM address.
+	_, err = ParsePayload(vaa.BodyContractRegistry{
+		Entries: []vaa.ContractRegistryEntry{{ChainID: vaa.ChainIDBSC, Core: vaa.Address{1}}},
+	}.Serialize())
+	assert.Error(t, err)
+
+	// Duplicate chain.
+	_, err = ParsePayload(vaa.BodyContractRegistry{
+		Entries: []vaa.ContractRegistryEntry{{ChainID: vaa.ChainIDBSC}, {ChainID: vaa.ChainIDBSC}},
+	}.Serialize())
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	keys, addrs := guardianKeys(t, 4)
+	gs := ethAbi.StructsGuardianSet{Keys: addrs}
+
+	v := registryVAA()
+	for i, key := range keys[:3] {
+		v.AddSignature(key, uint8(i))
+	}
+	assert.NoError(t, Verify(v, gs, 0, now))
+
+	// A previous guardian set is accepted until it expires.
+	assert.NoError(t, Verify(v, ethAbi.StructsGuardianSet{Keys: addrs, ExpirationTime: uint32(now.Unix() + 1)}, 1, now))
+	assert.Error(t, Verify(v, ethAbi.StructsGuardianSet{Keys: addrs, ExpirationTime: uint32(now.Unix())}, 1, now))
+	assert.Error(t, Verify(v, gs, 1, now))
+
+	// Below quorum.
+	v = registryVAA()
+	for i, key := range keys[:2] {
+		v.AddSignature(key, uint8(i))
+	}
+	assert.Error(t, Verify(v, gs, 0, now))
+
+	// Signed by the wrong guardians.
+	v = registryVAA()
+	for i, key := range keys[1:] {
+		v.AddSignature(key, uint8(i))
+	}
+	assert.Error(t, Verify(v, gs, 0, now))
+}
+
+func TestReadVAA(t *testing.T) {
+	keys, _ := guardianKeys(t, 1)
+	v := registryVAA()
+	v.AddSignature(keys[0], 0)
+	b, err := v.Marshal()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "registry.vaa")
+	require.NoError(t, os.WriteFile(path, []byte("0x"+hex.EncodeToString(b)+"\n"), 0600))
+
+	read, err := ReadVAA(path)
+	require.NoError(t, err)
+	assert.Equal(t, v.SigningMsg(), read.SigningMsg())
+	assert.Equal(t, v.Signatures, read.Signatures)
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	_, ok := r.Contract(vaa.ChainIDBSC)
+	assert.False(t, ok)
+	assert.Equal(t, ethCommon.Hash{}, r.CodeHash(vaa.ChainIDBSC))
+
+	r, err := ParseVAA(registryVAA())
+	require.NoError(t, err)
+	assert.Equal(t, bscCodeHash, r.CodeHash(vaa.ChainIDBSC))
+	assert.Equal(t, ethCommon.Hash{}, r.CodeHash(vaa.ChainIDPolygon))
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+)
+
+type AccountantDB interface {
+	StoreAccountantTransfer(msgID string, digest []byte, balances []*AccountantBalance) error
+	GetAccountantTransferDigest(msgID string) ([]byte, error)
+	GetAccountantBalances() ([]*AccountantBalance, error)
+}
+
+// AccountantBalance is the amount of a token held by the token bridge on a chain, as tracked by the accountant.
+// On the origin chain of the token, this is the amount locked in custody. On other chains, it is the wrapped supply.
+type AccountantBalance struct {
+	Ledger       string // Empty for the token bridge ledger.
+	Chain        vaa.ChainID
+	TokenChain   vaa.ChainID
+	TokenAddress vaa.Address
+	Amount       *big.Int
+}
+
+func (b *AccountantBalance) Marshal() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	vaa.MustWrite(buf, binary.BigEndian, b.Chain)
+	vaa.MustWrite(buf, binary.BigEndian, b.TokenChain)
+	buf.Write(b.TokenAddress[:])
+
+	// Balances can only become negative in log only mode, but the sign still needs to survive a restart.
+	var negative uint8
+	if b.Amount.Sign() < 0 {
+		negative = 1
+	}
+	vaa.MustWrite(buf, binary.BigEndian, negative)
+	buf.Write(b.Amount.Bytes())
+	return buf.Bytes(), nil
+}
+
+func UnmarshalAccountantBalance(data []byte) (*AccountantBalance, error) {
+	b := &AccountantBalance{}
+
+	reader := bytes.NewReader(data[:])
+
+	if err := binary.Read(reader, binary.BigEndian, &b.Chain); err != nil {
+		return nil, fmt.Errorf("failed to read chain id: %w", err)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &b.TokenChain); err != nil {
+		return nil, fmt.Errorf("failed to read token chain id: %w", err)
+	}
+
+	tokenAddress := vaa.Address{}
+	if n, err := reader.Read(tokenAddress[:]); err != nil || n != 32 {
+		return nil, fmt.Errorf("failed to read token address [%d]: %w", n, err)
+	}
+	b.TokenAddress = tokenAddress
+
+	var negative uint8
+	if err := binary.Read(reader, binary.BigEndian, &negative); err != nil {
+		return nil, fmt.Errorf("failed to read sign: %w", err)
+	}
+
+	amount := make([]byte, reader.Len())
+	if _, err := reader.Read(amount); err != nil && reader.Len() != 0 {
+		return nil, fmt.Errorf("failed to read amount: %w", err)
+	}
+	b.Amount = new(big.Int).SetBytes(amount)
+	if negative != 0 {
+		b.Amount.Neg(b.Amount)
+	}
+
+	return b, nil
+}
+
+const accountantBalance = "ACCT:BAL:"
+const accountantLedgerBalance = "ACCT:LBAL:"
+const accountantTransfer = "ACCT:XFER:"
+
+// Balances in the token bridge ledger are stored under their own prefix, without the ledger name.
+func AccountantBalanceID(b *AccountantBalance) []byte {
+	if b.Ledger != "" {
+		return []byte(fmt.Sprintf("%v%s/%d/%d/%v", accountantLedgerBalance, b.Ledger, b.Chain, b.TokenChain, b.TokenAddress))
+	}
+	return []byte(fmt.Sprintf("%v%d/%d/%v", accountantBalance, b.Chain, b.TokenChain, b.TokenAddress))
+}
+
+// Balances in other ledgers are stored with the length prefixed ledger name in front of the balance.
+func marshalAccountantLedgerBalance(b *AccountantBalance) ([]byte, error) {
+	if len(b.Ledger) > 255 {
+		return nil, fmt.Errorf("ledger name is too long")
+	}
+
+	data, err := b.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(uint8(len(b.Ledger)))
+	buf.WriteString(b.Ledger)
+	buf.Write(data)
+	return buf.Bytes(), nil
+}
+
+func unmarshalAccountantLedgerBalance(data []byte) (*AccountantBalance, error) {
+	if len(data) == 0 || len(data) < 1+int(data[0]) {
+		return nil, fmt.Errorf("failed to read ledger name")
+	}
+
+	ledger := string(data[1 : 1+int(data[0])])
+	b, err := UnmarshalAccountantBalance(data[1+int(data[0]):])
+	if err != nil {
+		return nil, err
+	}
+
+	b.Ledger = ledger
+	return b, nil
+}
+
+func AccountantTransferID(msgID string) []byte {
+	return []byte(fmt.Sprintf("%v%v", accountantTransfer, msgID))
+}
+
+// This is called by the accountant to record a transfer and the resulting balances in a single transaction.
+func (d *Database) StoreAccountantTransfer(msgID string, digest []byte, balances []*AccountantBalance) error {
+	err := d.db.Update(func(txn *badger.Txn) error {
+		for _, b := range balances {
+			var data []byte
+			var err error
+			if b.Ledger != "" {
+				data, err = marshalAccountantLedgerBalance(b)
+			} else {
+				data, err = b.Marshal()
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := txn.Set(AccountantBalanceID(b), data); err != nil {
+				return err
+			}
+		}
+
+		return txn.Set(AccountantTransferID(msgID), digest)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to commit accountant transfer tx: %w", err)
+	}
+
+	return nil
+}
+
+// This is called by the accountant to check whether a transfer has already been accounted for. Returns nil if it has not.
+func (d *Database) GetAccountantTransferDigest(msgID string) (digest []byte, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(AccountantTransferID(msgID))
+		if err != nil {
+			return err
+		}
+		digest, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+
+	return
+}
+
+// This is called by the accountant on start up to reload the balances.
+func (d *Database) GetAccountantBalances() (balances []*AccountantBalance, err error) {
+	balances, err = d.getAccountantBalances(accountantBalance, UnmarshalAccountantBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerBalances, err := d.getAccountantBalances(accountantLedgerBalance, unmarshalAccountantLedgerBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(balances, ledgerBalances...), nil
+}
+
+func (d *Database) getAccountantBalances(prefix string, unmarshal func([]byte) (*AccountantBalance, error)) (balances []*AccountantBalance, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			b, err := unmarshal(val)
+			if err != nil {
+				return err
+			}
+
+			balances = append(balances, b)
+		}
+		return nil
+	})
+
+	return
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeAndDeserializeOfAccountantBalance(t *testing.T) {
+	tokenAddr, err := vaa.StringToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	require.NoError(t, err)
+
+	for _, amount := range []int64{0, 1000, -1000} {
+		b := &AccountantBalance{Chain: vaa.ChainIDSolana, TokenChain: vaa.ChainIDEthereum, TokenAddress: tokenAddr, Amount: big.NewInt(amount)}
+		data, err := b.Marshal()
+		require.NoError(t, err)
+
+		b2, err := UnmarshalAccountantBalance(data)
+		require.NoError(t, err)
+		assert.Equal(t, b.Chain, b2.Chain)
+		assert.Equal(t, b.TokenChain, b2.TokenChain)
+		assert.Equal(t, b.TokenAddress, b2.TokenAddress)
+		assert.Equal(t, 0, b.Amount.Cmp(b2.Amount))
+	}
+}
+
+func TestStoreAndReloadAccountantLedgerBalances(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	tokenAddr, err := vaa.StringToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	require.NoError(t, err)
+
+	b1 := &AccountantBalance{Chain: vaa.ChainIDEthereum, TokenChain: vaa.ChainIDEthereum, TokenAddress: tokenAddr, Amount: big.NewInt(1000)}
+	b2 := &AccountantBalance{Ledger: "test-ntt", Chain: vaa.ChainIDEthereum, TokenChain: vaa.ChainIDEthereum, TokenAddress: tokenAddr, Amount: big.NewInt(2000)}
+	require.NoError(t, db.StoreAccountantTransfer("2/0000000000000000000000000000000000000000000000000000000000000001/1", []byte{1}, []*AccountantBalance{b1, b2}))
+
+	balances, err := db.GetAccountantBalances()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(balances))
+
+	byLedger := make(map[string]*AccountantBalance)
+	for _, b := range balances {
+		byLedger[b.Ledger] = b
+	}
+
+	require.NotNil(t, byLedger[""])
+	assert.Equal(t, int64(1000), byLedger[""].Amount.Int64())
+	require.NotNil(t, byLedger["test-ntt"])
+	assert.Equal(t, int64(2000), byLedger["test-ntt"].Amount.Int64())
+	assert.Equal(t, tokenAddr, byLedger["test-ntt"].TokenAddress)
+
+	digest, err := db.GetAccountantTransferDigest("2/0000000000000000000000000000000000000000000000000000000000000001/1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, digest)
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+)
+
+// GuardianAvailability is the number of times the availability of a guardian on a chain was sampled during a day, and
+// how many of those samples found it available. Chain ID zero counts the samples in which the guardian sent heartbeats.
+type GuardianAvailability struct {
+	// Midnight UTC.
+	Day       time.Time
+	Guardian  ethCommon.Address
+	Chain     vaa.ChainID
+	Samples   uint32
+	Available uint32
+}
+
+func (a *GuardianAvailability) Marshal() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	vaa.MustWrite(buf, binary.BigEndian, uint32(a.Day.Unix()))
+	buf.Write(a.Guardian[:])
+	vaa.MustWrite(buf, binary.BigEndian, a.Chain)
+	vaa.MustWrite(buf, binary.BigEndian, a.Samples)
+	vaa.MustWrite(buf, binary.BigEndian, a.Available)
+	return buf.Bytes(), nil
+}
+
+func UnmarshalGuardianAvailability(data []byte) (*GuardianAvailability, error) {
+	a := &GuardianAvailability{}
+
+	reader := bytes.NewReader(data[:])
+
+	unixSeconds := uint32(0)
+	if err := binary.Read(reader, binary.BigEndian, &unixSeconds); err != nil {
+		return nil, fmt.Errorf("failed to read day: %w", err)
+	}
+	a.Day = time.Unix(int64(unixSeconds), 0).UTC()
+
+	if n, err := reader.Read(a.Guardian[:]); err != nil || n != len(a.Guardian) {
+		return nil, fmt.Errorf("failed to read guardian [%d]: %w", n, err)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &a.Chain); err != nil {
+		return nil, fmt.Errorf("failed to read chain id: %w", err)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &a.Samples); err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &a.Available); err != nil {
+		return nil, fmt.Errorf("failed to read available: %w", err)
+	}
+
+	return a, nil
+}
+
+const availability = "AVAIL:"
+
+// The day comes first in the key, so the aggregates are ordered by day.
+func availabilityDayPrefix(day time.Time) []byte {
+	return []byte(fmt.Sprintf("%v%s/", availability, day.UTC().Format("20060102")))
+}
+
+func GuardianAvailabilityID(a *GuardianAvailability) []byte {
+	return []byte(fmt.Sprintf("%s%s/%d", availabilityDayPrefix(a.Day), a.Guardian.Hex(), a.Chain))
+}
+
+// StoreGuardianAvailability stores daily availability aggregates, replacing those of the same day, guardian and chain.
+func (d *Database) StoreGuardianAvailability(aggregates []*GuardianAvailability) error {
+	err := d.db.Update(func(txn *badger.Txn) error {
+		for _, a := range aggregates {
+			b, err := a.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(GuardianAvailabilityID(a), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to commit guardian availability tx: %w", err)
+	}
+
+	return nil
+}
+
+// GetGuardianAvailability returns the daily availability aggregates from the day of since onwards.
+func (d *Database) GetGuardianAvailability(since time.Time) (aggregates []*GuardianAvailability, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(availability)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(availabilityDayPrefix(since)); it.ValidForPrefix(opts.Prefix); it.Next() {
+			data, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			a, err := UnmarshalGuardianAvailability(data)
+			if err != nil {
+				return err
+			}
+			aggregates = append(aggregates, a)
+		}
+		return nil
+	})
+
+	return
+}
+
+// PruneGuardianAvailability deletes the daily availability aggregates of the days before the day of before, and
+// returns how many were deleted.
+func (d *Database) PruneGuardianAvailability(before time.Time) (int, error) {
+	var keys [][]byte
+	err := d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(availability)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		end := availabilityDayPrefix(before)
+		for it.Rewind(); it.Valid() && bytes.Compare(it.Item().Key(), end) < 0; it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wb := d.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, k := range keys {
+		if err := wb.Delete(k); err != nil {
+			return 0, fmt.Errorf("failed to delete guardian availability: %w", err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to delete guardian availability: %w", err)
+	}
+
+	return len(keys), nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeAndDeserializeOfGuardianAvailability(t *testing.T) {
+	a := &GuardianAvailability{
+		Day:       time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC),
+		Guardian:  ethCommon.HexToAddress("0xbeFA429d57cD18b7F8A4d91A2da9AB4AF05d0FBe"),
+		Chain:     vaa.ChainIDSolana,
+		Samples:   1440,
+		Available: 1437,
+	}
+
+	data, err := a.Marshal()
+	require.NoError(t, err)
+
+	a2, err := UnmarshalGuardianAvailability(data)
+	require.NoError(t, err)
+	assert.Equal(t, a, a2)
+
+	_, err = UnmarshalGuardianAvailability(data[:len(data)-1])
+	assert.Error(t, err)
+}
+
+func TestStoreAndPruneGuardianAvailability(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	guardian := ethCommon.HexToAddress("0xbeFA429d57cD18b7F8A4d91A2da9AB4AF05d0FBe")
+	day := time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC)
+
+	var aggregates []*GuardianAvailability
+	for i := 0; i < 3; i++ {
+		aggregates = append(aggregates,
+			&GuardianAvailability{Day: day.AddDate(0, 0, i), Guardian: guardian, Chain: vaa.ChainIDUnset, Samples: 10, Available: 10},
+			&GuardianAvailability{Day: day.AddDate(0, 0, i), Guardian: guardian, Chain: vaa.ChainIDEthereum, Samples: 10, Available: uint32(i)},
+		)
+	}
+	require.NoError(t, db.StoreGuardianAvailability(aggregates))
+
+	// Storing again replaces the aggregate.
+	require.NoError(t, db.StoreGuardianAvailability([]*GuardianAvailability{
+		{Day: day, Guardian: guardian, Chain: vaa.ChainIDEthereum, Samples: 20, Available: 5},
+	}))
+
+	all, err := db.GetGuardianAvailability(day)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(all))
+	assert.Equal(t, uint32(20), all[1].Samples)
+
+	// Any time of the day includes the whole day.
+	recent, err := db.GetGuardianAvailability(day.AddDate(0, 0, 1).Add(12 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, aggregates[2:], recent)
+
+	n, err := db.PruneGuardianAvailability(day.AddDate(0, 0, 2).Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	all, err = db.GetGuardianAvailability(time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, aggregates[4:], all)
+}
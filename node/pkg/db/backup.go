@@ -0,0 +1,269 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// A backup starts with backupMagic, followed by sections. Each section is a kind byte followed by the section data,
+// split into chunks prefixed with their length as a big endian uint32 and terminated by an empty chunk. The last
+// section is the manifest, which holds the checksum of the other sections.
+var backupMagic = []byte("WHDBBK01")
+
+const (
+	// BadgerDB backup stream of the node's state, except for the signed VAAs.
+	backupSectionState byte = 1
+	// VAA archive of the signed VAAs.
+	backupSectionVAAs byte = 2
+	// JSON encoded BackupManifest.
+	backupSectionManifest byte = 3
+)
+
+var backupSectionNames = map[byte]string{
+	backupSectionState: "state",
+	backupSectionVAAs:  "vaas",
+}
+
+// BackupManifest describes the content of a backup.
+type BackupManifest struct {
+	CreatedAt  time.Time       `json:"createdAt"`
+	SignedVAAs uint64          `json:"signedVAAs"`
+	Sections   []BackupSection `json:"sections"`
+}
+
+type BackupSection struct {
+	Name   string `json:"name"`
+	Size   uint64 `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// chunkWriter frames each write as a chunk.
+type chunkWriter struct {
+	w io.Writer
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := binary.Write(c.w, binary.BigEndian, uint32(len(p))); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+// writeBackupSection writes a section with the data written by fn and returns its description.
+func writeBackupSection(w io.Writer, kind byte, fn func(w io.Writer) error) (BackupSection, error) {
+	if _, err := w.Write([]byte{kind}); err != nil {
+		return BackupSection{}, err
+	}
+
+	h := sha256.New()
+	counter := &countingWriter{}
+	bw := bufio.NewWriterSize(&chunkWriter{w}, 64<<10)
+	if err := fn(io.MultiWriter(bw, h, counter)); err != nil {
+		return BackupSection{}, err
+	}
+	if err := bw.Flush(); err != nil {
+		return BackupSection{}, err
+	}
+
+	// Terminating empty chunk
+	if err := binary.Write(w, binary.BigEndian, uint32(0)); err != nil {
+		return BackupSection{}, err
+	}
+
+	return BackupSection{Name: backupSectionNames[kind], Size: counter.n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+type countingWriter struct {
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += uint64(len(p))
+	return len(p), nil
+}
+
+// Backup writes a backup of the database to w. It can run while the node is live: the state is read from a
+// consistent snapshot of the BadgerDB, and the signed VAAs are read from a snapshot of the VAA store taken right after.
+func (d *Database) Backup(w io.Writer) (*BackupManifest, error) {
+	manifest := &BackupManifest{CreatedAt: time.Now().UTC()}
+
+	if _, err := w.Write(backupMagic); err != nil {
+		return nil, err
+	}
+
+	section, err := writeBackupSection(w, backupSectionState, func(w io.Writer) error {
+		stream := d.db.NewStream()
+		stream.LogPrefix = "DB.Backup"
+		// Signed VAAs are backed up separately, since they may be kept in another store. Their time index and checksums
+		// are rebuilt when they are restored.
+		stream.ChooseKey = func(item *badger.Item) bool {
+			key := item.Key()
+			return !bytes.HasPrefix(key, []byte("signed/")) && !bytes.HasPrefix(key, []byte(timeIndexPrefix)) &&
+				!bytes.HasPrefix(key, []byte(checksumPrefix))
+		}
+		_, err := stream.Backup(w, 0)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up state: %w", err)
+	}
+	manifest.Sections = append(manifest.Sections, section)
+
+	section, err = writeBackupSection(w, backupSectionVAAs, func(w io.Writer) error {
+		return d.IterateSignedVAAs(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+			manifest.SignedVAAs++
+			return WriteVAAArchiveEntry(w, b)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up signed VAAs: %w", err)
+	}
+	manifest.Sections = append(manifest.Sections, section)
+
+	_, err = writeBackupSection(w, backupSectionManifest, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(manifest)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// sectionReader reads the data of a section until its terminating chunk, hashing it.
+type sectionReader struct {
+	r         io.Reader
+	remaining uint32
+	done      bool
+	h         hash.Hash
+	n         uint64
+}
+
+func (s *sectionReader) Read(p []byte) (int, error) {
+	for s.remaining == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := binary.Read(s.r, binary.BigEndian, &s.remaining); err != nil {
+			return 0, fmt.Errorf("failed to read chunk length: %w", err)
+		}
+		if s.remaining == 0 {
+			s.done = true
+		}
+	}
+
+	if uint32(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+	n, err := s.r.Read(p)
+	s.remaining -= uint32(n)
+	s.n += uint64(n)
+	s.h.Write(p[:n])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// readBackup reads a backup, passing the reader of each data section to fn, which must consume it. The checksums of
+// the sections are verified against the manifest once it is read.
+func readBackup(r io.Reader, fn func(kind byte, r io.Reader) error) (*BackupManifest, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, backupMagic) {
+		return nil, errors.New("not a database backup")
+	}
+
+	var sections []BackupSection
+	for {
+		kind, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read section: %w", err)
+		}
+
+		s := &sectionReader{r: br, h: sha256.New()}
+		if kind == backupSectionManifest {
+			var manifest BackupManifest
+			if err := json.NewDecoder(s).Decode(&manifest); err != nil {
+				return nil, fmt.Errorf("failed to decode manifest: %w", err)
+			}
+			if _, err := io.Copy(io.Discard, s); err != nil {
+				return nil, err
+			}
+
+			if len(manifest.Sections) != len(sections) {
+				return nil, fmt.Errorf("the manifest lists %d sections, found %d", len(manifest.Sections), len(sections))
+			}
+			for i, expected := range manifest.Sections {
+				if sections[i] != expected {
+					return nil, fmt.Errorf("section %s does not match the manifest: got %d bytes with checksum %s, expected %d bytes with checksum %s",
+						expected.Name, sections[i].Size, sections[i].SHA256, expected.Size, expected.SHA256)
+				}
+			}
+			return &manifest, nil
+		}
+
+		name, ok := backupSectionNames[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown section kind %d", kind)
+		}
+		if err := fn(kind, s); err != nil {
+			return nil, fmt.Errorf("failed to read section %s: %w", name, err)
+		}
+		// Drain what fn did not consume, so the checksum covers the whole section.
+		if _, err := io.Copy(io.Discard, s); err != nil {
+			return nil, fmt.Errorf("failed to read section %s: %w", name, err)
+		}
+		sections = append(sections, BackupSection{Name: name, Size: s.n, SHA256: hex.EncodeToString(s.h.Sum(nil))})
+	}
+}
+
+// VerifyBackup reads a backup and verifies it against its manifest.
+func VerifyBackup(r io.Reader) (*BackupManifest, error) {
+	return readBackup(r, func(kind byte, r io.Reader) error {
+		if kind != backupSectionVAAs {
+			return nil
+		}
+		return ReadVAAArchive(r, func(b []byte) error {
+			_, err := vaa.Unmarshal(b)
+			return err
+		})
+	})
+}
+
+// Restore loads a backup into the database, which should be empty and must not be used concurrently. Verify the
+// backup with VerifyBackup first: the checksums are only checked once all sections have been loaded.
+func (d *Database) Restore(r io.Reader) (*BackupManifest, error) {
+	return readBackup(r, func(kind byte, r io.Reader) error {
+		switch kind {
+		case backupSectionState:
+			return d.db.Load(r, 256)
+		case backupSectionVAAs:
+			return ReadVAAArchive(r, func(b []byte) error {
+				v, err := vaa.Unmarshal(b)
+				if err != nil {
+					return err
+				}
+				return d.vaas.PutVAA(VaaIDFromVAA(v), b)
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"bytes"
+	"math"
+	"path"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	src, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer src.Close()
+
+	storeTestVAAs(t, src)
+	id := VAAID{EmitterChain: vaa.ChainIDSolana, EmitterAddress: vaa.Address{1}, Sequence: 1}
+	require.NoError(t, src.StoreMessageTxHash(id, []byte{1, 2, 3}))
+
+	var backup bytes.Buffer
+	manifest, err := src.Backup(&backup)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(48), manifest.SignedVAAs)
+	require.Equal(t, 2, len(manifest.Sections))
+
+	verified, err := VerifyBackup(bytes.NewReader(backup.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Sections, verified.Sections)
+
+	dir := t.TempDir()
+	badgerDB, err := Open(path.Join(dir, "badger"))
+	require.NoError(t, err)
+	defer badgerDB.Close()
+	sqliteDB, err := OpenWithSQLiteVAAStore(path.Join(dir, "db"), path.Join(dir, "vaas.sqlite"))
+	require.NoError(t, err)
+	defer sqliteDB.Close()
+
+	for name, dst := range map[string]*Database{"badger": badgerDB, "sqlite": sqliteDB} {
+		t.Run(name, func(t *testing.T) {
+			_, err := dst.Restore(bytes.NewReader(backup.Bytes()))
+			require.NoError(t, err)
+
+			count, err := dst.CountSignedVAAs(VAAFilter{LastSequence: math.MaxUint64})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(48), count)
+
+			txHash, err := dst.GetMessageTxHash(id)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{1, 2, 3}, txHash)
+		})
+	}
+}
+
+func TestVerifyBackupDetectsCorruption(t *testing.T) {
+	src, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer src.Close()
+
+	storeTestVAAs(t, src)
+
+	var backup bytes.Buffer
+	_, err = src.Backup(&backup)
+	require.NoError(t, err)
+
+	// Flip a byte in the payload of the last VAA, which still parses.
+	b := backup.Bytes()
+	i := bytes.LastIndex(b, []byte{97, 97, 97})
+	require.NotEqual(t, -1, i)
+	b[i] = 98
+
+	_, err = VerifyBackup(bytes.NewReader(b))
+	assert.ErrorContains(t, err, "section vaas does not match the manifest")
+
+	_, err = VerifyBackup(bytes.NewReader(b[:len(b)/2]))
+	assert.Error(t, err)
+
+	_, err = VerifyBackup(bytes.NewReader([]byte("not a backup")))
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -12,6 +13,8 @@ import (
 
 type Database struct {
 	db *badger.DB
+	// vaas stores the signed VAAs, in the BadgerDB unless another store was chosen.
+	vaas Store
 }
 
 type VAAID struct {
@@ -76,15 +79,81 @@ func Open(path string) (*Database, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	store := &badgerStore{db}
+	if err := store.buildTimeIndex(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to build the time index: %w", err)
+	}
+
 	return &Database{
-		db: db,
+		db:   db,
+		vaas: store,
 	}, nil
 }
 
+// OpenWithSQLiteVAAStore opens the database at path, but stores signed VAAs in the SQLite database at sqlitePath.
+// If the SQLite database holds no VAAs yet, the signed VAAs stored in the BadgerDB are copied to it.
+func OpenWithSQLiteVAAStore(path string, sqlitePath string) (*Database, error) {
+	d, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := openSQLiteStore(sqlitePath)
+	if err != nil {
+		d.db.Close()
+		return nil, err
+	}
+
+	if err := store.importIfEmpty(d.vaas); err != nil {
+		store.Close()
+		d.db.Close()
+		return nil, fmt.Errorf("failed to copy signed VAAs to SQLite: %w", err)
+	}
+
+	d.vaas = store
+	return d, nil
+}
+
+// Sync flushes the writes to disk. Signed VAAs stored in SQLite are already committed by each write.
+func (d *Database) Sync() error {
+	return d.db.Sync()
+}
+
 func (d *Database) Close() error {
+	if err := d.vaas.Close(); err != nil {
+		d.db.Close()
+		return err
+	}
 	return d.db.Close()
 }
 
+// Stats describes the size of the database and the state of its compactions.
+type Stats struct {
+	LSMSize  int64
+	VlogSize int64
+	// Number of tables in level zero, where flushed memtables accumulate until they are compacted.
+	Level0Tables int
+	// Number of levels whose compaction score is high enough for them to be picked for compaction.
+	LevelsPendingCompaction int
+}
+
+func (d *Database) Stats() Stats {
+	var stats Stats
+	stats.LSMSize, stats.VlogSize = d.db.Size()
+
+	for _, level := range d.db.Levels() {
+		if level.Level == 0 {
+			stats.Level0Tables = level.NumTables
+		}
+		if level.Score >= 1.0 {
+			stats.LevelsPendingCompaction++
+		}
+	}
+
+	return stats
+}
+
 func (d *Database) StoreSignedVAA(v *vaa.VAA) error {
 	if len(v.Signatures) == 0 {
 		panic("StoreSignedVAA called for unsigned VAA")
@@ -99,14 +168,7 @@ func (d *Database) StoreSignedVAA(v *vaa.VAA) error {
 	//
 	// TODO: panic on non-identical signing digest?
 
-	err := d.db.Update(func(txn *badger.Txn) error {
-		if err := txn.Set(VaaIDFromVAA(v).Bytes(), b); err != nil {
-			return err
-		}
-		return nil
-	})
-
-	if err != nil {
+	if err := d.vaas.PutVAA(VaaIDFromVAA(v), b); err != nil {
 		return fmt.Errorf("failed to commit tx: %w", err)
 	}
 
@@ -114,79 +176,50 @@ func (d *Database) StoreSignedVAA(v *vaa.VAA) error {
 }
 
 func (d *Database) GetSignedVAABytes(id VAAID) (b []byte, err error) {
-	if err := d.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(id.Bytes())
-		if err != nil {
-			return err
-		}
-		if val, err := item.ValueCopy(nil); err != nil {
-			return err
-		} else {
-			b = val
-		}
-		return nil
-	}); err != nil {
-		if err == badger.ErrKeyNotFound {
-			return nil, ErrVAANotFound
-		}
-		return nil, err
-	}
-	return
+	return d.vaas.GetVAA(&id)
 }
 
 func (d *Database) FindEmitterSequenceGap(prefix VAAID) (resp []uint64, firstSeq uint64, lastSeq uint64, err error) {
 	resp = make([]uint64, 0)
-	if err = d.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it.Close()
-		prefix := prefix.EmitterPrefixBytes()
-
-		// Find all sequence numbers (the message IDs are ordered lexicographically,
-		// rather than numerically, so we need to sort them in-memory).
-		seqs := make(map[uint64]bool)
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			item := it.Item()
-			key := item.Key()
-			err := item.Value(func(val []byte) error {
-				v, err := vaa.Unmarshal(val)
-				if err != nil {
-					return fmt.Errorf("failed to unmarshal VAA for %s: %v", string(key), err)
-				}
-
-				seqs[v.Sequence] = true
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-		}
-
-		// Find min/max (yay lack of Go generics)
-		first := false
-		for k := range seqs {
-			if first {
-				firstSeq = k
-				first = false
-			}
-			if k < firstSeq {
-				firstSeq = k
-			}
-			if k > lastSeq {
-				lastSeq = k
-			}
-		}
 
-		// Figure out gaps.
-		for i := firstSeq; i <= lastSeq; i++ {
-			if !seqs[i] {
-				fmt.Printf("missing: %d\n", i)
-				resp = append(resp, i)
-			}
+	// Find all sequence numbers (the message IDs are not necessarily ordered numerically,
+	// so we need to sort them in-memory).
+	seqs := make(map[uint64]bool)
+	filter := VAAFilter{EmitterChain: prefix.EmitterChain, EmitterAddress: &prefix.EmitterAddress, LastSequence: math.MaxUint64}
+	if err = d.vaas.IterateByEmitter(filter, func(id *VAAID, val []byte) error {
+		v, err := vaa.Unmarshal(val)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal VAA for %s: %v", string(id.Bytes()), err)
 		}
 
+		seqs[v.Sequence] = true
 		return nil
 	}); err != nil {
 		return
 	}
+
+	// Find min/max (yay lack of Go generics)
+	first := false
+	for k := range seqs {
+		if first {
+			firstSeq = k
+			first = false
+		}
+		if k < firstSeq {
+			firstSeq = k
+		}
+		if k > lastSeq {
+			lastSeq = k
+		}
+	}
+
+	// Figure out gaps.
+	for i := firstSeq; i <= lastSeq; i++ {
+		if !seqs[i] {
+			fmt.Printf("missing: %d\n", i)
+			resp = append(resp, i)
+		}
+	}
+
 	return
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"os"
 
+	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"github.com/ethereum/go-ethereum/crypto"
 
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func getVAA() vaa.VAA {
@@ -159,3 +161,88 @@ func TestFindEmitterSequenceGap(t *testing.T) {
 	assert.Equal(t, uint64(0x1), lastSeq)
 	assert.NoError(t, err)
 }
+
+func TestStoreAndGetMessageTxHash(t *testing.T) {
+	db, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	defer db.Close()
+
+	id := VAAID{EmitterChain: vaa.ChainIDAptos, EmitterAddress: vaa.Address{1}, Sequence: 42}
+
+	_, err = db.GetMessageTxHash(id)
+	assert.Equal(t, ErrTxHashNotFound, err)
+
+	txHash := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2}
+	assert.NoError(t, db.StoreMessageTxHash(id, txHash))
+
+	b, err := db.GetMessageTxHash(id)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, b)
+}
+
+func TestGetMessageIDsByTxHash(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	txHash := []byte{1, 2, 3, 4}
+	ids := []VAAID{
+		{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{2}, Sequence: 7},
+		{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 12},
+		{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 11},
+	}
+	for _, id := range ids {
+		require.NoError(t, db.StoreMessageTxHash(id, txHash))
+	}
+	// The same hash on another chain, and another hash with the same prefix.
+	require.NoError(t, db.StoreMessageTxHash(VAAID{EmitterChain: vaa.ChainIDBSC, EmitterAddress: vaa.Address{1}, Sequence: 1}, txHash))
+	require.NoError(t, db.StoreMessageTxHash(VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 2}, []byte{1, 2, 3, 4, 5}))
+
+	found, err := db.GetMessageIDsByTxHash(vaa.ChainIDEthereum, txHash)
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, ids[2], *found[0])
+	assert.Equal(t, ids[1], *found[1])
+	assert.Equal(t, ids[0], *found[2])
+
+	found, err = db.GetMessageIDsByTxHash(vaa.ChainIDSolana, txHash)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestStoreAndGetMessageProvenance(t *testing.T) {
+	db, err := Open(t.TempDir())
+	assert.NoError(t, err)
+	defer db.Close()
+
+	id := VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 42}
+
+	_, err = db.GetMessageProvenance(id)
+	assert.Equal(t, ErrProvenanceNotFound, err)
+
+	p := &common.Provenance{RPCEndpoint: "ws://eth-devnet:8545", FetchLatency: time.Second, Block: "0x1234", WatcherVersion: "v2.8.9"}
+	assert.NoError(t, db.StoreMessageProvenance(id, p))
+
+	stored, err := db.GetMessageProvenance(id)
+	assert.NoError(t, err)
+	assert.Equal(t, p, stored)
+}
+
+func TestStats(t *testing.T) {
+	dbPath := t.TempDir()
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Error("failed to open database")
+	}
+	defer db.Close()
+
+	v := getVAA()
+	privKey, _ := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	v.AddSignature(privKey, 0)
+	assert.NoError(t, db.StoreSignedVAA(&v))
+
+	stats := db.Stats()
+	assert.GreaterOrEqual(t, stats.LSMSize, int64(0))
+	assert.GreaterOrEqual(t, stats.VlogSize, int64(0))
+	assert.Equal(t, 0, stats.LevelsPendingCompaction)
+}
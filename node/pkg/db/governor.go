@@ -19,6 +19,12 @@ type GovernorDB interface {
 	DeleteTransfer(t *Transfer) error
 	DeletePendingMsg(k *PendingTransfer) error
 	GetChainGovernorData(logger *zap.Logger) (transfers []*Transfer, pending []*PendingTransfer, err error)
+	StoreUsage(u *GovernorUsage) error
+	DeleteUsage(u *GovernorUsage) error
+	GetChainGovernorUsage() (usage []*GovernorUsage, err error)
+	StoreFlowCancelTransfer(t *FlowCancelTransfer) error
+	DeleteFlowCancelTransfer(t *FlowCancelTransfer) error
+	GetChainGovernorFlowCancelTransfers() (transfers []*FlowCancelTransfer, err error)
 }
 
 type MockGovernorDB struct {
@@ -44,6 +50,30 @@ func (d *MockGovernorDB) GetChainGovernorData(logger *zap.Logger) (transfers []*
 	return nil, nil, nil
 }
 
+func (d *MockGovernorDB) StoreUsage(u *GovernorUsage) error {
+	return nil
+}
+
+func (d *MockGovernorDB) DeleteUsage(u *GovernorUsage) error {
+	return nil
+}
+
+func (d *MockGovernorDB) GetChainGovernorUsage() (usage []*GovernorUsage, err error) {
+	return nil, nil
+}
+
+func (d *MockGovernorDB) StoreFlowCancelTransfer(t *FlowCancelTransfer) error {
+	return nil
+}
+
+func (d *MockGovernorDB) DeleteFlowCancelTransfer(t *FlowCancelTransfer) error {
+	return nil
+}
+
+func (d *MockGovernorDB) GetChainGovernorFlowCancelTransfers() (transfers []*FlowCancelTransfer, err error) {
+	return nil, nil
+}
+
 type Transfer struct {
 	Timestamp      time.Time
 	Value          uint64
@@ -115,6 +145,10 @@ func UnmarshalTransfer(data []byte) (*Transfer, error) {
 type PendingTransfer struct {
 	ReleaseTime time.Time
 	Msg         common.MessagePublication
+
+	// Set if the transfer is held until enough guardians voted to release it. The payload of the message takes the rest
+	// of the serialized transfer, so the hold is stored under its own key rather than by Marshal.
+	EmergencyHold bool
 }
 
 func (p *PendingTransfer) Marshal() ([]byte, error) {
@@ -159,6 +193,87 @@ func UnmarshalPendingTransfer(data []byte) (*PendingTransfer, error) {
 	return p, nil
 }
 
+// GovernorUsage is the total value published by the chain governor for an emitter chain during one hour.
+// Unlike transfers, usage is retained beyond the 24 hour window so that it can be used for reporting.
+type GovernorUsage struct {
+	EmitterChain vaa.ChainID
+	Hour         time.Time
+	Value        uint64
+	Count        uint32
+}
+
+func (u *GovernorUsage) Marshal() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	vaa.MustWrite(buf, binary.BigEndian, u.EmitterChain)
+	vaa.MustWrite(buf, binary.BigEndian, uint32(u.Hour.Unix()))
+	vaa.MustWrite(buf, binary.BigEndian, u.Value)
+	vaa.MustWrite(buf, binary.BigEndian, u.Count)
+	return buf.Bytes(), nil
+}
+
+func UnmarshalGovernorUsage(data []byte) (*GovernorUsage, error) {
+	u := &GovernorUsage{}
+
+	reader := bytes.NewReader(data[:])
+
+	if err := binary.Read(reader, binary.BigEndian, &u.EmitterChain); err != nil {
+		return nil, fmt.Errorf("failed to read emitter chain id: %w", err)
+	}
+
+	unixSeconds := uint32(0)
+	if err := binary.Read(reader, binary.BigEndian, &unixSeconds); err != nil {
+		return nil, fmt.Errorf("failed to read hour: %w", err)
+	}
+	u.Hour = time.Unix(int64(unixSeconds), 0)
+
+	if err := binary.Read(reader, binary.BigEndian, &u.Value); err != nil {
+		return nil, fmt.Errorf("failed to read value: %w", err)
+	}
+
+	if err := binary.Read(reader, binary.BigEndian, &u.Count); err != nil {
+		return nil, fmt.Errorf("failed to read count: %w", err)
+	}
+
+	return u, nil
+}
+
+// FlowCancelTransfer is an inbound transfer to a governed chain, which reduces the value counted towards the daily limit
+// of that chain. The embedded transfer is the one counted towards the daily limit of its emitter chain.
+type FlowCancelTransfer struct {
+	Transfer
+	TargetChain vaa.ChainID
+}
+
+func (f *FlowCancelTransfer) Marshal() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	vaa.MustWrite(buf, binary.BigEndian, f.TargetChain)
+	b, err := f.Transfer.Marshal()
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to marshal flow cancel transfer: %w", err)
+	}
+	buf.Write(b)
+	return buf.Bytes(), nil
+}
+
+func UnmarshalFlowCancelTransfer(data []byte) (*FlowCancelTransfer, error) {
+	f := &FlowCancelTransfer{}
+
+	if len(data) < 2 {
+		return nil, fmt.Errorf("failed to read target chain id: flow cancel transfer too short")
+	}
+	f.TargetChain = vaa.ChainID(binary.BigEndian.Uint16(data[0:2]))
+
+	t, err := UnmarshalTransfer(data[2:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flow cancel transfer: %w", err)
+	}
+	f.Transfer = *t
+
+	return f, nil
+}
+
 const transfer = "GOV:XFER:"
 const transferLen = len(transfer)
 
@@ -174,6 +289,31 @@ const pendingLen = len(pending)
 
 const minMsgIdLen = len("1/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/0")
 
+const emergencyHold = "GOV:HOLD:"
+const emergencyHoldLen = len(emergencyHold)
+
+const usage = "GOV:USAGE:"
+const usageLen = len(usage)
+
+func UsageID(u *GovernorUsage) []byte {
+	return []byte(fmt.Sprintf("%v%d/%d", usage, u.EmitterChain, u.Hour.Unix()))
+}
+
+const flowCancel = "GOV:FLOWCANCEL:"
+const flowCancelLen = len(flowCancel)
+
+func FlowCancelMsgID(t *FlowCancelTransfer) []byte {
+	return []byte(fmt.Sprintf("%v%v", flowCancel, t.MsgID))
+}
+
+func IsFlowCancelTransfer(keyBytes []byte) bool {
+	return (len(keyBytes) >= flowCancelLen+minMsgIdLen) && (string(keyBytes[0:flowCancelLen]) == flowCancel)
+}
+
+func IsUsage(keyBytes []byte) bool {
+	return (len(keyBytes) > usageLen) && (string(keyBytes[0:usageLen]) == usage)
+}
+
 func TransferMsgID(t *Transfer) []byte {
 	return []byte(fmt.Sprintf("%v%v", transfer, t.MsgID))
 }
@@ -182,6 +322,10 @@ func PendingMsgID(k *common.MessagePublication) []byte {
 	return []byte(fmt.Sprintf("%v%v", pending, k.MessageIDString()))
 }
 
+func EmergencyHoldMsgID(k *common.MessagePublication) []byte {
+	return []byte(fmt.Sprintf("%v%v", emergencyHold, k.MessageIDString()))
+}
+
 func oldPendingMsgID(k *common.MessagePublication) []byte {
 	return []byte(fmt.Sprintf("%v%v", oldPending, k.MessageIDString()))
 }
@@ -194,6 +338,10 @@ func IsPendingMsg(keyBytes []byte) bool {
 	return (len(keyBytes) >= pendingLen+minMsgIdLen) && (string(keyBytes[0:pendingLen]) == pending)
 }
 
+func IsEmergencyHold(keyBytes []byte) bool {
+	return (len(keyBytes) >= emergencyHoldLen+minMsgIdLen) && (string(keyBytes[0:emergencyHoldLen]) == emergencyHold)
+}
+
 func isOldPendingMsg(keyBytes []byte) bool {
 	return (len(keyBytes) >= oldPendingLen+minMsgIdLen) && (string(keyBytes[0:oldPendingLen]) == oldPending)
 }
@@ -205,6 +353,7 @@ func (d *Database) GetChainGovernorData(logger *zap.Logger) (transfers []*Transf
 
 func (d *Database) GetChainGovernorDataForTime(logger *zap.Logger, now time.Time) (transfers []*Transfer, pending []*PendingTransfer, err error) {
 	oldPendingToUpdate := []*PendingTransfer{}
+	held := map[string]bool{}
 	err = d.db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchSize = 10
@@ -225,6 +374,8 @@ func (d *Database) GetChainGovernorDataForTime(logger *zap.Logger, now time.Time
 				}
 
 				pending = append(pending, p)
+			} else if IsEmergencyHold(key) {
+				held[string(key[emergencyHoldLen:])] = true
 			} else if IsTransfer(key) {
 				v, err := UnmarshalTransfer(val)
 				if err != nil {
@@ -262,6 +413,10 @@ func (d *Database) GetChainGovernorDataForTime(logger *zap.Logger, now time.Time
 		return nil
 	})
 
+	for _, p := range pending {
+		p.EmergencyHold = held[p.Msg.MessageIDString()]
+	}
+
 	return
 }
 
@@ -291,7 +446,10 @@ func (d *Database) StorePendingMsg(pending *PendingTransfer) error {
 		if err := txn.Set(PendingMsgID(&pending.Msg), b); err != nil {
 			return err
 		}
-		return nil
+		if pending.EmergencyHold {
+			return txn.Set(EmergencyHoldMsgID(&pending.Msg), []byte{1})
+		}
+		return txn.Delete(EmergencyHoldMsgID(&pending.Msg))
 	})
 
 	if err != nil {
@@ -320,5 +478,137 @@ func (d *Database) DeletePendingMsg(pending *PendingTransfer) error {
 		return fmt.Errorf("failed to delete pending msg for key [%v]: %w", key, err)
 	}
 
+	holdKey := EmergencyHoldMsgID(&pending.Msg)
+	err = d.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(holdKey)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete emergency hold for key [%v]: %w", string(holdKey), err)
+	}
+
 	return nil
 }
+
+// This is called by the chain governor to persist the usage for an hour.
+func (d *Database) StoreUsage(u *GovernorUsage) error {
+	b, _ := u.Marshal()
+
+	err := d.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(UsageID(u), b); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to commit usage tx: %w", err)
+	}
+
+	return nil
+}
+
+// This is called by the chain governor to delete usage after the retention period has expired.
+func (d *Database) DeleteUsage(u *GovernorUsage) error {
+	key := UsageID(u)
+	err := d.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete usage for key [%v]: %w", string(key), err)
+	}
+
+	return nil
+}
+
+// This is called by the chain governor on start up to reload the usage history.
+func (d *Database) GetChainGovernorUsage() (usageList []*GovernorUsage, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(usage)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if !IsUsage(item.Key()) {
+				continue
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			u, err := UnmarshalGovernorUsage(val)
+			if err != nil {
+				return err
+			}
+
+			usageList = append(usageList, u)
+		}
+		return nil
+	})
+
+	return
+}
+
+// This is called by the chain governor to persist a transfer which frees outbound capacity of its target chain.
+func (d *Database) StoreFlowCancelTransfer(t *FlowCancelTransfer) error {
+	b, _ := t.Marshal()
+
+	err := d.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(FlowCancelMsgID(t), b); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to commit flow cancel transfer tx: %w", err)
+	}
+
+	return nil
+}
+
+// This is called by the chain governor to delete a flow cancel transfer after the time limit has expired.
+func (d *Database) DeleteFlowCancelTransfer(t *FlowCancelTransfer) error {
+	key := FlowCancelMsgID(t)
+	err := d.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete flow cancel transfer for key [%v]: %w", string(key), err)
+	}
+
+	return nil
+}
+
+// This is called by the chain governor on start up to reload the flow cancel transfers.
+func (d *Database) GetChainGovernorFlowCancelTransfers() (transfers []*FlowCancelTransfer, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(flowCancel)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if !IsFlowCancelTransfer(item.Key()) {
+				continue
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			t, err := UnmarshalFlowCancelTransfer(val)
+			if err != nil {
+				return err
+			}
+
+			transfers = append(transfers, t)
+		}
+		return nil
+	})
+
+	return
+}
@@ -244,6 +244,61 @@ func TestDeletePendingMsg(t *testing.T) {
 	assert.Nil(t, err4)
 }
 
+func TestStoreAndReloadEmergencyHold(t *testing.T) {
+	dbPath := t.TempDir()
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tokenBridgeAddr, err := vaa.StringToAddress("0x0290fb167208af455bb137780163b7b7a9a10c16")
+	require.NoError(t, err)
+
+	newPending := func(sequence uint64, hold bool) *PendingTransfer {
+		msg := common.MessagePublication{
+			TxHash:           eth_common.HexToHash("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654516425), 0),
+			Nonce:            123456,
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   tokenBridgeAddr,
+			Payload:          []byte{1, 2, 3, 4, 5, 6},
+			ConsistencyLevel: 16,
+		}
+		return &PendingTransfer{ReleaseTime: msg.Timestamp.Add(time.Hour * 72), Msg: msg, EmergencyHold: hold}
+	}
+
+	held := newPending(1, true)
+	notHeld := newPending(2, false)
+	require.NoError(t, db.StorePendingMsg(held))
+	require.NoError(t, db.StorePendingMsg(notHeld))
+
+	holds := func() map[uint64]bool {
+		_, pending, err := db.GetChainGovernorData(zap.NewNop())
+		require.NoError(t, err)
+		res := map[uint64]bool{}
+		for _, p := range pending {
+			res[p.Msg.Sequence] = p.EmergencyHold
+		}
+		return res
+	}
+	assert.Equal(t, map[uint64]bool{1: true, 2: false}, holds())
+
+	// Storing the transfer again without the hold clears it.
+	held.EmergencyHold = false
+	require.NoError(t, db.StorePendingMsg(held))
+	assert.Equal(t, map[uint64]bool{1: false, 2: false}, holds())
+
+	held.EmergencyHold = true
+	require.NoError(t, db.StorePendingMsg(held))
+	require.NoError(t, db.DeletePendingMsg(held))
+	assert.Equal(t, map[uint64]bool{2: false}, holds())
+	err = db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(EmergencyHoldMsgID(&held.Msg))
+		return err
+	})
+	assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+}
+
 func TestSerializeAndDeserializeOfPendingTransfer(t *testing.T) {
 	tokenBridgeAddr, err := vaa.StringToAddress("0x0290fb167208af455bb137780163b7b7a9a10c16")
 	require.NoError(t, err)
@@ -487,3 +542,143 @@ func TestLoadingOldPendingTransfers(t *testing.T) {
 	assert.Equal(t, pending1, pendings2[0])
 	assert.Equal(t, pending2, pendings2[1])
 }
+
+func TestSerializeAndDeserializeOfGovernorUsage(t *testing.T) {
+	u1 := &GovernorUsage{
+		EmitterChain: vaa.ChainIDEthereum,
+		Hour:         time.Unix(int64(1654516800), 0),
+		Value:        125000,
+		Count:        42,
+	}
+
+	bytes, err := u1.Marshal()
+	require.NoError(t, err)
+
+	u2, err := UnmarshalGovernorUsage(bytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, u1, u2)
+
+	expectedUsageKey := "GOV:USAGE:2/1654516800"
+	assert.Equal(t, expectedUsageKey, string(UsageID(u2)))
+	assert.True(t, IsUsage(UsageID(u2)))
+	assert.False(t, IsTransfer(UsageID(u2)))
+	assert.False(t, IsPendingMsg(UsageID(u2)))
+}
+
+func TestStoreAndReloadGovernorUsage(t *testing.T) {
+	dbPath := t.TempDir()
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Error("failed to open database")
+	}
+	defer db.Close()
+
+	u1 := &GovernorUsage{EmitterChain: vaa.ChainIDEthereum, Hour: time.Unix(int64(1654516800), 0), Value: 125000, Count: 2}
+	u2 := &GovernorUsage{EmitterChain: vaa.ChainIDSolana, Hour: time.Unix(int64(1654520400), 0), Value: 3000, Count: 1}
+	require.NoError(t, db.StoreUsage(u1))
+	require.NoError(t, db.StoreUsage(u2))
+
+	// Updating a bucket overwrites it.
+	u1.Value = 150000
+	u1.Count = 3
+	require.NoError(t, db.StoreUsage(u1))
+
+	usage, err := db.GetChainGovernorUsage()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(usage))
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].Hour.Before(usage[j].Hour)
+	})
+	assert.Equal(t, u1, usage[0])
+	assert.Equal(t, u2, usage[1])
+
+	// Usage should not show up as governor transfers.
+	xfers, pending, err := db.GetChainGovernorData(zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(xfers))
+	assert.Equal(t, 0, len(pending))
+
+	require.NoError(t, db.DeleteUsage(u1))
+	usage, err = db.GetChainGovernorUsage()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(usage))
+	assert.Equal(t, u2, usage[0])
+}
+
+func TestSerializeAndDeserializeOfFlowCancelTransfer(t *testing.T) {
+	tokenAddr, err := vaa.StringToAddress("0x707f9118e33a9b8998bea41dd0d46f38bb963fc8")
+	require.NoError(t, err)
+
+	ethereumTokenBridgeAddr, err := vaa.StringToAddress("0x0290fb167208af455bb137780163b7b7a9a10c16")
+	require.NoError(t, err)
+
+	f1 := &FlowCancelTransfer{
+		Transfer: Transfer{
+			Timestamp:      time.Unix(int64(1654516425), 0),
+			Value:          125000,
+			OriginChain:    vaa.ChainIDEthereum,
+			OriginAddress:  tokenAddr,
+			EmitterChain:   vaa.ChainIDEthereum,
+			EmitterAddress: ethereumTokenBridgeAddr,
+			MsgID:          "2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/789101112131415",
+		},
+		TargetChain: vaa.ChainIDSolana,
+	}
+
+	bytes, err := f1.Marshal()
+	require.NoError(t, err)
+
+	f2, err := UnmarshalFlowCancelTransfer(bytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, f1, f2)
+
+	expectedKey := "GOV:FLOWCANCEL:2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/789101112131415"
+	assert.Equal(t, expectedKey, string(FlowCancelMsgID(f2)))
+	assert.True(t, IsFlowCancelTransfer(FlowCancelMsgID(f2)))
+	assert.False(t, IsTransfer(FlowCancelMsgID(f2)))
+	assert.False(t, IsPendingMsg(FlowCancelMsgID(f2)))
+}
+
+func TestStoreAndReloadFlowCancelTransfers(t *testing.T) {
+	dbPath := t.TempDir()
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Error("failed to open database")
+	}
+	defer db.Close()
+
+	ethereumTokenBridgeAddr, err := vaa.StringToAddress("0x0290fb167208af455bb137780163b7b7a9a10c16")
+	require.NoError(t, err)
+
+	f1 := &FlowCancelTransfer{
+		Transfer: Transfer{
+			Timestamp:      time.Unix(int64(1654516425), 0),
+			Value:          125000,
+			OriginChain:    vaa.ChainIDEthereum,
+			EmitterChain:   vaa.ChainIDEthereum,
+			EmitterAddress: ethereumTokenBridgeAddr,
+			MsgID:          "2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/1",
+		},
+		TargetChain: vaa.ChainIDSolana,
+	}
+	require.NoError(t, db.StoreFlowCancelTransfer(f1))
+
+	transfers, err := db.GetChainGovernorFlowCancelTransfers()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(transfers))
+	assert.Equal(t, f1, transfers[0])
+
+	// Flow cancel transfers should not show up as governor transfers.
+	xfers, pending, err := db.GetChainGovernorData(zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(xfers))
+	assert.Equal(t, 0, len(pending))
+
+	require.NoError(t, db.DeleteFlowCancelTransfer(f1))
+	transfers, err = db.GetChainGovernorFlowCancelTransfers()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(transfers))
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var corruptVAAsTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "wormhole_db_corrupt_vaas_total",
+		Help: "Total number of corrupt signed VAAs found and quarantined by integrity checks",
+	})
+
+// vaaChecksum returns the checksum stored along with each signed VAA.
+func vaaChecksum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+// The checksum of each signed VAA in the BadgerDB is stored under "signedsum/<chain>/<address>/<sequence>", and
+// quarantined VAAs are moved to "quarantine/<chain>/<address>/<sequence>".
+const (
+	checksumPrefix   = "signedsum/"
+	quarantinePrefix = "quarantine/"
+)
+
+func (i *VAAID) checksumKey() []byte {
+	return append([]byte(checksumPrefix), bytes.TrimPrefix(i.Bytes(), []byte("signed/"))...)
+}
+
+func (i *VAAID) quarantineKey() []byte {
+	return append([]byte(quarantinePrefix), bytes.TrimPrefix(i.Bytes(), []byte("signed/"))...)
+}
+
+func (s *badgerStore) IterateWithChecksums(fn func(id *VAAID, b []byte, checksum []byte) error) error {
+	prefix := []byte("signed/")
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			id, err := VaaIDFromString(string(bytes.TrimPrefix(item.Key(), prefix)))
+			if err != nil {
+				return fmt.Errorf("failed to parse key %s: %w", string(item.Key()), err)
+			}
+
+			var checksum []byte
+			sumItem, err := txn.Get(id.checksumKey())
+			if err == nil {
+				if checksum, err = sumItem.ValueCopy(nil); err != nil {
+					return err
+				}
+			} else if err != badger.ErrKeyNotFound {
+				return err
+			}
+
+			if err := item.Value(func(val []byte) error { return fn(id, val, checksum) }); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// storedValueIs returns whether the value stored under key is b.
+func storedValueIs(txn *badger.Txn, key []byte, b []byte) (bool, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	equal := false
+	err = item.Value(func(val []byte) error {
+		equal = bytes.Equal(val, b)
+		return nil
+	})
+	return equal, err
+}
+
+func (s *badgerStore) SetChecksum(id *VAAID, b []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		if ok, err := storedValueIs(txn, id.Bytes(), b); err != nil || !ok {
+			return err
+		}
+
+		if _, err := txn.Get(id.checksumKey()); err != badger.ErrKeyNotFound {
+			return err
+		}
+		return txn.Set(id.checksumKey(), vaaChecksum(b))
+	})
+}
+
+func (s *badgerStore) Quarantine(id *VAAID, b []byte) error {
+	// The timestamp of a corrupt VAA cannot be trusted, so look for its time index keys. The index is scanned
+	// beforehand so that the update transaction does not track all the keys read.
+	var indexKeys [][]byte
+	suffix := bytes.TrimPrefix(id.Bytes(), []byte("signed"))
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(timeIndexPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if bytes.HasSuffix(it.Item().Key(), suffix) {
+				indexKeys = append(indexKeys, it.Item().KeyCopy(nil))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		if ok, err := storedValueIs(txn, id.Bytes(), b); err != nil || !ok {
+			return err
+		}
+
+		if err := txn.Set(id.quarantineKey(), b); err != nil {
+			return err
+		}
+		for _, key := range append(indexKeys, id.Bytes(), id.checksumKey()) {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *badgerStore) IterateQuarantined(fn func(id *VAAID) error) error {
+	prefix := []byte(quarantinePrefix)
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			id, err := VaaIDFromString(string(bytes.TrimPrefix(it.Item().Key(), prefix)))
+			if err != nil {
+				return fmt.Errorf("failed to parse key %s: %w", string(it.Item().Key()), err)
+			}
+			if err := fn(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IntegrityReport describes the outcome of an integrity check.
+type IntegrityReport struct {
+	Checked uint64
+	// Number of VAAs stored without a checksum, which were checksummed.
+	ChecksumsAdded uint64
+	// The corrupt VAAs, which were quarantined.
+	Corrupt []VAAID
+}
+
+// verifyStoredVAA returns an error if the bytes stored for a VAA are not a valid VAA with that ID, or do not match the
+// checksum.
+func verifyStoredVAA(id *VAAID, b []byte, checksum []byte) error {
+	if checksum != nil && !bytes.Equal(checksum, vaaChecksum(b)) {
+		return fmt.Errorf("checksum mismatch")
+	}
+
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal VAA: %w", err)
+	}
+	if *VaaIDFromVAA(v) != *id {
+		return fmt.Errorf("VAA is stored under the wrong ID")
+	}
+	return nil
+}
+
+// CheckIntegrity verifies all signed VAAs and moves the corrupt ones to quarantine, where they are kept for
+// inspection. VAAs stored before checksums were are checksummed if they are valid. It can run while the node is live.
+func (d *Database) CheckIntegrity(ctx context.Context) (IntegrityReport, error) {
+	var (
+		report  IntegrityReport
+		corrupt [][]byte
+		missing []VAAID
+		sums    [][]byte
+	)
+
+	err := d.vaas.IterateWithChecksums(func(id *VAAID, b []byte, checksum []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.Checked++
+
+		if err := verifyStoredVAA(id, b, checksum); err != nil {
+			report.Corrupt = append(report.Corrupt, *id)
+			corrupt = append(corrupt, append([]byte(nil), b...))
+			return nil
+		}
+		if checksum == nil {
+			missing = append(missing, *id)
+			sums = append(sums, append([]byte(nil), b...))
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for i := range missing {
+		if err := d.vaas.SetChecksum(&missing[i], sums[i]); err != nil {
+			return report, fmt.Errorf("failed to store checksum of %s: %w", string(missing[i].Bytes()), err)
+		}
+		report.ChecksumsAdded++
+	}
+
+	for i := range report.Corrupt {
+		if err := d.vaas.Quarantine(&report.Corrupt[i], corrupt[i]); err != nil {
+			return report, fmt.Errorf("failed to quarantine %s: %w", string(report.Corrupt[i].Bytes()), err)
+		}
+		corruptVAAsTotal.Inc()
+	}
+
+	return report, nil
+}
+
+// UnhealedVAAs returns the IDs of the quarantined VAAs which have not been stored again since.
+func (d *Database) UnhealedVAAs() ([]VAAID, error) {
+	var ids []VAAID
+	err := d.vaas.IterateQuarantined(func(id *VAAID) error {
+		_, err := d.vaas.GetVAA(id)
+		if err == ErrVAANotFound {
+			ids = append(ids, *id)
+			return nil
+		}
+		return err
+	})
+	return ids, err
+}
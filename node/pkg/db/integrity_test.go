@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overwriteStoredVAA replaces the bytes of a stored VAA behind the back of the store, optionally dropping its checksum.
+func overwriteStoredVAA(t *testing.T, db *Database, id *VAAID, b []byte, dropChecksum bool) {
+	switch store := db.vaas.(type) {
+	case *badgerStore:
+		require.NoError(t, store.db.Update(func(txn *badger.Txn) error {
+			if dropChecksum {
+				if err := txn.Delete(id.checksumKey()); err != nil {
+					return err
+				}
+			}
+			return txn.Set(id.Bytes(), b)
+		}))
+	case *sqliteStore:
+		query := "UPDATE signed_vaas SET vaa = ? WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ?"
+		if dropChecksum {
+			query = "UPDATE signed_vaas SET vaa = ?, checksum = NULL WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ?"
+		}
+		_, err := store.db.Exec(query, b, int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence))
+		require.NoError(t, err)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	for name, db := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			storeTestVAAs(t, db)
+
+			flipped := VAAID{EmitterChain: vaa.ChainIDSolana, EmitterAddress: vaa.Address{1}, Sequence: 3}
+			good, err := db.GetSignedVAABytes(flipped)
+			require.NoError(t, err)
+			bad := append([]byte(nil), good...)
+			bad[len(bad)-1] ^= 1
+			overwriteStoredVAA(t, db, &flipped, bad, false)
+
+			truncated := VAAID{EmitterChain: vaa.ChainIDFantom, EmitterAddress: vaa.Address{2}, Sequence: 7}
+			b, err := db.GetSignedVAABytes(truncated)
+			require.NoError(t, err)
+			overwriteStoredVAA(t, db, &truncated, b[:10], true)
+
+			unchecked := VAAID{EmitterChain: vaa.ChainIDFantom, EmitterAddress: vaa.Address{1}, Sequence: 1}
+			b, err = db.GetSignedVAABytes(unchecked)
+			require.NoError(t, err)
+			overwriteStoredVAA(t, db, &unchecked, b, true)
+
+			report, err := db.CheckIntegrity(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, uint64(48), report.Checked)
+			assert.Equal(t, uint64(1), report.ChecksumsAdded)
+			assert.ElementsMatch(t, []VAAID{flipped, truncated}, report.Corrupt)
+
+			_, err = db.GetSignedVAABytes(flipped)
+			assert.Equal(t, ErrVAANotFound, err)
+
+			unhealed, err := db.UnhealedVAAs()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []VAAID{flipped, truncated}, unhealed)
+
+			// Storing the VAA again, as when it is fetched from peers, heals it.
+			v, err := vaa.Unmarshal(good)
+			require.NoError(t, err)
+			require.NoError(t, db.StoreSignedVAA(v))
+			unhealed, err = db.UnhealedVAAs()
+			require.NoError(t, err)
+			assert.Equal(t, []VAAID{truncated}, unhealed)
+
+			report, err = db.CheckIntegrity(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, uint64(47), report.Checked)
+			assert.Equal(t, uint64(0), report.ChecksumsAdded)
+			assert.Empty(t, report.Corrupt)
+		})
+	}
+}
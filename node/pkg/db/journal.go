@@ -0,0 +1,91 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// The observation journal holds a "observationjournal/<digest>" key for each observation made by this node which has not
+// reached quorum yet. Entries are kept for as long as the processor retransmits the observation, after which an
+// observation without quorum is given up anyway.
+const (
+	observationJournalPrefix    = "observationjournal/"
+	observationJournalRetention = 120 * time.Hour
+)
+
+// JournaledObservation is an observation made by this node, written to the journal before its signature is gossiped.
+type JournaledObservation struct {
+	// The observed VAA, without signatures.
+	VAA    []byte `json:"vaa"`
+	TxHash []byte `json:"txHash,omitempty"`
+	// Our signature of the VAA, unset if the node stopped before signing it.
+	Signature []byte    `json:"signature,omitempty"`
+	Observed  time.Time `json:"observed"`
+}
+
+func observationJournalKey(hash string) []byte {
+	return []byte(observationJournalPrefix + hash)
+}
+
+// JournalObservation writes the observation with the hex digest hash to the journal. The write is synced to disk
+// before returning, so the observation survives a crash of the node right after.
+func (d *Database) JournalObservation(hash string, o *JournaledObservation) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	if err := d.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(observationJournalKey(hash), b).WithTTL(observationJournalRetention))
+	}); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	if err := d.db.Sync(); err != nil {
+		return fmt.Errorf("failed to sync observation journal: %w", err)
+	}
+	return nil
+}
+
+// DeleteJournaledObservation removes the observation with the hex digest hash from the journal, once it reached quorum
+// or was given up.
+func (d *Database) DeleteJournaledObservation(hash string) error {
+	if err := d.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(observationJournalKey(hash))
+	}); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// JournaledObservations returns the observations of the journal, by hex digest.
+func (d *Database) JournaledObservations() (map[string]*JournaledObservation, error) {
+	res := make(map[string]*JournaledObservation)
+	err := d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(observationJournalPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			b, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			var o JournaledObservation
+			if err := json.Unmarshal(b, &o); err != nil {
+				return fmt.Errorf("invalid observation journal entry %s: %w", string(item.Key()), err)
+			}
+			res[string(item.Key()[len(observationJournalPrefix):])] = &o
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
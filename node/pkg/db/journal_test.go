@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservationJournal(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	observed := time.Unix(1_700_000_000, 0).UTC()
+	require.NoError(t, db.JournalObservation("aa", &JournaledObservation{VAA: []byte{1}, TxHash: []byte{2}, Observed: observed}))
+	require.NoError(t, db.JournalObservation("bb", &JournaledObservation{VAA: []byte{3}, Observed: observed}))
+	// Journaling the signature replaces the unsigned entry.
+	require.NoError(t, db.JournalObservation("aa", &JournaledObservation{VAA: []byte{1}, TxHash: []byte{2}, Signature: []byte{4}, Observed: observed}))
+
+	journal, err := db.JournaledObservations()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*JournaledObservation{
+		"aa": {VAA: []byte{1}, TxHash: []byte{2}, Signature: []byte{4}, Observed: observed},
+		"bb": {VAA: []byte{3}, Observed: observed},
+	}, journal)
+
+	require.NoError(t, db.DeleteJournaledObservation("aa"))
+	journal, err = db.JournaledObservations()
+	require.NoError(t, err)
+	assert.Len(t, journal, 1)
+	assert.Contains(t, journal, "bb")
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// networkConfigKey holds the signed VAA of the network configuration currently applied by the node.
+var networkConfigKey = []byte("networkconfig")
+
+// StoreNetworkConfigVAA replaces the stored network configuration VAA.
+func (d *Database) StoreNetworkConfigVAA(b []byte) error {
+	if err := d.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(networkConfigKey, b)
+	}); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+// GetNetworkConfigVAA returns the stored network configuration VAA, or nil if none was stored.
+func (d *Database) GetNetworkConfigVAA() (b []byte, err error) {
+	err = d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(networkConfigKey)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b, err = item.ValueCopy(nil)
+		return err
+	})
+	return
+}
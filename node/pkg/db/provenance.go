@@ -0,0 +1,59 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/dgraph-io/badger/v3"
+)
+
+var (
+	ErrProvenanceNotFound = errors.New("requested provenance not found in store")
+)
+
+func (i *VAAID) ProvenanceBytes() []byte {
+	return []byte(fmt.Sprintf("provenance/%d/%s/%d", i.EmitterChain, i.EmitterAddress, i.Sequence))
+}
+
+// StoreMessageProvenance records how a message observed by this node was obtained. The entry expires after the same
+// retention period as the tx hash of the message.
+func (d *Database) StoreMessageProvenance(id VAAID, p *common.Provenance) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+
+	err = d.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(id.ProvenanceBytes(), b).WithTTL(messageTxHashRetention))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Database) GetMessageProvenance(id VAAID) (*common.Provenance, error) {
+	var b []byte
+	if err := d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(id.ProvenanceBytes())
+		if err != nil {
+			return err
+		}
+		b, err = item.ValueCopy(nil)
+		return err
+	}); err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrProvenanceNotFound
+		}
+		return nil, err
+	}
+
+	var p common.Provenance
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal provenance: %w", err)
+	}
+	return &p, nil
+}
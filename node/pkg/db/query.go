@@ -0,0 +1,146 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
+
+// VAAFilter selects signed VAAs by emitter chain, emitter address and sequence range.
+type VAAFilter struct {
+	// Zero matches all chains.
+	EmitterChain vaa.ChainID
+	// Nil matches all emitters. Requires EmitterChain to be set.
+	EmitterAddress *vaa.Address
+	// Inclusive sequence range.
+	FirstSequence uint64
+	LastSequence  uint64
+}
+
+// ErrStopIteration can be returned by the callback of IterateSignedVAAs to stop early without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+func (f *VAAFilter) validate() error {
+	if f.FirstSequence > f.LastSequence {
+		return errors.New("the first sequence must not be greater than the last sequence")
+	}
+	if f.EmitterChain == vaa.ChainIDUnset && f.EmitterAddress != nil {
+		return errors.New("the emitter chain must be specified when the emitter address is")
+	}
+	return nil
+}
+
+func (f *VAAFilter) matchesSequence(sequence uint64) bool {
+	return sequence >= f.FirstSequence && sequence <= f.LastSequence
+}
+
+// IterateSignedVAAs calls fn with the ID and the bytes of each signed VAA matching the filter, ordered by emitter.
+// The bytes are only valid during the callback.
+func (d *Database) IterateSignedVAAs(filter VAAFilter, fn func(id *VAAID, b []byte) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+
+	err := d.vaas.IterateByEmitter(filter, fn)
+	if err == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
+// IterateSignedVAAsByTime calls fn with the ID and the bytes of each signed VAA matching the filter and the time
+// range, ordered by timestamp. The bytes are only valid during the callback.
+func (d *Database) IterateSignedVAAsByTime(filter VAAFilter, r TimeRange, fn func(id *VAAID, b []byte) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+
+	err := d.vaas.IterateByTime(filter, r, fn)
+	if err == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
+// GetSignedVAAsByTime returns the bytes of the first signed VAAs matching the filter and the time range, ordered by
+// timestamp, and whether more than limit VAAs match.
+func (d *Database) GetSignedVAAsByTime(filter VAAFilter, r TimeRange, limit int) ([][]byte, bool, error) {
+	var (
+		vaas      [][]byte
+		truncated bool
+	)
+	err := d.IterateSignedVAAsByTime(filter, r, func(id *VAAID, b []byte) error {
+		if len(vaas) == limit {
+			truncated = true
+			return ErrStopIteration
+		}
+		vaas = append(vaas, append([]byte(nil), b...))
+		return nil
+	})
+	return vaas, truncated, err
+}
+
+// CountSignedVAAs returns the number of signed VAAs matching the filter.
+func (d *Database) CountSignedVAAs(filter VAAFilter) (uint64, error) {
+	var count uint64
+	err := d.IterateSignedVAAs(filter, func(id *VAAID, b []byte) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// HasSignedVAAsFromEmitter returns whether at least one signed VAA of the emitter is stored.
+func (d *Database) HasSignedVAAsFromEmitter(chainID vaa.ChainID, emitter vaa.Address) (bool, error) {
+	var found bool
+	err := d.IterateSignedVAAs(VAAFilter{EmitterChain: chainID, EmitterAddress: &emitter, LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		found = true
+		return ErrStopIteration
+	})
+	return found, err
+}
+
+// PruneSignedVAAs deletes the signed VAAs matching the filter and returns how many were deleted.
+func (d *Database) PruneSignedVAAs(filter VAAFilter) (uint64, error) {
+	if err := filter.validate(); err != nil {
+		return 0, err
+	}
+	return d.vaas.Prune(filter)
+}
+
+// A VAA archive is a sequence of signed VAAs, each prefixed with its length as a big endian uint32.
+
+// WriteVAAArchiveEntry appends a signed VAA to an archive.
+func WriteVAAArchiveEntry(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadVAAArchive calls fn with each signed VAA in an archive.
+func ReadVAAArchive(r io.Reader, fn func(b []byte) error) error {
+	for {
+		var length uint32
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read entry length: %w", err)
+		}
+
+		b := make([]byte, length)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return fmt.Errorf("failed to read entry: %w", err)
+		}
+
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+}
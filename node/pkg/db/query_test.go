@@ -0,0 +1,133 @@
+package db
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func storeTestVAAs(t *testing.T, db *Database) {
+	privKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	require.NoError(t, err)
+
+	// Chain 1 and chain 10 share a textual prefix, which must not be matched by a filter on chain 1.
+	for _, chainID := range []vaa.ChainID{vaa.ChainIDSolana, vaa.ChainIDFantom} {
+		for _, emitter := range []vaa.Address{{1}, {2}} {
+			for seq := uint64(1); seq <= 12; seq++ {
+				v := getVAA()
+				v.EmitterChain = chainID
+				v.EmitterAddress = emitter
+				v.Sequence = seq
+				v.AddSignature(privKey, 0)
+				require.NoError(t, db.StoreSignedVAA(&v))
+			}
+		}
+	}
+}
+
+func TestCountSignedVAAs(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	storeTestVAAs(t, db)
+
+	emitter := vaa.Address{1}
+	tests := []struct {
+		filter   VAAFilter
+		expected uint64
+	}{
+		{VAAFilter{LastSequence: math.MaxUint64}, 48},
+		{VAAFilter{EmitterChain: vaa.ChainIDSolana, LastSequence: math.MaxUint64}, 24},
+		{VAAFilter{EmitterChain: vaa.ChainIDSolana, EmitterAddress: &emitter, LastSequence: math.MaxUint64}, 12},
+		{VAAFilter{EmitterChain: vaa.ChainIDSolana, EmitterAddress: &emitter, FirstSequence: 2, LastSequence: 10}, 9},
+		{VAAFilter{EmitterChain: vaa.ChainIDEthereum, LastSequence: math.MaxUint64}, 0},
+	}
+
+	for _, tc := range tests {
+		count, err := db.CountSignedVAAs(tc.filter)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, count)
+	}
+
+	_, err = db.CountSignedVAAs(VAAFilter{EmitterAddress: &emitter, LastSequence: math.MaxUint64})
+	assert.Error(t, err)
+
+	_, err = db.CountSignedVAAs(VAAFilter{FirstSequence: 2, LastSequence: 1})
+	assert.Error(t, err)
+}
+
+func TestIterateSignedVAAsStop(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	storeTestVAAs(t, db)
+
+	count := 0
+	err = db.IterateSignedVAAs(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		count++
+		if count == 5 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestHasSignedVAAsFromEmitter(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	storeTestVAAs(t, db)
+
+	found, err := db.HasSignedVAAsFromEmitter(vaa.ChainIDSolana, vaa.Address{2})
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = db.HasSignedVAAsFromEmitter(vaa.ChainIDSolana, vaa.Address{3})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = db.HasSignedVAAsFromEmitter(vaa.ChainIDEthereum, vaa.Address{1})
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestVAAArchive(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	storeTestVAAs(t, db)
+
+	emitter := vaa.Address{2}
+	filter := VAAFilter{EmitterChain: vaa.ChainIDFantom, EmitterAddress: &emitter, FirstSequence: 5, LastSequence: 7}
+
+	var archive bytes.Buffer
+	err = db.IterateSignedVAAs(filter, func(id *VAAID, b []byte) error {
+		return WriteVAAArchiveEntry(&archive, b)
+	})
+	require.NoError(t, err)
+
+	var sequences []uint64
+	err = ReadVAAArchive(&archive, func(b []byte) error {
+		v, err := vaa.Unmarshal(b)
+		require.NoError(t, err)
+		assert.Equal(t, vaa.ChainIDFantom, v.EmitterChain)
+		assert.Equal(t, emitter, v.EmitterAddress)
+		sequences = append(sequences, v.Sequence)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{5, 6, 7}, sequences)
+}
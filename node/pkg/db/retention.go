@@ -0,0 +1,333 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	prunedVAAsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_db_pruned_vaas_total",
+			Help: "Total number of signed VAAs deleted by the retention policy",
+		}, []string{"emitter_chain"})
+	prunedVAABytesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_db_pruned_vaa_bytes_total",
+			Help: "Total size of the signed VAAs deleted by the retention policy",
+		})
+)
+
+const (
+	defaultRetentionInterval      = time.Hour
+	defaultRetentionDeletesPerSec = 1000
+)
+
+// RetentionRule selects which signed VAAs of an emitter are kept. A VAA is deleted once it is older than MaxAgeDays and
+// not among the KeepSequences most recent sequences of its emitter. Unset limits do not restrict deletion, and a rule
+// with neither limit set keeps all VAAs.
+type RetentionRule struct {
+	// Chain name or ID, unset in the default rule.
+	Chain string `json:"chain,omitempty"`
+	// Hex emitter address. If unset, the rule applies to all emitters of the chain.
+	EmitterAddress string `json:"emitterAddress,omitempty"`
+	MaxAgeDays     uint   `json:"maxAgeDays,omitempty"`
+	KeepSequences  uint64 `json:"keepSequences,omitempty"`
+}
+
+// RetentionConfig is the JSON configuration of the retention policy.
+type RetentionConfig struct {
+	// Applies to the emitters without a more specific rule.
+	Default RetentionRule   `json:"default"`
+	Rules   []RetentionRule `json:"rules"`
+	// How often to prune, as a Go duration string. Defaults to one hour.
+	Interval string `json:"interval,omitempty"`
+	// Maximum number of VAAs deleted per second. Defaults to 1000.
+	MaxDeletesPerSecond uint `json:"maxDeletesPerSecond,omitempty"`
+}
+
+type retention struct {
+	maxAge        time.Duration
+	keepSequences uint64
+}
+
+type emitterKey struct {
+	chain   vaa.ChainID
+	address vaa.Address
+}
+
+// RetentionPolicy decides which signed VAAs are pruned. Governance VAAs are always kept.
+type RetentionPolicy struct {
+	defaultRule         retention
+	chains              map[vaa.ChainID]retention
+	emitters            map[emitterKey]retention
+	interval            time.Duration
+	maxDeletesPerSecond uint
+}
+
+func (r RetentionRule) retention() retention {
+	return retention{maxAge: time.Duration(r.MaxAgeDays) * 24 * time.Hour, keepSequences: r.KeepSequences}
+}
+
+func parseRetentionChain(s string) (vaa.ChainID, error) {
+	if chainID, err := vaa.ChainIDFromString(s); err == nil {
+		return chainID, nil
+	}
+
+	i, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return vaa.ChainIDUnset, fmt.Errorf("failed to parse as name or uint16: %v", err)
+	}
+
+	return vaa.ChainID(i), nil
+}
+
+// NewRetentionPolicy validates a retention config.
+func NewRetentionPolicy(cfg RetentionConfig) (*RetentionPolicy, error) {
+	p := &RetentionPolicy{
+		defaultRule:         cfg.Default.retention(),
+		chains:              make(map[vaa.ChainID]retention),
+		emitters:            make(map[emitterKey]retention),
+		interval:            defaultRetentionInterval,
+		maxDeletesPerSecond: defaultRetentionDeletesPerSec,
+	}
+
+	if cfg.Default.Chain != "" || cfg.Default.EmitterAddress != "" {
+		return nil, errors.New("the default rule must not set a chain or emitter address")
+	}
+
+	for _, rule := range cfg.Rules {
+		if rule.Chain == "" {
+			return nil, errors.New("each rule must set a chain")
+		}
+		chainID, err := parseRetentionChain(rule.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain %s: %w", rule.Chain, err)
+		}
+
+		if rule.EmitterAddress == "" {
+			if _, exists := p.chains[chainID]; exists {
+				return nil, fmt.Errorf("duplicate rule for chain %s", rule.Chain)
+			}
+			p.chains[chainID] = rule.retention()
+			continue
+		}
+
+		addr, err := vaa.StringToAddress(rule.EmitterAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid emitter address %s: %w", rule.EmitterAddress, err)
+		}
+		key := emitterKey{chainID, addr}
+		if _, exists := p.emitters[key]; exists {
+			return nil, fmt.Errorf("duplicate rule for emitter %s/%s", rule.Chain, rule.EmitterAddress)
+		}
+		p.emitters[key] = rule.retention()
+	}
+
+	if cfg.Interval != "" {
+		interval, err := time.ParseDuration(cfg.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval: %s", cfg.Interval)
+		}
+		p.interval = interval
+	}
+
+	if cfg.MaxDeletesPerSecond != 0 {
+		p.maxDeletesPerSecond = cfg.MaxDeletesPerSecond
+	}
+
+	return p, nil
+}
+
+// LoadRetentionPolicy reads a retention config from a JSON file.
+func LoadRetentionPolicy(path string) (*RetentionPolicy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retention config: %w", err)
+	}
+
+	var cfg RetentionConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse retention config: %w", err)
+	}
+
+	return NewRetentionPolicy(cfg)
+}
+
+// ruleFor returns the most specific rule for an emitter, and false if all its VAAs are kept.
+func (p *RetentionPolicy) ruleFor(chain vaa.ChainID, address vaa.Address) (retention, bool) {
+	if chain == vaa.GovernanceChain && address == vaa.GovernanceEmitter {
+		return retention{}, false
+	}
+
+	r, ok := p.emitters[emitterKey{chain, address}]
+	if !ok {
+		r, ok = p.chains[chain]
+	}
+	if !ok {
+		r = p.defaultRule
+	}
+
+	return r, r.maxAge != 0 || r.keepSequences != 0
+}
+
+// PruneStats describes the outcome of a pruning pass.
+type PruneStats struct {
+	VAAs  uint64
+	Bytes uint64
+}
+
+type storedVAA struct {
+	sequence  uint64
+	timestamp time.Time
+	size      int
+}
+
+// PruneByRetention deletes the signed VAAs that the policy no longer keeps, at most policy.maxDeletesPerSecond per
+// second.
+func (d *Database) PruneByRetention(ctx context.Context, policy *RetentionPolicy, now time.Time) (PruneStats, error) {
+	var stats PruneStats
+
+	// Collect the VAAs of each emitter which has a limit.
+	emitters := make(map[emitterKey][]storedVAA)
+	err := d.IterateSignedVAAs(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		r, prunable := policy.ruleFor(id.EmitterChain, id.EmitterAddress)
+		if !prunable {
+			return nil
+		}
+
+		s := storedVAA{sequence: id.Sequence, size: len(b)}
+		if r.maxAge != 0 {
+			v, err := vaa.Unmarshal(b)
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal VAA %s: %w", string(id.Bytes()), err)
+			}
+			s.timestamp = v.Timestamp
+		}
+
+		key := emitterKey{id.EmitterChain, id.EmitterAddress}
+		emitters[key] = append(emitters[key], s)
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for key, vaas := range emitters {
+		r, _ := policy.ruleFor(key.chain, key.address)
+		sort.Slice(vaas, func(i, j int) bool { return vaas[i].sequence < vaas[j].sequence })
+		highest := vaas[len(vaas)-1].sequence
+
+		// Delete runs of consecutive sequences, so that VAAs stored since the VAAs were collected are never deleted.
+		var run []storedVAA
+		flush := func() error {
+			if len(run) == 0 {
+				return nil
+			}
+			n, err := d.pruneRun(ctx, key, run, policy.maxDeletesPerSecond)
+			stats.VAAs += n
+			for _, s := range run {
+				stats.Bytes += uint64(s.size)
+			}
+			run = nil
+			return err
+		}
+
+		for _, s := range vaas {
+			expired := r.maxAge == 0 || now.Sub(s.timestamp) > r.maxAge
+			superseded := r.keepSequences == 0 || highest-s.sequence >= r.keepSequences
+			if !expired || !superseded {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+				continue
+			}
+
+			if len(run) != 0 && (run[len(run)-1].sequence+1 != s.sequence || uint(len(run)) == policy.maxDeletesPerSecond) {
+				if err := flush(); err != nil {
+					return stats, err
+				}
+			}
+			run = append(run, s)
+		}
+		if err := flush(); err != nil {
+			return stats, err
+		}
+	}
+
+	prunedVAABytesTotal.Add(float64(stats.Bytes))
+
+	// Deleted values are only reclaimed from the BadgerDB value log by garbage collection.
+	if _, ok := d.vaas.(*badgerStore); ok && stats.VAAs != 0 {
+		for {
+			if err := d.db.RunValueLogGC(0.5); err != nil {
+				break
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// pruneRun deletes a run of consecutive sequences of an emitter, then waits long enough to respect the rate limit.
+func (d *Database) pruneRun(ctx context.Context, key emitterKey, run []storedVAA, maxDeletesPerSecond uint) (uint64, error) {
+	n, err := d.vaas.Prune(VAAFilter{
+		EmitterChain:   key.chain,
+		EmitterAddress: &key.address,
+		FirstSequence:  run[0].sequence,
+		LastSequence:   run[len(run)-1].sequence,
+	})
+	if err != nil {
+		return n, fmt.Errorf("failed to prune VAAs of %d/%s: %w", key.chain, key.address, err)
+	}
+	prunedVAAsTotal.WithLabelValues(key.chain.String()).Add(float64(n))
+
+	select {
+	case <-ctx.Done():
+		return n, ctx.Err()
+	case <-time.After(time.Duration(len(run)) * time.Second / time.Duration(maxDeletesPerSecond)):
+	}
+
+	return n, nil
+}
+
+// RunRetention returns a runnable that prunes the database according to the policy.
+func (d *Database) RunRetention(logger *zap.Logger, policy *RetentionPolicy) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(policy.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				start := time.Now()
+				stats, err := d.PruneByRetention(ctx, policy, start)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					logger.Error("failed to prune database", zap.Error(err))
+					continue
+				}
+				logger.Info("pruned database",
+					zap.Uint64("vaas", stats.VAAs),
+					zap.Uint64("bytes", stats.Bytes),
+					zap.Duration("duration", time.Since(start)))
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetentionPolicy(t *testing.T) {
+	_, err := NewRetentionPolicy(RetentionConfig{
+		Default: RetentionRule{MaxAgeDays: 30},
+		Rules: []RetentionRule{
+			{Chain: "solana", KeepSequences: 10},
+			{Chain: "2", EmitterAddress: vaa.Address{1}.String(), MaxAgeDays: 1},
+		},
+		Interval: "30m",
+	})
+	assert.NoError(t, err)
+
+	invalid := []RetentionConfig{
+		{Default: RetentionRule{Chain: "solana"}},
+		{Rules: []RetentionRule{{MaxAgeDays: 1}}},
+		{Rules: []RetentionRule{{Chain: "nochain"}}},
+		{Rules: []RetentionRule{{Chain: "solana"}, {Chain: "1"}}},
+		{Rules: []RetentionRule{{Chain: "solana", EmitterAddress: "xyz"}}},
+		{Interval: "soon"},
+	}
+	for _, cfg := range invalid {
+		_, err := NewRetentionPolicy(cfg)
+		assert.Error(t, err)
+	}
+}
+
+func TestPruneByRetention(t *testing.T) {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	privKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Unix(100*86400, 0)
+	emitters := []struct {
+		chain   vaa.ChainID
+		address vaa.Address
+	}{
+		{vaa.ChainIDSolana, vaa.Address{1}},
+		{vaa.ChainIDEthereum, vaa.Address{1}},
+		{vaa.ChainIDEthereum, vaa.Address{2}},
+		{vaa.GovernanceChain, vaa.GovernanceEmitter},
+	}
+	// One VAA per day over the last 10 days, with sequences 1 to 10.
+	for _, e := range emitters {
+		for seq := uint64(1); seq <= 10; seq++ {
+			v := getVAA()
+			v.EmitterChain = e.chain
+			v.EmitterAddress = e.address
+			v.Sequence = seq
+			v.Timestamp = now.Add(-time.Duration(10-seq) * 24 * time.Hour)
+			v.AddSignature(privKey, 0)
+			require.NoError(t, db.StoreSignedVAA(&v))
+		}
+	}
+
+	policy, err := NewRetentionPolicy(RetentionConfig{
+		// Sequences 1 to 5 are older than 4 days.
+		Default: RetentionRule{MaxAgeDays: 4},
+		Rules: []RetentionRule{
+			{Chain: "ethereum", KeepSequences: 3},
+			{Chain: "ethereum", EmitterAddress: vaa.Address{2}.String(), MaxAgeDays: 4, KeepSequences: 8},
+		},
+		MaxDeletesPerSecond: 1000000,
+	})
+	require.NoError(t, err)
+
+	stats, err := db.PruneByRetention(context.Background(), policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5+7+2), stats.VAAs)
+	assert.NotZero(t, stats.Bytes)
+
+	remaining := map[vaa.ChainID]map[vaa.Address][]uint64{}
+	err = db.IterateSignedVAAs(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		if remaining[id.EmitterChain] == nil {
+			remaining[id.EmitterChain] = map[vaa.Address][]uint64{}
+		}
+		remaining[id.EmitterChain][id.EmitterAddress] = append(remaining[id.EmitterChain][id.EmitterAddress], id.Sequence)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []uint64{6, 7, 8, 9, 10}, remaining[vaa.ChainIDSolana][vaa.Address{1}])
+	assert.ElementsMatch(t, []uint64{8, 9, 10}, remaining[vaa.ChainIDEthereum][vaa.Address{1}])
+	assert.ElementsMatch(t, []uint64{3, 4, 5, 6, 7, 8, 9, 10}, remaining[vaa.ChainIDEthereum][vaa.Address{2}])
+	assert.Equal(t, 10, len(remaining[vaa.GovernanceChain][vaa.GovernanceEmitter]))
+
+	// A second pass has nothing left to prune.
+	stats, err = db.PruneByRetention(context.Background(), policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), stats.VAAs)
+}
@@ -0,0 +1,400 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+
+	// Registers the sqlite3 driver.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore keeps signed VAAs in a SQLite table, which can be queried with standard tools. SQLite integers are
+// signed, so sequences above math.MaxInt64 are not supported.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// The timestamp column, a copy of the VAA timestamp, and the checksum column were added later. The timestamp is NULL
+// until migrateSQLiteSchema fills it, and the checksum until the first integrity check does.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS signed_vaas (
+	emitter_chain INTEGER NOT NULL,
+	emitter_address TEXT NOT NULL,
+	sequence INTEGER NOT NULL,
+	vaa BLOB NOT NULL,
+	timestamp INTEGER,
+	checksum BLOB,
+	PRIMARY KEY (emitter_chain, emitter_address, sequence)
+);
+CREATE TABLE IF NOT EXISTS quarantined_vaas (
+	emitter_chain INTEGER NOT NULL,
+	emitter_address TEXT NOT NULL,
+	sequence INTEGER NOT NULL,
+	vaa BLOB NOT NULL,
+	PRIMARY KEY (emitter_chain, emitter_address, sequence)
+)`
+
+const sqliteTimeIndex = `CREATE INDEX IF NOT EXISTS signed_vaas_by_time ON signed_vaas (timestamp)`
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	// The write-ahead log allows reads while the node writes, and the busy timeout makes
+	// concurrent writers wait for each other instead of failing.
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite schema: %w", err)
+	}
+
+	if err := migrateSQLiteSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate SQLite schema: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteTimeIndex); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite time index: %w", err)
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+// migrateSQLiteSchema adds the columns missing from tables created by older releases, and fills in the timestamps.
+func migrateSQLiteSchema(db *sql.DB) error {
+	for _, column := range []string{"timestamp INTEGER", "checksum BLOB"} {
+		name := strings.Fields(column)[0]
+		var hasColumn bool
+		if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pragma_table_info('signed_vaas') WHERE name = ?)", name).Scan(&hasColumn); err != nil {
+			return err
+		}
+		if !hasColumn {
+			if _, err := db.Exec("ALTER TABLE signed_vaas ADD COLUMN " + column); err != nil {
+				return err
+			}
+		}
+	}
+
+	type row struct {
+		rowID     int64
+		timestamp int64
+	}
+	var missing []row
+
+	rows, err := db.Query("SELECT rowid, vaa FROM signed_vaas WHERE timestamp IS NULL")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			rowID int64
+			b     []byte
+		)
+		if err := rows.Scan(&rowID, &b); err != nil {
+			rows.Close()
+			return err
+		}
+		// Corrupt VAAs are left to the integrity check.
+		timestamp, err := vaaTimestamp(b)
+		if err != nil {
+			continue
+		}
+		missing = append(missing, row{rowID, timestamp})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, r := range missing {
+		if _, err := tx.Exec("UPDATE signed_vaas SET timestamp = ? WHERE rowid = ?", r.timestamp, r.rowID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func sqliteSequence(sequence uint64) int64 {
+	if sequence > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(sequence)
+}
+
+func (s *sqliteStore) PutVAA(id *VAAID, b []byte) error {
+	if id.Sequence > math.MaxInt64 {
+		return fmt.Errorf("sequence %d is too large for SQLite", id.Sequence)
+	}
+
+	timestamp, err := vaaTimestamp(b)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO signed_vaas (emitter_chain, emitter_address, sequence, vaa, timestamp, checksum) VALUES (?, ?, ?, ?, ?, ?)",
+		int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence), b, timestamp, vaaChecksum(b))
+	return err
+}
+
+func (s *sqliteStore) GetVAA(id *VAAID) ([]byte, error) {
+	if id.Sequence > math.MaxInt64 {
+		return nil, ErrVAANotFound
+	}
+
+	var b []byte
+	err := s.db.QueryRow("SELECT vaa FROM signed_vaas WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ?",
+		int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence)).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, ErrVAANotFound
+	}
+	return b, err
+}
+
+// where returns the WHERE clause selecting the rows matching the filter, and its arguments.
+func (f *VAAFilter) where() (string, []interface{}) {
+	conditions := []string{"sequence >= ?", "sequence <= ?"}
+	args := []interface{}{sqliteSequence(f.FirstSequence), sqliteSequence(f.LastSequence)}
+
+	if f.EmitterChain != vaa.ChainIDUnset {
+		conditions = append(conditions, "emitter_chain = ?")
+		args = append(args, int64(f.EmitterChain))
+	}
+	if f.EmitterAddress != nil {
+		conditions = append(conditions, "emitter_address = ?")
+		args = append(args, f.EmitterAddress.String())
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// Sequences are ordered numerically.
+func (s *sqliteStore) IterateByEmitter(filter VAAFilter, fn func(id *VAAID, b []byte) error) error {
+	if filter.FirstSequence > math.MaxInt64 {
+		return nil
+	}
+
+	where, args := filter.where()
+	rows, err := s.db.Query("SELECT emitter_chain, emitter_address, sequence, vaa FROM signed_vaas WHERE "+where+
+		" ORDER BY emitter_chain, emitter_address, sequence", args...)
+	if err != nil {
+		return err
+	}
+	return scanSQLiteVAAs(rows, fn)
+}
+
+// Sequences are ordered by timestamp, then by emitter and sequence.
+func (s *sqliteStore) IterateByTime(filter VAAFilter, r TimeRange, fn func(id *VAAID, b []byte) error) error {
+	if filter.FirstSequence > math.MaxInt64 {
+		return nil
+	}
+
+	where, args := filter.where()
+	since, until := r.unixBounds()
+	rows, err := s.db.Query("SELECT emitter_chain, emitter_address, sequence, vaa FROM signed_vaas WHERE "+where+
+		" AND timestamp >= ? AND timestamp < ? ORDER BY timestamp, emitter_chain, emitter_address, sequence", append(args, since, until)...)
+	if err != nil {
+		return err
+	}
+	return scanSQLiteVAAs(rows, fn)
+}
+
+// scanSQLiteVAAs calls fn with each row of a query selecting the emitter chain, emitter address, sequence and bytes of
+// signed VAAs, and closes the rows.
+func scanSQLiteVAAs(rows *sql.Rows, fn func(id *VAAID, b []byte) error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			emitterChain   int64
+			emitterAddress string
+			sequence       int64
+			b              []byte
+		)
+		if err := rows.Scan(&emitterChain, &emitterAddress, &sequence, &b); err != nil {
+			return err
+		}
+
+		addr, err := vaa.StringToAddress(emitterAddress)
+		if err != nil {
+			return fmt.Errorf("invalid emitter address %s: %w", emitterAddress, err)
+		}
+
+		id := &VAAID{EmitterChain: vaa.ChainID(emitterChain), EmitterAddress: addr, Sequence: uint64(sequence)}
+		if err := fn(id, b); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqliteStore) Prune(filter VAAFilter) (uint64, error) {
+	if filter.FirstSequence > math.MaxInt64 {
+		return 0, nil
+	}
+
+	where, args := filter.where()
+	res, err := s.db.Exec("DELETE FROM signed_vaas WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return uint64(n), err
+}
+
+func (s *sqliteStore) IterateWithChecksums(fn func(id *VAAID, b []byte, checksum []byte) error) error {
+	rows, err := s.db.Query("SELECT emitter_chain, emitter_address, sequence, vaa, checksum FROM signed_vaas ORDER BY emitter_chain, emitter_address, sequence")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			emitterChain   int64
+			emitterAddress string
+			sequence       int64
+			b              []byte
+			checksum       []byte
+		)
+		if err := rows.Scan(&emitterChain, &emitterAddress, &sequence, &b, &checksum); err != nil {
+			return err
+		}
+
+		addr, err := vaa.StringToAddress(emitterAddress)
+		if err != nil {
+			return fmt.Errorf("invalid emitter address %s: %w", emitterAddress, err)
+		}
+
+		id := &VAAID{EmitterChain: vaa.ChainID(emitterChain), EmitterAddress: addr, Sequence: uint64(sequence)}
+		if err := fn(id, b, checksum); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqliteStore) SetChecksum(id *VAAID, b []byte) error {
+	_, err := s.db.Exec("UPDATE signed_vaas SET checksum = ? WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ? AND vaa = ? AND checksum IS NULL",
+		vaaChecksum(b), int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence), b)
+	return err
+}
+
+func (s *sqliteStore) Quarantine(id *VAAID, b []byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	args := []interface{}{int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence), b}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO quarantined_vaas (emitter_chain, emitter_address, sequence, vaa) "+
+		"SELECT emitter_chain, emitter_address, sequence, vaa FROM signed_vaas WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ? AND vaa = ?", args...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM signed_vaas WHERE emitter_chain = ? AND emitter_address = ? AND sequence = ? AND vaa = ?", args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) IterateQuarantined(fn func(id *VAAID) error) error {
+	rows, err := s.db.Query("SELECT emitter_chain, emitter_address, sequence FROM quarantined_vaas")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Collect the IDs first, fn may query the database.
+	var ids []*VAAID
+	for rows.Next() {
+		var (
+			emitterChain   int64
+			emitterAddress string
+			sequence       int64
+		)
+		if err := rows.Scan(&emitterChain, &emitterAddress, &sequence); err != nil {
+			return err
+		}
+
+		addr, err := vaa.StringToAddress(emitterAddress)
+		if err != nil {
+			return fmt.Errorf("invalid emitter address %s: %w", emitterAddress, err)
+		}
+		ids = append(ids, &VAAID{EmitterChain: vaa.ChainID(emitterChain), EmitterAddress: addr, Sequence: uint64(sequence)})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// importIfEmpty copies all signed VAAs of src in a single transaction, unless the store already holds VAAs.
+func (s *sqliteStore) importIfEmpty(src Store) error {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM signed_vaas)").Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	stmt, err := tx.Prepare("INSERT INTO signed_vaas (emitter_chain, emitter_address, sequence, vaa, timestamp, checksum) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	err = src.IterateByEmitter(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		if id.Sequence > math.MaxInt64 {
+			return fmt.Errorf("sequence %d is too large for SQLite", id.Sequence)
+		}
+		timestamp, err := vaaTimestamp(b)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.Exec(int64(id.EmitterChain), id.EmitterAddress.String(), int64(id.Sequence), b, timestamp, vaaChecksum(b))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
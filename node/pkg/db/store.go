@@ -0,0 +1,207 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// Store persists signed VAAs. The Database keeps all other state in BadgerDB, but signed VAAs, which make up the bulk
+// of the data, can be kept in a different backend.
+type Store interface {
+	// PutVAA stores the bytes of a signed VAA, replacing any VAA stored under the same ID.
+	PutVAA(id *VAAID, b []byte) error
+	// GetVAA returns the bytes of a signed VAA, or ErrVAANotFound.
+	GetVAA(id *VAAID) ([]byte, error)
+	// IterateByEmitter calls fn with the ID and the bytes of each signed VAA matching the filter, ordered by emitter.
+	// The order of the sequences of an emitter is specific to the store. The bytes are only valid during the callback.
+	IterateByEmitter(filter VAAFilter, fn func(id *VAAID, b []byte) error) error
+	// IterateByTime calls fn with the ID and the bytes of each signed VAA matching the filter and the time range,
+	// ordered by timestamp. The bytes are only valid during the callback.
+	IterateByTime(filter VAAFilter, r TimeRange, fn func(id *VAAID, b []byte) error) error
+	// Prune deletes the signed VAAs matching the filter and returns how many were deleted.
+	Prune(filter VAAFilter) (uint64, error)
+	// IterateWithChecksums calls fn with the ID, the bytes and the checksum of each signed VAA. The checksum is nil for
+	// VAAs stored before checksums were. The bytes and the checksum are only valid during the callback.
+	IterateWithChecksums(fn func(id *VAAID, b []byte, checksum []byte) error) error
+	// SetChecksum stores the checksum of a signed VAA stored without one, provided its bytes are still b.
+	SetChecksum(id *VAAID, b []byte) error
+	// Quarantine moves a signed VAA out of the store, provided its bytes are still b. The bytes are kept for inspection.
+	Quarantine(id *VAAID, b []byte) error
+	// IterateQuarantined calls fn with the ID of each quarantined VAA.
+	IterateQuarantined(fn func(id *VAAID) error) error
+	Close() error
+}
+
+// badgerStore keeps signed VAAs in the node's BadgerDB under the "signed/<chain>/<address>/<sequence>" keys, along
+// with their checksum, and indexes them by time.
+type badgerStore struct {
+	db *badger.DB
+}
+
+// The prefix of the keys matched by the filter. Keys are ordered lexicographically, so the sequence range is checked per key.
+func (f *VAAFilter) prefix() ([]byte, error) {
+	if f.EmitterChain == vaa.ChainIDUnset {
+		if f.EmitterAddress != nil {
+			return nil, errors.New("the emitter chain must be specified when the emitter address is")
+		}
+		return []byte("signed/"), nil
+	}
+
+	if f.EmitterAddress == nil {
+		return []byte(fmt.Sprintf("signed/%d/", f.EmitterChain)), nil
+	}
+
+	return []byte(fmt.Sprintf("signed/%d/%s/", f.EmitterChain, f.EmitterAddress)), nil
+}
+
+func (s *badgerStore) PutVAA(id *VAAID, b []byte) error {
+	timestamp, err := vaaTimestamp(b)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		// A VAA replacing another one may have a different timestamp. The timestamp of a corrupt VAA is unknown, so
+		// its time index key is left behind.
+		item, err := txn.Get(id.Bytes())
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				old, err := vaaTimestamp(val)
+				if err != nil || old == timestamp {
+					return nil
+				}
+				return txn.Delete(timeIndexKey(id, old))
+			}); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		if err := txn.Set(timeIndexKey(id, timestamp), nil); err != nil {
+			return err
+		}
+		if err := txn.Set(id.checksumKey(), vaaChecksum(b)); err != nil {
+			return err
+		}
+		return txn.Set(id.Bytes(), b)
+	})
+}
+
+func (s *badgerStore) GetVAA(id *VAAID) (b []byte, err error) {
+	if err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(id.Bytes())
+		if err != nil {
+			return err
+		}
+		b, err = item.ValueCopy(nil)
+		return err
+	}); err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrVAANotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+// Keys are ordered lexicographically, so the sequences of an emitter are not in numerical order.
+func (s *badgerStore) IterateByEmitter(filter VAAFilter, fn func(id *VAAID, b []byte) error) error {
+	prefix, err := filter.prefix()
+	if err != nil {
+		return err
+	}
+
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			id, err := VaaIDFromString(string(bytes.TrimPrefix(item.Key(), []byte("signed/"))))
+			if err != nil {
+				return fmt.Errorf("failed to parse key %s: %w", string(item.Key()), err)
+			}
+
+			if !filter.matchesSequence(id.Sequence) {
+				continue
+			}
+
+			if err := item.Value(func(val []byte) error { return fn(id, val) }); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *badgerStore) Prune(filter VAAFilter) (uint64, error) {
+	prefix, err := filter.prefix()
+	if err != nil {
+		return 0, err
+	}
+
+	// Collect the keys and their time index and checksum keys first, since a single transaction may be too small to delete all of them.
+	var keys [][]byte
+	err = s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			id, err := VaaIDFromString(string(bytes.TrimPrefix(key, []byte("signed/"))))
+			if err != nil {
+				return fmt.Errorf("failed to parse key %s: %w", string(key), err)
+			}
+
+			if !filter.matchesSequence(id.Sequence) {
+				continue
+			}
+
+			keys = append(keys, key, id.checksumKey())
+			// The timestamp of a corrupt VAA is unknown, so its time index key is left behind.
+			if err := it.Item().Value(func(val []byte) error {
+				if timestamp, err := vaaTimestamp(val); err == nil {
+					keys = append(keys, timeIndexKey(id, timestamp))
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned uint64
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if bytes.HasPrefix(key, []byte("signed/")) {
+			pruned++
+		}
+		if err := wb.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to delete VAAs: %w", err)
+	}
+
+	return pruned, nil
+}
+
+// The BadgerDB is owned by the Database, which closes it.
+func (s *badgerStore) Close() error {
+	return nil
+}
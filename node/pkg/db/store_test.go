@@ -0,0 +1,209 @@
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestStores(t *testing.T) map[string]*Database {
+	badgerDB, err := Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { badgerDB.Close() })
+
+	dir := t.TempDir()
+	sqliteDB, err := OpenWithSQLiteVAAStore(path.Join(dir, "db"), path.Join(dir, "vaas.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqliteDB.Close() })
+
+	return map[string]*Database{"badger": badgerDB, "sqlite": sqliteDB}
+}
+
+func TestStores(t *testing.T) {
+	for name, db := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			storeTestVAAs(t, db)
+
+			emitter := vaa.Address{1}
+			id := VAAID{EmitterChain: vaa.ChainIDFantom, EmitterAddress: emitter, Sequence: 3}
+			b, err := db.GetSignedVAABytes(id)
+			require.NoError(t, err)
+			v, err := vaa.Unmarshal(b)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(3), v.Sequence)
+
+			_, err = db.GetSignedVAABytes(VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: emitter, Sequence: 3})
+			assert.Equal(t, ErrVAANotFound, err)
+
+			count, err := db.CountSignedVAAs(VAAFilter{EmitterChain: vaa.ChainIDSolana, EmitterAddress: &emitter, FirstSequence: 2, LastSequence: 10})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), count)
+
+			pruned, err := db.PruneSignedVAAs(VAAFilter{EmitterChain: vaa.ChainIDSolana, LastSequence: 4})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(8), pruned)
+
+			count, err = db.CountSignedVAAs(VAAFilter{LastSequence: math.MaxUint64})
+			require.NoError(t, err)
+			assert.Equal(t, uint64(40), count)
+
+			_, err = db.PruneSignedVAAs(VAAFilter{EmitterAddress: &emitter, LastSequence: math.MaxUint64})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSQLiteStoreImportsBadgerVAAs(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := path.Join(dir, "db")
+
+	db, err := Open(dbPath)
+	require.NoError(t, err)
+	storeTestVAAs(t, db)
+	require.NoError(t, db.Close())
+
+	db, err = OpenWithSQLiteVAAStore(dbPath, path.Join(dir, "vaas.sqlite"))
+	require.NoError(t, err)
+
+	count, err := db.CountSignedVAAs(VAAFilter{LastSequence: math.MaxUint64})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(48), count)
+
+	// The VAAs are only copied once, pruning them from SQLite does not bring them back.
+	_, err = db.PruneSignedVAAs(VAAFilter{EmitterChain: vaa.ChainIDSolana, LastSequence: math.MaxUint64})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = OpenWithSQLiteVAAStore(dbPath, path.Join(dir, "vaas.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	count, err = db.CountSignedVAAs(VAAFilter{LastSequence: math.MaxUint64})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(24), count)
+}
+
+// storeTimedTestVAAs stores one VAA per hour for each of two emitters, with sequence n stored at hour n.
+func storeTimedTestVAAs(t *testing.T, db *Database) time.Time {
+	privKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	require.NoError(t, err)
+
+	start := time.Unix(1000*3600, 0)
+	for _, emitter := range []vaa.Address{{1}, {2}} {
+		for seq := uint64(0); seq < 10; seq++ {
+			v := getVAA()
+			v.EmitterAddress = emitter
+			v.Sequence = seq
+			v.Timestamp = start.Add(time.Duration(seq) * time.Hour)
+			v.AddSignature(privKey, 0)
+			require.NoError(t, db.StoreSignedVAA(&v))
+		}
+	}
+	return start
+}
+
+func TestStoresIterateByTime(t *testing.T) {
+	for name, db := range openTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			start := storeTimedTestVAAs(t, db)
+
+			var timestamps []time.Time
+			emitter := vaa.Address{2}
+			err := db.IterateSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{Since: start.Add(2 * time.Hour), Until: start.Add(5 * time.Hour)}, func(id *VAAID, b []byte) error {
+				v, err := vaa.Unmarshal(b)
+				require.NoError(t, err)
+				assert.Equal(t, id.Sequence, v.Sequence)
+				timestamps = append(timestamps, v.Timestamp)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 6, len(timestamps))
+			for i := 1; i < len(timestamps); i++ {
+				assert.False(t, timestamps[i].Before(timestamps[i-1]))
+			}
+
+			vaas, truncated, err := db.GetSignedVAAsByTime(VAAFilter{EmitterChain: vaa.ChainIDSolana, EmitterAddress: &emitter, LastSequence: math.MaxUint64}, TimeRange{Since: start.Add(8 * time.Hour)}, 10)
+			require.NoError(t, err)
+			assert.False(t, truncated)
+			assert.Equal(t, 2, len(vaas))
+
+			vaas, truncated, err = db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{}, 5)
+			require.NoError(t, err)
+			assert.True(t, truncated)
+			assert.Equal(t, 5, len(vaas))
+
+			// Replacing a VAA with a different timestamp moves it in the index.
+			v, err := vaa.Unmarshal(vaas[0])
+			require.NoError(t, err)
+			v.Timestamp = start.Add(100 * time.Hour)
+			require.NoError(t, db.StoreSignedVAA(v))
+			vaas, _, err = db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{Until: start.Add(time.Hour)}, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, len(vaas))
+			vaas, _, err = db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{Since: start.Add(100 * time.Hour)}, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, len(vaas))
+
+			// Pruned VAAs are removed from the index.
+			_, err = db.PruneSignedVAAs(VAAFilter{EmitterChain: vaa.ChainIDSolana, LastSequence: 4})
+			require.NoError(t, err)
+			vaas, _, err = db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{}, 100)
+			require.NoError(t, err)
+			assert.Equal(t, 10, len(vaas))
+		})
+	}
+}
+
+func TestBadgerTimeIndexIsBuiltForExistingVAAs(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(dir)
+	require.NoError(t, err)
+	start := storeTimedTestVAAs(t, db)
+
+	// Simulate a database created before the time index.
+	require.NoError(t, db.db.DropPrefix([]byte(timeIndexPrefix), []byte(timeIndexComplete)))
+	vaas, _, err := db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{}, 100)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(vaas))
+	require.NoError(t, db.Close())
+
+	db, err = Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	vaas, _, err = db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{Since: start.Add(5 * time.Hour)}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 10, len(vaas))
+}
+
+func TestSQLiteTimestampMigration(t *testing.T) {
+	dir := t.TempDir()
+	sqlitePath := path.Join(dir, "vaas.sqlite")
+	db, err := OpenWithSQLiteVAAStore(path.Join(dir, "db"), sqlitePath)
+	require.NoError(t, err)
+	start := storeTimedTestVAAs(t, db)
+
+	// Simulate a table created before the timestamp column.
+	store := db.vaas.(*sqliteStore)
+	_, err = store.db.Exec("DROP INDEX signed_vaas_by_time")
+	require.NoError(t, err)
+	_, err = store.db.Exec("ALTER TABLE signed_vaas DROP COLUMN timestamp")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = OpenWithSQLiteVAAStore(path.Join(dir, "db"), sqlitePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	vaas, _, err := db.GetSignedVAAsByTime(VAAFilter{LastSequence: math.MaxUint64}, TimeRange{Since: start.Add(5 * time.Hour)}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 10, len(vaas))
+}
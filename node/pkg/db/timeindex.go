@@ -0,0 +1,169 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// TimeRange selects signed VAAs by their timestamp. Since is inclusive and Until is exclusive, a zero bound is open.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+func (r *TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// unixBounds returns the range as Unix timestamps, the upper bound being exclusive. VAA timestamps are uint32.
+func (r *TimeRange) unixBounds() (int64, int64) {
+	since, until := int64(0), int64(math.MaxUint32)+1
+	if !r.Since.IsZero() && r.Since.Unix() > since {
+		since = r.Since.Unix()
+	}
+	if !r.Until.IsZero() && r.Until.Unix() < until {
+		until = r.Until.Unix()
+	}
+	return since, until
+}
+
+func (f *VAAFilter) matches(id *VAAID) bool {
+	if f.EmitterChain != vaa.ChainIDUnset && id.EmitterChain != f.EmitterChain {
+		return false
+	}
+	if f.EmitterAddress != nil && id.EmitterAddress != *f.EmitterAddress {
+		return false
+	}
+	return f.matchesSequence(id.Sequence)
+}
+
+func vaaTimestamp(b []byte) (int64, error) {
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unmarshal VAA: %w", err)
+	}
+	return v.Timestamp.Unix(), nil
+}
+
+// The time index holds an empty "signedtime/<timestamp>/<chain>/<address>/<sequence>" key for each signed VAA in the
+// BadgerDB, the timestamp being fixed width hex so that keys are ordered by time. It is updated in the same
+// transaction as the VAA.
+const timeIndexPrefix = "signedtime/"
+
+// Set once the time index covers all signed VAAs, which databases created before the index did not have.
+const timeIndexComplete = "signedtime-complete"
+
+func timeIndexSeekKey(timestamp int64) []byte {
+	return []byte(fmt.Sprintf("%s%08x/", timeIndexPrefix, timestamp))
+}
+
+func timeIndexKey(id *VAAID, timestamp int64) []byte {
+	return append(timeIndexSeekKey(timestamp), bytes.TrimPrefix(id.Bytes(), []byte("signed/"))...)
+}
+
+func parseTimeIndexKey(key []byte) (*VAAID, int64, error) {
+	s := string(bytes.TrimPrefix(key, []byte(timeIndexPrefix)))
+	if len(s) < 9 || s[8] != '/' {
+		return nil, 0, fmt.Errorf("invalid time index key %s", string(key))
+	}
+
+	timestamp, err := strconv.ParseInt(s[:8], 16, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid time index key %s: %w", string(key), err)
+	}
+
+	id, err := VaaIDFromString(s[9:])
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid time index key %s: %w", string(key), err)
+	}
+
+	return id, timestamp, nil
+}
+
+// Sequences are ordered by timestamp, then by key.
+func (s *badgerStore) IterateByTime(filter VAAFilter, r TimeRange, fn func(id *VAAID, b []byte) error) error {
+	since, until := r.unixBounds()
+	if since >= until {
+		return nil
+	}
+
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(timeIndexPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(timeIndexSeekKey(since)); it.Valid(); it.Next() {
+			id, timestamp, err := parseTimeIndexKey(it.Item().Key())
+			if err != nil {
+				return err
+			}
+			if timestamp >= until {
+				return nil
+			}
+
+			if !filter.matches(id) {
+				continue
+			}
+
+			// Keys of corrupt VAAs which were replaced or deleted may be left behind.
+			item, err := txn.Get(id.Bytes())
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get indexed VAA %s: %w", string(id.Bytes()), err)
+			}
+			if err := item.Value(func(val []byte) error { return fn(id, val) }); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// buildTimeIndex indexes the signed VAAs stored before the time index existed.
+func (s *badgerStore) buildTimeIndex() error {
+	complete := false
+	if err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(timeIndexComplete))
+		if err == nil {
+			complete = true
+			return nil
+		}
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	}); err != nil || complete {
+		return err
+	}
+
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	err := s.IterateByEmitter(VAAFilter{LastSequence: math.MaxUint64}, func(id *VAAID, b []byte) error {
+		// Corrupt VAAs are left to the integrity check.
+		timestamp, err := vaaTimestamp(b)
+		if err != nil {
+			return nil
+		}
+		return wb.Set(timeIndexKey(id, timestamp), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := wb.Set([]byte(timeIndexComplete), nil); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// The tx hash of each observed message is kept for a limited time, so the message can be reobserved knowing only its ID.
+const messageTxHashRetention = 30 * 24 * time.Hour
+
+var (
+	ErrTxHashNotFound = errors.New("requested tx hash not found in store")
+)
+
+func (i *VAAID) TxHashBytes() []byte {
+	return []byte(fmt.Sprintf("txhash/%d/%s/%d", i.EmitterChain, i.EmitterAddress, i.Sequence))
+}
+
+// The tx hash index holds an empty "txhashidx/<chain>/<tx hash>/<address>/<sequence>" key for each observed message, so
+// the messages emitted by a transaction can be found from its hash. Unlike the tx hash of a message, it does not expire,
+// since it is only useful as long as the signed VAAs are kept.
+const txHashIndexPrefix = "txhashidx/"
+
+func txHashIndexPrefixBytes(chain vaa.ChainID, txHash []byte) []byte {
+	return []byte(fmt.Sprintf("%s%d/%s/", txHashIndexPrefix, chain, hex.EncodeToString(txHash)))
+}
+
+func txHashIndexKey(id *VAAID, txHash []byte) []byte {
+	return append(txHashIndexPrefixBytes(id.EmitterChain, txHash), []byte(fmt.Sprintf("%s/%d", id.EmitterAddress, id.Sequence))...)
+}
+
+// StoreMessageTxHash records the chain-specific tx hash of an observed message. The entry expires after the retention period,
+// but the message can still be found by tx hash using GetMessageIDsByTxHash.
+func (d *Database) StoreMessageTxHash(id VAAID, txHash []byte) error {
+	err := d.db.Update(func(txn *badger.Txn) error {
+		if err := txn.SetEntry(badger.NewEntry(id.TxHashBytes(), txHash).WithTTL(messageTxHashRetention)); err != nil {
+			return err
+		}
+		return txn.Set(txHashIndexKey(&id, txHash), nil)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Database) GetMessageTxHash(id VAAID) (b []byte, err error) {
+	if err := d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(id.TxHashBytes())
+		if err != nil {
+			return err
+		}
+		b, err = item.ValueCopy(nil)
+		return err
+	}); err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, ErrTxHashNotFound
+		}
+		return nil, err
+	}
+	return
+}
+
+// GetMessageIDsByTxHash returns the IDs of the messages observed in a transaction on the emitter chain, ordered by emitter
+// and sequence.
+func (d *Database) GetMessageIDsByTxHash(chain vaa.ChainID, txHash []byte) ([]*VAAID, error) {
+	prefix := txHashIndexPrefixBytes(chain, txHash)
+	var ids []*VAAID
+	err := d.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().Key()
+			id, err := VaaIDFromString(fmt.Sprintf("%d/%s", chain, bytes.TrimPrefix(key, prefix)))
+			if err != nil {
+				return fmt.Errorf("invalid tx hash index key %s: %w", string(key), err)
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
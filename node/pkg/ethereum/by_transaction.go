@@ -81,6 +81,8 @@ func MessageEventsForTransaction(
 			EmitterAddress:   PadAddress(ev.Sender),
 			Payload:          ev.Payload,
 			ConsistencyLevel: ev.ConsistencyLevel,
+			// Completed by the caller, which knows the RPC endpoint.
+			Provenance: &common.Provenance{Block: receipt.BlockHash.Hex()},
 		}
 
 		msgs = append(msgs, message)
@@ -0,0 +1,97 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/testutils/consistency"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testBlock returns the eth_getBlockByHash result of an empty block.
+func testBlock(t *testing.T, number uint64) map[string]interface{} {
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Time:       1660000000,
+		Difficulty: big.NewInt(0),
+		TxHash:     types.EmptyRootHash,
+		UncleHash:  types.EmptyUncleHash,
+	}
+	b, err := json.Marshal(header)
+	require.NoError(t, err)
+	var block map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &block))
+	block["transactions"] = []interface{}{}
+	block["uncles"] = []interface{}{}
+	return block
+}
+
+// testReceipt returns the receipt of a transaction in the given block which published msg.
+func testReceipt(t *testing.T, contract eth_common.Address, sender eth_common.Address, block uint64, msg *common.MessagePublication) *types.Receipt {
+	l := messageLog(t, contract, sender, msg)
+	log := &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data, BlockNumber: block, TxHash: msg.TxHash, BlockHash: eth_common.HexToHash("0xb1")}
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		Logs:        []*types.Log{log},
+		Bloom:       types.CreateBloom(types.Receipts{{Logs: []*types.Log{log}}}),
+		TxHash:      msg.TxHash,
+		BlockHash:   log.BlockHash,
+		BlockNumber: new(big.Int).SetUint64(block),
+	}
+}
+
+// TestConsistencyLevelConformance checks that the watcher waits for the documented number of confirmations before
+// observing messages, for each consistency level.
+func TestConsistencyLevelConformance(t *testing.T) {
+	const minConfirmations = 5
+	const block = 100
+
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+
+	contract := eth_common.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550")
+	sender := eth_common.HexToAddress("0x0290FB167208Af455bB137780163b7B7a9a10C16")
+	server.SetResult("eth_getBlockByHash", testBlock(t, block))
+
+	msgC := make(chan *common.MessagePublication, 1)
+	w := NewEthWatcher(server.URL, contract, "eth", common.ReadinessEthSyncing, vaa.ChainIDEthereum, msgC, nil, minConfirmations, nil, true)
+	require.NoError(t, w.ethIntf.DialContext(context.Background(), server.URL))
+	require.NoError(t, w.ethIntf.NewAbiFilterer(contract))
+
+	for _, c := range consistency.EVM(minConfirmations) {
+		t.Run(fmt.Sprintf("level %d", c.Level), func(t *testing.T) {
+			require.Equal(t, consistency.Confirmations, c.Finality)
+			// The same number of confirmations is awaited by the primary watcher.
+			assert.Equal(t, c.Confirmations, w.expectedConfirmations(c.Level))
+
+			msg := &common.MessagePublication{TxHash: eth_common.HexToHash("0x01"), Sequence: 1, Payload: []byte{1}, ConsistencyLevel: c.Level}
+			server.SetResult("eth_getTransactionReceipt", testReceipt(t, contract, sender, block, msg))
+
+			w.reobserve(context.Background(), zap.NewNop(), msg.TxHash, block+c.Confirmations-1)
+			select {
+			case obsv := <-msgC:
+				t.Fatalf("message observed after %d confirmations: %v", c.Confirmations-1, obsv)
+			default:
+			}
+
+			w.reobserve(context.Background(), zap.NewNop(), msg.TxHash, block+c.Confirmations)
+			select {
+			case obsv := <-msgC:
+				assert.Equal(t, c.ObservedLevel, obsv.ConsistencyLevel)
+				assert.Equal(t, uint64(1), obsv.Sequence)
+			default:
+				t.Fatalf("message not observed after %d confirmations", c.Confirmations)
+			}
+		})
+	}
+}
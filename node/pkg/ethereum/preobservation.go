@@ -0,0 +1,176 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	ethPreObservations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eth_pre_observations_total",
+			Help: "Total number of messages found by simulating pending transactions",
+		}, []string{"eth_network"})
+	ethPreObservationsDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eth_pre_observations_dropped_total",
+			Help: "Total number of messages found by simulating pending transactions that were dropped because the consumer could not keep up",
+		}, []string{"eth_network"})
+	ethPreObservationErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eth_pre_observation_errors_total",
+			Help: "Total number of pending transactions that could not be simulated",
+		}, []string{"eth_network"})
+)
+
+// Number of pending transactions looked up and simulated concurrently.
+const preObservationWorkers = 8
+
+// PreObservationWatcher watches the mempool for pending transactions calling the core bridge, directly or through
+// other contracts like the token bridge, and simulates them against the pending state of the chain. The messages they
+// would publish are sent as pre-observations, which let relayers prepare the transactions on the target chain before
+// the message is even confirmed.
+//
+// Pre-observations are never signed or gossiped: the transaction may never be included, or publish a different message
+// once it is. They are only consumed by the spy. The RPC node must support newPendingTransactions subscriptions and
+// debug_traceCall with the callTracer.
+type PreObservationWatcher struct {
+	url         string
+	contract    eth_common.Address
+	networkName string
+	chainID     vaa.ChainID
+	// Only transactions calling one of these contracts, including the core bridge, are simulated.
+	targets map[eth_common.Address]bool
+	// Pre-observations are dropped if the channel is full.
+	preObsvC chan<- *common.MessagePublication
+}
+
+// NewPreObservationWatcher creates a watcher simulating the pending transactions calling the core bridge at contract
+// or one of the other target contracts. The timestamp of the pre-observations is the time the transaction was seen.
+func NewPreObservationWatcher(url string, contract eth_common.Address, networkName string, chainID vaa.ChainID, targets []eth_common.Address, preObsvC chan<- *common.MessagePublication) *PreObservationWatcher {
+	w := &PreObservationWatcher{
+		url:         url,
+		contract:    contract,
+		networkName: networkName,
+		chainID:     chainID,
+		targets:     map[eth_common.Address]bool{contract: true},
+		preObsvC:    preObsvC,
+	}
+	for _, t := range targets {
+		w.targets[t] = true
+	}
+	return w
+}
+
+func (w *PreObservationWatcher) Run(ctx context.Context) error {
+	logger := supervisor.Logger(ctx)
+
+	c, err := rpc.DialContext(ctx, w.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to url %s: %w", w.url, err)
+	}
+	defer c.Close()
+
+	hashes := make(chan eth_common.Hash, 1000)
+	sub, err := c.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to pending transactions: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	logger.Info("watching pending transactions", zap.String("eth_network", w.networkName), zap.Int("targets", len(w.targets)))
+	supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for i := 0; i < preObservationWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case h := <-hashes:
+					if err := w.simulate(ctx, c, h, time.Now()); err != nil {
+						ethPreObservationErrors.WithLabelValues(w.networkName).Inc()
+						logger.Debug("failed to simulate pending transaction", zap.String("eth_network", w.networkName), zap.Stringer("tx_hash", h), zap.Error(err))
+					}
+				}
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-sub.Err():
+		return fmt.Errorf("pending transaction subscription failed: %w", err)
+	}
+}
+
+// pendingTransaction holds the fields of eth_getTransactionByHash needed to simulate a transaction.
+type pendingTransaction struct {
+	From      eth_common.Address  `json:"from"`
+	To        *eth_common.Address `json:"to"`
+	Input     hexutil.Bytes       `json:"input"`
+	Value     *hexutil.Big        `json:"value"`
+	Gas       hexutil.Uint64      `json:"gas"`
+	BlockHash *eth_common.Hash    `json:"blockHash"`
+}
+
+// simulate traces a pending transaction against the pending state and sends the messages it publishes.
+func (w *PreObservationWatcher) simulate(ctx context.Context, c *rpc.Client, txHash eth_common.Hash, seenAt time.Time) error {
+	var tx *pendingTransaction
+	if err := c.CallContext(ctx, &tx, "eth_getTransactionByHash", txHash); err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	// The transaction was already dropped or included, deploys a contract or calls another contract.
+	if tx == nil || tx.BlockHash != nil || tx.To == nil || !w.targets[*tx.To] {
+		return nil
+	}
+
+	var trace callFrame
+	err := c.CallContext(ctx, &trace, "debug_traceCall", map[string]interface{}{
+		"from":  tx.From,
+		"to":    tx.To,
+		"gas":   tx.Gas,
+		"value": tx.Value,
+		"data":  tx.Input,
+	}, "pending", map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to trace transaction: %w", err)
+	}
+
+	for _, msg := range messagesInTrace(&trace, w.contract) {
+		msg.TxHash = txHash
+		msg.Timestamp = seenAt
+		msg.EmitterChain = w.chainID
+
+		ethPreObservations.WithLabelValues(w.networkName).Inc()
+		select {
+		case w.preObsvC <- msg:
+		default:
+			ethPreObservationsDropped.WithLabelValues(w.networkName).Inc()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreObservationSimulate(t *testing.T) {
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+	c, err := rpc.DialContext(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer c.Close()
+
+	contract := eth_common.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550")
+	tokenBridge := eth_common.HexToAddress("0x0290FB167208Af455bB137780163b7B7a9a10C16")
+	user := eth_common.HexToAddress("0xe982E462b094850F12AF94d21D470e21bE9D0E9C")
+	msg := &common.MessagePublication{Sequence: 42, Nonce: 7, Payload: []byte{1, 2, 3}, ConsistencyLevel: 15}
+
+	preObsvC := make(chan *common.MessagePublication, 1)
+	w := NewPreObservationWatcher(server.URL, contract, "eth", vaa.ChainIDEthereum, []eth_common.Address{tokenBridge}, preObsvC)
+
+	var traced map[string]interface{}
+	server.Handle("debug_traceCall", func(params json.RawMessage) (interface{}, error) {
+		var args []interface{}
+		require.NoError(t, json.Unmarshal(params, &args))
+		traced = args[0].(map[string]interface{})
+		assert.Equal(t, "pending", args[1])
+		// The token bridge publishes the message through the core bridge.
+		return callFrame{To: tokenBridge, Calls: []callFrame{{To: contract, Logs: []callLog{messageLog(t, contract, tokenBridge, msg)}}}}, nil
+	})
+
+	txHash := eth_common.HexToHash("0x01")
+	seenAt := time.Unix(1660000000, 0)
+	server.SetResult("eth_getTransactionByHash", map[string]interface{}{"from": user, "to": tokenBridge, "input": "0xabcd", "value": "0x0", "gas": "0x5208", "blockHash": nil})
+	require.NoError(t, w.simulate(context.Background(), c, txHash, seenAt))
+
+	assert.Equal(t, "0xabcd", traced["data"])
+	assert.Equal(t, user.Hex(), eth_common.HexToAddress(traced["from"].(string)).Hex())
+	select {
+	case preObsv := <-preObsvC:
+		assert.Equal(t, &common.MessagePublication{
+			TxHash:           txHash,
+			Timestamp:        seenAt,
+			Nonce:            7,
+			Sequence:         42,
+			ConsistencyLevel: 15,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   PadAddress(tokenBridge),
+			Payload:          []byte{1, 2, 3},
+		}, preObsv)
+	default:
+		t.Fatal("no pre-observation")
+	}
+
+	// Pre-observations are dropped if the consumer cannot keep up.
+	preObsvC <- msg
+	require.NoError(t, w.simulate(context.Background(), c, txHash, seenAt))
+	<-preObsvC
+
+	// Transactions calling other contracts and included transactions are not simulated.
+	traced = nil
+	server.SetResult("eth_getTransactionByHash", map[string]interface{}{"from": user, "to": user, "input": "0x", "value": "0x0", "gas": "0x5208", "blockHash": nil})
+	require.NoError(t, w.simulate(context.Background(), c, txHash, seenAt))
+	server.SetResult("eth_getTransactionByHash", map[string]interface{}{"from": user, "to": contract, "input": "0x", "value": "0x0", "gas": "0x5208", "blockHash": eth_common.HexToHash("0x02")})
+	require.NoError(t, w.simulate(context.Background(), c, txHash, seenAt))
+	server.SetResult("eth_getTransactionByHash", nil)
+	require.NoError(t, w.simulate(context.Background(), c, txHash, seenAt))
+	assert.Nil(t, traced)
+	assert.Empty(t, preObsvC)
+}
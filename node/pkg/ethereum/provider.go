@@ -0,0 +1,254 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var ethProviderQuirks = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "wormhole_eth_provider_quirks",
+		Help: "Quirks of the RPC provider of an EVM chain detected at startup: the eth_getLogs block range cap (0 if none), and 1 if eth_getBlockReceipts or the finalized block tag are unusable",
+	}, []string{"eth_network", "quirk"})
+
+// Block ranges tried, largest first, to find the maximum range of eth_getLogs. Providers typically cap it at 10000,
+// 5000, 2000 or 1000 blocks, and some free tiers much lower.
+var logRangeCandidates = []uint64{10000, 5000, 2000, 1000, 500, 100, 10, 1}
+
+// ErrNoBlockReceipts is returned by Provider.BlockReceipts when the provider does not support eth_getBlockReceipts.
+var ErrNoBlockReceipts = errors.New("eth_getBlockReceipts is not supported by the provider")
+
+// RPCCaller is the part of an RPC client used by Provider. It is implemented by rpc.Client.
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// ProviderQuirks are the deviations of an RPC provider from what a self-hosted node supports.
+type ProviderQuirks struct {
+	// Maximum number of blocks covered by an eth_getLogs request, 0 if the provider accepted the largest range tried.
+	MaxLogRange uint64
+	// Whether eth_getBlockReceipts is supported.
+	BlockReceipts bool
+	// Whether the finalized block tag is supported and consistent with the latest block.
+	FinalizedTag bool
+}
+
+// Provider is an RPC client working around the quirks of its provider, as probed when it is created. The same watcher
+// code then works against Alchemy, Infura, QuickNode or a self-hosted node.
+type Provider struct {
+	c      RPCCaller
+	close  func()
+	Quirks ProviderQuirks
+}
+
+// DialProvider connects to the RPC endpoint at url and probes its quirks. The probes query the logs of contract, which
+// should be the core bridge.
+func DialProvider(ctx context.Context, url string, contract eth_common.Address) (*Provider, error) {
+	c, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	quirks, err := ProbeProviderQuirks(ctx, c, contract)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	p := NewProvider(c, quirks)
+	p.close = c.Close
+	return p, nil
+}
+
+func NewProvider(c RPCCaller, quirks ProviderQuirks) *Provider {
+	return &Provider{c: c, Quirks: quirks}
+}
+
+// Close closes the connection of a provider created by DialProvider.
+func (p *Provider) Close() {
+	if p.close != nil {
+		p.close()
+	}
+}
+
+// ProbeProviderQuirks probes the quirks of the provider behind c with a few requests near the latest block.
+func ProbeProviderQuirks(ctx context.Context, c RPCCaller, contract eth_common.Address) (ProviderQuirks, error) {
+	var q ProviderQuirks
+
+	var latest hexutil.Uint64
+	if err := c.CallContext(ctx, &latest, "eth_blockNumber"); err != nil {
+		return q, fmt.Errorf("failed to get the latest block: %w", err)
+	}
+
+	// Log range caps are found by trying smaller and smaller ranges ending at the latest block.
+	found := false
+	for i, n := range logRangeCandidates {
+		var logs []ethTypes.Log
+		err := c.CallContext(ctx, &logs, "eth_getLogs", toFilterArg(ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(uint64(latest) + 1 - min(n, uint64(latest)+1)),
+			ToBlock:   new(big.Int).SetUint64(uint64(latest)),
+			Addresses: []eth_common.Address{contract},
+			Topics:    [][]eth_common.Hash{{logMessagePublishedTopic}},
+		}))
+		if err == nil {
+			if i != 0 {
+				q.MaxLogRange = n
+			}
+			found = true
+			break
+		}
+		if !isLogRangeError(err) {
+			return q, fmt.Errorf("failed to probe the eth_getLogs block range: %w", err)
+		}
+	}
+	if !found {
+		return q, errors.New("eth_getLogs fails even for a single block")
+	}
+
+	var receipts []*ethTypes.Receipt
+	if err := c.CallContext(ctx, &receipts, "eth_getBlockReceipts", latest); err == nil && receipts != nil {
+		q.BlockReceipts = true
+	}
+
+	var finalized *ethTypes.Header
+	if err := c.CallContext(ctx, &finalized, "eth_getBlockByNumber", "finalized", false); err == nil && finalized != nil {
+		// Some providers answer with a block ahead of the latest one, from another node of their pool.
+		q.FinalizedTag = finalized.Number != nil && finalized.Number.Uint64() <= uint64(latest)
+	}
+
+	return q, nil
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// isLogRangeError returns whether err rejects an eth_getLogs request for covering too many blocks or returning too
+// many logs, like "block range too large", "query returned more than 10000 results" or "Log response size exceeded".
+// Other errors are not caused by the range and fail the probe.
+func isLogRangeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"range", "limit", "exceed", "more than", "too many", "too large"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportQuirks exports the quirks of the provider of network as metrics.
+func (p *Provider) ReportQuirks(network string) {
+	ethProviderQuirks.WithLabelValues(network, "max_log_range").Set(float64(p.Quirks.MaxLogRange))
+	ethProviderQuirks.WithLabelValues(network, "no_block_receipts").Set(boolToFloat(!p.Quirks.BlockReceipts))
+	ethProviderQuirks.WithLabelValues(network, "no_finalized_tag").Set(boolToFloat(!p.Quirks.FinalizedTag))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *Provider) BlockNumber(ctx context.Context) (uint64, error) {
+	var n hexutil.Uint64
+	if err := p.c.CallContext(ctx, &n, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+// FilterLogs returns the logs matching q, split in as many eth_getLogs requests as the log range cap requires.
+func (p *Provider) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	if p.Quirks.MaxLogRange == 0 || q.BlockHash != nil || q.FromBlock == nil || q.ToBlock == nil {
+		var logs []ethTypes.Log
+		err := p.c.CallContext(ctx, &logs, "eth_getLogs", toFilterArg(q))
+		return logs, err
+	}
+
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	var res []ethTypes.Log
+	for start := from; start <= to; start += p.Quirks.MaxLogRange {
+		end := start + p.Quirks.MaxLogRange - 1
+		if end > to || end < start {
+			end = to
+		}
+		chunk := q
+		chunk.FromBlock = new(big.Int).SetUint64(start)
+		chunk.ToBlock = new(big.Int).SetUint64(end)
+
+		var logs []ethTypes.Log
+		if err := p.c.CallContext(ctx, &logs, "eth_getLogs", toFilterArg(chunk)); err != nil {
+			return nil, err
+		}
+		res = append(res, logs...)
+		if end == to {
+			break
+		}
+	}
+	return res, nil
+}
+
+// BlockReceipts returns the receipts of the block with hash, or ErrNoBlockReceipts if the provider can't. A nil result
+// means that the block is unknown, as happens after a reorg.
+func (p *Provider) BlockReceipts(ctx context.Context, hash eth_common.Hash) ([]*ethTypes.Receipt, error) {
+	if !p.Quirks.BlockReceipts {
+		return nil, ErrNoBlockReceipts
+	}
+	var receipts []*ethTypes.Receipt
+	if err := p.c.CallContext(ctx, &receipts, "eth_getBlockReceipts", hash); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// FinalizedBlockNumber returns the number of the latest finalized block. ok is false if the provider doesn't support
+// the finalized tag consistently, in which case the caller must count confirmations instead.
+func (p *Provider) FinalizedBlockNumber(ctx context.Context) (n uint64, ok bool, err error) {
+	if !p.Quirks.FinalizedTag {
+		return 0, false, nil
+	}
+	var h *ethTypes.Header
+	if err := p.c.CallContext(ctx, &h, "eth_getBlockByNumber", "finalized", false); err != nil {
+		return 0, false, err
+	}
+	if h == nil || h.Number == nil {
+		return 0, false, errors.New("the provider returned no finalized block")
+	}
+	return h.Number.Uint64(), true, nil
+}
+
+// toFilterArg converts q to the parameters of eth_getLogs, like ethclient does.
+func toFilterArg(q ethereum.FilterQuery) interface{} {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		arg["blockHash"] = *q.BlockHash
+		return arg
+	}
+	if q.FromBlock == nil {
+		arg["fromBlock"] = "0x0"
+	} else {
+		arg["fromBlock"] = hexutil.EncodeBig(q.FromBlock)
+	}
+	if q.ToBlock == nil {
+		arg["toBlock"] = "latest"
+	} else {
+		arg["toBlock"] = hexutil.EncodeBig(q.ToBlock)
+	}
+	return arg
+}
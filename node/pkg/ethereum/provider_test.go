@@ -0,0 +1,135 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider answers JSON-RPC requests like a provider capping eth_getLogs at maxLogRange blocks.
+type fakeProvider struct {
+	latest        uint64
+	maxLogRange   uint64
+	blockReceipts bool
+	finalized     string
+	// Ranges of the eth_getLogs requests.
+	logRanges [][2]uint64
+}
+
+func (f *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	var rpcErr string
+	switch req.Method {
+	case "eth_blockNumber":
+		result = hexutil.Uint64(f.latest)
+	case "eth_getLogs":
+		var arg struct {
+			FromBlock hexutil.Uint64 `json:"fromBlock"`
+			ToBlock   hexutil.Uint64 `json:"toBlock"`
+		}
+		if err := json.Unmarshal(req.Params[0], &arg); err != nil {
+			rpcErr = err.Error()
+		} else if uint64(arg.ToBlock-arg.FromBlock)+1 > f.maxLogRange {
+			rpcErr = fmt.Sprintf("block range is too wide, at most %d blocks are allowed", f.maxLogRange)
+		} else {
+			f.logRanges = append(f.logRanges, [2]uint64{uint64(arg.FromBlock), uint64(arg.ToBlock)})
+			result = []interface{}{}
+		}
+	case "eth_getBlockReceipts":
+		if f.blockReceipts {
+			result = []interface{}{}
+		} else {
+			rpcErr = "the method eth_getBlockReceipts does not exist/is not available"
+		}
+	case "eth_getBlockByNumber":
+		if f.finalized == "" {
+			rpcErr = "invalid block tag"
+		} else {
+			result = map[string]interface{}{
+				"number": f.finalized, "parentHash": eth_common.Hash{}, "sha3Uncles": eth_common.Hash{}, "miner": eth_common.Address{},
+				"stateRoot": eth_common.Hash{}, "transactionsRoot": eth_common.Hash{}, "receiptsRoot": eth_common.Hash{},
+				"logsBloom": hexutil.Bytes(make([]byte, 256)), "difficulty": "0x0", "gasLimit": "0x0", "gasUsed": "0x0",
+				"timestamp": "0x0", "extraData": "0x",
+			}
+		}
+	default:
+		rpcErr = "method not found"
+	}
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if rpcErr != "" {
+		resp["error"] = map[string]interface{}{"code": -32000, "message": rpcErr}
+	} else {
+		resp["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func dialFakeProvider(t *testing.T, f *fakeProvider) *rpc.Client {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c, err := rpc.DialHTTP(srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestProbeProviderQuirks(t *testing.T) {
+	ctx := context.Background()
+	contract := eth_common.HexToAddress("0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B")
+
+	// A self-hosted node has no quirks.
+	q, err := ProbeProviderQuirks(ctx, dialFakeProvider(t, &fakeProvider{latest: 20000, maxLogRange: 100000, blockReceipts: true, finalized: "0x4e00"}), contract)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderQuirks{BlockReceipts: true, FinalizedTag: true}, q)
+
+	q, err = ProbeProviderQuirks(ctx, dialFakeProvider(t, &fakeProvider{latest: 20000, maxLogRange: 2000}), contract)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderQuirks{MaxLogRange: 2000}, q)
+
+	// A finalized block ahead of the latest block can't be trusted.
+	q, err = ProbeProviderQuirks(ctx, dialFakeProvider(t, &fakeProvider{latest: 20000, maxLogRange: 5, finalized: "0x" + strconv.FormatUint(20001, 16)}), contract)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderQuirks{MaxLogRange: 1}, q)
+
+	_, err = ProbeProviderQuirks(ctx, dialFakeProvider(t, &fakeProvider{latest: 20000}), contract)
+	assert.Error(t, err)
+}
+
+func TestProviderFilterLogs(t *testing.T) {
+	f := &fakeProvider{latest: 20000, maxLogRange: 1000}
+	p := NewProvider(dialFakeProvider(t, f), ProviderQuirks{MaxLogRange: 1000})
+
+	_, err := p.FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(500), ToBlock: big.NewInt(2600)})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]uint64{{500, 1499}, {1500, 2499}, {2500, 2600}}, f.logRanges)
+
+	_, err = p.BlockReceipts(context.Background(), eth_common.Hash{1})
+	assert.ErrorIs(t, err, ErrNoBlockReceipts)
+	_, ok, err := p.FinalizedBlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
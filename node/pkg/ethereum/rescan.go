@@ -0,0 +1,112 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/time/rate"
+)
+
+// LogFilterer is the part of an RPC client used to rescan block ranges. It is implemented by ethclient.Client.
+type LogFilterer interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethTypes.Log, error)
+}
+
+// RescanProgress is the progress of a block range rescan.
+type RescanProgress struct {
+	// Last block scanned
+	ScannedTo     uint64
+	BlocksScanned uint64
+	BlocksTotal   uint64
+	// Number of transactions which published messages
+	Transactions uint64
+}
+
+// RescanBlockRange scans the blocks from..to, both included, for the messages published by the core bridge contract.
+// found is called once for each transaction which published messages, and progress after each eth_getLogs request.
+// The requests cover at most blocksPerRequest blocks each, and are paced by limiter to spare the RPC provider.
+//
+// The messages are not observed here: the transactions are meant to be re-observed by the watcher, which checks their
+// confirmations like for any observation request.
+func RescanBlockRange(
+	ctx context.Context,
+	client LogFilterer,
+	contract eth_common.Address,
+	from uint64,
+	to uint64,
+	blocksPerRequest uint64,
+	limiter *rate.Limiter,
+	found func(tx eth_common.Hash) error,
+	progress func(RescanProgress) error,
+) error {
+	if to < from {
+		return fmt.Errorf("the range ends at block %d, before its start at block %d", to, from)
+	}
+	if blocksPerRequest == 0 {
+		return fmt.Errorf("at least one block must be scanned per request")
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	latest, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	if to > latest {
+		return fmt.Errorf("the range ends at block %d, beyond the latest block %d", to, latest)
+	}
+
+	p := RescanProgress{BlocksTotal: to - from + 1}
+	for start := from; ; start += blocksPerRequest {
+		end := start + blocksPerRequest - 1
+		if end > to || end < start {
+			end = to
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		logs, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []eth_common.Address{contract},
+			Topics:    [][]eth_common.Hash{{logMessagePublishedTopic}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get the logs of blocks %d to %d: %w", start, end, err)
+		}
+
+		// A transaction can publish more than one message, and is re-observed as a whole.
+		seen := make(map[eth_common.Hash]bool)
+		for _, l := range logs {
+			// SECURITY: The RPC node is not trusted to filter the logs, and the watcher checks them again anyway.
+			if l.Removed || l.Address != contract || len(l.Topics) == 0 || l.Topics[0] != logMessagePublishedTopic {
+				continue
+			}
+			if l.BlockNumber < start || l.BlockNumber > end || seen[l.TxHash] {
+				continue
+			}
+			seen[l.TxHash] = true
+			if err := found(l.TxHash); err != nil {
+				return err
+			}
+		}
+
+		p.ScannedTo = end
+		p.BlocksScanned = end - from + 1
+		p.Transactions += uint64(len(seen))
+		if err := progress(p); err != nil {
+			return err
+		}
+
+		if end == to {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// mockLogFilterer serves logs from memory, filtered by block range only, and records the requested ranges.
+type mockLogFilterer struct {
+	latest uint64
+	logs   []ethTypes.Log
+	ranges [][2]uint64
+}
+
+func (m *mockLogFilterer) BlockNumber(ctx context.Context) (uint64, error) {
+	return m.latest, nil
+}
+
+func (m *mockLogFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]ethTypes.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	m.ranges = append(m.ranges, [2]uint64{from, to})
+	var logs []ethTypes.Log
+	for _, l := range m.logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func TestRescanBlockRange(t *testing.T) {
+	contract := eth_common.HexToAddress("0x98f3c9e6E3fAce36bAAd05FE09d375Ef1464288B")
+	message := func(block uint64, tx byte) ethTypes.Log {
+		return ethTypes.Log{
+			Address:     contract,
+			Topics:      []eth_common.Hash{logMessagePublishedTopic},
+			BlockNumber: block,
+			TxHash:      eth_common.Hash{tx},
+		}
+	}
+	removed := message(14, 4)
+	removed.Removed = true
+	otherContract := message(15, 5)
+	otherContract.Address = eth_common.Address{1}
+	otherEvent := message(16, 6)
+	otherEvent.Topics = []eth_common.Hash{{1}}
+
+	client := &mockLogFilterer{
+		latest: 100,
+		logs: []ethTypes.Log{
+			message(9, 9),
+			message(10, 1),
+			// A transaction publishing two messages.
+			message(12, 2),
+			message(12, 2),
+			removed,
+			otherContract,
+			otherEvent,
+			message(25, 3),
+			message(26, 7),
+		},
+	}
+
+	var txs []eth_common.Hash
+	var progress []RescanProgress
+	err := RescanBlockRange(context.Background(), client, contract, 10, 25, 10, rate.NewLimiter(rate.Inf, 1),
+		func(tx eth_common.Hash) error {
+			txs = append(txs, tx)
+			return nil
+		},
+		func(p RescanProgress) error {
+			progress = append(progress, p)
+			return nil
+		})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][2]uint64{{10, 19}, {20, 25}}, client.ranges)
+	assert.Equal(t, []eth_common.Hash{{1}, {2}, {3}}, txs)
+	assert.Equal(t, []RescanProgress{
+		{ScannedTo: 19, BlocksScanned: 10, BlocksTotal: 16, Transactions: 2},
+		{ScannedTo: 25, BlocksScanned: 16, BlocksTotal: 16, Transactions: 3},
+	}, progress)
+
+	// The range must not go beyond the latest block.
+	err = RescanBlockRange(context.Background(), client, contract, 10, 101, 10, rate.NewLimiter(rate.Inf, 1),
+		func(eth_common.Hash) error { return nil }, func(RescanProgress) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,250 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/ethereum/abi"
+	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/tracing"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var ethTraceVerifications = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wormhole_eth_trace_verifications_total",
+		Help: "Total number of Eth messages verified against the call trace of their transaction, by result (verified, mismatch or error)",
+	}, []string{"eth_network", "result"})
+
+const (
+	// maxTraceAttempts is the number of blocks at which the trace of a confirmed message is fetched before the message
+	// is dropped.
+	maxTraceAttempts = 5
+	// rpcMethodNotFound is the JSON-RPC error code of a method the node does not support.
+	rpcMethodNotFound = -32601
+)
+
+// traceConfig makes debug_traceTransaction return the call trace along with the logs of each call.
+var traceConfig = map[string]interface{}{
+	"tracer":       "callTracer",
+	"tracerConfig": map[string]interface{}{"withLog": true},
+}
+
+// TraceVerification confirms, using debug_traceTransaction, that the core bridge itself emitted the log of a message
+// before it is observed. Messages emitted from deep internal calls are otherwise only validated using the receipt logs,
+// which an RPC implementation may get wrong.
+type TraceVerification struct {
+	// Token bridge emitter of the chain, whose transfers are valued by Valuer.
+	TokenBridge vaa.Address
+	// Only the token bridge transfers worth at least MinValue are verified. If zero, every message is verified.
+	MinValue uint64
+	Valuer   governor.TransferValuer
+}
+
+// required returns whether a message must be verified.
+func (v *TraceVerification) required(msg *common.MessagePublication) bool {
+	if v.MinValue == 0 {
+		return true
+	}
+	if v.Valuer == nil || msg.EmitterAddress != v.TokenBridge || !vaa.IsTransfer(msg.Payload) {
+		return false
+	}
+	payload, err := vaa.DecodeTransferPayloadHdr(msg.Payload)
+	if err != nil {
+		return false
+	}
+	value, known := v.Valuer.TransferValue(payload)
+	return known && value >= v.MinValue
+}
+
+// tracedMessage is a confirmed message whose trace is verified before it is observed.
+type tracedMessage struct {
+	key     pendingKey
+	pending *pendingMessage
+}
+
+// callFrame is a call of the trace returned by the callTracer of debug_traceTransaction, along with its logs.
+type callFrame struct {
+	To    eth_common.Address `json:"to"`
+	Error string             `json:"error,omitempty"`
+	Logs  []callLog          `json:"logs,omitempty"`
+	Calls []callFrame        `json:"calls,omitempty"`
+}
+
+type callLog struct {
+	Address eth_common.Address `json:"address"`
+	Topics  []eth_common.Hash  `json:"topics"`
+	Data    hexutil.Bytes      `json:"data"`
+}
+
+// traceContainsMessage returns whether the trace contains a log emitted by the contract which publishes the message.
+// The logs of reverted calls are ignored.
+func traceContainsMessage(frame *callFrame, contract eth_common.Address, msg *common.MessagePublication) bool {
+	for _, m := range messagesInTrace(frame, contract) {
+		if m.EmitterAddress == msg.EmitterAddress &&
+			m.Sequence == msg.Sequence &&
+			m.Nonce == msg.Nonce &&
+			m.ConsistencyLevel == msg.ConsistencyLevel &&
+			bytes.Equal(m.Payload, msg.Payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// messagesInTrace returns the messages published by the contract in the trace. Only the fields found in the logs are
+// set. The logs of reverted calls are ignored.
+func messagesInTrace(frame *callFrame, contract eth_common.Address) []*common.MessagePublication {
+	if frame.Error != "" {
+		return nil
+	}
+
+	var msgs []*common.MessagePublication
+	for _, l := range frame.Logs {
+		if l.Address != contract || len(l.Topics) == 0 || l.Topics[0] != logMessagePublishedTopic {
+			continue
+		}
+		ev, err := logMessagePublishedFilterer.ParseLogMessagePublished(ethTypes.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, &common.MessagePublication{
+			EmitterAddress:   PadAddress(ev.Sender),
+			Sequence:         ev.Sequence,
+			Nonce:            ev.Nonce,
+			ConsistencyLevel: ev.ConsistencyLevel,
+			Payload:          ev.Payload,
+		})
+	}
+
+	for i := range frame.Calls {
+		msgs = append(msgs, messagesInTrace(&frame.Calls[i], contract)...)
+	}
+	return msgs
+}
+
+// Only used to parse logs, it is not bound to a contract.
+var logMessagePublishedFilterer, _ = abi.NewAbiFilterer(eth_common.Address{}, nil)
+
+// dialTraceClient connects the client used to fetch the traces, and checks that the RPC node supports
+// debug_traceTransaction by tracing a transaction which does not exist. Nodes without the debug namespace fail with
+// "method not found", while the others fail to find the transaction.
+func (e *Watcher) dialTraceClient(ctx context.Context) error {
+	timeout, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	c, err := rpc.DialContext(timeout, e.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to url %s to trace transactions: %w", e.url, err)
+	}
+
+	var trace callFrame
+	err = c.CallContext(timeout, &trace, "debug_traceTransaction", eth_common.Hash{}, traceConfig)
+	var rpcErr rpc.Error
+	if err != nil && (!errors.As(err, &rpcErr) || rpcErr.ErrorCode() == rpcMethodNotFound) {
+		c.Close()
+		return fmt.Errorf("RPC node does not support debug_traceTransaction: %w", err)
+	}
+
+	e.traceClient = c
+	return nil
+}
+
+// verifyTrace returns whether the call trace of the transaction of a message shows the core bridge emitting it.
+func (e *Watcher) verifyTrace(ctx context.Context, msg *common.MessagePublication) (bool, error) {
+	var trace callFrame
+	err := e.traceClient.CallContext(ctx, &trace, "debug_traceTransaction", msg.TxHash, traceConfig)
+	if err != nil {
+		return false, fmt.Errorf("failed to trace transaction %s: %w", msg.TxHash.Hex(), err)
+	}
+
+	return traceContainsMessage(&trace, e.contract, msg), nil
+}
+
+// confirmTraced verifies the traces of confirmed messages, and observes the messages which the core bridge emitted. It
+// must be called without pendingMu held, which is only taken to update the pending messages once a trace is fetched.
+func (e *Watcher) confirmTraced(ctx context.Context, logger *zap.Logger, traced []tracedMessage, blockNumber uint64) {
+	for _, t := range traced {
+		timeout, cancel := context.WithTimeout(ctx, 15*time.Second)
+		verified, err := e.verifyTrace(timeout, t.pending.message)
+		cancel()
+
+		e.pendingMu.Lock()
+		// The message was observed again while its trace was fetched, and is confirmed once the new one is.
+		if e.pending[t.key] != t.pending {
+			e.pendingMu.Unlock()
+			continue
+		}
+
+		if err != nil {
+			ethTraceVerifications.WithLabelValues(e.networkName, "error").Inc()
+			t.pending.traceAttempts++
+			if t.pending.traceAttempts < maxTraceAttempts {
+				// Tracing is slow and may time out, so we retry next block.
+				logger.Warn("transaction trace could not be fetched",
+					zap.Stringer("tx", t.pending.message.TxHash),
+					zap.Stringer("emitter_address", t.key.EmitterAddress),
+					zap.Uint64("sequence", t.key.Sequence),
+					zap.Int("attempts", t.pending.traceAttempts),
+					zap.String("eth_network", e.networkName),
+					zap.Error(err))
+			} else {
+				// The message can be reobserved once the RPC node is able to trace it.
+				logger.Error("transaction trace could not be fetched, dropping the message",
+					zap.Stringer("tx", t.pending.message.TxHash),
+					zap.Stringer("emitter_address", t.key.EmitterAddress),
+					zap.Uint64("sequence", t.key.Sequence),
+					zap.Int("attempts", t.pending.traceAttempts),
+					zap.String("eth_network", e.networkName),
+					zap.Error(err))
+				delete(e.pending, t.key)
+				ethMessagesOrphaned.WithLabelValues(e.networkName, "trace_error").Inc()
+			}
+			e.pendingMu.Unlock()
+			continue
+		}
+
+		delete(e.pending, t.key)
+		e.pendingMu.Unlock()
+
+		// SECURITY: the receipt contains a message log which the core bridge did not emit according to the trace.
+		// Either RPC result is wrong, so we refuse to observe it.
+		if !verified {
+			logger.Error("SECURITY: message log not found in the transaction trace, refusing to observe it",
+				zap.Stringer("tx", t.pending.message.TxHash),
+				zap.Stringer("blockhash", t.key.BlockHash),
+				zap.Stringer("emitter_address", t.key.EmitterAddress),
+				zap.Uint64("sequence", t.key.Sequence),
+				zap.String("eth_network", e.networkName))
+			ethTraceVerifications.WithLabelValues(e.networkName, "mismatch").Inc()
+			ethMessagesOrphaned.WithLabelValues(e.networkName, "trace_mismatch").Inc()
+			continue
+		}
+		ethTraceVerifications.WithLabelValues(e.networkName, "verified").Inc()
+
+		logger.Info("observation confirmed",
+			zap.Stringer("tx", t.pending.message.TxHash),
+			zap.Stringer("blockhash", t.key.BlockHash),
+			zap.Stringer("emitter_address", t.key.EmitterAddress),
+			zap.Uint64("sequence", t.key.Sequence),
+			zap.Uint64("current_block", blockNumber),
+			zap.String("eth_network", e.networkName))
+		tracing.Record(e.chainID, t.pending.message.MessageIDString(), "watcher.confirm", t.pending.observed, time.Now(),
+			attribute.Int64("block", int64(t.pending.height)),
+			attribute.Int64("current_block", int64(blockNumber)))
+		e.msgChan <- t.pending.message
+		ethMessagesConfirmed.WithLabelValues(e.networkName).Inc()
+	}
+}
@@ -0,0 +1,175 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/ethereum/abi"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testValuer map[vaa.Address]uint64
+
+func (v testValuer) TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool) {
+	value, known := v[payload.OriginAddress]
+	return value, known
+}
+
+// transferPayload returns a token bridge transfer of the given token.
+func transferPayload(token vaa.Address) []byte {
+	b := make([]byte, 133)
+	b[0] = 1
+	big.NewInt(1000).FillBytes(b[1:33])
+	copy(b[33:65], token[:])
+	binary.BigEndian.PutUint16(b[65:67], uint16(vaa.ChainIDEthereum))
+	binary.BigEndian.PutUint16(b[99:101], uint16(vaa.ChainIDSolana))
+	return b
+}
+
+func TestTraceVerificationRequired(t *testing.T) {
+	tokenBridge := vaa.Address{1}
+	known, unknown := vaa.Address{2}, vaa.Address{3}
+	v := &TraceVerification{TokenBridge: tokenBridge, MinValue: 100, Valuer: testValuer{known: 100}}
+
+	assert.True(t, v.required(&common.MessagePublication{EmitterAddress: tokenBridge, Payload: transferPayload(known)}))
+	assert.False(t, v.required(&common.MessagePublication{EmitterAddress: tokenBridge, Payload: transferPayload(unknown)}))
+	assert.False(t, v.required(&common.MessagePublication{EmitterAddress: vaa.Address{4}, Payload: transferPayload(known)}))
+	assert.False(t, v.required(&common.MessagePublication{EmitterAddress: tokenBridge, Payload: []byte{2}}))
+
+	v.MinValue = 101
+	assert.False(t, v.required(&common.MessagePublication{EmitterAddress: tokenBridge, Payload: transferPayload(known)}))
+
+	// Without a minimum value, every message is verified.
+	v.MinValue = 0
+	assert.True(t, v.required(&common.MessagePublication{EmitterAddress: vaa.Address{4}, Payload: []byte{2}}))
+}
+
+// messageLog returns the LogMessagePublished log of a message emitted by the contract.
+func messageLog(t *testing.T, contract eth_common.Address, sender eth_common.Address, msg *common.MessagePublication) callLog {
+	parsed, err := ethAbi.JSON(strings.NewReader(abi.AbiABI))
+	require.NoError(t, err)
+	data, err := parsed.Events["LogMessagePublished"].Inputs.NonIndexed().Pack(msg.Sequence, msg.Nonce, msg.Payload, msg.ConsistencyLevel)
+	require.NoError(t, err)
+	return callLog{
+		Address: contract,
+		Topics:  []eth_common.Hash{logMessagePublishedTopic, eth_common.BytesToHash(sender.Bytes())},
+		Data:    data,
+	}
+}
+
+func TestVerifyTrace(t *testing.T) {
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+
+	contract := eth_common.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550")
+	sender := eth_common.HexToAddress("0x0290FB167208Af455bB137780163b7B7a9a10C16")
+	msg := &common.MessagePublication{
+		TxHash:           eth_common.HexToHash("0x01"),
+		Sequence:         42,
+		Nonce:            7,
+		EmitterAddress:   PadAddress(sender),
+		Payload:          []byte{1, 2, 3},
+		ConsistencyLevel: 1,
+	}
+	w := NewEthWatcher(server.URL, contract, "eth", common.ReadinessEthSyncing, vaa.ChainIDEthereum, nil, nil, 1, nil, true)
+
+	// Nodes without the debug namespace are refused on startup.
+	assert.ErrorContains(t, w.dialTraceClient(context.Background()), "does not support debug_traceTransaction")
+	server.Handle("debug_traceTransaction", func(json.RawMessage) (interface{}, error) {
+		return nil, errors.New("transaction not found")
+	})
+	require.NoError(t, w.dialTraceClient(context.Background()))
+	defer w.traceClient.Close()
+
+	verify := func(trace callFrame) (bool, error) {
+		server.SetResult("debug_traceTransaction", trace)
+		return w.verifyTrace(context.Background(), msg)
+	}
+
+	// The message is emitted by the core bridge in a nested call.
+	verified, err := verify(callFrame{To: sender, Calls: []callFrame{{To: contract, Logs: []callLog{messageLog(t, contract, sender, msg)}}}})
+	require.NoError(t, err)
+	assert.True(t, verified)
+
+	// The log was emitted by another contract.
+	verified, err = verify(callFrame{To: sender, Logs: []callLog{messageLog(t, sender, sender, msg)}})
+	require.NoError(t, err)
+	assert.False(t, verified)
+
+	// The call emitting the log reverted.
+	verified, err = verify(callFrame{To: sender, Calls: []callFrame{{To: contract, Error: "execution reverted", Logs: []callLog{messageLog(t, contract, sender, msg)}}}})
+	require.NoError(t, err)
+	assert.False(t, verified)
+
+	// The log publishes a different message.
+	other := *msg
+	other.Sequence = 43
+	verified, err = verify(callFrame{To: contract, Logs: []callLog{messageLog(t, contract, sender, &other)}})
+	require.NoError(t, err)
+	assert.False(t, verified)
+
+	// The trace is returned as JSON by the callTracer.
+	var trace callFrame
+	require.NoError(t, json.Unmarshal([]byte(`{"to": "0xc89ce4735882c9f0f0fe26686c53074e09b0d550", "calls": [{"to": "0x0290fb167208af455bb137780163b7b7a9a10c16"}]}`), &trace))
+	assert.Equal(t, contract, trace.To)
+	assert.Equal(t, sender, trace.Calls[0].To)
+
+	server.SetFault(mockchain.Fault{Status: 500})
+	_, err = w.verifyTrace(context.Background(), msg)
+	assert.Error(t, err)
+}
+
+func TestConfirmTracedRetriesAreCapped(t *testing.T) {
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+
+	contract := eth_common.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550")
+	sender := eth_common.HexToAddress("0x0290FB167208Af455bB137780163b7B7a9a10C16")
+	msg := &common.MessagePublication{
+		TxHash:           eth_common.HexToHash("0x01"),
+		Sequence:         42,
+		EmitterAddress:   PadAddress(sender),
+		Payload:          []byte{1, 2, 3},
+		ConsistencyLevel: 1,
+	}
+	msgC := make(chan *common.MessagePublication, 1)
+	w := NewEthWatcher(server.URL, contract, "eth", common.ReadinessEthSyncing, vaa.ChainIDEthereum, msgC, nil, 1, nil, true)
+	server.SetResult("debug_traceTransaction", callFrame{To: contract, Logs: []callLog{messageLog(t, contract, sender, msg)}})
+	require.NoError(t, w.dialTraceClient(context.Background()))
+	defer w.traceClient.Close()
+
+	key := pendingKey{TxHash: msg.TxHash, EmitterAddress: msg.EmitterAddress, Sequence: msg.Sequence}
+	traced := func() []tracedMessage {
+		w.pending[key] = &pendingMessage{message: msg}
+		return []tracedMessage{{key: key, pending: w.pending[key]}}
+	}
+
+	// The message is dropped once its trace could not be fetched at maxTraceAttempts blocks.
+	server.SetFault(mockchain.Fault{Status: 500})
+	failing := traced()
+	for i := 0; i < maxTraceAttempts; i++ {
+		require.Contains(t, w.pending, key)
+		w.confirmTraced(context.Background(), zap.NewNop(), failing, 100)
+	}
+	assert.NotContains(t, w.pending, key)
+	assert.Empty(t, msgC)
+
+	// A verified message is observed.
+	server.SetFault(mockchain.Fault{})
+	w.confirmTraced(context.Background(), zap.NewNop(), traced(), 100)
+	assert.NotContains(t, w.pending, key)
+	require.Len(t, msgC, 1)
+	assert.Equal(t, msg, <-msgC)
+}
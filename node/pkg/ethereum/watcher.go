@@ -15,6 +15,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/certusone/wormhole/node/pkg/celo"
@@ -22,6 +26,7 @@ import (
 	"github.com/certusone/wormhole/node/pkg/ethereum/abi"
 	"github.com/certusone/wormhole/node/pkg/readiness"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 )
 
@@ -73,7 +78,7 @@ type (
 		chainID vaa.ChainID
 
 		// Channel to send new messages to.
-		msgChan chan *common.MessagePublication
+		msgChan chan<- *common.MessagePublication
 
 		// Channel to send guardian set changes to.
 		// setChan can be set to nil if no guardian set changes are needed.
@@ -102,6 +107,17 @@ type (
 		// Interface to the chain specific ethereum library.
 		ethIntf             common.Ethish
 		shouldCheckSafeMode bool
+
+		// Expected keccak256 hash of the bytecode deployed at the contract address. Checked on startup if set.
+		codeHash eth_common.Hash
+
+		// Verifies the call trace of the messages before they are observed. Nil if disabled.
+		traceVerification *TraceVerification
+		// Client used to fetch the traces, connected on startup if the verification is enabled.
+		traceClient *rpc.Client
+
+		// Works around the quirks of the RPC provider. Nil if the probes failed, or for chains with their own library.
+		provider *Provider
 	}
 
 	pendingKey struct {
@@ -114,6 +130,10 @@ type (
 	pendingMessage struct {
 		message *common.MessagePublication
 		height  uint64
+		// When the message was observed, to trace the time it takes to be confirmed.
+		observed time.Time
+		// Number of blocks at which the trace of the message could not be fetched.
+		traceAttempts int
 	}
 )
 
@@ -123,7 +143,7 @@ func NewEthWatcher(
 	networkName string,
 	readiness readiness.Component,
 	chainID vaa.ChainID,
-	messageEvents chan *common.MessagePublication,
+	messageEvents chan<- *common.MessagePublication,
 	setEvents chan *common.GuardianSet,
 	minConfirmations uint64,
 	obsvReqC chan *gossipv1.ObservationRequest,
@@ -157,6 +177,21 @@ func NewEthWatcher(
 		shouldCheckSafeMode: (chainID == vaa.ChainIDKarura || chainID == vaa.ChainIDAcala) && (!unsafeDevMode)}
 }
 
+// WithCodeHash sets the expected hash of the contract bytecode. The watcher refuses to start if the contract deployed at
+// its address does not match it. A zero hash disables the check.
+func (e *Watcher) WithCodeHash(codeHash eth_common.Hash) *Watcher {
+	e.codeHash = codeHash
+	return e
+}
+
+// WithTraceVerification verifies the call trace of the messages which require it before they are observed, which
+// requires an RPC node supporting debug_traceTransaction. The watcher refuses to start if the node does not support it.
+// Nil disables the verification.
+func (e *Watcher) WithTraceVerification(v *TraceVerification) *Watcher {
+	e.traceVerification = v
+	return e
+}
+
 func (e *Watcher) Run(ctx context.Context) error {
 	logger := supervisor.Logger(ctx)
 	e.ethIntf.SetLogger(logger)
@@ -167,6 +202,19 @@ func (e *Watcher) Run(ctx context.Context) error {
 		}
 	}
 
+	if e.codeHash != (eth_common.Hash{}) {
+		if err := e.checkCodeHash(ctx); err != nil {
+			return err
+		}
+	}
+
+	if e.traceVerification != nil {
+		if err := e.dialTraceClient(ctx); err != nil {
+			return fmt.Errorf("refusing to enable trace verification: %w", err)
+		}
+		defer e.traceClient.Close()
+	}
+
 	// Initialize gossip metrics (we want to broadcast the address even if we're not yet syncing)
 	p2p.DefaultRegistry.SetNetworkStats(e.chainID, &gossipv1.Heartbeat_Network{
 		ContractAddress: e.contract.Hex(),
@@ -186,6 +234,24 @@ func (e *Watcher) Run(ctx context.Context) error {
 		return fmt.Errorf("could not create wormhole contract filter: %w", err)
 	}
 
+	if _, ok := e.ethIntf.(*EthImpl); ok {
+		timeout, cancel := context.WithTimeout(ctx, 15*time.Second)
+		e.provider, err = DialProvider(timeout, e.url, e.contract)
+		cancel()
+		if err != nil {
+			// The watcher works without, only less efficiently.
+			logger.Warn("failed to probe the quirks of the RPC provider", zap.String("eth_network", e.networkName), zap.Error(err))
+		} else {
+			defer e.provider.Close()
+			e.provider.ReportQuirks(e.networkName)
+			logger.Info("probed the quirks of the RPC provider",
+				zap.String("eth_network", e.networkName),
+				zap.Uint64("max_log_range", e.provider.Quirks.MaxLogRange),
+				zap.Bool("block_receipts", e.provider.Quirks.BlockReceipts),
+				zap.Bool("finalized_tag", e.provider.Quirks.FinalizedTag))
+		}
+	}
+
 	err = e.ethIntf.NewAbiCaller(e.contract)
 	if err != nil {
 		panic(err)
@@ -263,54 +329,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 					continue
 				}
 
-				timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
-				blockNumber, msgs, err := MessageEventsForTransaction(timeout, e.ethIntf, e.contract, e.chainID, tx)
-				cancel()
-
-				if err != nil {
-					logger.Error("failed to process observation request",
-						zap.Error(err), zap.String("eth_network", e.networkName))
-					continue
-				}
-
-				for _, msg := range msgs {
-					expectedConfirmations := uint64(msg.ConsistencyLevel)
-					if expectedConfirmations < e.minConfirmations {
-						expectedConfirmations = e.minConfirmations
-					}
-
-					// SECURITY: In the recovery flow, we already know which transaction to
-					// observe, and we can assume that it has reached the expected finality
-					// level a long time ago. Therefore, the logic is much simpler than the
-					// primary watcher, which has to wait for finality.
-					//
-					// Instead, we can simply check if the transaction's block number is in
-					// the past by more than the expected confirmation number.
-					//
-					// Ensure that the current block number is at least expectedConfirmations
-					// larger than the message observation's block number.
-					if blockNumber+expectedConfirmations <= blockNumberU {
-						logger.Info("re-observed message publication transaction",
-							zap.Stringer("tx", msg.TxHash),
-							zap.Stringer("emitter_address", msg.EmitterAddress),
-							zap.Uint64("sequence", msg.Sequence),
-							zap.Uint64("current_block", blockNumberU),
-							zap.Uint64("observed_block", blockNumber),
-							zap.String("eth_network", e.networkName),
-						)
-						e.msgChan <- msg
-					} else {
-						logger.Info("ignoring re-observed message publication transaction",
-							zap.Stringer("tx", msg.TxHash),
-							zap.Stringer("emitter_address", msg.EmitterAddress),
-							zap.Uint64("sequence", msg.Sequence),
-							zap.Uint64("current_block", blockNumberU),
-							zap.Uint64("observed_block", blockNumber),
-							zap.Uint64("expected_confirmations", expectedConfirmations),
-							zap.String("eth_network", e.networkName),
-						)
-					}
-				}
+				e.reobserve(ctx, logger, tx, blockNumberU)
 			}
 		}
 	}()
@@ -331,7 +350,8 @@ func (e *Watcher) Run(ctx context.Context) error {
 				timeout, cancel := context.WithTimeout(ctx, 15*time.Second)
 				blockTime, err := e.ethIntf.TimeOfBlockByHash(timeout, ev.Raw.BlockHash)
 				cancel()
-				queryLatency.WithLabelValues(e.networkName, "block_by_number").Observe(time.Since(msm).Seconds())
+				fetched := time.Now()
+				queryLatency.WithLabelValues(e.networkName, "block_by_number").Observe(fetched.Sub(msm).Seconds())
 
 				if err != nil {
 					ethConnectionErrors.WithLabelValues(e.networkName, "block_by_number_error").Inc()
@@ -349,6 +369,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 					EmitterAddress:   PadAddress(ev.Sender),
 					Payload:          ev.Payload,
 					ConsistencyLevel: ev.ConsistencyLevel,
+					Provenance:       common.NewProvenance(e.url, fetched.Sub(msm), ev.Raw.BlockHash.Hex()),
 				}
 
 				logger.Info("found new message publication transaction",
@@ -361,6 +382,9 @@ func (e *Watcher) Run(ctx context.Context) error {
 					zap.String("eth_network", e.networkName))
 
 				ethMessagesObserved.WithLabelValues(e.networkName).Inc()
+				tracing.Record(e.chainID, message.MessageIDString(), "watcher.fetch", msm, fetched,
+					attribute.String("tx_hash", ev.Raw.TxHash.Hex()),
+					attribute.Int64("block", int64(ev.Raw.BlockNumber)))
 
 				key := pendingKey{
 					TxHash:         message.TxHash,
@@ -371,8 +395,9 @@ func (e *Watcher) Run(ctx context.Context) error {
 
 				e.pendingMu.Lock()
 				e.pending[key] = &pendingMessage{
-					message: message,
-					height:  ev.Raw.BlockNumber,
+					message:  message,
+					height:   ev.Raw.BlockNumber,
+					observed: fetched,
 				}
 				e.pendingMu.Unlock()
 			}
@@ -427,11 +452,11 @@ func (e *Watcher) Run(ctx context.Context) error {
 				blockNumberU := ev.Number.Uint64()
 				atomic.StoreUint64(&currentBlockNumber, blockNumberU)
 
+				receipts := e.prefetchReceipts(ctx, logger, blockNumberU)
+
+				var traced []tracedMessage
 				for key, pLock := range e.pending {
-					expectedConfirmations := uint64(pLock.message.ConsistencyLevel)
-					if expectedConfirmations < e.minConfirmations {
-						expectedConfirmations = e.minConfirmations
-					}
+					expectedConfirmations := e.expectedConfirmations(pLock.message.ConsistencyLevel)
 
 					// Transaction was dropped and never picked up again
 					if pLock.height+4*uint64(expectedConfirmations) <= blockNumberU {
@@ -451,9 +476,13 @@ func (e *Watcher) Run(ctx context.Context) error {
 
 					// Transaction is now ready
 					if pLock.height+uint64(expectedConfirmations) <= blockNumberU {
-						timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
-						tx, err := e.ethIntf.TransactionReceipt(timeout, pLock.message.TxHash)
-						cancel()
+						tx, ok := receipts[pLock.message.TxHash]
+						var err error
+						if !ok {
+							timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+							tx, err = e.ethIntf.TransactionReceipt(timeout, pLock.message.TxHash)
+							cancel()
+						}
 
 						// If the node returns an error after waiting expectedConfirmation blocks,
 						// it means the chain reorged and the transaction was orphaned. The
@@ -527,6 +556,12 @@ func (e *Watcher) Run(ctx context.Context) error {
 							continue
 						}
 
+						// Tracing is slow, so the trace is fetched once pendingMu is released.
+						if e.traceVerification != nil && e.traceVerification.required(pLock.message) {
+							traced = append(traced, tracedMessage{key: key, pending: pLock})
+							continue
+						}
+
 						logger.Info("observation confirmed",
 							zap.Stringer("tx", pLock.message.TxHash),
 							zap.Stringer("blockhash", key.BlockHash),
@@ -536,12 +571,20 @@ func (e *Watcher) Run(ctx context.Context) error {
 							zap.Stringer("current_blockhash", currentHash),
 							zap.String("eth_network", e.networkName))
 						delete(e.pending, key)
+						tracing.Record(e.chainID, pLock.message.MessageIDString(), "watcher.confirm", pLock.observed, time.Now(),
+							attribute.Int64("block", int64(pLock.height)),
+							attribute.Int64("current_block", ev.Number.Int64()))
 						e.msgChan <- pLock.message
 						ethMessagesConfirmed.WithLabelValues(e.networkName).Inc()
 					}
 				}
 
 				e.pendingMu.Unlock()
+
+				if len(traced) != 0 {
+					e.confirmTraced(ctx, logger, traced, blockNumberU)
+				}
+
 				logger.Info("processed new header",
 					zap.Stringer("current_block", ev.Number),
 					zap.Stringer("current_blockhash", currentHash),
@@ -559,6 +602,74 @@ func (e *Watcher) Run(ctx context.Context) error {
 	}
 }
 
+// reobserve publishes the messages of a transaction requested by an observation request, if the transaction has
+// reached the expected number of confirmations at blockNumberU.
+func (e *Watcher) reobserve(ctx context.Context, logger *zap.Logger, tx eth_common.Hash, blockNumberU uint64) {
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	fetchStart := time.Now()
+	blockNumber, msgs, err := MessageEventsForTransaction(timeout, e.ethIntf, e.contract, e.chainID, tx)
+	fetchEnd := time.Now()
+	cancel()
+
+	if err != nil {
+		logger.Error("failed to process observation request",
+			zap.Error(err), zap.String("eth_network", e.networkName))
+		return
+	}
+
+	for _, msg := range msgs {
+		msg.Provenance = common.NewProvenance(e.url, fetchEnd.Sub(fetchStart), msg.Provenance.Block)
+		tracing.Record(e.chainID, msg.MessageIDString(), "watcher.reobserve", fetchStart, fetchEnd,
+			attribute.String("tx_hash", msg.TxHash.Hex()),
+			attribute.Int64("block", int64(blockNumber)))
+
+		expectedConfirmations := e.expectedConfirmations(msg.ConsistencyLevel)
+
+		// SECURITY: In the recovery flow, we already know which transaction to
+		// observe, and we can assume that it has reached the expected finality
+		// level a long time ago. Therefore, the logic is much simpler than the
+		// primary watcher, which has to wait for finality.
+		//
+		// Instead, we can simply check if the transaction's block number is in
+		// the past by more than the expected confirmation number.
+		//
+		// Ensure that the current block number is at least expectedConfirmations
+		// larger than the message observation's block number.
+		if blockNumber+expectedConfirmations <= blockNumberU {
+			logger.Info("re-observed message publication transaction",
+				zap.Stringer("tx", msg.TxHash),
+				zap.Stringer("emitter_address", msg.EmitterAddress),
+				zap.Uint64("sequence", msg.Sequence),
+				zap.Uint64("current_block", blockNumberU),
+				zap.Uint64("observed_block", blockNumber),
+				zap.String("eth_network", e.networkName),
+			)
+			e.msgChan <- msg
+		} else {
+			logger.Info("ignoring re-observed message publication transaction",
+				zap.Stringer("tx", msg.TxHash),
+				zap.Stringer("emitter_address", msg.EmitterAddress),
+				zap.Uint64("sequence", msg.Sequence),
+				zap.Uint64("current_block", blockNumberU),
+				zap.Uint64("observed_block", blockNumber),
+				zap.Uint64("expected_confirmations", expectedConfirmations),
+				zap.String("eth_network", e.networkName),
+			)
+		}
+	}
+}
+
+// expectedConfirmations returns the number of confirmations a message with the given consistency level must reach before
+// it is observed. The consistency level is the number of confirmations requested by the emitter, raised to the minimum
+// number of confirmations of the chain.
+func (e *Watcher) expectedConfirmations(consistencyLevel uint8) uint64 {
+	expectedConfirmations := uint64(consistencyLevel)
+	if expectedConfirmations < e.minConfirmations {
+		expectedConfirmations = e.minConfirmations
+	}
+	return expectedConfirmations
+}
+
 func (e *Watcher) fetchAndUpdateGuardianSet(
 	logger *zap.Logger,
 	ctx context.Context,
@@ -589,8 +700,9 @@ func (e *Watcher) fetchAndUpdateGuardianSet(
 
 	if e.setChan != nil {
 		e.setChan <- &common.GuardianSet{
-			Keys:  gs.Keys,
-			Index: idx,
+			Keys:           gs.Keys,
+			Index:          idx,
+			ExpirationTime: gs.ExpirationTime,
 		}
 	}
 
@@ -633,3 +745,67 @@ func (e *Watcher) checkForSafeMode(ctx context.Context) error {
 
 	return nil
 }
+
+func (e *Watcher) checkCodeHash(ctx context.Context) error {
+	timeout, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	c, err := rpc.DialContext(timeout, e.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to url %s to check the contract code: %w", e.url, err)
+	}
+	defer c.Close()
+
+	var code hexutil.Bytes
+	err = c.CallContext(timeout, &code, "eth_getCode", e.contract, "latest")
+	if err != nil {
+		return fmt.Errorf("failed to get the code of contract %s: %w", e.contract.Hex(), err)
+	}
+
+	if h := crypto.Keccak256Hash(code); h != e.codeHash {
+		return fmt.Errorf("code hash of contract %s is %s, expected %s", e.contract.Hex(), h.Hex(), e.codeHash.Hex())
+	}
+
+	return nil
+}
+
+// prefetchReceipts fetches with eth_getBlockReceipts the receipts of the blocks holding several pending messages which
+// are confirmed at blockNumber, which saves a request per message when a block holds many. The receipts missing from
+// the result are fetched one by one, like without eth_getBlockReceipts. Must be called with pendingMu held.
+func (e *Watcher) prefetchReceipts(ctx context.Context, logger *zap.Logger, blockNumber uint64) map[eth_common.Hash]*ethTypes.Receipt {
+	if e.provider == nil || !e.provider.Quirks.BlockReceipts {
+		return nil
+	}
+
+	ready := make(map[eth_common.Hash]int)
+	for key, pLock := range e.pending {
+		if pLock.height+uint64(e.expectedConfirmations(pLock.message.ConsistencyLevel)) <= blockNumber {
+			ready[key.BlockHash]++
+		}
+	}
+
+	var res map[eth_common.Hash]*ethTypes.Receipt
+	for hash, n := range ready {
+		if n < 2 {
+			continue
+		}
+		timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+		receipts, err := e.provider.BlockReceipts(timeout, hash)
+		cancel()
+		if err != nil {
+			logger.Warn("failed to get block receipts", zap.Stringer("blockhash", hash), zap.String("eth_network", e.networkName), zap.Error(err))
+			continue
+		}
+		for _, r := range receipts {
+			// SECURITY: Only the receipts of the block asked for are used, and are checked like the others afterwards.
+			if r == nil || r.BlockHash != hash {
+				continue
+			}
+			if res == nil {
+				res = make(map[eth_common.Hash]*ethTypes.Receipt)
+			}
+			res[r.TxHash] = r
+		}
+	}
+	return res
+}
@@ -0,0 +1,31 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	eth_common "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCodeHash(t *testing.T) {
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+
+	code := []byte{0x60, 0x80, 0x60, 0x40}
+	server.SetResult("eth_getCode", hexutil.Bytes(code))
+
+	w := NewEthWatcher(server.URL, eth_common.HexToAddress("0xC89Ce4735882C9F0f0FE26686c53074E09B0D550"), "bsc",
+		common.ReadinessBSCSyncing, vaa.ChainIDBSC, nil, nil, 1, nil, true)
+
+	assert.NoError(t, w.WithCodeHash(crypto.Keccak256Hash(code)).checkCodeHash(context.Background()))
+	assert.Error(t, w.WithCodeHash(crypto.Keccak256Hash([]byte("other"))).checkCodeHash(context.Background()))
+
+	server.SetFault(mockchain.Fault{Status: 500})
+	assert.Error(t, w.WithCodeHash(crypto.Keccak256Hash(code)).checkCodeHash(context.Background()))
+}
@@ -0,0 +1,194 @@
+// Package eventbus carries the events exchanged by the node's components: the messages observed by the watchers, and
+// the observations, signed VAAs and observation requests received from p2p.
+//
+// Each topic has a single input channel, which publishers are handed instead of a channel owned by the consumer, and
+// any number of subscribers. Subscribing does not require changing the publishers, so cross-cutting consumers such as
+// metrics or audit logs can be added next to the processor without threading new channels through the constructors.
+//
+// Events are delivered to the subscribers in order of priority. A blocking subscriber holds back the publishers while
+// it is busy, like an unbuffered channel would, whereas a lossy subscriber drops the events it has no room for.
+// Subscriptions should be made before the bus runs to receive every event: the events published before are queued in
+// the input channels, not replayed. Subscribing again under the same name replaces the previous subscription.
+package eventbus
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsPublished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eventbus_published_total",
+			Help: "Total number of events published, by topic",
+		}, []string{"topic"})
+	eventsDelivered = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eventbus_delivered_total",
+			Help: "Total number of events delivered, by topic and subscriber",
+		}, []string{"topic", "subscriber"})
+	eventsDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_eventbus_dropped_total",
+			Help: "Total number of events dropped because a lossy subscriber was full, by topic and subscriber",
+		}, []string{"topic", "subscriber"})
+	deliveryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "wormhole_eventbus_delivery_duration_seconds",
+			Help:    "Time taken to deliver an event to all subscribers, by topic",
+			Buckets: []float64{0.0001, 0.001, 0.01, 0.1, 1, 10},
+		}, []string{"topic"})
+)
+
+// Subscription priorities. Any other value can be used.
+const (
+	PriorityLow     = -10
+	PriorityDefault = 0
+	PriorityHigh    = 10
+)
+
+// Options configures a subscription.
+type Options struct {
+	// Subscribers with a higher priority receive each event first.
+	Priority int
+	// Number of events buffered for the subscriber.
+	Buffer int
+	// Lossy subscribers drop the events they have no room for instead of holding back the publishers.
+	Lossy bool
+}
+
+// subscriber is a subscription of a topic. deliver sends an event to it and returns whether it was delivered, which it
+// is not if the subscriber is full and block is false, or if ctx is cancelled.
+type subscriber struct {
+	name      string
+	opts      Options
+	deliver   func(ctx context.Context, ev interface{}, block bool) bool
+	delivered prometheus.Counter
+	dropped   prometheus.Counter
+}
+
+// topic delivers the events published to a topic to its subscribers. The typed topics wrap it.
+type topic struct {
+	name string
+
+	mu   sync.Mutex
+	subs []*subscriber
+}
+
+func newTopic(name string) *topic {
+	return &topic{name: name}
+}
+
+func (t *topic) subscribe(name string, opts Options, deliver func(ctx context.Context, ev interface{}, block bool) bool) {
+	s := &subscriber{
+		name:      name,
+		opts:      opts,
+		deliver:   deliver,
+		delivered: eventsDelivered.WithLabelValues(t.name, name),
+		dropped:   eventsDropped.WithLabelValues(t.name, name),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// The list is replaced rather than modified, so publish can iterate over it without holding the lock. A
+	// subscription replaces the previous one of the same name, which a restarted component no longer reads.
+	var subs []*subscriber
+	for _, sub := range t.subs {
+		if sub.name != name {
+			subs = append(subs, sub)
+		}
+	}
+	subs = append(subs, s)
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].opts.Priority > subs[j].opts.Priority })
+	t.subs = subs
+}
+
+func (t *topic) subscribers() []*subscriber {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.subs
+}
+
+// publish delivers an event to the subscribers in order of priority.
+func (t *topic) publish(ctx context.Context, ev interface{}) {
+	start := time.Now()
+	eventsPublished.WithLabelValues(t.name).Inc()
+	for _, s := range t.subscribers() {
+		if s.deliver(ctx, ev, !s.opts.Lossy) {
+			s.delivered.Inc()
+		} else if s.opts.Lossy {
+			s.dropped.Inc()
+		}
+	}
+	deliveryDuration.WithLabelValues(t.name).Observe(time.Since(start).Seconds())
+}
+
+// Bus holds the node's topics.
+type Bus struct {
+	messagePublications *MessagePublicationTopic
+	signedObservations  *SignedObservationTopic
+	signedVAAs          *SignedVAATopic
+	observationRequests *ObservationRequestTopic
+}
+
+// New creates a bus. Its input channels have the same buffers as the channels they replace.
+func New() *Bus {
+	return &Bus{
+		messagePublications: newMessagePublicationTopic(0),
+		signedObservations:  newSignedObservationTopic(50),
+		signedVAAs:          newSignedVAATopic(50),
+		observationRequests: newObservationRequestTopic(common.ObsvReqChannelSize),
+	}
+}
+
+// MessagePublications returns the topic of the messages observed by the watchers.
+func (b *Bus) MessagePublications() *MessagePublicationTopic {
+	return b.messagePublications
+}
+
+// SignedObservations returns the topic of the observations received from p2p and made by the processor.
+func (b *Bus) SignedObservations() *SignedObservationTopic {
+	return b.signedObservations
+}
+
+// SignedVAAs returns the topic of the signed VAAs received from p2p or injected by the admin commands.
+func (b *Bus) SignedVAAs() *SignedVAATopic {
+	return b.signedVAAs
+}
+
+// ObservationRequests returns the topic of the observation requests received from p2p.
+func (b *Bus) ObservationRequests() *ObservationRequestTopic {
+	return b.observationRequests
+}
+
+// Run delivers the published events until the context is cancelled.
+func (b *Bus) Run(ctx context.Context) error {
+	pumps := []func(ctx context.Context){
+		b.messagePublications.pump,
+		b.signedObservations.pump,
+		b.signedVAAs.pump,
+		b.observationRequests.pump,
+	}
+
+	// A topic must not be pumped twice, which would reorder its events, so the pumps are stopped before returning.
+	var wg sync.WaitGroup
+	for _, pump := range pumps {
+		wg.Add(1)
+		go func(pump func(ctx context.Context)) {
+			defer wg.Done()
+			pump(ctx)
+		}(pump)
+	}
+
+	supervisor.Signal(ctx, supervisor.SignalHealthy)
+	<-ctx.Done()
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,99 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func runBus(t *testing.T, b *Bus) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	supervisor.New(ctx, zap.NewNop(), b.Run)
+}
+
+func TestBusDeliversToAllSubscribers(t *testing.T) {
+	b := New()
+	first := b.MessagePublications().Subscribe("first", Options{})
+	second := b.MessagePublications().Subscribe("second", Options{Buffer: 1})
+	runBus(t, b)
+
+	k := &common.MessagePublication{Sequence: 1}
+	b.MessagePublications().C() <- k
+	assert.Equal(t, k, <-first)
+	assert.Equal(t, k, <-second)
+}
+
+func TestBusPriority(t *testing.T) {
+	b := New()
+	// Unbuffered, so each delivery completes only once the event is read.
+	low := b.SignedObservations().Subscribe("low", Options{Priority: PriorityLow})
+	high := b.SignedObservations().Subscribe("high", Options{Priority: PriorityHigh})
+	runBus(t, b)
+
+	m := &gossipv1.SignedObservation{MessageId: "1"}
+	b.SignedObservations().C() <- m
+
+	// The low priority subscriber only receives the event once the high priority one did.
+	select {
+	case <-low:
+		t.Fatal("low priority subscriber received the event first")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, m, <-high)
+	assert.Equal(t, m, <-low)
+}
+
+func TestBusLossySubscriber(t *testing.T) {
+	b := New()
+	blocking := b.SignedVAAs().Subscribe("blocking", Options{})
+	lossy := b.SignedVAAs().Subscribe("lossy-test", Options{Buffer: 1, Lossy: true, Priority: PriorityHigh})
+	runBus(t, b)
+	dropped := testutil.ToFloat64(eventsDropped.WithLabelValues("signed_vaas", "lossy-test"))
+
+	// The lossy subscriber does not hold back the blocking one once full.
+	for i := 0; i < 3; i++ {
+		b.SignedVAAs().C() <- &gossipv1.SignedVAAWithQuorum{Vaa: []byte{byte(i)}}
+		assert.Equal(t, []byte{byte(i)}, (<-blocking).Vaa)
+	}
+	assert.Equal(t, []byte{0}, (<-lossy).Vaa)
+	assert.Equal(t, dropped+2, testutil.ToFloat64(eventsDropped.WithLabelValues("signed_vaas", "lossy-test")))
+}
+
+func TestBusResubscribe(t *testing.T) {
+	b := New()
+	stale := b.ObservationRequests().Subscribe("reobservation", Options{})
+	current := b.ObservationRequests().Subscribe("reobservation", Options{})
+	runBus(t, b)
+
+	// The stale subscription is not read, and would block the bus if it was not replaced.
+	req := &gossipv1.ObservationRequest{ChainId: 1}
+	b.ObservationRequests().C() <- req
+	assert.Equal(t, req, <-current)
+	assert.Len(t, stale, 0)
+}
+
+func TestBusQueuesUntilRun(t *testing.T) {
+	b := New()
+	sub := b.SignedObservations().Subscribe("processor", Options{})
+
+	// Events published before the bus runs are queued in the input channel.
+	m := &gossipv1.SignedObservation{MessageId: "1"}
+	b.SignedObservations().C() <- m
+	runBus(t, b)
+
+	select {
+	case got := <-sub:
+		require.Equal(t, m, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered")
+	}
+}
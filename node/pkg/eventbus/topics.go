@@ -0,0 +1,200 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+)
+
+// MessagePublicationTopic carries the messages observed by the watchers.
+type MessagePublicationTopic struct {
+	t  *topic
+	in chan *common.MessagePublication
+}
+
+func newMessagePublicationTopic(buffer int) *MessagePublicationTopic {
+	return &MessagePublicationTopic{t: newTopic("message_publications"), in: make(chan *common.MessagePublication, buffer)}
+}
+
+// C returns the channel to publish to.
+func (m *MessagePublicationTopic) C() chan<- *common.MessagePublication {
+	return m.in
+}
+
+// Subscribe returns a channel receiving the messages published from now on.
+func (m *MessagePublicationTopic) Subscribe(name string, opts Options) <-chan *common.MessagePublication {
+	ch := make(chan *common.MessagePublication, opts.Buffer)
+	m.t.subscribe(name, opts, func(ctx context.Context, ev interface{}, block bool) bool {
+		if !block {
+			select {
+			case ch <- ev.(*common.MessagePublication):
+				return true
+			default:
+				return false
+			}
+		}
+		select {
+		case ch <- ev.(*common.MessagePublication):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	return ch
+}
+
+func (m *MessagePublicationTopic) pump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case k := <-m.in:
+			m.t.publish(ctx, k)
+		}
+	}
+}
+
+// SignedObservationTopic carries the observations received from p2p and made by the processor.
+type SignedObservationTopic struct {
+	t  *topic
+	in chan *gossipv1.SignedObservation
+}
+
+func newSignedObservationTopic(buffer int) *SignedObservationTopic {
+	return &SignedObservationTopic{t: newTopic("signed_observations"), in: make(chan *gossipv1.SignedObservation, buffer)}
+}
+
+// C returns the channel to publish to.
+func (o *SignedObservationTopic) C() chan<- *gossipv1.SignedObservation {
+	return o.in
+}
+
+// Subscribe returns a channel receiving the observations published from now on.
+func (o *SignedObservationTopic) Subscribe(name string, opts Options) <-chan *gossipv1.SignedObservation {
+	ch := make(chan *gossipv1.SignedObservation, opts.Buffer)
+	o.t.subscribe(name, opts, func(ctx context.Context, ev interface{}, block bool) bool {
+		if !block {
+			select {
+			case ch <- ev.(*gossipv1.SignedObservation):
+				return true
+			default:
+				return false
+			}
+		}
+		select {
+		case ch <- ev.(*gossipv1.SignedObservation):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	return ch
+}
+
+func (o *SignedObservationTopic) pump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-o.in:
+			o.t.publish(ctx, m)
+		}
+	}
+}
+
+// SignedVAATopic carries the signed VAAs received from p2p or injected by the admin commands.
+type SignedVAATopic struct {
+	t  *topic
+	in chan *gossipv1.SignedVAAWithQuorum
+}
+
+func newSignedVAATopic(buffer int) *SignedVAATopic {
+	return &SignedVAATopic{t: newTopic("signed_vaas"), in: make(chan *gossipv1.SignedVAAWithQuorum, buffer)}
+}
+
+// C returns the channel to publish to.
+func (v *SignedVAATopic) C() chan<- *gossipv1.SignedVAAWithQuorum {
+	return v.in
+}
+
+// Subscribe returns a channel receiving the signed VAAs published from now on.
+func (v *SignedVAATopic) Subscribe(name string, opts Options) <-chan *gossipv1.SignedVAAWithQuorum {
+	ch := make(chan *gossipv1.SignedVAAWithQuorum, opts.Buffer)
+	v.t.subscribe(name, opts, func(ctx context.Context, ev interface{}, block bool) bool {
+		if !block {
+			select {
+			case ch <- ev.(*gossipv1.SignedVAAWithQuorum):
+				return true
+			default:
+				return false
+			}
+		}
+		select {
+		case ch <- ev.(*gossipv1.SignedVAAWithQuorum):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	return ch
+}
+
+func (v *SignedVAATopic) pump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-v.in:
+			v.t.publish(ctx, m)
+		}
+	}
+}
+
+// ObservationRequestTopic carries the observation requests received from p2p.
+type ObservationRequestTopic struct {
+	t  *topic
+	in chan *gossipv1.ObservationRequest
+}
+
+func newObservationRequestTopic(buffer int) *ObservationRequestTopic {
+	return &ObservationRequestTopic{t: newTopic("observation_requests"), in: make(chan *gossipv1.ObservationRequest, buffer)}
+}
+
+// C returns the channel to publish to.
+func (r *ObservationRequestTopic) C() chan<- *gossipv1.ObservationRequest {
+	return r.in
+}
+
+// Subscribe returns a channel receiving the observation requests published from now on.
+func (r *ObservationRequestTopic) Subscribe(name string, opts Options) <-chan *gossipv1.ObservationRequest {
+	ch := make(chan *gossipv1.ObservationRequest, opts.Buffer)
+	r.t.subscribe(name, opts, func(ctx context.Context, ev interface{}, block bool) bool {
+		if !block {
+			select {
+			case ch <- ev.(*gossipv1.ObservationRequest):
+				return true
+			default:
+				return false
+			}
+		}
+		select {
+		case ch <- ev.(*gossipv1.ObservationRequest):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	return ch
+}
+
+func (r *ObservationRequestTopic) pump(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-r.in:
+			r.t.publish(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build faultinject
+// +build faultinject
+
+package faultinject
+
+// Enabled is set in builds with the faultinject build tag, which must only be run in devnet.
+const Enabled = true
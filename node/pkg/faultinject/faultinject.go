@@ -0,0 +1,116 @@
+// Package faultinject injects faults into gossip and chain RPC clients, to test how the node recovers from them in
+// devnet. Faults can only be enabled in builds with the faultinject build tag (see Enabled), and are configured at
+// runtime with the admin command fault-inject.
+//
+// Every hook checks the constant Enabled first, so the hooks compile to nothing in regular builds.
+package faultinject
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config is the set of faults to inject. The zero value injects none.
+type Config struct {
+	// Percentage of received gossip messages to drop.
+	GossipDropPercent uint32
+	// Delay added to every chain RPC request.
+	RPCDelay time.Duration
+	// Percentage of chain RPC responses to replace with malformed JSON.
+	MalformedRPCPercent uint32
+}
+
+// ErrDisabled is returned when configuring faults in a build without the faultinject build tag.
+var ErrDisabled = errors.New("fault injection is not available in this build (build with -tags faultinject)")
+
+var (
+	mu     sync.RWMutex
+	config Config
+)
+
+// Set replaces the faults to inject.
+func Set(c Config) error {
+	if !Enabled {
+		return ErrDisabled
+	}
+	if c.GossipDropPercent > 100 || c.MalformedRPCPercent > 100 {
+		return fmt.Errorf("percentages must be between 0 and 100")
+	}
+	if c.RPCDelay < 0 {
+		return fmt.Errorf("the RPC delay must not be negative")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	config = c
+	return nil
+}
+
+// Get returns the faults currently injected.
+func Get() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return config
+}
+
+// chance returns true with the given probability in percent.
+func chance(percent uint32) bool {
+	return percent > 0 && uint32(rand.Intn(100)) < percent //#nosec G404 Faults don't need secure randomness.
+}
+
+// DropGossip returns whether a received gossip message should be dropped.
+func DropGossip() bool {
+	if !Enabled {
+		return false
+	}
+	return chance(Get().GossipDropPercent)
+}
+
+// malformedJSON is returned in place of chain RPC responses. It is truncated, so it neither parses as JSON nor
+// matches any expected field.
+const malformedJSON = `{"jsonrpc":"2.0","result":{"`
+
+// transport delays chain RPC requests and corrupts their responses.
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := Get()
+	if c.RPCDelay > 0 {
+		select {
+		case <-time.After(c.RPCDelay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !chance(c.MalformedRPCPercent) {
+		return resp, err
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader([]byte(malformedJSON)))
+	resp.ContentLength = int64(len(malformedJSON))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// HTTPClient is the HTTP client of chain RPC clients. In builds with the faultinject build tag, it injects RPC faults;
+// otherwise, it is http.DefaultClient.
+var HTTPClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	if !Enabled {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: transport{next: http.DefaultTransport}}
+}
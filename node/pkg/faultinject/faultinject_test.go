@@ -0,0 +1,57 @@
+package faultinject
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	defer func() { config = Config{} }()
+
+	err := Set(Config{GossipDropPercent: 50})
+	if !Enabled {
+		assert.Equal(t, ErrDisabled, err)
+		assert.False(t, DropGossip())
+		assert.Equal(t, http.DefaultClient, HTTPClient)
+		return
+	}
+	require.NoError(t, err)
+	assert.Equal(t, uint32(50), Get().GossipDropPercent)
+
+	assert.Error(t, Set(Config{GossipDropPercent: 101}))
+	assert.Error(t, Set(Config{RPCDelay: -time.Second}))
+}
+
+func TestTransport(t *testing.T) {
+	defer func() { config = Config{} }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":1}`))
+	}))
+	defer srv.Close()
+	c := &http.Client{Transport: transport{next: http.DefaultTransport}}
+
+	get := func() []byte {
+		resp, err := c.Get(srv.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return b
+	}
+
+	assert.True(t, json.Valid(get()))
+
+	config = Config{MalformedRPCPercent: 100, RPCDelay: 50 * time.Millisecond}
+	start := time.Now()
+	b := get()
+	assert.False(t, json.Valid(b))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
@@ -0,0 +1,142 @@
+// Package featureflags gates changes of behavior, typically of a watcher, behind flags enabled per node. A change is
+// rolled out by enabling its flag on a few guardians first, then on the rest of the guardian set. The enabled flags are
+// advertised in the heartbeats, so the progress of a rollout can be followed across the guardian set.
+//
+// A change defines its flag once, at package initialization, and checks it where the behavior differs:
+//
+//	var streamingFlag = featureflags.Define("aptos_streaming", vaa.ChainIDAptos, "Stream events instead of polling them")
+//
+//	if streamingFlag.Enabled() { ... }
+//
+// Flags are enabled with --featureFlags when the node starts, and can't be changed while it runs. Once a change is
+// rolled out everywhere, its flag is removed along with the previous behavior.
+package featureflags
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
+
+// HeartbeatPrefix prefixes the enabled flags in the features of the heartbeats.
+const HeartbeatPrefix = "flag:"
+
+var validName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Flag is a change of behavior which can be enabled on the node.
+type Flag struct {
+	Name string
+	// Chain whose watcher the flag changes, or ChainIDUnset for flags of the whole node.
+	Chain       vaa.ChainID
+	Description string
+
+	r *Registry
+}
+
+// Enabled returns whether the flag is enabled on this node.
+func (f *Flag) Enabled() bool {
+	f.r.mu.RLock()
+	defer f.r.mu.RUnlock()
+	return f.r.enabled[f.Name]
+}
+
+// Registry is a set of defined flags.
+type Registry struct {
+	mu      sync.RWMutex
+	flags   map[string]*Flag
+	enabled map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		flags:   make(map[string]*Flag),
+		enabled: make(map[string]bool),
+	}
+}
+
+// DefaultRegistry holds the flags of the node.
+var DefaultRegistry = NewRegistry()
+
+// Define defines a flag in the default registry.
+func Define(name string, chain vaa.ChainID, description string) *Flag {
+	return DefaultRegistry.Define(name, chain, description)
+}
+
+// Define defines a flag. It panics if the name is invalid or already defined, which is a programming error.
+func (r *Registry) Define(name string, chain vaa.ChainID, description string) *Flag {
+	if !validName.MatchString(name) {
+		panic(fmt.Sprintf("invalid feature flag name %q", name))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flags[name]; ok {
+		panic(fmt.Sprintf("feature flag %s is already defined", name))
+	}
+	f := &Flag{Name: name, Chain: chain, Description: description, r: r}
+	r.flags[name] = f
+	return f
+}
+
+// Enable enables the flags of names, which must be defined. The flags enabled previously are disabled.
+func (r *Registry) Enable(names []string) error {
+	enabled := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		r.mu.RLock()
+		_, ok := r.flags[name]
+		r.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown feature flag %q", name)
+		}
+		enabled[name] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled
+	return nil
+}
+
+// Flags returns the defined flags, sorted by name.
+func (r *Registry) Flags() []*Flag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	flags := make([]*Flag, 0, len(r.flags))
+	for _, f := range r.flags {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// HeartbeatFeatures returns the enabled flags as heartbeat features, sorted by name.
+func (r *Registry) HeartbeatFeatures() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	features := make([]string, 0, len(r.enabled))
+	for name := range r.enabled {
+		features = append(features, HeartbeatPrefix+name)
+	}
+	sort.Strings(features)
+	return features
+}
+
+// FromHeartbeatFeatures returns the flags enabled on a node, from the features of its heartbeat. The flags may be
+// unknown to this node, if the other node runs a different release.
+func FromHeartbeatFeatures(features []string) []string {
+	var names []string
+	for _, f := range features {
+		if strings.HasPrefix(f, HeartbeatPrefix) {
+			names = append(names, strings.TrimPrefix(f, HeartbeatPrefix))
+		}
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package featureflags
+
+import (
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	streaming := r.Define("aptos_streaming", vaa.ChainIDAptos, "Stream Aptos events")
+	batch := r.Define("batch_vaa", vaa.ChainIDUnset, "Sign batch VAAs")
+
+	assert.Panics(t, func() { r.Define("batch_vaa", vaa.ChainIDUnset, "") })
+	assert.Panics(t, func() { r.Define("Batch VAA", vaa.ChainIDUnset, "") })
+
+	assert.False(t, streaming.Enabled())
+	assert.Empty(t, r.HeartbeatFeatures())
+
+	require.NoError(t, r.Enable([]string{"batch_vaa", " aptos_streaming", ""}))
+	assert.True(t, streaming.Enabled())
+	assert.True(t, batch.Enabled())
+	assert.Equal(t, []string{"flag:aptos_streaming", "flag:batch_vaa"}, r.HeartbeatFeatures())
+
+	// Unknown flags are refused, and the enabled flags are left unchanged.
+	assert.Error(t, r.Enable([]string{"aptos_streaming", "typo"}))
+	assert.True(t, batch.Enabled())
+
+	require.NoError(t, r.Enable([]string{"aptos_streaming"}))
+	assert.False(t, batch.Enabled())
+
+	flags := r.Flags()
+	require.Len(t, flags, 2)
+	assert.Equal(t, "aptos_streaming", flags[0].Name)
+	assert.Equal(t, vaa.ChainIDAptos, flags[0].Chain)
+}
+
+func TestFromHeartbeatFeatures(t *testing.T) {
+	assert.Equal(t, []string{"aptos_streaming", "new_flag"},
+		FromHeartbeatFeatures([]string{"governor", "flag:aptos_streaming", "degraded:memory", "flag:new_flag"}))
+	assert.Empty(t, FromHeartbeatFeatures([]string{"governor"}))
+}
@@ -17,6 +17,10 @@
 //
 // The chain governor supports admin client commands as documented in governor_cmd.go.
 //
+// NFT transfers and payload three transfers can be given a fixed notional value or held for approval using payload evaluators, see governor_evaluators.go.
+//
+// Inbound transfers of some tokens can be allowed to free outbound capacity of their target chain, see governor_flow_cancel.go.
+//
 // The set of tokens to be monitored is specified in tokens.go, which can be auto generated using the tool in node/hack/governor. See the README there.
 //
 // The set of chains to be monitored is specified in chains.go, which can be edited by hand.
@@ -87,9 +91,13 @@ type (
 
 	// Payload for each enqueued transfer
 	pendingEntry struct {
-		token  *tokenEntry // Store a reference to the token so we can get the current price to compute the value each interval.
-		amount *big.Int
-		dbData db.PendingTransfer // This info gets persisted in the DB.
+		token      *tokenEntry // Store a reference to the token so we can get the current price to compute the value each interval.
+		amount     *big.Int
+		evaluation *Evaluation // Set if the transfer was valued by a payload evaluator, in which case token and amount are nil.
+		// This info gets persisted in the DB, including whether the transfer is held until enough guardians voted to
+		// release it (see governor_emergency.go). The hold is decided when the transfer is enqueued, rather than each
+		// interval or on reload, so that price changes don't release it.
+		dbData db.PendingTransfer
 	}
 
 	// Payload of the map of chains being monitored
@@ -102,6 +110,10 @@ type (
 
 		transfers []*db.Transfer
 		pending   []*pendingEntry
+		usage     []*db.GovernorUsage
+
+		// Inbound transfers which free outbound capacity, see governor_flow_cancel.go.
+		flowCancelTransfers []*db.FlowCancelTransfer
 	}
 )
 
@@ -117,9 +129,18 @@ type ChainGovernor struct {
 	tokensByCoinGeckoId map[string][]*tokenEntry
 	chains              map[vaa.ChainID]*chainEntry
 	msgsToPublish       []*common.MessagePublication
+	evaluators          []PayloadEvaluator
 	dayLengthInMinutes  int
 	coinGeckoQuery      string
 	env                 int
+	flowCancelEnabled   bool
+	flowCancelTokens    map[tokenKey]struct{}
+
+	emergencyHoldThreshold uint64
+	emergencyReleaseQuorum int
+	// The guardian set whose members' votes are counted to release held transfers.
+	emergencyGuardianSet *common.GuardianSetState
+	emergencyReleases    map[string]*emergencyReleaseVotes
 }
 
 func NewChainGovernor(
@@ -134,6 +155,8 @@ func NewChainGovernor(
 		tokensByCoinGeckoId: make(map[string][]*tokenEntry),
 		chains:              make(map[vaa.ChainID]*chainEntry),
 		env:                 env,
+		flowCancelTokens:    make(map[tokenKey]struct{}),
+		emergencyReleases:   make(map[string]*emergencyReleaseVotes),
 	}
 }
 
@@ -219,18 +242,12 @@ func (gov *ChainGovernor) initConfig() error {
 		return fmt.Errorf("no tokens are configured")
 	}
 
-	emitterMap := &common.KnownTokenbridgeEmitters
-	if gov.env == TestNetMode {
-		emitterMap = &common.KnownTestnetTokenbridgeEmitters
-	} else if gov.env == DevNetMode {
-		emitterMap = &common.KnownDevnetTokenbridgeEmitters
-	}
-
+	emitterMap := tokenBridgeEmitterMap(gov.env)
 	for _, cc := range configChains {
 		var emitterAddr vaa.Address
 		var err error
 
-		emitterAddrBytes, exists := (*emitterMap)[cc.emitterChainID]
+		emitterAddrBytes, exists := emitterMap[cc.emitterChainID]
 		if !exists {
 			return fmt.Errorf("failed to look up token bridge emitter address for chain: %v", cc.emitterChainID)
 		}
@@ -262,7 +279,7 @@ func (gov *ChainGovernor) initConfig() error {
 		return fmt.Errorf("no chains are configured")
 	}
 
-	return nil
+	return gov.initFlowCancelConfig()
 }
 
 // Returns true if the message can be published, false if it has been added to the pending list.
@@ -292,6 +309,17 @@ func (gov *ChainGovernor) ProcessMsgForTime(msg *common.MessagePublication, now
 		return true, nil
 	}
 
+	// Give the payload evaluators a chance to claim the message before applying the token bridge logic.
+	eval, err := gov.evaluate(msg)
+	if err != nil {
+		gov.logger.Error("cgov: failed to evaluate vaa", zap.String("msgID", msg.MessageIDString()), zap.Error(err))
+		return false, err
+	}
+
+	if eval != nil {
+		return gov.processEvaluatedMsgForTime(ce, msg, eval, now)
+	}
+
 	// If we don't care about this emitter, the VAA can be published.
 	if msg.EmitterAddress != ce.emitterAddr {
 		gov.logger.Info("cgov: ignoring vaa because the emitter address is not configured", zap.String("msgID", msg.MessageIDString()))
@@ -338,8 +366,19 @@ func (gov *ChainGovernor) ProcessMsgForTime(msg *common.MessagePublication, now
 	}
 
 	enqueueIt := false
+	emergencyHold := false
 	var releaseTime time.Time
-	if ce.isBigTransfer(value) {
+	if gov.isEmergencyTransfer(value) {
+		enqueueIt = true
+		emergencyHold = true
+		releaseTime = now.Add(maxEnqueuedTime)
+		gov.logger.Error("cgov: holding vaa until enough guardians vote to release it because it exceeds the emergency threshold",
+			zap.Uint64("value", value),
+			zap.String("msgID", msg.MessageIDString()),
+			zap.Uint64("emergencyHoldThreshold", gov.emergencyHoldThreshold),
+			zap.Int("emergencyReleaseQuorum", gov.emergencyReleaseQuorum),
+		)
+	} else if ce.isBigTransfer(value) {
 		enqueueIt = true
 		releaseTime = now.Add(maxEnqueuedTime)
 		gov.logger.Error("cgov: enqueuing vaa because it is a big transaction",
@@ -363,7 +402,7 @@ func (gov *ChainGovernor) ProcessMsgForTime(msg *common.MessagePublication, now
 	}
 
 	if enqueueIt {
-		dbData := db.PendingTransfer{ReleaseTime: releaseTime, Msg: *msg}
+		dbData := db.PendingTransfer{ReleaseTime: releaseTime, Msg: *msg, EmergencyHold: emergencyHold}
 		ce.pending = append(ce.pending, &pendingEntry{token: token, amount: payload.Amount, dbData: dbData})
 		err = gov.db.StorePendingMsg(&dbData)
 		if err != nil {
@@ -388,9 +427,101 @@ func (gov *ChainGovernor) ProcessMsgForTime(msg *common.MessagePublication, now
 		return false, err
 	}
 
+	if err := gov.recordFlowCancel(&xfer, payload); err != nil {
+		return false, err
+	}
+
+	gov.recordUsage(ce, value, now)
+
 	return true, nil
 }
 
+// Handles a message that was claimed by a payload evaluator. Assumes the lock is held.
+func (gov *ChainGovernor) processEvaluatedMsgForTime(ce *chainEntry, msg *common.MessagePublication, eval *Evaluation, now time.Time) (bool, error) {
+	if gov.isEmergencyTransfer(eval.Value) {
+		gov.logger.Error("cgov: holding evaluated vaa until enough guardians vote to release it because it exceeds the emergency threshold",
+			zap.String("evaluator", eval.Evaluator),
+			zap.Uint64("value", eval.Value),
+			zap.String("msgID", msg.MessageIDString()),
+			zap.Uint64("emergencyHoldThreshold", gov.emergencyHoldThreshold),
+			zap.Int("emergencyReleaseQuorum", gov.emergencyReleaseQuorum),
+		)
+
+		return false, gov.enqueueEvaluatedMsg(ce, msg, eval, now.Add(maxEnqueuedTime), true)
+	}
+
+	if eval.RequiresApproval {
+		gov.logger.Error("cgov: enqueuing vaa because it requires approval",
+			zap.String("evaluator", eval.Evaluator),
+			zap.String("msgID", msg.MessageIDString()),
+		)
+
+		return false, gov.enqueueEvaluatedMsg(ce, msg, eval, now.Add(maxEnqueuedTime), false)
+	}
+
+	startTime := now.Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
+	prevTotalValue, err := ce.TrimAndSumValue(startTime, gov.db)
+	if err != nil {
+		gov.logger.Error("cgov: failed to trim transfers", zap.String("msgID", msg.MessageIDString()), zap.Error(err))
+		return false, err
+	}
+
+	value := eval.Value
+	newTotalValue := prevTotalValue + value
+	if newTotalValue < prevTotalValue {
+		gov.logger.Error("cgov: total value has overflowed", zap.String("msgID", msg.MessageIDString()), zap.Uint64("prevTotalValue", prevTotalValue), zap.Uint64("newTotalValue", newTotalValue))
+		return false, fmt.Errorf("total value has overflowed")
+	}
+
+	if ce.isBigTransfer(value) || newTotalValue > ce.dailyLimit {
+		releaseTime := now.Add(maxEnqueuedTime)
+		gov.logger.Error("cgov: enqueuing evaluated vaa because it is a big transaction or would exceed the daily limit",
+			zap.String("evaluator", eval.Evaluator),
+			zap.Uint64("value", value),
+			zap.Uint64("prevTotalValue", prevTotalValue),
+			zap.Uint64("newTotalValue", newTotalValue),
+			zap.Stringer("releaseTime", releaseTime),
+			zap.String("msgID", msg.MessageIDString()),
+		)
+
+		return false, gov.enqueueEvaluatedMsg(ce, msg, eval, releaseTime, false)
+	}
+
+	gov.logger.Info("cgov: posting evaluated vaa",
+		zap.String("evaluator", eval.Evaluator),
+		zap.Uint64("value", value),
+		zap.Uint64("prevTotalValue", prevTotalValue),
+		zap.Uint64("newTotalValue", newTotalValue),
+		zap.String("msgID", msg.MessageIDString()))
+
+	xfer := newEvaluatedTransfer(msg, value, now)
+	ce.transfers = append(ce.transfers, xfer)
+	if err := gov.db.StoreTransfer(xfer); err != nil {
+		gov.logger.Error("cgov: failed to store transfer", zap.String("msgID", msg.MessageIDString()), zap.Error(err))
+		return false, err
+	}
+
+	gov.recordUsage(ce, value, now)
+
+	return true, nil
+}
+
+func (gov *ChainGovernor) enqueueEvaluatedMsg(ce *chainEntry, msg *common.MessagePublication, eval *Evaluation, releaseTime time.Time, emergencyHold bool) error {
+	dbData := db.PendingTransfer{ReleaseTime: releaseTime, Msg: *msg, EmergencyHold: emergencyHold}
+	ce.pending = append(ce.pending, &pendingEntry{evaluation: eval, dbData: dbData})
+	if err := gov.db.StorePendingMsg(&dbData); err != nil {
+		gov.logger.Error("cgov: failed to store pending vaa", zap.String("msgID", msg.MessageIDString()), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func newEvaluatedTransfer(msg *common.MessagePublication, value uint64, now time.Time) *db.Transfer {
+	return &db.Transfer{Timestamp: now, Value: value, OriginChain: msg.EmitterChain, OriginAddress: msg.EmitterAddress,
+		EmitterChain: msg.EmitterChain, EmitterAddress: msg.EmitterAddress, MsgID: msg.MessageIDString()}
+}
+
 func (gov *ChainGovernor) CheckPending() ([]*common.MessagePublication, error) {
 	return gov.CheckPendingForTime(time.Now())
 }
@@ -422,11 +553,16 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 
 			// Keep going until we find something that fits or hit the end.
 			for idx, pe := range ce.pending {
-				value, err := computeValue(pe.amount, pe.token)
+				// Transfers in the approval queue are only released by admin command.
+				if pe.requiresApproval() && !pe.dbData.EmergencyHold {
+					continue
+				}
+
+				value, err := pe.computeValue()
 				if err != nil {
 					gov.logger.Error("cgov: failed to compute value for pending vaa",
-						zap.Stringer("amount", pe.amount),
-						zap.Stringer("price", pe.token.price),
+						pe.amountField(),
+						pe.priceField(),
 						zap.String("msgID", pe.dbData.Msg.MessageIDString()),
 						zap.Error(err),
 					)
@@ -436,23 +572,39 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 				}
 
 				countsTowardsTransfers := true
-				if ce.isBigTransfer(value) {
+				if pe.dbData.EmergencyHold {
+					msgId := pe.dbData.Msg.MessageIDString()
+					votes := gov.emergencyReleaseCount(msgId)
+					if votes < gov.emergencyReleaseQuorum {
+						continue // Keep waiting for the guardians to vote.
+					}
+
+					countsTowardsTransfers = false
+					delete(gov.emergencyReleases, msgId)
+					gov.logger.Warn("cgov: posting vaa held by the emergency hold because enough guardians voted to release it",
+						pe.amountField(),
+						pe.priceField(),
+						zap.Uint64("value", value),
+						zap.Int("votes", votes),
+						zap.Int("quorum", gov.emergencyReleaseQuorum),
+						zap.String("msgID", msgId))
+				} else if ce.isBigTransfer(value) {
 					if now.Before(pe.dbData.ReleaseTime) {
 						continue // Keep waiting for the timer to expire.
 					}
 
 					countsTowardsTransfers = false
 					gov.logger.Info("cgov: posting pending big vaa because the release time has been reached",
-						zap.Stringer("amount", pe.amount),
-						zap.Stringer("price", pe.token.price),
+						pe.amountField(),
+						pe.priceField(),
 						zap.Uint64("value", value),
 						zap.Stringer("releaseTime", pe.dbData.ReleaseTime),
 						zap.String("msgID", pe.dbData.Msg.MessageIDString()))
 				} else if now.After(pe.dbData.ReleaseTime) {
 					countsTowardsTransfers = false
 					gov.logger.Info("cgov: posting pending vaa because the release time has been reached",
-						zap.Stringer("amount", pe.amount),
-						zap.Stringer("price", pe.token.price),
+						pe.amountField(),
+						pe.priceField(),
 						zap.Uint64("value", value),
 						zap.Stringer("releaseTime", pe.dbData.ReleaseTime),
 						zap.String("msgID", pe.dbData.Msg.MessageIDString()))
@@ -469,8 +621,8 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 					}
 
 					gov.logger.Info("cgov: posting pending vaa",
-						zap.Stringer("amount", pe.amount),
-						zap.Stringer("price", pe.token.price),
+						pe.amountField(),
+						pe.priceField(),
 						zap.Uint64("value", value),
 						zap.Uint64("prevTotalValue", prevTotalValue),
 						zap.Uint64("newTotalValue", newTotalValue),
@@ -481,14 +633,26 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 				msgsToPublish = append(msgsToPublish, &pe.dbData.Msg)
 
 				if countsTowardsTransfers {
-					xfer := db.Transfer{Timestamp: now, Value: value, OriginChain: pe.token.token.chain, OriginAddress: pe.token.token.addr,
-						EmitterChain: pe.dbData.Msg.EmitterChain, EmitterAddress: pe.dbData.Msg.EmitterAddress, MsgID: pe.dbData.Msg.MessageIDString()}
-					ce.transfers = append(ce.transfers, &xfer)
+					var xfer *db.Transfer
+					if pe.evaluation != nil {
+						xfer = newEvaluatedTransfer(&pe.dbData.Msg, value, now)
+					} else {
+						xfer = &db.Transfer{Timestamp: now, Value: value, OriginChain: pe.token.token.chain, OriginAddress: pe.token.token.addr,
+							EmitterChain: pe.dbData.Msg.EmitterChain, EmitterAddress: pe.dbData.Msg.EmitterAddress, MsgID: pe.dbData.Msg.MessageIDString()}
+					}
+					ce.transfers = append(ce.transfers, xfer)
 
-					if err := gov.db.StoreTransfer(&xfer); err != nil {
+					if err := gov.db.StoreTransfer(xfer); err != nil {
 						gov.msgsToPublish = msgsToPublish
 						return nil, err
 					}
+
+					if pe.evaluation == nil {
+						if err := gov.recordPendingFlowCancel(xfer, pe); err != nil {
+							gov.msgsToPublish = msgsToPublish
+							return nil, err
+						}
+					}
 				}
 
 				if err := gov.db.DeletePendingMsg(&pe.dbData); err != nil {
@@ -496,6 +660,8 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 					return nil, err
 				}
 
+				gov.recordUsage(ce, value, now)
+
 				ce.pending = append(ce.pending[:idx], ce.pending[idx+1:]...)
 				foundOne = true
 				break // We messed up our loop indexing, so we have to break out and start over.
@@ -507,9 +673,54 @@ func (gov *ChainGovernor) CheckPendingForTime(now time.Time) ([]*common.MessageP
 		}
 	}
 
+	gov.trimEmergencyReleases(now)
+
 	return msgsToPublish, nil
 }
 
+// Returns the map of token bridge emitters for the specified environment.
+func tokenBridgeEmitterMap(env int) map[vaa.ChainID][]byte {
+	if env == TestNetMode {
+		return common.KnownTestnetTokenbridgeEmitters
+	} else if env == DevNetMode {
+		return common.KnownDevnetTokenbridgeEmitters
+	}
+	return common.KnownTokenbridgeEmitters
+}
+
+// Returns the map of NFT bridge emitters for the specified environment.
+func nftBridgeEmitterMap(env int) map[vaa.ChainID][]byte {
+	if env == TestNetMode {
+		return common.KnownTestnetNFTBridgeEmitters
+	} else if env == DevNetMode {
+		return common.KnownDevnetNFTBridgeEmitters
+	}
+	return common.KnownNFTBridgeEmitters
+}
+
+// TransferValuer returns the notional value of a transfer, or false if it is unknown. It is implemented by the chain
+// governor, and used by the components which weigh messages by value, such as the circuit breaker.
+type TransferValuer interface {
+	TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool)
+}
+
+// TransferValue returns the notional value of a token bridge transfer at the current price, or false if the token is not
+// monitored by the governor.
+func (gov *ChainGovernor) TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	token, exists := gov.tokens[tokenKey{chain: payload.OriginChain, addr: payload.OriginAddress}]
+	if !exists {
+		return 0, false
+	}
+	value, err := computeValue(payload.Amount, token)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
 func computeValue(amount *big.Int, token *tokenEntry) (uint64, error) {
 	amountFloat := new(big.Float)
 	amountFloat = amountFloat.SetInt(amount)
@@ -529,9 +740,19 @@ func computeValue(amount *big.Int, token *tokenEntry) (uint64, error) {
 	return value, nil
 }
 
+// Returns the value counted towards the daily limit of the chain, which is reduced by the flow cancel transfers to it.
 func (ce *chainEntry) TrimAndSumValue(startTime time.Time, db db.GovernorDB) (sum uint64, err error) {
 	sum, ce.transfers, err = TrimAndSumValue(ce.transfers, startTime, db)
-	return sum, err
+	if err != nil {
+		return 0, err
+	}
+
+	inbound, err := ce.trimAndSumFlowCancel(startTime, db)
+	if err != nil {
+		return 0, err
+	}
+
+	return subtractFlowCancel(sum, inbound), nil
 }
 
 func TrimAndSumValue(transfers []*db.Transfer, startTime time.Time, db db.GovernorDB) (uint64, []*db.Transfer, error) {
@@ -1,4 +1,5 @@
-// This file contains the code to load transfers and pending messages from the database.
+// This file contains the code to load transfers, pending messages and usage history from the database.
+// Flow cancel transfers are loaded by governor_flow_cancel.go.
 
 package governor
 
@@ -53,7 +54,11 @@ func (gov *ChainGovernor) loadFromDBAlreadyLocked() error {
 		}
 	}
 
-	return nil
+	if err := gov.loadFlowCancelFromDBAlreadyLocked(now); err != nil {
+		return err
+	}
+
+	return gov.loadUsageFromDBAlreadyLocked(now)
 }
 
 func (gov *ChainGovernor) reloadPendingTransfer(pending *db.PendingTransfer, now time.Time) {
@@ -73,6 +78,31 @@ func (gov *ChainGovernor) reloadPendingTransfer(pending *db.PendingTransfer, now
 		return
 	}
 
+	eval, err := gov.evaluate(msg)
+	if err != nil {
+		gov.logger.Error("cgov: failed to evaluate reloaded pending transfer, dropping it",
+			zap.String("MsgID", msg.MessageIDString()),
+			zap.Stringer("TxHash", msg.TxHash),
+			zap.Stringer("Timestamp", msg.Timestamp),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if eval != nil {
+		gov.logger.Info("cgov: reloaded evaluated pending transfer",
+			zap.String("MsgID", msg.MessageIDString()),
+			zap.Stringer("TxHash", msg.TxHash),
+			zap.Stringer("Timestamp", msg.Timestamp),
+			zap.String("Evaluator", eval.Evaluator),
+			zap.Uint64("Value", eval.Value),
+			zap.Bool("RequiresApproval", eval.RequiresApproval),
+		)
+
+		ce.pending = append(ce.pending, &pendingEntry{evaluation: eval, dbData: *pending})
+		return
+	}
+
 	if msg.EmitterAddress != ce.emitterAddr {
 		gov.logger.Error("cgov: reloaded pending transfer for unsupported emitter address, dropping it",
 			zap.String("MsgID", msg.MessageIDString()),
@@ -151,6 +181,19 @@ func (gov *ChainGovernor) reloadTransfer(xfer *db.Transfer, now time.Time, start
 		return
 	}
 
+	if isEvaluatedTransfer(xfer) {
+		gov.logger.Info("cgov: reloaded evaluated transfer",
+			zap.Stringer("Timestamp", xfer.Timestamp),
+			zap.Uint64("Value", xfer.Value),
+			zap.Stringer("EmitterChain", xfer.EmitterChain),
+			zap.Stringer("EmitterAddress", xfer.EmitterAddress),
+			zap.String("MsgID", xfer.MsgID),
+		)
+
+		ce.transfers = append(ce.transfers, xfer)
+		return
+	}
+
 	if xfer.EmitterAddress != ce.emitterAddr {
 		gov.logger.Error("cgov: reloaded transfer for unsupported emitter address, dropping it",
 			zap.Stringer("Timestamp", xfer.Timestamp),
@@ -0,0 +1,181 @@
+// This file contains the emergency hold of the chain governor.
+//
+// The release timer of big transactions gives the guardians a few days to react to an exploit, after which the transfer
+// is released whether or not anybody looked at it. When the emergency hold is enabled, transfers worth at least the
+// emergency threshold are held indefinitely instead, until enough guardian operators voted to release them with the
+// governor-emergency-release admin command. It is a human-in-the-loop brake for transfers large enough to be catastrophic.
+//
+// The votes are signed with the guardian keys and gossiped by the p2p layer, which records the valid ones here. Only the
+// votes of the members of the current guardian set are recorded, and only the votes of the guardians which are still
+// members of it are counted when the transfer is released, so that votes don't outlive a guardian set change. Votes are kept in memory: a guardian re-gossips its
+// own votes periodically while the transfer is held, so a node that restarted recovers the votes of the other guardians
+// within a heartbeat interval, but its operator has to vote again.
+//
+// Held transfers are neither released by the release timer nor by the governor-release-pending-vaa admin command, and
+// they do not count towards the daily limit once released, like big transactions released by the timer.
+
+package governor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+
+	"go.uber.org/zap"
+)
+
+// Votes to release a transfer held by the emergency hold.
+type emergencyReleaseVotes struct {
+	guardians map[ethCommon.Address]struct{}
+	// Set if the operator of this node voted, in which case the vote is gossiped until the transfer is released.
+	own bool
+	// When the first vote was recorded, to forget the votes for transfers this node never held.
+	firstSeen time.Time
+}
+
+// EnableEmergencyHold holds the transfers worth at least threshold until quorum members of the guardian set gst voted to
+// release them. It must be called before Run.
+func (gov *ChainGovernor) EnableEmergencyHold(threshold uint64, quorum int, gst *common.GuardianSetState) error {
+	if threshold == 0 {
+		return fmt.Errorf("the emergency hold threshold must be positive")
+	}
+	if quorum < 1 {
+		return fmt.Errorf("the emergency release quorum must be at least one")
+	}
+	if gst == nil {
+		return fmt.Errorf("the emergency hold requires the guardian set")
+	}
+
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+	gov.emergencyHoldThreshold = threshold
+	gov.emergencyReleaseQuorum = quorum
+	gov.emergencyGuardianSet = gst
+	return nil
+}
+
+// Returns true if a transfer of the given value must be held by the emergency hold.
+func (gov *ChainGovernor) isEmergencyTransfer(value uint64) bool {
+	return gov.emergencyHoldThreshold != 0 && value >= gov.emergencyHoldThreshold
+}
+
+// Returns the number of members of the current guardian set which voted to release a transfer. Votes of guardians
+// removed from the set since they voted are not counted. Assumes the lock is held.
+func (gov *ChainGovernor) emergencyReleaseCount(msgId string) int {
+	v, exists := gov.emergencyReleases[msgId]
+	if !exists || gov.emergencyGuardianSet == nil {
+		return 0
+	}
+	gs := gov.emergencyGuardianSet.Get()
+	if gs == nil {
+		return 0
+	}
+
+	count := 0
+	for guardian := range v.guardians {
+		if _, ok := gs.KeyIndex(guardian); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// Returns the votes for a transfer, creating them if needed. Assumes the lock is held.
+func (gov *ChainGovernor) emergencyReleaseVotesFor(msgId string, now time.Time) *emergencyReleaseVotes {
+	v, exists := gov.emergencyReleases[msgId]
+	if !exists {
+		v = &emergencyReleaseVotes{guardians: make(map[ethCommon.Address]struct{}), firstSeen: now}
+		gov.emergencyReleases[msgId] = v
+	}
+	return v
+}
+
+// Returns the pending transfer held by the emergency hold with the given message ID, or nil. Assumes the lock is held.
+func (gov *ChainGovernor) findEmergencyHold(msgId string) *pendingEntry {
+	for _, ce := range gov.chains {
+		for _, pe := range ce.pending {
+			if pe.dbData.EmergencyHold && pe.dbData.Msg.MessageIDString() == msgId {
+				return pe
+			}
+		}
+	}
+	return nil
+}
+
+// Admin command to vote to release a transfer held by the emergency hold. The vote is gossiped by the p2p layer, which
+// records it with RecordEmergencyRelease.
+func (gov *ChainGovernor) EmergencyRelease(vaaId string) (string, error) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	if gov.findEmergencyHold(vaaId) == nil {
+		return "", fmt.Errorf("vaa not found in the emergency hold")
+	}
+
+	v := gov.emergencyReleaseVotesFor(vaaId, time.Now())
+	v.own = true
+	votes := gov.emergencyReleaseCount(vaaId)
+	gov.logger.Warn("cgov: voting to release vaa held by the emergency hold due to admin command",
+		zap.String("msgId", vaaId),
+		zap.Int("votes", votes),
+		zap.Int("quorum", gov.emergencyReleaseQuorum),
+	)
+
+	return fmt.Sprintf("vote to release vaa \"%v\" will be gossiped with the next heartbeat, %d of %d votes were received so far",
+		vaaId, votes, gov.emergencyReleaseQuorum), nil
+}
+
+// OwnEmergencyReleases returns the message IDs of the held transfers this node voted to release, whose votes must be
+// gossiped.
+func (gov *ChainGovernor) OwnEmergencyReleases() []string {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	var msgIds []string
+	for msgId, v := range gov.emergencyReleases {
+		if v.own && gov.findEmergencyHold(msgId) != nil {
+			msgIds = append(msgIds, msgId)
+		}
+	}
+	return msgIds
+}
+
+// RecordEmergencyRelease records the vote of a guardian to release a transfer held by the emergency hold. The caller
+// must have verified the signature of the vote, and that the guardian is a member of the current guardian set. Votes for
+// transfers which aren't held yet are kept, since other guardians may observe the transfer first.
+func (gov *ChainGovernor) RecordEmergencyRelease(vaaId string, guardian ethCommon.Address) {
+	gov.recordEmergencyReleaseForTime(vaaId, guardian, time.Now())
+}
+
+func (gov *ChainGovernor) recordEmergencyReleaseForTime(vaaId string, guardian ethCommon.Address, now time.Time) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	if gov.emergencyHoldThreshold == 0 {
+		return
+	}
+
+	v := gov.emergencyReleaseVotesFor(vaaId, now)
+	if _, exists := v.guardians[guardian]; exists {
+		return
+	}
+	v.guardians[guardian] = struct{}{}
+	gov.logger.Info("cgov: recorded vote to release vaa held by the emergency hold",
+		zap.String("msgId", vaaId),
+		zap.Stringer("guardian", guardian),
+		zap.Int("votes", gov.emergencyReleaseCount(vaaId)),
+		zap.Int("quorum", gov.emergencyReleaseQuorum),
+	)
+}
+
+// Forgets the votes for transfers which have not been held for longer than a transfer can be enqueued, so that votes
+// for transfers this node never observed don't accumulate. Assumes the lock is held.
+func (gov *ChainGovernor) trimEmergencyReleases(now time.Time) {
+	for msgId, v := range gov.emergencyReleases {
+		if now.Sub(v.firstSeen) > maxEnqueuedTime && gov.findEmergencyHold(msgId) == nil {
+			delete(gov.emergencyReleases, msgId)
+		}
+	}
+}
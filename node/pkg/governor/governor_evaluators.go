@@ -0,0 +1,251 @@
+// This file contains the payload evaluators used by the chain governor for messages it cannot price using the token list.
+//
+// By default, the chain governor only considers token bridge transfers (payload types one and three) of tokens in the token list,
+// and values them using the token price. Payload evaluators allow other messages from a governed chain to be taken into account:
+//   - NFT bridge transfers can be assigned a fixed notional value that counts towards the daily limit of the emitter chain.
+//   - Payload three (contract controlled) token bridge transfers of tokens that are not in the token list can be assigned a fixed
+//     notional value. Transfers of listed tokens are still valued using the token price, unless they require approval.
+//   - The token transfers of integrators decoded by a payload registry, such as CCTP or NTT transfers, can be valued using the
+//     price of their token in the token list.
+//
+// Instead of counting towards the daily limit, an evaluator may route messages to the approval queue. Messages in the approval queue
+// are never released automatically. They remain in the pending list until they are released or dropped using the admin commands.
+//
+// Evaluators are consulted in the order they were added, before the default token bridge logic. The first evaluator that
+// claims a message determines how it is handled.
+
+package governor
+
+import (
+	"fmt"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// PayloadEvaluator assigns a notional value to the messages it is interested in.
+	PayloadEvaluator interface {
+		// Name returns a short description of the evaluator, used in logs and admin commands.
+		Name() string
+
+		// Evaluate returns nil if the evaluator is not interested in the message.
+		Evaluate(msg *common.MessagePublication) (*Evaluation, error)
+	}
+
+	// Evaluation is the result of evaluating a message.
+	Evaluation struct {
+		// The evaluator that produced this evaluation.
+		Evaluator string
+
+		// The notional value that counts towards the daily limit.
+		Value uint64
+
+		// If set, the message is added to the approval queue rather than being counted towards the daily limit.
+		RequiresApproval bool
+	}
+
+	// Evaluator for NFT bridge transfers.
+	nftTransferEvaluator struct {
+		emitters        map[vaa.ChainID]vaa.Address
+		notional        uint64
+		requireApproval bool
+	}
+
+	// Evaluator for payload three (contract controlled) token bridge transfers.
+	payload3Evaluator struct {
+		gov             *ChainGovernor
+		emitters        map[vaa.ChainID]vaa.Address
+		notional        uint64
+		requireApproval bool
+	}
+
+	// Evaluator for the token transfers decoded by a payload registry.
+	payloadRegistryEvaluator struct {
+		gov             *ChainGovernor
+		registry        *vaa.PayloadRegistry
+		requireApproval bool
+	}
+)
+
+// The NFT bridge transfer payload type.
+const nftTransferPayloadType = 1
+
+// The token bridge payload type for contract controlled transfers.
+const transferWithPayloadType = 3
+
+// NewNFTTransferEvaluator creates an evaluator that assigns the specified notional value to each NFT bridge transfer
+// or, if requireApproval is set, adds it to the approval queue.
+func NewNFTTransferEvaluator(env int, notional uint64, requireApproval bool) (PayloadEvaluator, error) {
+	emitters, err := buildEmitterAddressMap(nftBridgeEmitterMap(env))
+	if err != nil {
+		return nil, err
+	}
+
+	return &nftTransferEvaluator{emitters: emitters, notional: notional, requireApproval: requireApproval}, nil
+}
+
+func (e *nftTransferEvaluator) Name() string {
+	return "nft"
+}
+
+func (e *nftTransferEvaluator) Evaluate(msg *common.MessagePublication) (*Evaluation, error) {
+	emitterAddr, exists := e.emitters[msg.EmitterChain]
+	if !exists || msg.EmitterAddress != emitterAddr {
+		return nil, nil
+	}
+
+	if len(msg.Payload) == 0 || msg.Payload[0] != nftTransferPayloadType {
+		return nil, nil
+	}
+
+	return &Evaluation{Evaluator: e.Name(), Value: e.notional, RequiresApproval: e.requireApproval}, nil
+}
+
+// NewPayload3Evaluator creates an evaluator that assigns the specified notional value to each payload three token bridge transfer
+// of a token that is not in the token list or, if requireApproval is set, adds every payload three transfer to the approval queue.
+// Transfers of listed tokens that do not require approval are left to the default logic, so that sending a transfer as payload
+// three cannot be used to have it counted below its token value.
+func (gov *ChainGovernor) NewPayload3Evaluator(env int, notional uint64, requireApproval bool) (PayloadEvaluator, error) {
+	emitters, err := buildEmitterAddressMap(tokenBridgeEmitterMap(env))
+	if err != nil {
+		return nil, err
+	}
+
+	return &payload3Evaluator{gov: gov, emitters: emitters, notional: notional, requireApproval: requireApproval}, nil
+}
+
+func (e *payload3Evaluator) Name() string {
+	return "payload3"
+}
+
+func (e *payload3Evaluator) Evaluate(msg *common.MessagePublication) (*Evaluation, error) {
+	emitterAddr, exists := e.emitters[msg.EmitterChain]
+	if !exists || msg.EmitterAddress != emitterAddr {
+		return nil, nil
+	}
+
+	if len(msg.Payload) == 0 || msg.Payload[0] != transferWithPayloadType {
+		return nil, nil
+	}
+
+	// Called with the governor lock held, which protects the token list.
+	if !e.requireApproval {
+		hdr, err := vaa.DecodeTransferPayloadHdr(msg.Payload)
+		if err != nil {
+			return nil, nil
+		}
+		if _, exists := e.gov.tokens[tokenKey{chain: hdr.OriginChain, addr: hdr.OriginAddress}]; exists {
+			return nil, nil
+		}
+	}
+
+	return &Evaluation{Evaluator: e.Name(), Value: e.notional, RequiresApproval: e.requireApproval}, nil
+}
+
+// NewPayloadRegistryEvaluator creates an evaluator that values the token transfers decoded by the registry using the price
+// of their token in the token list or, if requireApproval is set, adds them to the approval queue. Transfers of tokens
+// that are not in the list, and payloads that fail to decode, are left to the default logic.
+func (gov *ChainGovernor) NewPayloadRegistryEvaluator(registry *vaa.PayloadRegistry, requireApproval bool) PayloadEvaluator {
+	return &payloadRegistryEvaluator{gov: gov, registry: registry, requireApproval: requireApproval}
+}
+
+func (e *payloadRegistryEvaluator) Name() string {
+	return "registry"
+}
+
+// Evaluate is called with the governor lock held, which protects the token list.
+func (e *payloadRegistryEvaluator) Evaluate(msg *common.MessagePublication) (*Evaluation, error) {
+	p, err := e.registry.Decode(msg.EmitterChain, msg.EmitterAddress, msg.Payload)
+	if err != nil {
+		e.gov.logger.Warn("cgov: failed to decode payload with the payload registry, ignoring it", zap.String("msgID", msg.MessageIDString()), zap.Error(err))
+		return nil, nil
+	}
+	if p == nil || p.Transfer == nil {
+		return nil, nil
+	}
+
+	token, exists := e.gov.tokens[tokenKey{chain: p.Transfer.TokenChain, addr: p.Transfer.TokenAddress}]
+	if !exists {
+		return nil, nil
+	}
+
+	value, err := computeValue(p.Transfer.Amount, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Evaluation{Evaluator: fmt.Sprintf("%s/%s", e.Name(), p.Format), Value: value, RequiresApproval: e.requireApproval}, nil
+}
+
+func buildEmitterAddressMap(emitterMap map[vaa.ChainID][]byte) (map[vaa.ChainID]vaa.Address, error) {
+	emitters := make(map[vaa.ChainID]vaa.Address)
+	for chainID, emitterAddrBytes := range emitterMap {
+		emitterAddr, err := vaa.BytesToAddress(emitterAddrBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert emitter address for chain: %v", chainID)
+		}
+		emitters[chainID] = emitterAddr
+	}
+
+	return emitters, nil
+}
+
+// AddPayloadEvaluator adds an evaluator to the chain governor. It should be called before the governor is started.
+func (gov *ChainGovernor) AddPayloadEvaluator(e PayloadEvaluator) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+	gov.evaluators = append(gov.evaluators, e)
+}
+
+// evaluate returns the evaluation of the first evaluator interested in the message, or nil if there is none.
+func (gov *ChainGovernor) evaluate(msg *common.MessagePublication) (*Evaluation, error) {
+	for _, e := range gov.evaluators {
+		eval, err := e.Evaluate(msg)
+		if err != nil {
+			return nil, fmt.Errorf("%s evaluator failed: %w", e.Name(), err)
+		}
+		if eval != nil {
+			return eval, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Returns the value of a pending transfer, using the current price for token bridge transfers.
+func (pe *pendingEntry) computeValue() (uint64, error) {
+	if pe.evaluation != nil {
+		return pe.evaluation.Value, nil
+	}
+	return computeValue(pe.amount, pe.token)
+}
+
+// Returns true if the pending transfer is waiting in the approval queue.
+func (pe *pendingEntry) requiresApproval() bool {
+	return pe.evaluation != nil && pe.evaluation.RequiresApproval
+}
+
+// Returns the logging field describing the amount of a pending transfer, or the evaluator for evaluated transfers.
+func (pe *pendingEntry) amountField() zap.Field {
+	if pe.evaluation != nil {
+		return zap.String("evaluator", pe.evaluation.Evaluator)
+	}
+	return zap.Stringer("amount", pe.amount)
+}
+
+// Returns the logging field describing the token price of a pending transfer, which is skipped for evaluated transfers.
+func (pe *pendingEntry) priceField() zap.Field {
+	if pe.evaluation != nil {
+		return zap.Skip()
+	}
+	return zap.Stringer("price", pe.token.price)
+}
+
+// Evaluated transfers are not associated with a token, so they are persisted with the emitter as their origin.
+func isEvaluatedTransfer(xfer *db.Transfer) bool {
+	return xfer.OriginChain == xfer.EmitterChain && xfer.OriginAddress == xfer.EmitterAddress
+}
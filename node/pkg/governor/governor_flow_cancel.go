@@ -0,0 +1,244 @@
+// This file contains the flow cancel support of the chain governor.
+//
+// Without flow canceling, the daily limit of a chain only counts the value leaving it, so a corridor with balanced traffic
+// in both directions gets delayed as if all of it were outbound. When flow canceling is enabled, a transfer of an eligible
+// token to a governed chain frees the same notional value of the outbound capacity of that chain for the rest of the 24 hour
+// window. The value counted towards the daily limit of a chain never goes below zero, but inbound transfers in excess of
+// the outbound ones are not discarded: they keep canceling the outbound transfers made later in the window, so a chain
+// can send up to its daily limit plus the value it received in the last 24 hours.
+//
+// Only the tokens in the flow cancel list for the environment are eligible, and a transfer only cancels flow once it is
+// counted towards the daily limit of its emitter chain. Transfers released by the release timer or by admin command do
+// not count towards the daily limit, so they do not cancel flow either.
+//
+// Flow cancel transfers are persisted in the database, and reloaded on start up along with the other transfers.
+
+package governor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"go.uber.org/zap"
+)
+
+// Layout of the config data for each token eligible for flow canceling
+type flowCancelTokenConfigEntry struct {
+	chain  uint16
+	addr   string
+	symbol string
+}
+
+var (
+	// guardian_governor_flow_cancel_transfers_total{chain_id="1",chain_name="solana"} 3
+	metricFlowCancelTransfers = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "guardian_governor_flow_cancel_transfers_total",
+			Help: "Chain governor number of inbound transfers which freed outbound capacity per chain",
+		}, []string{"chain_id", "chain_name"})
+
+	// guardian_governor_flow_cancel_notional{chain_id="1",chain_name="solana"} 25000
+	metricFlowCancelNotional = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "guardian_governor_flow_cancel_notional",
+			Help: "Chain governor notional value of the inbound transfers within the window which free outbound capacity per chain",
+		}, []string{"chain_id", "chain_name"})
+)
+
+// Returns the tokens eligible for flow canceling on mainnet. These should be assets whose flows are expected to balance
+// out, such as the major stablecoins.
+func flowCancelTokenList() []flowCancelTokenConfigEntry {
+	return []flowCancelTokenConfigEntry{
+		{chain: 1, addr: "c6fa7af3bedbad3a3d65f36aabc97431b1bbe4c2d2f6e0e47ca60203452f5d61", symbol: "USDC"},
+		{chain: 1, addr: "ce010e60afedb22717bd63192f54145a3f965a33bb82d2c7029eb2ce1e208264", symbol: "USDT"},
+		{chain: 2, addr: "000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", symbol: "USDC"},
+		{chain: 2, addr: "000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7", symbol: "USDT"},
+	}
+}
+
+// EnableFlowCancel makes inbound transfers of the eligible tokens free outbound capacity. It must be called before Run.
+func (gov *ChainGovernor) EnableFlowCancel() {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+	gov.flowCancelEnabled = true
+}
+
+// Loads the tokens eligible for flow canceling. Assumes the lock is held.
+func (gov *ChainGovernor) initFlowCancelConfig() error {
+	if !gov.flowCancelEnabled {
+		return nil
+	}
+
+	var configTokens []flowCancelTokenConfigEntry
+	if gov.env == MainNetMode {
+		configTokens = flowCancelTokenList()
+	}
+
+	for _, ct := range configTokens {
+		addr, err := vaa.StringToAddress(ct.addr)
+		if err != nil {
+			return fmt.Errorf("invalid flow cancel token address: %s", ct.addr)
+		}
+
+		key := tokenKey{chain: vaa.ChainID(ct.chain), addr: addr}
+		if _, exists := gov.tokens[key]; !exists {
+			return fmt.Errorf("flow cancel token %s (%s) is not in the token list", key, ct.symbol)
+		}
+
+		gov.flowCancelTokens[key] = struct{}{}
+		gov.logger.Info("cgov: token is eligible for flow cancel:", zap.Stringer("chain", key.chain),
+			zap.Stringer("addr", key.addr),
+			zap.String("symbol", ct.symbol),
+		)
+	}
+
+	return nil
+}
+
+// Credits a transfer counted towards the daily limit of its emitter chain to its target chain, if it is eligible for flow
+// canceling. Assumes the lock is held.
+func (gov *ChainGovernor) recordFlowCancel(xfer *db.Transfer, payload *vaa.TransferPayloadHdr) error {
+	if !gov.flowCancelEnabled || payload.TargetChain == xfer.EmitterChain {
+		return nil
+	}
+
+	if _, eligible := gov.flowCancelTokens[tokenKey{chain: payload.OriginChain, addr: payload.OriginAddress}]; !eligible {
+		return nil
+	}
+
+	target, exists := gov.chains[payload.TargetChain]
+	if !exists {
+		return nil
+	}
+
+	fc := &db.FlowCancelTransfer{Transfer: *xfer, TargetChain: payload.TargetChain}
+	target.flowCancelTransfers = append(target.flowCancelTransfers, fc)
+	if err := gov.db.StoreFlowCancelTransfer(fc); err != nil {
+		gov.logger.Error("cgov: failed to store flow cancel transfer", zap.String("msgID", xfer.MsgID), zap.Error(err))
+		return err
+	}
+
+	metricFlowCancelTransfers.WithLabelValues(fmt.Sprint(uint16(target.emitterChainId)), target.emitterChainId.String()).Inc()
+	gov.logger.Info("cgov: inbound transfer frees outbound capacity",
+		zap.Stringer("targetChain", payload.TargetChain),
+		zap.Uint64("value", xfer.Value),
+		zap.String("msgID", xfer.MsgID))
+
+	return nil
+}
+
+// Loads the flow cancel transfers from the database. Assumes the lock is held.
+func (gov *ChainGovernor) loadFlowCancelFromDBAlreadyLocked(now time.Time) error {
+	if !gov.flowCancelEnabled {
+		return nil
+	}
+
+	transfers, err := gov.db.GetChainGovernorFlowCancelTransfers()
+	if err != nil {
+		gov.logger.Error("cgov: failed to reload flow cancel transfers from db", zap.Error(err))
+		return err
+	}
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp.Before(transfers[j].Timestamp)
+	})
+
+	startTime := now.Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
+	for _, fc := range transfers {
+		if !startTime.Before(fc.Timestamp) {
+			if err := gov.db.DeleteFlowCancelTransfer(fc); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ce, exists := gov.chains[fc.TargetChain]
+		if !exists {
+			gov.logger.Error("cgov: reloaded flow cancel transfer for unsupported chain, dropping it", zap.Stringer("targetChain", fc.TargetChain), zap.String("msgID", fc.MsgID))
+			continue
+		}
+
+		if _, eligible := gov.flowCancelTokens[tokenKey{chain: fc.OriginChain, addr: fc.OriginAddress}]; !eligible {
+			gov.logger.Error("cgov: reloaded flow cancel transfer for ineligible token, dropping it", zap.Stringer("targetChain", fc.TargetChain), zap.String("msgID", fc.MsgID))
+			continue
+		}
+
+		ce.flowCancelTransfers = append(ce.flowCancelTransfers, fc)
+	}
+
+	return nil
+}
+
+// Removes the flow cancel transfers that are outside the window and returns the sum of the remaining ones.
+func (ce *chainEntry) trimAndSumFlowCancel(startTime time.Time, gdb db.GovernorDB) (uint64, error) {
+	var trimIdx int = -1
+	var sum uint64
+
+	for idx, fc := range ce.flowCancelTransfers {
+		if fc.Timestamp.Before(startTime) {
+			trimIdx = idx
+		} else {
+			sum += fc.Value
+		}
+	}
+
+	if trimIdx >= 0 {
+		if gdb != nil {
+			for idx := 0; idx <= trimIdx; idx++ {
+				if err := gdb.DeleteFlowCancelTransfer(ce.flowCancelTransfers[idx]); err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		ce.flowCancelTransfers = ce.flowCancelTransfers[trimIdx+1:]
+	}
+
+	return sum, nil
+}
+
+// Returns the sum of the flow cancel transfers within the window, without trimming them.
+func (ce *chainEntry) flowCancelValue(startTime time.Time) uint64 {
+	var sum uint64
+	for _, fc := range ce.flowCancelTransfers {
+		if !fc.Timestamp.Before(startTime) {
+			sum += fc.Value
+		}
+	}
+	return sum
+}
+
+// Returns the value counted towards the daily limit of the chain within the window, without trimming the transfers.
+func (ce *chainEntry) netValue(startTime time.Time) uint64 {
+	return subtractFlowCancel(sumValue(ce.transfers, startTime), ce.flowCancelValue(startTime))
+}
+
+// Subtracts the flow cancel value from the outbound value, without going below zero.
+func subtractFlowCancel(outbound uint64, inbound uint64) uint64 {
+	if inbound >= outbound {
+		return 0
+	}
+	return outbound - inbound
+}
+
+// Credits a pending transfer which was released and counted towards the daily limit of its emitter chain to its target
+// chain. Assumes the lock is held.
+func (gov *ChainGovernor) recordPendingFlowCancel(xfer *db.Transfer, pe *pendingEntry) error {
+	if !gov.flowCancelEnabled {
+		return nil
+	}
+
+	payload, err := vaa.DecodeTransferPayloadHdr(pe.dbData.Msg.Payload)
+	if err != nil {
+		gov.logger.Error("cgov: failed to decode released vaa for flow cancel", zap.String("msgID", xfer.MsgID), zap.Error(err))
+		return nil
+	}
+
+	return gov.recordFlowCancel(xfer, payload)
+}
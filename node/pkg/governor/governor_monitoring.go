@@ -9,6 +9,11 @@
 //   - governor-drop-pending-vaa [VAA_ID] - removes the specified transfer from the pending list and discards it.
 //   - governor-release-pending-vaa [VAA_ID] - removes the specified transfer from the pending list and publishes it, without regard to the threshold.
 //   - governor-reset-release-timer - resets the release timer for the specified VAA to the configured maximum.
+//   - governor-list-pending-vaas [CHAIN_ID] - lists the pending transfers (optionally for a single emitter chain), including their decoded payloads.
+//   - governor-move-pending-vaa [VAA_ID] [POSITION] - moves the specified transfer to a new position in the pending list of its emitter chain.
+//     Note that the order of the pending list is only maintained in memory. When the governor is reloaded, the list is sorted by message timestamp.
+//
+// Transfers in the approval queue (see governor_evaluators.go) are listed as pending, and can only be published using governor-release-pending-vaa.
 //
 // The VAA_ID is of the form "2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/3", which is "emitter chain / emitter address / sequence number".
 
@@ -36,6 +41,19 @@
 // Returns:
 // {"isEnqueued":true}
 //
+// Query: http://localhost:7071/v1/governor/usage_history
+//
+// Returns:
+// {"entries":[
+//	{"chainId":1,"notionalLimit":"100000","dayNotional":"3783","dayCount":"2","weekNotional":"21480","weekCount":"15","monthNotional":"80214","monthCount":"61","peakDailyNotional":"9127"},
+//	{"chainId":2,"notionalLimit":"100000","dayNotional":"0","dayCount":"0","weekNotional":"0","weekCount":"0","monthNotional":"0","monthCount":"0","peakDailyNotional":"0"}
+// ]}
+//
+// Query: http://localhost:7071/v1/governor/simulate_transfer/2/2/000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2/1000000000
+//
+// Returns:
+// {"outcome":"OUTCOME_DELAYED_DAILY_LIMIT","notionalValue":"16000","remainingAvailableNotional":"12000","estimatedReleaseTime":1662099312,"enqueuedVaas":0}
+//
 // Query: http://localhost:7071/v1/governor/token_list
 //
 // Returns:
@@ -64,11 +82,13 @@ package governor
 
 import (
 	"fmt"
+	"math/big"
 	"sort"
 	"time"
 
 	"github.com/certusone/wormhole/node/pkg/db"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"go.uber.org/zap"
@@ -85,16 +105,26 @@ func (gov *ChainGovernor) Status() string {
 	startTime := time.Now().Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
 	var resp string
 	for _, ce := range gov.chains {
-		valueTrans := sumValue(ce.transfers, startTime)
+		valueTrans := ce.netValue(startTime)
 		s1 := fmt.Sprintf("chain: %v, dailyLimit: %v, total: %v, numPending: %v", ce.emitterChainId, ce.dailyLimit, valueTrans, len(ce.pending))
+		if gov.flowCancelEnabled {
+			s1 += fmt.Sprintf(", flowCancel: %v", ce.flowCancelValue(startTime))
+		}
 		s2 := fmt.Sprintf("cgov: %v", s1)
 		resp += s1 + "\n"
 		gov.logger.Info(s2)
 		if len(ce.pending) != 0 {
 			for idx, pe := range ce.pending {
-				value, _ := computeValue(pe.amount, pe.token)
+				value, _ := pe.computeValue()
 				s1 := fmt.Sprintf("chain: %v, pending[%v], value: %v, vaa: %v, timeStamp: %v, releaseTime: %v", ce.emitterChainId, idx, value,
 					pe.dbData.Msg.MessageIDString(), pe.dbData.Msg.Timestamp.String(), pe.dbData.ReleaseTime.String())
+				if pe.requiresApproval() {
+					s1 += ", requiresApproval: true"
+				}
+				if pe.dbData.EmergencyHold {
+					s1 += fmt.Sprintf(", emergencyHold: true, emergencyReleases: %v/%v",
+						gov.emergencyReleaseCount(pe.dbData.Msg.MessageIDString()), gov.emergencyReleaseQuorum)
+				}
 				s2 := fmt.Sprintf("cgov: %v", s1)
 				gov.logger.Info(s2)
 				resp += "   " + s1 + "\n"
@@ -117,6 +147,8 @@ func (gov *ChainGovernor) Reload() (string, error) {
 	for _, ce := range gov.chains {
 		ce.transfers = nil
 		ce.pending = nil
+		ce.usage = nil
+		ce.flowCancelTransfers = nil
 	}
 
 	if err := gov.loadFromDBAlreadyLocked(); err != nil {
@@ -136,7 +168,7 @@ func (gov *ChainGovernor) DropPendingVAA(vaaId string) (string, error) {
 		for idx, pe := range ce.pending {
 			msgId := pe.dbData.Msg.MessageIDString()
 			if msgId == vaaId {
-				value, _ := computeValue(pe.amount, pe.token)
+				value, _ := pe.computeValue()
 				gov.logger.Info("cgov: dropping pending vaa",
 					zap.String("msgId", msgId),
 					zap.Uint64("value", value),
@@ -166,7 +198,11 @@ func (gov *ChainGovernor) ReleasePendingVAA(vaaId string) (string, error) {
 		for idx, pe := range ce.pending {
 			msgId := pe.dbData.Msg.MessageIDString()
 			if msgId == vaaId {
-				value, _ := computeValue(pe.amount, pe.token)
+				if pe.dbData.EmergencyHold {
+					return "", fmt.Errorf("vaa is held by the emergency hold, it is only released once enough guardians voted with governor-emergency-release")
+				}
+
+				value, _ := pe.computeValue()
 				gov.logger.Info("cgov: releasing pending vaa, should be published soon",
 					zap.String("msgId", msgId),
 					zap.Uint64("value", value),
@@ -176,12 +212,14 @@ func (gov *ChainGovernor) ReleasePendingVAA(vaaId string) (string, error) {
 				gov.msgsToPublish = append(gov.msgsToPublish, &pe.dbData.Msg)
 
 				// We delete the pending message from the database, but we don't add it to the transfers
-				// because released messages do not apply to the limit.
+				// because released messages do not apply to the limit. They are still included in the usage history.
 
 				if err := gov.db.DeletePendingMsg(&pe.dbData); err != nil {
 					return "", err
 				}
 
+				gov.recordUsage(ce, value, time.Now())
+
 				ce.pending = append(ce.pending[:idx], ce.pending[idx+1:]...)
 				str := fmt.Sprintf("pending vaa \"%v\" has been released and will be published soon", msgId)
 				return str, nil
@@ -226,6 +264,121 @@ func (gov *ChainGovernor) resetReleaseTimerForTime(vaaId string, now time.Time)
 	return "", fmt.Errorf("vaa not found in the pending list")
 }
 
+// Admin command to list the pending VAAs, including their decoded payloads. If emitterChain is ChainIDUnset, all chains are listed.
+func (gov *ChainGovernor) ListPendingVAAs(emitterChain vaa.ChainID) ([]*nodev1.ChainGovernorListPendingVAAsResponse_Entry, error) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	if emitterChain != vaa.ChainIDUnset {
+		if _, exists := gov.chains[emitterChain]; !exists {
+			return nil, fmt.Errorf("chain %v is not configured in the chain governor", emitterChain)
+		}
+	}
+
+	resp := make([]*nodev1.ChainGovernorListPendingVAAsResponse_Entry, 0)
+	for _, ce := range gov.chains {
+		if emitterChain != vaa.ChainIDUnset && ce.emitterChainId != emitterChain {
+			continue
+		}
+
+		for idx, pe := range ce.pending {
+			value, err := pe.computeValue()
+			if err != nil {
+				gov.logger.Error("cgov: failed to compute value of pending transfer", zap.String("msgID", pe.dbData.Msg.MessageIDString()), zap.Error(err))
+				value = 0
+			}
+
+			entry := &nodev1.ChainGovernorListPendingVAAsResponse_Entry{
+				EmitterChain:   uint32(ce.emitterChainId),
+				Position:       uint32(idx),
+				VaaId:          pe.dbData.Msg.MessageIDString(),
+				TxHash:         pe.dbData.Msg.TxHash.String(),
+				Timestamp:      uint32(pe.dbData.Msg.Timestamp.Unix()),
+				ReleaseTime:    uint32(pe.dbData.ReleaseTime.Unix()),
+				NotionalValue:  value,
+				BigTransaction: ce.isBigTransfer(value),
+			}
+
+			if pe.dbData.EmergencyHold {
+				entry.EmergencyHold = true
+				entry.EmergencyReleases = uint32(gov.emergencyReleaseCount(entry.VaaId))
+				entry.EmergencyReleaseQuorum = uint32(gov.emergencyReleaseQuorum)
+			}
+
+			if len(pe.dbData.Msg.Payload) != 0 {
+				entry.PayloadType = uint32(pe.dbData.Msg.Payload[0])
+			}
+
+			if pe.evaluation != nil {
+				entry.Evaluator = pe.evaluation.Evaluator
+				entry.RequiresApproval = pe.evaluation.RequiresApproval
+			} else {
+				entry.Amount = pe.amount.String()
+				entry.OriginChain = uint32(pe.token.token.chain)
+				entry.OriginAddress = pe.token.token.addr.String()
+				entry.TokenSymbol = pe.token.symbol
+
+				// The payload was already decoded when the transfer was enqueued, so this should not fail.
+				payload, err := vaa.DecodeTransferPayloadHdr(pe.dbData.Msg.Payload)
+				if err != nil {
+					gov.logger.Error("cgov: failed to decode payload of pending transfer", zap.String("msgID", pe.dbData.Msg.MessageIDString()), zap.Error(err))
+				} else {
+					entry.TargetChain = uint32(payload.TargetChain)
+					entry.TargetAddress = payload.TargetAddress.String()
+				}
+			}
+
+			resp = append(resp, entry)
+		}
+	}
+
+	sort.SliceStable(resp, func(i, j int) bool {
+		if resp[i].EmitterChain != resp[j].EmitterChain {
+			return resp[i].EmitterChain < resp[j].EmitterChain
+		}
+		return resp[i].Position < resp[j].Position
+	})
+
+	return resp, nil
+}
+
+// Admin command to move a VAA to a new position in the pending list of its emitter chain. Since the governor releases the first pending
+// transfer that fits under the daily limit, this can be used to prioritize a transfer. If the position is past the end of the list, the
+// VAA is moved to the end.
+func (gov *ChainGovernor) MovePendingVAA(vaaId string, position int) (string, error) {
+	if position < 0 {
+		return "", fmt.Errorf("invalid position: %d", position)
+	}
+
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	for _, ce := range gov.chains {
+		for idx, pe := range ce.pending {
+			msgId := pe.dbData.Msg.MessageIDString()
+			if msgId == vaaId {
+				if position >= len(ce.pending) {
+					position = len(ce.pending) - 1
+				}
+
+				gov.logger.Info("cgov: moving pending vaa due to admin command",
+					zap.String("msgId", msgId),
+					zap.Int("oldPosition", idx),
+					zap.Int("newPosition", position),
+				)
+
+				ce.pending = append(ce.pending[:idx], ce.pending[idx+1:]...)
+				ce.pending = append(ce.pending[:position], append([]*pendingEntry{pe}, ce.pending[position:]...)...)
+
+				str := fmt.Sprintf("pending vaa \"%v\" has been moved from position %d to position %d", msgId, idx, position)
+				return str, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("vaa not found in the pending list")
+}
+
 func sumValue(transfers []*db.Transfer, startTime time.Time) uint64 {
 	if len(transfers) == 0 {
 		return 0
@@ -251,7 +404,7 @@ func (gov *ChainGovernor) GetAvailableNotionalByChain() []*publicrpcv1.GovernorG
 
 	startTime := time.Now().Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
 	for _, ce := range gov.chains {
-		value := sumValue(ce.transfers, startTime)
+		value := ce.netValue(startTime)
 		if value >= ce.dailyLimit {
 			value = 0
 		} else {
@@ -273,6 +426,53 @@ func (gov *ChainGovernor) GetAvailableNotionalByChain() []*publicrpcv1.GovernorG
 	return resp
 }
 
+// REST query to get a summary of the governor state per chain.
+func (gov *ChainGovernor) GetStatus() *publicrpcv1.GovernorGetStatusResponse {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	resp := &publicrpcv1.GovernorGetStatusResponse{
+		Enabled: true,
+		Entries: make([]*publicrpcv1.GovernorGetStatusResponse_Entry, 0, len(gov.chains)),
+	}
+
+	startTime := time.Now().Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
+	for _, ce := range gov.chains {
+		value := ce.netValue(startTime)
+		if value >= ce.dailyLimit {
+			value = 0
+		} else {
+			value = ce.dailyLimit - value
+		}
+
+		var enqueuedNotional uint64
+		for _, pe := range ce.pending {
+			v, err := pe.computeValue()
+			if err != nil {
+				gov.logger.Error("cgov: failed to compute value of pending transfer", zap.String("msgID", pe.dbData.Msg.MessageIDString()), zap.Error(err))
+				continue
+			}
+			enqueuedNotional += v
+		}
+
+		resp.Entries = append(resp.Entries, &publicrpcv1.GovernorGetStatusResponse_Entry{
+			ChainId:                    uint32(ce.emitterChainId),
+			RemainingAvailableNotional: value,
+			NotionalLimit:              ce.dailyLimit,
+			BigTransactionSize:         ce.bigTransactionSize,
+			EnqueuedVaas:               uint32(len(ce.pending)),
+			EnqueuedNotional:           enqueuedNotional,
+		})
+		resp.TotalEnqueuedVaas += uint32(len(ce.pending))
+	}
+
+	sort.SliceStable(resp.Entries, func(i, j int) bool {
+		return (resp.Entries[i].ChainId < resp.Entries[j].ChainId)
+	})
+
+	return resp
+}
+
 // REST query to get the list of enqueued VAAs.
 func (gov *ChainGovernor) GetEnqueuedVAAs() []*publicrpcv1.GovernorGetEnqueuedVAAsResponse_Entry {
 	gov.mutex.Lock()
@@ -282,7 +482,7 @@ func (gov *ChainGovernor) GetEnqueuedVAAs() []*publicrpcv1.GovernorGetEnqueuedVA
 
 	for _, ce := range gov.chains {
 		for _, pe := range ce.pending {
-			value, err := computeValue(pe.amount, pe.token)
+			value, err := pe.computeValue()
 			if err != nil {
 				gov.logger.Error("cgov: failed to compute value of pending transfer", zap.String("msgID", pe.dbData.Msg.MessageIDString()), zap.Error(err))
 				value = 0
@@ -325,6 +525,87 @@ func (gov *ChainGovernor) IsVAAEnqueued(msgId *publicrpcv1.MessageID) (bool, err
 	return false, nil
 }
 
+// REST query to predict whether a token bridge transfer of amount of the token would be delayed if it was sent from
+// emitterChain now. The amount is as in the transfer payload.
+func (gov *ChainGovernor) SimulateTransfer(emitterChain vaa.ChainID, originChain vaa.ChainID, originAddress vaa.Address, amount *big.Int) (*publicrpcv1.GovernorSimulateTransferResponse, error) {
+	return gov.simulateTransferForTime(emitterChain, originChain, originAddress, amount, time.Now())
+}
+
+func (gov *ChainGovernor) simulateTransferForTime(emitterChain vaa.ChainID, originChain vaa.ChainID, originAddress vaa.Address, amount *big.Int, now time.Time) (*publicrpcv1.GovernorSimulateTransferResponse, error) {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	resp := &publicrpcv1.GovernorSimulateTransferResponse{Outcome: publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_NOT_GOVERNED}
+
+	ce, exists := gov.chains[emitterChain]
+	if !exists {
+		return resp, nil
+	}
+	token, exists := gov.tokens[tokenKey{chain: originChain, addr: originAddress}]
+	if !exists {
+		return resp, nil
+	}
+
+	value, err := computeValue(amount, token)
+	if err != nil {
+		return nil, err
+	}
+
+	window := time.Minute * time.Duration(gov.dayLengthInMinutes)
+	used := ce.netValue(now.Add(-window))
+	resp.NotionalValue = value
+	resp.EnqueuedVaas = uint32(len(ce.pending))
+	if used < ce.dailyLimit {
+		resp.RemainingAvailableNotional = ce.dailyLimit - used
+	}
+
+	// The same checks as ProcessMsgForTime.
+	latest := now.Add(maxEnqueuedTime)
+	if gov.isEmergencyTransfer(value) {
+		resp.Outcome = publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_HELD_EMERGENCY
+	} else if ce.isBigTransfer(value) {
+		resp.Outcome = publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_BIG_TRANSACTION
+		resp.EstimatedReleaseTime = uint32(latest.Unix())
+	} else if value > resp.RemainingAvailableNotional {
+		resp.Outcome = publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_DAILY_LIMIT
+		resp.EstimatedReleaseTime = uint32(ce.estimateReleaseTime(value, now, window, latest).Unix())
+	} else {
+		resp.Outcome = publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_RELEASED
+	}
+
+	return resp, nil
+}
+
+// Returns the first time after now at which a transfer of value fits in the daily limit of the chain, as the transfers
+// within the window age out, assuming no other transfers are sent. If it does not fit before latest, returns latest.
+// Assumes the lock is held.
+func (ce *chainEntry) estimateReleaseTime(value uint64, now time.Time, window time.Duration, latest time.Time) time.Time {
+	if value > ce.dailyLimit {
+		return latest
+	}
+
+	expiries := make([]time.Time, 0, len(ce.transfers))
+	for _, t := range ce.transfers {
+		expiries = append(expiries, t.Timestamp.Add(window))
+	}
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].Before(expiries[j]) })
+
+	for _, expiry := range expiries {
+		if !expiry.After(now) {
+			continue
+		}
+		if !expiry.Before(latest) {
+			break
+		}
+		// Right after expiry, the transfers up to it are out of the window.
+		if ce.netValue(expiry.Add(-window).Add(time.Nanosecond)) <= ce.dailyLimit-value {
+			return expiry
+		}
+	}
+
+	return latest
+}
+
 // REST query to get the list of tokens being monitored by the governor.
 func (gov *ChainGovernor) GetTokenList() []*publicrpcv1.GovernorGetTokenListResponse_Entry {
 	gov.mutex.Lock()
@@ -401,13 +682,17 @@ func (gov *ChainGovernor) CollectMetrics(hb *gossipv1.Heartbeat) {
 
 		if exists {
 			enabled = "1"
-			value := sumValue(ce.transfers, startTime)
+			value := ce.netValue(startTime)
 			if value >= ce.dailyLimit {
 				value = 0
 			} else {
 				value = ce.dailyLimit - value
 			}
 
+			if gov.flowCancelEnabled {
+				metricFlowCancelNotional.WithLabelValues(chainId, chain.String()).Set(float64(ce.flowCancelValue(startTime)))
+			}
+
 			pending := len(ce.pending)
 			totalNotional = fmt.Sprint(ce.dailyLimit)
 			available = float64(value)
@@ -1,6 +1,7 @@
 package governor
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/db"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"go.uber.org/zap"
 )
@@ -102,7 +104,7 @@ func (gov *ChainGovernor) getStatsForAllChains() (numTrans int, valueTrans uint6
 
 		numPending += len(ce.pending)
 		for _, pe := range ce.pending {
-			value, _ := computeValue(pe.amount, pe.token)
+			value, _ := pe.computeValue()
 			valuePending += value
 		}
 	}
@@ -552,6 +554,19 @@ func TestTransfersUpToAndOverTheLimit(t *testing.T) {
 	assert.Equal(t, uint64(4436+2218), valueTrans)
 	assert.Equal(t, 1, numPending)
 	assert.Equal(t, uint64(2218274), valuePending)
+
+	status := gov.GetStatus()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, uint32(1), status.TotalEnqueuedVaas)
+	for _, e := range status.Entries {
+		if e.ChainId != uint32(vaa.ChainIDEthereum) {
+			assert.Zero(t, e.EnqueuedVaas)
+			continue
+		}
+		assert.Equal(t, uint64(1000000-4436-2218), e.RemainingAvailableNotional)
+		assert.Equal(t, uint32(1), e.EnqueuedVaas)
+		assert.Equal(t, uint64(2218274), e.EnqueuedNotional)
+	}
 }
 
 func TestPendingTransferBeingReleased(t *testing.T) {
@@ -1287,6 +1302,27 @@ func TestIsBigTransfer(t *testing.T) {
 	assert.Equal(t, true, ce.isBigTransfer(uint64(5_000_001)))
 }
 
+func TestTransferValue(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+	require.NoError(t, err)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62))
+
+	payload, err := vaa.DecodeTransferPayloadHdr(buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 1.25))
+	require.NoError(t, err)
+	value, ok := gov.TransferValue(payload)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2218), value)
+
+	payload, err = vaa.DecodeTransferPayloadHdr(buildMockTransferPayloadBytes(1, vaa.ChainIDPolygon, tokenAddrStr, vaa.ChainIDEthereum, toAddrStr, 1.25))
+	require.NoError(t, err)
+	_, ok = gov.TransferValue(payload)
+	assert.False(t, ok)
+}
+
 func TestTransferPayloadTooShort(t *testing.T) {
 	ctx := context.Background()
 	gov, err := newChainGovernorForTest(ctx)
@@ -1336,3 +1372,774 @@ func TestTransferPayloadTooShort(t *testing.T) {
 	canPost := gov.ProcessMsg(&msg)
 	assert.Equal(t, false, canPost)
 }
+
+func TestListAndMovePendingVAAs(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+
+	require.NoError(t, err)
+	assert.NotNil(t, gov)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	tokenBridgeAddr, err := vaa.StringToAddress(tokenBridgeAddrStr)
+	require.NoError(t, err)
+
+	gov.setDayLengthInMinutes(24 * 60)
+	err = gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 0)
+	require.NoError(t, err)
+	err = gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62)
+	require.NoError(t, err)
+
+	buildMsg := func(sequence uint64, amount float64) *common.MessagePublication {
+		return &common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654543099), 0),
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   tokenBridgeAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, amount),
+		}
+	}
+
+	// The first one uses up most of the daily limit, so the next three get queued up.
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	canPost, err := gov.ProcessMsgForTime(buildMsg(1, 550), now)
+	require.NoError(t, err)
+	assert.Equal(t, true, canPost)
+
+	msgA := buildMsg(2, 300)
+	msgB := buildMsg(3, 200)
+	msgC := buildMsg(4, 100)
+	for _, msg := range []*common.MessagePublication{msgA, msgB, msgC} {
+		canPost, err = gov.ProcessMsgForTime(msg, now)
+		require.NoError(t, err)
+		assert.Equal(t, false, canPost)
+	}
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDUnset)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	assert.Equal(t, msgA.MessageIDString(), entries[0].VaaId)
+	assert.Equal(t, uint32(0), entries[0].Position)
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), entries[0].EmitterChain)
+	assert.Equal(t, uint64(532385), entries[0].NotionalValue)
+	assert.Equal(t, uint32(1), entries[0].PayloadType)
+	assert.Equal(t, "30000000000", entries[0].Amount)
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), entries[0].OriginChain)
+	assert.Equal(t, uint32(vaa.ChainIDPolygon), entries[0].TargetChain)
+	assert.Equal(t, "000000000000000000000000707f9118e33a9b8998bea41dd0d46f38bb963fc8", entries[0].TargetAddress)
+	assert.Equal(t, "WETH", entries[0].TokenSymbol)
+	assert.Equal(t, msgB.MessageIDString(), entries[1].VaaId)
+	assert.Equal(t, msgC.MessageIDString(), entries[2].VaaId)
+
+	_, err = gov.ListPendingVAAs(vaa.ChainIDPythNet)
+	assert.Error(t, err)
+
+	_, err = gov.MovePendingVAA("2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/42", 0)
+	assert.Error(t, err)
+
+	// Move the smallest one to the front of the queue.
+	_, err = gov.MovePendingVAA(msgC.MessageIDString(), 0)
+	require.NoError(t, err)
+
+	entries, err = gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	assert.Equal(t, msgC.MessageIDString(), entries[0].VaaId)
+	assert.Equal(t, msgA.MessageIDString(), entries[1].VaaId)
+	assert.Equal(t, msgB.MessageIDString(), entries[2].VaaId)
+	assert.Equal(t, uint32(2), entries[2].Position)
+
+	// Moving past the end of the queue puts it at the end.
+	_, err = gov.MovePendingVAA(msgA.MessageIDString(), 10)
+	require.NoError(t, err)
+
+	entries, err = gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	assert.Equal(t, msgC.MessageIDString(), entries[0].VaaId)
+	assert.Equal(t, msgB.MessageIDString(), entries[1].VaaId)
+	assert.Equal(t, msgA.MessageIDString(), entries[2].VaaId)
+
+	// Once the first transfer drops off, the pending ones are released in queue order, as long as they fit.
+	gov.chains[vaa.ChainIDEthereum].dailyLimit = 600000
+	now, _ = time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 2, 2022 at 3:00pm (CST)")
+	toBePublished, err := gov.CheckPendingForTime(now)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(toBePublished))
+	assert.Equal(t, msgC.MessageIDString(), toBePublished[0].MessageIDString())
+	assert.Equal(t, msgB.MessageIDString(), toBePublished[1].MessageIDString())
+
+	entries, err = gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, msgA.MessageIDString(), entries[0].VaaId)
+}
+
+func TestPayloadEvaluators(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+
+	require.NoError(t, err)
+	assert.NotNil(t, gov)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+
+	gov.setDayLengthInMinutes(24 * 60)
+	err = gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 0)
+	require.NoError(t, err)
+	err = gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62)
+	require.NoError(t, err)
+
+	nftEvaluator, err := NewNFTTransferEvaluator(GoTestMode, 400000, false)
+	require.NoError(t, err)
+	gov.AddPayloadEvaluator(nftEvaluator)
+
+	payload3Evaluator, err := gov.NewPayload3Evaluator(GoTestMode, 0, true)
+	require.NoError(t, err)
+	gov.AddPayloadEvaluator(payload3Evaluator)
+
+	nftBridgeAddr, err := vaa.BytesToAddress(common.KnownNFTBridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+	tokenBridgeAddr, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+
+	buildMsg := func(sequence uint64, emitterAddr vaa.Address, payload []byte) *common.MessagePublication {
+		return &common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654543099), 0),
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   emitterAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          payload,
+		}
+	}
+
+	// The first two NFT transfers fit under the daily limit, but the third one gets queued up.
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	for seq := uint64(1); seq <= 3; seq++ {
+		canPost, err := gov.ProcessMsgForTime(buildMsg(seq, nftBridgeAddr, []byte{1, 2, 3}), now)
+		require.NoError(t, err)
+		assert.Equal(t, seq < 3, canPost)
+	}
+
+	numTrans, valueTrans, numPending, valuePending := gov.getStatsForAllChains()
+	assert.Equal(t, 2, numTrans)
+	assert.Equal(t, uint64(800000), valueTrans)
+	assert.Equal(t, 1, numPending)
+	assert.Equal(t, uint64(400000), valuePending)
+
+	// Other NFT bridge messages are not governed.
+	canPost, err := gov.ProcessMsgForTime(buildMsg(4, nftBridgeAddr, []byte{2, 2, 3}), now)
+	require.NoError(t, err)
+	assert.Equal(t, true, canPost)
+
+	// A payload three transfer goes to the approval queue, even though it is small.
+	payload3Msg := buildMsg(5, tokenBridgeAddr, buildMockTransferPayloadBytes(3, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 1))
+	canPost, err = gov.ProcessMsgForTime(payload3Msg, now)
+	require.NoError(t, err)
+	assert.Equal(t, false, canPost)
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "nft", entries[0].Evaluator)
+	assert.Equal(t, false, entries[0].RequiresApproval)
+	assert.Equal(t, "payload3", entries[1].Evaluator)
+	assert.Equal(t, true, entries[1].RequiresApproval)
+	assert.Equal(t, uint32(3), entries[1].PayloadType)
+
+	// Once the first transfers drop off and the release time has passed, the NFT transfer is published, but the payload three transfer still requires approval.
+	now, _ = time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 5, 2022 at 3:00pm (CST)")
+	toBePublished, err := gov.CheckPendingForTime(now)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(toBePublished))
+	assert.Equal(t, uint64(3), toBePublished[0].Sequence)
+
+	numTrans, _, numPending, _ = gov.getStatsForAllChains()
+	assert.Equal(t, 0, numTrans)
+	assert.Equal(t, 1, numPending)
+
+	_, err = gov.ReleasePendingVAA(payload3Msg.MessageIDString())
+	require.NoError(t, err)
+
+	toBePublished, err = gov.CheckPendingForTime(now)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(toBePublished))
+	assert.Equal(t, payload3Msg.MessageIDString(), toBePublished[0].MessageIDString())
+}
+
+func TestPayload3EvaluatorPricesListedTokens(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+
+	require.NoError(t, err)
+	assert.NotNil(t, gov)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	otherTokenAddrStr := "0x1111111111111111111111111111111111111111"
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+
+	tokenBridgeAddr, err := vaa.BytesToAddress(common.KnownTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+
+	gov.setDayLengthInMinutes(24 * 60)
+	err = gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddr.String(), 1000000, 100000)
+	require.NoError(t, err)
+	err = gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62)
+	require.NoError(t, err)
+
+	payload3Evaluator, err := gov.NewPayload3Evaluator(GoTestMode, 5000, false)
+	require.NoError(t, err)
+	gov.AddPayloadEvaluator(payload3Evaluator)
+
+	buildMsg := func(sequence uint64, payload []byte) *common.MessagePublication {
+		return &common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654543099), 0),
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   tokenBridgeAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          payload,
+		}
+	}
+
+	// A payload three transfer of 100 WETH is valued at its token price, not at the notional value, so it is a big transaction.
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	canPost, err := gov.ProcessMsgForTime(buildMsg(1, buildMockTransferPayloadBytes(3, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 100)), now)
+	require.NoError(t, err)
+	assert.Equal(t, false, canPost)
+
+	// A payload three transfer of a token that is not in the list is counted at the notional value.
+	canPost, err = gov.ProcessMsgForTime(buildMsg(2, buildMockTransferPayloadBytes(3, vaa.ChainIDEthereum, otherTokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 1000000)), now)
+	require.NoError(t, err)
+	assert.Equal(t, true, canPost)
+
+	numTrans, valueTrans, numPending, valuePending := gov.getStatsForAllChains()
+	assert.Equal(t, 1, numTrans)
+	assert.Equal(t, uint64(5000), valueTrans)
+	assert.Equal(t, 1, numPending)
+	assert.Equal(t, uint64(177461), valuePending)
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "", entries[0].Evaluator)
+}
+
+// Builds an NTT transceiver message carrying a transfer of amount, with eight decimals.
+func buildMockNTTTransferPayloadBytes(tokenAddrStr string, amount uint64) []byte {
+	tokenAddr, _ := vaa.StringToAddress(tokenAddrStr)
+
+	transfer := new(bytes.Buffer)
+	transfer.Write([]byte{0x99, 0x4e, 0x54, 0x54})
+	vaa.MustWrite(transfer, binary.BigEndian, uint8(8))
+	vaa.MustWrite(transfer, binary.BigEndian, amount)
+	transfer.Write(tokenAddr[:])
+	transfer.Write(make([]byte, 32))
+	vaa.MustWrite(transfer, binary.BigEndian, vaa.ChainIDSolana)
+
+	manager := new(bytes.Buffer)
+	manager.Write(make([]byte, 64))
+	vaa.MustWrite(manager, binary.BigEndian, uint16(transfer.Len()))
+	manager.Write(transfer.Bytes())
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0x99, 0x45, 0xff, 0x10})
+	buf.Write(make([]byte, 64))
+	vaa.MustWrite(buf, binary.BigEndian, uint16(manager.Len()))
+	buf.Write(manager.Bytes())
+	vaa.MustWrite(buf, binary.BigEndian, uint16(0))
+	return buf.Bytes()
+}
+
+func TestPayloadRegistryEvaluator(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+
+	require.NoError(t, err)
+	assert.NotNil(t, gov)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	otherTokenAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	nttManagerAddr, err := vaa.StringToAddress("0x1111111111111111111111111111111111111111")
+	require.NoError(t, err)
+
+	gov.setDayLengthInMinutes(24 * 60)
+	err = gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 0)
+	require.NoError(t, err)
+	err = gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62)
+	require.NoError(t, err)
+
+	registry, err := vaa.ParsePayloadRegistry([]byte(`{"decoders": [{"format": "ntt"}]}`))
+	require.NoError(t, err)
+	gov.AddPayloadEvaluator(gov.NewPayloadRegistryEvaluator(registry, false))
+
+	buildMsg := func(sequence uint64, payload []byte) *common.MessagePublication {
+		return &common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654543099), 0),
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   nttManagerAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          payload,
+		}
+	}
+
+	// A transfer of 100 WETH is valued at the token price and fits under the daily limit, but 500 more do not.
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	canPost, err := gov.ProcessMsgForTime(buildMsg(1, buildMockNTTTransferPayloadBytes(tokenAddrStr, 100_00000000)), now)
+	require.NoError(t, err)
+	assert.Equal(t, true, canPost)
+	canPost, err = gov.ProcessMsgForTime(buildMsg(2, buildMockNTTTransferPayloadBytes(tokenAddrStr, 500_00000000)), now)
+	require.NoError(t, err)
+	assert.Equal(t, false, canPost)
+
+	// Transfers of tokens that are not in the list are not governed.
+	canPost, err = gov.ProcessMsgForTime(buildMsg(3, buildMockNTTTransferPayloadBytes(otherTokenAddrStr, 500_00000000)), now)
+	require.NoError(t, err)
+	assert.Equal(t, true, canPost)
+
+	numTrans, valueTrans, numPending, valuePending := gov.getStatsForAllChains()
+	assert.Equal(t, 1, numTrans)
+	assert.Equal(t, uint64(177461), valueTrans)
+	assert.Equal(t, 1, numPending)
+	assert.Equal(t, uint64(887309), valuePending)
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "registry/ntt", entries[0].Evaluator)
+}
+
+func TestUsageHistory(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+
+	require.NoError(t, err)
+	assert.NotNil(t, gov)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	tokenBridgeAddr, err := vaa.StringToAddress(tokenBridgeAddrStr)
+	require.NoError(t, err)
+
+	gov.setDayLengthInMinutes(24 * 60)
+	err = gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 0)
+	require.NoError(t, err)
+	err = gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "WETH", 1774.62)
+	require.NoError(t, err)
+
+	postTransfer := func(sequence uint64, amount float64, timeStr string) {
+		msg := common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        time.Unix(int64(1654543099), 0),
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     vaa.ChainIDEthereum,
+			EmitterAddress:   tokenBridgeAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, amount),
+		}
+
+		now, err := time.Parse("Jan 2, 2006 at 3:04pm (MST)", timeStr)
+		require.NoError(t, err)
+		canPost, err := gov.ProcessMsgForTime(&msg, now)
+		require.NoError(t, err)
+		assert.Equal(t, true, canPost)
+	}
+
+	postTransfer(1, 100, "Jun 1, 2022 at 12:00pm (CST)") // 177461
+	postTransfer(2, 100, "Jun 1, 2022 at 12:30pm (CST)") // 177461, same hour
+	postTransfer(3, 100, "Jun 1, 2022 at 2:00pm (CST)")  // 177461
+	postTransfer(4, 250, "Jun 3, 2022 at 12:00pm (CST)") // 443654
+	postTransfer(5, 100, "Jun 20, 2022 at 8:00am (CST)") // 177461
+
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 20, 2022 at 12:30pm (CST)")
+	var entry *publicrpcv1.GovernorGetUsageHistoryResponse_Entry
+	for _, e := range gov.getUsageHistoryForTime(now) {
+		if e.ChainId == uint32(vaa.ChainIDEthereum) {
+			entry = e
+		}
+	}
+
+	require.NotNil(t, entry)
+	assert.Equal(t, uint64(1000000), entry.NotionalLimit)
+	assert.Equal(t, uint64(177461), entry.DayNotional)
+	assert.Equal(t, uint64(1), entry.DayCount)
+	assert.Equal(t, uint64(177461), entry.WeekNotional)
+	assert.Equal(t, uint64(1), entry.WeekCount)
+	assert.Equal(t, uint64(3*177461+443654+177461), entry.MonthNotional)
+	assert.Equal(t, uint64(5), entry.MonthCount)
+	assert.Equal(t, uint64(3*177461), entry.PeakDailyNotional)
+
+	// Only the last transfer is within the last 30 days.
+	now, _ = time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jul 5, 2022 at 12:30pm (CST)")
+	for _, e := range gov.getUsageHistoryForTime(now) {
+		if e.ChainId == uint32(vaa.ChainIDEthereum) {
+			entry = e
+		}
+	}
+
+	assert.Equal(t, uint64(0), entry.DayNotional)
+	assert.Equal(t, uint64(177461), entry.MonthNotional)
+	assert.Equal(t, uint64(1), entry.MonthCount)
+	assert.Equal(t, uint64(177461), entry.PeakDailyNotional)
+
+	// Recording usage trims the buckets past the retention period.
+	postTransfer(6, 100, "Jul 5, 2022 at 12:30pm (CST)")
+	assert.Equal(t, 2, len(gov.chains[vaa.ChainIDEthereum].usage))
+}
+
+func (gov *ChainGovernor) setFlowCancelTokenForTesting(tokenChainID vaa.ChainID, tokenAddrStr string) error {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	tokenAddr, err := vaa.StringToAddress(tokenAddrStr)
+	if err != nil {
+		return err
+	}
+
+	gov.flowCancelEnabled = true
+	gov.flowCancelTokens[tokenKey{chain: tokenChainID, addr: tokenAddr}] = struct{}{}
+	return nil
+}
+
+func TestFlowCancel(t *testing.T) {
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	otherTokenAddrStr := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	ethTokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16"                         //nolint:gosec
+	solTokenBridgeAddrStr := "0xec7372995d5cc8732397fb0ad35c0121e0eaa90d26f828a534cab54391b3a4f5" //nolint:gosec
+
+	newGovernor := func(flowCancel bool) *ChainGovernor {
+		gov, err := newChainGovernorForTest(context.Background())
+		require.NoError(t, err)
+
+		gov.setDayLengthInMinutes(24 * 60)
+		require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, ethTokenBridgeAddrStr, 10000, 0))
+		require.NoError(t, gov.setChainForTesting(vaa.ChainIDSolana, solTokenBridgeAddrStr, 10000, 0))
+		require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "USDC", 1))
+		require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, otherTokenAddrStr, "WETH", 1))
+		if flowCancel {
+			require.NoError(t, gov.setFlowCancelTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr))
+		}
+		return gov
+	}
+
+	sequence := uint64(0)
+	transfer := func(gov *ChainGovernor, from vaa.ChainID, to vaa.ChainID, token string, amount float64, now time.Time) bool {
+		emitterAddrStr := ethTokenBridgeAddrStr
+		if from == vaa.ChainIDSolana {
+			emitterAddrStr = solTokenBridgeAddrStr
+		}
+		emitterAddr, err := vaa.StringToAddress(emitterAddrStr)
+		require.NoError(t, err)
+
+		sequence++
+		msg := common.MessagePublication{
+			TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:        now,
+			Nonce:            uint32(1),
+			Sequence:         sequence,
+			EmitterChain:     from,
+			EmitterAddress:   emitterAddr,
+			ConsistencyLevel: uint8(32),
+			Payload:          buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, token, to, toAddrStr, amount),
+		}
+
+		canPost, err := gov.ProcessMsgForTime(&msg, now)
+		require.NoError(t, err)
+		return canPost
+	}
+
+	netValue := func(gov *ChainGovernor, chain vaa.ChainID, now time.Time) uint64 {
+		startTime := now.Add(-time.Minute * time.Duration(gov.dayLengthInMinutes))
+		return gov.chains[chain].netValue(startTime)
+	}
+
+	now, err := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	require.NoError(t, err)
+
+	// Without flow cancel, an inbound transfer does not free outbound capacity.
+	gov := newGovernor(false)
+	assert.True(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDSolana, tokenAddrStr, 8000, now))
+	assert.True(t, transfer(gov, vaa.ChainIDSolana, vaa.ChainIDEthereum, tokenAddrStr, 5000, now))
+	assert.False(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDSolana, tokenAddrStr, 6000, now))
+
+	gov = newGovernor(true)
+	assert.True(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDSolana, tokenAddrStr, 8000, now))
+	assert.Equal(t, uint64(8000), netValue(gov, vaa.ChainIDEthereum, now))
+	assert.Equal(t, uint64(0), netValue(gov, vaa.ChainIDSolana, now))
+	assert.Equal(t, 1, len(gov.chains[vaa.ChainIDSolana].flowCancelTransfers))
+
+	// The inbound transfer frees outbound capacity of Ethereum, but Solana is not credited beyond zero.
+	assert.True(t, transfer(gov, vaa.ChainIDSolana, vaa.ChainIDEthereum, tokenAddrStr, 5000, now))
+	assert.Equal(t, uint64(3000), netValue(gov, vaa.ChainIDEthereum, now))
+	assert.Equal(t, uint64(0), netValue(gov, vaa.ChainIDSolana, now))
+	assert.True(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDSolana, tokenAddrStr, 6000, now))
+	assert.Equal(t, uint64(9000), netValue(gov, vaa.ChainIDEthereum, now))
+
+	// Tokens which are not eligible do not free outbound capacity.
+	assert.True(t, transfer(gov, vaa.ChainIDSolana, vaa.ChainIDEthereum, otherTokenAddrStr, 5000, now))
+	assert.Equal(t, uint64(9000), netValue(gov, vaa.ChainIDEthereum, now))
+	assert.False(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDSolana, tokenAddrStr, 2000, now))
+
+	// The freed capacity expires with the inbound transfer.
+	later := now.Add(23 * time.Hour)
+	assert.True(t, transfer(gov, vaa.ChainIDSolana, vaa.ChainIDEthereum, tokenAddrStr, 1000, later))
+	later = now.Add(25 * time.Hour)
+	assert.True(t, transfer(gov, vaa.ChainIDEthereum, vaa.ChainIDPolygon, tokenAddrStr, 500, later))
+	assert.Equal(t, 1, len(gov.chains[vaa.ChainIDEthereum].flowCancelTransfers))
+	assert.Equal(t, uint64(0), netValue(gov, vaa.ChainIDEthereum, later))
+}
+
+func TestSimulateTransfer(t *testing.T) {
+	ctx := context.Background()
+	gov, err := newChainGovernorForTest(ctx)
+	require.NoError(t, err)
+
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	tokenBridgeAddr, err := vaa.StringToAddress(tokenBridgeAddrStr)
+	require.NoError(t, err)
+	tokenAddr, err := vaa.StringToAddress(tokenAddrStr)
+	require.NoError(t, err)
+
+	gov.setDayLengthInMinutes(24 * 60)
+	require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 10000, 5000))
+	require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "USDC", 1))
+
+	now, _ := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	for i, transfer := range []struct {
+		amount float64
+		time   time.Time
+	}{
+		{3000, now.Add(-20 * time.Hour)},
+		{4000, now.Add(-10 * time.Hour)},
+	} {
+		msg := common.MessagePublication{
+			TxHash:         hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+			Timestamp:      transfer.time,
+			Sequence:       uint64(i),
+			EmitterChain:   vaa.ChainIDEthereum,
+			EmitterAddress: tokenBridgeAddr,
+			Payload:        buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, transfer.amount),
+		}
+		canPost, err := gov.ProcessMsgForTime(&msg, transfer.time)
+		require.NoError(t, err)
+		require.True(t, canPost)
+	}
+
+	simulate := func(emitterChain vaa.ChainID, originAddr vaa.Address, amount int64) *publicrpcv1.GovernorSimulateTransferResponse {
+		resp, err := gov.simulateTransferForTime(emitterChain, vaa.ChainIDEthereum, originAddr, big.NewInt(amount*100000000), now)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := simulate(vaa.ChainIDEthereum, tokenAddr, 2000)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_RELEASED, resp.Outcome)
+	assert.Equal(t, uint64(2000), resp.NotionalValue)
+	assert.Equal(t, uint64(3000), resp.RemainingAvailableNotional)
+	assert.Zero(t, resp.EstimatedReleaseTime)
+
+	// It fits once the first transfer is out of the window.
+	resp = simulate(vaa.ChainIDEthereum, tokenAddr, 4000)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_DAILY_LIMIT, resp.Outcome)
+	assert.Equal(t, uint32(now.Add(4*time.Hour).Unix()), resp.EstimatedReleaseTime)
+
+	// Nothing is recorded.
+	numTrans, valueTrans, numPending, _ := gov.getStatsForAllChains()
+	assert.Equal(t, 2, numTrans)
+	assert.Equal(t, uint64(7000), valueTrans)
+	assert.Equal(t, 0, numPending)
+
+	// With a lower limit, it only fits once both transfers are out of the window.
+	transfers := gov.chains[vaa.ChainIDEthereum].transfers
+	require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 8000, 0))
+	gov.chains[vaa.ChainIDEthereum].transfers = transfers
+	resp = simulate(vaa.ChainIDEthereum, tokenAddr, 6000)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_DAILY_LIMIT, resp.Outcome)
+	assert.Equal(t, uint32(now.Add(14*time.Hour).Unix()), resp.EstimatedReleaseTime)
+
+	// Transfers over the daily limit are only released when the maximum delay expires.
+	resp = simulate(vaa.ChainIDEthereum, tokenAddr, 9000)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_DAILY_LIMIT, resp.Outcome)
+	assert.Equal(t, uint32(now.Add(maxEnqueuedTime).Unix()), resp.EstimatedReleaseTime)
+
+	require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 10000, 5000))
+	resp = simulate(vaa.ChainIDEthereum, tokenAddr, 5000)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_DELAYED_BIG_TRANSACTION, resp.Outcome)
+	assert.Equal(t, uint32(now.Add(maxEnqueuedTime).Unix()), resp.EstimatedReleaseTime)
+
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_NOT_GOVERNED, simulate(vaa.ChainID(60000), tokenAddr, 1).Outcome)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_NOT_GOVERNED, simulate(vaa.ChainIDEthereum, vaa.Address{1}, 1).Outcome)
+}
+
+func TestEmergencyHold(t *testing.T) {
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	tokenBridgeAddr, err := vaa.StringToAddress(tokenBridgeAddrStr)
+	require.NoError(t, err)
+
+	gov, err := newChainGovernorForTest(context.Background())
+	require.NoError(t, err)
+	gov.setDayLengthInMinutes(24 * 60)
+	require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 100000))
+	require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "USDC", 1))
+	guardian1 := eth_common.HexToAddress("0x1")
+	guardian2 := eth_common.HexToAddress("0x2")
+	guardian3 := eth_common.HexToAddress("0x3")
+	gst := common.NewGuardianSetState()
+	gst.Set(&common.GuardianSet{Keys: []eth_common.Address{guardian1, guardian2, guardian3}, Index: 0})
+	require.NoError(t, gov.EnableEmergencyHold(500000, 2, gst))
+
+	msg := common.MessagePublication{
+		TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+		Timestamp:        time.Unix(int64(1654543099), 0),
+		Nonce:            uint32(1),
+		Sequence:         uint64(1),
+		EmitterChain:     vaa.ChainIDEthereum,
+		EmitterAddress:   tokenBridgeAddr,
+		ConsistencyLevel: uint8(32),
+		Payload:          buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 600000),
+	}
+	msgId := msg.MessageIDString()
+
+	now, err := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	require.NoError(t, err)
+
+	// The simulation reports the transfer as held, with no release time.
+	tokenAddr, err := vaa.StringToAddress(tokenAddrStr)
+	require.NoError(t, err)
+	resp, err := gov.simulateTransferForTime(vaa.ChainIDEthereum, vaa.ChainIDEthereum, tokenAddr, big.NewInt(600000*100000000), now)
+	require.NoError(t, err)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_HELD_EMERGENCY, resp.Outcome)
+	assert.Zero(t, resp.EstimatedReleaseTime)
+
+	canPost, err := gov.ProcessMsgForTime(&msg, now)
+	require.NoError(t, err)
+	assert.False(t, canPost)
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDUnset)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].EmergencyHold)
+	assert.Equal(t, uint32(2), entries[0].EmergencyReleaseQuorum)
+
+	// A single operator can't release it, and neither can the release timer.
+	_, err = gov.ReleasePendingVAA(msgId)
+	assert.Error(t, err)
+	later := now.Add(maxEnqueuedTime + time.Hour)
+	toBePublished, err := gov.CheckPendingForTime(later)
+	require.NoError(t, err)
+	assert.Empty(t, toBePublished)
+
+	// Only the admin command of this node adds its own vote to the gossip.
+	assert.Empty(t, gov.OwnEmergencyReleases())
+	_, err = gov.EmergencyRelease("2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/2")
+	assert.Error(t, err)
+	_, err = gov.EmergencyRelease(msgId)
+	require.NoError(t, err)
+	assert.Equal(t, []string{msgId}, gov.OwnEmergencyReleases())
+
+	// The vote of a guardian removed from the guardian set since it voted is not counted.
+	gov.recordEmergencyReleaseForTime(msgId, guardian3, later)
+	gst.Set(&common.GuardianSet{Keys: []eth_common.Address{guardian1, guardian2}, Index: 1})
+
+	// Repeated votes of the same guardian are counted once.
+	gov.recordEmergencyReleaseForTime(msgId, guardian1, later)
+	gov.recordEmergencyReleaseForTime(msgId, guardian1, later)
+	toBePublished, err = gov.CheckPendingForTime(later)
+	require.NoError(t, err)
+	assert.Empty(t, toBePublished)
+
+	gov.recordEmergencyReleaseForTime(msgId, guardian2, later)
+	toBePublished, err = gov.CheckPendingForTime(later)
+	require.NoError(t, err)
+	require.Len(t, toBePublished, 1)
+	assert.Equal(t, msgId, toBePublished[0].MessageIDString())
+	assert.Empty(t, gov.OwnEmergencyReleases())
+
+	// Released transfers don't count towards the daily limit.
+	numTrans, _, numPending, _ := gov.getStatsForAllChains()
+	assert.Equal(t, 0, numTrans)
+	assert.Equal(t, 0, numPending)
+
+	// Votes for transfers which were never held are eventually forgotten.
+	gov.recordEmergencyReleaseForTime("2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/3", guardian1, later)
+	_, err = gov.CheckPendingForTime(later.Add(maxEnqueuedTime + time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, gov.emergencyReleases)
+}
+
+func TestEmergencyHoldSurvivesReload(t *testing.T) {
+	tokenAddrStr := "0xDDb64fE46a91D46ee29420539FC25FD07c5FEa3E" //nolint:gosec
+	toAddrStr := "0x707f9118e33a9b8998bea41dd0d46f38bb963fc8"
+	tokenBridgeAddrStr := "0x0290fb167208af455bb137780163b7b7a9a10c16" //nolint:gosec
+	tokenBridgeAddr, err := vaa.StringToAddress(tokenBridgeAddrStr)
+	require.NoError(t, err)
+
+	newGovernor := func(price float64) *ChainGovernor {
+		gov, err := newChainGovernorForTest(context.Background())
+		require.NoError(t, err)
+		gov.setDayLengthInMinutes(24 * 60)
+		require.NoError(t, gov.setChainForTesting(vaa.ChainIDEthereum, tokenBridgeAddrStr, 1000000, 100000))
+		require.NoError(t, gov.setTokenForTesting(vaa.ChainIDEthereum, tokenAddrStr, "USDC", price))
+		gst := common.NewGuardianSetState()
+		gst.Set(&common.GuardianSet{Keys: []eth_common.Address{eth_common.HexToAddress("0x1"), eth_common.HexToAddress("0x2")}})
+		require.NoError(t, gov.EnableEmergencyHold(500000, 2, gst))
+		return gov
+	}
+
+	msg := common.MessagePublication{
+		TxHash:           hashFromString("0x06f541f5ecfc43407c31587aa6ac3a689e8960f36dc23c332db5510dfc6a4063"),
+		Timestamp:        time.Unix(int64(1654543099), 0),
+		Nonce:            uint32(1),
+		Sequence:         uint64(1),
+		EmitterChain:     vaa.ChainIDEthereum,
+		EmitterAddress:   tokenBridgeAddr,
+		ConsistencyLevel: uint8(32),
+		Payload:          buildMockTransferPayloadBytes(1, vaa.ChainIDEthereum, tokenAddrStr, vaa.ChainIDPolygon, toAddrStr, 600000),
+	}
+
+	gov := newGovernor(1)
+	now, err := time.Parse("Jan 2, 2006 at 3:04pm (MST)", "Jun 1, 2022 at 12:00pm (CST)")
+	require.NoError(t, err)
+	canPost, err := gov.ProcessMsgForTime(&msg, now)
+	require.NoError(t, err)
+	assert.False(t, canPost)
+	require.Len(t, gov.chains[vaa.ChainIDEthereum].pending, 1)
+	stored := gov.chains[vaa.ChainIDEthereum].pending[0].dbData
+	assert.True(t, stored.EmergencyHold)
+
+	// After a restart, the price dropped below the emergency threshold, but the transfer is still held past its release time.
+	gov = newGovernor(0.5)
+	gov.mutex.Lock()
+	gov.reloadPendingTransfer(&stored, now)
+	gov.mutex.Unlock()
+
+	toBePublished, err := gov.CheckPendingForTime(now.Add(maxEnqueuedTime + time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, toBePublished)
+
+	entries, err := gov.ListPendingVAAs(vaa.ChainIDUnset)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].EmergencyHold)
+}
@@ -0,0 +1,166 @@
+// This file contains the code to maintain the usage history of the chain governor.
+//
+// The governor only needs the transfers from the last 24 hours to enforce the daily limit. To allow the limits to be tuned
+// based on actual data, the value of every governed transfer that gets published is also accumulated into hourly buckets per
+// emitter chain. These buckets are persisted in the database and retained for 30 days. This includes transfers that were
+// released by the release timer or by admin command, even though those do not count towards the daily limit.
+
+package governor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+
+	"go.uber.org/zap"
+)
+
+const usageRetention = 30 * 24 * time.Hour
+
+// Adds the value of a published transfer to the usage history of the chain. Assumes the lock is held.
+func (gov *ChainGovernor) recordUsage(ce *chainEntry, value uint64, now time.Time) {
+	hour := now.UTC().Truncate(time.Hour)
+
+	var u *db.GovernorUsage
+	if len(ce.usage) != 0 && ce.usage[len(ce.usage)-1].Hour.Equal(hour) {
+		u = ce.usage[len(ce.usage)-1]
+	} else {
+		u = &db.GovernorUsage{EmitterChain: ce.emitterChainId, Hour: hour}
+		ce.usage = append(ce.usage, u)
+		gov.trimUsage(ce, now)
+	}
+
+	u.Value += value
+	u.Count++
+
+	// Failing to update the history should not prevent the transfer from being published.
+	if err := gov.db.StoreUsage(u); err != nil {
+		gov.logger.Error("cgov: failed to store usage", zap.Stringer("emitterChain", ce.emitterChainId), zap.Stringer("hour", hour), zap.Error(err))
+	}
+}
+
+// Removes the usage that is past the retention period. Assumes the lock is held.
+func (gov *ChainGovernor) trimUsage(ce *chainEntry, now time.Time) {
+	startTime := now.Add(-usageRetention)
+	trimIdx := -1
+	for idx, u := range ce.usage {
+		if !u.Hour.Before(startTime) {
+			break
+		}
+
+		if err := gov.db.DeleteUsage(u); err != nil {
+			gov.logger.Error("cgov: failed to delete usage", zap.Stringer("emitterChain", ce.emitterChainId), zap.Stringer("hour", u.Hour), zap.Error(err))
+		}
+		trimIdx = idx
+	}
+
+	if trimIdx >= 0 {
+		ce.usage = ce.usage[trimIdx+1:]
+	}
+}
+
+// Loads the usage history from the database. Assumes the lock is held.
+func (gov *ChainGovernor) loadUsageFromDBAlreadyLocked(now time.Time) error {
+	usage, err := gov.db.GetChainGovernorUsage()
+	if err != nil {
+		gov.logger.Error("cgov: failed to reload usage from db", zap.Error(err))
+		return err
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].Hour.Before(usage[j].Hour)
+	})
+
+	for _, u := range usage {
+		ce, exists := gov.chains[u.EmitterChain]
+		if !exists {
+			gov.logger.Info("cgov: ignoring reloaded usage for unsupported chain", zap.Stringer("emitterChain", u.EmitterChain), zap.Stringer("hour", u.Hour))
+			continue
+		}
+
+		ce.usage = append(ce.usage, u)
+	}
+
+	for _, ce := range gov.chains {
+		gov.trimUsage(ce, now)
+	}
+
+	return nil
+}
+
+// Returns the total value and number of transfers since the start time.
+func sumUsage(usage []*db.GovernorUsage, startTime time.Time) (value uint64, count uint64) {
+	for _, u := range usage {
+		if !u.Hour.Before(startTime) {
+			value += u.Value
+			count += uint64(u.Count)
+		}
+	}
+
+	return
+}
+
+// REST query to get the usage history per chain.
+func (gov *ChainGovernor) GetUsageHistory() []*publicrpcv1.GovernorGetUsageHistoryResponse_Entry {
+	return gov.getUsageHistoryForTime(time.Now())
+}
+
+func (gov *ChainGovernor) getUsageHistoryForTime(now time.Time) []*publicrpcv1.GovernorGetUsageHistoryResponse_Entry {
+	gov.mutex.Lock()
+	defer gov.mutex.Unlock()
+
+	// The windows include the current (partial) hour, so they are aligned on the hour.
+	hour := now.UTC().Truncate(time.Hour)
+	startTime1d := hour.Add(-23 * time.Hour)
+	startTime7d := hour.Add(-(7*24 - 1) * time.Hour)
+	startTime30d := hour.Add(-(30*24 - 1) * time.Hour)
+
+	resp := make([]*publicrpcv1.GovernorGetUsageHistoryResponse_Entry, 0)
+	for _, ce := range gov.chains {
+		entry := &publicrpcv1.GovernorGetUsageHistoryResponse_Entry{
+			ChainId:       uint32(ce.emitterChainId),
+			NotionalLimit: ce.dailyLimit,
+		}
+
+		entry.DayNotional, entry.DayCount = sumUsage(ce.usage, startTime1d)
+		entry.WeekNotional, entry.WeekCount = sumUsage(ce.usage, startTime7d)
+		entry.MonthNotional, entry.MonthCount = sumUsage(ce.usage, startTime30d)
+
+		// The peak is the largest value published in any 24 hour window over the last 30 days, using hourly resolution.
+		entry.PeakDailyNotional = peakDailyUsage(ce.usage, startTime30d)
+
+		resp = append(resp, entry)
+	}
+
+	sort.SliceStable(resp, func(i, j int) bool {
+		return (resp[i].ChainId < resp[j].ChainId)
+	})
+
+	return resp
+}
+
+// Returns the largest sum of usage over any 24 hour window starting on or after the start time.
+func peakDailyUsage(usage []*db.GovernorUsage, startTime time.Time) uint64 {
+	var peak, sum uint64
+	first := 0
+	for _, u := range usage {
+		if u.Hour.Before(startTime) {
+			first++
+			continue
+		}
+
+		sum += u.Value
+		for !usage[first].Hour.Add(24 * time.Hour).After(u.Hour) {
+			sum -= usage[first].Value
+			first++
+		}
+
+		if sum > peak {
+			peak = sum
+		}
+	}
+
+	return peak
+}
@@ -5,9 +5,11 @@ import (
 	"testing"
 
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
 
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestTokenListSize(t *testing.T) {
@@ -105,3 +107,13 @@ func TestTokenListEmptyCoinGeckoId(t *testing.T) {
 		assert.Greater(t, len(tokenConfigEntry.coinGeckoId), 0)
 	}
 }
+
+func TestFlowCancelTokenListIsGoverned(t *testing.T) {
+	var db db.MockGovernorDB
+	gov := NewChainGovernor(zap.NewNop(), &db, MainNetMode)
+	gov.EnableFlowCancel()
+
+	/* Flow cancel tokens must be priced by the token list */
+	assert.NoError(t, gov.initConfig())
+	assert.Equal(t, len(flowCancelTokenList()), len(gov.flowCancelTokens))
+}
@@ -0,0 +1,309 @@
+package guardiansigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Rules of the signing anomaly detector.
+const (
+	// An observation was signed for a chain the node doesn't watch.
+	AnomalyUnwatchedChain = "unwatched_chain"
+	// More signatures than expected were made in the last hour.
+	AnomalyRateSpike = "rate_spike"
+	// A governance VAA was signed outside of the announced governance windows.
+	AnomalyGovernanceOutsideWindow = "governance_outside_window"
+)
+
+const (
+	anomalyWebhookTimeout = 10 * time.Second
+	// Alerts waiting for the sinks. Alerts are dropped rather than delaying signatures when the sinks are slow.
+	anomalyQueueSize = 100
+)
+
+var signingAnomalies = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wormhole_guardian_signer_anomalies_total",
+		Help: "Total number of unexpected signing patterns detected in the signing audit log",
+	}, []string{"rule"})
+
+// AnomalyConfig is the configuration file of the signing anomaly detector:
+//
+//	{
+//	  "maxSignaturesPerHour": 5000,
+//	  "quietHours": {"startHour": 22, "endHour": 6, "maxSignaturesPerHour": 500},
+//	  "governanceWindows": [{"start": "2026-10-20T14:00:00Z", "end": "2026-10-20T18:00:00Z"}],
+//	  "sinks": [{"type": "webhook", "url": "https://alerts.example.com/guardian"}]
+//	}
+type AnomalyConfig struct {
+	// Signatures per hour above which a rate spike is reported, heartbeats excluded. 0 disables the rule.
+	MaxSignaturesPerHour int `json:"maxSignaturesPerHour"`
+	// Hours of the day with a lower threshold, optional.
+	QuietHours *QuietHours `json:"quietHours"`
+	// Governance VAAs signed outside of these windows are reported. Without windows, every governance VAA is reported.
+	GovernanceWindows []TimeWindow      `json:"governanceWindows"`
+	Sinks             []AlertSinkConfig `json:"sinks"`
+}
+
+// QuietHours are the hours of the day, in UTC, from StartHour included to EndHour excluded. They wrap around midnight
+// if EndHour is before StartHour.
+type QuietHours struct {
+	StartHour            int `json:"startHour"`
+	EndHour              int `json:"endHour"`
+	MaxSignaturesPerHour int `json:"maxSignaturesPerHour"`
+}
+
+func (q *QuietHours) contains(t time.Time) bool {
+	h := t.UTC().Hour()
+	if q.StartHour <= q.EndHour {
+		return h >= q.StartHour && h < q.EndHour
+	}
+	return h >= q.StartHour || h < q.EndHour
+}
+
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type AlertSinkConfig struct {
+	// Only webhook for now, which POSTs each anomaly as JSON to URL.
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// LoadAnomalyConfig reads and validates the configuration file of the signing anomaly detector at path.
+func LoadAnomalyConfig(path string) (*AnomalyConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing anomaly config: %w", err)
+	}
+
+	var c AnomalyConfig
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse signing anomaly config: %w", err)
+	}
+
+	if c.MaxSignaturesPerHour < 0 {
+		return nil, fmt.Errorf("maxSignaturesPerHour must not be negative")
+	}
+	if q := c.QuietHours; q != nil {
+		if q.StartHour < 0 || q.StartHour > 23 || q.EndHour < 0 || q.EndHour > 23 || q.StartHour == q.EndHour {
+			return nil, fmt.Errorf("quiet hours %d to %d are invalid", q.StartHour, q.EndHour)
+		}
+		if q.MaxSignaturesPerHour <= 0 {
+			return nil, fmt.Errorf("maxSignaturesPerHour of the quiet hours must be positive")
+		}
+	}
+	for i, w := range c.GovernanceWindows {
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("governance window %d ends before it starts", i)
+		}
+	}
+	for i, s := range c.Sinks {
+		switch s.Type {
+		case "webhook":
+			if s.URL == "" {
+				return nil, fmt.Errorf("sink %d: url must be set for webhook sinks", i)
+			}
+		default:
+			return nil, fmt.Errorf("sink %d: unknown type %q", i, s.Type)
+		}
+	}
+	return &c, nil
+}
+
+// Anomaly is an unexpected signing pattern.
+type Anomaly struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+	// The entry of the signature which triggered the rule.
+	Entry AuditEntry `json:"entry"`
+}
+
+// AlertSink is notified of the anomalies.
+type AlertSink interface {
+	SigningAnomaly(a Anomaly) error
+}
+
+type webhookSink struct {
+	client *http.Client
+	url    string
+}
+
+func (s *webhookSink) SigningAnomaly(a Anomaly) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// AnomalyDetector watches the signing audit log for unexpected signing patterns, which might be the sign of a
+// compromised key or host: observations of chains the node doesn't watch, spikes of the signing rate, and governance
+// VAAs signed outside of the announced windows. Anomalies are logged, counted and sent to the alert sinks. They don't
+// prevent signing.
+type AnomalyDetector struct {
+	logger  *zap.Logger
+	cfg     *AnomalyConfig
+	watched map[uint16]bool
+	alertC  chan Anomaly
+
+	mu    sync.Mutex
+	sinks []AlertSink
+	// Times of the signatures of the last hour, heartbeats excluded.
+	recent []time.Time
+	// Whether the current rate spike was reported already.
+	spiking bool
+}
+
+// NewAnomalyDetector returns a detector for a node watching the chains watched. Call Observe with the entries of the
+// audit log, and run Run to deliver the alerts.
+func NewAnomalyDetector(logger *zap.Logger, cfg *AnomalyConfig, watched []uint16) *AnomalyDetector {
+	d := &AnomalyDetector{
+		logger:  logger,
+		cfg:     cfg,
+		watched: make(map[uint16]bool),
+		alertC:  make(chan Anomaly, anomalyQueueSize),
+	}
+	for _, c := range watched {
+		d.watched[c] = true
+	}
+	for _, s := range cfg.Sinks {
+		d.sinks = append(d.sinks, &webhookSink{client: &http.Client{Timeout: anomalyWebhookTimeout}, url: s.URL})
+	}
+	return d
+}
+
+// AddSink adds a sink to the sinks of the configuration.
+func (d *AnomalyDetector) AddSink(s AlertSink) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sinks = append(d.sinks, s)
+}
+
+// Observe checks the entry of a signature against the rules. It doesn't block, so it can be passed to
+// AuditLog.OnAppend.
+func (d *AnomalyDetector) Observe(e AuditEntry) {
+	for _, a := range d.check(e) {
+		signingAnomalies.WithLabelValues(a.Rule).Inc()
+		d.logger.Warn("unexpected signing pattern",
+			zap.String("rule", a.Rule),
+			zap.String("detail", a.Detail),
+			zap.Uint64("index", e.Index),
+			zap.String("digest", e.Digest))
+
+		select {
+		case d.alertC <- a:
+		default:
+			d.logger.Error("signing anomaly alert queue is full, dropping alert", zap.String("rule", a.Rule))
+		}
+	}
+}
+
+func (d *AnomalyDetector) check(e AuditEntry) []Anomaly {
+	var res []Anomaly
+
+	switch e.Type {
+	case AuditTypeObservation:
+		if !d.watched[e.EmitterChain] {
+			res = append(res, Anomaly{
+				Rule:   AnomalyUnwatchedChain,
+				Detail: fmt.Sprintf("signed an observation of chain %d, which is not watched", e.EmitterChain),
+				Entry:  e,
+			})
+		}
+	case AuditTypeInjectedVAA:
+		if !d.inGovernanceWindow(e.Timestamp) {
+			res = append(res, Anomaly{
+				Rule:   AnomalyGovernanceOutsideWindow,
+				Detail: "signed a governance VAA outside of the announced governance windows",
+				Entry:  e,
+			})
+		}
+	}
+
+	// Heartbeats are signed at a fixed interval, so they would only dilute the rate.
+	if e.Type == AuditTypeHeartbeat {
+		return res
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.recent = append(d.recent, e.Timestamp)
+	start := e.Timestamp.Add(-time.Hour)
+	i := 0
+	for i < len(d.recent) && !d.recent[i].After(start) {
+		i++
+	}
+	d.recent = d.recent[i:]
+
+	max := d.cfg.MaxSignaturesPerHour
+	quiet := d.cfg.QuietHours != nil && d.cfg.QuietHours.contains(e.Timestamp)
+	if quiet {
+		max = d.cfg.QuietHours.MaxSignaturesPerHour
+	}
+	if max == 0 || len(d.recent) <= max {
+		d.spiking = false
+		return res
+	}
+	// Report each spike once, until the rate goes back under the threshold.
+	if !d.spiking {
+		d.spiking = true
+		detail := fmt.Sprintf("made %d signatures in the last hour, more than the maximum of %d", len(d.recent), max)
+		if quiet {
+			detail += " during quiet hours"
+		}
+		res = append(res, Anomaly{Rule: AnomalyRateSpike, Detail: detail, Entry: e})
+	}
+	return res
+}
+
+func (d *AnomalyDetector) inGovernanceWindow(t time.Time) bool {
+	for _, w := range d.cfg.GovernanceWindows {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run delivers the alerts to the sinks.
+func (d *AnomalyDetector) Run(ctx context.Context) error {
+	supervisor.Signal(ctx, supervisor.SignalHealthy)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case a := <-d.alertC:
+			d.mu.Lock()
+			sinks := d.sinks
+			d.mu.Unlock()
+			for _, s := range sinks {
+				if err := s.SigningAnomaly(a); err != nil {
+					d.logger.Error("failed to send signing anomaly alert", zap.String("rule", a.Rule), zap.Error(err))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package guardiansigner
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func rules(anomalies []Anomaly) []string {
+	var res []string
+	for _, a := range anomalies {
+		res = append(res, a.Rule)
+	}
+	return res
+}
+
+func TestAnomalyRules(t *testing.T) {
+	windowStart := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	d := NewAnomalyDetector(zap.NewNop(), &AnomalyConfig{
+		MaxSignaturesPerHour: 3,
+		QuietHours:           &QuietHours{StartHour: 22, EndHour: 6, MaxSignaturesPerHour: 1},
+		GovernanceWindows:    []TimeWindow{{Start: windowStart, End: windowStart.Add(4 * time.Hour)}},
+	}, []uint16{1, 2})
+
+	noon := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	entry := func(typ string, chain uint16, t time.Time) AuditEntry {
+		return AuditEntry{Type: typ, EmitterChain: chain, Timestamp: t}
+	}
+
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 2, noon)))
+	assert.Equal(t, []string{AnomalyUnwatchedChain}, rules(d.check(entry(AuditTypeObservation, 5, noon.Add(time.Minute)))))
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 1, noon.Add(2*time.Minute))))
+	// Heartbeats don't count towards the rate.
+	assert.Empty(t, d.check(entry(AuditTypeHeartbeat, 0, noon.Add(3*time.Minute))))
+
+	// The 4th signature within an hour is reported, once.
+	assert.Equal(t, []string{AnomalyRateSpike}, rules(d.check(entry(AuditTypeObservation, 1, noon.Add(4*time.Minute)))))
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 1, noon.Add(5*time.Minute))))
+
+	// Once the earlier signatures are more than an hour old, the rate is back to normal.
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 1, noon.Add(64*time.Minute))))
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 1, noon.Add(80*time.Minute))))
+
+	// Governance VAAs are only expected within the announced windows.
+	assert.Empty(t, d.check(entry(AuditTypeInjectedVAA, 0, windowStart.Add(time.Hour))))
+	assert.Equal(t, []string{AnomalyGovernanceOutsideWindow}, rules(d.check(entry(AuditTypeInjectedVAA, 0, windowStart.Add(5*time.Hour)))))
+
+	// The threshold is lower at night, and the quiet hours wrap around midnight.
+	night := time.Date(2026, 10, 21, 2, 0, 0, 0, time.UTC)
+	assert.Empty(t, d.check(entry(AuditTypeObservation, 1, night)))
+	assert.Equal(t, []string{AnomalyRateSpike}, rules(d.check(entry(AuditTypeObservation, 1, night.Add(time.Minute)))))
+}
+
+func TestLoadAnomalyConfig(t *testing.T) {
+	dir := t.TempDir()
+	load := func(config string) (*AnomalyConfig, error) {
+		path := filepath.Join(dir, "anomalies.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(config), 0600))
+		return LoadAnomalyConfig(path)
+	}
+
+	c, err := load(`{"maxSignaturesPerHour": 5000, "quietHours": {"startHour": 22, "endHour": 6, "maxSignaturesPerHour": 500},
+		"governanceWindows": [{"start": "2026-10-20T14:00:00Z", "end": "2026-10-20T18:00:00Z"}],
+		"sinks": [{"type": "webhook", "url": "https://alerts.example.com/guardian"}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, 500, c.QuietHours.MaxSignaturesPerHour)
+	assert.Len(t, c.GovernanceWindows, 1)
+
+	for _, config := range []string{
+		`{"maxSignaturesPerHour": -1}`,
+		`{"quietHours": {"startHour": 22, "endHour": 24, "maxSignaturesPerHour": 500}}`,
+		`{"quietHours": {"startHour": 22, "endHour": 6}}`,
+		`{"governanceWindows": [{"start": "2026-10-20T18:00:00Z", "end": "2026-10-20T14:00:00Z"}]}`,
+		`{"sinks": [{"type": "webhook"}]}`,
+		`{"sinks": [{"type": "email"}]}`,
+	} {
+		_, err := load(config)
+		assert.Error(t, err, config)
+	}
+}
+
+func TestAnomalyWebhookSink(t *testing.T) {
+	received := make(chan Anomaly, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Anomaly
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		received <- a
+	}))
+	defer srv.Close()
+
+	l, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	d := NewAnomalyDetector(zap.NewNop(), &AnomalyConfig{Sinks: []AlertSinkConfig{{Type: "webhook", URL: srv.URL}}}, []uint16{2})
+	l.OnAppend(d.Observe)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	supervisor.New(ctx, zap.NewNop(), d.Run)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewAuditedSigner(NewLocalSigner(key), l)
+	_, err = s.Sign(WithAuditInfo(context.Background(), AuditInfo{Type: AuditTypeObservation, EmitterChain: 7, Sequence: 1}), testDigest)
+	require.NoError(t, err)
+
+	select {
+	case a := <-received:
+		assert.Equal(t, AnomalyUnwatchedChain, a.Rule)
+		assert.Equal(t, uint16(7), a.Entry.EmitterChain)
+		assert.Equal(t, uint64(0), a.Entry.Index)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert received")
+	}
+}
@@ -0,0 +1,282 @@
+package guardiansigner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of the messages signed with the guardian key, as recorded in the audit log.
+const (
+	AuditTypeObservation         = "observation"
+	AuditTypeInjectedVAA         = "injected_vaa"
+	AuditTypeHeartbeat           = "heartbeat"
+	AuditTypeObservationRequest  = "observation_request"
+	AuditTypeGossipKeyDelegation = "gossip_key_delegation"
+	AuditTypeGovernorRelease     = "governor_release"
+	AuditTypeUnknown             = "unknown"
+)
+
+// auditGenesisHash is the previous hash of the first entry of an audit log.
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// AuditInfo describes what a digest is the digest of.
+type AuditInfo struct {
+	Type           string
+	EmitterChain   uint16
+	EmitterAddress string
+	Sequence       uint64
+}
+
+type auditInfoKey struct{}
+
+// WithAuditInfo returns a context which describes the digest signed with it in the audit log.
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+// AuditEntry is an entry of the signing audit log, stored as a line of JSON. Each entry includes the hash of the
+// previous entry, so entries can't be removed or changed without breaking the chain.
+type AuditEntry struct {
+	Index          uint64    `json:"index"`
+	Timestamp      time.Time `json:"timestamp"`
+	Signer         string    `json:"signer"`
+	Digest         string    `json:"digest"`
+	Type           string    `json:"type"`
+	EmitterChain   uint16    `json:"emitterChain,omitempty"`
+	EmitterAddress string    `json:"emitterAddress,omitempty"`
+	Sequence       uint64    `json:"sequence,omitempty"`
+	PrevHash       string    `json:"prevHash"`
+	// SHA-256 of the JSON encoding of the entry with an empty Hash.
+	Hash string `json:"hash"`
+}
+
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// AuditLog is an append-only, hash-chained log of the signatures made with the guardian key.
+type AuditLog struct {
+	mu       sync.Mutex
+	f        *os.File
+	next     uint64
+	lastHash string
+	// Size of the complete entries in the file.
+	size int64
+	// Size of the partial entry removed from the end of the file when it was opened.
+	truncated int64
+	// Called with each appended entry.
+	observers []func(AuditEntry)
+}
+
+// OpenAuditLog opens the audit log at path, creating it if it doesn't exist. A partial entry at the end of the file, left
+// by a crash while it was written, is removed (see Truncated), and the remaining entries are verified.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing audit log: %w", err)
+	}
+
+	truncated, err := truncatePartialEntry(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to remove the partial entry of signing audit log %s: %w", path, err)
+	}
+
+	res, err := VerifyAuditLog(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("signing audit log %s is invalid: %w", path, err)
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &AuditLog{f: f, next: res.Entries, lastHash: res.LastHash, size: size, truncated: truncated}, nil
+}
+
+// truncatePartialEntry removes the bytes after the last newline of the file, which are the beginning of an entry whose
+// write was interrupted. Since signatures are only used once their entry is synced, the entry is of a signature which
+// was discarded. It returns the number of bytes removed.
+func truncatePartialEntry(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for pos := end; pos > 0; {
+		n := int64(len(buf))
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return truncateTo(f, end, pos+int64(i)+1)
+		}
+	}
+	return truncateTo(f, end, 0)
+}
+
+func truncateTo(f *os.File, size int64, to int64) (int64, error) {
+	if to == size {
+		return 0, nil
+	}
+	if err := f.Truncate(to); err != nil {
+		return 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	return size - to, nil
+}
+
+// Truncated returns the size of the partial entry removed from the end of the log when it was opened, or zero.
+func (l *AuditLog) Truncated() int64 {
+	return l.truncated
+}
+
+// OnAppend registers f to be called with each entry appended to the log. f is called in the order of the entries, with
+// the log locked, so it must not block.
+func (l *AuditLog) OnAppend(f func(AuditEntry)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers, f)
+}
+
+func (l *AuditLog) Close() error {
+	return l.f.Close()
+}
+
+// append writes an entry for a signature and syncs it to disk. The entries are written one at a time, so every signature
+// waits for the sync of its entry and of the entries before it.
+func (l *AuditLog) append(signer string, digest []byte, info AuditInfo, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := AuditEntry{
+		Index:          l.next,
+		Timestamp:      now.UTC(),
+		Signer:         signer,
+		Digest:         hex.EncodeToString(digest),
+		Type:           info.Type,
+		EmitterChain:   info.EmitterChain,
+		EmitterAddress: info.EmitterAddress,
+		Sequence:       info.Sequence,
+		PrevHash:       l.lastHash,
+	}
+	e.Hash = e.computeHash()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	b = append(b, '\n')
+
+	if _, err := l.f.Write(b); err != nil {
+		return fmt.Errorf("failed to write signing audit log: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync signing audit log: %w", err)
+	}
+
+	l.next++
+	l.lastHash = e.Hash
+	l.size += int64(len(b))
+	for _, f := range l.observers {
+		f(e)
+	}
+	return nil
+}
+
+// Reader returns a reader of the entries written so far.
+func (l *AuditLog) Reader() io.Reader {
+	l.mu.Lock()
+	size := l.size
+	l.mu.Unlock()
+	return io.NewSectionReader(l.f, 0, size)
+}
+
+// AuditLogVerification is the result of verifying an audit log.
+type AuditLogVerification struct {
+	Entries  uint64
+	LastHash string
+}
+
+// VerifyAuditLog checks that the entries read from r are consecutive, and that the hash chain is intact.
+func VerifyAuditLog(r io.Reader) (*AuditLogVerification, error) {
+	res := &AuditLogVerification{LastHash: auditGenesisHash}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return res, fmt.Errorf("entry %d: invalid JSON: %w", res.Entries, err)
+		}
+		if e.Index != res.Entries {
+			return res, fmt.Errorf("entry %d: unexpected index %d", res.Entries, e.Index)
+		}
+		if e.PrevHash != res.LastHash {
+			return res, fmt.Errorf("entry %d: previous hash %s does not match %s", res.Entries, e.PrevHash, res.LastHash)
+		}
+		if h := e.computeHash(); e.Hash != h {
+			return res, fmt.Errorf("entry %d: hash %s does not match the entry's hash %s", res.Entries, e.Hash, h)
+		}
+		res.Entries++
+		res.LastHash = e.Hash
+	}
+	if err := s.Err(); err != nil {
+		return res, fmt.Errorf("entry %d: %w", res.Entries, err)
+	}
+	return res, nil
+}
+
+type auditedSigner struct {
+	GuardianSigner
+	log *AuditLog
+}
+
+// NewAuditedSigner returns a signer which records every signature in the audit log. Signatures that can't be recorded
+// are not returned.
+func NewAuditedSigner(s GuardianSigner, log *AuditLog) GuardianSigner {
+	return &auditedSigner{GuardianSigner: s, log: log}
+}
+
+func (s *auditedSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	sig, err := s.GuardianSigner.Sign(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := ctx.Value(auditInfoKey{}).(AuditInfo)
+	if !ok {
+		info = AuditInfo{Type: AuditTypeUnknown}
+	}
+	if err := s.log.append(Address(s).Hex(), digest, info, time.Now()); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
@@ -0,0 +1,122 @@
+package guardiansigner
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditedSigner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewAuditedSigner(NewLocalSigner(key), l)
+
+	ctx := WithAuditInfo(context.Background(), AuditInfo{Type: AuditTypeObservation, EmitterChain: 2, EmitterAddress: "0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585", Sequence: 42})
+	_, err = s.Sign(ctx, testDigest)
+	require.NoError(t, err)
+	_, err = s.Sign(context.Background(), crypto.Keccak256([]byte("heartbeat")))
+	require.NoError(t, err)
+
+	res, err := VerifyAuditLog(l.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), res.Entries)
+	require.NoError(t, l.Close())
+
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"type":"observation","emitterChain":2`)
+	assert.Contains(t, lines[0], `"sequence":42`)
+	assert.Contains(t, lines[0], `"signer":"`+crypto.PubkeyToAddress(key.PublicKey).Hex()+`"`)
+	assert.Contains(t, lines[1], `"type":"unknown"`)
+
+	// Reopening continues the chain.
+	l, err = OpenAuditLog(path)
+	require.NoError(t, err)
+	s = NewAuditedSigner(NewLocalSigner(key), l)
+	_, err = s.Sign(context.Background(), testDigest)
+	require.NoError(t, err)
+	res, err = VerifyAuditLog(l.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), res.Entries)
+	require.NoError(t, l.Close())
+
+	// Tampering is detected.
+	b, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	tampered := bytes.Replace(b, []byte(`"sequence":42`), []byte(`"sequence":43`), 1)
+	_, err = VerifyAuditLog(bytes.NewReader(tampered))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entry 0: hash")
+
+	removed := []byte(strings.Join(strings.Split(string(b), "\n")[1:], "\n"))
+	_, err = VerifyAuditLog(bytes.NewReader(removed))
+	assert.EqualError(t, err, "entry 0: unexpected index 1")
+
+	require.NoError(t, ioutil.WriteFile(path, tampered, 0600))
+	_, err = OpenAuditLog(path)
+	assert.Error(t, err)
+}
+
+func TestOpenAuditLogTruncatesPartialEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := OpenAuditLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), l.Truncated())
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = NewAuditedSigner(NewLocalSigner(key), l).Sign(context.Background(), testDigest)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// A crash interrupted the write of the next entry.
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	partial := `{"index":1,"timestamp":"2024-`
+	require.NoError(t, ioutil.WriteFile(path, append(b, partial...), 0600))
+
+	l, err = OpenAuditLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(partial)), l.Truncated())
+	_, err = NewAuditedSigner(NewLocalSigner(key), l).Sign(context.Background(), testDigest)
+	require.NoError(t, err)
+	res, err := VerifyAuditLog(l.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), res.Entries)
+	require.NoError(t, l.Close())
+
+	// A log holding only a partial entry is emptied.
+	require.NoError(t, ioutil.WriteFile(path, []byte(partial), 0600))
+	l, err = OpenAuditLog(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(partial)), l.Truncated())
+	res, err = VerifyAuditLog(l.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), res.Entries)
+	require.NoError(t, l.Close())
+}
+
+func TestAuditedSignerFailure(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	l, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// Signatures that can't be recorded are not returned.
+	_, err = NewAuditedSigner(NewLocalSigner(key), l).Sign(context.Background(), testDigest)
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+type awsKMSSigner struct {
+	client *kms.Client
+	// Key ID, ARN or alias of the key.
+	keyID string
+	pub   *ecdsa.PublicKey
+}
+
+// NewAWSKMSSigner returns a signer for an ECC_SECG_P256K1 key in AWS KMS.
+// Credentials and region are taken from the environment (default AWS SDK configuration).
+func NewAWSKMSSigner(ctx context.Context, keyID string) (GuardianSigner, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := kms.NewFromConfig(cfg)
+
+	reqCtx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+	resp, err := client.GetPublicKey(reqCtx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key of %s: %w", keyID, err)
+	}
+	if resp.KeySpec != types.KeySpecEccSecgP256k1 {
+		return nil, fmt.Errorf("key %s has key spec %s, expected ECC_SECG_P256K1", keyID, resp.KeySpec)
+	}
+
+	pub, err := parsePublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return instrument("awskms", &awsKMSSigner{client: client, keyID: keyID, pub: pub}), nil
+}
+
+func (s *awsKMSSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+
+	// The KMS signs the digest as is, regardless of the hash function it is labeled with.
+	resp, err := s.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS sign request failed: %w", err)
+	}
+
+	return recoverableSignature(resp.Signature, digest, s.pub)
+}
+
+func (s *awsKMSSigner) PublicKey() *ecdsa.PublicKey {
+	return s.pub
+}
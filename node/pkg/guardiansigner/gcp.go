@@ -0,0 +1,73 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+type gcpKMSSigner struct {
+	client *kms.KeyManagementClient
+	// Resource name of the key version, projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*.
+	name string
+	pub  *ecdsa.PublicKey
+}
+
+// NewGCPKMSSigner returns a signer for an EC_SIGN_SECP256K1_SHA256 key version in Google Cloud KMS.
+// Credentials are taken from the environment (Application Default Credentials).
+func NewGCPKMSSigner(ctx context.Context, name string) (GuardianSigner, error) {
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP KMS client: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+	resp, err := client.GetPublicKey(reqCtx, &kmspb.GetPublicKeyRequest{Name: name})
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get public key of %s: %w", name, err)
+	}
+	if resp.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		client.Close()
+		return nil, fmt.Errorf("key %s has algorithm %s, expected EC_SIGN_SECP256K1_SHA256", name, resp.Algorithm)
+	}
+
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		client.Close()
+		return nil, errors.New("failed to decode PEM public key")
+	}
+	pub, err := parsePublicKey(block.Bytes)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return instrument("gcpkms", &gcpKMSSigner{client: client, name: name, pub: pub}), nil
+}
+
+func (s *gcpKMSSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, kmsTimeout)
+	defer cancel()
+
+	// The KMS signs the digest as is, regardless of the hash function it is labeled with.
+	resp, err := s.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:   s.name,
+		Digest: &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GCP KMS sign request failed: %w", err)
+	}
+
+	return recoverableSignature(resp.Signature, digest, s.pub)
+}
+
+func (s *gcpKMSSigner) PublicKey() *ecdsa.PublicKey {
+	return s.pub
+}
@@ -0,0 +1,71 @@
+package guardiansigner
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// kmsTimeout bounds a single KMS request.
+const kmsTimeout = 5 * time.Second
+
+// parsePublicKey parses a DER encoded SubjectPublicKeyInfo of a secp256k1 key, which crypto/x509 doesn't support.
+func parsePublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	pub, err := crypto.UnmarshalPubkey(spki.PublicKey.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("not a secp256k1 public key: %w", err)
+	}
+	return pub, nil
+}
+
+// recoverableSignature converts the DER encoded ECDSA signature returned by a KMS into the format of crypto.Sign.
+func recoverableSignature(der []byte, digest []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %w", err)
+	}
+	return recoverableSignatureRS(sig.R, sig.S, digest, pub)
+}
+
+// recoverableSignatureRS converts the ECDSA signature (r, s) into the format of crypto.Sign. Recovering the public key
+// from the result also verifies that the right digest was signed with the right key.
+func recoverableSignatureRS(r *big.Int, s *big.Int, digest []byte, pub *ecdsa.PublicKey) ([]byte, error) {
+	// Only signatures with a low S value are valid (EIP-2), but KMS and HSM signatures can have either.
+	n := crypto.S256().Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
+	if r.BitLen() > 256 || s.BitLen() > 256 {
+		return nil, errors.New("invalid signature values")
+	}
+
+	out := make([]byte, crypto.SignatureLength)
+	r.FillBytes(out[0:32])
+	s.FillBytes(out[32:64])
+
+	want := crypto.FromECDSAPub(pub)
+	for v := byte(0); v < 2; v++ {
+		out[64] = v
+		if got, err := crypto.Ecrecover(digest, out); err == nil && bytes.Equal(got, want) {
+			return out, nil
+		}
+	}
+	return nil, errors.New("signature does not match the guardian key")
+}
@@ -0,0 +1,308 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const defaultPKCS11Sessions = 4
+
+var pkcs11SessionsInUse = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "wormhole_guardian_signer_pkcs11_sessions_in_use",
+		Help: "Number of PKCS#11 sessions currently signing",
+	})
+
+// PKCS11Config locates a guardian key in a PKCS#11 token, like a YubiHSM or an AWS CloudHSM cluster.
+type PKCS11Config struct {
+	// Path to the PKCS#11 module of the HSM vendor.
+	Module string
+	// Label of the token holding the key.
+	Token string
+	// Label of the key pair.
+	Object string
+	// User PIN of the token.
+	PIN string
+	// Maximum number of concurrent signing sessions. Defaults to 4.
+	MaxSessions int
+}
+
+// ParsePKCS11URI parses a PKCS#11 URI (RFC 7512) of a guardian key, e.g.
+//
+//	pkcs11:token=wormhole;object=guardian?module-path=/usr/lib/pkcs11/yubihsm_pkcs11.so&pin-source=file:/etc/guardiand/hsm.pin&x-max-sessions=8
+//
+// The PIN is given either inline as pin-value, or read from the file given as pin-source.
+func ParsePKCS11URI(uri string) (*PKCS11Config, error) {
+	if !strings.HasPrefix(uri, "pkcs11:") {
+		return nil, errors.New("PKCS#11 URI must start with pkcs11:")
+	}
+	path := strings.TrimPrefix(uri, "pkcs11:")
+	var query string
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+
+	c := &PKCS11Config{MaxSessions: defaultPKCS11Sessions}
+	var pinSource string
+
+	for _, attr := range strings.Split(path, ";") {
+		if attr == "" {
+			continue
+		}
+		k, v, err := uriAttribute(attr)
+		if err != nil {
+			return nil, err
+		}
+		switch k {
+		case "token":
+			c.Token = v
+		case "object":
+			c.Object = v
+		default:
+			return nil, fmt.Errorf("unsupported PKCS#11 URI attribute %s", k)
+		}
+	}
+
+	for _, attr := range strings.Split(query, "&") {
+		if attr == "" {
+			continue
+		}
+		k, v, err := uriAttribute(attr)
+		if err != nil {
+			return nil, err
+		}
+		switch k {
+		case "module-path":
+			c.Module = v
+		case "pin-value":
+			c.PIN = v
+		case "pin-source":
+			pinSource = v
+		case "x-max-sessions":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid x-max-sessions %q", v)
+			}
+			c.MaxSessions = n
+		default:
+			return nil, fmt.Errorf("unsupported PKCS#11 URI query attribute %s", k)
+		}
+	}
+
+	if c.Module == "" || c.Token == "" || c.Object == "" {
+		return nil, errors.New("PKCS#11 URI must specify token, object and module-path")
+	}
+
+	if pinSource != "" {
+		if c.PIN != "" {
+			return nil, errors.New("PKCS#11 URI must not specify both pin-value and pin-source")
+		}
+		b, err := ioutil.ReadFile(strings.TrimPrefix(pinSource, "file:"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PIN: %w", err)
+		}
+		c.PIN = strings.TrimSpace(string(b))
+	}
+
+	return c, nil
+}
+
+func uriAttribute(attr string) (string, string, error) {
+	i := strings.IndexByte(attr, '=')
+	if i < 0 {
+		return "", "", fmt.Errorf("invalid PKCS#11 URI attribute %q", attr)
+	}
+	v, err := url.PathUnescape(attr[i+1:])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid PKCS#11 URI attribute %q: %w", attr, err)
+	}
+	return attr[:i], v, nil
+}
+
+// sessionPool bounds the number of concurrent HSM operations to the number of open sessions.
+type sessionPool chan pkcs11.SessionHandle
+
+func (p sessionPool) acquire(ctx context.Context) (pkcs11.SessionHandle, error) {
+	select {
+	case sh := <-p:
+		pkcs11SessionsInUse.Inc()
+		return sh, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (p sessionPool) release(sh pkcs11.SessionHandle) {
+	pkcs11SessionsInUse.Dec()
+	p <- sh
+}
+
+type pkcs11Signer struct {
+	p        *pkcs11.Ctx
+	slot     uint
+	key      pkcs11.ObjectHandle
+	pub      *ecdsa.PublicKey
+	sessions sessionPool
+}
+
+// NewPKCS11Signer returns a signer for a secp256k1 key pair in a PKCS#11 token. The public key is read once at startup,
+// so only signing requires the HSM.
+func NewPKCS11Signer(c *PKCS11Config) (GuardianSigner, error) {
+	p := pkcs11.New(c.Module)
+	if p == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", c.Module)
+	}
+	if err := p.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+
+	s, err := openPKCS11Signer(p, c)
+	if err != nil {
+		p.Finalize()
+		p.Destroy()
+		return nil, err
+	}
+	return instrument("pkcs11", s), nil
+}
+
+func openPKCS11Signer(p *pkcs11.Ctx, c *PKCS11Config) (*pkcs11Signer, error) {
+	slots, err := p.GetSlotList(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PKCS#11 slots: %w", err)
+	}
+	s := &pkcs11Signer{p: p, sessions: make(sessionPool, c.MaxSessions)}
+	found := false
+	for _, slot := range slots {
+		info, err := p.GetTokenInfo(slot)
+		if err == nil && strings.TrimRight(info.Label, " \x00") == c.Token {
+			s.slot = slot
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("PKCS#11 token %s not found", c.Token)
+	}
+
+	for i := 0; i < c.MaxSessions; i++ {
+		sh, err := p.OpenSession(s.slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open PKCS#11 session: %w", err)
+		}
+		s.sessions <- sh
+	}
+
+	// The login applies to all sessions of the application.
+	sh := <-s.sessions
+	defer func() { s.sessions <- sh }()
+	if err := p.Login(sh, pkcs11.CKU_USER, c.PIN); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return nil, fmt.Errorf("failed to log in to PKCS#11 token: %w", err)
+	}
+
+	if s.key, err = findObject(p, sh, pkcs11.CKO_PRIVATE_KEY, c.Object); err != nil {
+		return nil, err
+	}
+	pubKey, err := findObject(p, sh, pkcs11.CKO_PUBLIC_KEY, c.Object)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := p.GetAttributeValue(sh, pubKey, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	if s.pub, err = parseECPoint(attrs[0].Value); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func findObject(p *pkcs11.Ctx, sh pkcs11.SessionHandle, class uint, label string) (pkcs11.ObjectHandle, error) {
+	if err := p.FindObjectsInit(sh, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}); err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+	objs, _, err := p.FindObjects(sh, 2)
+	p.FindObjectsFinal(sh)
+	if err != nil {
+		return 0, fmt.Errorf("failed to search PKCS#11 objects: %w", err)
+	}
+
+	kind := "private"
+	if class == pkcs11.CKO_PUBLIC_KEY {
+		kind = "public"
+	}
+	switch len(objs) {
+	case 0:
+		return 0, fmt.Errorf("no %s key labeled %s", kind, label)
+	case 1:
+		return objs[0], nil
+	default:
+		return 0, fmt.Errorf("multiple %s keys labeled %s", kind, label)
+	}
+}
+
+// parseECPoint parses the CKA_EC_POINT attribute of a secp256k1 public key. It should be a DER encoded octet string
+// holding the uncompressed point, but some tokens return the raw point.
+func parseECPoint(b []byte) (*ecdsa.PublicKey, error) {
+	var point []byte
+	if rest, err := asn1.Unmarshal(b, &point); err == nil && len(rest) == 0 {
+		b = point
+	}
+	pub, err := crypto.UnmarshalPubkey(b)
+	if err != nil {
+		return nil, fmt.Errorf("not a secp256k1 public key: %w", err)
+	}
+	return pub, nil
+}
+
+func (s *pkcs11Signer) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	sh, err := s.sessions.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no PKCS#11 session available: %w", err)
+	}
+
+	sig, err := s.sign(sh, digest)
+	if errors.Is(err, pkcs11.Error(pkcs11.CKR_SESSION_HANDLE_INVALID)) || errors.Is(err, pkcs11.Error(pkcs11.CKR_SESSION_CLOSED)) {
+		// The HSM dropped the session, e.g. after a restart. Replace it for the next request.
+		if nsh, openErr := s.p.OpenSession(s.slot, pkcs11.CKF_SERIAL_SESSION); openErr == nil {
+			sh = nsh
+		}
+	}
+	s.sessions.release(sh)
+	if err != nil {
+		return nil, fmt.Errorf("PKCS#11 sign failed: %w", err)
+	}
+
+	// CKM_ECDSA signatures are the concatenation r || s.
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("unexpected PKCS#11 signature length %d", len(sig))
+	}
+	return recoverableSignatureRS(new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]), digest, s.pub)
+}
+
+func (s *pkcs11Signer) sign(sh pkcs11.SessionHandle, digest []byte) ([]byte, error) {
+	if err := s.p.SignInit(sh, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, err
+	}
+	return s.p.Sign(sh, digest)
+}
+
+func (s *pkcs11Signer) PublicKey() *ecdsa.PublicKey {
+	return s.pub
+}
@@ -0,0 +1,104 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePKCS11URI(t *testing.T) {
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	require.NoError(t, ioutil.WriteFile(pinFile, []byte("0001password\n"), 0600))
+
+	c, err := ParsePKCS11URI("pkcs11:token=wormhole;object=guardian%20key?module-path=/usr/lib/yubihsm_pkcs11.so&pin-source=file:" + pinFile + "&x-max-sessions=8")
+	require.NoError(t, err)
+	assert.Equal(t, &PKCS11Config{
+		Module:      "/usr/lib/yubihsm_pkcs11.so",
+		Token:       "wormhole",
+		Object:      "guardian key",
+		PIN:         "0001password",
+		MaxSessions: 8,
+	}, c)
+
+	c, err = ParsePKCS11URI("pkcs11:token=wormhole;object=guardian?module-path=/usr/lib/libcloudhsm_pkcs11.so&pin-value=user:pass")
+	require.NoError(t, err)
+	assert.Equal(t, "user:pass", c.PIN)
+	assert.Equal(t, defaultPKCS11Sessions, c.MaxSessions)
+
+	for _, uri := range []string{
+		"awskms://key",
+		"pkcs11:token=wormhole?module-path=/usr/lib/yubihsm_pkcs11.so",
+		"pkcs11:token=wormhole;object=guardian",
+		"pkcs11:token=wormhole;object=guardian;slot-id=1?module-path=/m.so",
+		"pkcs11:token=wormhole;object=guardian?module-path=/m.so&x-max-sessions=0",
+		"pkcs11:token=wormhole;object=guardian?module-path=/m.so&pin-value=a&pin-source=file:" + pinFile,
+	} {
+		_, err := ParsePKCS11URI(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestParseECPoint(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	raw := crypto.FromECDSAPub(&key.PublicKey)
+	der, err := asn1.Marshal(raw)
+	require.NoError(t, err)
+
+	for _, b := range [][]byte{der, raw} {
+		pub, err := parseECPoint(b)
+		require.NoError(t, err)
+		assert.Equal(t, key.PublicKey, *pub)
+	}
+
+	_, err = parseECPoint([]byte{4, 1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestRecoverableSignatureRS(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// CKM_ECDSA returns r || s, with arbitrary S.
+	r, s, err := ecdsa.Sign(rand.Reader, key, testDigest)
+	require.NoError(t, err)
+
+	sig, err := recoverableSignatureRS(r, s, testDigest, &key.PublicKey)
+	require.NoError(t, err)
+	pub, err := crypto.SigToPub(testDigest, sig)
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey, *pub)
+}
+
+func TestSessionPool(t *testing.T) {
+	p := make(sessionPool, 2)
+	p <- 1
+	p <- 2
+
+	a, err := p.acquire(context.Background())
+	require.NoError(t, err)
+	b, err := p.acquire(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []pkcs11.SessionHandle{1, 2}, []pkcs11.SessionHandle{a, b})
+
+	// All sessions are busy.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.acquire(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	p.release(a)
+	c, err := p.acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a, c)
+}
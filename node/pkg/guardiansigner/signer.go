@@ -0,0 +1,115 @@
+// Package guardiansigner signs with the guardian key, which is either held in memory or in a cloud KMS.
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	signLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "wormhole_guardian_signer_sign_latency_seconds",
+			Help:    "Latency of signing with the guardian key",
+			Buckets: []float64{0.0001, 0.001, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		}, []string{"backend"})
+	signErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_guardian_signer_errors_total",
+			Help: "Total number of failures to sign with the guardian key",
+		}, []string{"backend"})
+	signFallbacks = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_guardian_signer_fallbacks_total",
+			Help: "Total number of signatures made with the local fallback key after the primary signer failed",
+		})
+)
+
+// GuardianSigner signs digests with a guardian key.
+type GuardianSigner interface {
+	// Sign returns the 65 byte recoverable signature [R || S || V] of the 32 byte digest, in the format of crypto.Sign.
+	Sign(ctx context.Context, digest []byte) ([]byte, error)
+	// PublicKey returns the public key of the guardian key.
+	PublicKey() *ecdsa.PublicKey
+}
+
+// Address returns the guardian address of the signer's key.
+func Address(s GuardianSigner) common.Address {
+	return crypto.PubkeyToAddress(*s.PublicKey())
+}
+
+// instrumentedSigner records the latency and errors of a signer backend.
+type instrumentedSigner struct {
+	GuardianSigner
+	backend string
+}
+
+func instrument(backend string, s GuardianSigner) GuardianSigner {
+	return &instrumentedSigner{GuardianSigner: s, backend: backend}
+}
+
+func (s *instrumentedSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	start := time.Now()
+	sig, err := s.GuardianSigner.Sign(ctx, digest)
+	signLatency.WithLabelValues(s.backend).Observe(time.Since(start).Seconds())
+	if err != nil {
+		signErrors.WithLabelValues(s.backend).Inc()
+	}
+	return sig, err
+}
+
+type localSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewLocalSigner returns a signer for a guardian key held in memory.
+func NewLocalSigner(key *ecdsa.PrivateKey) GuardianSigner {
+	return instrument("local", &localSigner{key: key})
+}
+
+func (s *localSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	return crypto.Sign(digest, s.key)
+}
+
+func (s *localSigner) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+type fallbackSigner struct {
+	primary  GuardianSigner
+	fallback GuardianSigner
+}
+
+// NewFallbackSigner returns a signer which signs with primary, and with fallback if primary fails,
+// e.g. because the KMS is unreachable. Both signers must hold the same key.
+func NewFallbackSigner(primary GuardianSigner, fallback GuardianSigner) (GuardianSigner, error) {
+	if Address(primary) != Address(fallback) {
+		return nil, fmt.Errorf("fallback key %s does not match the guardian key %s", Address(fallback), Address(primary))
+	}
+	return &fallbackSigner{primary: primary, fallback: fallback}, nil
+}
+
+func (s *fallbackSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	sig, err := s.primary.Sign(ctx, digest)
+	if err == nil {
+		return sig, nil
+	}
+
+	sig, fallbackErr := s.fallback.Sign(ctx, digest)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%v (fallback: %v)", err, fallbackErr)
+	}
+	signFallbacks.Inc()
+	return sig, nil
+}
+
+func (s *fallbackSigner) PublicKey() *ecdsa.PublicKey {
+	return s.primary.PublicKey()
+}
@@ -0,0 +1,126 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDigest = crypto.Keccak256([]byte("test"))
+
+type failingSigner struct {
+	pub *ecdsa.PublicKey
+}
+
+func (s *failingSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	return nil, errors.New("kms unavailable")
+}
+
+func (s *failingSigner) PublicKey() *ecdsa.PublicKey {
+	return s.pub
+}
+
+func TestLocalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewLocalSigner(key)
+
+	sig, err := s.Sign(context.Background(), testDigest)
+	require.NoError(t, err)
+	pub, err := crypto.SigToPub(testDigest, sig)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pub))
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), Address(s))
+}
+
+func TestRecoverableSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// Signatures as returned by a KMS, in DER encoding and with arbitrary S.
+	r, s, err := ecdsa.Sign(rand.Reader, key, testDigest)
+	require.NoError(t, err)
+	lowS := s
+	n := crypto.S256().Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		lowS = new(big.Int).Sub(n, s)
+	}
+	highS := new(big.Int).Sub(n, lowS)
+
+	for _, s := range []*big.Int{lowS, highS} {
+		der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		require.NoError(t, err)
+
+		sig, err := recoverableSignature(der, testDigest, &key.PublicKey)
+		require.NoError(t, err)
+		assert.True(t, crypto.VerifySignature(crypto.FromECDSAPub(&key.PublicKey), testDigest, sig[:64]))
+		pub, err := crypto.SigToPub(testDigest, sig)
+		require.NoError(t, err)
+		assert.Equal(t, key.PublicKey, *pub)
+
+		_, err = recoverableSignature(der, testDigest, &other.PublicKey)
+		assert.Error(t, err)
+	}
+
+	_, err = recoverableSignature([]byte{1, 2, 3}, testDigest, &key.PublicKey)
+	assert.Error(t, err)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	der, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			// id-ecPublicKey, secp256k1
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}},
+		},
+		PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&key.PublicKey), BitLength: 8 * 65},
+	})
+	require.NoError(t, err)
+
+	pub, err := parsePublicKey(der)
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey, *pub)
+
+	_, err = parsePublicKey([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestFallbackSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = NewFallbackSigner(&failingSigner{pub: &key.PublicKey}, NewLocalSigner(other))
+	assert.Error(t, err)
+
+	s, err := NewFallbackSigner(&failingSigner{pub: &key.PublicKey}, NewLocalSigner(key))
+	require.NoError(t, err)
+	sig, err := s.Sign(context.Background(), testDigest)
+	require.NoError(t, err)
+	pub, err := crypto.SigToPub(testDigest, sig)
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey, *pub)
+
+	s, err = NewFallbackSigner(&failingSigner{pub: &key.PublicKey}, &failingSigner{pub: &key.PublicKey})
+	require.NoError(t, err)
+	_, err = s.Sign(context.Background(), testDigest)
+	assert.EqualError(t, err, "kms unavailable (fallback: kms unavailable)")
+}
@@ -0,0 +1,162 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tssv1 "github.com/certusone/wormhole/node/pkg/proto/tss/v1"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// tssTimeout bounds a single signing request. Threshold signing takes several rounds of communication between the
+// parties, so it is slower than a KMS.
+const tssTimeout = 10 * time.Second
+
+// TSSConfig locates a guardian key split across the parties of a threshold signature scheme, which is used through an
+// external coordinator implementing TSSCoordinatorService.
+type TSSConfig struct {
+	// host:port of the coordinator.
+	Endpoint string
+	// Identifier of the distributed key at the coordinator.
+	KeyID string
+	// TLS configuration of the connection, or nil for plaintext.
+	TLS *tls.Config
+}
+
+// ParseTSSURI parses the URI of a threshold guardian key, e.g.
+//
+//	tss://coordinator.internal:7070/guardian?ca=/etc/guardiand/tss-ca.pem&cert=/etc/guardiand/tss.pem&key=/etc/guardiand/tss.key
+//
+// The coordinator is verified against the system roots unless ca is given. cert and key present a client certificate.
+// insecure=true disables TLS, which is only meant for local testing.
+func ParseTSSURI(uri string) (*TSSConfig, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TSS URI: %w", err)
+	}
+	if u.Scheme != "tss" {
+		return nil, errors.New("TSS URI must start with tss://")
+	}
+	keyID := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || keyID == "" {
+		return nil, errors.New("TSS URI must specify the coordinator and the key ID")
+	}
+	c := &TSSConfig{Endpoint: u.Host, KeyID: keyID}
+
+	q := u.Query()
+	for k := range q {
+		switch k {
+		case "ca", "cert", "key", "insecure":
+		default:
+			return nil, fmt.Errorf("unsupported TSS URI query attribute %s", k)
+		}
+	}
+
+	if v := q.Get("insecure"); v != "" {
+		plaintext, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid insecure %q", v)
+		}
+		if plaintext {
+			if q.Get("ca") != "" || q.Get("cert") != "" || q.Get("key") != "" {
+				return nil, errors.New("TSS URI must not specify TLS files with insecure=true")
+			}
+			return c, nil
+		}
+	}
+
+	c.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca := q.Get("ca"); ca != "" {
+		b, err := ioutil.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		c.TLS.RootCAs = x509.NewCertPool()
+		if !c.TLS.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no CA certificates found in %s", ca)
+		}
+	}
+	if (q.Get("cert") == "") != (q.Get("key") == "") {
+		return nil, errors.New("TSS URI must specify both cert and key, or neither")
+	}
+	if q.Get("cert") != "" {
+		cert, err := tls.LoadX509KeyPair(q.Get("cert"), q.Get("key"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client TLS certificate: %w", err)
+		}
+		c.TLS.Certificates = []tls.Certificate{cert}
+	}
+
+	return c, nil
+}
+
+type tssSigner struct {
+	client tssv1.TSSCoordinatorServiceClient
+	keyID  string
+	pub    *ecdsa.PublicKey
+}
+
+// NewTSSSigner returns a signer which signs through a threshold signature coordinator. The public key is requested once
+// at startup.
+func NewTSSSigner(ctx context.Context, c *TSSConfig) (GuardianSigner, error) {
+	creds := insecure.NewCredentials()
+	if c.TLS != nil {
+		creds = credentials.NewTLS(c.TLS)
+	}
+	conn, err := grpc.DialContext(ctx, c.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to TSS coordinator: %w", err)
+	}
+
+	s, err := newTSSSigner(ctx, conn, c.KeyID)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return instrument("tss", s), nil
+}
+
+func newTSSSigner(ctx context.Context, conn *grpc.ClientConn, keyID string) (*tssSigner, error) {
+	client := tssv1.NewTSSCoordinatorServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(ctx, tssTimeout)
+	defer cancel()
+	resp, err := client.GetPublicKey(ctx, &tssv1.GetPublicKeyRequest{KeyId: keyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key from TSS coordinator: %w", err)
+	}
+	pub, err := crypto.UnmarshalPubkey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("not a secp256k1 public key: %w", err)
+	}
+
+	return &tssSigner{client: client, keyID: keyID, pub: pub}, nil
+}
+
+func (s *tssSigner) Sign(ctx context.Context, digest []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, tssTimeout)
+	defer cancel()
+
+	resp, err := s.client.Sign(ctx, &tssv1.SignRequest{KeyId: s.keyID, Digest: digest})
+	if err != nil {
+		return nil, fmt.Errorf("TSS sign failed: %w", err)
+	}
+	return recoverableSignatureRS(new(big.Int).SetBytes(resp.R), new(big.Int).SetBytes(resp.S), digest, s.pub)
+}
+
+func (s *tssSigner) PublicKey() *ecdsa.PublicKey {
+	return s.pub
+}
@@ -0,0 +1,94 @@
+package guardiansigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	tssv1 "github.com/certusone/wormhole/node/pkg/proto/tss/v1"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testCoordinator signs with a single local key, standing in for the parties of a threshold scheme.
+type testCoordinator struct {
+	tssv1.UnimplementedTSSCoordinatorServiceServer
+	key *ecdsa.PrivateKey
+}
+
+func (c *testCoordinator) GetPublicKey(ctx context.Context, req *tssv1.GetPublicKeyRequest) (*tssv1.GetPublicKeyResponse, error) {
+	if req.KeyId != "guardian" {
+		return nil, status.Error(codes.NotFound, "unknown key")
+	}
+	return &tssv1.GetPublicKeyResponse{PublicKey: crypto.FromECDSAPub(&c.key.PublicKey)}, nil
+}
+
+func (c *testCoordinator) Sign(ctx context.Context, req *tssv1.SignRequest) (*tssv1.SignResponse, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, req.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &tssv1.SignResponse{R: r.Bytes(), S: s.Bytes()}, nil
+}
+
+func TestTSSSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	tssv1.RegisterTSSCoordinatorServiceServer(server, &testCoordinator{key: key})
+	go server.Serve(l) //nolint:errcheck
+	defer server.Stop()
+
+	ctx := context.Background()
+	c, err := ParseTSSURI("tss://" + l.Addr().String() + "/guardian?insecure=true")
+	require.NoError(t, err)
+	s, err := NewTSSSigner(ctx, c)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), Address(s))
+
+	for i := 0; i < 10; i++ {
+		sig, err := s.Sign(ctx, testDigest)
+		require.NoError(t, err)
+		pub, err := crypto.SigToPub(testDigest, sig)
+		require.NoError(t, err)
+		assert.Equal(t, key.PublicKey, *pub)
+	}
+
+	c.KeyID = "other"
+	_, err = NewTSSSigner(ctx, c)
+	assert.Error(t, err)
+}
+
+func TestParseTSSURI(t *testing.T) {
+	c, err := ParseTSSURI("tss://coordinator:7070/guardian")
+	require.NoError(t, err)
+	assert.Equal(t, "coordinator:7070", c.Endpoint)
+	assert.Equal(t, "guardian", c.KeyID)
+	assert.NotNil(t, c.TLS)
+
+	c, err = ParseTSSURI("tss://coordinator:7070/guardian?insecure=true")
+	require.NoError(t, err)
+	assert.Nil(t, c.TLS)
+
+	for _, uri := range []string{
+		"awskms://key",
+		"tss://coordinator:7070",
+		"tss:///guardian",
+		"tss://coordinator:7070/guardian?pin=1",
+		"tss://coordinator:7070/guardian?cert=/tmp/cert.pem",
+		"tss://coordinator:7070/guardian?insecure=true&ca=/tmp/ca.pem",
+		"tss://coordinator:7070/guardian?ca=/nonexistent",
+	} {
+		_, err := ParseTSSURI(uri)
+		assert.Error(t, err, uri)
+	}
+}
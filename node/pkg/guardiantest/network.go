@@ -0,0 +1,333 @@
+// Package guardiantest runs a network of guardians in a single process, for fast integration tests of the consensus
+// path without Kubernetes or Tilt.
+//
+// Each guardian runs the real processor with its own guardian key and database. The p2p layer is replaced by an
+// in-memory gossip network which delivers every message to all other guardians, and the chain watchers by mock
+// watchers that emit scripted message publications:
+//
+//	n := guardiantest.NewNetwork(t, 4)
+//	w := n.Watcher(vaa.ChainIDEthereum)
+//	msg := w.Message(1, []byte("payload"))
+//	w.Publish(msg)
+//	v := n.RequireQuorumVAA(t, msg)
+//
+// Guardians can be taken off the gossip network with SetOnline to test behaviour without quorum.
+package guardiantest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/devnet"
+	"github.com/certusone/wormhole/node/pkg/eventbus"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/processor"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/reporter"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/proto"
+)
+
+// Guardian is a guardian of the network.
+type Guardian struct {
+	Index int
+	Key   *ecdsa.PrivateKey
+	DB    *db.Database
+
+	bus          *eventbus.Bus
+	setC         chan *common.GuardianSet
+	sendC        chan []byte
+	obsvReqSendC chan *gossipv1.ObservationRequest
+	injectC      chan *vaa.VAA
+	gst          *common.GuardianSetState
+}
+
+// Address returns the guardian's address.
+func (g *Guardian) Address() ethcommon.Address {
+	return ethcrypto.PubkeyToAddress(g.Key.PublicKey)
+}
+
+// Network is a set of guardians connected by in-memory gossip.
+type Network struct {
+	Guardians   []*Guardian
+	GuardianSet *common.GuardianSet
+
+	ctx context.Context
+
+	mu       sync.Mutex
+	online   []bool
+	watchers map[vaa.ChainID]*MockWatcher
+}
+
+// NewNetwork starts a network of n guardians with the devnet guardian keys, which is stopped at the end of the test.
+func NewNetwork(t testing.TB, n int) *Network {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	net := &Network{
+		GuardianSet: &common.GuardianSet{Index: 0},
+		ctx:         ctx,
+		online:      make([]bool, n),
+		watchers:    map[vaa.ChainID]*MockWatcher{},
+	}
+
+	for i := 0; i < n; i++ {
+		d, err := db.Open(t.TempDir())
+		require.NoError(t, err)
+
+		g := &Guardian{
+			Index:        i,
+			Key:          devnet.InsecureDeterministicEcdsaKeyByIndex(ethcrypto.S256(), uint64(i)),
+			DB:           d,
+			bus:          eventbus.New(),
+			setC:         make(chan *common.GuardianSet),
+			sendC:        make(chan []byte),
+			obsvReqSendC: make(chan *gossipv1.ObservationRequest, common.ObsvReqChannelSize),
+			injectC:      make(chan *vaa.VAA),
+			gst:          common.NewGuardianSetState(),
+		}
+		net.Guardians = append(net.Guardians, g)
+		net.GuardianSet.Keys = append(net.GuardianSet.Keys, g.Address())
+		net.online[i] = true
+	}
+
+	// The processors are stopped before their databases are closed.
+	var running sync.WaitGroup
+	t.Cleanup(func() {
+		cancel()
+		running.Wait()
+		for _, g := range net.Guardians {
+			g.DB.Close()
+		}
+	})
+
+	logger := zaptest.NewLogger(t, zaptest.Level(zap.WarnLevel))
+	supervisor.New(ctx, logger, func(ctx context.Context) error {
+		for _, g := range net.Guardians {
+			p := processor.NewProcessor(ctx,
+				g.DB,
+				g.bus,
+				g.setC,
+				g.sendC,
+				g.obsvReqSendC,
+				g.injectC,
+				guardiansigner.NewLocalSigner(g.Key),
+				nil,
+				g.gst,
+				false,
+				0,
+				"",
+				false,
+				reporter.EventListener(logger),
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			)
+			run := func(ctx context.Context) error {
+				running.Add(1)
+				defer running.Done()
+				return p.Run(ctx)
+			}
+			if err := supervisor.Run(ctx, fmt.Sprintf("guardian%d", g.Index), run); err != nil {
+				return err
+			}
+			if err := supervisor.Run(ctx, fmt.Sprintf("guardian%d-eventbus", g.Index), g.bus.Run); err != nil {
+				return err
+			}
+		}
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	for _, g := range net.Guardians {
+		g.setC <- net.GuardianSet
+		go net.gossip(g)
+	}
+
+	return net
+}
+
+// Quorum returns the number of signatures required for a VAA.
+func (n *Network) Quorum() int {
+	return vaa.CalculateQuorum(len(n.Guardians))
+}
+
+// SetOnline connects a guardian to the gossip network, or disconnects it. A disconnected guardian neither sends nor
+// receives gossip, but still observes messages.
+func (n *Network) SetOnline(i int, online bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.online[i] = online
+}
+
+func (n *Network) isOnline(i int) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.online[i]
+}
+
+// gossip delivers the messages broadcast by a guardian to all other guardians, and its observation requests to all
+// guardians including itself, as the p2p layer does.
+func (n *Network) gossip(from *Guardian) {
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case req := <-from.obsvReqSendC:
+			if !n.isOnline(from.Index) {
+				n.handleObservationRequest(from, req)
+				continue
+			}
+			for _, g := range n.Guardians {
+				if g == from || n.isOnline(g.Index) {
+					n.handleObservationRequest(g, req)
+				}
+			}
+		case b := <-from.sendC:
+			var msg gossipv1.GossipMessage
+			if err := proto.Unmarshal(b, &msg); err != nil {
+				panic(err)
+			}
+			if !n.isOnline(from.Index) {
+				continue
+			}
+			for _, g := range n.Guardians {
+				if g == from || !n.isOnline(g.Index) {
+					continue
+				}
+				switch m := msg.Message.(type) {
+				case *gossipv1.GossipMessage_SignedObservation:
+					n.deliverObservation(g, m.SignedObservation)
+				case *gossipv1.GossipMessage_SignedVaaWithQuorum:
+					n.deliverSignedVAA(g, m.SignedVaaWithQuorum)
+				}
+			}
+		}
+	}
+}
+
+func (n *Network) deliverObservation(g *Guardian, m *gossipv1.SignedObservation) {
+	select {
+	case g.bus.SignedObservations().C() <- m:
+	case <-n.ctx.Done():
+	}
+}
+
+func (n *Network) deliverSignedVAA(g *Guardian, m *gossipv1.SignedVAAWithQuorum) {
+	select {
+	case g.bus.SignedVAAs().C() <- m:
+	case <-n.ctx.Done():
+	}
+}
+
+func (n *Network) handleObservationRequest(g *Guardian, req *gossipv1.ObservationRequest) {
+	n.mu.Lock()
+	w := n.watchers[vaa.ChainID(req.ChainId)]
+	n.mu.Unlock()
+	if w != nil {
+		w.reobserve(g, ethcommon.BytesToHash(req.TxHash))
+	}
+}
+
+// publish delivers a message publication to a guardian's processor.
+func (n *Network) publish(g *Guardian, msg *common.MessagePublication) {
+	select {
+	case g.bus.MessagePublications().C() <- msg:
+	case <-n.ctx.Done():
+	}
+}
+
+// InjectVAA injects a VAA, like the governance-vaa-inject admin command, into the given guardians or all guardians.
+func (n *Network) InjectVAA(v *vaa.VAA, guardians ...int) {
+	for _, g := range n.selectGuardians(guardians) {
+		select {
+		case g.injectC <- v:
+		case <-n.ctx.Done():
+		}
+	}
+}
+
+func (n *Network) selectGuardians(indexes []int) []*Guardian {
+	if len(indexes) == 0 {
+		return n.Guardians
+	}
+	gs := make([]*Guardian, 0, len(indexes))
+	for _, i := range indexes {
+		gs = append(gs, n.Guardians[i])
+	}
+	return gs
+}
+
+// SignedVAA returns the signed VAA of a message from a guardian's database, or nil if it has none.
+func (n *Network) SignedVAA(t testing.TB, i int, id db.VAAID) *vaa.VAA {
+	t.Helper()
+	b, err := n.Guardians[i].DB.GetSignedVAABytes(id)
+	if err == db.ErrVAANotFound {
+		return nil
+	}
+	require.NoError(t, err)
+	v, err := vaa.Unmarshal(b)
+	require.NoError(t, err)
+	return v
+}
+
+// RequireQuorumVAA waits until every online guardian has stored a signed VAA of msg, and checks that it is signed by a
+// quorum of the guardian set and matches msg.
+func (n *Network) RequireQuorumVAA(t testing.TB, msg *common.MessagePublication) *vaa.VAA {
+	t.Helper()
+	id := db.VAAID{EmitterChain: msg.EmitterChain, EmitterAddress: msg.EmitterAddress, Sequence: msg.Sequence}
+
+	var v *vaa.VAA
+	require.Eventually(t, func() bool {
+		for _, g := range n.Guardians {
+			if !n.isOnline(g.Index) {
+				continue
+			}
+			if v = n.SignedVAA(t, g.Index, id); v == nil {
+				return false
+			}
+		}
+		return v != nil
+	}, 10*time.Second, 10*time.Millisecond, "no quorum VAA for %s", msg.MessageIDString())
+
+	require.GreaterOrEqual(t, len(v.Signatures), n.Quorum(), "VAA is signed by less than a quorum")
+	require.True(t, v.VerifySignatures(n.GuardianSet.Keys), "VAA signatures are invalid")
+	require.Equal(t, msg.Payload, v.Payload)
+	require.Equal(t, msg.Nonce, v.Nonce)
+	require.Equal(t, msg.ConsistencyLevel, v.ConsistencyLevel)
+	require.Equal(t, uint32(msg.Timestamp.Unix()), uint32(v.Timestamp.Unix()))
+	return v
+}
+
+// RequireNoVAA checks that no guardian stores a signed VAA of msg for the given duration.
+func (n *Network) RequireNoVAA(t testing.TB, msg *common.MessagePublication, d time.Duration) {
+	t.Helper()
+	id := db.VAAID{EmitterChain: msg.EmitterChain, EmitterAddress: msg.EmitterAddress, Sequence: msg.Sequence}
+
+	require.Never(t, func() bool {
+		for _, g := range n.Guardians {
+			if n.SignedVAA(t, g.Index, id) != nil {
+				return true
+			}
+		}
+		return false
+	}, d, 10*time.Millisecond, "unexpected VAA for %s", msg.MessageIDString())
+}
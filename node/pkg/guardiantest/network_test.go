@@ -0,0 +1,52 @@
+package guardiantest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorum(t *testing.T) {
+	n := NewNetwork(t, 4)
+	w := n.Watcher(vaa.ChainIDEthereum)
+
+	for seq := uint64(1); seq <= 3; seq++ {
+		msg := w.Message(seq, []byte("payload"))
+		w.Publish(msg)
+		v := n.RequireQuorumVAA(t, msg)
+		assert.Equal(t, seq, v.Sequence)
+	}
+}
+
+func TestQuorumWithOfflineGuardian(t *testing.T) {
+	n := NewNetwork(t, 4)
+	n.SetOnline(3, false)
+	w := n.Watcher(vaa.ChainIDSolana)
+
+	msg := w.Message(1, []byte("payload"))
+	w.Publish(msg)
+	v := n.RequireQuorumVAA(t, msg)
+	assert.Len(t, v.Signatures, 3)
+
+	// The offline guardian observed the message, but never received the other signatures.
+	assert.Nil(t, n.SignedVAA(t, 3, *db.VaaIDFromVAA(v)))
+}
+
+func TestNoQuorum(t *testing.T) {
+	n := NewNetwork(t, 4)
+	w := n.Watcher(vaa.ChainIDEthereum)
+
+	// Two out of four guardians are not a quorum.
+	msg := w.Message(1, []byte("payload"))
+	w.Publish(msg, 0, 1)
+	n.RequireNoVAA(t, msg, 500*time.Millisecond)
+
+	// A conflicting observation by the other guardians doesn't count towards it either.
+	conflicting := *msg
+	conflicting.Payload = []byte("other payload")
+	w.Publish(&conflicting, 2, 3)
+	n.RequireNoVAA(t, msg, 500*time.Millisecond)
+}
@@ -0,0 +1,79 @@
+package guardiantest
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// MockWatcher emits scripted message publications of a chain to the guardians, in place of a chain watcher.
+type MockWatcher struct {
+	n     *Network
+	chain vaa.ChainID
+
+	mu sync.Mutex
+	// Messages published so far, by transaction hash, for reobservation.
+	txs map[ethcommon.Hash][]*common.MessagePublication
+}
+
+// Watcher returns the mock watcher of a chain.
+func (n *Network) Watcher(chain vaa.ChainID) *MockWatcher {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	w, ok := n.watchers[chain]
+	if !ok {
+		w = &MockWatcher{n: n, chain: chain, txs: map[ethcommon.Hash][]*common.MessagePublication{}}
+		n.watchers[chain] = w
+	}
+	return w
+}
+
+// Message returns a message publication of the chain with the given sequence and payload. The emitter, timestamp and
+// transaction hash are derived from the chain and sequence.
+func (w *MockWatcher) Message(sequence uint64, payload []byte) *common.MessagePublication {
+	var b [10]byte
+	binary.BigEndian.PutUint16(b[:2], uint16(w.chain))
+	binary.BigEndian.PutUint64(b[2:], sequence)
+
+	return &common.MessagePublication{
+		TxHash:           ethcrypto.Keccak256Hash(b[:]),
+		Timestamp:        time.Unix(int64(1_600_000_000+sequence), 0),
+		Nonce:            uint32(sequence),
+		Sequence:         sequence,
+		ConsistencyLevel: 1,
+		EmitterChain:     w.chain,
+		EmitterAddress:   vaa.Address{31: byte(w.chain)},
+		Payload:          payload,
+	}
+}
+
+// Publish emits a message publication to the given guardians, or to all guardians. Guardians which don't receive it
+// can still observe it when it is reobserved.
+func (w *MockWatcher) Publish(msg *common.MessagePublication, guardians ...int) {
+	w.mu.Lock()
+	w.txs[msg.TxHash] = append(w.txs[msg.TxHash], msg)
+	w.mu.Unlock()
+
+	for _, g := range w.n.selectGuardians(guardians) {
+		w.n.publish(g, msg)
+	}
+}
+
+// reobserve emits the messages of a transaction to a guardian again, in response to an observation request.
+func (w *MockWatcher) reobserve(g *Guardian, txHash ethcommon.Hash) {
+	w.mu.Lock()
+	msgs := w.txs[txHash]
+	w.mu.Unlock()
+
+	// The processor may be blocked on sending gossip, so this must not block the gossip network.
+	go func() {
+		for _, msg := range msgs {
+			w.n.publish(g, msg)
+		}
+	}()
+}
@@ -6,12 +6,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/certusone/wormhole/node/pkg/catchup"
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/faultinject"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	"github.com/certusone/wormhole/node/pkg/readiness"
@@ -31,10 +32,10 @@ type (
 		nearRPC          string
 		wormholeContract string
 
-		msgChan  chan *common.MessagePublication
+		msgChan  chan<- *common.MessagePublication
 		obsvReqC chan *gossipv1.ObservationRequest
 
-		next_round  uint64
+		catchup     *catchup.Scheduler
 		final_round uint64
 
 		pending   map[pendingKey]*pendingMessage
@@ -68,7 +69,7 @@ var (
 func NewWatcher(
 	nearRPC string,
 	wormholeContract string,
-	lockEvents chan *common.MessagePublication,
+	lockEvents chan<- *common.MessagePublication,
 	obsvReqC chan *gossipv1.ObservationRequest,
 ) *Watcher {
 	return &Watcher{
@@ -76,7 +77,6 @@ func NewWatcher(
 		wormholeContract: wormholeContract,
 		msgChan:          lockEvents,
 		obsvReqC:         obsvReqC,
-		next_round:       0,
 		final_round:      0,
 		pending:          map[pendingKey]*pendingMessage{},
 	}
@@ -84,7 +84,7 @@ func NewWatcher(
 
 func (e *Watcher) getBlock(block uint64) ([]byte, error) {
 	s := fmt.Sprintf(`{"id": "dontcare", "jsonrpc": "2.0", "method": "block", "params": {"block_id": %d}}`, block)
-	resp, err := http.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
+	resp, err := faultinject.HTTPClient.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
 
 	if err != nil {
 		return nil, err
@@ -96,7 +96,7 @@ func (e *Watcher) getBlock(block uint64) ([]byte, error) {
 
 func (e *Watcher) getFinalBlock() ([]byte, error) {
 	s := `{"id": "dontcare", "jsonrpc": "2.0", "method": "block", "params": {"finality": "final"}}`
-	resp, err := http.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
+	resp, err := faultinject.HTTPClient.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
 
 	if err != nil {
 		return nil, err
@@ -109,7 +109,7 @@ func (e *Watcher) getFinalBlock() ([]byte, error) {
 func (e *Watcher) getChunk(chunk string) ([]byte, error) {
 	s := fmt.Sprintf(`{"id": "dontcare", "jsonrpc": "2.0", "method": "chunk", "params": {"chunk_id": "%s"}}`, chunk)
 
-	resp, err := http.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
+	resp, err := faultinject.HTTPClient.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
 
 	if err != nil {
 		return nil, err
@@ -122,7 +122,7 @@ func (e *Watcher) getChunk(chunk string) ([]byte, error) {
 func (e *Watcher) getTxStatus(logger *zap.Logger, tx string, src string) ([]byte, error) {
 	s := fmt.Sprintf(`{"id": "dontcare", "jsonrpc": "2.0", "method": "EXPERIMENTAL_tx_status", "params": ["%s", "%s"]}`, tx, src)
 
-	resp, err := http.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
+	resp, err := faultinject.HTTPClient.Post(e.nearRPC, "application/json", bytes.NewBuffer([]byte(s)))
 
 	if err != nil {
 		return nil, err
@@ -389,7 +389,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 	logger.Info("Near watcher connecting to RPC node ", zap.String("url", e.nearRPC))
 
 	go func() {
-		if e.next_round == 0 {
+		if e.catchup == nil {
 			finalBody, err := e.getFinalBlock()
 			if err != nil {
 				logger.Error("StatusAfterBlock", zap.Error(err))
@@ -397,7 +397,8 @@ func (e *Watcher) Run(ctx context.Context) error {
 				errC <- err
 				return
 			}
-			e.next_round = gjson.ParseBytes(finalBody).Get("result.chunks.0.height_created").Uint()
+			height := gjson.ParseBytes(finalBody).Get("result.chunks.0.height_created").Uint()
+			e.catchup = catchup.NewScheduler(logger, vaa.ChainIDNear.String(), height-1, catchup.Config{})
 		}
 
 		timer := time.NewTicker(time.Second * 1)
@@ -453,44 +454,35 @@ func (e *Watcher) Run(ctx context.Context) error {
 				}
 				e.pendingMu.Unlock()
 
-				logger.Info("lastBlock", zap.Uint64("lastBlock", lastBlock), zap.Uint64("next_round", e.next_round))
-
-				for ; e.next_round <= lastBlock; e.next_round = e.next_round + 1 {
-					if e.next_round == lastBlock {
-						err := e.inspectBody(logger, e.next_round, parsedFinalBody)
-						if err != nil {
-							logger.Error(fmt.Sprintf("inspectBody: %s", err.Error()))
-
-							p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDNear, 1)
-							errC <- err
-							return
-
+				logger.Info("lastBlock", zap.Uint64("lastBlock", lastBlock), zap.Uint64("processed", e.catchup.Processed()))
+
+				err = e.catchup.CatchUp(ctx, lastBlock, func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+					for height := from; height <= to; height++ {
+						body := parsedFinalBody
+						if height != lastBlock {
+							b, err := e.getBlock(height)
+							if err != nil {
+								return height - 1, fmt.Errorf("nearClient.Status: %w", err)
+							}
+							body = gjson.ParseBytes(b)
 						}
-					} else {
-						b, err := e.getBlock(e.next_round)
-						if err != nil {
-							logger.Error(fmt.Sprintf("nearClient.Status: %s", err.Error()))
-
-							p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDNear, 1)
-							errC <- err
-							return
-
-						}
-						err = e.inspectBody(logger, e.next_round, gjson.ParseBytes(b))
-						if err != nil {
-							logger.Error(fmt.Sprintf("inspectBody: %s", err.Error()))
-
-							p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDNear, 1)
-							errC <- err
-							return
-
+						if err := e.inspectBody(logger, height, body); err != nil {
+							return height - 1, fmt.Errorf("inspectBody: %w", err)
 						}
 					}
+					return to, nil
+				})
+				if err != nil {
+					logger.Error(err.Error())
+
+					p2p.DefaultRegistry.AddErrorCount(vaa.ChainIDNear, 1)
+					errC <- err
+					return
 				}
 
-				currentNearHeight.Set(float64(e.next_round - 1))
+				currentNearHeight.Set(float64(e.catchup.Processed()))
 				p2p.DefaultRegistry.SetNetworkStats(vaa.ChainIDNear, &gossipv1.Heartbeat_Network{
-					Height:          int64(e.next_round - 1),
+					Height:          int64(e.catchup.Processed()),
 					ContractAddress: e.wormholeContract,
 				})
 				readiness.SetReady(common.ReadinessNearSyncing)
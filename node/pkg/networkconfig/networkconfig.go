@@ -0,0 +1,238 @@
+// Package networkconfig schedules the activation of guardian features from a signed network configuration.
+//
+// The network configuration is a governance VAA which lists features along with the time from which they are active.
+// Once the guardians reach quorum on it, it is gossiped like any other signed VAA, and every node applies it after
+// verifying it against its current guardian set. A feature can then be switched on by all guardians at the same time,
+// without coordinating a flag change across the operators.
+//
+// A configuration replaces the previous one only if its version is higher, so replaying an old configuration has no
+// effect. The applied configuration is kept in the database to survive restarts.
+package networkconfig
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var networkConfigVersion = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "wormhole_network_config_version",
+		Help: "Version of the network configuration applied by the node, 0 if none",
+	})
+
+// Config holds the activation times of the features listed in a network configuration VAA.
+type Config struct {
+	Version  uint64
+	Features map[string]time.Time
+}
+
+// ParsePayload parses the payload of a network configuration governance VAA.
+func ParsePayload(payload []byte) (*Config, error) {
+	reader := bytes.NewReader(payload)
+
+	module := make([]byte, 32)
+	if n, err := reader.Read(module); err != nil || n != 32 {
+		return nil, errors.New("failed to read module")
+	}
+	if !bytes.Equal(module, vaa.NetworkConfigModule) {
+		return nil, errors.New("not a network config payload")
+	}
+
+	var action uint8
+	if err := binary.Read(reader, binary.BigEndian, &action); err != nil {
+		return nil, fmt.Errorf("failed to read action: %w", err)
+	}
+	if action != 1 {
+		return nil, fmt.Errorf("unknown action %d", action)
+	}
+
+	var targetChain vaa.ChainID
+	if err := binary.Read(reader, binary.BigEndian, &targetChain); err != nil {
+		return nil, fmt.Errorf("failed to read target chain: %w", err)
+	}
+	if targetChain != 0 {
+		return nil, fmt.Errorf("unexpected target chain %v", targetChain)
+	}
+
+	c := &Config{Features: make(map[string]time.Time)}
+	if err := binary.Read(reader, binary.BigEndian, &c.Version); err != nil {
+		return nil, fmt.Errorf("failed to read version: %w", err)
+	}
+
+	var numFeatures uint8
+	if err := binary.Read(reader, binary.BigEndian, &numFeatures); err != nil {
+		return nil, fmt.Errorf("failed to read number of features: %w", err)
+	}
+
+	for i := 0; i < int(numFeatures); i++ {
+		name := make([]byte, 32)
+		if n, err := reader.Read(name); err != nil || n != 32 {
+			return nil, fmt.Errorf("failed to read name of feature %d", i)
+		}
+		var activationTime uint64
+		if err := binary.Read(reader, binary.BigEndian, &activationTime); err != nil {
+			return nil, fmt.Errorf("failed to read activation time of feature %d: %w", i, err)
+		}
+		feature := string(bytes.TrimLeft(name, "\x00"))
+		if feature == "" {
+			return nil, fmt.Errorf("feature %d has no name", i)
+		}
+		if _, exists := c.Features[feature]; exists {
+			return nil, fmt.Errorf("duplicate feature %s", feature)
+		}
+		c.Features[feature] = time.Unix(int64(activationTime), 0)
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes", reader.Len())
+	}
+
+	return c, nil
+}
+
+// IsNetworkConfigVAA returns whether a VAA is a network configuration governance VAA, without parsing it.
+func IsNetworkConfigVAA(v *vaa.VAA) bool {
+	return v.EmitterChain == vaa.GovernanceChain && v.EmitterAddress == vaa.GovernanceEmitter &&
+		bytes.HasPrefix(v.Payload, vaa.NetworkConfigModule)
+}
+
+// ParseVAA parses a network configuration governance VAA. The signatures are not verified.
+func ParseVAA(v *vaa.VAA) (*Config, error) {
+	if v.EmitterChain != vaa.GovernanceChain || v.EmitterAddress != vaa.GovernanceEmitter {
+		return nil, fmt.Errorf("not a governance VAA (emitter %v:%v)", v.EmitterChain, v.EmitterAddress)
+	}
+	return ParsePayload(v.Payload)
+}
+
+// DB stores the applied network configuration VAA.
+type DB interface {
+	StoreNetworkConfigVAA(b []byte) error
+	// GetNetworkConfigVAA returns nil if no configuration was stored.
+	GetNetworkConfigVAA() ([]byte, error)
+}
+
+// State holds the network configuration applied by the node. It is safe for concurrent use, and its methods can be
+// called on a nil state, which has no configuration.
+type State struct {
+	logger *zap.Logger
+	db     DB
+
+	mu     sync.RWMutex
+	config *Config
+	signed []byte
+}
+
+// NewState returns a state without configuration. db may be nil, in which case the configuration is not persisted.
+func NewState(logger *zap.Logger, db DB) *State {
+	return &State{logger: logger, db: db}
+}
+
+// Load applies the configuration stored in the database, if any. Its signatures are not verified again, since only
+// verified configurations are stored.
+func (s *State) Load() error {
+	b, err := s.db.GetNetworkConfigVAA()
+	if err != nil {
+		return fmt.Errorf("failed to read network config: %w", err)
+	}
+	if b == nil {
+		return nil
+	}
+
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal network config VAA: %w", err)
+	}
+	c, err := ParseVAA(v)
+	if err != nil {
+		return fmt.Errorf("failed to parse network config VAA: %w", err)
+	}
+
+	s.mu.Lock()
+	s.config, s.signed = c, b
+	s.mu.Unlock()
+	networkConfigVersion.Set(float64(c.Version))
+	s.logger.Info("loaded network config", zap.Uint64("version", c.Version), zap.Any("features", c.Features))
+	return nil
+}
+
+// ProcessGovernanceVAA applies a network configuration VAA which reached quorum, if its version is higher than the
+// applied one. Other VAAs are ignored. The caller must have verified the signatures against the current guardian set.
+// Returns whether the configuration was applied.
+func (s *State) ProcessGovernanceVAA(v *vaa.VAA) bool {
+	if s == nil || !IsNetworkConfigVAA(v) {
+		return false
+	}
+
+	c, err := ParseVAA(v)
+	if err != nil {
+		s.logger.Error("failed to parse network config", zap.String("message_id", v.MessageID()), zap.Error(err))
+		return false
+	}
+
+	b, err := v.Marshal()
+	if err != nil {
+		s.logger.Error("failed to marshal network config", zap.String("message_id", v.MessageID()), zap.Error(err))
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config != nil && c.Version <= s.config.Version {
+		s.logger.Debug("ignoring network config not newer than the applied one",
+			zap.Uint64("version", c.Version), zap.Uint64("applied_version", s.config.Version))
+		return false
+	}
+
+	if s.db != nil {
+		if err := s.db.StoreNetworkConfigVAA(b); err != nil {
+			s.logger.Error("failed to store network config", zap.Uint64("version", c.Version), zap.Error(err))
+			return false
+		}
+	}
+
+	s.config, s.signed = c, b
+	networkConfigVersion.Set(float64(c.Version))
+	s.logger.Info("applied network config", zap.Uint64("version", c.Version), zap.Any("features", c.Features))
+	return true
+}
+
+// Config returns the applied configuration, or nil if there is none. It must not be modified.
+func (s *State) Config() *Config {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// SignedVAA returns the signed VAA of the applied configuration, or nil if there is none.
+func (s *State) SignedVAA() []byte {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signed
+}
+
+// IsActive returns whether a feature is active at the given time. Features absent from the configuration are not
+// active.
+func (s *State) IsActive(feature string, now time.Time) bool {
+	c := s.Config()
+	if c == nil {
+		return false
+	}
+	t, ok := c.Features[feature]
+	return ok && !now.Before(t)
+}
@@ -0,0 +1,87 @@
+package networkconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func configVAA(version uint64, features ...vaa.NetworkConfigFeature) *vaa.VAA {
+	return vaa.CreateGovernanceVAA(time.Unix(1_700_000_000, 0), 1, version, 0, vaa.BodyNetworkConfig{
+		Version:  version,
+		Features: features,
+	}.Serialize())
+}
+
+func TestParseVAA(t *testing.T) {
+	c, err := ParseVAA(configVAA(2, vaa.NetworkConfigFeature{Name: "batch", ActivationTime: 1_700_000_100}))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), c.Version)
+	assert.Equal(t, map[string]time.Time{"batch": time.Unix(1_700_000_100, 0)}, c.Features)
+
+	// Not from the governance emitter.
+	v := configVAA(2)
+	v.EmitterChain = vaa.ChainIDEthereum
+	_, err = ParseVAA(v)
+	assert.Error(t, err)
+
+	// Duplicate feature.
+	_, err = ParseVAA(configVAA(2, vaa.NetworkConfigFeature{Name: "batch"}, vaa.NetworkConfigFeature{Name: "batch"}))
+	assert.Error(t, err)
+
+	// Trailing bytes.
+	v = configVAA(2)
+	v.Payload = append(v.Payload, 0)
+	_, err = ParseVAA(v)
+	assert.Error(t, err)
+
+	// Another module.
+	_, err = ParsePayload(vaa.BodyContractRegistry{Version: 2}.Serialize())
+	assert.Error(t, err)
+}
+
+func TestState(t *testing.T) {
+	database, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer database.Close()
+
+	s := NewState(zap.NewNop(), database)
+	now := time.Unix(1_700_000_050, 0)
+	assert.False(t, s.IsActive("batch", now))
+
+	// Other VAAs are ignored.
+	assert.False(t, s.ProcessGovernanceVAA(vaa.CreateGovernanceVAA(now, 1, 1, 0, vaa.BodyContractRegistry{Version: 5}.Serialize())))
+
+	assert.True(t, s.ProcessGovernanceVAA(configVAA(2, vaa.NetworkConfigFeature{Name: "batch", ActivationTime: 1_700_000_100})))
+	assert.False(t, s.IsActive("batch", now))
+	assert.True(t, s.IsActive("batch", now.Add(50*time.Second)))
+	assert.False(t, s.IsActive("other", now.Add(time.Hour)))
+
+	// Older and identical versions do not replace the applied configuration.
+	assert.False(t, s.ProcessGovernanceVAA(configVAA(1, vaa.NetworkConfigFeature{Name: "batch", ActivationTime: 0})))
+	assert.False(t, s.ProcessGovernanceVAA(configVAA(2, vaa.NetworkConfigFeature{Name: "batch", ActivationTime: 0})))
+	assert.False(t, s.IsActive("batch", now))
+
+	assert.True(t, s.ProcessGovernanceVAA(configVAA(3, vaa.NetworkConfigFeature{Name: "batch", ActivationTime: 0})))
+	assert.True(t, s.IsActive("batch", now))
+
+	// The applied configuration survives a restart.
+	loaded := NewState(zap.NewNop(), database)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, uint64(3), loaded.Config().Version)
+	assert.Equal(t, s.SignedVAA(), loaded.SignedVAA())
+	assert.True(t, loaded.IsActive("batch", now))
+}
+
+func TestNilState(t *testing.T) {
+	var s *State
+	assert.False(t, s.ProcessGovernanceVAA(configVAA(1)))
+	assert.False(t, s.IsActive("batch", time.Now()))
+	assert.Nil(t, s.Config())
+	assert.Nil(t, s.SignedVAA())
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
@@ -156,3 +157,46 @@ func (d *DiscordNotifier) MissingSignaturesOnObservation(o Observation, hasSigs,
 
 	return nil
 }
+
+// CircuitBreakerTripped pages the channel when the circuit breaker paused signing on a chain.
+func (d *DiscordNotifier) CircuitBreakerTripped(chainID vaa.ChainID, reason string, detail string, messageID string) error {
+	for _, cn := range d.chans {
+		if _, err := d.c.SendMessage(cn.ID, "**SIGNING PAUSED** - the circuit breaker fired, resume signing once investigated @here",
+			discord.Embed{
+				Title: "Circuit breaker tripped",
+				Fields: []discord.EmbedField{
+					{Name: "Source Chain", Value: strings.Title(chainID.String()), Inline: true},
+					{Name: "Heuristic", Value: wrapCode(reason), Inline: true},
+					{Name: "Message ID", Value: wrapCode(messageID), Inline: false},
+					{Name: "Detail", Value: detail, Inline: false},
+				},
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SigningAnomaly pages the channel when an unexpected signing pattern was detected in the signing audit log.
+func (d *DiscordNotifier) SigningAnomaly(a guardiansigner.Anomaly) error {
+	for _, cn := range d.chans {
+		if _, err := d.c.SendMessage(cn.ID, "**UNEXPECTED SIGNING PATTERN** - check that the guardian key and host are not compromised @here",
+			discord.Embed{
+				Title: "Signing anomaly",
+				Fields: []discord.EmbedField{
+					{Name: "Rule", Value: wrapCode(a.Rule), Inline: true},
+					{Name: "Type", Value: wrapCode(a.Entry.Type), Inline: true},
+					{Name: "Audit Log Entry", Value: wrapCode(fmt.Sprint(a.Entry.Index)), Inline: true},
+					{Name: "Digest", Value: wrapCode(a.Entry.Digest), Inline: false},
+					{Name: "Detail", Value: a.Detail, Inline: false},
+				},
+			},
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,276 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// The availability tracker samples the network overview every minute, and counts for each guardian of the current
+// guardian set how often it was available. A guardian is available if one of its nodes sent a heartbeat recently, and
+// it is available on a chain if its heartbeat reports a height for the chain which is not stuck behind the median
+// height of the guardians. The samples are aggregated per day (UTC) and stored in the database, so the availability
+// of the guardians over the last weeks can be compared from any node.
+
+const (
+	availabilityInterval      = time.Minute
+	availabilityFlushInterval = 5 * time.Minute
+	// A guardian whose height for a chain is behind the median is only unavailable once its height stopped advancing
+	// for this long, so that heartbeats sent just before a new block do not count against it.
+	availabilityStallAfter = 10 * time.Minute
+	// AvailabilityRetention is how long the daily aggregates are kept.
+	AvailabilityRetention = 90 * 24 * time.Hour
+)
+
+var availabilityWindows = []int{1, 7, 30}
+
+var (
+	guardianAvailabilityHeartbeat = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_heartbeat_availability",
+			Help: "Ratio of the samples in which the given guardian sent heartbeats, over the given number of days (including today)",
+		}, []string{"guardian_addr", "window"})
+	guardianAvailabilityChain = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_chain_availability",
+			Help: "Ratio of the samples in which the given guardian was available on the given chain, over the given number of days (including today)",
+		}, []string{"guardian_addr", "chain", "window"})
+)
+
+type AvailabilityDB interface {
+	StoreGuardianAvailability(aggregates []*db.GuardianAvailability) error
+	GetGuardianAvailability(since time.Time) ([]*db.GuardianAvailability, error)
+	PruneGuardianAvailability(before time.Time) (int, error)
+}
+
+type availabilityKey struct {
+	guardian ethcommon.Address
+	chain    vaa.ChainID
+}
+
+// heightProgress is the last time a guardian's height for a chain advanced.
+type heightProgress struct {
+	height int64
+	since  time.Time
+}
+
+type availabilityTracker struct {
+	day      time.Time
+	counts   map[availabilityKey]*db.GuardianAvailability
+	progress map[availabilityKey]heightProgress
+}
+
+func newAvailabilityTracker() *availabilityTracker {
+	return &availabilityTracker{
+		counts:   make(map[availabilityKey]*db.GuardianAvailability),
+		progress: make(map[availabilityKey]heightProgress),
+	}
+}
+
+func availabilityDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// load resumes counting from the aggregates of the current day.
+func (t *availabilityTracker) load(aggregates []*db.GuardianAvailability, now time.Time) {
+	t.day = availabilityDay(now)
+	for _, a := range aggregates {
+		if a.Day.Equal(t.day) {
+			c := *a
+			t.counts[availabilityKey{a.Guardian, a.Chain}] = &c
+		}
+	}
+}
+
+func (t *availabilityTracker) count(guardian ethcommon.Address, chain vaa.ChainID, available bool) {
+	k := availabilityKey{guardian, chain}
+	c, ok := t.counts[k]
+	if !ok {
+		c = &db.GuardianAvailability{Day: t.day, Guardian: guardian, Chain: chain}
+		t.counts[k] = c
+	}
+	c.Samples++
+	if available {
+		c.Available++
+	}
+}
+
+// sample counts the availability of each guardian in overview. The chains sampled are those reported by any guardian.
+// It returns the aggregates of the previous day if the day changed, which must be stored before they are lost.
+func (t *availabilityTracker) sample(overview []*guardianOverview, now time.Time) (finished []*db.GuardianAvailability) {
+	if day := availabilityDay(now); !day.Equal(t.day) {
+		finished = t.aggregates()
+		t.day = day
+		t.counts = make(map[availabilityKey]*db.GuardianAvailability)
+	}
+
+	// If this node doesn't see any other guardian, it is likely itself cut off from the network.
+	present := 0
+	chains := make(map[vaa.ChainID]bool)
+	for _, g := range overview {
+		if !g.missing {
+			present++
+		}
+		for chain := range g.heights {
+			chains[chain] = true
+		}
+	}
+	if present <= 1 {
+		return finished
+	}
+
+	for _, g := range overview {
+		t.count(g.addr, vaa.ChainIDUnset, !g.missing)
+
+		for chain := range chains {
+			k := availabilityKey{g.addr, chain}
+			h, ok := g.heights[chain]
+			if p, seen := t.progress[k]; !seen || h != p.height {
+				t.progress[k] = heightProgress{height: h, since: now}
+			}
+
+			available := !g.missing && ok && h > 0 && (g.lags[chain] == 0 || now.Sub(t.progress[k].since) <= availabilityStallAfter)
+			t.count(g.addr, chain, available)
+		}
+	}
+
+	return finished
+}
+
+func (t *availabilityTracker) aggregates() []*db.GuardianAvailability {
+	res := make([]*db.GuardianAvailability, 0, len(t.counts))
+	for _, c := range t.counts {
+		a := *c
+		res = append(res, &a)
+	}
+	return res
+}
+
+// SumAvailability adds up the daily aggregates of each guardian and chain from the day of since onwards. The day of
+// the returned aggregates is that of since.
+func SumAvailability(aggregates []*db.GuardianAvailability, since time.Time) []*db.GuardianAvailability {
+	since = availabilityDay(since)
+	sums := make(map[availabilityKey]*db.GuardianAvailability)
+	for _, a := range aggregates {
+		if a.Day.Before(since) {
+			continue
+		}
+		k := availabilityKey{a.Guardian, a.Chain}
+		s, ok := sums[k]
+		if !ok {
+			s = &db.GuardianAvailability{Day: since, Guardian: a.Guardian, Chain: a.Chain}
+			sums[k] = s
+		}
+		s.Samples += a.Samples
+		s.Available += a.Available
+	}
+
+	res := make([]*db.GuardianAvailability, 0, len(sums))
+	for _, s := range sums {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Guardian != res[j].Guardian {
+			return res[i].Guardian.Hex() < res[j].Guardian.Hex()
+		}
+		return res[i].Chain < res[j].Chain
+	})
+	return res
+}
+
+// updateAvailabilityMetrics sets the availability ratios of the guardians in gs over each window.
+func updateAvailabilityMetrics(gs *common.GuardianSet, aggregates []*db.GuardianAvailability, now time.Time) {
+	guardianAvailabilityHeartbeat.Reset()
+	guardianAvailabilityChain.Reset()
+
+	for _, days := range availabilityWindows {
+		window := fmt.Sprintf("%dd", days)
+		for _, a := range SumAvailability(aggregates, now.AddDate(0, 0, 1-days)) {
+			if _, ok := gs.KeyIndex(a.Guardian); !ok || a.Samples == 0 {
+				continue
+			}
+			ratio := float64(a.Available) / float64(a.Samples)
+			if a.Chain == vaa.ChainIDUnset {
+				guardianAvailabilityHeartbeat.WithLabelValues(a.Guardian.Hex(), window).Set(ratio)
+			} else {
+				guardianAvailabilityChain.WithLabelValues(a.Guardian.Hex(), a.Chain.String(), window).Set(ratio)
+			}
+		}
+	}
+}
+
+// AvailabilityRunnable periodically samples the availability of the guardians, stores the daily aggregates in the
+// database and reports the ratios as the wormhole_network_guardian_*_availability metrics.
+func AvailabilityRunnable(logger *zap.Logger, database AvailabilityDB, gst *common.GuardianSetState) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		logger := logger.With(zap.String("component", "availability"))
+
+		t := newAvailabilityTracker()
+		today, err := database.GetGuardianAvailability(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to load guardian availability: %w", err)
+		}
+		t.load(today, time.Now())
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		ticker := time.NewTicker(availabilityInterval)
+		defer ticker.Stop()
+		lastFlush := time.Now()
+		lastPrune := time.Time{}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if err := database.StoreGuardianAvailability(t.aggregates()); err != nil {
+					logger.Error("failed to store guardian availability", zap.Error(err))
+				}
+				return nil
+			case <-ticker.C:
+				gs := gst.Get()
+				if gs == nil {
+					continue
+				}
+				now := time.Now()
+				finished := t.sample(networkOverview(gs, gst.GetAll(), now), now)
+				if finished == nil && now.Sub(lastFlush) < availabilityFlushInterval {
+					continue
+				}
+
+				if err := database.StoreGuardianAvailability(append(finished, t.aggregates()...)); err != nil {
+					logger.Error("failed to store guardian availability", zap.Error(err))
+					continue
+				}
+				lastFlush = now
+
+				if now.Sub(lastPrune) > 24*time.Hour {
+					n, err := database.PruneGuardianAvailability(now.Add(-AvailabilityRetention))
+					if err != nil {
+						logger.Error("failed to prune guardian availability", zap.Error(err))
+					} else {
+						logger.Debug("pruned guardian availability", zap.Int("deleted", n))
+						lastPrune = now
+					}
+				}
+
+				aggregates, err := database.GetGuardianAvailability(now.AddDate(0, 0, -availabilityWindows[len(availabilityWindows)-1]))
+				if err != nil {
+					logger.Error("failed to load guardian availability", zap.Error(err))
+					continue
+				}
+				updateAvailabilityMetrics(gs, aggregates, now)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailabilitySample(t *testing.T) {
+	now := time.Date(2022, 11, 3, 23, 0, 0, 0, time.UTC)
+	a := ethcommon.HexToAddress("0x01")
+	b := ethcommon.HexToAddress("0x02")
+	c := ethcommon.HexToAddress("0x03")
+
+	g := func(addr ethcommon.Address, missing bool, ethHeight int64, ethLag int64) *guardianOverview {
+		o := &guardianOverview{addr: addr, missing: missing, heights: map[vaa.ChainID]int64{}, lags: map[vaa.ChainID]int64{}}
+		if ethHeight != 0 {
+			o.heights[vaa.ChainIDEthereum] = ethHeight
+			o.lags[vaa.ChainIDEthereum] = ethLag
+		}
+		return o
+	}
+
+	tracker := newAvailabilityTracker()
+	tracker.load([]*db.GuardianAvailability{
+		// Counts of the current day are resumed, older ones are ignored.
+		{Day: time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC), Guardian: a, Chain: vaa.ChainIDUnset, Samples: 10, Available: 9},
+		{Day: time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC), Guardian: b, Chain: vaa.ChainIDUnset, Samples: 10, Available: 9},
+	}, now)
+
+	// a is up to date, b lags behind but its height advances, c does not report ethereum.
+	for i := int64(0); i < 5; i++ {
+		assert.Nil(t, tracker.sample([]*guardianOverview{g(a, false, 100+i, 0), g(b, false, 90+i, 10), g(c, false, 0, 0)}, now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	counts := func(addr ethcommon.Address, chain vaa.ChainID) (uint32, uint32) {
+		x := tracker.counts[availabilityKey{addr, chain}]
+		require.NotNil(t, x, "%v %v", addr, chain)
+		return x.Samples, x.Available
+	}
+	s, av := counts(a, vaa.ChainIDUnset)
+	assert.Equal(t, [2]uint32{15, 14}, [2]uint32{s, av})
+	s, av = counts(a, vaa.ChainIDEthereum)
+	assert.Equal(t, [2]uint32{5, 5}, [2]uint32{s, av})
+	s, av = counts(b, vaa.ChainIDEthereum)
+	assert.Equal(t, [2]uint32{5, 5}, [2]uint32{s, av})
+	s, av = counts(c, vaa.ChainIDUnset)
+	assert.Equal(t, [2]uint32{5, 5}, [2]uint32{s, av})
+	s, av = counts(c, vaa.ChainIDEthereum)
+	assert.Equal(t, [2]uint32{5, 0}, [2]uint32{s, av})
+
+	// b's height stops advancing behind the median. It is only unavailable after availabilityStallAfter.
+	stalled := now.Add(5 * time.Minute)
+	assert.Nil(t, tracker.sample([]*guardianOverview{g(a, false, 200, 0), g(b, false, 94, 106), g(c, true, 0, 0)}, stalled))
+	assert.Nil(t, tracker.sample([]*guardianOverview{g(a, false, 201, 0), g(b, false, 94, 107), g(c, true, 0, 0)}, stalled.Add(availabilityStallAfter+time.Second)))
+	s, av = counts(b, vaa.ChainIDEthereum)
+	assert.Equal(t, [2]uint32{7, 6}, [2]uint32{s, av})
+	s, av = counts(c, vaa.ChainIDUnset)
+	assert.Equal(t, [2]uint32{7, 5}, [2]uint32{s, av})
+
+	// If no other guardian is seen, the sample is discarded.
+	tracker.sample([]*guardianOverview{g(a, false, 202, 0), g(b, true, 0, 0), g(c, true, 0, 0)}, stalled.Add(availabilityStallAfter+2*time.Second))
+	s, _ = counts(a, vaa.ChainIDUnset)
+	assert.Equal(t, uint32(17), s)
+
+	// The counts of the previous day are returned when the day changes.
+	finished := tracker.sample([]*guardianOverview{g(a, false, 300, 0), g(b, false, 300, 0)}, now.Add(time.Hour))
+	require.Len(t, finished, 6)
+	for _, f := range finished {
+		assert.Equal(t, time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC), f.Day)
+	}
+	s, av = counts(a, vaa.ChainIDUnset)
+	assert.Equal(t, [2]uint32{1, 1}, [2]uint32{s, av})
+	assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), tracker.counts[availabilityKey{a, vaa.ChainIDUnset}].Day)
+}
+
+func TestSumAvailability(t *testing.T) {
+	a := ethcommon.HexToAddress("0x01")
+	b := ethcommon.HexToAddress("0x02")
+	day := func(d int) time.Time { return time.Date(2022, 11, d, 0, 0, 0, 0, time.UTC) }
+
+	sums := SumAvailability([]*db.GuardianAvailability{
+		{Day: day(1), Guardian: a, Chain: vaa.ChainIDUnset, Samples: 100, Available: 0},
+		{Day: day(2), Guardian: b, Chain: vaa.ChainIDEthereum, Samples: 100, Available: 50},
+		{Day: day(2), Guardian: a, Chain: vaa.ChainIDUnset, Samples: 100, Available: 90},
+		{Day: day(3), Guardian: a, Chain: vaa.ChainIDUnset, Samples: 100, Available: 100},
+	}, day(2).Add(12*time.Hour))
+
+	assert.Equal(t, []*db.GuardianAvailability{
+		{Day: day(2), Guardian: a, Chain: vaa.ChainIDUnset, Samples: 200, Available: 190},
+		{Day: day(2), Guardian: b, Chain: vaa.ChainIDEthereum, Samples: 100, Available: 50},
+	}, sums)
+}
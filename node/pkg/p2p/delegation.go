@@ -0,0 +1,123 @@
+package p2p
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// A guardian can delegate the signing of its heartbeats and observation requests to a gossip key, so that the guardian
+// key, which may be held in an HSM or KMS with limited throughput, only signs observations. The guardian key signs a
+// delegation of the gossip key, valid until an expiry, which is attached to each message signed by the gossip key.
+// Receivers attribute such messages to the guardian that signed the delegation.
+
+var gossipKeyDelegationPrefix = []byte("gossip_key_delegation|")
+
+func gossipKeyDelegationDigest(delegate common.Address, expiry int64) common.Hash {
+	var e [8]byte
+	binary.BigEndian.PutUint64(e[:], uint64(expiry))
+	return ethcrypto.Keccak256Hash(gossipKeyDelegationPrefix, delegate.Bytes(), e[:])
+}
+
+// GossipSigner signs heartbeats and observation requests with a delegated gossip key.
+type GossipSigner struct {
+	gossipKey guardiansigner.GuardianSigner
+	// How long the delegations signed by the guardian key are valid. They are renewed once half of it has elapsed.
+	validity time.Duration
+
+	mu sync.Mutex
+	// Delegations by guardian address, since the guardian key changes during a key rotation.
+	delegations map[common.Address]*gossipv1.GossipKeyDelegation
+}
+
+// NewGossipSigner creates a signer delegating to gossipKey, with delegations valid for the given duration.
+func NewGossipSigner(gossipKey guardiansigner.GuardianSigner, validity time.Duration) *GossipSigner {
+	return &GossipSigner{
+		gossipKey:   gossipKey,
+		validity:    validity,
+		delegations: make(map[common.Address]*gossipv1.GossipKeyDelegation),
+	}
+}
+
+// delegation returns a delegation of the gossip key signed by the guardian key, signing a new one if there is none or
+// it is about to expire.
+func (s *GossipSigner) delegation(ctx context.Context, key guardiansigner.GuardianSigner, now time.Time) (*gossipv1.GossipKeyDelegation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guardian := guardiansigner.Address(key)
+	if d, ok := s.delegations[guardian]; ok && time.Unix(d.Expiry, 0).Sub(now) > s.validity/2 {
+		return d, nil
+	}
+
+	delegate := guardiansigner.Address(s.gossipKey)
+	expiry := now.Add(s.validity).Unix()
+	digest := gossipKeyDelegationDigest(delegate, expiry)
+	sig, err := key.Sign(guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{Type: guardiansigner.AuditTypeGossipKeyDelegation}), digest.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign gossip key delegation: %w", err)
+	}
+
+	d := &gossipv1.GossipKeyDelegation{DelegateAddr: delegate.Bytes(), Expiry: expiry, Signature: sig}
+	s.delegations[guardian] = d
+	return d, nil
+}
+
+// Sign signs the digest of a heartbeat or observation request on behalf of the guardian key, and returns the delegation
+// to attach to it. Without a gossip signer, the digest is signed by the guardian key and the delegation is nil.
+func (s *GossipSigner) Sign(ctx context.Context, key guardiansigner.GuardianSigner, digest []byte) ([]byte, *gossipv1.GossipKeyDelegation, error) {
+	if s == nil {
+		sig, err := key.Sign(ctx, digest)
+		return sig, nil, err
+	}
+
+	d, err := s.delegation(ctx, key, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := s.gossipKey.Sign(ctx, digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, d, nil
+}
+
+func recoverAddress(digest common.Hash, sig []byte) (common.Address, error) {
+	pubKey, err := ethcrypto.Ecrecover(digest.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, errors.New("failed to recover public key")
+	}
+	return common.BytesToAddress(ethcrypto.Keccak256(pubKey[1:])[12:]), nil
+}
+
+// recoverGossipSigner returns the address of the guardian on whose behalf a heartbeat or observation request was
+// signed. That is the signer itself, or the guardian which signed the delegation if one is attached.
+func recoverGossipSigner(digest common.Hash, sig []byte, d *gossipv1.GossipKeyDelegation, now time.Time) (common.Address, error) {
+	signer, err := recoverAddress(digest, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if d == nil {
+		return signer, nil
+	}
+
+	if common.BytesToAddress(d.DelegateAddr) != signer {
+		return common.Address{}, fmt.Errorf("signer %v is not the delegated gossip key", signer)
+	}
+	if now.Unix() > d.Expiry {
+		return common.Address{}, fmt.Errorf("gossip key delegation expired at %v", time.Unix(d.Expiry, 0))
+	}
+	guardian, err := recoverAddress(gossipKeyDelegationDigest(signer, d.Expiry), d.Signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid gossip key delegation: %w", err)
+	}
+	return guardian, nil
+}
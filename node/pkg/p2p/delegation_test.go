@@ -0,0 +1,121 @@
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	node_common "github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
+
+func TestGossipSignerDelegatesToGossipKey(t *testing.T) {
+	ctx := context.Background()
+	gk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	gossipKey := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	s := NewGossipSigner(gossipKey, time.Hour)
+
+	digest := ethcrypto.Keccak256Hash([]byte("heartbeat"))
+	sig, d, err := s.Sign(ctx, gk, digest.Bytes())
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Equal(t, guardiansigner.Address(gossipKey).Bytes(), d.DelegateAddr)
+
+	signer, err := recoverAddress(digest, sig)
+	require.NoError(t, err)
+	assert.Equal(t, guardiansigner.Address(gossipKey), signer)
+
+	guardian, err := recoverGossipSigner(digest, sig, d, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, guardiansigner.Address(gk), guardian)
+
+	// The delegation is reused until half of its validity has elapsed.
+	_, d2, err := s.Sign(ctx, gk, digest.Bytes())
+	require.NoError(t, err)
+	assert.Same(t, d, d2)
+
+	d3, err := s.delegation(ctx, gk, time.Now().Add(31*time.Minute))
+	require.NoError(t, err)
+	assert.Greater(t, d3.Expiry, d.Expiry)
+
+	// A delegation is signed for each guardian key, as the key changes during a key rotation.
+	nextGk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	sig, d, err = s.Sign(ctx, nextGk, digest.Bytes())
+	require.NoError(t, err)
+	guardian, err = recoverGossipSigner(digest, sig, d, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, guardiansigner.Address(nextGk), guardian)
+}
+
+func TestRecoverGossipSignerRejectsInvalidDelegations(t *testing.T) {
+	ctx := context.Background()
+	gk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	s := NewGossipSigner(guardiansigner.NewLocalSigner(mustGenerateKey(t)), time.Hour)
+
+	digest := ethcrypto.Keccak256Hash([]byte("heartbeat"))
+	sig, d, err := s.Sign(ctx, gk, digest.Bytes())
+	require.NoError(t, err)
+
+	_, err = recoverGossipSigner(digest, sig, d, time.Now().Add(2*time.Hour))
+	assert.ErrorContains(t, err, "expired")
+
+	// A message signed by another key cannot use the delegation.
+	otherSig, err := guardiansigner.NewLocalSigner(mustGenerateKey(t)).Sign(ctx, digest.Bytes())
+	require.NoError(t, err)
+	_, err = recoverGossipSigner(digest, otherSig, d, time.Now())
+	assert.ErrorContains(t, err, "not the delegated gossip key")
+
+	// Extending the expiry invalidates the signature of the delegation, which then recovers to another guardian.
+	d.Expiry += 3600
+	guardian, err := recoverGossipSigner(digest, sig, d, time.Now())
+	require.NoError(t, err)
+	assert.NotEqual(t, guardiansigner.Address(gk), guardian)
+}
+
+func TestProcessSignedHeartbeatRejectsTamperedDelegation(t *testing.T) {
+	ctx := context.Background()
+	gk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	s := NewGossipSigner(guardiansigner.NewLocalSigner(mustGenerateKey(t)), time.Hour)
+	gs := &node_common.GuardianSet{Keys: []common.Address{guardiansigner.Address(gk)}}
+
+	b, err := proto.Marshal(&gossipv1.Heartbeat{NodeName: "guardian", GuardianAddr: guardiansigner.Address(gk).Hex()})
+	require.NoError(t, err)
+	sig, d, err := s.Sign(ctx, gk, heartbeatDigest(b).Bytes())
+	require.NoError(t, err)
+	signed := &gossipv1.SignedHeartbeat{Heartbeat: b, Signature: sig, GuardianAddr: guardiansigner.Address(gk).Bytes(), Delegation: d}
+
+	_, err = processSignedHeartbeat("", signed, gs, node_common.NewGuardianSetState(), false)
+	require.NoError(t, err)
+
+	d.Expiry += 3600
+	_, err = processSignedHeartbeat("", signed, gs, node_common.NewGuardianSetState(), false)
+	assert.ErrorContains(t, err, "invalid signer")
+}
+
+func TestNilGossipSignerSignsWithGuardianKey(t *testing.T) {
+	gk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	var s *GossipSigner
+
+	digest := ethcrypto.Keccak256Hash([]byte("heartbeat"))
+	sig, d, err := s.Sign(context.Background(), gk, digest.Bytes())
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	guardian, err := recoverGossipSigner(digest, sig, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, guardiansigner.Address(gk), guardian)
+}
@@ -0,0 +1,118 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	node_common "github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/protobuf/proto"
+)
+
+// The operators vote to release the transfers held by the emergency hold of the chain governor with an admin command.
+// The votes are signed with the guardian key and gossiped along with the heartbeats until the transfer is released.
+
+var governorReleasePrefix = []byte("governor_release|")
+
+func governorReleaseDigest(b []byte) common.Hash {
+	return ethcrypto.Keccak256Hash(append(governorReleasePrefix, b...))
+}
+
+// governorReleaser signs the votes of this node and keeps them to publish them again. Each vote is only signed once,
+// since the guardian key may be held in an HSM or KMS with limited throughput.
+type governorReleaser struct {
+	// Gossip messages of the votes by message ID, and the guardian that signed them.
+	signed map[string]signedGovernorRelease
+}
+
+type signedGovernorRelease struct {
+	guardian common.Address
+	msg      []byte
+}
+
+func newGovernorReleaser() *governorReleaser {
+	return &governorReleaser{signed: make(map[string]signedGovernorRelease)}
+}
+
+// messages returns the gossip messages of the votes of this node for the transfers still held, signing the new ones with
+// key. The votes are also recorded in gov, since gossip messages from ourselves are ignored.
+func (r *governorReleaser) messages(ctx context.Context, gov *governor.ChainGovernor, key guardiansigner.GuardianSigner, now time.Time) ([][]byte, error) {
+	guardian := guardiansigner.Address(key)
+	held := make(map[string]struct{})
+	var res [][]byte
+	for _, msgId := range gov.OwnEmergencyReleases() {
+		held[msgId] = struct{}{}
+
+		s, ok := r.signed[msgId]
+		if !ok || s.guardian != guardian {
+			b, err := signGovernorRelease(ctx, key, msgId, now)
+			if err != nil {
+				return res, err
+			}
+			s = signedGovernorRelease{guardian: guardian, msg: b}
+			r.signed[msgId] = s
+		}
+
+		gov.RecordEmergencyRelease(msgId, guardian)
+		res = append(res, s.msg)
+	}
+
+	for msgId := range r.signed {
+		if _, ok := held[msgId]; !ok {
+			delete(r.signed, msgId)
+		}
+	}
+	return res, nil
+}
+
+func signGovernorRelease(ctx context.Context, key guardiansigner.GuardianSigner, msgId string, now time.Time) ([]byte, error) {
+	b, err := proto.Marshal(&gossipv1.GovernorRelease{MessageId: msgId, Timestamp: now.Unix()})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := governorReleaseDigest(b)
+	sig, err := key.Sign(guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{Type: guardiansigner.AuditTypeGovernorRelease}), digest.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign governor release: %w", err)
+	}
+
+	return proto.Marshal(&gossipv1.GossipMessage{Message: &gossipv1.GossipMessage_SignedGovernorRelease{
+		SignedGovernorRelease: &gossipv1.SignedGovernorRelease{
+			GovernorRelease: b,
+			Signature:       sig,
+			GuardianAddr:    guardiansigner.Address(key).Bytes(),
+		}}})
+}
+
+// processSignedGovernorRelease verifies that a vote was signed by the guardian key of a member of gs, and returns it
+// along with the address of the guardian.
+func processSignedGovernorRelease(s *gossipv1.SignedGovernorRelease, gs *node_common.GuardianSet) (*gossipv1.GovernorRelease, common.Address, error) {
+	envelopeAddr := common.BytesToAddress(s.GuardianAddr)
+	if _, ok := gs.KeyIndex(envelopeAddr); !ok {
+		return nil, common.Address{}, fmt.Errorf("invalid message: %s not in guardian set", envelopeAddr)
+	}
+
+	signerAddr, err := recoverAddress(governorReleaseDigest(s.GovernorRelease), s.Signature)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if signerAddr != envelopeAddr {
+		return nil, common.Address{}, fmt.Errorf("invalid signer: %v", signerAddr)
+	}
+
+	var r gossipv1.GovernorRelease
+	if err := proto.Unmarshal(s.GovernorRelease, &r); err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to unmarshal governor release: %w", err)
+	}
+	if r.MessageId == "" {
+		return nil, common.Address{}, fmt.Errorf("governor release without message ID")
+	}
+
+	return &r, signerAddr, nil
+}
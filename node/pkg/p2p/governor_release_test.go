@@ -0,0 +1,45 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	node_common "github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProcessSignedGovernorRelease(t *testing.T) {
+	gk := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	other := guardiansigner.NewLocalSigner(mustGenerateKey(t))
+	gs := &node_common.GuardianSet{Keys: []common.Address{guardiansigner.Address(gk)}}
+
+	b, err := signGovernorRelease(context.Background(), gk, "2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/1", time.Unix(1654543099, 0))
+	require.NoError(t, err)
+	var msg gossipv1.GossipMessage
+	require.NoError(t, proto.Unmarshal(b, &msg))
+	s := msg.GetSignedGovernorRelease()
+	require.NotNil(t, s)
+
+	r, guardian, err := processSignedGovernorRelease(s, gs)
+	require.NoError(t, err)
+	assert.Equal(t, guardiansigner.Address(gk), guardian)
+	assert.Equal(t, "2/0000000000000000000000000290fb167208af455bb137780163b7b7a9a10c16/1", r.MessageId)
+	assert.Equal(t, int64(1654543099), r.Timestamp)
+
+	// Votes of guardians outside of the guardian set are rejected.
+	_, _, err = processSignedGovernorRelease(s, &node_common.GuardianSet{Keys: []common.Address{guardiansigner.Address(other)}})
+	assert.Error(t, err)
+
+	// So are votes attributed to another guardian than their signer.
+	forged := proto.Clone(s).(*gossipv1.SignedGovernorRelease)
+	forged.GuardianAddr = guardiansigner.Address(other).Bytes()
+	gs.Keys = append(gs.Keys, guardiansigner.Address(other))
+	_, _, err = processSignedGovernorRelease(forged, gs)
+	assert.Error(t, err)
+}
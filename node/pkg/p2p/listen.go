@@ -0,0 +1,124 @@
+package p2p
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/multiformats/go-multiaddr"
+)
+
+// ListenConfig is where the p2p host listens, and which of its addresses it advertises to its peers.
+type ListenConfig struct {
+	// QUIC multiaddrs to listen on, on IPv4 and IPv6 and on several ports if needed.
+	Listen []multiaddr.Multiaddr
+	// Addresses advertised instead of the addresses the host listens on, like the public address of a node behind a
+	// NAT. When empty, the listen addresses are advertised, with the unspecified IPs expanded to the interface IPs.
+	Announce []multiaddr.Multiaddr
+	// Addresses never advertised: exact multiaddrs, or IP ranges like /ip4/10.0.0.0/ipcidr/8.
+	NoAnnounce []multiaddr.Multiaddr
+}
+
+// DefaultListenConfig listens on port on all IPv4 and IPv6 interfaces, and advertises every address.
+func DefaultListenConfig(port uint) ListenConfig {
+	return ListenConfig{
+		Listen: []multiaddr.Multiaddr{
+			// Listen on QUIC only.
+			// https://github.com/libp2p/go-libp2p/issues/688
+			multiaddr.StringCast(fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic", port)),
+			multiaddr.StringCast(fmt.Sprintf("/ip6/::/udp/%d/quic", port)),
+		},
+	}
+}
+
+// ParseListenConfig parses the multiaddrs of a ListenConfig. Without listen addresses, it listens like
+// DefaultListenConfig(defaultPort).
+func ParseListenConfig(listen []string, announce []string, noAnnounce []string, defaultPort uint) (ListenConfig, error) {
+	c := DefaultListenConfig(defaultPort)
+
+	parse := func(what string, addrs []string) ([]multiaddr.Multiaddr, error) {
+		var res []multiaddr.Multiaddr
+		for _, s := range addrs {
+			a, err := multiaddr.NewMultiaddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s address %q: %w", what, s, err)
+			}
+			res = append(res, a)
+		}
+		return res, nil
+	}
+
+	var err error
+	if len(listen) > 0 {
+		if c.Listen, err = parse("listen", listen); err != nil {
+			return c, err
+		}
+	}
+	if c.Announce, err = parse("announce", announce); err != nil {
+		return c, err
+	}
+	if c.NoAnnounce, err = parse("no-announce", noAnnounce); err != nil {
+		return c, err
+	}
+
+	for _, a := range c.Listen {
+		// QUIC is the only transport.
+		if _, err := a.ValueForProtocol(multiaddr.P_QUIC); err != nil {
+			return c, fmt.Errorf("listen address %s is not a QUIC address", a)
+		}
+	}
+	if _, err := c.addrsFactory(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// addrsFactory returns the function filtering the addresses advertised by the host.
+func (c ListenConfig) addrsFactory() (func([]multiaddr.Multiaddr) []multiaddr.Multiaddr, error) {
+	filters := multiaddr.NewFilters()
+	exact := make(map[string]bool)
+	for _, a := range c.NoAnnounce {
+		ipnet, ok, err := ipcidr(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid no-announce range %s: %w", a, err)
+		}
+		if ok {
+			filters.AddFilter(*ipnet, multiaddr.ActionDeny)
+		} else {
+			exact[string(a.Bytes())] = true
+		}
+	}
+
+	return func(addrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+		if len(c.Announce) > 0 {
+			addrs = c.Announce
+		}
+		res := make([]multiaddr.Multiaddr, 0, len(addrs))
+		for _, a := range addrs {
+			if exact[string(a.Bytes())] || filters.AddrBlocked(a) {
+				continue
+			}
+			res = append(res, a)
+		}
+		return res
+	}, nil
+}
+
+// ipcidr returns the IP range of an /ip4/<ip>/ipcidr/<bits> or /ip6/<ip>/ipcidr/<bits> multiaddr. ok is false for
+// other multiaddrs.
+func ipcidr(a multiaddr.Multiaddr) (ipnet *net.IPNet, ok bool, err error) {
+	bits, err := a.ValueForProtocol(multiaddr.P_IPCIDR)
+	if err != nil {
+		return nil, false, nil
+	}
+	ip, err := a.ValueForProtocol(multiaddr.P_IP4)
+	if err != nil {
+		if ip, err = a.ValueForProtocol(multiaddr.P_IP6); err != nil {
+			return nil, false, fmt.Errorf("an IP range must start with /ip4 or /ip6")
+		}
+	}
+	_, ipnet, err = net.ParseCIDR(ip + "/" + bits)
+	if err != nil {
+		return nil, false, err
+	}
+	return ipnet, true, nil
+}
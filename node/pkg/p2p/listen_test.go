@@ -0,0 +1,67 @@
+package p2p
+
+import (
+	"testing"
+
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addrs(s ...string) []multiaddr.Multiaddr {
+	var res []multiaddr.Multiaddr
+	for _, a := range s {
+		res = append(res, multiaddr.StringCast(a))
+	}
+	return res
+}
+
+func TestParseListenConfig(t *testing.T) {
+	c, err := ParseListenConfig(nil, nil, nil, 8999)
+	require.NoError(t, err)
+	assert.Equal(t, addrs("/ip4/0.0.0.0/udp/8999/quic", "/ip6/::/udp/8999/quic"), c.Listen)
+
+	c, err = ParseListenConfig([]string{"/ip4/10.0.0.5/udp/8999/quic", "/ip6/::/udp/8998/quic"}, nil, []string{"/ip4/10.0.0.0/ipcidr/8"}, 8999)
+	require.NoError(t, err)
+	assert.Equal(t, addrs("/ip4/10.0.0.5/udp/8999/quic", "/ip6/::/udp/8998/quic"), c.Listen)
+
+	for _, tc := range []struct {
+		listen, announce, noAnnounce []string
+	}{
+		{listen: []string{"not a multiaddr"}},
+		{listen: []string{"/ip4/0.0.0.0/tcp/8999"}},
+		{announce: []string{"/ip4/1.2.3.4/udp"}},
+		{noAnnounce: []string{"/ip4/10.0.0.0/ipcidr/33"}},
+		{noAnnounce: []string{"/dns4/example.com/ipcidr/8"}},
+	} {
+		_, err := ParseListenConfig(tc.listen, tc.announce, tc.noAnnounce, 8999)
+		assert.Error(t, err, "%+v", tc)
+	}
+}
+
+func TestListenConfigAdvertisedAddrs(t *testing.T) {
+	hostAddrs := addrs(
+		"/ip4/127.0.0.1/udp/8999/quic",
+		"/ip4/10.1.2.3/udp/8999/quic",
+		"/ip4/203.0.113.7/udp/8999/quic",
+		"/ip6/2001:db8::1/udp/8998/quic",
+	)
+
+	c, err := ParseListenConfig(nil, nil, []string{"/ip4/10.0.0.0/ipcidr/8", "/ip4/127.0.0.1/udp/8999/quic"}, 8999)
+	require.NoError(t, err)
+	f, err := c.addrsFactory()
+	require.NoError(t, err)
+	assert.Equal(t, addrs("/ip4/203.0.113.7/udp/8999/quic", "/ip6/2001:db8::1/udp/8998/quic"), f(hostAddrs))
+
+	// Behind a NAT, only the public addresses are advertised.
+	c, err = ParseListenConfig(nil, []string{"/ip4/198.51.100.1/udp/8999/quic", "/ip6/2001:db8::1/udp/8998/quic"}, []string{"/ip6/2001:db8::/ipcidr/32"}, 8999)
+	require.NoError(t, err)
+	f, err = c.addrsFactory()
+	require.NoError(t, err)
+	assert.Equal(t, addrs("/ip4/198.51.100.1/udp/8999/quic"), f(hostAddrs))
+
+	// Without restrictions, every address is advertised.
+	f, err = DefaultListenConfig(8999).addrsFactory()
+	require.NoError(t, err)
+	assert.Equal(t, hostAddrs, f(hostAddrs))
+}
@@ -0,0 +1,184 @@
+package p2p
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/version"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The network overview aggregates the heartbeats of each guardian in the current guardian set, unlike the metrics in
+// netmetrics.go which are per node and never expire. Only guardians in the guardian set are reported, and the metrics
+// are rebuilt periodically, so guardians that left the set or nodes that went away do not leave stale series behind.
+
+const networkOverviewInterval = 15 * time.Second
+
+var (
+	networkGuardianHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_height",
+			Help: "Highest height reported by any node of the given guardian per chain",
+		}, []string{"guardian_addr", "chain"})
+	networkGuardianHeightLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_height_lag",
+			Help: "Number of blocks the given guardian is behind the median height of all guardians per chain",
+		}, []string{"guardian_addr", "chain"})
+	networkGuardianMissing = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_missing",
+			Help: "Whether no node of the given guardian sent a heartbeat recently",
+		}, []string{"guardian_addr"})
+	networkGuardianLastHeartbeat = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_last_heartbeat_timestamp_seconds",
+			Help: "Timestamp of the most recent heartbeat of any node of the given guardian",
+		}, []string{"guardian_addr"})
+	networkGuardianInfo = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardian_info",
+			Help: "Name and version of the node of the given guardian that sent the most recent heartbeat",
+		}, []string{"guardian_addr", "node_name", "version"})
+	networkGuardiansMissing = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_network_guardians_missing",
+			Help: "Number of guardians in the current guardian set that did not send a heartbeat recently",
+		})
+)
+
+// guardianOverview is the aggregated state of a guardian's nodes.
+type guardianOverview struct {
+	addr ethcommon.Address
+	// Zero if no heartbeat was received.
+	lastHeartbeat time.Time
+	missing       bool
+	// Of the node which sent the most recent heartbeat.
+	nodeName string
+	version  string
+	heights  map[vaa.ChainID]int64
+	lags     map[vaa.ChainID]int64
+}
+
+// networkOverview aggregates the heartbeats of each guardian in gs. A guardian is missing if none of its nodes sent a
+// heartbeat within common.MaxStateAge of now.
+func networkOverview(gs *common.GuardianSet, heartbeats map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat, now time.Time) []*guardianOverview {
+	res := make([]*guardianOverview, 0, len(gs.Keys))
+	heights := make(map[vaa.ChainID][]int64)
+
+	for _, addr := range gs.Keys {
+		g := &guardianOverview{
+			addr:    addr,
+			heights: make(map[vaa.ChainID]int64),
+			lags:    make(map[vaa.ChainID]int64),
+		}
+
+		var latest *gossipv1.Heartbeat
+		for _, hb := range heartbeats[addr] {
+			if latest == nil || hb.Timestamp > latest.Timestamp {
+				latest = hb
+			}
+			for _, n := range hb.Networks {
+				if n == nil {
+					continue
+				}
+				if h, ok := g.heights[vaa.ChainID(n.Id)]; !ok || n.Height > h {
+					g.heights[vaa.ChainID(n.Id)] = n.Height
+				}
+			}
+		}
+		for chain, h := range g.heights {
+			heights[chain] = append(heights[chain], h)
+		}
+
+		if latest != nil {
+			g.lastHeartbeat = time.Unix(0, latest.Timestamp)
+			g.nodeName = latest.NodeName
+			g.version = sanitizeVersion(latest.Version, version.Version())
+		}
+		g.missing = latest == nil || now.Sub(g.lastHeartbeat) > common.MaxStateAge
+
+		res = append(res, g)
+	}
+
+	// Like readiness, the lag is relative to the median so that a single guardian cannot skew it.
+	for chain, hs := range heights {
+		sort.Slice(hs, func(i, j int) bool { return hs[i] < hs[j] })
+		median := hs[len(hs)/2]
+		for _, g := range res {
+			if h, ok := g.heights[chain]; ok {
+				lag := median - h
+				if lag < 0 {
+					lag = 0
+				}
+				g.lags[chain] = lag
+			}
+		}
+	}
+
+	return res
+}
+
+func updateNetworkOverview(overview []*guardianOverview) {
+	networkGuardianHeight.Reset()
+	networkGuardianHeightLag.Reset()
+	networkGuardianMissing.Reset()
+	networkGuardianLastHeartbeat.Reset()
+	networkGuardianInfo.Reset()
+
+	missing := 0
+	for _, g := range overview {
+		addr := g.addr.Hex()
+
+		if g.missing {
+			missing++
+			networkGuardianMissing.WithLabelValues(addr).Set(1)
+		} else {
+			networkGuardianMissing.WithLabelValues(addr).Set(0)
+		}
+
+		if g.lastHeartbeat.IsZero() {
+			continue
+		}
+		networkGuardianLastHeartbeat.WithLabelValues(addr).Set(float64(g.lastHeartbeat.Unix()))
+		networkGuardianInfo.WithLabelValues(addr, g.nodeName, g.version).Set(1)
+
+		for chain, h := range g.heights {
+			networkGuardianHeight.WithLabelValues(addr, chain.String()).Set(float64(h))
+			networkGuardianHeightLag.WithLabelValues(addr, chain.String()).Set(float64(g.lags[chain]))
+		}
+	}
+	networkGuardiansMissing.Set(float64(missing))
+}
+
+// NetworkOverviewRunnable periodically aggregates the heartbeats of the guardians into the wormhole_network_guardian_*
+// metrics, making every node a monitor of the whole network.
+func NetworkOverviewRunnable(gst *common.GuardianSetState) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		ticker := time.NewTicker(networkOverviewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				gs := gst.Get()
+				if gs == nil {
+					continue
+				}
+				updateNetworkOverview(networkOverview(gs, gst.GetAll(), time.Now()))
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/version"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkOverview(t *testing.T) {
+	now := time.Unix(1660000000, 0)
+	a := ethcommon.HexToAddress("0x01")
+	b := ethcommon.HexToAddress("0x02")
+	c := ethcommon.HexToAddress("0x03")
+	d := ethcommon.HexToAddress("0x04")
+	notInSet := ethcommon.HexToAddress("0x05")
+
+	hb := func(name string, age time.Duration, ethHeight int64) *gossipv1.Heartbeat {
+		return &gossipv1.Heartbeat{
+			NodeName:  name,
+			Version:   "v2.8.0",
+			Timestamp: now.Add(-age).UnixNano(),
+			Networks:  []*gossipv1.Heartbeat_Network{{Id: uint32(vaa.ChainIDEthereum), Height: ethHeight}},
+		}
+	}
+
+	overview := networkOverview(&common.GuardianSet{Keys: []ethcommon.Address{a, b, c, d}}, map[ethcommon.Address]map[peer.ID]*gossipv1.Heartbeat{
+		// Two nodes, the highest height and the most recent name are used.
+		a: {"node1": hb("a-primary", 20*time.Second, 100), "node2": hb("a-backup", 5*time.Second, 90)},
+		b: {"node1": hb("b", 10*time.Second, 95)},
+		// Stale heartbeat.
+		c: {"node1": hb("c", 2*time.Minute, 80)},
+		// d never sent a heartbeat.
+		notInSet: {"node1": hb("e", time.Second, 1)},
+	}, now)
+
+	require.Len(t, overview, 4)
+
+	assert.Equal(t, a, overview[0].addr)
+	assert.False(t, overview[0].missing)
+	assert.Equal(t, "a-backup", overview[0].nodeName)
+	assert.Equal(t, sanitizeVersion("v2.8.0", version.Version()), overview[0].version)
+	assert.Equal(t, int64(100), overview[0].heights[vaa.ChainIDEthereum])
+	assert.Equal(t, int64(0), overview[0].lags[vaa.ChainIDEthereum])
+
+	// The median of 80, 95 and 100 is 95.
+	assert.False(t, overview[1].missing)
+	assert.Equal(t, int64(0), overview[1].lags[vaa.ChainIDEthereum])
+	assert.True(t, overview[2].missing)
+	assert.Equal(t, int64(15), overview[2].lags[vaa.ChainIDEthereum])
+
+	assert.True(t, overview[3].missing)
+	assert.True(t, overview[3].lastHeartbeat.IsZero())
+	assert.Empty(t, overview[3].heights)
+}
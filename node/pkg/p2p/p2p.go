@@ -2,14 +2,15 @@ package p2p
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	node_common "github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/faultinject"
+	"github.com/certusone/wormhole/node/pkg/featureflags"
 	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	"github.com/certusone/wormhole/node/pkg/version"
 	"github.com/ethereum/go-ethereum/common"
@@ -67,10 +68,15 @@ func signedObservationRequestDigest(b []byte) common.Hash {
 	return ethcrypto.Keccak256Hash(append(signedObservationRequestPrefix, b...))
 }
 
-func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.ObservationRequest, obsvReqSendC chan *gossipv1.ObservationRequest, sendC chan []byte, signedInC chan *gossipv1.SignedVAAWithQuorum, priv crypto.PrivKey, gk *ecdsa.PrivateKey, gst *node_common.GuardianSetState, port uint, networkID string, bootstrapPeers string, nodeName string, disableHeartbeatVerify bool, rootCtxCancel context.CancelFunc, gov *governor.ChainGovernor) func(ctx context.Context) error {
+func Run(obsvC chan<- *gossipv1.SignedObservation, obsvReqC chan<- *gossipv1.ObservationRequest, obsvReqSendC chan *gossipv1.ObservationRequest, sendC chan []byte, signedInC chan<- *gossipv1.SignedVAAWithQuorum, priv crypto.PrivKey, gk guardiansigner.GuardianSigner, nextGk guardiansigner.GuardianSigner, gossipSigner *GossipSigner, gst *node_common.GuardianSetState, listen ListenConfig, networkID string, bootstrapPeers string, nodeName string, disableHeartbeatVerify bool, readOnly bool, rootCtxCancel context.CancelFunc, gov *governor.ChainGovernor) func(ctx context.Context) error {
 	return func(ctx context.Context) (re error) {
 		logger := supervisor.Logger(ctx)
 
+		addrsFactory, err := listen.addrsFactory()
+		if err != nil {
+			return err
+		}
+
 		mgr, err := connmgr.NewConnManager(
 			100, // LowWater
 			400, // HighWater,
@@ -85,12 +91,8 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 			libp2p.Identity(priv),
 
 			// Multiple listen addresses
-			libp2p.ListenAddrStrings(
-				// Listen on QUIC only.
-				// https://github.com/libp2p/go-libp2p/issues/688
-				fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic", port),
-				fmt.Sprintf("/ip6/::/udp/%d/quic", port),
-			),
+			libp2p.ListenAddrs(listen.Listen...),
+			libp2p.AddrsFactory(addrsFactory),
 
 			// Enable TLS security as the only security protocol.
 			libp2p.Security(libp2ptls.ID, libp2ptls.New),
@@ -190,6 +192,7 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 			}
 
 			ctr := int64(0)
+			releaser := newGovernorReleaser()
 			tick := time.NewTicker(15 * time.Second)
 			defer tick.Stop()
 
@@ -198,73 +201,117 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 				case <-ctx.Done():
 					return
 				case <-tick.C:
-					DefaultRegistry.mu.Lock()
-					networks := make([]*gossipv1.Heartbeat_Network, 0, len(DefaultRegistry.networkStats))
-					for _, v := range DefaultRegistry.networkStats {
-						errCtr := DefaultRegistry.GetErrorCount(vaa.ChainID(v.Id))
-						v.ErrorCount = errCtr
-						networks = append(networks, v)
-					}
+				case <-DefaultRegistry.offlineC:
+				}
 
-					features := make([]string, 0)
-					if gov != nil {
-						features = append(features, "governor")
-					}
+				DefaultRegistry.mu.Lock()
+				networks := make([]*gossipv1.Heartbeat_Network, 0, len(DefaultRegistry.networkStats))
+				for _, v := range DefaultRegistry.networkStats {
+					errCtr := DefaultRegistry.GetErrorCount(vaa.ChainID(v.Id))
+					v.ErrorCount = errCtr
+					v.Paused = DefaultRegistry.paused[vaa.ChainID(v.Id)]
+					networks = append(networks, v)
+				}
+				DefaultRegistry.connectedPeers = len(h.Network().Peers())
+				DefaultRegistry.gossipPeers = len(th.ListPeers())
 
-					heartbeat := &gossipv1.Heartbeat{
-						NodeName:      nodeName,
-						Counter:       ctr,
-						Timestamp:     time.Now().UnixNano(),
-						Networks:      networks,
-						Version:       version.Version(),
-						GuardianAddr:  DefaultRegistry.guardianAddress,
-						BootTimestamp: bootTime.UnixNano(),
-						Features:      features,
-					}
+				// During a key rotation, sign with the key that is a member of the current guardian set.
+				key := gst.Get().SigningKey(gk, nextGk)
+				ourAddr := guardiansigner.Address(key)
+				DefaultRegistry.guardianAddress = ourAddr.Hex()
 
-					ourAddr := ethcrypto.PubkeyToAddress(gk.PublicKey)
-					if err := gst.SetHeartbeat(ourAddr, h.ID(), heartbeat); err != nil {
-						panic(err)
-					}
-					collectNodeMetrics(ourAddr, h.ID(), heartbeat)
+				features := make([]string, 0)
+				if gov != nil {
+					features = append(features, "governor")
+				}
+				for _, resource := range DefaultRegistry.degraded {
+					features = append(features, "degraded:"+resource)
+				}
+				features = append(features, featureflags.DefaultRegistry.HeartbeatFeatures()...)
+
+				heartbeat := &gossipv1.Heartbeat{
+					NodeName:      nodeName,
+					Counter:       ctr,
+					Timestamp:     time.Now().UnixNano(),
+					Networks:      networks,
+					Version:       version.Version(),
+					GuardianAddr:  DefaultRegistry.guardianAddress,
+					BootTimestamp: bootTime.UnixNano(),
+					Features:      features,
+					GoingOffline:  DefaultRegistry.goingOffline,
+				}
 
-					if gov != nil {
-						gov.CollectMetrics(heartbeat)
-					}
+				if err := gst.SetHeartbeat(ourAddr, h.ID(), heartbeat); err != nil {
+					panic(err)
+				}
+				collectNodeMetrics(ourAddr, h.ID(), heartbeat)
 
-					b, err := proto.Marshal(heartbeat)
-					if err != nil {
-						panic(err)
-					}
+				if gov != nil {
+					gov.CollectMetrics(heartbeat)
+				}
+
+				b, err := proto.Marshal(heartbeat)
+				if err != nil {
+					panic(err)
+				}
 
-					DefaultRegistry.mu.Unlock()
+				DefaultRegistry.mu.Unlock()
 
-					// Sign the heartbeat using our node's guardian key.
-					digest := heartbeatDigest(b)
-					sig, err := ethcrypto.Sign(digest.Bytes(), gk)
-					if err != nil {
-						panic(err)
+				// Read-only nodes keep their heartbeat local, it is never signed or published.
+				if readOnly {
+					if heartbeat.GoingOffline {
+						DefaultRegistry.offlineAnnounced()
 					}
+					ctr += 1
+					continue
+				}
 
-					msg := gossipv1.GossipMessage{Message: &gossipv1.GossipMessage_SignedHeartbeat{
-						SignedHeartbeat: &gossipv1.SignedHeartbeat{
-							Heartbeat:    b,
-							Signature:    sig,
-							GuardianAddr: ourAddr.Bytes(),
-						}}}
+				// Sign the heartbeat using our node's guardian key, or the gossip key it delegated to.
+				digest := heartbeatDigest(b)
+				sig, delegation, err := gossipSigner.Sign(guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{Type: guardiansigner.AuditTypeHeartbeat}), key, digest.Bytes())
+				if err != nil {
+					logger.Error("failed to sign heartbeat", zap.Error(err))
+					ctr += 1
+					continue
+				}
 
-					b, err = proto.Marshal(&msg)
-					if err != nil {
-						panic(err)
-					}
+				msg := gossipv1.GossipMessage{Message: &gossipv1.GossipMessage_SignedHeartbeat{
+					SignedHeartbeat: &gossipv1.SignedHeartbeat{
+						Heartbeat:    b,
+						Signature:    sig,
+						GuardianAddr: ourAddr.Bytes(),
+						Delegation:   delegation,
+					}}}
 
-					err = th.Publish(ctx, b)
+				b, err = proto.Marshal(&msg)
+				if err != nil {
+					panic(err)
+				}
+
+				err = th.Publish(ctx, b)
+				if err != nil {
+					logger.Warn("failed to publish heartbeat message", zap.Error(err))
+				} else if heartbeat.GoingOffline {
+					logger.Info("announced that the node is going offline")
+					DefaultRegistry.offlineAnnounced()
+				}
+
+				p2pHeartbeatsSent.Inc()
+				ctr += 1
+
+				// Gossip the votes to release transfers held by the governor emergency hold along with the heartbeats.
+				if gov != nil {
+					releases, err := releaser.messages(ctx, gov, key, time.Now())
 					if err != nil {
-						logger.Warn("failed to publish heartbeat message", zap.Error(err))
+						logger.Error("failed to sign governor release", zap.Error(err))
+					}
+					for _, b := range releases {
+						if err := th.Publish(ctx, b); err != nil {
+							logger.Warn("failed to publish governor release", zap.Error(err))
+						} else {
+							p2pMessagesSent.Inc()
+						}
 					}
-
-					p2pHeartbeatsSent.Inc()
-					ctr += 1
 				}
 			}
 		}()
@@ -275,28 +322,43 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 				case <-ctx.Done():
 					return
 				case msg := <-sendC:
+					if readOnly {
+						logger.Debug("dropping outbound message since the node is read-only")
+						continue
+					}
 					err := th.Publish(ctx, msg)
 					p2pMessagesSent.Inc()
 					if err != nil {
 						logger.Error("failed to publish message from queue", zap.Error(err))
 					}
 				case msg := <-obsvReqSendC:
+					if readOnly {
+						// Only reobserve locally
+						obsvReqC <- msg
+						continue
+					}
 					b, err := proto.Marshal(msg)
 					if err != nil {
 						panic(err)
 					}
 
-					// Sign the observation request using our node's guardian key.
+					// Sign the observation request using our node's guardian key, or the gossip key it delegated to.
+					key := gst.Get().SigningKey(gk, nextGk)
 					digest := signedObservationRequestDigest(b)
-					sig, err := ethcrypto.Sign(digest.Bytes(), gk)
+					sig, delegation, err := gossipSigner.Sign(guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{
+						Type:         guardiansigner.AuditTypeObservationRequest,
+						EmitterChain: uint16(msg.ChainId),
+					}), key, digest.Bytes())
 					if err != nil {
-						panic(err)
+						logger.Error("failed to sign observation request", zap.Error(err))
+						continue
 					}
 
 					sReq := &gossipv1.SignedObservationRequest{
 						ObservationRequest: b,
 						Signature:          sig,
-						GuardianAddr:       ethcrypto.PubkeyToAddress(gk.PublicKey).Bytes(),
+						GuardianAddr:       guardiansigner.Address(key).Bytes(),
+						Delegation:         delegation,
 					}
 
 					envelope := &gossipv1.GossipMessage{
@@ -345,6 +407,12 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 				continue
 			}
 
+			if faultinject.DropGossip() {
+				logger.Debug("dropping received message (fault injection)",
+					zap.String("from", envelope.GetFrom().String()))
+				continue
+			}
+
 			logger.Debug("received message",
 				zap.Any("payload", msg.Message),
 				zap.Binary("raw", envelope.Data),
@@ -374,6 +442,12 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 					logger.Debug("valid signed heartbeat received",
 						zap.Any("value", heartbeat),
 						zap.String("from", envelope.GetFrom().String()))
+					if heartbeat.GoingOffline {
+						logger.Info("guardian node announced that it is going offline",
+							zap.String("guardian_addr", heartbeat.GuardianAddr),
+							zap.String("node_name", heartbeat.NodeName),
+							zap.String("from", envelope.GetFrom().String()))
+					}
 				}
 			case *gossipv1.GossipMessage_SignedObservation:
 				obsvC <- m.SignedObservation
@@ -407,6 +481,23 @@ func Run(obsvC chan *gossipv1.SignedObservation, obsvReqC chan *gossipv1.Observa
 
 					obsvReqC <- r
 				}
+			case *gossipv1.GossipMessage_SignedGovernorRelease:
+				s := m.SignedGovernorRelease
+				gs := gst.Get()
+				if gov == nil || gs == nil {
+					break
+				}
+				r, guardian, err := processSignedGovernorRelease(s, gs)
+				if err != nil {
+					p2pMessagesReceived.WithLabelValues("invalid_governor_release").Inc()
+					logger.Debug("invalid signed governor release received",
+						zap.Error(err),
+						zap.Any("value", s),
+						zap.String("from", envelope.GetFrom().String()))
+				} else {
+					p2pMessagesReceived.WithLabelValues("governor_release").Inc()
+					gov.RecordEmergencyRelease(r.MessageId, guardian)
+				}
 			default:
 				p2pMessagesReceived.WithLabelValues("unknown").Inc()
 				logger.Warn("received unknown message type (running outdated software?)",
@@ -432,12 +523,10 @@ func processSignedHeartbeat(from peer.ID, s *gossipv1.SignedHeartbeat, gs *node_
 
 	digest := heartbeatDigest(s.Heartbeat)
 
-	pubKey, err := ethcrypto.Ecrecover(digest.Bytes(), s.Signature)
+	signerAddr, err := recoverGossipSigner(digest, s.Signature, s.Delegation, time.Now())
 	if err != nil {
-		return nil, errors.New("failed to recover public key")
+		return nil, err
 	}
-
-	signerAddr := common.BytesToAddress(ethcrypto.Keccak256(pubKey[1:])[12:])
 	if pk != signerAddr && !disableVerify {
 		return nil, fmt.Errorf("invalid signer: %v", signerAddr)
 	}
@@ -470,12 +559,10 @@ func processSignedObservationRequest(s *gossipv1.SignedObservationRequest, gs *n
 
 	digest := signedObservationRequestDigest(s.ObservationRequest)
 
-	pubKey, err := ethcrypto.Ecrecover(digest.Bytes(), s.Signature)
+	signerAddr, err := recoverGossipSigner(digest, s.Signature, s.Delegation, time.Now())
 	if err != nil {
-		return nil, errors.New("failed to recover public key")
+		return nil, err
 	}
-
-	signerAddr := common.BytesToAddress(ethcrypto.Keccak256(pubKey[1:])[12:])
 	if pk != signerAddr {
 		return nil, fmt.Errorf("invalid signer: %v", signerAddr)
 	}
@@ -19,14 +19,33 @@ type registry struct {
 	errorCounters  map[vaa.ChainID]uint64
 	errorCounterMu sync.Mutex
 
+	// Chains whose watchers have been paused by the operator.
+	paused map[vaa.ChainID]bool
+
 	// Value of Heartbeat.guardian_addr.
 	guardianAddress string
+
+	// Number of connected libp2p peers and of peers subscribed to the gossip topic, updated with each heartbeat.
+	connectedPeers int
+	gossipPeers    int
+
+	// Value of Heartbeat.going_offline. Once it is set, offlineC triggers an immediate heartbeat, and offlineSent is
+	// closed once a heartbeat announcing it was published.
+	goingOffline bool
+	offlineC     chan struct{}
+	offlineSent  chan struct{}
+
+	// Resources exceeding the limits of the watchdog, announced as features of the heartbeat.
+	degraded []string
 }
 
 func NewRegistry() *registry {
 	return &registry{
 		networkStats:  map[vaa.ChainID]*gossipv1.Heartbeat_Network{},
 		errorCounters: map[vaa.ChainID]uint64{},
+		paused:        map[vaa.ChainID]bool{},
+		offlineC:      make(chan struct{}, 1),
+		offlineSent:   make(chan struct{}),
 	}
 }
 
@@ -35,13 +54,76 @@ var (
 )
 
 // SetGuardianAddress stores the node's guardian address to broadcast in Heartbeat messages.
-// This should be called once during startup, when the guardian key is loaded.
+// This should be called once during startup, when the guardian key is loaded. During a key rotation, the heartbeat
+// loop updates it to the address of the key matching the current guardian set.
 func (r *registry) SetGuardianAddress(addr string) {
 	r.mu.Lock()
 	r.guardianAddress = addr
 	r.mu.Unlock()
 }
 
+// GuardianAddress returns the node's guardian address, as broadcast in Heartbeat messages.
+func (r *registry) GuardianAddress() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.guardianAddress
+}
+
+// PeerCounts returns the number of connected peers and of peers subscribed to the gossip topic at the last heartbeat.
+func (r *registry) PeerCounts() (connected int, gossip int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connectedPeers, r.gossipPeers
+}
+
+// NetworkStats returns a copy of the current network status of each chain, as broadcast in Heartbeat messages.
+func (r *registry) NetworkStats() []*gossipv1.Heartbeat_Network {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	networks := make([]*gossipv1.Heartbeat_Network, 0, len(r.networkStats))
+	for chain, v := range r.networkStats {
+		networks = append(networks, &gossipv1.Heartbeat_Network{
+			Id:              v.Id,
+			Height:          v.Height,
+			ContractAddress: v.ContractAddress,
+			ErrorCount:      r.GetErrorCount(chain),
+			Paused:          r.paused[chain],
+		})
+	}
+	return networks
+}
+
+// SetGoingOffline announces in the next heartbeat, which is sent immediately, that the node is shutting down. The
+// returned channel is closed once the heartbeat was published.
+func (r *registry) SetGoingOffline() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.goingOffline {
+		r.goingOffline = true
+		r.offlineC <- struct{}{}
+	}
+	return r.offlineSent
+}
+
+func (r *registry) offlineAnnounced() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.offlineSent:
+	default:
+		close(r.offlineSent)
+	}
+}
+
+// SetDegraded sets the resources exceeding their limits, which are broadcast in Heartbeat messages as "degraded:<resource>"
+// features. The node is healthy if there are none.
+func (r *registry) SetDegraded(resources []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.degraded = resources
+}
+
 // SetNetworkStats sets the current network status to be broadcast in Heartbeat messages.
 // The "Id" field is automatically set to the specified chain ID.
 func (r *registry) SetNetworkStats(chain vaa.ChainID, data *gossipv1.Heartbeat_Network) {
@@ -51,6 +133,17 @@ func (r *registry) SetNetworkStats(chain vaa.ChainID, data *gossipv1.Heartbeat_N
 	r.mu.Unlock()
 }
 
+// SetPaused sets whether the watcher for a chain is paused, which is broadcast in Heartbeat messages.
+func (r *registry) SetPaused(chain vaa.ChainID, paused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if paused {
+		r.paused[chain] = true
+	} else {
+		delete(r.paused, chain)
+	}
+}
+
 func (r *registry) AddErrorCount(chain vaa.ChainID, delta uint64) {
 	r.errorCounterMu.Lock()
 	defer r.errorCounterMu.Unlock()
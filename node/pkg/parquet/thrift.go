@@ -0,0 +1,117 @@
+package parquet
+
+import (
+	"encoding/binary"
+)
+
+// The metadata of Parquet files is serialized with the Thrift compact protocol. Only the parts of the protocol used
+// by the structures written here are implemented.
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes a Thrift struct. Fields must be written in increasing order of their id.
+type thriftWriter struct {
+	buf []byte
+	// The id of the last field written in each open struct.
+	lastFields []int16
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf = append(t.buf, b[:n]...)
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.lastFields[len(t.lastFields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) listHeader(typ byte, size int) {
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|typ)
+	} else {
+		t.buf = append(t.buf, 0xf0|typ)
+		t.varint(uint64(size))
+	}
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastFields = append(t.lastFields, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf = append(t.buf, 0)
+	t.lastFields = t.lastFields[:len(t.lastFields)-1]
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) binary(b []byte) {
+	t.varint(uint64(len(b)))
+	t.buf = append(t.buf, b...)
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary([]byte(s))
+}
+
+func (t *thriftWriter) i32List(id int16, vs []int32) {
+	t.field(id, thriftList)
+	t.listHeader(thriftI32, len(vs))
+	for _, v := range vs {
+		t.zigzag(int64(v))
+	}
+}
+
+func (t *thriftWriter) stringList(id int16, vs []string) {
+	t.field(id, thriftList)
+	t.listHeader(thriftBinary, len(vs))
+	for _, v := range vs {
+		t.binary([]byte(v))
+	}
+}
+
+// structList writes a list of n structs, calling write for each of them between the start and the end of the struct.
+func (t *thriftWriter) structList(id int16, n int, write func(i int)) {
+	t.field(id, thriftList)
+	t.listHeader(thriftStruct, n)
+	for i := 0; i < n; i++ {
+		t.beginStruct()
+		write(i)
+		t.endStruct()
+	}
+}
+
+// structField writes a struct field, calling write between the start and the end of the struct.
+func (t *thriftWriter) structField(id int16, write func()) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+	write()
+	t.endStruct()
+}
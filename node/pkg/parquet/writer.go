@@ -0,0 +1,280 @@
+// Package parquet writes tables to Apache Parquet files, so they can be loaded into data warehouses.
+//
+// Only flat tables of required columns are supported. Values are written with the plain encoding and without
+// compression, which keeps the writer small while remaining readable by any Parquet implementation. Rows are buffered
+// and written in row groups, so the memory used does not grow with the size of the table.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Type is the type of the values of a column.
+type Type int
+
+const (
+	// String columns hold UTF-8 strings, given as string.
+	String Type = iota
+	// Int64 columns hold signed integers, given as int64.
+	Int64
+	// Timestamp columns hold times with millisecond precision, given as time.Time.
+	Timestamp
+)
+
+// Column describes a column of a table.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Values from the Parquet format specification.
+const (
+	physicalInt64     = 2
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageTypeData       = 0
+)
+
+var magic = []byte("PAR1")
+
+// DefaultRowGroupSize is the number of rows written per row group.
+const DefaultRowGroupSize = 65536
+
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroup struct {
+	chunks  []columnChunk
+	numRows int64
+	size    int64
+}
+
+// Writer writes the rows of a table to a Parquet file. Close must be called to write the footer of the file.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	// RowGroupSize is the number of rows buffered before they are written. It can be changed before the first row is
+	// written.
+	RowGroupSize int
+
+	offset int64
+	// The plain encoded values of each column in the current row group.
+	values    [][]byte
+	rows      int
+	rowGroups []rowGroup
+	closed    bool
+}
+
+// NewWriter returns a writer of a table with the given columns to w. The header of the file is written immediately.
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("a table needs at least one column")
+	}
+	names := make(map[string]bool)
+	for _, c := range columns {
+		if c.Name == "" || names[c.Name] {
+			return nil, fmt.Errorf("invalid or duplicate column name %q", c.Name)
+		}
+		if c.Type != String && c.Type != Int64 && c.Type != Timestamp {
+			return nil, fmt.Errorf("column %s has an invalid type", c.Name)
+		}
+		names[c.Name] = true
+	}
+
+	pw := &Writer{w: w, columns: columns, RowGroupSize: DefaultRowGroupSize, values: make([][]byte, len(columns))}
+	if err := pw.write(magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// Write adds a row to the table, with one value per column of the type expected by the column.
+func (w *Writer) Write(row ...interface{}) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(row))
+	}
+
+	// Check all values before encoding any, so a bad row does not leave the columns with different lengths.
+	for i, c := range w.columns {
+		var ok bool
+		switch c.Type {
+		case String:
+			_, ok = row[i].(string)
+		case Int64:
+			_, ok = row[i].(int64)
+		case Timestamp:
+			_, ok = row[i].(time.Time)
+		}
+		if !ok {
+			return fmt.Errorf("invalid value for column %s: %T", c.Name, row[i])
+		}
+	}
+
+	for i, c := range w.columns {
+		switch c.Type {
+		case String:
+			s := row[i].(string)
+			w.values[i] = appendUint32(w.values[i], uint32(len(s)))
+			w.values[i] = append(w.values[i], s...)
+		case Int64:
+			w.values[i] = appendUint64(w.values[i], uint64(row[i].(int64)))
+		case Timestamp:
+			w.values[i] = appendUint64(w.values[i], uint64(row[i].(time.Time).UnixMilli()))
+		}
+	}
+
+	w.rows++
+	if w.rows >= w.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group, with a single data page per column.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+
+	group := rowGroup{numRows: int64(w.rows)}
+	for i := range w.columns {
+		t := &thriftWriter{}
+		t.beginStruct()
+		t.i32(1, pageTypeData)
+		t.i32(2, int32(len(w.values[i])))
+		t.i32(3, int32(len(w.values[i])))
+		t.structField(5, func() {
+			t.i32(1, int32(w.rows))
+			t.i32(2, encodingPlain)
+			t.i32(3, encodingRLE)
+			t.i32(4, encodingRLE)
+		})
+		t.endStruct()
+
+		chunk := columnChunk{offset: w.offset, size: int64(len(t.buf) + len(w.values[i])), numValues: int64(w.rows)}
+		if err := w.write(t.buf); err != nil {
+			return err
+		}
+		if err := w.write(w.values[i]); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+		w.values[i] = w.values[i][:0]
+	}
+
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows = 0
+	return nil
+}
+
+// Close writes the remaining rows and the footer of the file. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	var numRows int64
+	for _, g := range w.rowGroups {
+		numRows += g.numRows
+	}
+
+	// FileMetaData
+	t := &thriftWriter{}
+	t.beginStruct()
+	t.i32(1, 1)
+	t.structList(2, len(w.columns)+1, func(i int) {
+		if i == 0 {
+			// The root of the schema, whose children are the columns.
+			t.string(4, "schema")
+			t.i32(5, int32(len(w.columns)))
+			return
+		}
+		c := w.columns[i-1]
+		physical, converted := physicalTypes(c.Type)
+		t.i32(1, physical)
+		t.i32(3, repetitionRequired)
+		t.string(4, c.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+	})
+	t.i64(3, numRows)
+	t.structList(4, len(w.rowGroups), func(i int) {
+		g := w.rowGroups[i]
+		t.structList(1, len(g.chunks), func(j int) {
+			chunk := g.chunks[j]
+			physical, _ := physicalTypes(w.columns[j].Type)
+			t.i64(2, chunk.offset)
+			t.structField(3, func() {
+				t.i32(1, physical)
+				t.i32List(2, []int32{encodingPlain})
+				t.stringList(3, []string{w.columns[j].Name})
+				t.i32(4, codecUncompressed)
+				t.i64(5, chunk.numValues)
+				t.i64(6, chunk.size)
+				t.i64(7, chunk.size)
+				t.i64(9, chunk.offset)
+			})
+		})
+		t.i64(2, g.size)
+		t.i64(3, g.numRows)
+	})
+	t.string(6, "guardiand")
+	t.endStruct()
+
+	footer := appendUint32(t.buf, uint32(len(t.buf)))
+	footer = append(footer, magic...)
+	return w.write(footer)
+}
+
+// physicalTypes returns the physical type of a column and its converted type, or -1 if it has none.
+func physicalTypes(typ Type) (physical int32, converted int32) {
+	switch typ {
+	case String:
+		return physicalByteArray, convertedUTF8
+	case Timestamp:
+		return physicalInt64, convertedTimestampMillis
+	default:
+		return physicalInt64, -1
+	}
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
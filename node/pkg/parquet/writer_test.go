@@ -0,0 +1,146 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes Thrift compact structs into maps from field id to value, where values are int64, []byte,
+// []interface{} or map[int16]interface{}.
+type thriftReader struct {
+	t *testing.T
+	b []byte
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	require.Greater(r.t, n, 0)
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := r.varint()
+		v := r.b[:n]
+		r.b = r.b[n:]
+		return v
+	case thriftList:
+		header := r.b[0]
+		r.b = r.b[1:]
+		size := uint64(header >> 4)
+		if size == 15 {
+			size = r.varint()
+		}
+		var list []interface{}
+		for i := uint64(0); i < size; i++ {
+			list = append(list, r.value(header&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.b[0]
+		r.b = r.b[1:]
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+// readColumn reads the plain encoded values of a column chunk written by the writer.
+func readColumn(t *testing.T, file []byte, chunk map[int16]interface{}) []byte {
+	offset := chunk[9].(int64)
+	r := &thriftReader{t: t, b: file[offset:]}
+	header := r.readStruct()
+	assert.Equal(t, int64(pageTypeData), header[1])
+	return r.b[:header[3].(int64)]
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{"name", String}, {"count", Int64}, {"time", Timestamp}})
+	require.NoError(t, err)
+	w.RowGroupSize = 2
+
+	ts := time.UnixMilli(1660000000123)
+	require.NoError(t, w.Write("a", int64(1), ts))
+	assert.Error(t, w.Write("b", 2, ts))
+	assert.Error(t, w.Write("b"))
+	require.NoError(t, w.Write("bc", int64(-2), ts))
+	require.NoError(t, w.Write("", int64(3), ts))
+	require.NoError(t, w.Close())
+	assert.Error(t, w.Write("d", int64(4), ts))
+
+	file := buf.Bytes()
+	require.Equal(t, magic, file[:4])
+	require.Equal(t, magic, file[len(file)-4:])
+	footerLen := binary.LittleEndian.Uint32(file[len(file)-8:])
+	r := &thriftReader{t: t, b: file[len(file)-8-int(footerLen) : len(file)-8]}
+	meta := r.readStruct()
+	assert.Empty(t, r.b)
+
+	assert.Equal(t, int64(3), meta[3])
+	schema := meta[2].([]interface{})
+	require.Equal(t, 4, len(schema))
+	assert.Equal(t, int64(3), schema[0].(map[int16]interface{})[5])
+	assert.Equal(t, []byte("name"), schema[1].(map[int16]interface{})[4])
+	assert.Equal(t, int64(convertedUTF8), schema[1].(map[int16]interface{})[6])
+	assert.Equal(t, int64(convertedTimestampMillis), schema[3].(map[int16]interface{})[6])
+
+	// Three rows with a row group size of two make two row groups.
+	rowGroups := meta[4].([]interface{})
+	require.Equal(t, 2, len(rowGroups))
+	first := rowGroups[0].(map[int16]interface{})
+	assert.Equal(t, int64(2), first[3])
+	chunks := first[1].([]interface{})
+	require.Equal(t, 3, len(chunks))
+
+	name := chunks[0].(map[int16]interface{})[3].(map[int16]interface{})
+	assert.Equal(t, []interface{}{[]byte("name")}, name[3])
+	assert.Equal(t, []byte{1, 0, 0, 0, 'a', 2, 0, 0, 0, 'b', 'c'}, readColumn(t, file, name))
+
+	count := chunks[1].(map[int16]interface{})[3].(map[int16]interface{})
+	assert.Equal(t, []byte{1, 0, 0, 0, 0, 0, 0, 0, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, readColumn(t, file, count))
+
+	last := rowGroups[1].(map[int16]interface{})
+	assert.Equal(t, int64(1), last[3])
+	tsColumn := last[1].([]interface{})[2].(map[int16]interface{})[3].(map[int16]interface{})
+	assert.Equal(t, uint64(1660000000123), binary.LittleEndian.Uint64(readColumn(t, file, tsColumn)))
+}
+
+func TestNewWriterInvalidColumns(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	_, err = NewWriter(&bytes.Buffer{}, []Column{{"a", String}, {"a", Int64}})
+	assert.Error(t, err)
+	_, err = NewWriter(&bytes.Buffer{}, []Column{{"a", Type(42)}})
+	assert.Error(t, err)
+}
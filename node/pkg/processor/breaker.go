@@ -0,0 +1,364 @@
+package processor
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	circuitBreakerTripsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_circuit_breaker_trips_total",
+			Help: "Total number of times the circuit breaker paused signing on a chain, by heuristic",
+		},
+		[]string{"emitter_chain", "reason"})
+
+	circuitBreakerPaused = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_circuit_breaker_paused",
+			Help: "1 if signing is paused on the chain by the circuit breaker until the operator resumes it, 0 otherwise",
+		},
+		[]string{"emitter_chain"})
+
+	circuitBreakerDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_circuit_breaker_dropped_total",
+			Help: "Total number of messages dropped without signing because the circuit breaker paused their chain",
+		},
+		[]string{"emitter_chain"})
+)
+
+// Heuristics which trip the circuit breaker.
+const (
+	BreakerReasonRateSpike  = "rate_spike"
+	BreakerReasonNewEmitter = "new_emitter"
+)
+
+const (
+	// breakerBaselineWarmup is the number of minutes the rate of a chain is measured before the rate spike heuristic
+	// applies to it.
+	breakerBaselineWarmup = 10
+	// breakerBaselineWeight is the weight of the last minute in the baseline rate of a chain, which is an exponential
+	// moving average over roughly the last hour.
+	breakerBaselineWeight = 1.0 / 60
+	// breakerMaxIdleMinutes bounds the number of idle minutes averaged into the baseline at once. The baseline has
+	// decayed to nothing long before.
+	breakerMaxIdleMinutes = 600
+)
+
+// EmitterHistory tells whether an emitter has had messages signed before. It is implemented by the database.
+type EmitterHistory interface {
+	HasSignedVAAsFromEmitter(chainID vaa.ChainID, emitter vaa.Address) (bool, error)
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Trip when the messages of a chain in the current minute exceed SpikeFactor times its baseline rate. Zero disables
+	// the heuristic.
+	SpikeFactor float64
+	// Minimum number of messages in a minute for the rate spike heuristic to apply, so a quiet chain does not trip on a
+	// handful of messages.
+	SpikeMinMessages uint64
+	// Trip when an emitter which never had a message signed sends a token transfer worth at least this notional value
+	// in USD. Zero disables the heuristic. Requires Valuer and History.
+	NewEmitterMinValue uint64
+	Valuer             governor.TransferValuer
+	History            EmitterHistory
+	// Emitters always considered seen before, such as the token and NFT bridges.
+	KnownEmitters []common.EmitterInfo
+}
+
+// chainBreaker is the circuit breaker state of a chain.
+type chainBreaker struct {
+	// Messages accepted in the current minute.
+	windowStart time.Time
+	window      uint64
+
+	// Average number of messages per minute, over the minutes measured so far.
+	baseline float64
+	minutes  uint64
+
+	paused      bool
+	trip        *CircuitBreakerTrip
+	dropped     uint64
+	lastDropped time.Time
+}
+
+// CircuitBreakerTrip describes why the circuit breaker paused a chain.
+type CircuitBreakerTrip struct {
+	ChainID vaa.ChainID
+	Reason  string
+	// Human-readable description of the anomaly.
+	Detail string
+	Time   time.Time
+	// ID of the message which tripped the breaker.
+	MessageID string
+
+	emitter vaa.Address
+	// Messages in the minute of a rate spike.
+	rate uint64
+}
+
+// CircuitBreakerStatus is the circuit breaker state of a chain, as reported by the admin RPC.
+type CircuitBreakerStatus struct {
+	ChainID vaa.ChainID
+	Paused  bool
+	// Last trip, nil if the breaker never tripped on the chain.
+	LastTrip *CircuitBreakerTrip
+	// Zero until the baseline has been measured for long enough.
+	BaselinePerMinute float64
+	CurrentMinute     uint64
+	// Messages dropped while the chain was paused.
+	Dropped     uint64
+	LastDropped time.Time
+}
+
+// CircuitBreaker pauses signing on a chain when its messages look anomalous, until the operator resumes it. Messages
+// of a paused chain are dropped before they are signed, and can be reobserved once signing is resumed.
+//
+// The heuristics are a spike of the message rate of a chain above its baseline, and a high-value token transfer from
+// an emitter which never had a message signed.
+type CircuitBreaker struct {
+	mutex  sync.Mutex
+	cfg    CircuitBreakerConfig
+	known  map[bypassKey]bool
+	chains map[vaa.ChainID]*chainBreaker
+}
+
+// NewCircuitBreaker creates a circuit breaker. It returns an error if the configuration is invalid.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) (*CircuitBreaker, error) {
+	if cfg.SpikeFactor != 0 && cfg.SpikeFactor <= 1 {
+		return nil, fmt.Errorf("the spike factor must be greater than one, got %v", cfg.SpikeFactor)
+	}
+	if cfg.NewEmitterMinValue != 0 && (cfg.Valuer == nil || cfg.History == nil) {
+		return nil, errors.New("the new emitter heuristic requires a transfer valuer and the emitter history")
+	}
+
+	b := &CircuitBreaker{
+		cfg:    cfg,
+		known:  make(map[bypassKey]bool),
+		chains: make(map[vaa.ChainID]*chainBreaker),
+	}
+
+	for _, e := range cfg.KnownEmitters {
+		addr, err := hex.DecodeString(e.Emitter)
+		if err != nil || len(addr) != 32 {
+			return nil, fmt.Errorf("invalid known emitter %v:%s (expected 32 bytes hex)", e.ChainID, e.Emitter)
+		}
+		var key bypassKey
+		key.chainID = e.ChainID
+		copy(key.emitter[:], addr)
+		b.known[key] = true
+	}
+
+	return b, nil
+}
+
+// chain returns the state of a chain. It must be called with the mutex held.
+func (b *CircuitBreaker) chain(chainID vaa.ChainID, now time.Time) *chainBreaker {
+	c, ok := b.chains[chainID]
+	if !ok {
+		c = &chainBreaker{windowStart: now}
+		b.chains[chainID] = c
+		circuitBreakerPaused.WithLabelValues(chainID.String()).Set(0)
+	}
+	c.roll(now)
+	return c
+}
+
+// roll averages the minutes which are over into the baseline. The baseline is frozen while the chain is paused, so
+// the anomaly does not become the new normal.
+func (c *chainBreaker) roll(now time.Time) {
+	elapsed := now.Sub(c.windowStart)
+	if elapsed < time.Minute {
+		return
+	}
+
+	if !c.paused {
+		minutes := int(elapsed / time.Minute)
+		if minutes > breakerMaxIdleMinutes {
+			minutes = breakerMaxIdleMinutes
+		}
+		for i := 0; i < minutes; i++ {
+			var count float64
+			if i == 0 {
+				count = float64(c.window)
+			}
+			// The baseline is the plain average of the first minutes, until there are enough of them for the moving
+			// average to take over.
+			c.minutes++
+			weight := 1 / float64(c.minutes)
+			if weight < breakerBaselineWeight {
+				weight = breakerBaselineWeight
+			}
+			c.baseline += weight * (count - c.baseline)
+		}
+	}
+
+	c.window = 0
+	c.windowStart = c.windowStart.Add(elapsed.Truncate(time.Minute))
+}
+
+// Check returns whether a message may be signed. If the message trips the breaker, its chain is paused and the trip is
+// returned, so the caller can page the operator.
+func (b *CircuitBreaker) Check(k *common.MessagePublication, now time.Time) (bool, *CircuitBreakerTrip) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := b.chain(k.EmitterChain, now)
+	if c.paused {
+		c.dropped++
+		c.lastDropped = now
+		circuitBreakerDroppedTotal.WithLabelValues(k.EmitterChain.String()).Inc()
+		return false, nil
+	}
+
+	c.window++
+
+	var trip *CircuitBreakerTrip
+	if b.cfg.SpikeFactor != 0 && c.minutes >= breakerBaselineWarmup && c.window >= b.cfg.SpikeMinMessages {
+		baseline := c.baseline
+		if baseline < 1 {
+			baseline = 1
+		}
+		if float64(c.window) > b.cfg.SpikeFactor*baseline {
+			trip = &CircuitBreakerTrip{
+				rate:   c.window,
+				Reason: BreakerReasonRateSpike,
+				Detail: fmt.Sprintf("%d messages in the current minute, over %.1f times the baseline of %.1f per minute",
+					c.window, b.cfg.SpikeFactor, c.baseline),
+			}
+		}
+	}
+	if trip == nil {
+		trip = b.checkNewEmitter(k)
+	}
+	if trip == nil {
+		return true, nil
+	}
+
+	trip.ChainID = k.EmitterChain
+	trip.Time = now
+	trip.MessageID = k.MessageIDString()
+	trip.emitter = k.EmitterAddress
+	c.paused = true
+	c.trip = trip
+	circuitBreakerTripsTotal.WithLabelValues(k.EmitterChain.String(), trip.Reason).Inc()
+	circuitBreakerPaused.WithLabelValues(k.EmitterChain.String()).Set(1)
+	return false, trip
+}
+
+// checkNewEmitter returns a trip if the message is a high-value transfer from an emitter which never had a message
+// signed. It must be called with the mutex held.
+func (b *CircuitBreaker) checkNewEmitter(k *common.MessagePublication) *CircuitBreakerTrip {
+	if b.cfg.NewEmitterMinValue == 0 {
+		return nil
+	}
+	key := bypassKey{k.EmitterChain, k.EmitterAddress}
+	if b.known[key] {
+		return nil
+	}
+
+	payload, err := vaa.DecodeTransferPayloadHdr(k.Payload)
+	if err != nil {
+		return nil
+	}
+	value, ok := b.cfg.Valuer.TransferValue(payload)
+	if !ok || value < b.cfg.NewEmitterMinValue {
+		return nil
+	}
+
+	seen, err := b.cfg.History.HasSignedVAAsFromEmitter(k.EmitterChain, k.EmitterAddress)
+	if err != nil {
+		// Fail closed: the operator is paged and can resume the chain.
+		return &CircuitBreakerTrip{
+			Reason: BreakerReasonNewEmitter,
+			Detail: fmt.Sprintf("transfer worth $%d from emitter %v, which could not be looked up: %v", value, k.EmitterAddress, err),
+		}
+	}
+	if seen {
+		b.known[key] = true
+		return nil
+	}
+
+	return &CircuitBreakerTrip{
+		Reason: BreakerReasonNewEmitter,
+		Detail: fmt.Sprintf("transfer worth $%d from emitter %v, which never had a message signed", value, k.EmitterAddress),
+	}
+}
+
+// Resume resumes signing on a paused chain. The anomaly which tripped the breaker is accepted: after a rate spike the
+// baseline is raised to the rate of the spike, and after a transfer from a new emitter the emitter is considered seen.
+func (b *CircuitBreaker) Resume(chainID vaa.ChainID, now time.Time) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, ok := b.chains[chainID]
+	if !ok || !c.paused {
+		return fmt.Errorf("signing is not paused on %v", chainID)
+	}
+	c.roll(now)
+
+	switch c.trip.Reason {
+	case BreakerReasonRateSpike:
+		if rate := float64(c.trip.rate); rate > c.baseline {
+			c.baseline = rate
+		}
+		c.window = 0
+	case BreakerReasonNewEmitter:
+		b.known[bypassKey{chainID, c.trip.emitter}] = true
+	}
+
+	c.paused = false
+	circuitBreakerPaused.WithLabelValues(chainID.String()).Set(0)
+	return nil
+}
+
+// UpdateMetrics rolls the windows of all chains, so the baseline of chains that stopped sending messages decays.
+func (b *CircuitBreaker) UpdateMetrics(now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, c := range b.chains {
+		c.roll(now)
+	}
+}
+
+// Status returns the circuit breaker state of the chains that have sent messages, ordered by chain ID.
+func (b *CircuitBreaker) Status(now time.Time) []CircuitBreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	out := make([]CircuitBreakerStatus, 0, len(b.chains))
+	for chainID := range b.chains {
+		c := b.chain(chainID, now)
+		s := CircuitBreakerStatus{
+			ChainID:       chainID,
+			Paused:        c.paused,
+			CurrentMinute: c.window,
+			Dropped:       c.dropped,
+			LastDropped:   c.lastDropped,
+		}
+		if c.minutes >= breakerBaselineWarmup {
+			s.BaselinePerMinute = c.baseline
+		}
+		if c.trip != nil {
+			trip := *c.trip
+			s.LastTrip = &trip
+		}
+		out = append(out, s)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ChainID < out[j].ChainID })
+	return out
+}
@@ -0,0 +1,178 @@
+package processor
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockValuer values transfers at their amount.
+type mockValuer struct{}
+
+func (mockValuer) TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool) {
+	return payload.Amount.Uint64(), payload.OriginChain == vaa.ChainIDEthereum
+}
+
+// mockHistory knows the emitters with a signed VAA.
+type mockHistory struct {
+	seen map[vaa.Address]bool
+	err  error
+}
+
+func (h *mockHistory) HasSignedVAAsFromEmitter(chainID vaa.ChainID, emitter vaa.Address) (bool, error) {
+	return h.seen[emitter], h.err
+}
+
+func transferMessage(emitter vaa.Address, amount uint64) *common.MessagePublication {
+	payload := make([]byte, 101)
+	payload[0] = 1
+	binary.BigEndian.PutUint64(payload[25:33], amount)
+	binary.BigEndian.PutUint16(payload[65:67], uint16(vaa.ChainIDEthereum))
+	return &common.MessagePublication{EmitterChain: vaa.ChainIDBSC, EmitterAddress: emitter, Payload: payload}
+}
+
+func TestCircuitBreakerRateSpike(t *testing.T) {
+	b, err := NewCircuitBreaker(CircuitBreakerConfig{SpikeFactor: 4, SpikeMinMessages: 10})
+	require.NoError(t, err)
+
+	start := time.Unix(1_700_000_000, 0)
+	emitter := vaa.Address{31: 1}
+	send := func(n int, now time.Time) {
+		for i := 0; i < n; i++ {
+			allowed, trip := b.Check(rateLimitedMessage(vaa.ChainIDBSC, emitter), now)
+			require.True(t, allowed)
+			require.Nil(t, trip)
+		}
+	}
+
+	// The heuristic does not apply while the baseline is measured.
+	send(50, start)
+	for i := 1; i < breakerBaselineWarmup; i++ {
+		send(5, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	// A baseline of 9.5 messages per minute allows up to 38 messages in a minute.
+	now := start.Add(breakerBaselineWarmup * time.Minute)
+	status := b.Status(now)
+	require.Equal(t, 1, len(status))
+	baseline := status[0].BaselinePerMinute
+	assert.Equal(t, 9.5, baseline)
+	send(38, now)
+	allowed, trip := b.Check(rateLimitedMessage(vaa.ChainIDBSC, emitter), now)
+	assert.False(t, allowed)
+	require.NotNil(t, trip)
+	assert.Equal(t, BreakerReasonRateSpike, trip.Reason)
+	assert.Equal(t, vaa.ChainIDBSC, trip.ChainID)
+	assert.Equal(t, float64(1), testutil.ToFloat64(circuitBreakerPaused.WithLabelValues("bsc")))
+
+	// The chain stays paused, and its baseline is frozen.
+	allowed, trip = b.Check(rateLimitedMessage(vaa.ChainIDBSC, emitter), now.Add(5*time.Minute))
+	assert.False(t, allowed)
+	assert.Nil(t, trip)
+	status = b.Status(now.Add(5 * time.Minute))
+	assert.True(t, status[0].Paused)
+	assert.Equal(t, uint64(1), status[0].Dropped)
+	assert.Equal(t, baseline, status[0].BaselinePerMinute)
+	require.NotNil(t, status[0].LastTrip)
+	assert.Equal(t, BreakerReasonRateSpike, status[0].LastTrip.Reason)
+
+	// Other chains are not paused.
+	allowed, _ = b.Check(rateLimitedMessage(vaa.ChainIDSolana, emitter), now)
+	assert.True(t, allowed)
+
+	require.NoError(t, b.Resume(vaa.ChainIDBSC, now.Add(5*time.Minute)))
+	assert.Error(t, b.Resume(vaa.ChainIDBSC, now.Add(5*time.Minute)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(circuitBreakerPaused.WithLabelValues("bsc")))
+	send(10, now.Add(5*time.Minute))
+}
+
+func TestCircuitBreakerRateSpikeResume(t *testing.T) {
+	b, err := NewCircuitBreaker(CircuitBreakerConfig{SpikeFactor: 2, SpikeMinMessages: 1})
+	require.NoError(t, err)
+
+	start := time.Unix(1_700_000_000, 0)
+	for i := 0; i <= breakerBaselineWarmup; i++ {
+		allowed, _ := b.Check(rateLimitedMessage(vaa.ChainIDBSC, vaa.Address{}), start.Add(time.Duration(i)*time.Minute))
+		require.True(t, allowed)
+	}
+
+	now := start.Add(breakerBaselineWarmup * time.Minute)
+	allowed, trip := b.Check(rateLimitedMessage(vaa.ChainIDBSC, vaa.Address{}), now)
+	assert.True(t, allowed)
+	assert.Nil(t, trip)
+	allowed, trip = b.Check(rateLimitedMessage(vaa.ChainIDBSC, vaa.Address{}), now)
+	assert.False(t, allowed)
+	require.NotNil(t, trip)
+
+	// Resuming accepts the current rate as the baseline.
+	require.NoError(t, b.Resume(vaa.ChainIDBSC, now))
+	for i := 0; i < 6; i++ {
+		allowed, _ = b.Check(rateLimitedMessage(vaa.ChainIDBSC, vaa.Address{}), now)
+		assert.True(t, allowed)
+	}
+}
+
+func TestCircuitBreakerNewEmitter(t *testing.T) {
+	tokenBridge := vaa.Address{31: 2}
+	known := vaa.Address{31: 3}
+	history := &mockHistory{seen: map[vaa.Address]bool{known: true}}
+	b, err := NewCircuitBreaker(CircuitBreakerConfig{
+		NewEmitterMinValue: 1000,
+		Valuer:             mockValuer{},
+		History:            history,
+		KnownEmitters:      []common.EmitterInfo{{ChainID: vaa.ChainIDBSC, Emitter: tokenBridge.String()}},
+	})
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	check := func(k *common.MessagePublication) (bool, *CircuitBreakerTrip) {
+		return b.Check(k, now)
+	}
+
+	allowed, _ := check(transferMessage(tokenBridge, 1_000_000))
+	assert.True(t, allowed)
+	allowed, _ = check(transferMessage(known, 1_000_000))
+	assert.True(t, allowed)
+
+	// Low-value transfers and other messages of new emitters are allowed.
+	newEmitter := vaa.Address{31: 4}
+	allowed, _ = check(transferMessage(newEmitter, 999))
+	assert.True(t, allowed)
+	allowed, _ = check(rateLimitedMessage(vaa.ChainIDBSC, newEmitter))
+	assert.True(t, allowed)
+
+	allowed, trip := check(transferMessage(newEmitter, 1000))
+	assert.False(t, allowed)
+	require.NotNil(t, trip)
+	assert.Equal(t, BreakerReasonNewEmitter, trip.Reason)
+	allowed, _ = check(transferMessage(tokenBridge, 1))
+	assert.False(t, allowed)
+
+	// Once resumed, the emitter is considered seen.
+	require.NoError(t, b.Resume(vaa.ChainIDBSC, now))
+	allowed, _ = check(transferMessage(newEmitter, 1_000_000))
+	assert.True(t, allowed)
+
+	// The breaker fails closed if the emitter cannot be looked up.
+	history.err = errors.New("db error")
+	allowed, trip = check(transferMessage(vaa.Address{31: 5}, 1_000_000))
+	assert.False(t, allowed)
+	require.NotNil(t, trip)
+	assert.Contains(t, trip.Detail, "db error")
+}
+
+func TestNewCircuitBreakerInvalid(t *testing.T) {
+	_, err := NewCircuitBreaker(CircuitBreakerConfig{SpikeFactor: 1})
+	assert.Error(t, err)
+	_, err = NewCircuitBreaker(CircuitBreakerConfig{NewEmitterMinValue: 1000})
+	assert.Error(t, err)
+	_, err = NewCircuitBreaker(CircuitBreakerConfig{KnownEmitters: []common.EmitterInfo{{ChainID: vaa.ChainIDBSC, Emitter: "0102"}}})
+	assert.Error(t, err)
+}
@@ -8,10 +8,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 )
 
@@ -30,7 +31,7 @@ func (p *Processor) broadcastSignature(
 ) {
 	digest := o.SigningMsg()
 	obsv := gossipv1.SignedObservation{
-		Addr:      crypto.PubkeyToAddress(p.gk.PublicKey).Bytes(),
+		Addr:      guardiansigner.Address(p.signingKey()).Bytes(),
 		Hash:      digest.Bytes(),
 		Signature: signature,
 		TxHash:    txhash,
@@ -44,7 +45,9 @@ func (p *Processor) broadcastSignature(
 		panic(err)
 	}
 
+	span := tracing.Start(o.GetEmitterChain(), o.MessageID(), "gossip.broadcast_observation")
 	p.sendC <- msg
+	span.End()
 
 	// Store our VAA in case we're going to submit it to Solana
 	hash := hex.EncodeToString(digest.Bytes())
@@ -64,7 +67,7 @@ func (p *Processor) broadcastSignature(
 	p.state.signatures[hash].gs = p.gs // guaranteed to match ourObservation - there's no concurrent access to p.gs
 
 	// Fast path for our own signature
-	go func() { p.obsvC <- &obsv }()
+	go func() { p.bus.SignedObservations().C() <- &obsv }()
 
 	observationsBroadcastTotal.Inc()
 }
@@ -84,5 +87,7 @@ func (p *Processor) broadcastSignedVAA(v *vaa.VAA) {
 		panic(err)
 	}
 
+	span := tracing.Start(v.EmitterChain, v.MessageID(), "gossip.broadcast_vaa")
 	p.sendC <- msg
+	span.End()
 }
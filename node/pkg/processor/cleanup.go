@@ -63,6 +63,22 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 	p.logger.Info("aggregation state summary", zap.Int("cached", len(p.state.signatures)))
 	aggregationStateEntries.Set(float64(len(p.state.signatures)))
 
+	if p.observerMode {
+		p.cleanupObserverState()
+	}
+
+	if p.signLimiter != nil {
+		p.signLimiter.UpdateMetrics(time.Now())
+	}
+
+	if p.breaker != nil {
+		p.breaker.UpdateMetrics(time.Now())
+	}
+
+	p.rebroadcastNetworkConfig(time.Now())
+
+	p.replay.prune(time.Now())
+
 	for hash, s := range p.state.signatures {
 		delta := time.Since(s.firstObserved)
 
@@ -73,7 +89,7 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 			// This occurs when we observed a message after the cluster has already reached
 			// consensus on it, causing us to never achieve quorum.
 			if ourVaa, ok := s.ourObservation.(*VAA); ok {
-				if _, err := p.db.GetSignedVAABytes(*db.VaaIDFromVAA(&ourVaa.VAA)); err == nil {
+				if stored, err := p.hasSignedVAA(*db.VaaIDFromVAA(&ourVaa.VAA), ourVaa.GuardianSetIndex); err == nil && stored {
 					// If we have a stored quorum VAA, we can safely expire the state.
 					//
 					// This is a rare case, and we can safely expire the state, since we
@@ -81,8 +97,10 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 					p.logger.Info("Expiring late VAA", zap.String("digest", hash), zap.Duration("delta", delta))
 					aggregationStateLate.Inc()
 					delete(p.state.signatures, hash)
+					p.replay.addExpired(hash, time.Now())
+					p.forgetObservation(hash)
 					break
-				} else if err != db.ErrVAANotFound {
+				} else if err != nil {
 					p.logger.Error("failed to look up VAA in database",
 						zap.String("digest", hash),
 						zap.Error(err),
@@ -106,8 +124,8 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 			}
 
 			hasSigs := len(s.signatures)
-			wantSigs := CalculateQuorum(len(gs.Keys))
-			quorum := hasSigs >= wantSigs
+			wantSigs := vaa.CalculateQuorum(len(gs.Keys))
+			quorum := vaa.HasQuorum(hasSigs, len(gs.Keys))
 
 			var chain vaa.ChainID
 			if s.ourObservation != nil {
@@ -169,12 +187,16 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 			// and then expired after a while (as noted in observation.go, this can be abused by a byzantine guardian).
 			p.logger.Info("expiring submitted observation", zap.String("digest", hash), zap.Duration("delta", delta))
 			delete(p.state.signatures, hash)
+			p.replay.addExpired(hash, time.Now())
 			aggregationStateExpiration.Inc()
 		case !s.submitted && ((s.ourMsg != nil && s.retryCount >= 14400 /* 120 hours */) || (s.ourMsg == nil && s.retryCount >= 10 /* 5 minutes */)):
 			// Clearly, this horse is dead and continued beatings won't bring it closer to quorum.
 			p.logger.Info("expiring unsubmitted observation after exhausting retries", zap.String("digest", hash), zap.Duration("delta", delta))
 			delete(p.state.signatures, hash)
 			aggregationStateTimeout.Inc()
+			if s.ourMsg != nil {
+				p.forgetObservation(hash)
+			}
 		case !s.submitted && delta.Minutes() >= 5:
 			// Poor observation has been unsubmitted for five minutes - clearly, something went wrong.
 			// If we have previously submitted an observation, we can make another attempt to get it over
@@ -189,7 +211,7 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 					zap.Uint("retry", s.retryCount))
 				req := &gossipv1.ObservationRequest{
 					ChainId: uint32(s.ourObservation.GetEmitterChain()),
-					TxHash:  s.txHash,
+					TxHash:  common.ObservationRequestTxHash(s.ourObservation.GetEmitterChain(), s.txHash),
 				}
 				if err := common.PostObservationRequest(p.obsvReqSendC, req); err != nil {
 					p.logger.Warn("failed to broadcast re-observation request", zap.Error(err))
@@ -202,18 +224,20 @@ func (p *Processor) handleCleanup(ctx context.Context) {
 				// network reached consensus without us. We don't know the correct guardian
 				// set, so we simply use the most recent one.
 				hasSigs := len(s.signatures)
-				wantSigs := CalculateQuorum(len(p.gs.Keys))
+				wantSigs := vaa.CalculateQuorum(len(p.gs.Keys))
 
 				p.logger.Info("expiring unsubmitted nil observation",
 					zap.String("digest", hash),
 					zap.Duration("delta", delta),
 					zap.Int("have_sigs", hasSigs),
 					zap.Int("required_sigs", wantSigs),
-					zap.Bool("quorum", hasSigs >= wantSigs),
+					zap.Bool("quorum", vaa.HasQuorum(hasSigs, len(p.gs.Keys))),
 				)
 				delete(p.state.signatures, hash)
 				aggregationStateUnobserved.Inc()
 			}
 		}
 	}
+
+	p.reportStuckMessages(time.Now())
 }
@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DrainComponent is the name under which the processor is registered with the node's common.Drain.
+const DrainComponent = "processor"
+
+func (p *Processor) draining() bool {
+	return p.drain != nil && p.drain.IsStarted()
+}
+
+// inFlight returns the number of our own observations which did not reach quorum yet, but still may, since they are
+// younger than the settlement time.
+func (p *Processor) inFlight(now time.Time) int {
+	n := 0
+	for _, s := range p.state.signatures {
+		if s.ourObservation != nil && !s.submitted && now.Sub(s.firstObserved) < settlementTime {
+			n++
+		}
+	}
+	return n
+}
+
+// checkDrained reports the processor as finished to the drain once none of our observations is in flight. It returns
+// whether the processor is drained.
+func (p *Processor) checkDrained(now time.Time) bool {
+	if n := p.inFlight(now); n != 0 {
+		p.logger.Info("draining: waiting for our observations to reach quorum", zap.Int("in_flight", n))
+		return false
+	}
+
+	p.logger.Info("draining: all our observations reached quorum or settled")
+	p.drain.Finish(DrainComponent)
+	return true
+}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCheckDrained(t *testing.T) {
+	drain := common.NewDrain()
+	drain.Add(DrainComponent)
+	now := time.Now()
+	ours := &VAA{VAA: getVAA()}
+	p := Processor{
+		logger: zap.NewNop(),
+		drain:  drain,
+		state: &aggregationState{signatures: observationMap{
+			// Our observation is still waiting for quorum.
+			"pending": {firstObserved: now, ourObservation: ours},
+			// Our observation reached quorum.
+			"submitted": {firstObserved: now, ourObservation: ours, submitted: true},
+			// Our observation will not reach quorum anymore.
+			"expired": {firstObserved: now.Add(-settlementTime - time.Second), ourObservation: ours},
+			// Only other guardians observed it.
+			"theirs": {firstObserved: now},
+		}},
+	}
+
+	assert.False(t, p.draining())
+	drain.Start()
+	assert.True(t, p.draining())
+
+	assert.Equal(t, 1, p.inFlight(now))
+	assert.False(t, p.checkDrained(now))
+	assert.Equal(t, []string{DrainComponent}, drain.Pending())
+
+	p.state.signatures["pending"].submitted = true
+	assert.True(t, p.checkDrained(now))
+	assert.Empty(t, drain.Pending())
+}
@@ -7,9 +7,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"go.uber.org/zap"
 
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 )
@@ -22,19 +22,47 @@ var (
 		})
 )
 
-// handleInjection processes a pre-populated VAA injected locally.
+// handleInjection processes a pre-populated VAA injected locally. If the VAA carries a single signature, it has been
+// made offline with our guardian key and verified by the originator, and is broadcast instead of signing the VAA.
 func (p *Processor) handleInjection(ctx context.Context, v *vaa.VAA) {
+	if p.observerMode {
+		p.logger.Warn("dropping injected VAA since the node is in observer mode",
+			zap.String("digest", hex.EncodeToString(v.SigningMsg().Bytes())))
+		return
+	}
+
+	var s []byte
+	if len(v.Signatures) == 1 {
+		s = v.Signatures[0].Signature[:]
+		v.Signatures = nil
+	}
+
 	// Generate digest of the unsigned VAA.
 	digest := v.SigningMsg()
+	hash := hex.EncodeToString(digest.Bytes())
+
+	if s == nil {
+		p.journalObservation(hash, v, nil, nil)
 
-	// The internal originator is responsible for logging the full VAA, just log the digest here.
-	supervisor.Logger(ctx).Info("signing injected VAA",
-		zap.String("digest", hex.EncodeToString(digest.Bytes())))
+		// The internal originator is responsible for logging the full VAA, just log the digest here.
+		supervisor.Logger(ctx).Info("signing injected VAA",
+			zap.String("digest", hex.EncodeToString(digest.Bytes())))
 
-	// Sign the digest using our node's guardian key.
-	s, err := crypto.Sign(digest.Bytes(), p.gk)
-	if err != nil {
-		panic(err)
+		// Sign the digest using our node's guardian key.
+		var err error
+		auditCtx := guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{
+			Type:           guardiansigner.AuditTypeInjectedVAA,
+			EmitterChain:   uint16(v.EmitterChain),
+			EmitterAddress: v.EmitterAddress.String(),
+			Sequence:       v.Sequence,
+		})
+		s, err = p.signingKey().Sign(auditCtx, digest.Bytes())
+		if err != nil {
+			p.logger.Error("failed to sign injected VAA",
+				zap.String("digest", hex.EncodeToString(digest.Bytes())),
+				zap.Error(err))
+			return
+		}
 	}
 
 	p.logger.Info("observed and signed injected VAA",
@@ -42,5 +70,6 @@ func (p *Processor) handleInjection(ctx context.Context, v *vaa.VAA) {
 		zap.String("signature", hex.EncodeToString(s)))
 
 	vaaInjectionsTotal.Inc()
+	p.journalObservation(hash, v, nil, s)
 	p.broadcastSignature(&VAA{VAA: *v}, s, nil)
 }
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	journaledObservationsReplayed = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_journaled_observations_replayed_total",
+			Help: "Total number of observations without quorum gossiped again from the observation journal after a restart",
+		})
+)
+
+// journalObservation writes our observation of v to the observation journal, before it is signed and once more with
+// its signature before the signature is gossiped. If the node crashes before the observation reaches quorum, it is
+// gossiped again by replayObservationJournal after the restart, instead of being lost until a reobservation.
+//
+// Failing to write the journal doesn't prevent signing, since the journal only narrows the crash window.
+func (p *Processor) journalObservation(hash string, v *vaa.VAA, txHash []byte, signature []byte) {
+	b, err := v.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	if err := p.db.JournalObservation(hash, &db.JournaledObservation{
+		VAA:       b,
+		TxHash:    txHash,
+		Signature: signature,
+		Observed:  time.Now(),
+	}); err != nil {
+		p.logger.Error("failed to journal observation", zap.String("digest", hash), zap.Error(err))
+	}
+}
+
+// forgetObservation removes our observation from the observation journal, once it reached quorum or was given up.
+func (p *Processor) forgetObservation(hash string) {
+	if err := p.db.DeleteJournaledObservation(hash); err != nil {
+		p.logger.Warn("failed to delete journaled observation", zap.String("digest", hash), zap.Error(err))
+	}
+}
+
+// replayObservationJournal gossips again the observations of the journal which haven't reached quorum, when the
+// guardian set is first known after a restart. Observations which weren't signed before the crash are signed now.
+// The replayed observations are then retransmitted by the cleanup like any other observation.
+func (p *Processor) replayObservationJournal(ctx context.Context) {
+	if p.observerMode {
+		return
+	}
+
+	journal, err := p.db.JournaledObservations()
+	if err != nil {
+		p.logger.Error("failed to read observation journal", zap.Error(err))
+		return
+	}
+
+	for hash, o := range journal {
+		v, err := vaa.Unmarshal(o.VAA)
+		if err != nil {
+			p.logger.Error("dropping invalid journaled observation", zap.String("digest", hash), zap.Error(err))
+			p.forgetObservation(hash)
+			continue
+		}
+		if digest := hex.EncodeToString(v.SigningMsg().Bytes()); digest != hash {
+			p.logger.Error("dropping journaled observation with a mismatching digest",
+				zap.String("digest", hash),
+				zap.String("vaa_digest", digest))
+			p.forgetObservation(hash)
+			continue
+		}
+
+		if stored, err := p.hasSignedVAA(*db.VaaIDFromVAA(v), v.GuardianSetIndex); err == nil && stored {
+			// Quorum was reached while the node was down, or before the journal entry was deleted.
+			p.forgetObservation(hash)
+			continue
+		} else if err != nil {
+			p.logger.Error("failed to look up VAA in database", zap.String("digest", hash), zap.Error(err))
+			continue
+		}
+
+		// The signatures of another guardian set don't count towards the quorum of the current one. The message is
+		// recovered by a reobservation.
+		if v.GuardianSetIndex != p.gs.Index {
+			p.logger.Info("dropping journaled observation of a previous guardian set",
+				zap.String("digest", hash),
+				zap.String("message_id", v.MessageID()),
+				zap.Uint32("guardian_set_index", v.GuardianSetIndex))
+			p.forgetObservation(hash)
+			continue
+		}
+
+		s := o.Signature
+		if s == nil {
+			auditType := guardiansigner.AuditTypeObservation
+			if v.EmitterChain == vaa.GovernanceChain && v.EmitterAddress == vaa.GovernanceEmitter {
+				auditType = guardiansigner.AuditTypeInjectedVAA
+			}
+			auditCtx := guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{
+				Type:           auditType,
+				EmitterChain:   uint16(v.EmitterChain),
+				EmitterAddress: v.EmitterAddress.String(),
+				Sequence:       v.Sequence,
+			})
+			if s, err = p.signingKey().Sign(auditCtx, v.SigningMsg().Bytes()); err != nil {
+				p.logger.Error("failed to sign journaled observation",
+					zap.String("digest", hash),
+					zap.String("message_id", v.MessageID()),
+					zap.Error(err))
+				continue
+			}
+			p.journalObservation(hash, v, o.TxHash, s)
+		}
+
+		p.logger.Info("gossiping journaled observation without quorum",
+			zap.String("digest", hash),
+			zap.String("message_id", v.MessageID()),
+			zap.Time("observed", o.Observed))
+		journaledObservationsReplayed.Inc()
+		p.broadcastSignature(&VAA{VAA: *v}, s, o.TxHash)
+	}
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/eventbus"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReplayObservationJournal(t *testing.T) {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer d.Close()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	gk := guardiansigner.NewLocalSigner(key)
+	p := &Processor{
+		logger: zap.NewNop(),
+		db:     d,
+		bus:    eventbus.New(),
+		sendC:  make(chan []byte, 10),
+		gk:     gk,
+		gs:     &common.GuardianSet{Keys: []ethcommon.Address{crypto.PubkeyToAddress(key.PublicKey)}, Index: 1},
+		state:  &aggregationState{observationMap{}},
+	}
+
+	observation := func(sequence uint64, guardianSetIndex uint32) (string, *vaa.VAA) {
+		v := getVAA()
+		v.Sequence = sequence
+		v.GuardianSetIndex = guardianSetIndex
+		return hex.EncodeToString(v.SigningMsg().Bytes()), &v
+	}
+
+	// Signed and gossiped before the crash.
+	signedHash, signed := observation(1, 1)
+	s, err := gk.Sign(context.Background(), signed.SigningMsg().Bytes())
+	require.NoError(t, err)
+	p.journalObservation(signedHash, signed, []byte{1}, s)
+
+	// The node crashed before signing it.
+	unsignedHash, unsigned := observation(2, 1)
+	p.journalObservation(unsignedHash, unsigned, []byte{2}, nil)
+
+	// Quorum was reached while the node was down.
+	quorumHash, quorum := observation(3, 1)
+	p.journalObservation(quorumHash, quorum, nil, s)
+	stored := *quorum
+	stored.AddSignature(key, 0)
+	require.NoError(t, d.StoreSignedVAA(&stored))
+
+	// The guardian set changed while the node was down.
+	previousHash, previous := observation(4, 0)
+	p.journalObservation(previousHash, previous, nil, s)
+
+	p.replayObservationJournal(context.Background())
+
+	assert.Len(t, p.sendC, 2)
+	require.Contains(t, p.state.signatures, signedHash)
+	require.Contains(t, p.state.signatures, unsignedHash)
+	assert.Equal(t, []byte{1}, p.state.signatures[signedHash].txHash)
+	assert.Equal(t, p.gs, p.state.signatures[unsignedHash].gs)
+
+	journal, err := d.JournaledObservations()
+	require.NoError(t, err)
+	assert.Len(t, journal, 2)
+	require.Contains(t, journal, unsignedHash)
+	assert.NotEmpty(t, journal[unsignedHash].Signature)
+
+	// Once at quorum, the observation is no longer journaled.
+	p.forgetObservation(signedHash)
+	journal, err = d.JournaledObservations()
+	require.NoError(t, err)
+	assert.NotContains(t, journal, signedHash)
+}
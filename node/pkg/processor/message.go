@@ -3,20 +3,23 @@ package processor
 import (
 	"context"
 	"encoding/hex"
+	"time"
 
 	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
 	"github.com/mr-tron/base58"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
-	"github.com/ethereum/go-ethereum/crypto"
 	"go.uber.org/zap"
 
 	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/reporter"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -36,6 +39,16 @@ var (
 			Help: "Total number of message observations that were successfully signed",
 		},
 		[]string{"emitter_chain"})
+
+	// From the on-chain timestamp of the message, which has a resolution of a second, until it is signed. This includes
+	// the time spent waiting for confirmations.
+	observationLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "wormhole_message_observation_latency_seconds",
+			Help:    "Latency from the on-chain timestamp of a message until it was signed",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 13),
+		},
+		[]string{"emitter_chain"})
 )
 
 // handleMessage processes a message received from a chain and instantiates our deterministic copy of the VAA. An
@@ -52,6 +65,10 @@ func (p *Processor) handleMessage(ctx context.Context, k *common.MessagePublicat
 		return
 	}
 
+	// Ended once the message is signed, or when it is dropped.
+	span := tracing.Start(k.EmitterChain, k.MessageIDString(), "processor.sign", attribute.String("tx_hash", k.TxHash.Hex()))
+	defer span.End()
+
 	supervisor.Logger(ctx).Info("message publication confirmed",
 		zap.Stringer("emitter_chain", k.EmitterChain),
 		zap.Stringer("emitter_address", k.EmitterAddress),
@@ -67,20 +84,7 @@ func (p *Processor) handleMessage(ctx context.Context, k *common.MessagePublicat
 	// All nodes will create the exact same VAA and sign its digest.
 	// Consensus is established on this digest.
 
-	v := &VAA{
-		VAA: vaa.VAA{
-			Version:          vaa.SupportedVAAVersion,
-			GuardianSetIndex: p.gs.Index,
-			Signatures:       nil,
-			Timestamp:        k.Timestamp,
-			Nonce:            k.Nonce,
-			EmitterChain:     k.EmitterChain,
-			EmitterAddress:   k.EmitterAddress,
-			Payload:          k.Payload,
-			Sequence:         k.Sequence,
-			ConsistencyLevel: k.ConsistencyLevel,
-		},
-	}
+	v := &VAA{VAA: *k.CreateVAA(p.gs.Index)}
 
 	// A governance message should never be emitted on-chain
 	if v.EmitterAddress == vaa.GovernanceEmitter && v.EmitterChain == vaa.GovernanceChain {
@@ -100,14 +104,17 @@ func (p *Processor) handleMessage(ctx context.Context, k *common.MessagePublicat
 	//
 	// Exception: if an observation is made within the settlement time (30s), we'll
 	// process it so other nodes won't consider it a miss.
+	//
+	// In observer mode, the stored VAA is compared to our observation instead. A VAA signed by an older guardian set
+	// doesn't count, so the message can be signed again by the current set.
+	var existing *vaa.VAA
 	if vb, err := p.db.GetSignedVAABytes(*db.VaaIDFromVAA(&v.VAA)); err == nil {
 		// unmarshal vaa
-		var existing *vaa.VAA
 		if existing, err = vaa.Unmarshal(vb); err != nil {
 			panic("failed to unmarshal VAA from db")
 		}
 
-		if k.Timestamp.Sub(existing.Timestamp) > settlementTime {
+		if !p.observerMode && existing.GuardianSetIndex >= p.gs.Index && k.Timestamp.Sub(existing.Timestamp) > settlementTime {
 			p.logger.Info("ignoring observation since we already have a quorum VAA for it",
 				zap.Stringer("emitter_chain", k.EmitterChain),
 				zap.Stringer("emitter_address", k.EmitterAddress),
@@ -135,10 +142,30 @@ func (p *Processor) handleMessage(ctx context.Context, k *common.MessagePublicat
 	// Generate digest of the unsigned VAA.
 	digest := v.SigningMsg()
 
+	if p.observerMode {
+		p.handleObserverMessage(v, hex.EncodeToString(digest.Bytes()), existing)
+		return
+	}
+
+	hash := hex.EncodeToString(digest.Bytes())
+	p.journalObservation(hash, &v.VAA, k.TxHash.Bytes(), nil)
+
 	// Sign the digest using our node's guardian key.
-	s, err := crypto.Sign(digest.Bytes(), p.gk)
+	auditCtx := guardiansigner.WithAuditInfo(ctx, guardiansigner.AuditInfo{
+		Type:           guardiansigner.AuditTypeObservation,
+		EmitterChain:   uint16(k.EmitterChain),
+		EmitterAddress: k.EmitterAddress.String(),
+		Sequence:       k.Sequence,
+	})
+	s, err := p.signingKey().Sign(auditCtx, digest.Bytes())
 	if err != nil {
-		panic(err)
+		// The message is recovered by a reobservation once the signer is available again.
+		p.logger.Error("failed to sign message publication",
+			zap.Stringer("emitter_chain", k.EmitterChain),
+			zap.Stringer("txhash", k.TxHash),
+			zap.String("message_id", v.MessageID()),
+			zap.Error(err))
+		return
 	}
 
 	p.logger.Info("observed and signed confirmed message publication",
@@ -158,7 +185,33 @@ func (p *Processor) handleMessage(ctx context.Context, k *common.MessagePublicat
 	messagesSignedTotal.With(prometheus.Labels{
 		"emitter_chain": k.EmitterChain.String()}).Add(1)
 
+	// Skewed clocks can make the latency negative.
+	latency := time.Since(k.Timestamp)
+	if latency < 0 {
+		latency = 0
+	}
+	observationLatency.With(prometheus.Labels{
+		"emitter_chain": k.EmitterChain.String()}).Observe(latency.Seconds())
+	span.End()
+
 	p.attestationEvents.ReportMessagePublication(&reporter.MessagePublication{VAA: v.VAA, InitiatingTxID: k.TxHash})
 
+	// Keep the tx hash so the message can be reobserved by ID using the admin command.
+	if err := p.db.StoreMessageTxHash(*db.VaaIDFromVAA(&v.VAA), k.TxHash.Bytes()); err != nil {
+		p.logger.Warn("failed to store message tx hash",
+			zap.String("message_id", v.MessageID()),
+			zap.Error(err))
+	}
+
+	// Keep where the message came from, to debug disputed observations using the admin command.
+	if k.Provenance != nil {
+		if err := p.db.StoreMessageProvenance(*db.VaaIDFromVAA(&v.VAA), k.Provenance); err != nil {
+			p.logger.Warn("failed to store message provenance",
+				zap.String("message_id", v.MessageID()),
+				zap.Error(err))
+		}
+	}
+
+	p.journalObservation(hash, &v.VAA, k.TxHash.Bytes(), s)
 	p.broadcastSignature(v, s, k.TxHash.Bytes())
 }
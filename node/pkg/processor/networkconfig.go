@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"time"
+
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// networkConfigBroadcastInterval is how often the applied network configuration is gossiped again, so that guardians
+// which were offline when it reached quorum apply it as well.
+const networkConfigBroadcastInterval = 10 * time.Minute
+
+// rebroadcastNetworkConfig gossips the applied network configuration VAA if it was not gossiped within the interval.
+// Guardians which already store it ignore it, and the others verify it against their guardian set before applying it.
+func (p *Processor) rebroadcastNetworkConfig(now time.Time) {
+	b := p.netConfig.SignedVAA()
+	if b == nil || now.Sub(p.netConfigBroadcast) < networkConfigBroadcastInterval {
+		return
+	}
+	p.netConfigBroadcast = now
+
+	msg, err := proto.Marshal(&gossipv1.GossipMessage{Message: &gossipv1.GossipMessage_SignedVaaWithQuorum{
+		SignedVaaWithQuorum: &gossipv1.SignedVAAWithQuorum{Vaa: b},
+	}})
+	if err != nil {
+		panic(err)
+	}
+
+	p.logger.Debug("gossiping network config", zap.Uint64("version", p.netConfig.Config().Version))
+	p.sendC <- msg
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/networkconfig"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRebroadcastNetworkConfig(t *testing.T) {
+	p := &Processor{
+		logger:    zap.NewNop(),
+		sendC:     make(chan []byte, 10),
+		netConfig: networkconfig.NewState(zap.NewNop(), nil),
+	}
+	now := time.Unix(1_700_000_000, 0)
+
+	// Nothing to gossip before a configuration is applied.
+	p.rebroadcastNetworkConfig(now)
+	assert.Empty(t, p.sendC)
+
+	v := vaa.CreateGovernanceVAA(now, 1, 1, 0, vaa.BodyNetworkConfig{
+		Version:  1,
+		Features: []vaa.NetworkConfigFeature{{Name: "batch", ActivationTime: 1_700_000_100}},
+	}.Serialize())
+	require.True(t, p.netConfig.ProcessGovernanceVAA(v))
+
+	p.rebroadcastNetworkConfig(now)
+	require.Equal(t, 1, len(p.sendC))
+	var msg gossipv1.GossipMessage
+	require.NoError(t, proto.Unmarshal(<-p.sendC, &msg))
+	assert.Equal(t, p.netConfig.SignedVAA(), msg.GetSignedVaaWithQuorum().Vaa)
+
+	p.rebroadcastNetworkConfig(now.Add(networkConfigBroadcastInterval - time.Second))
+	assert.Empty(t, p.sendC)
+	p.rebroadcastNetworkConfig(now.Add(networkConfigBroadcastInterval))
+	assert.Equal(t, 1, len(p.sendC))
+
+	// A processor without network configuration never gossips one.
+	p = &Processor{logger: zap.NewNop(), sendC: make(chan []byte, 10)}
+	p.rebroadcastNetworkConfig(now)
+	assert.Empty(t, p.sendC)
+}
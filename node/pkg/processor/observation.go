@@ -17,7 +17,9 @@ import (
 	"go.uber.org/zap"
 
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -53,6 +55,24 @@ func (p *Processor) handleObservation(ctx context.Context, m *gossipv1.SignedObs
 
 	hash := hex.EncodeToString(m.Hash)
 
+	observationsReceivedTotal.Inc()
+
+	// Drop the replays before the cost of verifying them.
+	var reason string
+	if p.replay.duplicate(hash, common.BytesToAddress(m.Addr), m.Signature, time.Now()) {
+		reason = "duplicate"
+	} else if p.replay.expired(hash) && p.state.signatures[hash] == nil {
+		reason = "expired"
+	}
+	if reason != "" {
+		p.logger.Debug("dropping replayed observation",
+			zap.String("digest", hash),
+			zap.String("addr", hex.EncodeToString(m.Addr)),
+			zap.String("reason", reason))
+		observationsReplayedTotal.WithLabelValues(reason).Inc()
+		return
+	}
+
 	p.logger.Info("received observation",
 		zap.String("digest", hash),
 		zap.String("signature", hex.EncodeToString(m.Signature)),
@@ -62,8 +82,6 @@ func (p *Processor) handleObservation(ctx context.Context, m *gossipv1.SignedObs
 		zap.String("message_id", m.MessageId),
 	)
 
-	observationsReceivedTotal.Inc()
-
 	// Verify the Guardian's signature. This verifies that m.Signature matches m.Hash and recovers
 	// the public key that was used to sign the payload.
 	pk, err := crypto.Ecrecover(m.Hash, m.Signature)
@@ -145,6 +163,7 @@ func (p *Processor) handleObservation(ctx context.Context, m *gossipv1.SignedObs
 
 	// We can now count events by guardian without worry about cardinality explosions:
 	observationsReceivedByGuardianAddressTotal.WithLabelValues(their_addr.Hex()).Inc()
+	p.replay.addVerified(hash, their_addr, m.Signature, time.Now())
 
 	// []byte isn't hashable in a map. Paying a small extra cost for encoding for easier debugging.
 	if p.state.signatures[hash] == nil {
@@ -192,7 +211,7 @@ func (p *Processor) handleObservation(ctx context.Context, m *gossipv1.SignedObs
 		// We have made this observation on chain!
 
 		// 2/3+ majority required for VAA to be valid - wait until we have quorum to submit VAA.
-		quorum := CalculateQuorum(len(gs.Keys))
+		quorum := vaa.CalculateQuorum(len(gs.Keys))
 
 		p.logger.Info("aggregation state for observation",
 			zap.String("digest", hash),
@@ -201,10 +220,10 @@ func (p *Processor) handleObservation(ctx context.Context, m *gossipv1.SignedObs
 			zap.Bools("aggregation", agg),
 			zap.Int("required_sigs", quorum),
 			zap.Int("have_sigs", len(sigs)),
-			zap.Bool("quorum", len(sigs) >= quorum),
+			zap.Bool("quorum", vaa.HasQuorum(len(sigs), len(gs.Keys))),
 		)
 
-		if len(sigs) >= quorum && !p.state.signatures[hash].submitted {
+		if vaa.HasQuorum(len(sigs), len(gs.Keys)) && !p.state.signatures[hash].submitted {
 			p.state.signatures[hash].ourObservation.HandleQuorum(sigs, hash, p)
 		} else {
 			p.logger.Info("quorum not met or already submitted, doing nothing",
@@ -258,8 +277,8 @@ func (p *Processor) handleInboundSignedVAAWithQuorum(ctx context.Context, m *gos
 	}
 
 	// Verify VAA has enough signatures for quorum
-	quorum := CalculateQuorum(len(p.gs.Keys))
-	if len(v.Signatures) < quorum {
+	quorum := vaa.CalculateQuorum(len(p.gs.Keys))
+	if !vaa.HasQuorum(len(v.Signatures), len(p.gs.Keys)) {
 		p.logger.Warn("received SignedVAAWithQuorum message without quorum",
 			zap.String("digest", hash),
 			zap.Any("message", m),
@@ -285,17 +304,17 @@ func (p *Processor) handleInboundSignedVAAWithQuorum(ctx context.Context, m *gos
 	//  - the signature's addresses match the node's current guardian set
 	//  - enough signatures are present for the VAA to reach quorum
 
-	// Check if we already store this VAA
-	_, err = p.db.GetSignedVAABytes(*db.VaaIDFromVAA(v))
-	if err == nil {
-		p.logger.Debug("ignored SignedVAAWithQuorum message for VAA we already store",
+	// Check if we already store this VAA. A stored VAA of an older guardian set is replaced.
+	stored, err := p.hasSignedVAA(*db.VaaIDFromVAA(v), v.GuardianSetIndex)
+	if err != nil {
+		p.logger.Error("failed to look up VAA in database",
 			zap.String("digest", hash),
+			zap.Error(err),
 		)
 		return
-	} else if err != db.ErrVAANotFound {
-		p.logger.Error("failed to look up VAA in database",
+	} else if stored {
+		p.logger.Debug("ignored SignedVAAWithQuorum message for VAA we already store",
 			zap.String("digest", hash),
-			zap.Error(err),
 		)
 		return
 	}
@@ -307,9 +326,23 @@ func (p *Processor) handleInboundSignedVAAWithQuorum(ctx context.Context, m *gos
 		zap.String("bytes", hex.EncodeToString(m.Vaa)),
 		zap.String("message_id", v.MessageID()))
 
+	span := tracing.Start(v.EmitterChain, v.MessageID(), "db.store", attribute.String("source", "gossip"))
 	if err := p.db.StoreSignedVAA(v); err != nil {
 		p.logger.Error("failed to store signed VAA", zap.Error(err))
+		span.RecordError(err)
+		span.End()
 		return
 	}
+	span.End()
+	p.forgetObservation(hash)
 	p.attestationEvents.ReportVAAQuorum(v)
+
+	if p.acct != nil {
+		p.acct.ProcessGovernanceVAA(v)
+	}
+	p.netConfig.ProcessGovernanceVAA(v)
+
+	if p.observerMode {
+		p.handleObserverQuorum(v, hash)
+	}
 }
@@ -8,11 +8,14 @@ import (
 	"time"
 
 	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/reporter"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 )
@@ -123,3 +126,38 @@ func TestHandleInboundSignedVAAWithQuorum(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleInboundSignedVAAWithQuorumReplacesOldGuardianSet(t *testing.T) {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	defer d.Close()
+
+	oldKey, _ := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	key, _ := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	processor := Processor{
+		logger:            zap.NewNop(),
+		db:                d,
+		gs:                &common.GuardianSet{Keys: []ethcommon.Address{crypto.PubkeyToAddress(key.PublicKey)}, Index: 1},
+		attestationEvents: reporter.EventListener(zap.NewNop()),
+	}
+
+	// A VAA of the previous guardian set is stored, and doesn't prevent the message from being signed again.
+	old := getVAA()
+	old.GuardianSetIndex = 0
+	old.AddSignature(oldKey, 0)
+	require.NoError(t, d.StoreSignedVAA(&old))
+	id := *db.VaaIDFromVAA(&old)
+	stored, err := processor.hasSignedVAA(id, 1)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	// It is replaced by the VAA of the current guardian set.
+	current := getVAA()
+	current.AddSignature(key, 0)
+	b, err := current.Marshal()
+	require.NoError(t, err)
+	processor.handleInboundSignedVAAWithQuorum(context.Background(), &gossipv1.SignedVAAWithQuorum{Vaa: b})
+	stored, err = processor.hasSignedVAA(id, 1)
+	require.NoError(t, err)
+	assert.True(t, stored)
+}
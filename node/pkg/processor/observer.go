@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
+
+// In observer mode, the node runs all watchers and follows the gossip network, but never signs or publishes anything.
+// Instead, the digest it would have signed for each message is compared to the digest of the quorum VAA produced by
+// the network, which shows whether the node's watchers are configured correctly before its guardian key goes live.
+
+var (
+	observerDigestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_observer_digests_total",
+			Help: "Total number of messages compared against the network in observer mode, grouped by result (match, mismatch, missed or unconfirmed)",
+		}, []string{"emitter_chain", "result"})
+)
+
+const (
+	// How long to wait for both our observation and the network quorum before counting a message as
+	// missed (quorum without our observation) or unconfirmed (our observation without quorum).
+	observerSettlementTime = 10 * time.Minute
+)
+
+// observerEntry is the observer mode view of a message, keyed by message ID.
+type observerEntry struct {
+	firstSeen    time.Time
+	emitterChain vaa.ChainID
+	// Digest we would have signed, empty until we observed the message.
+	ourDigest string
+	// Digest of the quorum VAA, empty until the network reached quorum.
+	networkDigest string
+	// Set once both digests were compared.
+	compared bool
+}
+
+// handleObserverMessage records the digest we would have signed for a message. If a quorum VAA is already stored for
+// it, the digests are compared right away.
+func (p *Processor) handleObserverMessage(v *VAA, digest string, existing *vaa.VAA) {
+	p.logger.Info("observed confirmed message publication in observer mode, not signing",
+		zap.String("message_id", v.MessageID()),
+		zap.String("digest", digest))
+
+	networkDigest := ""
+	if existing != nil {
+		networkDigest = hex.EncodeToString(existing.SigningMsg().Bytes())
+	}
+	p.updateObserverEntry(v.EmitterChain, v.MessageID(), digest, networkDigest)
+}
+
+// handleObserverQuorum records the digest of a quorum VAA received from the network.
+func (p *Processor) handleObserverQuorum(v *vaa.VAA, digest string) {
+	p.updateObserverEntry(v.EmitterChain, v.MessageID(), "", digest)
+}
+
+func (p *Processor) updateObserverEntry(emitterChain vaa.ChainID, messageID string, ourDigest string, networkDigest string) {
+	e, ok := p.observer[messageID]
+	if !ok {
+		e = &observerEntry{firstSeen: time.Now(), emitterChain: emitterChain}
+		p.observer[messageID] = e
+	}
+	if ourDigest != "" {
+		e.ourDigest = ourDigest
+	}
+	if networkDigest != "" {
+		e.networkDigest = networkDigest
+	}
+
+	if e.compared || e.ourDigest == "" || e.networkDigest == "" {
+		return
+	}
+	e.compared = true
+
+	if e.ourDigest == e.networkDigest {
+		p.logger.Info("observer mode: our observation matches the network quorum",
+			zap.String("message_id", messageID),
+			zap.String("digest", e.ourDigest))
+		observerDigestsTotal.WithLabelValues(emitterChain.String(), "match").Inc()
+	} else {
+		p.logger.Error("observer mode: our observation diverges from the network quorum",
+			zap.String("message_id", messageID),
+			zap.String("our_digest", e.ourDigest),
+			zap.String("network_digest", e.networkDigest))
+		observerDigestsTotal.WithLabelValues(emitterChain.String(), "mismatch").Inc()
+	}
+}
+
+// cleanupObserverState expires observer entries after the settlement time, counting the messages for which only one
+// side was seen.
+func (p *Processor) cleanupObserverState() {
+	for messageID, e := range p.observer {
+		if time.Since(e.firstSeen) <= observerSettlementTime {
+			continue
+		}
+
+		switch {
+		case e.compared:
+		case e.ourDigest == "":
+			p.logger.Warn("observer mode: the network reached quorum on a message we did not observe",
+				zap.String("message_id", messageID),
+				zap.String("network_digest", e.networkDigest))
+			observerDigestsTotal.WithLabelValues(e.emitterChain.String(), "missed").Inc()
+		default:
+			p.logger.Warn("observer mode: the network did not reach quorum on a message we observed",
+				zap.String("message_id", messageID),
+				zap.String("our_digest", e.ourDigest))
+			observerDigestsTotal.WithLabelValues(e.emitterChain.String(), "unconfirmed").Inc()
+		}
+
+		delete(p.observer, messageID)
+	}
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestObserverMode(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.InfoLevel)
+	processor := Processor{
+		logger:       zap.New(observedZapCore),
+		observerMode: true,
+		observer:     map[string]*observerEntry{},
+	}
+
+	matching := getVAA()
+	digest := hex.EncodeToString(matching.SigningMsg().Bytes())
+
+	// Our observation first, then the network quorum.
+	processor.handleObserverMessage(&VAA{VAA: matching}, digest, nil)
+	require.False(t, processor.observer[matching.MessageID()].compared)
+	processor.handleObserverQuorum(&matching, digest)
+	assert.True(t, processor.observer[matching.MessageID()].compared)
+	assert.Equal(t, "observer mode: our observation matches the network quorum", observedLogs.All()[1].Message)
+
+	// A quorum VAA stored before our observation, with a different payload.
+	diverging := getVAA()
+	diverging.Sequence = 2
+	stored := diverging
+	stored.Payload = []byte{1}
+	processor.handleObserverMessage(&VAA{VAA: diverging}, hex.EncodeToString(diverging.SigningMsg().Bytes()), &stored)
+	assert.True(t, processor.observer[diverging.MessageID()].compared)
+	assert.Equal(t, "observer mode: our observation diverges from the network quorum", observedLogs.All()[3].Message)
+
+	// A quorum VAA we never observe, and an observation that never reaches quorum.
+	missed := getVAA()
+	missed.Sequence = 3
+	processor.handleObserverQuorum(&missed, hex.EncodeToString(missed.SigningMsg().Bytes()))
+	unconfirmed := getVAA()
+	unconfirmed.Sequence = 4
+	processor.handleObserverMessage(&VAA{VAA: unconfirmed}, hex.EncodeToString(unconfirmed.SigningMsg().Bytes()), nil)
+
+	// Nothing expires before the settlement time.
+	processor.cleanupObserverState()
+	assert.Equal(t, 4, len(processor.observer))
+
+	for _, e := range processor.observer {
+		e.firstSeen = time.Now().Add(-observerSettlementTime - time.Second)
+	}
+	observedLogs.TakeAll()
+	processor.cleanupObserverState()
+	assert.Equal(t, 0, len(processor.observer))
+
+	messages := map[string]string{}
+	for _, entry := range observedLogs.All() {
+		messages[entry.ContextMap()["message_id"].(string)] = entry.Message
+	}
+	assert.Equal(t, map[string]string{
+		missed.MessageID():      "observer mode: the network reached quorum on a message we did not observe",
+		unconfirmed.MessageID(): "observer mode: the network did not reach quorum on a message we observed",
+	}, messages)
+}
+
+func TestObserverModeDropsInjections(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.InfoLevel)
+	processor := Processor{
+		logger:       zap.New(observedZapCore),
+		observerMode: true,
+	}
+
+	v := vaa.CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, []byte{1})
+	processor.handleInjection(context.Background(), v)
+
+	require.Equal(t, 1, observedLogs.Len())
+	assert.Equal(t, "dropping injected VAA since the node is in observer mode", observedLogs.All()[0].Message)
+}
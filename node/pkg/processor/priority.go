@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// The processor handles the messages of all chains and the observations of all guardians in a single goroutine. So that
+// governance messages never wait behind a backlog of token transfers, it reads its message and observation channels
+// ahead of processing. Governance messages and observations are handled as soon as they are read, while the others are
+// queued and handled one at a time, reading the channels again in between. Once read, a governance message waits for
+// at most one other message or observation to be handled.
+
+// maxQueued is the maximum number of messages and observations read ahead. Once reached, the processor stops reading
+// its channels until the queue shrinks, which blocks their senders as if it did not read ahead.
+const maxQueued = 1000
+
+// Lanes of the messages and observations handled by the processor.
+const (
+	laneGovernance = "governance"
+	laneDefault    = "default"
+)
+
+var (
+	processorQueued = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wormhole_processor_queued",
+			Help: "Number of messages and observations read ahead by the processor and waiting to be handled",
+		})
+	processorHandledTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_processor_handled_total",
+			Help: "Total number of messages and observations handled by the processor, by lane and kind",
+		}, []string{"lane", "kind"})
+	processorQueueLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "wormhole_processor_queue_latency_seconds",
+			Help:    "Time between reading a message or observation from its channel and handling it, by lane",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30},
+		}, []string{"lane"})
+)
+
+// governanceMessageIDPrefix is the prefix of the IDs of the messages of the governance emitter.
+var governanceMessageIDPrefix = fmt.Sprintf("%d/%s/", uint16(vaa.GovernanceChain), vaa.GovernanceEmitter)
+
+// queuedItem is a message or an observation waiting to be handled.
+type queuedItem struct {
+	received time.Time
+	msg      *common.MessagePublication
+	obsv     *gossipv1.SignedObservation
+}
+
+func isGovernanceMessage(k *common.MessagePublication) bool {
+	return k.EmitterChain == vaa.GovernanceChain && k.EmitterAddress == vaa.GovernanceEmitter
+}
+
+// isGovernanceObservation returns whether an observation is of a governance message. The message ID is not covered by
+// the signature, so a guardian can move its other observations to the governance lane, which only changes the order in
+// which they are handled.
+func isGovernanceObservation(m *gossipv1.SignedObservation) bool {
+	return strings.HasPrefix(m.MessageId, governanceMessageIDPrefix)
+}
+
+// receive handles a governance message or observation, or queues any other one.
+func (p *Processor) receive(ctx context.Context, item queuedItem) {
+	if (item.msg != nil && isGovernanceMessage(item.msg)) || (item.obsv != nil && isGovernanceObservation(item.obsv)) {
+		p.handleQueued(ctx, item, laneGovernance)
+		return
+	}
+	p.queue = append(p.queue, item)
+	processorQueued.Set(float64(len(p.queue)))
+}
+
+// readAhead reads the messages and observations waiting in the channels without blocking.
+func (p *Processor) readAhead(ctx context.Context) {
+	for len(p.queue) < maxQueued {
+		select {
+		case k := <-p.lockC:
+			p.receive(ctx, queuedItem{received: time.Now(), msg: k})
+		case m := <-p.obsvC:
+			p.receive(ctx, queuedItem{received: time.Now(), obsv: m})
+		default:
+			return
+		}
+	}
+}
+
+// handleNextQueued handles the oldest queued message or observation.
+func (p *Processor) handleNextQueued(ctx context.Context) {
+	item := p.queue[0]
+	p.queue[0] = queuedItem{}
+	p.queue = p.queue[1:]
+	processorQueued.Set(float64(len(p.queue)))
+	p.handleQueued(ctx, item, laneDefault)
+}
+
+func (p *Processor) handleQueued(ctx context.Context, item queuedItem, lane string) {
+	processorQueueLatency.WithLabelValues(lane).Observe(time.Since(item.received).Seconds())
+	if item.msg != nil {
+		processorHandledTotal.WithLabelValues(lane, "message").Inc()
+		p.processMessage(ctx, item.msg)
+	} else {
+		processorHandledTotal.WithLabelValues(lane, "observation").Inc()
+		p.handleObservation(ctx, item.obsv)
+	}
+}
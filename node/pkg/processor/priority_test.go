@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGovernanceLane(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	// Draining, messages are dropped as soon as they are handled, which is logged.
+	drain := common.NewDrain()
+	drain.Start()
+	lockC := make(chan *common.MessagePublication, 10)
+	obsvC := make(chan *gossipv1.SignedObservation, 10)
+	p := &Processor{
+		logger: zap.New(core),
+		lockC:  lockC,
+		obsvC:  obsvC,
+		drain:  drain,
+	}
+
+	transfer := func(sequence uint64) string {
+		return fmt.Sprintf("%d/%s/%d", vaa.ChainIDEthereum, vaa.Address{1}, sequence)
+	}
+	governance := fmt.Sprintf("%d/%s/%d", vaa.GovernanceChain, vaa.GovernanceEmitter, 7)
+
+	for i := uint64(0); i < 3; i++ {
+		obsvC <- &gossipv1.SignedObservation{MessageId: transfer(i)}
+	}
+	lockC <- &common.MessagePublication{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: vaa.Address{1}, Sequence: 3}
+	obsvC <- &gossipv1.SignedObservation{MessageId: governance}
+	lockC <- &common.MessagePublication{EmitterChain: vaa.GovernanceChain, EmitterAddress: vaa.GovernanceEmitter, Sequence: 8}
+
+	// The governance observation and message are handled as soon as they are read, the others are queued.
+	p.readAhead(context.Background())
+	require.Equal(t, 4, len(p.queue))
+	handled := func() []string {
+		var ids []string
+		for _, e := range logs.All() {
+			if id, ok := e.ContextMap()["message_id"]; ok && (e.Message == "received observation" || e.Message == "draining: dropping message") {
+				ids = append(ids, id.(string))
+			}
+		}
+		return ids
+	}
+	ids := handled()
+	require.Equal(t, 2, len(ids))
+	assert.ElementsMatch(t, []string{governance, fmt.Sprintf("%d/%s/%d", vaa.GovernanceChain, vaa.GovernanceEmitter, 8)}, ids)
+
+	// The others are handled in the order they were read. The message and the observations are read in any order,
+	// but the observations keep theirs.
+	for len(p.queue) != 0 {
+		p.handleNextQueued(context.Background())
+	}
+	ids = handled()[2:]
+	require.Equal(t, 4, len(ids))
+	assert.Contains(t, ids, transfer(3))
+	var observations []string
+	for _, id := range ids {
+		if id != transfer(3) {
+			observations = append(observations, id)
+		}
+	}
+	assert.Equal(t, []string{transfer(0), transfer(1), transfer(2)}, observations)
+
+	// Nothing is read ahead once the queue is full.
+	p.queue = make([]queuedItem, maxQueued)
+	obsvC <- &gossipv1.SignedObservation{MessageId: governance}
+	p.readAhead(context.Background())
+	assert.Equal(t, 1, len(p.obsvC))
+}
@@ -2,16 +2,18 @@ package processor
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"time"
 
 	"github.com/certusone/wormhole/node/pkg/notify/discord"
 
+	"github.com/certusone/wormhole/node/pkg/accountant"
 	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/eventbus"
 	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/guardiansigner"
+	"github.com/certusone/wormhole/node/pkg/networkconfig"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"go.uber.org/zap"
 
 	"github.com/certusone/wormhole/node/pkg/common"
@@ -70,33 +72,40 @@ type (
 )
 
 type Processor struct {
-	// lockC is a channel of observed emitted messages
-	lockC chan *common.MessagePublication
+	// bus carries the observed messages, observations and signed VAAs between the node's components
+	bus *eventbus.Bus
+	// lockC is the subscription to the observed emitted messages
+	lockC <-chan *common.MessagePublication
 	// setC is a channel of guardian set updates
 	setC chan *common.GuardianSet
 
 	// sendC is a channel of outbound messages to broadcast on p2p
 	sendC chan []byte
-	// obsvC is a channel of inbound decoded observations from p2p
-	obsvC chan *gossipv1.SignedObservation
+	// obsvC is the subscription to the inbound decoded observations from p2p, and our own
+	obsvC <-chan *gossipv1.SignedObservation
 
 	// obsvReqSendC is a send-only channel of outbound re-observation requests to broadcast on p2p
 	obsvReqSendC chan<- *gossipv1.ObservationRequest
 
-	// signedInC is a channel of inbound signed VAA observations from p2p
-	signedInC chan *gossipv1.SignedVAAWithQuorum
+	// signedInC is the subscription to the inbound signed VAA observations from p2p
+	signedInC <-chan *gossipv1.SignedVAAWithQuorum
 
 	// injectC is a channel of VAAs injected locally.
 	injectC chan *vaa.VAA
 
-	// gk is the node's guardian private key
-	gk *ecdsa.PrivateKey
+	// gk signs with the node's guardian key
+	gk guardiansigner.GuardianSigner
+	// nextGk is the optional key replacing gk in an upcoming guardian set, used once that set is active
+	nextGk guardiansigner.GuardianSigner
 
 	// devnetMode specified whether to submit transactions to the hardcoded Ethereum devnet
 	devnetMode         bool
 	devnetNumGuardians uint
 	devnetEthRPC       string
 
+	// observerMode disables signing. Our would-be observations are compared to the network's quorum VAAs instead.
+	observerMode bool
+
 	attestationEvents *reporter.AttestationEventReporter
 
 	logger *zap.Logger
@@ -117,44 +126,77 @@ type Processor struct {
 	ourAddr ethcommon.Address
 	// cleanup triggers periodic state cleanup
 	cleanup *time.Ticker
+	// observer is the observer mode view of messages, keyed by message ID
+	observer map[string]*observerEntry
+	// replay drops the observations replayed by peers, see replay.go
+	replay *observationReplayCache
 
 	notifier *discord.DiscordNotifier
 	governor *governor.ChainGovernor
+	acct     *accountant.Accountant
+	// signLimiter limits the number of messages signed per minute on each chain. Nil if disabled.
+	signLimiter *SigningRateLimiter
+	// breaker pauses signing on a chain when its messages look anomalous. Nil if disabled.
+	breaker *CircuitBreaker
+	// drain stops the processor from taking on new messages when the node shuts down gracefully. Nil if disabled.
+	drain *common.Drain
+	// queue holds the messages and observations read ahead, other than governance ones (see priority.go).
+	queue []queuedItem
+	// netConfig is the network configuration applied from governance VAAs. Nil if disabled.
+	netConfig *networkconfig.State
+	// netConfigBroadcast is when the network configuration was last gossiped.
+	netConfigBroadcast time.Time
+
+	// stuck receives our observations waiting for quorum at each cleanup. Nil if not reported.
+	stuck *StuckMessages
 }
 
 func NewProcessor(
 	ctx context.Context,
 	db *db.Database,
-	lockC chan *common.MessagePublication,
+	bus *eventbus.Bus,
 	setC chan *common.GuardianSet,
 	sendC chan []byte,
-	obsvC chan *gossipv1.SignedObservation,
 	obsvReqSendC chan<- *gossipv1.ObservationRequest,
 	injectC chan *vaa.VAA,
-	signedInC chan *gossipv1.SignedVAAWithQuorum,
-	gk *ecdsa.PrivateKey,
+	gk guardiansigner.GuardianSigner,
+	nextGk guardiansigner.GuardianSigner,
 	gst *common.GuardianSetState,
 	devnetMode bool,
 	devnetNumGuardians uint,
 	devnetEthRPC string,
+	observerMode bool,
 	attestationEvents *reporter.AttestationEventReporter,
 	notifier *discord.DiscordNotifier,
 	g *governor.ChainGovernor,
+	acct *accountant.Accountant,
+	signLimiter *SigningRateLimiter,
+	breaker *CircuitBreaker,
+	drain *common.Drain,
+	netConfig *networkconfig.State,
+	stuck *StuckMessages,
 ) *Processor {
 
+	// The processor is on the critical path, so it receives the events before the other subscribers, and holds back
+	// the publishers while it is busy.
+	opts := eventbus.Options{Priority: eventbus.PriorityHigh}
+
 	return &Processor{
-		lockC:              lockC,
+		bus:                bus,
+		lockC:              bus.MessagePublications().Subscribe("processor", opts),
 		setC:               setC,
 		sendC:              sendC,
-		obsvC:              obsvC,
+		obsvC:              bus.SignedObservations().Subscribe("processor", opts),
 		obsvReqSendC:       obsvReqSendC,
-		signedInC:          signedInC,
+		signedInC:          bus.SignedVAAs().Subscribe("processor", opts),
 		injectC:            injectC,
 		gk:                 gk,
+		nextGk:             nextGk,
 		gst:                gst,
 		devnetMode:         devnetMode,
 		devnetNumGuardians: devnetNumGuardians,
 		devnetEthRPC:       devnetEthRPC,
+		observerMode:       observerMode,
 		db:                 db,
 
 		attestationEvents: attestationEvents,
@@ -163,8 +205,17 @@ func NewProcessor(
 
 		logger:   supervisor.Logger(ctx),
 		state:    &aggregationState{observationMap{}},
-		ourAddr:  crypto.PubkeyToAddress(gk.PublicKey),
+		observer: map[string]*observerEntry{},
+		replay:   newObservationReplayCache(),
+		ourAddr:  guardiansigner.Address(gk),
 		governor: g,
+		acct:     acct,
+
+		signLimiter: signLimiter,
+		breaker:     breaker,
+		drain:       drain,
+		netConfig:   netConfig,
+		stuck:       stuck,
 	}
 }
 
@@ -174,38 +225,91 @@ func (p *Processor) Run(ctx context.Context) error {
 	// Always initialize the timer so don't have a nil pointer in the case below. It won't get rearmed after that.
 	govTimer := time.NewTimer(time.Minute)
 
+	// While draining, new messages are dropped and the in-flight observations are checked every second.
+	var drainStarted <-chan struct{}
+	var drainTick <-chan time.Time
+	if p.drain != nil {
+		drainStarted = p.drain.Started()
+	}
+
+	// Always ready, to handle the queued messages and observations when nothing else is.
+	ready := make(chan struct{})
+	close(ready)
+
+	// Our observations lost in a crash are gossiped again once the guardian set is known.
+	journalReplayed := false
+
 	for {
+		p.readAhead(ctx)
+
+		lockC, obsvC := p.lockC, p.obsvC
+		if len(p.queue) >= maxQueued {
+			lockC, obsvC = nil, nil
+		}
+		var queued <-chan struct{}
+		if len(p.queue) != 0 {
+			queued = ready
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-drainStarted:
+			drainStarted = nil
+			p.logger.Info("draining: no longer accepting new messages")
+			if !p.checkDrained(time.Now()) {
+				ticker := time.NewTicker(time.Second)
+				defer ticker.Stop()
+				drainTick = ticker.C
+			}
+		case <-drainTick:
+			if p.checkDrained(time.Now()) {
+				drainTick = nil
+			}
 		case p.gs = <-p.setC:
 			p.logger.Info("guardian set updated",
 				zap.Strings("set", p.gs.KeysAsHexStrings()),
 				zap.Uint32("index", p.gs.Index))
 			p.gst.Set(p.gs)
-		case k := <-p.lockC:
-			if p.governor != nil {
-				if !p.governor.ProcessMsg(k) {
-					continue
-				}
+			if p.nextGk != nil {
+				p.logger.Info("selected guardian key for the guardian set",
+					zap.Stringer("address", guardiansigner.Address(p.signingKey())),
+					zap.Uint32("index", p.gs.Index))
+			}
+			if !journalReplayed {
+				journalReplayed = true
+				p.replayObservationJournal(ctx)
 			}
-			p.handleMessage(ctx, k)
+		case k := <-lockC:
+			p.receive(ctx, queuedItem{received: time.Now(), msg: k})
+		case <-queued:
+			p.handleNextQueued(ctx)
 		case v := <-p.injectC:
+			if p.draining() {
+				p.logger.Warn("draining: dropping injected governance VAA", zap.String("message_id", v.MessageID()))
+				continue
+			}
 			p.handleInjection(ctx, v)
-		case m := <-p.obsvC:
-			p.handleObservation(ctx, m)
+		case m := <-obsvC:
+			p.receive(ctx, queuedItem{received: time.Now(), obsv: m})
 		case m := <-p.signedInC:
 			p.handleInboundSignedVAAWithQuorum(ctx, m)
 		case <-p.cleanup.C:
 			p.handleCleanup(ctx)
 		case <-govTimer.C:
-			if p.governor != nil {
+			// Pending transfers are kept in the database, and released after the restart.
+			if p.governor != nil && !p.draining() {
 				toBePublished, err := p.governor.CheckPending()
 				if err != nil {
 					return err
 				}
 				if len(toBePublished) != 0 {
 					for _, k := range toBePublished {
+						if p.acct != nil {
+							if !p.acct.ProcessMsg(k) {
+								continue
+							}
+						}
 						p.handleMessage(ctx, k)
 					}
 				}
@@ -214,3 +318,68 @@ func (p *Processor) Run(ctx context.Context) error {
 		}
 	}
 }
+
+// processMessage handles a message observed by our watchers, unless the node is draining or the message is held back by
+// the signing rate limit, the circuit breaker, the governor or the accountant.
+func (p *Processor) processMessage(ctx context.Context, k *common.MessagePublication) {
+	if p.draining() {
+		p.logger.Info("draining: dropping message", zap.String("message_id", k.MessageIDString()))
+		return
+	}
+	if p.signLimiter != nil {
+		if !p.signLimiter.Allow(k, time.Now()) {
+			// Not logged at a higher level, since the point of the limit is to withstand a flood of messages.
+			p.logger.Debug("dropping message over the signing rate limit of its chain",
+				zap.Stringer("emitter_chain", k.EmitterChain),
+				zap.String("message_id", k.MessageIDString()))
+			return
+		}
+	}
+	// Governance messages are never held back by the circuit breaker, so the guardians can still act on a paused chain.
+	if p.breaker != nil && !isGovernanceMessage(k) {
+		allowed, trip := p.breaker.Check(k, time.Now())
+		if trip != nil {
+			p.circuitBreakerTripped(trip)
+		}
+		if !allowed {
+			p.logger.Debug("dropping message of a chain paused by the circuit breaker",
+				zap.Stringer("emitter_chain", k.EmitterChain),
+				zap.String("message_id", k.MessageIDString()))
+			return
+		}
+	}
+	if p.governor != nil {
+		if !p.governor.ProcessMsg(k) {
+			return
+		}
+	}
+	if p.acct != nil {
+		if !p.acct.ProcessMsg(k) {
+			return
+		}
+	}
+	p.handleMessage(ctx, k)
+}
+
+// circuitBreakerTripped pages the operator when the circuit breaker paused signing on a chain.
+func (p *Processor) circuitBreakerTripped(trip *CircuitBreakerTrip) {
+	p.logger.Error("circuit breaker tripped, signing is paused on the chain until resumed with the admin command",
+		zap.Stringer("emitter_chain", trip.ChainID),
+		zap.String("reason", trip.Reason),
+		zap.String("detail", trip.Detail),
+		zap.String("message_id", trip.MessageID))
+
+	if p.notifier != nil {
+		go func() {
+			if err := p.notifier.CircuitBreakerTripped(trip.ChainID, trip.Reason, trip.Detail, trip.MessageID); err != nil {
+				p.logger.Error("failed to send notification", zap.Error(err))
+			}
+		}()
+	}
+}
+
+// signingKey returns the guardian key to sign with: the next key once it is a member of the current guardian set,
+// and the current key otherwise.
+func (p *Processor) signingKey() guardiansigner.GuardianSigner {
+	return p.gs.SigningKey(p.gk, p.nextGk)
+}
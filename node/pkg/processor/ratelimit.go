@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var (
+	messagesRateLimitedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_message_observations_rate_limited_total",
+			Help: "Total number of messages dropped without signing because their chain exceeded its signing rate limit",
+		},
+		[]string{"emitter_chain"})
+
+	signingRatePerMinute = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_message_signing_rate_per_minute",
+			Help: "Number of messages accepted for signing in the last full minute",
+		},
+		[]string{"emitter_chain"})
+)
+
+// SigningRateLimits configures a SigningRateLimiter.
+type SigningRateLimits struct {
+	// Maximum number of messages signed per minute on chains without an override. Zero means unlimited.
+	DefaultPerMinute uint64
+	// Per-chain overrides of the default. Zero means unlimited.
+	PerMinute map[vaa.ChainID]uint64
+	// Emitters whose messages are neither limited nor counted against the limit of their chain, such as the token and
+	// NFT bridges, so an integrator spamming messages cannot delay them. The governance emitter is always bypassed.
+	Bypass []common.EmitterInfo
+}
+
+type bypassKey struct {
+	chainID vaa.ChainID
+	emitter vaa.Address
+}
+
+// chainRate tracks the signing rate of a chain.
+type chainRate struct {
+	// Nil if the chain is unlimited.
+	limiter  *rate.Limiter
+	capacity uint64
+
+	// Messages accepted in the current minute, and in the previous one.
+	windowStart time.Time
+	window      uint64
+	lastMinute  uint64
+
+	dropped     uint64
+	lastDropped time.Time
+}
+
+// SigningRateStatus is the signing rate of a chain, as reported by the admin RPC.
+type SigningRateStatus struct {
+	ChainID vaa.ChainID
+	// Zero means unlimited.
+	LimitPerMinute uint64
+	// Messages accepted for signing in the last full minute, including bypassed emitters.
+	LastMinute  uint64
+	Dropped     uint64
+	LastDropped time.Time
+}
+
+// SigningRateLimiter limits the number of messages signed per minute on each chain. Messages over the limit are dropped
+// before they are signed, and can be reobserved once the rate has come down.
+//
+// The limit is a token bucket that holds a minute worth of messages, so a chain can briefly burst up to its limit
+// before it is throttled to the sustained rate.
+type SigningRateLimiter struct {
+	mutex  sync.Mutex
+	limits SigningRateLimits
+	bypass map[bypassKey]bool
+	chains map[vaa.ChainID]*chainRate
+}
+
+// NewSigningRateLimiter creates a rate limiter. It returns an error if a bypass emitter is invalid.
+func NewSigningRateLimiter(limits SigningRateLimits) (*SigningRateLimiter, error) {
+	l := &SigningRateLimiter{
+		limits: limits,
+		bypass: make(map[bypassKey]bool),
+		chains: make(map[vaa.ChainID]*chainRate),
+	}
+
+	// Governance messages are never dropped, so the guardians can still act on a chain flooded with messages.
+	l.bypass[bypassKey{vaa.GovernanceChain, vaa.GovernanceEmitter}] = true
+	for _, e := range limits.Bypass {
+		b, err := hex.DecodeString(e.Emitter)
+		if err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("invalid bypass emitter %v:%s (expected 32 bytes hex)", e.ChainID, e.Emitter)
+		}
+		var addr vaa.Address
+		copy(addr[:], b)
+		l.bypass[bypassKey{e.ChainID, addr}] = true
+	}
+
+	return l, nil
+}
+
+func (l *SigningRateLimiter) limit(chainID vaa.ChainID) uint64 {
+	if n, ok := l.limits.PerMinute[chainID]; ok {
+		return n
+	}
+	return l.limits.DefaultPerMinute
+}
+
+// chain returns the rate of a chain. It must be called with the mutex held.
+func (l *SigningRateLimiter) chain(chainID vaa.ChainID, now time.Time) *chainRate {
+	c, ok := l.chains[chainID]
+	if !ok {
+		c = &chainRate{capacity: l.limit(chainID), windowStart: now}
+		if c.capacity != 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(float64(c.capacity)/60), int(c.capacity))
+		}
+		l.chains[chainID] = c
+	}
+	c.roll(chainID, now)
+	return c
+}
+
+// roll starts a new minute window once the current one is over.
+func (c *chainRate) roll(chainID vaa.ChainID, now time.Time) {
+	elapsed := now.Sub(c.windowStart)
+	if elapsed < time.Minute {
+		return
+	}
+
+	if elapsed < 2*time.Minute {
+		c.lastMinute = c.window
+	} else {
+		c.lastMinute = 0
+	}
+	c.window = 0
+	c.windowStart = c.windowStart.Add(elapsed.Truncate(time.Minute))
+	signingRatePerMinute.WithLabelValues(chainID.String()).Set(float64(c.lastMinute))
+}
+
+// Allow returns whether a message may be signed, and counts it against the limit of its chain.
+func (l *SigningRateLimiter) Allow(k *common.MessagePublication, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	c := l.chain(k.EmitterChain, now)
+	if c.limiter != nil && !l.bypass[bypassKey{k.EmitterChain, k.EmitterAddress}] && !c.limiter.AllowN(now, 1) {
+		c.dropped++
+		c.lastDropped = now
+		messagesRateLimitedTotal.WithLabelValues(k.EmitterChain.String()).Inc()
+		return false
+	}
+
+	c.window++
+	return true
+}
+
+// UpdateMetrics rolls the windows of all chains, so the rate of chains that stopped sending messages drops to zero.
+func (l *SigningRateLimiter) UpdateMetrics(now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for chainID, c := range l.chains {
+		c.roll(chainID, now)
+	}
+}
+
+// Status returns the signing rate of the chains that have sent messages, ordered by chain ID.
+func (l *SigningRateLimiter) Status(now time.Time) []SigningRateStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	out := make([]SigningRateStatus, 0, len(l.chains))
+	for chainID := range l.chains {
+		c := l.chain(chainID, now)
+		out = append(out, SigningRateStatus{
+			ChainID:        chainID,
+			LimitPerMinute: c.capacity,
+			LastMinute:     c.lastMinute,
+			Dropped:        c.dropped,
+			LastDropped:    c.lastDropped,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ChainID < out[j].ChainID })
+	return out
+}
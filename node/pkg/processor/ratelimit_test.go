@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rateLimitedMessage(chainID vaa.ChainID, emitter vaa.Address) *common.MessagePublication {
+	return &common.MessagePublication{EmitterChain: chainID, EmitterAddress: emitter}
+}
+
+func TestSigningRateLimiter(t *testing.T) {
+	tokenBridge := vaa.Address{31: 2}
+	l, err := NewSigningRateLimiter(SigningRateLimits{
+		DefaultPerMinute: 3,
+		PerMinute:        map[vaa.ChainID]uint64{vaa.ChainIDSolana: 0},
+		Bypass:           []common.EmitterInfo{{ChainID: vaa.ChainIDBSC, Emitter: tokenBridge.String()}},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1_700_000_000, 0)
+	spam := vaa.Address{31: 1}
+
+	// A chain can burst up to its limit.
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow(rateLimitedMessage(vaa.ChainIDBSC, spam), start))
+	}
+	assert.False(t, l.Allow(rateLimitedMessage(vaa.ChainIDBSC, spam), start))
+	assert.Equal(t, float64(1), testutil.ToFloat64(messagesRateLimitedTotal.WithLabelValues("bsc")))
+
+	// Bypassed emitters are not limited.
+	assert.True(t, l.Allow(rateLimitedMessage(vaa.ChainIDBSC, tokenBridge), start))
+
+	// Chains with a zero limit are unlimited.
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Allow(rateLimitedMessage(vaa.ChainIDSolana, spam), start))
+	}
+
+	// The limit refills at the sustained rate of one message per 20 seconds.
+	assert.True(t, l.Allow(rateLimitedMessage(vaa.ChainIDBSC, spam), start.Add(20*time.Second)))
+	assert.False(t, l.Allow(rateLimitedMessage(vaa.ChainIDBSC, spam), start.Add(21*time.Second)))
+
+	status := l.Status(start.Add(time.Minute))
+	require.Equal(t, 2, len(status))
+	assert.Equal(t, SigningRateStatus{ChainID: vaa.ChainIDSolana, LastMinute: 10}, status[0])
+	assert.Equal(t, SigningRateStatus{
+		ChainID:        vaa.ChainIDBSC,
+		LimitPerMinute: 3,
+		LastMinute:     5,
+		Dropped:        2,
+		LastDropped:    start.Add(21 * time.Second),
+	}, status[1])
+	assert.Equal(t, float64(5), testutil.ToFloat64(signingRatePerMinute.WithLabelValues("bsc")))
+
+	// The rate drops to zero once a chain stops sending messages.
+	l.UpdateMetrics(start.Add(3 * time.Minute))
+	assert.Equal(t, float64(0), testutil.ToFloat64(signingRatePerMinute.WithLabelValues("bsc")))
+}
+
+func TestSigningRateLimiterNeverDropsGovernanceMessages(t *testing.T) {
+	l, err := NewSigningRateLimiter(SigningRateLimits{DefaultPerMinute: 1})
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	assert.True(t, l.Allow(rateLimitedMessage(vaa.GovernanceChain, vaa.Address{31: 1}), now))
+	assert.False(t, l.Allow(rateLimitedMessage(vaa.GovernanceChain, vaa.Address{31: 1}), now))
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Allow(rateLimitedMessage(vaa.GovernanceChain, vaa.GovernanceEmitter), now))
+	}
+}
+
+func TestSigningRateLimiterInvalidBypass(t *testing.T) {
+	_, err := NewSigningRateLimiter(SigningRateLimits{Bypass: []common.EmitterInfo{{ChainID: vaa.ChainIDBSC, Emitter: "0102"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"bytes"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Signed observations carry no timestamp, so a peer can rebroadcast old ones at will: either to make every guardian
+// recover their signatures again, or to recreate the aggregation state of messages that were submitted and expired.
+// The replay cache drops both before the signature is verified:
+//
+//   - Per guardian and digest, the signatures verified recently. The same signature received again within
+//     observationReplayInterval is a replay. The interval is shorter than the retransmissions of the cleanup service, so
+//     the observations that honest guardians rebroadcast still go through. Entries are only added once the signature
+//     has been verified and its guardian is part of the guardian set, so a forged observation cannot shadow a valid one.
+//   - Per digest, the aggregation states that expired after their VAA was submitted. Observations of these digests
+//     are dropped unless we have an aggregation state for them again, since they would only recreate one that expires
+//     again.
+
+var (
+	observationsReplayedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_observations_replayed_total",
+			Help: "Total number of replayed observations dropped before verification, grouped by reason",
+		}, []string{"reason"})
+)
+
+const (
+	// observationReplayInterval is how long a verified observation is remembered. It must stay shorter than the
+	// interval of the cleanup service, at which unsubmitted observations are retransmitted.
+	observationReplayInterval = 20 * time.Second
+	// observationReplayMaxPerGuardian bounds the observations remembered for each guardian.
+	observationReplayMaxPerGuardian = 10000
+
+	// expiredDigestRetention is how long the digests of expired aggregation states are remembered.
+	expiredDigestRetention = 24 * time.Hour
+	// expiredDigestMax bounds the digests of expired aggregation states remembered.
+	expiredDigestMax = 200000
+)
+
+type (
+	// observationReplayCache remembers the observations the processor is done with. A nil cache drops nothing.
+	observationReplayCache struct {
+		// Signatures verified within the replay interval, by guardian and digest.
+		verified map[ethcommon.Address]map[string]verifiedObservation
+		// Digests of the aggregation states which expired after submission, with their expiry time.
+		expiredDigests map[string]time.Time
+	}
+
+	verifiedObservation struct {
+		signature []byte
+		at        time.Time
+	}
+)
+
+func newObservationReplayCache() *observationReplayCache {
+	return &observationReplayCache{
+		verified:       make(map[ethcommon.Address]map[string]verifiedObservation),
+		expiredDigests: make(map[string]time.Time),
+	}
+}
+
+// duplicate returns true if the same signature of the guardian was verified within the replay interval.
+func (c *observationReplayCache) duplicate(hash string, addr ethcommon.Address, signature []byte, now time.Time) bool {
+	if c == nil {
+		return false
+	}
+	o, exists := c.verified[addr][hash]
+	return exists && now.Sub(o.at) < observationReplayInterval && bytes.Equal(o.signature, signature)
+}
+
+// expired returns true if the aggregation state of the digest expired after submission.
+func (c *observationReplayCache) expired(hash string) bool {
+	if c == nil {
+		return false
+	}
+	_, exists := c.expiredDigests[hash]
+	return exists
+}
+
+// addVerified remembers an observation whose signature was verified to be that of a guardian of the guardian set.
+func (c *observationReplayCache) addVerified(hash string, addr ethcommon.Address, signature []byte, now time.Time) {
+	if c == nil {
+		return
+	}
+	m, exists := c.verified[addr]
+	if !exists {
+		m = make(map[string]verifiedObservation)
+		c.verified[addr] = m
+	}
+	if _, exists := m[hash]; !exists && len(m) >= observationReplayMaxPerGuardian {
+		return
+	}
+	m[hash] = verifiedObservation{signature: signature, at: now}
+}
+
+// addExpired remembers the digest of an aggregation state which expired after its VAA was submitted.
+func (c *observationReplayCache) addExpired(hash string, now time.Time) {
+	if c == nil || len(c.expiredDigests) >= expiredDigestMax {
+		return
+	}
+	c.expiredDigests[hash] = now
+}
+
+// prune forgets the observations past the replay interval and the digests past their retention, and is called by the
+// cleanup service.
+func (c *observationReplayCache) prune(now time.Time) {
+	if c == nil {
+		return
+	}
+	for addr, m := range c.verified {
+		for hash, o := range m {
+			if now.Sub(o.at) >= observationReplayInterval {
+				delete(m, hash)
+			}
+		}
+		if len(m) == 0 {
+			delete(c.verified, addr)
+		}
+	}
+	for hash, t := range c.expiredDigests {
+		if now.Sub(t) >= expiredDigestRetention {
+			delete(c.expiredDigests, hash)
+		}
+	}
+}
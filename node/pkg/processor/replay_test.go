@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestObservationReplayCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	guardian := ethcommon.HexToAddress("0xbeFA429d57cD18b7F8A4d91A2da9AB4AF05d0FBe")
+	sig := []byte{1, 2, 3}
+
+	c := newObservationReplayCache()
+	assert.False(t, c.duplicate("aa", guardian, sig, now))
+	c.addVerified("aa", guardian, sig, now)
+
+	// Only the same signature of the same guardian is a replay, until the replay interval passed.
+	assert.True(t, c.duplicate("aa", guardian, sig, now.Add(time.Second)))
+	assert.False(t, c.duplicate("aa", guardian, []byte{4, 5, 6}, now.Add(time.Second)))
+	assert.False(t, c.duplicate("bb", guardian, sig, now.Add(time.Second)))
+	assert.False(t, c.duplicate("aa", guardian, sig, now.Add(observationReplayInterval)))
+
+	c.prune(now.Add(observationReplayInterval))
+	assert.Empty(t, c.verified)
+
+	c.addExpired("cc", now)
+	assert.True(t, c.expired("cc"))
+	c.prune(now.Add(time.Hour))
+	assert.True(t, c.expired("cc"))
+	c.prune(now.Add(expiredDigestRetention))
+	assert.False(t, c.expired("cc"))
+
+	// A nil cache drops nothing.
+	var nilCache *observationReplayCache
+	nilCache.addVerified("aa", guardian, sig, now)
+	assert.False(t, nilCache.duplicate("aa", guardian, sig, now))
+	assert.False(t, nilCache.expired("aa"))
+}
+
+func TestHandleObservationDropsReplays(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	p := &Processor{
+		logger: zap.NewNop(),
+		state:  &aggregationState{observationMap{}},
+		gs:     &common.GuardianSet{Keys: []ethcommon.Address{addr, crypto.PubkeyToAddress(other.PublicKey)}},
+		replay: newObservationReplayCache(),
+	}
+
+	digest := crypto.Keccak256([]byte("replayed"))
+	sig, err := crypto.Sign(digest, key)
+	require.NoError(t, err)
+	m := &gossipv1.SignedObservation{Addr: addr.Bytes(), Hash: digest, Signature: sig}
+
+	before := testutil.ToFloat64(observationsReplayedTotal.WithLabelValues("duplicate"))
+	p.handleObservation(context.Background(), m)
+	p.handleObservation(context.Background(), m)
+	assert.Equal(t, before+1, testutil.ToFloat64(observationsReplayedTotal.WithLabelValues("duplicate")))
+	assert.Contains(t, p.state.signatures[hex.EncodeToString(digest)].signatures, addr)
+
+	// A forged observation does not shadow the valid observation of another guardian.
+	forged := &gossipv1.SignedObservation{Addr: crypto.PubkeyToAddress(other.PublicKey).Bytes(), Hash: digest, Signature: sig}
+	p.handleObservation(context.Background(), forged)
+	otherSig, err := crypto.Sign(digest, other)
+	require.NoError(t, err)
+	p.handleObservation(context.Background(), &gossipv1.SignedObservation{Addr: crypto.PubkeyToAddress(other.PublicKey).Bytes(), Hash: digest, Signature: otherSig})
+	assert.Len(t, p.state.signatures[hex.EncodeToString(digest)].signatures, 2)
+
+	// Once the aggregation state expired after submission, the observation is not processed again.
+	delete(p.state.signatures, hex.EncodeToString(digest))
+	p.replay.addExpired(hex.EncodeToString(digest), time.Now())
+	p.replay.prune(time.Now().Add(observationReplayInterval))
+	before = testutil.ToFloat64(observationsReplayedTotal.WithLabelValues("expired"))
+	p.handleObservation(context.Background(), m)
+	assert.Equal(t, before+1, testutil.ToFloat64(observationsReplayedTotal.WithLabelValues("expired")))
+	assert.Nil(t, p.state.signatures[hex.EncodeToString(digest)])
+}
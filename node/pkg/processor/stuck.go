@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// StuckMessage is a message signed by this node which hasn't reached quorum yet.
+type StuckMessage struct {
+	MessageID string
+	// Hex-encoded digest of the observation.
+	Digest        string
+	EmitterChain  vaa.ChainID
+	TxHash        []byte
+	FirstObserved time.Time
+	// Number of times the observation was retransmitted.
+	Retries          uint
+	GuardianSetIndex uint32
+	// Guardians of the guardian set of the observation whose signature was received, this node included, and those
+	// whose signature is missing.
+	Signed  []ethcommon.Address
+	Missing []ethcommon.Address
+	Quorum  int
+}
+
+// StuckMessages is the processor's view of the messages waiting for quorum, for the admin RPC. The processor updates
+// it at each cleanup, so it is up to 30 seconds old.
+type StuckMessages struct {
+	mu       sync.Mutex
+	messages []StuckMessage
+	updated  time.Time
+}
+
+func NewStuckMessages() *StuckMessages {
+	return &StuckMessages{}
+}
+
+// List returns the messages waiting for quorum, oldest first, and when they were last updated.
+func (s *StuckMessages) List() ([]StuckMessage, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages, s.updated
+}
+
+func (s *StuckMessages) set(messages []StuckMessage, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = messages
+	s.updated = now
+}
+
+// reportStuckMessages updates p.stuck with our observations which haven't reached quorum.
+func (p *Processor) reportStuckMessages(now time.Time) {
+	if p.stuck == nil {
+		return
+	}
+
+	var messages []StuckMessage
+	for hash, s := range p.state.signatures {
+		if s.submitted || s.ourObservation == nil {
+			continue
+		}
+		gs := s.gs
+		if gs == nil {
+			gs = p.gs
+		}
+		if gs == nil {
+			continue
+		}
+
+		m := StuckMessage{
+			MessageID:        s.ourObservation.MessageID(),
+			Digest:           hash,
+			EmitterChain:     s.ourObservation.GetEmitterChain(),
+			TxHash:           s.txHash,
+			FirstObserved:    s.firstObserved,
+			Retries:          s.retryCount,
+			GuardianSetIndex: gs.Index,
+			Quorum:           vaa.CalculateQuorum(len(gs.Keys)),
+		}
+		for _, k := range gs.Keys {
+			if _, ok := s.signatures[k]; ok {
+				m.Signed = append(m.Signed, k)
+			} else {
+				m.Missing = append(m.Missing, k)
+			}
+		}
+		messages = append(messages, m)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].FirstObserved.Equal(messages[j].FirstObserved) {
+			return messages[i].FirstObserved.Before(messages[j].FirstObserved)
+		}
+		return messages[i].Digest < messages[j].Digest
+	})
+	p.stuck.set(messages, now)
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReportStuckMessages(t *testing.T) {
+	now := time.Now()
+	ours := &VAA{VAA: getVAA()}
+	gs := &common.GuardianSet{Keys: []ethcommon.Address{{1}, {2}, {3}, {4}}, Index: 1}
+	p := Processor{
+		logger: zap.NewNop(),
+		gs:     gs,
+		stuck:  NewStuckMessages(),
+		state: &aggregationState{signatures: observationMap{
+			"old": {firstObserved: now.Add(-time.Hour), ourObservation: ours, gs: gs, txHash: []byte{1}, retryCount: 3,
+				signatures: map[ethcommon.Address][]byte{{1}: {}, {3}: {}}},
+			"new": {firstObserved: now, ourObservation: ours, signatures: map[ethcommon.Address][]byte{{1}: {}}},
+			// Reached quorum.
+			"submitted": {firstObserved: now, ourObservation: ours, submitted: true},
+			// Only other guardians observed it.
+			"theirs": {firstObserved: now, signatures: map[ethcommon.Address][]byte{{2}: {}}},
+		}},
+	}
+
+	p.reportStuckMessages(now)
+	messages, updated := p.stuck.List()
+	assert.Equal(t, now, updated)
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, "old", messages[0].Digest)
+	assert.Equal(t, ours.MessageID(), messages[0].MessageID)
+	assert.Equal(t, uint(3), messages[0].Retries)
+	assert.Equal(t, []ethcommon.Address{{1}, {3}}, messages[0].Signed)
+	assert.Equal(t, []ethcommon.Address{{2}, {4}}, messages[0].Missing)
+	assert.Equal(t, 3, messages[0].Quorum)
+
+	// Without its own guardian set, the current one is used.
+	assert.Equal(t, "new", messages[1].Digest)
+	assert.Equal(t, uint32(1), messages[1].GuardianSetIndex)
+	assert.Len(t, messages[1].Missing, 3)
+}
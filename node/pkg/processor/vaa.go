@@ -2,8 +2,13 @@ package processor
 
 import (
 	"encoding/hex"
+	"fmt"
+	"time"
 
+	"github.com/certusone/wormhole/node/pkg/db"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -11,6 +16,23 @@ type VAA struct {
 	vaa.VAA
 }
 
+// hasSignedVAA returns whether a VAA of the message is stored which was signed by the guardian set index or a newer one.
+// A stored VAA of an older guardian set doesn't prevent the message from being signed again by the current set, as
+// requested by the purge-and-resign-vaa admin command, and is only replaced once the new VAA is stored.
+func (p *Processor) hasSignedVAA(id db.VAAID, guardianSetIndex uint32) (bool, error) {
+	b, err := p.db.GetSignedVAABytes(id)
+	if err == db.ErrVAANotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmarshal VAA from db: %w", err)
+	}
+	return v.GuardianSetIndex >= guardianSetIndex, nil
+}
+
 func (v *VAA) HandleQuorum(sigs []*vaa.Signature, hash string, p *Processor) {
 	// Deep copy the observation and add signatures
 	signed := &vaa.VAA{
@@ -37,9 +59,23 @@ func (v *VAA) HandleQuorum(sigs []*vaa.Signature, hash string, p *Processor) {
 		zap.String("bytes", hex.EncodeToString(vaaBytes)),
 		zap.String("message_id", signed.MessageID()))
 
+	// From the first observation of the message, ours or another guardian's, until quorum.
+	tracing.Record(signed.EmitterChain, signed.MessageID(), "processor.quorum", p.state.signatures[hash].firstObserved, time.Now(),
+		attribute.Int("signatures", len(sigs)))
+
+	span := tracing.Start(signed.EmitterChain, signed.MessageID(), "db.store")
 	if err := p.db.StoreSignedVAA(signed); err != nil {
 		p.logger.Error("failed to store signed VAA", zap.Error(err))
+		span.RecordError(err)
+	} else {
+		p.forgetObservation(hash)
+	}
+	span.End()
+
+	if p.acct != nil {
+		p.acct.ProcessGovernanceVAA(signed)
 	}
+	p.netConfig.ProcessGovernanceVAA(signed)
 
 	p.broadcastSignedVAA(signed)
 	p.attestationEvents.ReportVAAQuorum(signed)
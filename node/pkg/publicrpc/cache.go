@@ -0,0 +1,42 @@
+package publicrpc
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// How long the responses of the status endpoints are cached, so that exposing them publicly does not put load on
+	// the node.
+	guardianSetCacheTTL    = 10 * time.Second
+	governorStatusCacheTTL = 5 * time.Second
+)
+
+// responseCache caches a response for a fixed duration. Errors are not cached.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	resp    proto.Message
+	expires time.Time
+}
+
+// get returns the cached response, or the response of compute if it expired. The response must not be modified.
+func (c *responseCache) get(now time.Time, compute func() (proto.Message, error)) (proto.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resp != nil && now.Before(c.expires) {
+		return c.resp, nil
+	}
+
+	resp, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	c.resp = resp
+	c.expires = now.Add(c.ttl)
+	return resp, nil
+}
@@ -4,15 +4,22 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/db"
 	"github.com/certusone/wormhole/node/pkg/governor"
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
 	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // PublicrpcServer implements the publicrpc gRPC service.
@@ -22,6 +29,9 @@ type PublicrpcServer struct {
 	db     *db.Database
 	gst    *common.GuardianSetState
 	gov    *governor.ChainGovernor
+
+	guardianSetCache    responseCache
+	governorStatusCache responseCache
 }
 
 func NewPublicrpcServer(
@@ -35,6 +45,9 @@ func NewPublicrpcServer(
 		db:     db,
 		gst:    gst,
 		gov:    gov,
+
+		guardianSetCache:    responseCache{ttl: guardianSetCacheTTL},
+		governorStatusCache: responseCache{ttl: governorStatusCacheTTL},
 	}
 }
 
@@ -63,12 +76,41 @@ func (s *PublicrpcServer) GetLastHeartbeats(ctx context.Context, req *publicrpcv
 	return resp, nil
 }
 
-func (s *PublicrpcServer) GetSignedVAA(ctx context.Context, req *publicrpcv1.GetSignedVAARequest) (*publicrpcv1.GetSignedVAAResponse, error) {
-	if req.MessageId == nil {
+func (s *PublicrpcServer) GetGuardianHeartbeat(ctx context.Context, req *publicrpcv1.GetGuardianHeartbeatRequest) (*publicrpcv1.GetGuardianHeartbeatResponse, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(req.GuardianAddr, "0x"))
+	if err != nil || len(b) != ethcommon.AddressLength {
+		return nil, status.Error(codes.InvalidArgument, "guardian address must be 20 bytes hex-encoded")
+	}
+	addr := ethcommon.BytesToAddress(b)
+
+	heartbeats := s.gst.LastHeartbeat(addr)
+	if len(heartbeats) == 0 {
+		return nil, status.Error(codes.NotFound, "no heartbeat received from this guardian")
+	}
+
+	resp := &publicrpcv1.GetGuardianHeartbeatResponse{}
+	if gs := s.gst.Get(); gs != nil {
+		_, resp.InGuardianSet = gs.KeyIndex(addr)
+	}
+	for peerId, hb := range heartbeats {
+		resp.Entries = append(resp.Entries, &publicrpcv1.GetLastHeartbeatsResponse_Entry{
+			VerifiedGuardianAddr: addr.Hex(),
+			P2PNodeAddr:          peerId.Pretty(),
+			RawHeartbeat:         hb,
+		})
+	}
+	sort.Slice(resp.Entries, func(i, j int) bool { return resp.Entries[i].P2PNodeAddr < resp.Entries[j].P2PNodeAddr })
+
+	return resp, nil
+}
+
+// decodeMessageID validates a message ID of a request.
+func decodeMessageID(id *publicrpcv1.MessageID) (*db.VAAID, error) {
+	if id == nil {
 		return nil, status.Error(codes.InvalidArgument, "no message ID specified")
 	}
 
-	address, err := hex.DecodeString(req.MessageId.EmitterAddress)
+	address, err := hex.DecodeString(id.EmitterAddress)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode address: %v", err))
 	}
@@ -79,11 +121,20 @@ func (s *PublicrpcServer) GetSignedVAA(ctx context.Context, req *publicrpcv1.Get
 	addr := vaa.Address{}
 	copy(addr[:], address)
 
-	b, err := s.db.GetSignedVAABytes(db.VAAID{
-		EmitterChain:   vaa.ChainID(req.MessageId.EmitterChain.Number()),
+	return &db.VAAID{
+		EmitterChain:   vaa.ChainID(id.EmitterChain.Number()),
 		EmitterAddress: addr,
-		Sequence:       req.MessageId.Sequence,
-	})
+		Sequence:       id.Sequence,
+	}, nil
+}
+
+func (s *PublicrpcServer) GetSignedVAA(ctx context.Context, req *publicrpcv1.GetSignedVAARequest) (*publicrpcv1.GetSignedVAAResponse, error) {
+	id, err := decodeMessageID(req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.db.GetSignedVAABytes(*id)
 
 	if err != nil {
 		if err == db.ErrVAANotFound {
@@ -98,26 +149,230 @@ func (s *PublicrpcServer) GetSignedVAA(ctx context.Context, req *publicrpcv1.Get
 	}, nil
 }
 
-func (s *PublicrpcServer) GetCurrentGuardianSet(ctx context.Context, req *publicrpcv1.GetCurrentGuardianSetRequest) (*publicrpcv1.GetCurrentGuardianSetResponse, error) {
-	gs := s.gst.Get()
-	if gs == nil {
-		return nil, status.Error(codes.Unavailable, "guardian set not fetched from chain yet")
+const (
+	// Limits of BatchGetSignedVAA and GetSignedVAAsBySequence, which keep each query cheap.
+	maxBatchGetSignedVAAs   = 100
+	maxSignedVAAsBySequence = 1000
+)
+
+func (s *PublicrpcServer) BatchGetSignedVAA(ctx context.Context, req *publicrpcv1.BatchGetSignedVAARequest) (*publicrpcv1.BatchGetSignedVAAResponse, error) {
+	if len(req.MessageIds) > maxBatchGetSignedVAAs {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("at most %d message IDs can be requested", maxBatchGetSignedVAAs))
+	}
+
+	ids := make([]*db.VAAID, len(req.MessageIds))
+	for i, m := range req.MessageIds {
+		id, err := decodeMessageID(m)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("message ID %d: %s", i, status.Convert(err).Message()))
+		}
+		ids[i] = id
+	}
+
+	resp := &publicrpcv1.BatchGetSignedVAAResponse{
+		Entries: make([]*publicrpcv1.BatchGetSignedVAAResponse_Entry, len(ids)),
+	}
+	for i, id := range ids {
+		b, err := s.db.GetSignedVAABytes(*id)
+		if err != nil && err != db.ErrVAANotFound {
+			s.logger.Error("failed to fetch VAA", zap.Error(err), zap.Any("message_id", req.MessageIds[i]))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		resp.Entries[i] = &publicrpcv1.BatchGetSignedVAAResponse_Entry{
+			MessageId: req.MessageIds[i],
+			VaaBytes:  b,
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *PublicrpcServer) GetSignedVAAsBySequence(ctx context.Context, req *publicrpcv1.GetSignedVAAsBySequenceRequest) (*publicrpcv1.GetSignedVAAsBySequenceResponse, error) {
+	id, err := decodeMessageID(&publicrpcv1.MessageID{
+		EmitterChain:   req.EmitterChain,
+		EmitterAddress: req.EmitterAddress,
+		Sequence:       req.FirstSequence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.LastSequence < req.FirstSequence {
+		return nil, status.Error(codes.InvalidArgument, "the last sequence must not be less than the first sequence")
+	}
+	if req.LastSequence-req.FirstSequence >= maxSignedVAAsBySequence {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("the sequence range must not exceed %d sequences", maxSignedVAAsBySequence))
+	}
+
+	resp := &publicrpcv1.GetSignedVAAsBySequenceResponse{
+		VaaBytes: make([][]byte, 0),
+	}
+	for seq := req.FirstSequence; ; seq++ {
+		id.Sequence = seq
+		b, err := s.db.GetSignedVAABytes(*id)
+		switch {
+		case err == db.ErrVAANotFound:
+			resp.MissingSequences = append(resp.MissingSequences, seq)
+		case err != nil:
+			s.logger.Error("failed to fetch VAAs by sequence", zap.Error(err), zap.Any("request", req))
+			return nil, status.Error(codes.Internal, "internal server error")
+		default:
+			resp.VaaBytes = append(resp.VaaBytes, b)
+		}
+
+		// Checked here rather than in the loop condition, in case the last sequence is the maximum uint64.
+		if seq == req.LastSequence {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	return resp, nil
+}
+
+const (
+	// Limits of GetSignedVAAsByTime, which keep each query cheap.
+	maxSignedVAAsByTimeRange = 24 * time.Hour
+	maxSignedVAAsByTimeLimit = 1000
+)
+
+func (s *PublicrpcServer) GetSignedVAAsByTime(ctx context.Context, req *publicrpcv1.GetSignedVAAsByTimeRequest) (*publicrpcv1.GetSignedVAAsByTimeResponse, error) {
+	if req.Since == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no start of the time range specified")
+	}
+	since := time.Unix(int64(req.Since), 0)
+	until := time.Now()
+	if req.Until != 0 {
+		until = time.Unix(int64(req.Until), 0)
+	}
+	if until.Sub(since) > maxSignedVAAsByTimeRange {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("the time range must not exceed %v", maxSignedVAAsByTimeRange))
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 100
+	}
+	if limit > maxSignedVAAsByTimeLimit {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("the limit must not exceed %d", maxSignedVAAsByTimeLimit))
+	}
+
+	filter := db.VAAFilter{
+		EmitterChain: vaa.ChainID(req.EmitterChain.Number()),
+		LastSequence: math.MaxUint64,
+	}
+	if req.EmitterAddress != "" {
+		if filter.EmitterChain == vaa.ChainIDUnset {
+			return nil, status.Error(codes.InvalidArgument, "the emitter chain must be specified when the emitter address is")
+		}
+		addr, err := vaa.StringToAddress(req.EmitterAddress)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode address: %v", err))
+		}
+		filter.EmitterAddress = &addr
 	}
 
-	resp := &publicrpcv1.GetCurrentGuardianSetResponse{
-		GuardianSet: &publicrpcv1.GuardianSet{
-			Index:     gs.Index,
-			Addresses: make([]string, len(gs.Keys)),
-		},
+	vaas, truncated, err := s.db.GetSignedVAAsByTime(filter, db.TimeRange{Since: since, Until: until}, limit)
+	if err != nil {
+		s.logger.Error("failed to fetch VAAs by time", zap.Error(err), zap.Any("request", req))
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	return &publicrpcv1.GetSignedVAAsByTimeResponse{
+		VaaBytes:  vaas,
+		Truncated: truncated,
+	}, nil
+}
+
+// Transaction hashes are 32 bytes on most chains, and 64 bytes for Solana signatures.
+const maxTxHashLength = 64
+
+func (s *PublicrpcServer) GetSignedVAAsByTxHash(ctx context.Context, req *publicrpcv1.GetSignedVAAsByTxHashRequest) (*publicrpcv1.GetSignedVAAsByTxHashResponse, error) {
+	if req.EmitterChain == publicrpcv1.ChainID_CHAIN_ID_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "no emitter chain specified")
+	}
+	txHash, err := hex.DecodeString(req.TxHash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode tx hash: %v", err))
+	}
+	if len(txHash) == 0 || len(txHash) > maxTxHashLength {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("tx hash must be between 1 and %d bytes", maxTxHashLength))
+	}
+
+	ids, err := s.db.GetMessageIDsByTxHash(vaa.ChainID(req.EmitterChain.Number()), txHash)
+	if err != nil {
+		s.logger.Error("failed to fetch message IDs by tx hash", zap.Error(err), zap.Any("request", req))
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
-	for i, v := range gs.Keys {
-		resp.GuardianSet.Addresses[i] = v.Hex()
+	resp := &publicrpcv1.GetSignedVAAsByTxHashResponse{
+		Entries: make([]*publicrpcv1.GetSignedVAAsByTxHashResponse_Entry, len(ids)),
+	}
+	for i, id := range ids {
+		b, err := s.db.GetSignedVAABytes(*id)
+		if err != nil && err != db.ErrVAANotFound {
+			s.logger.Error("failed to fetch VAA", zap.Error(err), zap.Any("request", req))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		resp.Entries[i] = &publicrpcv1.GetSignedVAAsByTxHashResponse_Entry{
+			MessageId: &publicrpcv1.MessageID{
+				EmitterChain:   req.EmitterChain,
+				EmitterAddress: id.EmitterAddress.String(),
+				Sequence:       id.Sequence,
+			},
+			VaaBytes: b,
+		}
 	}
 
 	return resp, nil
 }
 
+func (s *PublicrpcServer) GetCurrentGuardianSet(ctx context.Context, req *publicrpcv1.GetCurrentGuardianSetRequest) (*publicrpcv1.GetCurrentGuardianSetResponse, error) {
+	resp, err := s.guardianSetCache.get(time.Now(), func() (proto.Message, error) {
+		gs := s.gst.Get()
+		if gs == nil {
+			return nil, status.Error(codes.Unavailable, "guardian set not fetched from chain yet")
+		}
+
+		resp := &publicrpcv1.GetCurrentGuardianSetResponse{
+			GuardianSet: &publicrpcv1.GuardianSet{
+				Index:          gs.Index,
+				Addresses:      make([]string, len(gs.Keys)),
+				ExpirationTime: gs.ExpirationTime,
+			},
+		}
+
+		for i, v := range gs.Keys {
+			resp.GuardianSet.Addresses[i] = v.Hex()
+		}
+
+		return resp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*publicrpcv1.GetCurrentGuardianSetResponse), nil
+}
+
+func (s *PublicrpcServer) GovernorGetStatus(ctx context.Context, req *publicrpcv1.GovernorGetStatusRequest) (*publicrpcv1.GovernorGetStatusResponse, error) {
+	if s.gov == nil {
+		return &publicrpcv1.GovernorGetStatusResponse{
+			Entries: make([]*publicrpcv1.GovernorGetStatusResponse_Entry, 0),
+		}, nil
+	}
+
+	resp, err := s.governorStatusCache.get(time.Now(), func() (proto.Message, error) {
+		return s.gov.GetStatus(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.(*publicrpcv1.GovernorGetStatusResponse), nil
+}
+
 func (s *PublicrpcServer) GovernorGetAvailableNotionalByChain(ctx context.Context, req *publicrpcv1.GovernorGetAvailableNotionalByChainRequest) (*publicrpcv1.GovernorGetAvailableNotionalByChainResponse, error) {
 	resp := &publicrpcv1.GovernorGetAvailableNotionalByChainResponse{}
 
@@ -169,3 +424,48 @@ func (s *PublicrpcServer) GovernorGetTokenList(ctx context.Context, req *publicr
 
 	return resp, nil
 }
+
+func (s *PublicrpcServer) GovernorGetUsageHistory(ctx context.Context, req *publicrpcv1.GovernorGetUsageHistoryRequest) (*publicrpcv1.GovernorGetUsageHistoryResponse, error) {
+	resp := &publicrpcv1.GovernorGetUsageHistoryResponse{}
+
+	if s.gov != nil {
+		resp.Entries = s.gov.GetUsageHistory()
+	} else {
+		resp.Entries = make([]*publicrpcv1.GovernorGetUsageHistoryResponse_Entry, 0)
+	}
+
+	return resp, nil
+}
+
+func (s *PublicrpcServer) GovernorSimulateTransfer(ctx context.Context, req *publicrpcv1.GovernorSimulateTransferRequest) (*publicrpcv1.GovernorSimulateTransferResponse, error) {
+	if req.EmitterChain > math.MaxUint16 || req.OriginChain > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "invalid chain id")
+	}
+
+	address, err := hex.DecodeString(req.OriginAddress)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to decode origin address: %v", err))
+	}
+	if len(address) != 32 {
+		return nil, status.Error(codes.InvalidArgument, "origin address must be 32 bytes")
+	}
+	originAddress := vaa.Address{}
+	copy(originAddress[:], address)
+
+	// Transfer amounts are uint256.
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be a decimal uint256")
+	}
+
+	if s.gov == nil {
+		return &publicrpcv1.GovernorSimulateTransferResponse{Outcome: publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_NOT_GOVERNED}, nil
+	}
+
+	resp, err := s.gov.SimulateTransfer(vaa.ChainID(req.EmitterChain), vaa.ChainID(req.OriginChain), originAddress, amount)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("failed to value the transfer: %v", err))
+	}
+
+	return resp, nil
+}
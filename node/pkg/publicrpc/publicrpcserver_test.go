@@ -2,13 +2,23 @@ package publicrpc
 
 import (
 	"context"
+	"encoding/hex"
 	"testing"
+	"time"
 
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/db"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestGetSignedVAANoMessage(t *testing.T) {
@@ -63,3 +73,254 @@ func TestGetSignedVAABadAddress(t *testing.T) {
 	expected_err := status.Error(codes.InvalidArgument, "address must be 32 bytes")
 	assert.Equal(t, expected_err, err)
 }
+
+func TestGetSignedVAAsByTimeNoSince(t *testing.T) {
+	msg := publicrpcv1.GetSignedVAAsByTimeRequest{}
+	ctx := context.Background()
+
+	logger, _ := zap.NewProduction()
+	server := &PublicrpcServer{logger: logger}
+
+	resp, err := server.GetSignedVAAsByTime(ctx, &msg)
+	assert.Nil(t, resp)
+
+	expected_err := status.Error(codes.InvalidArgument, "no start of the time range specified")
+	assert.Equal(t, expected_err, err)
+}
+
+func TestGetSignedVAAsByTimeRangeTooLong(t *testing.T) {
+	msg := publicrpcv1.GetSignedVAAsByTimeRequest{Since: 1000, Until: 1000 + 25*3600}
+	ctx := context.Background()
+
+	logger, _ := zap.NewProduction()
+	server := &PublicrpcServer{logger: logger}
+
+	resp, err := server.GetSignedVAAsByTime(ctx, &msg)
+	assert.Nil(t, resp)
+
+	expected_err := status.Error(codes.InvalidArgument, "the time range must not exceed 24h0m0s")
+	assert.Equal(t, expected_err, err)
+}
+
+func newTestServerWithVAAs(t *testing.T, emitter vaa.Address, sequences ...uint64) *PublicrpcServer {
+	d, err := db.Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+
+	for _, seq := range sequences {
+		require.NoError(t, d.StoreSignedVAA(&vaa.VAA{
+			Version:        vaa.SupportedVAAVersion,
+			Signatures:     []*vaa.Signature{{Index: 0}},
+			Timestamp:      time.Unix(1660000000, 0),
+			EmitterChain:   vaa.ChainIDEthereum,
+			EmitterAddress: emitter,
+			Sequence:       seq,
+			Payload:        []byte{1},
+		}))
+	}
+
+	logger, _ := zap.NewProduction()
+	return &PublicrpcServer{logger: logger, db: d}
+}
+
+func TestBatchGetSignedVAA(t *testing.T) {
+	emitter := vaa.Address{1}
+	server := newTestServerWithVAAs(t, emitter, 1, 3)
+
+	id := func(seq uint64) *publicrpcv1.MessageID {
+		return &publicrpcv1.MessageID{EmitterChain: publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, EmitterAddress: emitter.String(), Sequence: seq}
+	}
+
+	resp, err := server.BatchGetSignedVAA(context.Background(), &publicrpcv1.BatchGetSignedVAARequest{
+		MessageIds: []*publicrpcv1.MessageID{id(3), id(2), id(1)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 3)
+	for i, seq := range []uint64{3, 2, 1} {
+		assert.Equal(t, seq, resp.Entries[i].MessageId.Sequence)
+	}
+	assert.NotEmpty(t, resp.Entries[0].VaaBytes)
+	assert.Empty(t, resp.Entries[1].VaaBytes)
+	assert.NotEmpty(t, resp.Entries[2].VaaBytes)
+
+	_, err = server.BatchGetSignedVAA(context.Background(), &publicrpcv1.BatchGetSignedVAARequest{
+		MessageIds: []*publicrpcv1.MessageID{id(1), {EmitterAddress: "AAAA"}},
+	})
+	assert.Equal(t, status.Error(codes.InvalidArgument, "message ID 1: address must be 32 bytes"), err)
+
+	_, err = server.BatchGetSignedVAA(context.Background(), &publicrpcv1.BatchGetSignedVAARequest{
+		MessageIds: make([]*publicrpcv1.MessageID, maxBatchGetSignedVAAs+1),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetSignedVAAsBySequence(t *testing.T) {
+	emitter := vaa.Address{1}
+	server := newTestServerWithVAAs(t, emitter, 9, 10, 12, 2, 13)
+
+	resp, err := server.GetSignedVAAsBySequence(context.Background(), &publicrpcv1.GetSignedVAAsBySequenceRequest{
+		EmitterChain:   publicrpcv1.ChainID_CHAIN_ID_ETHEREUM,
+		EmitterAddress: emitter.String(),
+		FirstSequence:  9,
+		LastSequence:   12,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{11}, resp.MissingSequences)
+	require.Len(t, resp.VaaBytes, 3)
+	for i, seq := range []uint64{9, 10, 12} {
+		v, err := vaa.Unmarshal(resp.VaaBytes[i])
+		require.NoError(t, err)
+		assert.Equal(t, seq, v.Sequence)
+	}
+
+	_, err = server.GetSignedVAAsBySequence(context.Background(), &publicrpcv1.GetSignedVAAsBySequenceRequest{
+		EmitterChain:   publicrpcv1.ChainID_CHAIN_ID_ETHEREUM,
+		EmitterAddress: emitter.String(),
+		FirstSequence:  0,
+		LastSequence:   maxSignedVAAsBySequence,
+	})
+	assert.Equal(t, status.Error(codes.InvalidArgument, "the sequence range must not exceed 1000 sequences"), err)
+
+	_, err = server.GetSignedVAAsBySequence(context.Background(), &publicrpcv1.GetSignedVAAsBySequenceRequest{
+		EmitterChain:   publicrpcv1.ChainID_CHAIN_ID_ETHEREUM,
+		EmitterAddress: emitter.String(),
+		FirstSequence:  2,
+		LastSequence:   1,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetCurrentGuardianSetIsCached(t *testing.T) {
+	gst := common.NewGuardianSetState()
+	server := NewPublicrpcServer(zap.NewNop(), nil, gst, nil)
+
+	_, err := server.GetCurrentGuardianSet(context.Background(), &publicrpcv1.GetCurrentGuardianSetRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Errors are not cached.
+	gst.Set(&common.GuardianSet{Index: 1, Keys: []ethcommon.Address{{1}}, ExpirationTime: 1660000000})
+	resp, err := server.GetCurrentGuardianSet(context.Background(), &publicrpcv1.GetCurrentGuardianSetRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), resp.GuardianSet.Index)
+	assert.Equal(t, uint32(1660000000), resp.GuardianSet.ExpirationTime)
+	assert.Equal(t, []string{ethcommon.Address{1}.Hex()}, resp.GuardianSet.Addresses)
+
+	gst.Set(&common.GuardianSet{Index: 2})
+	resp, err = server.GetCurrentGuardianSet(context.Background(), &publicrpcv1.GetCurrentGuardianSetRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), resp.GuardianSet.Index)
+}
+
+func TestGetGuardianHeartbeat(t *testing.T) {
+	gst := common.NewGuardianSetState()
+	server := NewPublicrpcServer(zap.NewNop(), nil, gst, nil)
+	guardian := ethcommon.Address{1}
+
+	_, err := server.GetGuardianHeartbeat(context.Background(), &publicrpcv1.GetGuardianHeartbeatRequest{GuardianAddr: "0x1234"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.GetGuardianHeartbeat(context.Background(), &publicrpcv1.GetGuardianHeartbeatRequest{GuardianAddr: guardian.Hex()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	require.NoError(t, gst.SetHeartbeat(guardian, peer.ID("node-b"), &gossipv1.Heartbeat{NodeName: "b", Version: "v2.8.9"}))
+	require.NoError(t, gst.SetHeartbeat(guardian, peer.ID("node-a"), &gossipv1.Heartbeat{NodeName: "a", Features: []string{"governor"}}))
+	require.NoError(t, gst.SetHeartbeat(ethcommon.Address{2}, peer.ID("node-c"), &gossipv1.Heartbeat{NodeName: "c"}))
+
+	// The address can be given without the 0x prefix.
+	resp, err := server.GetGuardianHeartbeat(context.Background(), &publicrpcv1.GetGuardianHeartbeatRequest{GuardianAddr: hex.EncodeToString(guardian.Bytes())})
+	require.NoError(t, err)
+	assert.False(t, resp.InGuardianSet)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, "a", resp.Entries[0].RawHeartbeat.NodeName)
+	assert.Equal(t, []string{"governor"}, resp.Entries[0].RawHeartbeat.Features)
+	assert.Equal(t, "b", resp.Entries[1].RawHeartbeat.NodeName)
+	assert.Equal(t, guardian.Hex(), resp.Entries[1].VerifiedGuardianAddr)
+
+	gst.Set(&common.GuardianSet{Keys: []ethcommon.Address{guardian}})
+	resp, err = server.GetGuardianHeartbeat(context.Background(), &publicrpcv1.GetGuardianHeartbeatRequest{GuardianAddr: guardian.Hex()})
+	require.NoError(t, err)
+	assert.True(t, resp.InGuardianSet)
+}
+
+func TestResponseCacheExpires(t *testing.T) {
+	c := responseCache{ttl: time.Second}
+	now := time.Unix(1660000000, 0)
+	calls := 0
+	compute := func() (proto.Message, error) {
+		calls++
+		return &publicrpcv1.GovernorGetStatusResponse{TotalEnqueuedVaas: uint32(calls)}, nil
+	}
+
+	for _, tc := range []struct {
+		at   time.Time
+		want uint32
+	}{
+		{now, 1},
+		{now.Add(999 * time.Millisecond), 1},
+		{now.Add(time.Second), 2},
+	} {
+		resp, err := c.get(tc.at, compute)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, resp.(*publicrpcv1.GovernorGetStatusResponse).TotalEnqueuedVaas)
+	}
+}
+
+func TestGetSignedVAAsByTxHash(t *testing.T) {
+	emitter := vaa.Address{1}
+	server := newTestServerWithVAAs(t, emitter, 4)
+
+	txHash := []byte{0xab, 0xcd}
+	for _, seq := range []uint64{4, 5} {
+		require.NoError(t, server.db.StoreMessageTxHash(db.VAAID{EmitterChain: vaa.ChainIDEthereum, EmitterAddress: emitter, Sequence: seq}, txHash))
+	}
+
+	resp, err := server.GetSignedVAAsByTxHash(context.Background(), &publicrpcv1.GetSignedVAAsByTxHashRequest{
+		EmitterChain: publicrpcv1.ChainID_CHAIN_ID_ETHEREUM,
+		TxHash:       "abcd",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Entries, 2)
+	assert.Equal(t, emitter.String(), resp.Entries[0].MessageId.EmitterAddress)
+	assert.Equal(t, uint64(4), resp.Entries[0].MessageId.Sequence)
+	assert.NotEmpty(t, resp.Entries[0].VaaBytes)
+	assert.Equal(t, uint64(5), resp.Entries[1].MessageId.Sequence)
+	assert.Empty(t, resp.Entries[1].VaaBytes)
+
+	resp, err = server.GetSignedVAAsByTxHash(context.Background(), &publicrpcv1.GetSignedVAAsByTxHashRequest{
+		EmitterChain: publicrpcv1.ChainID_CHAIN_ID_SOLANA,
+		TxHash:       "abcd",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Entries)
+
+	for _, req := range []*publicrpcv1.GetSignedVAAsByTxHashRequest{
+		{TxHash: "abcd"},
+		{EmitterChain: publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, TxHash: "0xabcd"},
+		{EmitterChain: publicrpcv1.ChainID_CHAIN_ID_ETHEREUM},
+	} {
+		_, err = server.GetSignedVAAsByTxHash(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+	}
+}
+
+func TestGovernorSimulateTransfer(t *testing.T) {
+	server := &PublicrpcServer{logger: zap.NewNop()}
+	address := "000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+
+	for _, req := range []*publicrpcv1.GovernorSimulateTransferRequest{
+		{EmitterChain: 2, OriginChain: 70000, OriginAddress: address, Amount: "1"},
+		{EmitterChain: 2, OriginChain: 2, OriginAddress: "c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", Amount: "1"},
+		{EmitterChain: 2, OriginChain: 2, OriginAddress: address, Amount: ""},
+		{EmitterChain: 2, OriginChain: 2, OriginAddress: address, Amount: "-1"},
+		{EmitterChain: 2, OriginChain: 2, OriginAddress: address, Amount: "1.5"},
+	} {
+		_, err := server.GovernorSimulateTransfer(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+	}
+
+	// Without a governor, transfers are never delayed.
+	resp, err := server.GovernorSimulateTransfer(context.Background(), &publicrpcv1.GovernorSimulateTransferRequest{
+		EmitterChain: 2, OriginChain: 2, OriginAddress: address, Amount: "100000000000000",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, publicrpcv1.GovernorSimulateTransferResponse_OUTCOME_NOT_GOVERNED, resp.Outcome)
+}
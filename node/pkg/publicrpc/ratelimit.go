@@ -0,0 +1,211 @@
+package publicrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// APIKeyHeader is the metadata key (and HTTP header) carrying the API key of a client.
+	APIKeyHeader = "x-api-key"
+
+	servicePrefix = "/publicrpc.v1.PublicRPCService/"
+
+	// Limiters of clients that have not sent a request for this long are discarded.
+	clientIdleTimeout = 10 * time.Minute
+)
+
+var (
+	requestsThrottled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_publicrpc_requests_throttled_total",
+			Help: "Total number of public RPC requests rejected by the rate limiter",
+		}, []string{"method", "client"})
+	requestsUnauthenticated = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wormhole_publicrpc_requests_unknown_api_key_total",
+			Help: "Total number of public RPC requests rejected because of an unknown API key",
+		})
+)
+
+// Quota is a token bucket: clients may sustain RequestsPerSecond and burst up to Burst requests.
+// A zero RequestsPerSecond means unlimited.
+type Quota struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+// APIKey is a client that identifies itself with an API key and gets its own quota.
+type APIKey struct {
+	Key string `json:"key"`
+	Quota
+}
+
+// RateLimits configures the throttling of public RPC requests. Anonymous clients are throttled per IP address.
+type RateLimits struct {
+	Anonymous Quota
+	// API key clients by name. The name is used as the metric label.
+	APIKeys map[string]APIKey
+}
+
+// LoadAPIKeys reads the API key file at path:
+//
+//	{
+//	  "explorer": {"key": "3f9a...", "requestsPerSecond": 100, "burst": 200},
+//	  "bridge-ui": {"key": "c01d...", "requestsPerSecond": 0}
+//	}
+func LoadAPIKeys(path string) (map[string]APIKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	var keys map[string]APIKey
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API key file: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for name, k := range keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("API key %s: key must be set", name)
+		}
+		if other, ok := seen[k.Key]; ok {
+			return nil, fmt.Errorf("API key %s: same key as %s", name, other)
+		}
+		if k.RequestsPerSecond < 0 || k.Burst < 0 {
+			return nil, fmt.Errorf("API key %s: quota must not be negative", name)
+		}
+		seen[k.Key] = name
+	}
+	return keys, nil
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter throttles public RPC requests per client using token buckets.
+type RateLimiter struct {
+	limits RateLimits
+	// API key -> client name
+	names map[string]string
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	names := make(map[string]string, len(limits.APIKeys))
+	for name, k := range limits.APIKeys {
+		names[k.Key] = name
+	}
+	return &RateLimiter{
+		limits:  limits,
+		names:   names,
+		clients: make(map[string]*clientLimiter),
+	}
+}
+
+// allow reports whether the client identified by id may make a request at now.
+func (r *RateLimiter) allow(id string, q Quota, now time.Time) bool {
+	if q.RequestsPerSecond == 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastSweep) > clientIdleTimeout {
+		for k, c := range r.clients {
+			if now.Sub(c.lastSeen) > clientIdleTimeout {
+				delete(r.clients, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	c, ok := r.clients[id]
+	if !ok {
+		burst := q.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		c = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(q.RequestsPerSecond), burst)}
+		r.clients[id] = c
+	}
+	c.lastSeen = now
+	return c.limiter.AllowN(now, 1)
+}
+
+// identify returns the limiter key, metric label and quota of the caller. Requests on UNIX sockets
+// without a forwarded address are local and ok is false.
+func (r *RateLimiter) identify(ctx context.Context) (id string, label string, q Quota, ok bool, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if keys := md.Get(APIKeyHeader); len(keys) > 0 {
+		name, known := r.names[keys[0]]
+		if !known {
+			return "", "", Quota{}, false, status.Error(codes.Unauthenticated, "unknown API key")
+		}
+		return "key:" + name, name, r.limits.APIKeys[name].Quota, true, nil
+	}
+
+	p, _ := peer.FromContext(ctx)
+	if p == nil || p.Addr == nil {
+		return "", "", Quota{}, false, nil
+	}
+
+	if p.Addr.Network() == "unix" {
+		// Requests from the REST gateway carry the client address appended by the gateway.
+		// Earlier entries are supplied by the client and can't be trusted.
+		fwd := md.Get("x-forwarded-for")
+		if len(fwd) == 0 {
+			return "", "", Quota{}, false, nil
+		}
+		addrs := strings.Split(fwd[len(fwd)-1], ",")
+		return "ip:" + strings.TrimSpace(addrs[len(addrs)-1]), "anonymous", r.limits.Anonymous, true, nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host, "anonymous", r.limits.Anonymous, true, nil
+}
+
+// UnaryInterceptor rejects public RPC requests of clients that exceed their quota. Other services are passed through.
+func (r *RateLimiter) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+		return handler(ctx, req)
+	}
+
+	id, label, q, ok, err := r.identify(ctx)
+	if err != nil {
+		requestsUnauthenticated.Inc()
+		return nil, err
+	}
+	if ok && !r.allow(id, q, time.Now()) {
+		requestsThrottled.WithLabelValues(strings.TrimPrefix(info.FullMethod, servicePrefix), label).Inc()
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	return handler(ctx, req)
+}
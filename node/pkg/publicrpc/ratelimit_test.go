@@ -0,0 +1,142 @@
+package publicrpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func tcpPeer(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4242}})
+}
+
+func unixPeer(md metadata.MD) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.UnixAddr{Name: "/tmp/admin.sock", Net: "unix"}})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	r := NewRateLimiter(RateLimits{})
+	q := Quota{RequestsPerSecond: 1, Burst: 2}
+	now := time.Unix(1000, 0)
+
+	assert.True(t, r.allow("a", q, now))
+	assert.True(t, r.allow("a", q, now))
+	assert.False(t, r.allow("a", q, now))
+	// Other clients have their own bucket.
+	assert.True(t, r.allow("b", q, now))
+	// Tokens are refilled over time.
+	assert.True(t, r.allow("a", q, now.Add(time.Second)))
+	// Zero means unlimited.
+	for i := 0; i < 100; i++ {
+		assert.True(t, r.allow("c", Quota{}, now))
+	}
+
+	// Idle clients are discarded.
+	r.allow("d", q, now.Add(2*clientIdleTimeout))
+	assert.Len(t, r.clients, 1)
+}
+
+func TestRateLimiterIdentify(t *testing.T) {
+	r := NewRateLimiter(RateLimits{
+		Anonymous: Quota{RequestsPerSecond: 1},
+		APIKeys:   map[string]APIKey{"explorer": {Key: "secret", Quota: Quota{RequestsPerSecond: 100}}},
+	})
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		id    string
+		label string
+		ok    bool
+		err   error
+	}{
+		{"tcp client", tcpPeer("10.0.0.1"), "ip:10.0.0.1", "anonymous", true, nil},
+		{"api key", metadata.NewIncomingContext(tcpPeer("10.0.0.1"), metadata.Pairs(APIKeyHeader, "secret")), "key:explorer", "explorer", true, nil},
+		{"unknown api key", metadata.NewIncomingContext(tcpPeer("10.0.0.1"), metadata.Pairs(APIKeyHeader, "wrong")), "", "", false, status.Error(codes.Unauthenticated, "unknown API key")},
+		{"local admin client", unixPeer(nil), "", "", false, nil},
+		{"gateway", unixPeer(metadata.Pairs("x-forwarded-for", "1.2.3.4, 10.0.0.2")), "ip:10.0.0.2", "anonymous", true, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, label, _, ok, err := r.identify(tc.ctx)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.id, id)
+			assert.Equal(t, tc.label, label)
+		})
+	}
+}
+
+func TestRateLimiterUnaryInterceptor(t *testing.T) {
+	r := NewRateLimiter(RateLimits{Anonymous: Quota{RequestsPerSecond: 0.001, Burst: 1}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/publicrpc.v1.PublicRPCService/GetSignedVAA"}
+	ctx := tcpPeer("10.0.0.1")
+
+	resp, err := r.UnaryInterceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = r.UnaryInterceptor(ctx, nil, info, handler)
+	assert.Equal(t, status.Error(codes.ResourceExhausted, "rate limit exceeded"), err)
+
+	// Other services are not throttled.
+	_, err = r.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/node.v1.NodePrivilegedService/GetNodeStatus"}, handler)
+	assert.NoError(t, err)
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(s string) string {
+		p := filepath.Join(dir, "keys.json")
+		require.NoError(t, ioutil.WriteFile(p, []byte(s), 0600))
+		return p
+	}
+
+	keys, err := LoadAPIKeys(write(`{"explorer": {"key": "secret", "requestsPerSecond": 100, "burst": 200}}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]APIKey{"explorer": {Key: "secret", Quota: Quota{RequestsPerSecond: 100, Burst: 200}}}, keys)
+
+	_, err = LoadAPIKeys(write(`{"explorer": {"requestsPerSecond": 100}}`))
+	assert.EqualError(t, err, "API key explorer: key must be set")
+
+	_, err = LoadAPIKeys(write(`{"a": {"key": "secret"}, "b": {"key": "secret"}}`))
+	assert.Error(t, err)
+
+	_, err = LoadAPIKeys(write(`{"a": {"key": "secret", "burst": -1}}`))
+	assert.EqualError(t, err, "API key a: quota must not be negative")
+}
+
+func TestSheddingInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/publicrpc.v1.PublicRPCService/GetSignedVAA"}
+
+	// The number of goroutines of the test exceeds this limit.
+	wd := watchdog.New(zap.NewNop(), watchdog.Limits{Goroutines: 1}, "", time.Minute)
+	resp, err := SheddingInterceptor(wd)(context.Background(), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	wd.Check()
+	_, err = SheddingInterceptor(wd)(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Other services are not shed.
+	_, err = SheddingInterceptor(wd)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/node.v1.NodePrivilegedService/GetNodeStatus"}, handler)
+	assert.NoError(t, err)
+}
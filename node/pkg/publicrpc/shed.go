@@ -0,0 +1,31 @@
+package publicrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/certusone/wormhole/node/pkg/watchdog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var requestsShed = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wormhole_publicrpc_requests_shed_total",
+		Help: "Total number of public RPC requests rejected because the node is degraded",
+	}, []string{"method"})
+
+// SheddingInterceptor rejects public RPC requests while the node is degraded, see the watchdog package. Other services are
+// passed through.
+func SheddingInterceptor(wd *watchdog.Watchdog) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, servicePrefix) && wd.Degraded() {
+			requestsShed.WithLabelValues(strings.TrimPrefix(info.FullMethod, servicePrefix)).Inc()
+			return nil, status.Error(codes.Unavailable, "node is degraded, try another guardian")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -1,19 +1,44 @@
-// package readiness implements a minimal health-checking mechanism for use as k8s readiness probes. It will always
-// return a "ready" state after the conditions have been met for the first time - it's not meant for monitoring.
+// package readiness implements a minimal health-checking mechanism for use as k8s readiness probes. Each component
+// reports its health as it makes progress, and is only ready while its last report is recent enough and its lag is
+// below its threshold - a component that stalls becomes unready again.
 //
 // Uses a global singleton registry (similar to the Prometheus client's default behavior).
 package readiness
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
 )
 
+// Thresholds configures when a component is ready.
+type Thresholds struct {
+	// A component is only ready if it reported its health within MaxAge. Zero disables the deadline.
+	MaxAge time.Duration
+	// A component is only ready if its lag is at most MaxLag. Zero disables the check.
+	MaxLag int64
+}
+
+// DefaultThresholds apply to the components without configured thresholds.
+var DefaultThresholds = Thresholds{MaxAge: 2 * time.Minute}
+
+type health struct {
+	thresholds Thresholds
+	updated    time.Time
+	lag        int64
+}
+
 var (
 	mu       = sync.Mutex{}
-	registry = map[string]bool{}
+	registry = map[string]*health{}
+
+	// Replaced in tests.
+	now = time.Now
 )
 
 type Component string
@@ -24,19 +49,139 @@ func RegisterComponent(component Component) {
 	if _, ok := registry[string(component)]; ok {
 		panic("component already registered")
 	}
-	registry[string(component)] = false
+	registry[string(component)] = &health{thresholds: DefaultThresholds}
 	mu.Unlock()
 }
 
-// SetReady sets the given global component state.
+// SetReady reports that the given component made progress, which keeps it ready until its deadline.
 func SetReady(component Component) {
 	mu.Lock()
-	if !registry[string(component)] {
-		registry[string(component)] = true
+	if h, ok := registry[string(component)]; ok {
+		h.updated = now()
+	}
+	mu.Unlock()
+}
+
+// SetLag sets how far the given component lags behind, in a unit specific to the component (such as blocks).
+func SetLag(component Component, lag int64) {
+	mu.Lock()
+	if h, ok := registry[string(component)]; ok {
+		h.lag = lag
 	}
 	mu.Unlock()
 }
 
+// SetThresholds configures when the given component is ready.
+func SetThresholds(component Component, thresholds Thresholds) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	h, ok := registry[string(component)]
+	if !ok {
+		return fmt.Errorf("unknown component %s", component)
+	}
+	h.thresholds = thresholds
+	return nil
+}
+
+func (h *health) ready(t time.Time) bool {
+	if h.updated.IsZero() {
+		return false
+	}
+	if h.thresholds.MaxAge != 0 && t.Sub(h.updated) > h.thresholds.MaxAge {
+		return false
+	}
+	if h.thresholds.MaxLag != 0 && h.lag > h.thresholds.MaxLag {
+		return false
+	}
+	return true
+}
+
+// Status returns whether each registered component is ready.
+func Status() map[string]bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	t := now()
+	status := make(map[string]bool, len(registry))
+	for k, h := range registry {
+		status[k] = h.ready(t)
+	}
+	return status
+}
+
+// ThresholdsConfig is the JSON configuration of the thresholds of a component.
+type ThresholdsConfig struct {
+	// Go duration string. Zero disables the deadline.
+	MaxAge string `json:"maxAge,omitempty"`
+	MaxLag int64  `json:"maxLag,omitempty"`
+}
+
+// Config is the JSON configuration of the readiness thresholds.
+type Config struct {
+	// Applies to the components without thresholds of their own.
+	Default    *ThresholdsConfig           `json:"default,omitempty"`
+	Components map[string]ThresholdsConfig `json:"components"`
+}
+
+func (c ThresholdsConfig) thresholds() (Thresholds, error) {
+	t := Thresholds{MaxLag: c.MaxLag}
+	if c.MaxAge != "" {
+		maxAge, err := time.ParseDuration(c.MaxAge)
+		if err != nil || maxAge < 0 {
+			return t, fmt.Errorf("invalid maxAge: %s", c.MaxAge)
+		}
+		t.MaxAge = maxAge
+	}
+	if c.MaxLag < 0 {
+		return t, fmt.Errorf("invalid maxLag: %d", c.MaxLag)
+	}
+	return t, nil
+}
+
+// LoadConfig reads the readiness thresholds from a JSON file and applies them to the registered components.
+func LoadConfig(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read readiness config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return fmt.Errorf("failed to parse readiness config: %w", err)
+	}
+
+	return Configure(cfg)
+}
+
+// Configure applies the readiness thresholds of cfg to the registered components.
+func Configure(cfg Config) error {
+	if cfg.Default != nil {
+		t, err := cfg.Default.thresholds()
+		if err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+
+		mu.Lock()
+		for _, h := range registry {
+			h.thresholds = t
+		}
+		mu.Unlock()
+	}
+
+	for name, c := range cfg.Components {
+		t, err := c.thresholds()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := SetThresholds(Component(name), t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Handler returns a net/http handler for the readiness check. It returns 200 OK if all components are ready,
 // or 412 Precondition Failed otherwise. For operator convenience, a list of components and their states
 // is returned as plain text (not meant for machine consumption!).
@@ -48,19 +193,29 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		panic(err)
 	}
-	_, err = resp.Write([]byte("[these values update AT STARTUP ONLY - see https://github.com/certusone/wormhole/blob/dev.v2/docs/operations.md#readyz]\n\n"))
-	if err != nil {
-		panic(err)
-	}
 
 	mu.Lock()
-	for k, v := range registry {
-		_, err = fmt.Fprintf(resp, "%s\t%v\n", k, v)
+	t := now()
+	names := make([]string, 0, len(registry))
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		h := registry[k]
+		updated := "never"
+		if !h.updated.IsZero() {
+			updated = t.Sub(h.updated).Truncate(time.Second).String() + " ago"
+		}
+
+		_, err = fmt.Fprintf(resp, "%s\t%v\tlast update: %s (max age %v)\tlag: %d (max %d)\n",
+			k, h.ready(t), updated, h.thresholds.MaxAge, h.lag, h.thresholds.MaxLag)
 		if err != nil {
 			panic(err)
 		}
 
-		if !v {
+		if !h.ready(t) {
 			ready = false
 		}
 	}
@@ -0,0 +1,67 @@
+package readiness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withClock replaces the registry and the clock for the duration of a test.
+func withClock(t *testing.T) *time.Time {
+	clock := time.Unix(1000, 0)
+	oldRegistry, oldNow := registry, now
+	registry = map[string]*health{}
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { registry, now = oldRegistry, oldNow })
+	return &clock
+}
+
+func TestReadinessDeadline(t *testing.T) {
+	clock := withClock(t)
+	RegisterComponent("a")
+
+	assert.False(t, Status()["a"])
+
+	SetReady("a")
+	assert.True(t, Status()["a"])
+
+	// A component which stops reporting becomes unready again.
+	*clock = clock.Add(DefaultThresholds.MaxAge + time.Second)
+	assert.False(t, Status()["a"])
+
+	SetReady("a")
+	assert.True(t, Status()["a"])
+}
+
+func TestReadinessLag(t *testing.T) {
+	withClock(t)
+	RegisterComponent("a")
+	require.NoError(t, SetThresholds("a", Thresholds{MaxLag: 10}))
+
+	SetReady("a")
+	SetLag("a", 10)
+	assert.True(t, Status()["a"])
+
+	SetLag("a", 11)
+	assert.False(t, Status()["a"])
+}
+
+func TestConfigure(t *testing.T) {
+	withClock(t)
+	RegisterComponent("a")
+	RegisterComponent("b")
+
+	err := Configure(Config{
+		Default:    &ThresholdsConfig{MaxAge: "30s"},
+		Components: map[string]ThresholdsConfig{"b": {MaxAge: "0s", MaxLag: 5}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Thresholds{MaxAge: 30 * time.Second}, registry["a"].thresholds)
+	assert.Equal(t, Thresholds{MaxLag: 5}, registry["b"].thresholds)
+
+	assert.Error(t, Configure(Config{Components: map[string]ThresholdsConfig{"c": {}}}))
+	assert.Error(t, Configure(Config{Components: map[string]ThresholdsConfig{"a": {MaxAge: "soon"}}}))
+	assert.Error(t, Configure(Config{Default: &ThresholdsConfig{MaxLag: -1}}))
+}
@@ -0,0 +1,68 @@
+package redemption
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ethAbi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// The token bridge method used to look up whether a transfer has been redeemed.
+const tokenBridgeIsTransferCompletedABI = `[{"inputs":[{"internalType":"bytes32","name":"hash","type":"bytes32"}],"name":"isTransferCompleted","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+
+// evmRedemptionChecker checks redemptions using the token bridge contract on an EVM chain.
+type evmRedemptionChecker struct {
+	rpcURL      string
+	tokenBridge ethCommon.Address
+
+	mutex  sync.Mutex
+	bridge *bind.BoundContract
+}
+
+// NewEvmRedemptionChecker creates a redemption checker for the token bridge at the specified address. The connection is
+// established on first use.
+func NewEvmRedemptionChecker(rpcURL string, tokenBridge ethCommon.Address) RedemptionChecker {
+	return &evmRedemptionChecker{rpcURL: rpcURL, tokenBridge: tokenBridge}
+}
+
+func (c *evmRedemptionChecker) connect(ctx context.Context) (*bind.BoundContract, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.bridge == nil {
+		parsed, err := ethAbi.JSON(strings.NewReader(tokenBridgeIsTransferCompletedABI))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse token bridge abi: %w", err)
+		}
+
+		client, err := ethclient.DialContext(ctx, c.rpcURL)
+		if err != nil {
+			return nil, fmt.Errorf("dialing eth client failed: %w", err)
+		}
+
+		c.bridge = bind.NewBoundContract(c.tokenBridge, parsed, client, nil, nil)
+	}
+
+	return c.bridge, nil
+}
+
+// IsRedeemed returns whether the token bridge has completed the transfer. The token bridge records completed transfers
+// by the hash of the VAA body, which is the digest signed by the guardians.
+func (c *evmRedemptionChecker) IsRedeemed(ctx context.Context, digest ethCommon.Hash) (bool, error) {
+	bridge, err := c.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var out []interface{}
+	if err := bridge.Call(&bind.CallOpts{Context: ctx}, &out, "isTransferCompleted", [32]byte(digest)); err != nil {
+		return false, fmt.Errorf("failed to query isTransferCompleted: %w", err)
+	}
+
+	return *ethAbi.ConvertType(out[0], new(bool)).(*bool), nil
+}
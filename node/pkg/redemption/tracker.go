@@ -0,0 +1,289 @@
+// Package redemption tracks whether token bridge transfers signed by the guardians are redeemed on their target chain.
+//
+// The tracker subscribes to the VAAs reaching quorum. Transfers to a chain with a configured RedemptionChecker are kept
+// in memory and periodically checked against the token bridge on the target chain. Transfers which are still not
+// redeemed a while after they were sent are reported as stuck, which usually means that the relayer responsible for
+// them is down.
+//
+// Only transfers signed since the guardian was started are tracked, and transfers are forgotten after a maximum age,
+// whether they were redeemed or not.
+package redemption
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/reporter"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	// How often the tracker looks for transfers which are due to be checked.
+	checkInterval = time.Minute
+	// How long the tracker waits before checking an unredeemed transfer again.
+	recheckInterval = 10 * time.Minute
+	// Timeout of a single redemption check.
+	checkTimeout = 10 * time.Second
+)
+
+// RedemptionChecker checks whether the transfer VAA with the given digest has been redeemed on a chain.
+type RedemptionChecker interface {
+	IsRedeemed(ctx context.Context, digest ethCommon.Hash) (bool, error)
+}
+
+var (
+	// guardian_redemption_unredeemed_transfers{target_chain="ethereum"} 3
+	metricUnredeemed = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "guardian_redemption_unredeemed_transfers",
+			Help: "Redemption tracker number of tracked transfers not yet redeemed on the target chain",
+		}, []string{"target_chain"})
+
+	// guardian_redemption_stuck_transfers{target_chain="ethereum"} 1
+	metricStuck = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "guardian_redemption_stuck_transfers",
+			Help: "Redemption tracker number of transfers not redeemed on the target chain after the stuck threshold",
+		}, []string{"target_chain"})
+
+	// guardian_redemption_stuck_value{target_chain="ethereum"} 250000
+	metricStuckValue = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "guardian_redemption_stuck_value",
+			Help: "Redemption tracker notional value of the stuck transfers, for the transfers with a known value",
+		}, []string{"target_chain"})
+
+	// guardian_redemption_redeemed_total{target_chain="ethereum"} 42
+	metricRedeemed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "guardian_redemption_redeemed_total",
+			Help: "Redemption tracker number of tracked transfers observed to be redeemed on the target chain",
+		}, []string{"target_chain"})
+
+	// guardian_redemption_check_errors_total{target_chain="ethereum"} 0
+	metricCheckErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "guardian_redemption_check_errors_total",
+			Help: "Redemption tracker number of redemption checks which failed",
+		}, []string{"target_chain"})
+)
+
+// Config configures a Tracker.
+type Config struct {
+	// Token bridge emitters whose transfers are tracked.
+	Emitters map[vaa.ChainID][]byte
+	// Checkers of the target chains. Transfers to other chains are not tracked.
+	Checkers map[vaa.ChainID]RedemptionChecker
+	// Transfers with a notional value below MinValue are not tracked. If it is non-zero, Valuer must be set, and
+	// transfers of tokens with an unknown value are not tracked either.
+	MinValue uint64
+	Valuer   governor.TransferValuer
+	// Transfers are reported as stuck if they are not redeemed StuckAfter after they were sent.
+	StuckAfter time.Duration
+	// Transfers are no longer tracked MaxAge after they were sent.
+	MaxAge time.Duration
+}
+
+type transfer struct {
+	msgID       string
+	digest      ethCommon.Hash
+	targetChain vaa.ChainID
+	value       uint64
+	valueKnown  bool
+	timestamp   time.Time
+	nextCheck   time.Time
+	stuck       bool
+}
+
+// Tracker tracks the redemption of token bridge transfers on their target chains.
+type Tracker struct {
+	logger *zap.Logger
+	cfg    Config
+
+	mutex     sync.Mutex
+	transfers map[string]*transfer
+}
+
+func NewTracker(logger *zap.Logger, cfg Config) *Tracker {
+	return &Tracker{
+		logger:    logger,
+		cfg:       cfg,
+		transfers: make(map[string]*transfer),
+	}
+}
+
+// Run returns a runnable which tracks the transfers reaching quorum until it is canceled. It is meant to be run by the
+// supervisor.
+func (t *Tracker) Run(events *reporter.AttestationEventReporter) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		sub := events.Subscribe()
+		defer events.Unsubscribe(sub.ClientId)
+
+		go t.runChecks(ctx)
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-sub.Channels.MessagePublicationC:
+				// Only VAAs are tracked, but the channel must be drained.
+			case v := <-sub.Channels.VAAQuorumC:
+				t.Track(v, time.Now())
+			}
+		}
+	}
+}
+
+// runChecks checks the transfers which are due periodically. Checks are made separately from receiving events, so a
+// slow RPC doesn't cause events to be dropped.
+func (t *Tracker) runChecks(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Check(ctx, time.Now())
+		}
+	}
+}
+
+// Track starts tracking a VAA if it is a transfer which should be tracked.
+func (t *Tracker) Track(v *vaa.VAA, now time.Time) {
+	emitter, exists := t.cfg.Emitters[v.EmitterChain]
+	if !exists || !bytes.Equal(emitter, v.EmitterAddress.Bytes()) {
+		return
+	}
+	if !vaa.IsTransfer(v.Payload) {
+		return
+	}
+	payload, err := vaa.DecodeTransferPayloadHdr(v.Payload)
+	if err != nil {
+		t.logger.Error("redemption: failed to decode transfer", zap.String("msgID", v.MessageID()), zap.Error(err))
+		return
+	}
+	if _, exists := t.cfg.Checkers[payload.TargetChain]; !exists {
+		return
+	}
+	if now.Sub(v.Timestamp) > t.cfg.MaxAge {
+		return
+	}
+
+	var value uint64
+	var valueKnown bool
+	if t.cfg.Valuer != nil {
+		value, valueKnown = t.cfg.Valuer.TransferValue(payload)
+	}
+	if t.cfg.MinValue != 0 && (!valueKnown || value < t.cfg.MinValue) {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	msgID := v.MessageID()
+	if _, exists := t.transfers[msgID]; exists {
+		return
+	}
+	t.transfers[msgID] = &transfer{
+		msgID:       msgID,
+		digest:      v.SigningMsg(),
+		targetChain: payload.TargetChain,
+		value:       value,
+		valueKnown:  valueKnown,
+		timestamp:   v.Timestamp,
+		nextCheck:   now.Add(recheckInterval),
+	}
+	t.logger.Debug("redemption: tracking transfer", zap.String("msgID", msgID), zap.Stringer("targetChain", payload.TargetChain), zap.Uint64("value", value))
+}
+
+// Check checks the redemption of the transfers which are due, and updates the metrics.
+func (t *Tracker) Check(ctx context.Context, now time.Time) {
+	// Query the chains without holding the lock, so new transfers are not blocked while waiting on RPCs.
+	t.mutex.Lock()
+	due := make([]*transfer, 0)
+	for msgID, tr := range t.transfers {
+		if now.Sub(tr.timestamp) > t.cfg.MaxAge {
+			t.logger.Info("redemption: no longer tracking unredeemed transfer", zap.String("msgID", msgID), zap.Stringer("targetChain", tr.targetChain))
+			delete(t.transfers, msgID)
+			continue
+		}
+		if !now.Before(tr.nextCheck) {
+			due = append(due, tr)
+		}
+	}
+	t.mutex.Unlock()
+
+	redeemed := make([]*transfer, 0)
+	for _, tr := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		ok, err := t.cfg.Checkers[tr.targetChain].IsRedeemed(checkCtx, tr.digest)
+		cancel()
+		if err != nil {
+			metricCheckErrors.WithLabelValues(tr.targetChain.String()).Inc()
+			t.logger.Warn("redemption: failed to check transfer", zap.String("msgID", tr.msgID), zap.Stringer("targetChain", tr.targetChain), zap.Error(err))
+			continue
+		}
+		if ok {
+			redeemed = append(redeemed, tr)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, tr := range due {
+		tr.nextCheck = now.Add(recheckInterval)
+	}
+	for _, tr := range redeemed {
+		metricRedeemed.WithLabelValues(tr.targetChain.String()).Inc()
+		if tr.stuck {
+			t.logger.Info("redemption: stuck transfer was redeemed", zap.String("msgID", tr.msgID), zap.Stringer("targetChain", tr.targetChain), zap.Duration("age", now.Sub(tr.timestamp)))
+		}
+		delete(t.transfers, tr.msgID)
+	}
+
+	unredeemed := make(map[vaa.ChainID]int)
+	stuck := make(map[vaa.ChainID]int)
+	stuckValue := make(map[vaa.ChainID]uint64)
+	for _, tr := range t.transfers {
+		unredeemed[tr.targetChain]++
+		if now.Sub(tr.timestamp) < t.cfg.StuckAfter {
+			continue
+		}
+		if !tr.stuck {
+			tr.stuck = true
+			t.logger.Warn("redemption: transfer has not been redeemed",
+				zap.String("msgID", tr.msgID),
+				zap.Stringer("targetChain", tr.targetChain),
+				zap.String("digest", tr.digest.Hex()),
+				zap.Uint64("value", tr.value),
+				zap.Duration("age", now.Sub(tr.timestamp)),
+			)
+		}
+		stuck[tr.targetChain]++
+		if tr.valueKnown {
+			stuckValue[tr.targetChain] += tr.value
+		}
+	}
+
+	for chainID := range t.cfg.Checkers {
+		metricUnredeemed.WithLabelValues(chainID.String()).Set(float64(unredeemed[chainID]))
+		metricStuck.WithLabelValues(chainID.String()).Set(float64(stuck[chainID]))
+		metricStuckValue.WithLabelValues(chainID.String()).Set(float64(stuckValue[chainID]))
+	}
+}
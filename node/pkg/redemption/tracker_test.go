@@ -0,0 +1,210 @@
+package redemption
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type mockChecker struct {
+	mutex    sync.Mutex
+	redeemed map[ethCommon.Hash]bool
+	err      error
+	checks   int
+}
+
+func (c *mockChecker) IsRedeemed(ctx context.Context, digest ethCommon.Hash) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks++
+	return c.redeemed[digest], c.err
+}
+
+// mockValuer values transfers at their amount.
+type mockValuer struct{}
+
+func (mockValuer) TransferValue(payload *vaa.TransferPayloadHdr) (uint64, bool) {
+	return payload.Amount.Uint64(), payload.OriginChain == vaa.ChainIDEthereum
+}
+
+func newTestTracker(checker RedemptionChecker, minValue uint64) *Tracker {
+	return NewTracker(zap.NewNop(), Config{
+		Emitters:   common.KnownDevnetTokenbridgeEmitters,
+		Checkers:   map[vaa.ChainID]RedemptionChecker{vaa.ChainIDBSC: checker},
+		MinValue:   minValue,
+		Valuer:     mockValuer{},
+		StuckAfter: 6 * time.Hour,
+		MaxAge:     24 * time.Hour,
+	})
+}
+
+func transferVAA(t *testing.T, seq uint64, tokenChain vaa.ChainID, targetChain vaa.ChainID, amount uint64, timestamp time.Time) *vaa.VAA {
+	t.Helper()
+	emitter, err := vaa.BytesToAddress(common.KnownDevnetTokenbridgeEmitters[vaa.ChainIDEthereum])
+	require.NoError(t, err)
+
+	payload := make([]byte, 133)
+	payload[0] = 1
+	big.NewInt(int64(amount)).FillBytes(payload[1:33])
+	binary.BigEndian.PutUint16(payload[65:67], uint16(tokenChain))
+	binary.BigEndian.PutUint16(payload[99:101], uint16(targetChain))
+
+	return &vaa.VAA{
+		Version:          vaa.SupportedVAAVersion,
+		Timestamp:        timestamp,
+		EmitterChain:     vaa.ChainIDEthereum,
+		EmitterAddress:   emitter,
+		Sequence:         seq,
+		ConsistencyLevel: 1,
+		Payload:          payload,
+	}
+}
+
+func TestTrackerFiltersTransfers(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tracker := newTestTracker(&mockChecker{}, 1000)
+
+	// Tracked.
+	tracker.Track(transferVAA(t, 1, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, now), now)
+	// Tracked only once.
+	tracker.Track(transferVAA(t, 1, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, now), now)
+	// Below the minimum value.
+	tracker.Track(transferVAA(t, 2, vaa.ChainIDEthereum, vaa.ChainIDBSC, 999, now), now)
+	// Unknown value.
+	tracker.Track(transferVAA(t, 3, vaa.ChainIDSolana, vaa.ChainIDBSC, 5000, now), now)
+	// No checker for the target chain.
+	tracker.Track(transferVAA(t, 4, vaa.ChainIDEthereum, vaa.ChainIDPolygon, 5000, now), now)
+	// Too old.
+	tracker.Track(transferVAA(t, 5, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, now.Add(-25*time.Hour)), now)
+
+	// Not from the token bridge.
+	v := transferVAA(t, 6, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, now)
+	v.EmitterAddress = vaa.Address{1}
+	tracker.Track(v, now)
+
+	// Not a transfer.
+	v = transferVAA(t, 7, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, now)
+	v.Payload[0] = 2
+	tracker.Track(v, now)
+
+	assert.Equal(t, 1, len(tracker.transfers))
+}
+
+func TestTrackerDetectsStuckTransfers(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	checker := &mockChecker{redeemed: map[ethCommon.Hash]bool{}}
+	tracker := newTestTracker(checker, 0)
+
+	redeemed := transferVAA(t, 1, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, start)
+	stuck := transferVAA(t, 2, vaa.ChainIDEthereum, vaa.ChainIDBSC, 7000, start)
+	unknownValue := transferVAA(t, 3, vaa.ChainIDSolana, vaa.ChainIDBSC, 9000, start)
+	tracker.Track(redeemed, start)
+	tracker.Track(stuck, start)
+	tracker.Track(unknownValue, start)
+
+	// Transfers are not checked before the recheck interval.
+	tracker.Check(context.Background(), start.Add(time.Minute))
+	assert.Equal(t, 0, checker.checks)
+
+	checker.redeemed[redeemed.SigningMsg()] = true
+	tracker.Check(context.Background(), start.Add(recheckInterval))
+	assert.Equal(t, 3, checker.checks)
+	require.Equal(t, 2, len(tracker.transfers))
+	assert.False(t, tracker.transfers[stuck.MessageID()].stuck)
+
+	tracker.Check(context.Background(), start.Add(6*time.Hour))
+	assert.True(t, tracker.transfers[stuck.MessageID()].stuck)
+	assert.True(t, tracker.transfers[unknownValue.MessageID()].stuck)
+	assert.Equal(t, float64(2), testutil.ToFloat64(metricStuck.WithLabelValues("bsc")))
+	assert.Equal(t, float64(7000), testutil.ToFloat64(metricStuckValue.WithLabelValues("bsc")))
+
+	// A stuck transfer is no longer stuck once redeemed.
+	checker.redeemed[stuck.SigningMsg()] = true
+	tracker.Check(context.Background(), start.Add(7*time.Hour))
+	assert.Equal(t, 1, len(tracker.transfers))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metricStuck.WithLabelValues("bsc")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metricStuckValue.WithLabelValues("bsc")))
+
+	// Transfers are forgotten after the maximum age.
+	tracker.Check(context.Background(), start.Add(25*time.Hour))
+	assert.Equal(t, 0, len(tracker.transfers))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metricUnredeemed.WithLabelValues("bsc")))
+}
+
+func TestTrackerRetriesFailedChecks(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	checker := &mockChecker{err: errors.New("rpc down")}
+	tracker := newTestTracker(checker, 0)
+	tracker.Track(transferVAA(t, 1, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, start), start)
+
+	tracker.Check(context.Background(), start.Add(recheckInterval))
+	assert.Equal(t, 1, len(tracker.transfers))
+
+	checker.err = nil
+	checker.redeemed = map[ethCommon.Hash]bool{transferVAA(t, 1, vaa.ChainIDEthereum, vaa.ChainIDBSC, 5000, start).SigningMsg(): true}
+	tracker.Check(context.Background(), start.Add(2*recheckInterval))
+	assert.Equal(t, 0, len(tracker.transfers))
+}
+
+func TestEvmRedemptionChecker(t *testing.T) {
+	server := mockchain.NewEVMServer(1337)
+	defer server.Close()
+
+	bridge := ethCommon.HexToAddress("0x0290fb167208af455bb137780163b7b7a9a10c16")
+	completed := ethCommon.HexToHash("0x01")
+	server.Handle("eth_call", func(params json.RawMessage) (interface{}, error) {
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		var call struct {
+			To    ethCommon.Address `json:"to"`
+			Input string            `json:"input"`
+			Data  string            `json:"data"`
+		}
+		if err := json.Unmarshal(args[0], &call); err != nil {
+			return nil, err
+		}
+		if call.To != bridge {
+			return nil, errors.New("unexpected contract")
+		}
+		input := call.Input
+		if input == "" {
+			input = call.Data
+		}
+		result := make([]byte, 32)
+		if strings.HasSuffix(input, hex.EncodeToString(completed.Bytes())) {
+			result[31] = 1
+		}
+		return "0x" + hex.EncodeToString(result), nil
+	})
+
+	c := NewEvmRedemptionChecker(server.URL, bridge)
+	ok, err := c.IsRedeemed(context.Background(), completed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.IsRedeemed(context.Background(), ethCommon.HexToHash("0x02"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	server.SetFault(mockchain.Fault{Status: 500})
+	_, err = c.IsRedeemed(context.Background(), completed)
+	assert.Error(t, err)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/certusone/wormhole/node/pkg/catchup"
 	"github.com/certusone/wormhole/node/pkg/common"
 	"github.com/certusone/wormhole/node/pkg/p2p"
 	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
 	"github.com/certusone/wormhole/node/pkg/readiness"
 	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/tracing"
 	"github.com/certusone/wormhole/node/pkg/vaa"
 	eth_common "github.com/ethereum/go-ethereum/common"
 	"github.com/gagliardetto/solana-go"
@@ -20,6 +23,7 @@ import (
 	"github.com/near/borsh-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
@@ -28,9 +32,11 @@ type SolanaWatcher struct {
 	wsUrl        string
 	rpcUrl       string
 	commitment   rpc.CommitmentType
-	messageEvent chan *common.MessagePublication
+	messageEvent chan<- *common.MessagePublication
 	obsvReqC     chan *gossipv1.ObservationRequest
 	rpcClient    *rpc.Client
+	// Optional client of a second RPC, which message accounts are checked against.
+	secondaryRpcClient *rpc.Client
 	// Readiness component
 	readiness readiness.Component
 	// VAA ChainID of the network we're connecting to.
@@ -109,7 +115,7 @@ type PostMessageData struct {
 func NewSolanaWatcher(
 	wsUrl, rpcUrl string,
 	contractAddress solana.PublicKey,
-	messageEvents chan *common.MessagePublication,
+	messageEvents chan<- *common.MessagePublication,
 	obsvReqC chan *gossipv1.ObservationRequest,
 	commitment rpc.CommitmentType,
 	readiness readiness.Component,
@@ -136,7 +142,7 @@ func (s *SolanaWatcher) Run(ctx context.Context) error {
 
 	logger := supervisor.Logger(ctx)
 	errC := make(chan error)
-	var lastSlot uint64
+	var slots *catchup.Scheduler
 
 	go func() {
 		timer := time.NewTicker(time.Second * 1)
@@ -170,8 +176,8 @@ func (s *SolanaWatcher) Run(ctx context.Context) error {
 					errC <- err
 					return
 				}
-				if lastSlot == 0 {
-					lastSlot = slot - 1
+				if slots == nil {
+					slots = catchup.NewScheduler(logger, fmt.Sprintf("%s_%s", s.networkName, s.commitment), slot-1, catchup.Config{})
 				}
 				currentSolanaHeight.WithLabelValues(s.networkName, string(s.commitment)).Set(float64(slot))
 				readiness.SetReady(s.readiness)
@@ -182,24 +188,23 @@ func (s *SolanaWatcher) Run(ctx context.Context) error {
 				logger.Info("fetched current Solana height",
 					zap.String("commitment", string(s.commitment)),
 					zap.Uint64("slot", slot),
-					zap.Uint64("lastSlot", lastSlot),
-					zap.Uint64("pendingSlots", slot-lastSlot),
+					zap.Uint64("lastSlot", slots.Processed()),
+					zap.Uint64("pendingSlots", slot-slots.Processed()),
 					zap.Duration("took", time.Since(start)))
 
-				rangeStart := lastSlot + 1
-				rangeEnd := slot
-
 				logger.Info("fetching slots in range",
-					zap.Uint64("from", rangeStart), zap.Uint64("to", rangeEnd),
+					zap.Uint64("from", slots.Processed()+1), zap.Uint64("to", slot),
 					zap.Duration("took", time.Since(start)),
 					zap.String("commitment", string(s.commitment)))
 
-				// Requesting each slot
-				for slot := rangeStart; slot <= rangeEnd; slot++ {
-					go s.retryFetchBlock(ctx, logger, slot, 0)
-				}
-
-				lastSlot = slot
+				// Requesting each slot. The blocks are fetched in the background, with retries, so the batches
+				// never fail.
+				_ = slots.CatchUp(ctx, slot, func(ctx context.Context, from uint64, to uint64) (uint64, error) {
+					for slot := from; slot <= to; slot++ {
+						go s.retryFetchBlock(ctx, logger, slot, 0)
+					}
+					return to, nil
+				})
 			}
 		}
 	}()
@@ -475,7 +480,8 @@ func (s *SolanaWatcher) fetchMessageAccount(ctx context.Context, logger *zap.Log
 		Encoding:   solana.EncodingBase64,
 		Commitment: s.commitment,
 	})
-	queryLatency.WithLabelValues(s.networkName, "get_account_info", string(s.commitment)).Observe(time.Since(start).Seconds())
+	fetched := time.Now()
+	queryLatency.WithLabelValues(s.networkName, "get_account_info", string(s.commitment)).Observe(fetched.Sub(start).Seconds())
 	if err != nil {
 		p2p.DefaultRegistry.AddErrorCount(s.chainID, 1)
 		solanaConnectionErrors.WithLabelValues(s.networkName, string(s.commitment), "get_account_info_error").Inc()
@@ -509,17 +515,26 @@ func (s *SolanaWatcher) fetchMessageAccount(ctx context.Context, logger *zap.Log
 		return false
 	}
 
+	if s.secondaryRpcClient != nil {
+		ok, retryable := s.verifyMessageAccount(ctx, logger, acc, slot, data)
+		if !ok {
+			return retryable
+		}
+	}
+
 	logger.Info("found valid VAA account",
 		zap.Uint64("slot", slot),
 		zap.String("commitment", string(s.commitment)),
 		zap.Stringer("account", acc),
 		zap.Binary("data", data))
 
-	s.processMessageAccount(logger, data, acc)
+	s.processMessageAccount(logger, data, acc, slot, start, fetched)
 	return false
 }
 
-func (s *SolanaWatcher) processMessageAccount(logger *zap.Logger, data []byte, acc solana.PublicKey) {
+// processMessageAccount parses a message account fetched between fetchStart and fetchEnd and publishes the message.
+func (s *SolanaWatcher) processMessageAccount(logger *zap.Logger, data []byte, acc solana.PublicKey, slot uint64, fetchStart time.Time, fetchEnd time.Time) {
+	parseStart := time.Now()
 	proposal, err := ParseMessagePublicationAccount(data)
 	if err != nil {
 		solanaAccountSkips.WithLabelValues(s.networkName, "parse_transfer_out").Inc()
@@ -543,10 +558,19 @@ func (s *SolanaWatcher) processMessageAccount(logger *zap.Logger, data []byte, a
 		EmitterAddress:   proposal.EmitterAddress,
 		Payload:          proposal.Payload,
 		ConsistencyLevel: proposal.ConsistencyLevel,
+		Provenance:       common.NewProvenance(s.rpcUrl, fetchEnd.Sub(fetchStart), strconv.FormatUint(slot, 10)),
 	}
 
 	solanaMessagesConfirmed.WithLabelValues(s.networkName).Inc()
 
+	attrs := []attribute.KeyValue{
+		attribute.String("account", acc.String()),
+		attribute.Int64("slot", int64(slot)),
+		attribute.String("commitment", string(s.commitment)),
+	}
+	tracing.Record(s.chainID, observation.MessageIDString(), "watcher.fetch", fetchStart, fetchEnd, attrs...)
+	tracing.Record(s.chainID, observation.MessageIDString(), "watcher.parse", parseStart, time.Now(), attrs...)
+
 	logger.Info("message observed",
 		zap.Stringer("account", acc),
 		zap.Time("timestamp", observation.Timestamp),
@@ -0,0 +1,108 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/testutils/consistency"
+	"github.com/certusone/wormhole/node/pkg/testutils/mockchain"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/near/borsh-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// postMessageBlock returns a block with a transaction posting a message to the contract, with the message account
+// at index 2.
+func postMessageBlock(t *testing.T, contract solana.PublicKey, messageAccount solana.PublicKey, level uint8) *rpc.GetConfirmedBlockResult {
+	data, err := borsh.Serialize(PostMessageData{Nonce: 7, Payload: []byte{1, 2, 3}, ConsistencyLevel: ConsistencyLevel(level)})
+	require.NoError(t, err)
+
+	keys := []solana.PublicKey{solana.MustPublicKeyFromBase58("11111111111111111111111111111111"), contract, messageAccount}
+	accounts := make([]uint16, postMessageInstructionNumAccounts)
+	accounts[1] = 2
+
+	return &rpc.GetConfirmedBlockResult{
+		Transactions: []rpc.TransactionWithMeta{{
+			Meta: &rpc.TransactionMeta{},
+			Transaction: &solana.Transaction{
+				Signatures: []solana.Signature{{1}},
+				Message: solana.Message{
+					AccountKeys: keys,
+					Instructions: []solana.CompiledInstruction{{
+						ProgramIDIndex: 1,
+						Accounts:       accounts,
+						Data:           append([]byte{postMessageInstructionID}, data...),
+					}},
+				},
+			},
+		}},
+	}
+}
+
+// messageAccountInfo returns the getAccountInfo result of the account of a message with the given consistency level.
+func messageAccountInfo(t *testing.T, contract solana.PublicKey, level uint8) map[string]interface{} {
+	data, err := borsh.Serialize(MessagePublicationAccount{ConsistencyLevel: level, Nonce: 7, Sequence: 42, EmitterChain: uint16(vaa.ChainIDSolana), Payload: []byte{1, 2, 3}})
+	require.NoError(t, err)
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value": map[string]interface{}{
+			"data":       []string{base64.StdEncoding.EncodeToString(append([]byte("msg"), data...)), "base64"},
+			"executable": false,
+			"lamports":   1,
+			"owner":      contract.String(),
+			"rentEpoch":  0,
+		},
+	}
+}
+
+// TestConsistencyLevelConformance checks that messages are only observed by the watcher of the documented commitment
+// level, for each consistency level.
+func TestConsistencyLevelConformance(t *testing.T) {
+	server := mockchain.NewSolanaServer()
+	defer server.Close()
+
+	contract := solana.MustPublicKeyFromBase58("worm2ZoG2kUd4vFXhvjh93UUH596ayRfgQ2MgjNMTth")
+	messageAccount := solana.MustPublicKeyFromBase58("SysvarC1ock11111111111111111111111111111111")
+
+	commitments := map[consistency.Finality]rpc.CommitmentType{
+		consistency.Safe:      rpc.CommitmentConfirmed,
+		consistency.Finalized: rpc.CommitmentFinalized,
+	}
+
+	for _, c := range consistency.Solana() {
+		t.Run(fmt.Sprintf("level %d", c.Level), func(t *testing.T) {
+			server.SetResult("getConfirmedBlock", postMessageBlock(t, contract, messageAccount, c.Level))
+			server.SetResult("getAccountInfo", messageAccountInfo(t, contract, c.Level))
+
+			for finality, commitment := range commitments {
+				msgC := make(chan *common.MessagePublication, 1)
+				s := NewSolanaWatcher("", server.URL, contract, msgC, nil, commitment, "", vaa.ChainIDSolana)
+
+				fetched := server.Requests("getAccountInfo")
+				require.True(t, s.fetchBlock(context.Background(), zap.NewNop(), 1, 0))
+
+				if c.Rejected || c.Finality != finality {
+					// The message account is only fetched by the watcher of the requested commitment level.
+					assert.Equal(t, fetched, server.Requests("getAccountInfo"), "observed at %s", commitment)
+					continue
+				}
+
+				select {
+				case msg := <-msgC:
+					assert.Equal(t, c.ObservedLevel, msg.ConsistencyLevel)
+					assert.Equal(t, uint64(42), msg.Sequence)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("message not observed at %s", commitment)
+				}
+			}
+		})
+	}
+}
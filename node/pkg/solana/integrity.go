@@ -0,0 +1,78 @@
+package solana
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var solanaAccountMismatches = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "wormhole_solana_account_data_mismatches_total",
+		Help: "Total number of message accounts whose data differs between the primary and the secondary Solana RPC",
+	}, []string{"solana_network", "commitment"})
+
+// WithSecondaryRPC makes the watcher fetch each message account from a second RPC, ideally of another provider, and
+// only publish the message if the hash of its data matches the one fetched from the primary RPC.
+func (s *SolanaWatcher) WithSecondaryRPC(rpcUrl string) *SolanaWatcher {
+	if rpcUrl != "" {
+		s.secondaryRpcClient = rpc.New(rpcUrl)
+	}
+	return s
+}
+
+// accountDataMatches returns whether two copies of the data of an account hash to the same value.
+func accountDataMatches(primary []byte, secondary []byte) bool {
+	return sha256.Sum256(primary) == sha256.Sum256(secondary)
+}
+
+// verifyMessageAccount fetches a message account from the secondary RPC and compares its data with the data fetched
+// from the primary RPC. It returns whether the account matches, and whether a failure is retryable.
+func (s *SolanaWatcher) verifyMessageAccount(ctx context.Context, logger *zap.Logger, acc solana.PublicKey, slot uint64, data []byte) (ok bool, retryable bool) {
+	rCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	start := time.Now()
+	info, err := s.secondaryRpcClient.GetAccountInfoWithOpts(rCtx, acc, &rpc.GetAccountInfoOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: s.commitment,
+	})
+	queryLatency.WithLabelValues(s.networkName, "secondary_get_account_info", string(s.commitment)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		// The secondary RPC may not have caught up with the slot yet.
+		solanaConnectionErrors.WithLabelValues(s.networkName, string(s.commitment), "secondary_get_account_info_error").Inc()
+		if errors.Is(err, rpc.ErrNotFound) {
+			logger.Warn("account not found on the secondary RPC",
+				zap.Uint64("slot", slot),
+				zap.String("commitment", string(s.commitment)),
+				zap.Stringer("account", acc))
+		} else {
+			logger.Error("failed to request account from the secondary RPC",
+				zap.Error(err),
+				zap.Uint64("slot", slot),
+				zap.String("commitment", string(s.commitment)),
+				zap.Stringer("account", acc))
+		}
+		return false, true
+	}
+
+	if !info.Value.Owner.Equals(s.contract) || !accountDataMatches(data, info.Value.Data.GetBinary()) {
+		solanaAccountMismatches.WithLabelValues(s.networkName, string(s.commitment)).Inc()
+		logger.Error("SECURITY ERROR: message account differs between the primary and the secondary RPC",
+			zap.Uint64("slot", slot),
+			zap.String("commitment", string(s.commitment)),
+			zap.Stringer("account", acc),
+			zap.Binary("primary_data", data),
+			zap.Binary("secondary_data", info.Value.Data.GetBinary()),
+			zap.Stringer("secondary_owner", info.Value.Owner))
+		return false, false
+	}
+
+	return true, false
+}
@@ -0,0 +1,76 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// accountServer serves getAccountInfo with the given owner and data, or no account if data is nil.
+func accountServer(t *testing.T, owner solana.PublicKey, data []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+
+		var value interface{}
+		if data != nil {
+			value = map[string]interface{}{
+				"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+				"executable": false,
+				"lamports":   1,
+				"owner":      owner.String(),
+				"rentEpoch":  0,
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": value},
+		})
+	}))
+}
+
+func TestVerifyMessageAccount(t *testing.T) {
+	contract := solana.MustPublicKeyFromBase58("worm2ZoG2kUd4vFXhvjh93UUH596ayRfgQ2MgjNMTth")
+	other := solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
+	acc := solana.MustPublicKeyFromBase58("SysvarC1ock11111111111111111111111111111111")
+	data := []byte("msg\x00\x01\x02")
+
+	tests := []struct {
+		name      string
+		owner     solana.PublicKey
+		data      []byte
+		ok        bool
+		retryable bool
+	}{
+		{name: "match", owner: contract, data: data, ok: true},
+		{name: "data mismatch", owner: contract, data: []byte("msg\x00\x01\x03")},
+		{name: "owner mismatch", owner: other, data: data},
+		{name: "not found", owner: contract, data: nil, retryable: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := accountServer(t, tc.owner, tc.data)
+			defer srv.Close()
+
+			s := NewSolanaWatcher("", "", contract, nil, nil, rpc.CommitmentFinalized, "", 1).WithSecondaryRPC(srv.URL)
+			ok, retryable := s.verifyMessageAccount(context.Background(), zap.NewNop(), acc, 1, data)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.retryable, retryable)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package spyclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+)
+
+// checkpoint keeps the highest sequence handled per emitter, in a JSON file mapping "<chain>/<emitter>" to the
+// sequence. Without path, it is kept in memory only, which still avoids missing VAAs across reconnections.
+type checkpoint struct {
+	path string
+
+	mu        sync.Mutex
+	sequences map[string]uint64
+	dirty     bool
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	c := &checkpoint{path: path, sequences: make(map[string]uint64)}
+	if path == "" {
+		return c, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read spy checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(b, &c.sequences); err != nil {
+		return nil, fmt.Errorf("failed to parse spy checkpoint: %w", err)
+	}
+	for key := range c.sequences {
+		if _, _, err := parseEmitterKey(key); err != nil {
+			return nil, fmt.Errorf("invalid spy checkpoint entry %q: %w", key, err)
+		}
+	}
+	return c, nil
+}
+
+func emitterKey(chain vaa.ChainID, addr vaa.Address) string {
+	return fmt.Sprintf("%d/%s", chain, addr)
+}
+
+func parseEmitterKey(key string) (vaa.ChainID, vaa.Address, error) {
+	var chain uint16
+	var addr string
+	if _, err := fmt.Sscanf(key, "%d/%s", &chain, &addr); err != nil {
+		return 0, vaa.Address{}, err
+	}
+	a, err := vaa.StringToAddress(addr)
+	if err != nil {
+		return 0, vaa.Address{}, err
+	}
+	return vaa.ChainID(chain), a, nil
+}
+
+func (c *checkpoint) handled(v *vaa.VAA) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := emitterKey(v.EmitterChain, v.EmitterAddress)
+	if seq, ok := c.sequences[key]; !ok || v.Sequence > seq {
+		c.sequences[key] = v.Sequence
+		c.dirty = true
+	}
+}
+
+// replayFrom returns the replay requests resuming after the handled sequences, sorted by emitter.
+func (c *checkpoint) replayFrom() []*spyv1.ReplayFrom {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.sequences))
+	for key := range c.sequences {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	res := make([]*spyv1.ReplayFrom, 0, len(keys))
+	for _, key := range keys {
+		chain, addr, _ := parseEmitterKey(key)
+		res = append(res, &spyv1.ReplayFrom{
+			ChainId:        publicrpcv1.ChainID(chain),
+			EmitterAddress: addr.String(),
+			Sequence:       c.sequences[key] + 1,
+		})
+	}
+	return res
+}
+
+// write writes the checkpoint to its file if it changed, replacing the file atomically.
+func (c *checkpoint) write() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	b, err := json.Marshal(c.sequences)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
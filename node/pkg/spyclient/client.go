@@ -0,0 +1,346 @@
+// Package spyclient consumes the signed VAAs of a spy. It reconnects with a backoff when the stream breaks, delivers
+// each VAA once even though the spy forwards it from several guardians, and keeps a checkpoint of the last sequence
+// handled per emitter, so that a spy with a persistent store replays the VAAs missed while disconnected:
+//
+//	c, err := spyclient.New(logger, spyclient.Config{
+//		Addr:           "localhost:7073",
+//		Filters:        []*spyv1.FilterEntry{...},
+//		CheckpointPath: "/var/lib/relayer/spy-checkpoint.json",
+//	})
+//	err = c.Run(ctx, func(ctx context.Context, v *vaa.VAA) error { ... })
+//
+// VAAs are delivered at least once: after a crash, the VAAs handled since the last checkpoint was written are delivered
+// again. Handlers must be idempotent.
+package spyclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultDedupeSize         = 10000
+	defaultMinBackoff         = time.Second
+	defaultMaxBackoff         = time.Minute
+	defaultCheckpointInterval = time.Second
+)
+
+type Config struct {
+	// Address of the gRPC endpoint of the spy.
+	Addr string
+	// Filters of the subscription. A VAA is delivered if it matches any of the filters, or if there are none.
+	Filters []*spyv1.FilterEntry
+	// File keeping the last sequence handled per emitter, optional. Without checkpoint, the VAAs signed while
+	// disconnected are missed.
+	CheckpointPath string
+	// Number of recent VAAs remembered to drop the duplicates, 10000 by default.
+	DedupeSize int
+	// Delays between reconnections, doubled after each failed attempt. 1s and 1m by default.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Interval between writes of the checkpoint, 1s by default.
+	CheckpointInterval time.Duration
+	// Options of the connection to the spy, insecure by default.
+	DialOptions []grpc.DialOption
+}
+
+// Handler handles a signed VAA. The VAA is only checkpointed once the handler succeeded. If it fails, Run returns the
+// error.
+type Handler func(ctx context.Context, v *vaa.VAA) error
+
+type Client struct {
+	logger     *zap.Logger
+	cfg        Config
+	checkpoint *checkpoint
+	seen       *dedupe
+
+	// Set once the spy rejected the message filters, see subscribeRequest.
+	legacyFilters bool
+	// Set once the spy rejected the replay, because it runs without persistent store.
+	noReplay bool
+}
+
+// New returns a client of the spy at cfg.Addr, loading the checkpoint if there is one.
+func New(logger *zap.Logger, cfg Config) (*Client, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("the address of the spy must be set")
+	}
+	if cfg.DedupeSize == 0 {
+		cfg.DedupeSize = defaultDedupeSize
+	}
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = defaultMinBackoff
+	}
+	if cfg.MaxBackoff == 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		return nil, errors.New("the maximum backoff must not be less than the minimum backoff")
+	}
+	if cfg.CheckpointInterval == 0 {
+		cfg.CheckpointInterval = defaultCheckpointInterval
+	}
+	if cfg.DialOptions == nil {
+		cfg.DialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	cp, err := loadCheckpoint(cfg.CheckpointPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		logger:     logger,
+		cfg:        cfg,
+		checkpoint: cp,
+		seen:       newDedupe(cfg.DedupeSize),
+	}, nil
+}
+
+// Run subscribes to the spy and calls handler with each new VAA, until ctx is canceled or the handler fails. It
+// reconnects when the stream breaks, and only returns the errors of the spy which retrying won't fix, like invalid
+// filters.
+func (c *Client) Run(ctx context.Context, handler Handler) error {
+	conn, err := grpc.DialContext(ctx, c.cfg.Addr, c.cfg.DialOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to spy: %w", err)
+	}
+	defer conn.Close()
+	client := spyv1.NewSpyRPCServiceClient(conn)
+
+	// Write the checkpoint periodically rather than after each VAA.
+	flush := time.NewTicker(c.cfg.CheckpointInterval)
+	defer flush.Stop()
+	defer c.flushCheckpoint()
+
+	backoff := c.cfg.MinBackoff
+	for {
+		received, err := c.subscribe(ctx, client, handler, flush.C)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var herr handlerError
+		if errors.As(err, &herr) {
+			return herr.err
+		}
+
+		switch status.Code(err) {
+		case codes.InvalidArgument:
+			if !c.legacyFilters && strings.Contains(status.Convert(err).Message(), "unsupported filter type") {
+				c.logger.Warn("spy does not support message filters, filtering by emitter on the spy and the rest locally")
+				c.legacyFilters = true
+				continue
+			}
+			return fmt.Errorf("spy rejected the subscription: %w", err)
+		case codes.FailedPrecondition:
+			if !c.noReplay {
+				c.logger.Warn("spy cannot replay missed VAAs, subscribing to new VAAs only", zap.Error(err))
+				c.noReplay = true
+				continue
+			}
+		}
+
+		if received {
+			backoff = c.cfg.MinBackoff
+		}
+		c.logger.Warn("spy subscription failed, reconnecting", zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > c.cfg.MaxBackoff {
+			backoff = c.cfg.MaxBackoff
+		}
+	}
+}
+
+// handlerError wraps the errors of the handler, which end Run.
+type handlerError struct {
+	err error
+}
+
+func (e handlerError) Error() string {
+	return e.err.Error()
+}
+
+// subscribe consumes one subscription until it breaks. It returns whether any VAA was received.
+func (c *Client) subscribe(ctx context.Context, client spyv1.SpyRPCServiceClient, handler Handler, flush <-chan time.Time) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.SubscribeSignedVAA(ctx, c.subscribeRequest())
+	if err != nil {
+		return false, err
+	}
+
+	type result struct {
+		resp *spyv1.SubscribeSignedVAAResponse
+		err  error
+	}
+	recvC := make(chan result)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			select {
+			case recvC <- result{resp, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	received := false
+	for {
+		select {
+		case <-ctx.Done():
+			return received, ctx.Err()
+		case <-flush:
+			c.flushCheckpoint()
+		case r := <-recvC:
+			if r.err != nil {
+				return received, r.err
+			}
+			received = true
+			if err := c.handle(ctx, r.resp.VaaBytes, handler); err != nil {
+				return received, err
+			}
+		}
+	}
+}
+
+func (c *Client) handle(ctx context.Context, b []byte, handler Handler) error {
+	v, err := vaa.Unmarshal(b)
+	if err != nil {
+		c.logger.Warn("dropping invalid VAA received from spy", zap.Error(err))
+		return nil
+	}
+	if c.legacyFilters && !matchesAny(c.cfg.Filters, v) {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%x", v.MessageID(), v.SigningMsg().Bytes())
+	if c.seen.contains(key) {
+		return nil
+	}
+
+	if err := handler(ctx, v); err != nil {
+		return handlerError{err}
+	}
+	c.seen.add(key)
+	c.checkpoint.handled(v)
+	return nil
+}
+
+func (c *Client) flushCheckpoint() {
+	if err := c.checkpoint.write(); err != nil {
+		c.logger.Error("failed to write spy checkpoint", zap.Error(err))
+	}
+}
+
+// subscribeRequest returns the request of the next subscription. Spies predating message filters reject them, in which
+// case the message filters are replaced by the emitter filters they contain, or by no filter at all, and the VAAs are
+// filtered locally instead.
+func (c *Client) subscribeRequest() *spyv1.SubscribeSignedVAARequest {
+	req := &spyv1.SubscribeSignedVAARequest{Filters: c.cfg.Filters}
+	if c.legacyFilters {
+		req.Filters = legacyFilters(c.cfg.Filters)
+	}
+	if !c.noReplay {
+		req.ReplayFrom = c.checkpoint.replayFrom()
+	}
+	return req
+}
+
+func legacyFilters(filters []*spyv1.FilterEntry) []*spyv1.FilterEntry {
+	var res []*spyv1.FilterEntry
+	for _, f := range filters {
+		switch t := f.Filter.(type) {
+		case *spyv1.FilterEntry_EmitterFilter:
+			res = append(res, f)
+		case *spyv1.FilterEntry_MessageFilter:
+			if len(t.MessageFilter.Emitters) == 0 {
+				// Can't be narrowed down by emitter, so every VAA is needed.
+				return nil
+			}
+			for _, e := range t.MessageFilter.Emitters {
+				res = append(res, &spyv1.FilterEntry{Filter: &spyv1.FilterEntry_EmitterFilter{EmitterFilter: e}})
+			}
+		}
+	}
+	return res
+}
+
+func matchesAny(filters []*spyv1.FilterEntry, v *vaa.VAA) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		switch t := f.Filter.(type) {
+		case *spyv1.FilterEntry_EmitterFilter:
+			if matchesEmitter(t.EmitterFilter, v) {
+				return true
+			}
+		case *spyv1.FilterEntry_MessageFilter:
+			if matchesMessage(t.MessageFilter, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesEmitter(f *spyv1.EmitterFilter, v *vaa.VAA) bool {
+	return vaa.ChainID(f.ChainId) == v.EmitterChain && strings.EqualFold(f.EmitterAddress, v.EmitterAddress.String())
+}
+
+func matchesMessage(f *spyv1.MessageFilter, v *vaa.VAA) bool {
+	if len(f.ChainIds) != 0 && !containsChain(f.ChainIds, v.EmitterChain) {
+		return false
+	}
+	if len(f.Emitters) != 0 {
+		ok := false
+		for _, e := range f.Emitters {
+			ok = ok || matchesEmitter(e, v)
+		}
+		if !ok {
+			return false
+		}
+	}
+	if v.Sequence < f.MinSequence || f.MaxSequence != 0 && v.Sequence > f.MaxSequence {
+		return false
+	}
+	if len(f.PayloadPrefixes) != 0 {
+		for _, p := range f.PayloadPrefixes {
+			if bytes.HasPrefix(v.Payload, p) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsChain(chains []publicrpcv1.ChainID, c vaa.ChainID) bool {
+	for _, id := range chains {
+		if vaa.ChainID(id) == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package spyclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	publicrpcv1 "github.com/certusone/wormhole/node/pkg/proto/publicrpc/v1"
+	spyv1 "github.com/certusone/wormhole/node/pkg/proto/spy/v1"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSpy serves the VAAs of its sessions, one session per subscription, then keeps the last subscription open.
+type fakeSpy struct {
+	spyv1.UnimplementedSpyRPCServiceServer
+	// Whether message filters are rejected, like spies predating them.
+	legacy   bool
+	sessions [][]*vaa.VAA
+
+	mu       sync.Mutex
+	requests []*spyv1.SubscribeSignedVAARequest
+}
+
+func (s *fakeSpy) SubscribeSignedVAA(req *spyv1.SubscribeSignedVAARequest, resp spyv1.SpyRPCService_SubscribeSignedVAAServer) error {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	n := len(s.requests)
+	s.mu.Unlock()
+
+	if s.legacy {
+		for _, f := range req.Filters {
+			if f.GetMessageFilter() != nil {
+				return status.Error(codes.InvalidArgument, "unsupported filter type")
+			}
+		}
+	}
+
+	if n <= len(s.sessions) {
+		for _, v := range s.sessions[n-1] {
+			b, err := v.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := resp.Send(&spyv1.SubscribeSignedVAAResponse{VaaBytes: b}); err != nil {
+				return err
+			}
+		}
+		if n < len(s.sessions) {
+			return status.Error(codes.Unavailable, "spy restarting")
+		}
+	}
+	<-resp.Context().Done()
+	return nil
+}
+
+func (s *fakeSpy) request(i int) *spyv1.SubscribeSignedVAARequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[i]
+}
+
+func startFakeSpy(t *testing.T, s *fakeSpy) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	spyv1.RegisterSpyRPCServiceServer(srv, s)
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func testVAA(chain vaa.ChainID, emitter byte, sequence uint64) *vaa.VAA {
+	return &vaa.VAA{
+		Version:          1,
+		Timestamp:        time.Unix(1_700_000_000, 0),
+		EmitterChain:     chain,
+		EmitterAddress:   vaa.Address{emitter},
+		Sequence:         sequence,
+		Payload:          []byte{1, 2, 3},
+		ConsistencyLevel: 1,
+	}
+}
+
+// collect runs c until n VAAs are handled, and returns them.
+func collect(t *testing.T, c *Client, n int) []*vaa.VAA {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var res []*vaa.VAA
+	err := c.Run(ctx, func(ctx context.Context, v *vaa.VAA) error {
+		res = append(res, v)
+		if len(res) == n {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, res, n)
+	return res
+}
+
+func sequences(vaas []*vaa.VAA) []uint64 {
+	var res []uint64
+	for _, v := range vaas {
+		res = append(res, v.Sequence)
+	}
+	return res
+}
+
+func TestClientReconnectsAndResumes(t *testing.T) {
+	spy := &fakeSpy{sessions: [][]*vaa.VAA{
+		// The same VAA is forwarded twice, then the stream breaks.
+		{testVAA(vaa.ChainIDEthereum, 1, 1), testVAA(vaa.ChainIDEthereum, 1, 1), testVAA(vaa.ChainIDEthereum, 1, 2)},
+		{testVAA(vaa.ChainIDEthereum, 1, 2), testVAA(vaa.ChainIDEthereum, 1, 3)},
+	}}
+	addr := startFakeSpy(t, spy)
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	c, err := New(zap.NewNop(), Config{Addr: addr, CheckpointPath: path, MinBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, sequences(collect(t, c, 3)))
+
+	// The second subscription resumed after the last sequence handled.
+	replay := spy.request(1).ReplayFrom
+	require.Len(t, replay, 1)
+	assert.Equal(t, uint64(3), replay[0].Sequence)
+	assert.Equal(t, publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, replay[0].ChainId)
+	assert.Equal(t, vaa.Address{1}.String(), replay[0].EmitterAddress)
+
+	// The checkpoint is written when Run returns, and loaded by the next client.
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"2/0100000000000000000000000000000000000000000000000000000000000000": 3}`, string(b))
+
+	c, err = New(zap.NewNop(), Config{Addr: addr, CheckpointPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), c.subscribeRequest().ReplayFrom[0].Sequence)
+}
+
+func TestClientLegacyFilters(t *testing.T) {
+	spy := &fakeSpy{legacy: true, sessions: [][]*vaa.VAA{
+		nil,
+		{testVAA(vaa.ChainIDEthereum, 1, 4), testVAA(vaa.ChainIDEthereum, 1, 5), testVAA(vaa.ChainIDSolana, 2, 1)},
+	}}
+	addr := startFakeSpy(t, spy)
+
+	filters := []*spyv1.FilterEntry{
+		{Filter: &spyv1.FilterEntry_MessageFilter{MessageFilter: &spyv1.MessageFilter{
+			Emitters:    []*spyv1.EmitterFilter{{ChainId: publicrpcv1.ChainID_CHAIN_ID_ETHEREUM, EmitterAddress: vaa.Address{1}.String()}},
+			MinSequence: 5,
+		}}},
+		{Filter: &spyv1.FilterEntry_EmitterFilter{EmitterFilter: &spyv1.EmitterFilter{ChainId: publicrpcv1.ChainID_CHAIN_ID_SOLANA, EmitterAddress: vaa.Address{2}.String()}}},
+	}
+	c, err := New(zap.NewNop(), Config{Addr: addr, Filters: filters, MinBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	// The minimum sequence is applied locally.
+	assert.Equal(t, []uint64{5, 1}, sequences(collect(t, c, 2)))
+	assert.Len(t, spy.request(1).Filters, 2)
+	assert.NotNil(t, spy.request(1).Filters[0].GetEmitterFilter())
+}
+
+func TestDedupe(t *testing.T) {
+	d := newDedupe(2)
+	d.add("a")
+	d.add("b")
+	assert.True(t, d.contains("a"))
+	d.add("c")
+	assert.False(t, d.contains("a"))
+	assert.True(t, d.contains("b"))
+	assert.True(t, d.contains("c"))
+}
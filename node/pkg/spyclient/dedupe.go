@@ -0,0 +1,28 @@
+package spyclient
+
+// dedupe remembers the last size keys added.
+type dedupe struct {
+	keys map[string]bool
+	ring []string
+	next int
+}
+
+func newDedupe(size int) *dedupe {
+	return &dedupe{keys: make(map[string]bool, size), ring: make([]string, size)}
+}
+
+func (d *dedupe) contains(key string) bool {
+	return d.keys[key]
+}
+
+func (d *dedupe) add(key string) {
+	if d.keys[key] {
+		return
+	}
+	if old := d.ring[d.next]; old != "" {
+		delete(d.keys, old)
+	}
+	d.ring[d.next] = key
+	d.keys[key] = true
+	d.next = (d.next + 1) % len(d.ring)
+}
@@ -0,0 +1,207 @@
+// Package statuspage serves a read-only HTML page summarizing the state of the node from memory: the height and the
+// last observation of each watcher, the peer counts and the governor status. It gives operators a glanceable dashboard
+// without a Prometheus and Grafana setup.
+//
+// The page only shows information which is already public through the heartbeats and the public RPC, so it is safe to
+// expose to untrusted clients.
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/governor"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	"github.com/certusone/wormhole/node/pkg/readiness"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/certusone/wormhole/node/pkg/version"
+)
+
+// Page renders the status page. The time of the last message observed on each chain is recorded by Run.
+type Page struct {
+	startTime time.Time
+
+	mu               sync.Mutex
+	lastObservations map[vaa.ChainID]time.Time
+	gov              *governor.ChainGovernor
+}
+
+func New() *Page {
+	return &Page{
+		startTime:        time.Now(),
+		lastObservations: make(map[vaa.ChainID]time.Time),
+	}
+}
+
+// SetGovernor sets the governor whose status is shown, if it is enabled.
+func (p *Page) SetGovernor(gov *governor.ChainGovernor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gov = gov
+}
+
+// Run records the time at which the messages of msgC are observed.
+func (p *Page) Run(msgC <-chan *common.MessagePublication) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case k := <-msgC:
+				p.mu.Lock()
+				p.lastObservations[k.EmitterChain] = time.Now()
+				p.mu.Unlock()
+			}
+		}
+	}
+}
+
+type watcherRow struct {
+	Chain           vaa.ChainID
+	Height          int64
+	Paused          bool
+	Errors          uint64
+	LastObservation time.Time
+}
+
+type governorRow struct {
+	Chain              vaa.ChainID
+	RemainingNotional  uint64
+	NotionalLimit      uint64
+	BigTransactionSize uint64
+	EnqueuedVAAs       uint32
+	EnqueuedNotional   uint64
+}
+
+type readinessRow struct {
+	Name  string
+	Ready bool
+}
+
+type pageData struct {
+	Now             time.Time
+	Version         string
+	GuardianAddress string
+	Uptime          time.Duration
+	ConnectedPeers  int
+	GossipPeers     int
+	Readiness       []readinessRow
+	Watchers        []watcherRow
+	GovernorEnabled bool
+	Governor        []governorRow
+}
+
+func (p *Page) data(now time.Time) *pageData {
+	connected, gossip := p2p.DefaultRegistry.PeerCounts()
+	d := &pageData{
+		Now:             now,
+		Version:         version.Version(),
+		GuardianAddress: p2p.DefaultRegistry.GuardianAddress(),
+		Uptime:          now.Sub(p.startTime).Truncate(time.Second),
+		ConnectedPeers:  connected,
+		GossipPeers:     gossip,
+	}
+
+	for name, ready := range readiness.Status() {
+		d.Readiness = append(d.Readiness, readinessRow{Name: name, Ready: ready})
+	}
+	sort.Slice(d.Readiness, func(i, j int) bool { return d.Readiness[i].Name < d.Readiness[j].Name })
+
+	p.mu.Lock()
+	gov := p.gov
+	for _, n := range p2p.DefaultRegistry.NetworkStats() {
+		chain := vaa.ChainID(n.Id)
+		d.Watchers = append(d.Watchers, watcherRow{
+			Chain:           chain,
+			Height:          n.Height,
+			Paused:          n.Paused,
+			Errors:          n.ErrorCount,
+			LastObservation: p.lastObservations[chain],
+		})
+	}
+	p.mu.Unlock()
+	sort.Slice(d.Watchers, func(i, j int) bool { return d.Watchers[i].Chain < d.Watchers[j].Chain })
+
+	if gov != nil {
+		d.GovernorEnabled = true
+		for _, e := range gov.GetStatus().Entries {
+			d.Governor = append(d.Governor, governorRow{
+				Chain:              vaa.ChainID(e.ChainId),
+				RemainingNotional:  e.RemainingAvailableNotional,
+				NotionalLimit:      e.NotionalLimit,
+				BigTransactionSize: e.BigTransactionSize,
+				EnqueuedVAAs:       e.EnqueuedVaas,
+				EnqueuedNotional:   e.EnqueuedNotional,
+			})
+		}
+	}
+
+	return d
+}
+
+// ago formats the time elapsed since t, rounded to the second.
+func ago(now time.Time, t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Truncate(time.Second).String() + " ago"
+}
+
+var pageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{"ago": ago}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>guardiand status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.bad { color: #b00; }
+</style>
+</head>
+<body>
+<h1>guardiand {{.Version}}</h1>
+<p>Guardian {{.GuardianAddress}}, up for {{.Uptime}}. {{.ConnectedPeers}} connected peers, {{.GossipPeers}} gossip peers.</p>
+
+<h2>Readiness</h2>
+<table>
+<tr><th>Component</th><th>Ready</th></tr>
+{{range .Readiness}}<tr><td>{{.Name}}</td><td{{if not .Ready}} class="bad"{{end}}>{{.Ready}}</td></tr>
+{{end}}</table>
+
+<h2>Watchers</h2>
+<table>
+<tr><th>Chain</th><th>Height</th><th>Last observation</th><th>Errors</th><th>Paused</th></tr>
+{{range .Watchers}}<tr><td>{{.Chain}}</td><td>{{.Height}}</td><td>{{ago $.Now .LastObservation}}</td><td>{{.Errors}}</td><td{{if .Paused}} class="bad"{{end}}>{{.Paused}}</td></tr>
+{{end}}</table>
+
+<h2>Governor</h2>
+{{if .GovernorEnabled}}<table>
+<tr><th>Chain</th><th>Remaining notional</th><th>Daily limit</th><th>Big transaction size</th><th>Enqueued VAAs</th><th>Enqueued notional</th></tr>
+{{range .Governor}}<tr><td>{{.Chain}}</td><td>{{.RemainingNotional}}</td><td>{{.NotionalLimit}}</td><td>{{.BigTransactionSize}}</td><td>{{.EnqueuedVAAs}}</td><td>{{.EnqueuedNotional}}</td></tr>
+{{end}}</table>
+{{else}}<p>The governor is disabled.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (p *Page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, p.data(time.Now())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,57 @@
+package statuspage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/common"
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	gossipv1 "github.com/certusone/wormhole/node/pkg/proto/gossip/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p2p.DefaultRegistry.SetNetworkStats(vaa.ChainIDSolana, &gossipv1.Heartbeat_Network{Height: 1234})
+	p2p.DefaultRegistry.SetNetworkStats(vaa.ChainIDEthereum, &gossipv1.Heartbeat_Network{Height: 5678})
+
+	page := New()
+	msgC := make(chan *common.MessagePublication)
+	supervisor.New(ctx, zap.NewNop(), page.Run(msgC))
+	msgC <- &common.MessagePublication{EmitterChain: vaa.ChainIDEthereum}
+
+	// The message is recorded once the next one is received.
+	msgC <- &common.MessagePublication{EmitterChain: vaa.ChainIDEthereum}
+	d := page.data(time.Now())
+	require.Equal(t, 2, len(d.Watchers))
+	assert.Equal(t, vaa.ChainIDSolana, d.Watchers[0].Chain)
+	assert.True(t, d.Watchers[0].LastObservation.IsZero())
+	assert.Equal(t, vaa.ChainIDEthereum, d.Watchers[1].Chain)
+	assert.Equal(t, int64(5678), d.Watchers[1].Height)
+	assert.False(t, d.Watchers[1].LastObservation.IsZero())
+	assert.False(t, d.GovernorEnabled)
+
+	rec := httptest.NewRecorder()
+	page.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "<td>solana</td><td>1234</td><td>never</td>")
+	assert.Contains(t, body, "<td>ethereum</td><td>5678</td>")
+	assert.Contains(t, body, "The governor is disabled.")
+}
+
+func TestAgo(t *testing.T) {
+	now := time.Unix(1000, 0)
+	assert.Equal(t, "never", ago(now, time.Time{}))
+	assert.Equal(t, "1m30s ago", ago(now, now.Add(-90*time.Second-time.Millisecond)))
+}
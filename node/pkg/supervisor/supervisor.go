@@ -22,17 +22,17 @@ type Runnable func(ctx context.Context) error
 // unexpectedly, the result will be canceled and restarted.
 // The context here must be an existing Runnable context, and the spawned runnables will run under the node that this
 // context represents.
-func RunGroup(ctx context.Context, runnables map[string]Runnable) error {
+func RunGroup(ctx context.Context, runnables map[string]Runnable, opts ...RunOpt) error {
 	node, unlock := fromContext(ctx)
 	defer unlock()
-	return node.runGroup(runnables)
+	return node.runGroup(runnables, opts...)
 }
 
 // Run starts a single runnable in its own group.
-func Run(ctx context.Context, name string, runnable Runnable) error {
+func Run(ctx context.Context, name string, runnable Runnable, opts ...RunOpt) error {
 	return RunGroup(ctx, map[string]Runnable{
 		name: runnable,
-	})
+	}, opts...)
 }
 
 // Signal tells the supervisor that the calling runnable has reached a certain state of its lifecycle. All runnables
@@ -102,12 +102,12 @@ func New(ctx context.Context, logger *zap.Logger, rootRunnable Runnable, opts ..
 		pReq:    make(chan *processorRequest),
 	}
 
+	sup.root = newNode("root", rootRunnable, sup, nil)
+
 	for _, o := range opts {
 		o(sup)
 	}
 
-	sup.root = newNode("root", rootRunnable, sup, nil)
-
 	go sup.processor(ctx)
 
 	sup.pReq <- &processorRequest{
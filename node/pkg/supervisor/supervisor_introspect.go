@@ -0,0 +1,101 @@
+package supervisor
+
+// Introspection of the supervision tree, so that operators can see which runnables are flapping.
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeStatus is the state of a runnable in the supervision tree.
+type NodeStatus struct {
+	// Distinguished name of the runnable, such as root.ethwatch.watcher.
+	DN string `json:"dn"`
+	// One of new, healthy, done, dead, canceled, or pending while waiting to be restarted.
+	State string `json:"state"`
+	// Number of times the runnable died and was restarted since it was started by its parent.
+	Restarts int `json:"restarts"`
+	// The last error returned by the runnable, if any.
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorTime time.Time `json:"lastErrorTime"`
+	// When the runnable was started, zero if it is not running.
+	Started time.Time `json:"started"`
+	// Whether the runnable is not being restarted because it failed too often, see RestartPolicy.
+	CircuitOpen bool `json:"circuitOpen"`
+}
+
+// Introspector reports the state of the supervision tree of the supervisor it is passed to with WithIntrospector.
+// It can be created before the supervisor, for instance to be passed to the runnables.
+type Introspector struct {
+	mu  sync.Mutex
+	sup *supervisor
+}
+
+func NewIntrospector() *Introspector {
+	return &Introspector{}
+}
+
+// WithIntrospector attaches the given introspector to the supervisor.
+func WithIntrospector(i *Introspector) SupervisorOpt {
+	return func(s *supervisor) {
+		i.mu.Lock()
+		i.sup = s
+		i.mu.Unlock()
+	}
+}
+
+// Status returns the state of each runnable, sorted by DN. It is empty until the supervisor is created.
+func (i *Introspector) Status() []NodeStatus {
+	i.mu.Lock()
+	sup := i.sup
+	i.mu.Unlock()
+	if sup == nil {
+		return nil
+	}
+
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+
+	var res []NodeStatus
+	queue := []*node{sup.root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range n.children {
+			queue = append(queue, c)
+		}
+		res = append(res, n.status())
+	}
+
+	sort.Slice(res, func(a, b int) bool { return res[a].DN < res[b].DN })
+	return res
+}
+
+// ServeHTTP serves the state of the runnables as JSON.
+func (i *Introspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(i.Status())
+}
+
+func (n *node) status() NodeStatus {
+	st := NodeStatus{
+		DN:            n.dn(),
+		State:         strings.ToLower(strings.TrimPrefix(n.state.String(), "NODE_STATE_")),
+		Restarts:      n.restartCount,
+		LastError:     n.lastError,
+		LastErrorTime: n.lastErrorTime,
+		CircuitOpen:   n.circuitOpen,
+	}
+
+	switch {
+	case n.state == nodeStateNew && n.started.IsZero():
+		st.State = "pending"
+	case n.state == nodeStateNew || n.state == nodeStateHealthy || n.state == nodeStateDone:
+		st.Started = n.started
+	}
+	return st
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"go.uber.org/zap"
@@ -34,6 +35,19 @@ type node struct {
 
 	// Backoff used to keep runnables from being restarted too fast.
 	bo *backoff.ExponentialBackOff
+	// Restart policy set with WithRestartPolicy, or nil to use the default backoff.
+	policy *RestartPolicy
+	// When the runnable was last started, zero while it is waiting to be started, and the recent restarts counted
+	// by the policy's circuit breaker.
+	started  time.Time
+	restarts []time.Time
+	// Set while the runnable is not restarted because the policy's circuit breaker is open.
+	circuitOpen bool
+
+	// Reported by Introspector.
+	restartCount  int
+	lastError     string
+	lastErrorTime time.Time
 
 	// Context passed to the runnable, and its cancel function.
 	ctx  context.Context
@@ -171,6 +185,7 @@ func (n *node) reset() {
 
 	// Clear children and state
 	n.state = nodeStateNew
+	n.started = time.Time{}
 	n.children = make(map[string]*node)
 	n.groups = nil
 
@@ -203,7 +218,7 @@ func (s *supervisor) nodeByDN(dn string) *node {
 var reNodeName = regexp.MustCompile(`[a-z90-9_]{1,64}`)
 
 // runGroup schedules a new group of runnables to run on a node.
-func (n *node) runGroup(runnables map[string]Runnable) error {
+func (n *node) runGroup(runnables map[string]Runnable, opts ...RunOpt) error {
 	// Check that the parent node is in the right state.
 	if n.state != nodeStateNew {
 		return fmt.Errorf("cannot run new runnable on non-NEW node")
@@ -227,6 +242,9 @@ func (n *node) runGroup(runnables map[string]Runnable) error {
 			return fmt.Errorf("duplicate child name %q", name)
 		}
 		node := newNode(name, runnable, n.sup, n)
+		for _, o := range opts {
+			o(node)
+		}
 		n.children[name] = node
 
 		dns[name] = node.dn()
@@ -256,13 +274,17 @@ func (n *node) signal(signal SignalType) {
 			panic(fmt.Errorf("node %s signaled healthy", n))
 		}
 		n.state = nodeStateHealthy
-		n.bo.Reset()
+		if n.policy == nil {
+			n.bo.Reset()
+		}
 	case SignalDone:
 		if n.state != nodeStateHealthy {
 			panic(fmt.Errorf("node %s signaled done", n))
 		}
 		n.state = nodeStateDone
-		n.bo.Reset()
+		if n.policy == nil {
+			n.bo.Reset()
+		}
 	}
 }
 
@@ -0,0 +1,105 @@
+package supervisor
+
+// Restart policies allow runnables that fail repeatedly, such as watchers connected to a dead RPC node, to be restarted
+// less eagerly than the default backoff does, and to stop being restarted for a while once they fail too often.
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	restartsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_supervisor_restarts_total",
+			Help: "Total number of restarts of supervised runnables after they died",
+		}, []string{"runnable"})
+	circuitOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_supervisor_circuit_open",
+			Help: "Whether a supervised runnable is not being restarted because it failed too often",
+		}, []string{"runnable"})
+)
+
+// RestartPolicy configures how a runnable is restarted after it died. Zero fields use the values of
+// DefaultRestartPolicy, except MaxRestarts, where zero disables the circuit breaker.
+type RestartPolicy struct {
+	// The delay before the first restart, which doubles after each consecutive failure up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// The delay is only reset once the runnable ran for ResetAfter. Unlike the default backoff, it is not reset when
+	// the runnable signals healthy, since runnables may do so before they fail.
+	ResetAfter time.Duration
+
+	// If the runnable dies more than MaxRestarts times within Window, the circuit breaker opens and the runnable is not
+	// restarted for BreakDuration.
+	MaxRestarts   int
+	Window        time.Duration
+	BreakDuration time.Duration
+}
+
+// DefaultRestartPolicy provides the defaults for the zero fields of a RestartPolicy.
+var DefaultRestartPolicy = RestartPolicy{
+	InitialBackoff: time.Second,
+	MaxBackoff:     5 * time.Minute,
+	ResetAfter:     10 * time.Minute,
+	Window:         10 * time.Minute,
+	BreakDuration:  15 * time.Minute,
+}
+
+// RunOpt are options for the runnables started with Run and RunGroup.
+type RunOpt func(n *node)
+
+// WithRestartPolicy restarts the runnables according to the given policy instead of the default backoff.
+func WithRestartPolicy(p RestartPolicy) RunOpt {
+	p = p.withDefaults()
+	return func(n *node) {
+		bo := backoff.NewExponentialBackOff()
+		bo.InitialInterval = p.InitialBackoff
+		bo.MaxInterval = p.MaxBackoff
+		bo.MaxElapsedTime = 0
+		bo.Reset()
+
+		n.policy = &p
+		n.bo = bo
+	}
+}
+
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = DefaultRestartPolicy.InitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = DefaultRestartPolicy.MaxBackoff
+	}
+	if p.ResetAfter == 0 {
+		p.ResetAfter = DefaultRestartPolicy.ResetAfter
+	}
+	if p.Window == 0 {
+		p.Window = DefaultRestartPolicy.Window
+	}
+	if p.BreakDuration == 0 {
+		p.BreakDuration = DefaultRestartPolicy.BreakDuration
+	}
+	return p
+}
+
+// allowRestart records a restart at t in the history of restarts and reports whether it is allowed. If it is not, the
+// history is cleared so that the runnable gets MaxRestarts more attempts after the break.
+func (p *RestartPolicy) allowRestart(history []time.Time, t time.Time) ([]time.Time, bool) {
+	recent := history[:0]
+	for _, r := range history {
+		if t.Sub(r) < p.Window {
+			recent = append(recent, r)
+		}
+	}
+	recent = append(recent, t)
+
+	if p.MaxRestarts > 0 && len(recent) > p.MaxRestarts {
+		return nil, false
+	}
+	return recent, true
+}
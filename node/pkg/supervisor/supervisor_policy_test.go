@@ -0,0 +1,94 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAllowRestart(t *testing.T) {
+	p := RestartPolicy{MaxRestarts: 2, Window: time.Minute}
+	start := time.Unix(1000, 0)
+
+	history, ok := p.allowRestart(nil, start)
+	assert.True(t, ok)
+	history, ok = p.allowRestart(history, start.Add(10*time.Second))
+	assert.True(t, ok)
+
+	// The third restart within the window opens the circuit and clears the history.
+	h, ok := p.allowRestart(append([]time.Time(nil), history...), start.Add(20*time.Second))
+	assert.False(t, ok)
+	assert.Empty(t, h)
+
+	// Restarts outside of the window are not counted.
+	history, ok = p.allowRestart(history, start.Add(65*time.Second))
+	assert.True(t, ok)
+	assert.Len(t, history, 2)
+
+	// Zero MaxRestarts disables the circuit breaker.
+	p.MaxRestarts = 0
+	for i := 0; i < 10; i++ {
+		history, ok = p.allowRestart(history, start.Add(70*time.Second))
+		assert.True(t, ok)
+	}
+}
+
+func TestRestartPolicyCircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	restarts := testutil.ToFloat64(restartsTotal.WithLabelValues("root.failing"))
+
+	var starts int32
+	failing := func(ctx context.Context) error {
+		atomic.AddInt32(&starts, 1)
+		Signal(ctx, SignalHealthy)
+		return errors.New("dead RPC")
+	}
+
+	policy := RestartPolicy{
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRestarts:    3,
+		Window:         time.Hour,
+		BreakDuration:  time.Hour,
+	}
+	tree := NewIntrospector()
+	New(ctx, zap.NewNop(), func(ctx context.Context) error {
+		if err := Run(ctx, "failing", failing, WithRestartPolicy(policy)); err != nil {
+			return err
+		}
+		Signal(ctx, SignalHealthy)
+		Signal(ctx, SignalDone)
+		return nil
+	}, WithIntrospector(tree))
+
+	// The initial run and three restarts, after which the circuit breaker keeps it from being restarted.
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&starts) == 4 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(circuitOpen.WithLabelValues("root.failing")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&starts))
+	// Deaths are counted including the one that opened the circuit.
+	assert.Equal(t, restarts+4, testutil.ToFloat64(restartsTotal.WithLabelValues("root.failing")))
+
+	status := tree.Status()
+	if assert.Len(t, status, 2) {
+		assert.Equal(t, "root", status[0].DN)
+		assert.Equal(t, "done", status[0].State)
+
+		assert.Equal(t, "root.failing", status[1].DN)
+		assert.Equal(t, "pending", status[1].State)
+		assert.Equal(t, 4, status[1].Restarts)
+		assert.True(t, status[1].CircuitOpen)
+		assert.Contains(t, status[1].LastError, "dead RPC")
+		assert.True(t, status[1].Started.IsZero())
+	}
+}
@@ -24,6 +24,9 @@ type processorRequest struct {
 // processorRequestSchedule requests that a given node's runnable be started.
 type processorRequestSchedule struct {
 	dn string
+	// For restarts, the node that was reset. If it was removed from the tree in the meantime, for instance because its
+	// parent was restarted, it is not started.
+	node *node
 }
 
 // processorRequestDied is a signal from a runnable goroutine that the runnable has died.
@@ -129,7 +132,13 @@ func (s *supervisor) processSchedule(r *processorRequestSchedule) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if r.node != nil && !s.inTree(r.node) {
+		return
+	}
+
 	n := s.nodeByDN(r.dn)
+	n.started = time.Now()
+	n.circuitOpen = false
 	go func() {
 		if !s.propagatePanic {
 			defer func() {
@@ -200,6 +209,13 @@ func (s *supervisor) processDied(r *processorRequestDied) {
 	s.ilogger.Error("Runnable died", zap.String("dn", n.dn()), zap.Error(err))
 	// Mark as dead.
 	n.state = nodeStateDead
+	n.lastError = err.Error()
+	n.lastErrorTime = time.Now()
+
+	// With a restart policy, only a runnable that ran for long enough is restarted without delay.
+	if n.policy != nil && time.Since(n.started) >= n.policy.ResetAfter {
+		n.bo.Reset()
+	}
 
 	// Cancel that node's context, just in case something still depends on it.
 	n.ctxC()
@@ -371,8 +387,26 @@ func (s *supervisor) processGC() {
 
 		// Only back off when the node unexpectedly died - not when it got canceled.
 		bo := time.Duration(0)
+		broken := false
 		if n.state == nodeStateDead {
+			restartsTotal.WithLabelValues(dn).Inc()
+			n.restartCount++
 			bo = n.bo.NextBackOff()
+
+			if n.policy != nil {
+				var allowed bool
+				n.restarts, allowed = n.policy.allowRestart(n.restarts, time.Now())
+				if !allowed {
+					broken = true
+					n.circuitOpen = true
+					bo = n.policy.BreakDuration
+					n.bo.Reset()
+					circuitOpen.WithLabelValues(dn).Set(1)
+					s.ilogger.Error("supervised node failed too often, circuit breaker open",
+						zap.String("dn", dn), zap.Int("max_restarts", n.policy.MaxRestarts),
+						zap.Duration("window", n.policy.Window), zap.Duration("break", bo))
+				}
+			}
 		}
 
 		// Prepare node for rescheduling - remove its children, reset its state to new.
@@ -380,11 +414,24 @@ func (s *supervisor) processGC() {
 		s.ilogger.Info("rescheduling supervised node", zap.String("dn", dn), zap.Duration("backoff", bo))
 
 		// Reschedule node runnable to run after backoff.
-		go func(n *node, bo time.Duration) {
+		go func(n *node, dn string, bo time.Duration, broken bool) {
 			time.Sleep(bo)
+			if broken {
+				circuitOpen.WithLabelValues(dn).Set(0)
+			}
 			s.pReq <- &processorRequest{
-				schedule: &processorRequestSchedule{dn: n.dn()},
+				schedule: &processorRequestSchedule{dn: dn, node: n},
 			}
-		}(n, bo)
+		}(n, dn, bo, broken)
+	}
+}
+
+// inTree returns whether the given node is still part of the supervision tree.
+func (s *supervisor) inTree(n *node) bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.parent.children[n.name] != n {
+			return false
+		}
 	}
+	return n == s.root
 }
@@ -0,0 +1,129 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	logEntriesDroppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wormhole_telemetry_log_entries_dropped_total",
+			Help: "Total number of log entries dropped because a sink could not keep up or failed to ship them",
+		}, []string{"sink"})
+)
+
+const (
+	batchInterval    = time.Second
+	batchMaxEntries  = 1000
+	batchMaxBuffered = 10000
+	batchShipTimeout = 10 * time.Second
+)
+
+// record is a log entry buffered for shipping.
+type record struct {
+	time  time.Time
+	level zapcore.Level
+	line  string
+}
+
+// batcher buffers the entries of a sink and ships them in batches in the background, so that logging never blocks on
+// the network. Entries are dropped if the buffer is full or shipping fails.
+type batcher struct {
+	name string
+	ship func(ctx context.Context, records []record) error
+
+	mu  sync.Mutex
+	buf []record
+
+	flushC chan struct{}
+	stopC  chan struct{}
+	doneC  chan struct{}
+	once   sync.Once
+}
+
+func newBatcher(name string, ship func(ctx context.Context, records []record) error) *batcher {
+	b := &batcher{
+		name:   name,
+		ship:   ship,
+		flushC: make(chan struct{}, 1),
+		stopC:  make(chan struct{}),
+		doneC:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher) add(entry zapcore.Entry, line []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.buf) >= batchMaxBuffered {
+		logEntriesDroppedTotal.WithLabelValues(b.name).Inc()
+		return
+	}
+	b.buf = append(b.buf, record{time: entry.Time, level: entry.Level, line: string(line)})
+
+	if len(b.buf) >= batchMaxEntries {
+		select {
+		case b.flushC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.doneC)
+
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopC:
+			b.flush()
+			return
+		case <-ticker.C:
+			b.flush()
+		case <-b.flushC:
+			b.flush()
+		}
+	}
+}
+
+func (b *batcher) flush() {
+	b.mu.Lock()
+	records := b.buf
+	b.buf = nil
+	b.mu.Unlock()
+
+	for len(records) > 0 {
+		n := len(records)
+		if n > batchMaxEntries {
+			n = batchMaxEntries
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), batchShipTimeout)
+		err := b.ship(ctx, records[:n])
+		cancel()
+		if err != nil {
+			// Logging the error could loop.
+			fmt.Fprintf(os.Stderr, "telemetry: failed to ship %d log entries to %s: %v\n", n, b.name, err)
+			logEntriesDroppedTotal.WithLabelValues(b.name).Add(float64(n))
+		}
+		records = records[n:]
+	}
+}
+
+// close ships the buffered entries and stops the batcher.
+func (b *batcher) close() {
+	b.once.Do(func() { close(b.stopC) })
+	<-b.doneC
+}
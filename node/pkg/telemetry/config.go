@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Config is the log shipping configuration file of guardiand:
+//
+//	{
+//	  "sinks": [
+//	    {"type": "loki", "url": "http://loki:3100/loki/api/v1/push", "labels": {"cluster": "eu-1"}},
+//	    {"type": "otlp", "url": "http://otel-collector:4318/v1/logs"},
+//	    {"type": "file", "path": "/var/log/guardiand/node.log", "maxSizeMB": 100, "maxBackups": 5}
+//	  ],
+//	  "level": "info",
+//	  "sampling": {"root.solwatch": 0.1},
+//	  "redact": ["my-secret"]
+//	}
+type Config struct {
+	Sinks []SinkConfig `json:"sinks"`
+	// Defaults to info.
+	Level    string             `json:"level"`
+	Sampling map[string]float64 `json:"sampling"`
+	Redact   []string           `json:"redact"`
+}
+
+type SinkConfig struct {
+	// One of loki, otlp or file.
+	Type string `json:"type"`
+	// Push URL of the loki and otlp sinks.
+	URL string `json:"url"`
+	// Labels of the loki sink and resource attributes of the otlp sink, in addition to the labels of the node.
+	Labels map[string]string `json:"labels"`
+	// Settings of the file sink. The size defaults to 100 MB.
+	Path       string `json:"path"`
+	MaxSizeMB  int    `json:"maxSizeMB"`
+	MaxBackups int    `json:"maxBackups"`
+}
+
+// LoadConfig reads and validates the log shipping configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log shipping config: %w", err)
+	}
+
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse log shipping config: %w", err)
+	}
+
+	if _, err := c.level(); err != nil {
+		return nil, err
+	}
+	for i, s := range c.Sinks {
+		switch s.Type {
+		case "loki", "otlp":
+			if s.URL == "" {
+				return nil, fmt.Errorf("sink %d: url must be set for %s sinks", i, s.Type)
+			}
+		case "file":
+			if s.Path == "" {
+				return nil, fmt.Errorf("sink %d: path must be set for file sinks", i)
+			}
+		default:
+			return nil, fmt.Errorf("sink %d: unknown type %q", i, s.Type)
+		}
+	}
+	return &c, nil
+}
+
+func (c *Config) level() (zapcore.Level, error) {
+	if c.Level == "" {
+		return zapcore.InfoLevel, nil
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(c.Level)); err != nil {
+		return l, fmt.Errorf("invalid log shipping level %q: %w", c.Level, err)
+	}
+	return l, nil
+}
+
+// Options returns the options of the configuration, with the given strings redacted in addition to the configured ones.
+func (c *Config) Options(redact []string) Options {
+	l, _ := c.level()
+	return Options{
+		Level:    l,
+		Sampling: c.Sampling,
+		Redact:   append(append([]string{}, c.Redact...), redact...),
+	}
+}
+
+// NewSinks creates the configured sinks. The given labels are added to the labels of the loki and otlp sinks.
+func (c *Config) NewSinks(labels map[string]string) ([]Sink, error) {
+	var sinks []Sink
+	for i, s := range c.Sinks {
+		l := make(map[string]string, len(labels)+len(s.Labels))
+		for k, v := range labels {
+			l[k] = v
+		}
+		for k, v := range s.Labels {
+			l[k] = v
+		}
+
+		switch s.Type {
+		case "loki":
+			sinks = append(sinks, NewLokiSink(s.URL, l))
+		case "otlp":
+			sinks = append(sinks, NewOTLPSink(s.URL, l))
+		case "file":
+			maxSize := s.MaxSizeMB
+			if maxSize == 0 {
+				maxSize = 100
+			}
+			f, err := NewFileSink(s.Path, maxSize, s.MaxBackups)
+			if err != nil {
+				for _, sink := range sinks {
+					_ = sink.Close()
+				}
+				return nil, fmt.Errorf("sink %d: %w", i, err)
+			}
+			sinks = append(sinks, f)
+		}
+	}
+	return sinks, nil
+}
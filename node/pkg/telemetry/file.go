@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// FileSink writes the entries as JSON lines to a file, which is rotated to path.1, path.2, ... once it reaches its
+// maximum size.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewFileSink appends the entries to the file at path. It keeps up to maxBackups rotated files of up to maxSizeMB
+// megabytes each.
+func NewFileSink(path string, maxSizeMB int, maxBackups int) (*FileSink, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("maximum size of %s must be positive, got %d", path, maxSizeMB)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("number of backups of %s must not be negative, got %d", path, maxBackups)
+	}
+
+	s := &FileSink{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	s.f = f
+	s.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts the backups and opens a new file.
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	s.f = nil
+
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file: %w", err)
+		}
+	} else {
+		for i := s.maxBackups - 1; i > 0; i-- {
+			err := os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to rotate log file: %w", err)
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) Write(entry zapcore.Entry, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		// A previous rotation failed, try again.
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n := int64(len(line)) + 1
+	if s.size > 0 && s.size+n > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.f.Write(append(line[:len(line):len(line)], '\n')); err != nil {
+		return err
+	}
+	s.size += n
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/logging"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/api/option"
+)
+
+// Mirrors the conversion done by zapdriver. We need to convert this
+// to proto severity for usage with the SDK client library
+// (the JSON value encoded by zapdriver is ignored).
+var logLevelSeverity = map[zapcore.Level]logging.Severity{
+	zapcore.DebugLevel:  logging.Debug,
+	zapcore.InfoLevel:   logging.Info,
+	zapcore.WarnLevel:   logging.Warning,
+	zapcore.ErrorLevel:  logging.Error,
+	zapcore.DPanicLevel: logging.Critical,
+	zapcore.PanicLevel:  logging.Alert,
+	zapcore.FatalLevel:  logging.Emergency,
+}
+
+// GoogleCloudSink ships the entries to Google Cloud Logging.
+type GoogleCloudSink struct {
+	logger *logging.Logger
+	labels map[string]string
+}
+
+func NewGoogleCloudSink(ctx context.Context, project string, serviceAccountJSON []byte, labels map[string]string) (*GoogleCloudSink, error) {
+	gc, err := logging.NewClient(ctx, project, option.WithCredentialsJSON(serviceAccountJSON))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create logging client: %v", err)
+	}
+
+	gc.OnError = func(err error) {
+		fmt.Printf("telemetry: logging client error: %v\n", err)
+	}
+
+	return &GoogleCloudSink{
+		logger: gc.Logger("wormhole"),
+		labels: labels,
+	}, nil
+}
+
+func (s *GoogleCloudSink) Write(entry zapcore.Entry, line []byte) error {
+	// Create a copy of line (zap will reuse the same buffer otherwise)
+	lineCopy := make([]byte, len(line))
+	copy(lineCopy, line)
+
+	// Write raw message to log
+	s.logger.Log(logging.Entry{
+		Timestamp: entry.Time,
+		Payload:   json.RawMessage(lineCopy),
+		Severity:  logLevelSeverity[entry.Level],
+		Labels:    s.labels,
+	})
+	return nil
+}
+
+func (s *GoogleCloudSink) Close() error {
+	return s.logger.Flush()
+}
@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LokiSink ships the entries to the push API of Loki, with a "level" label in addition to the configured labels.
+type LokiSink struct {
+	url    string
+	labels map[string]string
+	client *http.Client
+	b      *batcher
+}
+
+// NewLokiSink ships the entries to the given push API URL, such as http://loki:3100/loki/api/v1/push.
+func NewLokiSink(url string, labels map[string]string) *LokiSink {
+	s := &LokiSink{
+		url:    url,
+		labels: labels,
+		client: &http.Client{},
+	}
+	s.b = newBatcher("loki", s.ship)
+	return s
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+func (s *LokiSink) ship(ctx context.Context, records []record) error {
+	streams := make(map[zapcore.Level]*lokiStream)
+	push := lokiPush{}
+	for _, r := range records {
+		st, ok := streams[r.level]
+		if !ok {
+			labels := map[string]string{"level": r.level.String()}
+			for k, v := range s.labels {
+				labels[k] = v
+			}
+			st = &lokiStream{Stream: labels}
+			streams[r.level] = st
+			push.Streams = append(push.Streams, st)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(r.time.UnixNano(), 10), r.line})
+	}
+
+	b, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+
+	return postLogs(ctx, s.client, s.url, "application/json", b)
+}
+
+func (s *LokiSink) Write(entry zapcore.Entry, line []byte) error {
+	s.b.add(entry, line)
+	return nil
+}
+
+func (s *LokiSink) Close() error {
+	s.b.close()
+	return nil
+}
+
+// postLogs posts a batch of entries and checks that the request succeeded.
+func postLogs(ctx context.Context, client *http.Client, url string, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
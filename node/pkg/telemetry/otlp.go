@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	collogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+)
+
+var logLevelOTLPSeverity = map[zapcore.Level]logsv1.SeverityNumber{
+	zapcore.DebugLevel:  logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	zapcore.InfoLevel:   logsv1.SeverityNumber_SEVERITY_NUMBER_INFO,
+	zapcore.WarnLevel:   logsv1.SeverityNumber_SEVERITY_NUMBER_WARN,
+	zapcore.ErrorLevel:  logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	zapcore.DPanicLevel: logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR2,
+	zapcore.PanicLevel:  logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR3,
+	zapcore.FatalLevel:  logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+// OTLPSink ships the entries to an OpenTelemetry collector using OTLP/HTTP. The body of each log record is the JSON
+// encoded entry, and the configured labels are set as resource attributes.
+type OTLPSink struct {
+	url      string
+	resource *resourcev1.Resource
+	client   *http.Client
+	b        *batcher
+}
+
+// NewOTLPSink ships the entries to the given URL, such as http://otel-collector:4318/v1/logs.
+func NewOTLPSink(url string, labels map[string]string) *OTLPSink {
+	res := &resourcev1.Resource{
+		Attributes: []*commonv1.KeyValue{otlpString("service.name", "guardiand")},
+	}
+	for k, v := range labels {
+		res.Attributes = append(res.Attributes, otlpString(k, v))
+	}
+
+	s := &OTLPSink{
+		url:      url,
+		resource: res,
+		client:   &http.Client{},
+	}
+	s.b = newBatcher("otlp", s.ship)
+	return s
+}
+
+func otlpString(k, v string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: k, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: v}}}
+}
+
+func (s *OTLPSink) ship(ctx context.Context, records []record) error {
+	logs := make([]*logsv1.LogRecord, 0, len(records))
+	for _, r := range records {
+		logs = append(logs, &logsv1.LogRecord{
+			TimeUnixNano:   uint64(r.time.UnixNano()),
+			SeverityNumber: logLevelOTLPSeverity[r.level],
+			SeverityText:   r.level.CapitalString(),
+			Body:           &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: r.line}},
+		})
+	}
+
+	b, err := proto.Marshal(&collogsv1.ExportLogsServiceRequest{
+		ResourceLogs: []*logsv1.ResourceLogs{{
+			Resource:  s.resource,
+			ScopeLogs: []*logsv1.ScopeLogs{{LogRecords: logs}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	return postLogs(ctx, s.client, s.url, "application/x-protobuf", b)
+}
+
+func (s *OTLPSink) Write(entry zapcore.Entry, line []byte) error {
+	s.b.add(entry, line)
+	return nil
+}
+
+func (s *OTLPSink) Close() error {
+	s.b.close()
+	return nil
+}
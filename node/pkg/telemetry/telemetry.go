@@ -1,99 +1,194 @@
+// Package telemetry ships the node's logs to one or more sinks, such as the shared Google Cloud Logging project, Loki,
+// an OTLP collector or a rotated file.
+//
+// The entries of each logger can be sampled, and configured secrets such as RPC endpoints and tokens are redacted
+// before the entries leave the process. Warnings and errors are never sampled.
 package telemetry
 
 import (
-	"context"
-	"encoding/json"
+	"bytes"
 	"fmt"
-	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 
-	"cloud.google.com/go/logging"
 	"github.com/blendle/zapdriver"
 	"go.uber.org/zap"
-	"go.uber.org/zap/buffer"
 	"go.uber.org/zap/zapcore"
-	"google.golang.org/api/option"
 )
 
-type Telemetry struct {
-	encoder            *encoder
-	serviceAccountJSON []byte
+// Sink ships log entries to a backend.
+type Sink interface {
+	// Write ships an entry encoded as a single line of JSON. It must not block and must not retain line.
+	Write(entry zapcore.Entry, line []byte) error
+	// Close flushes the buffered entries and releases the resources of the sink.
+	Close() error
 }
 
-type encoder struct {
-	zapcore.Encoder
-	logger *logging.Logger
-	labels map[string]string
+// Options configure which entries are shipped and how.
+type Options struct {
+	// Entries below Level are not shipped.
+	Level zapcore.Level
+	// Sampling maps logger names to the ratio of their entries below the warn level that are shipped. A logger uses the
+	// ratio of its longest configured prefix, such as "root.ethwatch" for "root.ethwatch.watcher". Loggers without a
+	// configured ratio ship all of their entries.
+	Sampling map[string]float64
+	// Redact lists strings, such as RPC endpoints which may include API keys, that are replaced in the shipped entries.
+	Redact []string
 }
 
-// Mirrors the conversion done by zapdriver. We need to convert this
-// to proto severity for usage with the SDK client library
-// (the JSON value encoded by zapdriver is ignored).
-var logLevelSeverity = map[zapcore.Level]logging.Severity{
-	zapcore.DebugLevel:  logging.Debug,
-	zapcore.InfoLevel:   logging.Info,
-	zapcore.WarnLevel:   logging.Warning,
-	zapcore.ErrorLevel:  logging.Error,
-	zapcore.DPanicLevel: logging.Critical,
-	zapcore.PanicLevel:  logging.Alert,
-	zapcore.FatalLevel:  logging.Emergency,
+// The text that replaces redacted strings.
+const redacted = "[redacted]"
+
+type Telemetry struct {
+	sinks    []Sink
+	opts     Options
+	prefixes []string
+	redact   []string
+
+	// Replaced in tests.
+	randMu sync.Mutex
+	rand   func() float64
 }
 
-func (enc *encoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
-	buf, err := enc.Encoder.EncodeEntry(entry, fields)
-	if err != nil {
-		return nil, err
+// New ships the entries of the loggers wrapped with WrapLogger to the given sinks.
+func New(sinks []Sink, opts Options) (*Telemetry, error) {
+	prefixes := make([]string, 0, len(opts.Sampling))
+	for name, ratio := range opts.Sampling {
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("sampling ratio of %s must be between 0 and 1, got %v", name, ratio)
+		}
+		prefixes = append(prefixes, name)
 	}
+	// Longest prefixes first, so that the most specific one is used.
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	// Ignore empty strings, and redact the longest strings first in case they overlap.
+	var redact []string
+	for _, s := range opts.Redact {
+		if s != "" {
+			redact = append(redact, s)
+		}
+	}
+	sort.Slice(redact, func(i, j int) bool { return len(redact[i]) > len(redact[j]) })
 
-	// Create a copy of buf (zap will reuse the same buffer otherwise)
-	bufCopy := make([]byte, len(buf.Bytes()))
-	copy(bufCopy, buf.Bytes())
+	return &Telemetry{
+		sinks:    sinks,
+		opts:     opts,
+		prefixes: prefixes,
+		redact:   redact,
+		rand:     rand.Float64, // #nosec G404 sampling need not be unpredictable
+	}, nil
+}
 
-	// Convert the zapcore.Level to a logging.Severity
-	severity := logLevelSeverity[entry.Level]
+// sampled returns whether an entry is shipped according to the sampling ratio of its logger.
+func (t *Telemetry) sampled(entry zapcore.Entry) bool {
+	if entry.Level >= zapcore.WarnLevel {
+		return true
+	}
 
-	// Write raw message to log
-	enc.logger.Log(logging.Entry{
-		Timestamp: entry.Time,
-		Payload:   json.RawMessage(bufCopy),
-		Severity:  severity,
-		Labels:    enc.labels,
-	})
+	for _, p := range t.prefixes {
+		if entry.LoggerName == p || strings.HasPrefix(entry.LoggerName, p+".") {
+			t.randMu.Lock()
+			r := t.rand()
+			t.randMu.Unlock()
+			return r < t.opts.Sampling[p]
+		}
+	}
+	return true
+}
 
-	return buf, nil
+// redactLine replaces the configured strings in an encoded entry. Strings are also replaced in their JSON-escaped form.
+func (t *Telemetry) redactLine(line []byte) []byte {
+	for _, s := range t.redact {
+		line = bytes.ReplaceAll(line, []byte(s), []byte(redacted))
+		if escaped := jsonEscape(s); escaped != s {
+			line = bytes.ReplaceAll(line, []byte(escaped), []byte(redacted))
+		}
+	}
+	return line
 }
 
-func New(ctx context.Context, project string, serviceAccountJSON []byte, labels map[string]string) (*Telemetry, error) {
-	gc, err := logging.NewClient(ctx, project, option.WithCredentialsJSON(serviceAccountJSON))
+func jsonEscape(s string) string {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, []zapcore.Field{zap.String("s", s)})
 	if err != nil {
-		return nil, fmt.Errorf("unable to create logging client: %v", err)
+		return s
 	}
+	defer buf.Free()
+	// {"s":"..."}\n
+	b := buf.Bytes()
+	return string(b[6 : len(b)-3])
+}
 
-	gc.OnError = func(err error) {
-		fmt.Printf("telemetry: logging client error: %v\n", err)
+// WrapLogger returns a logger that also ships its entries to the sinks.
+func (t *Telemetry) WrapLogger(logger *zap.Logger) *zap.Logger {
+	tc := &core{
+		LevelEnabler: t.opts.Level,
+		enc:          zapcore.NewJSONEncoder(zapdriver.NewProductionEncoderConfig()),
+		t:            t,
 	}
 
-	return &Telemetry{
-		serviceAccountJSON: serviceAccountJSON,
-		encoder: &encoder{
-			Encoder: zapcore.NewJSONEncoder(zapdriver.NewProductionEncoderConfig()),
-			logger:  gc.Logger("wormhole"),
-			labels:  labels,
-		},
-	}, nil
+	return logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(c, tc)
+	}))
 }
 
-func (s *Telemetry) WrapLogger(logger *zap.Logger) *zap.Logger {
-	tc := zapcore.NewCore(
-		s.encoder,
-		zapcore.AddSync(ioutil.Discard),
-		zap.InfoLevel,
-	)
+// Close flushes and closes the sinks.
+func (t *Telemetry) Close() error {
+	var errs []string
+	for _, s := range t.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to close log sinks: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
 
-	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-		return zapcore.NewTee(core, tc)
-	}))
+// core encodes the entries of a logger, redacts them and writes them to the sinks.
+type core struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	t   *Telemetry
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &core{LevelEnabler: c.LevelEnabler, enc: enc, t: c.t}
+}
+
+func (c *core) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) && c.t.sampled(entry) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	line := c.t.redactLine(bytes.TrimRight(buf.Bytes(), "\n"))
+	for _, s := range c.t.sinks {
+		if err := s.Write(entry, line); err != nil {
+			// Logging the error could loop.
+			fmt.Fprintf(os.Stderr, "telemetry: failed to ship log entry: %v\n", err)
+		}
+	}
+	return nil
 }
 
-func (s *Telemetry) Close() error {
-	return s.encoder.logger.Flush()
+func (c *core) Sync() error {
+	return nil
 }
@@ -0,0 +1,136 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type memorySink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *memorySink) Write(entry zapcore.Entry, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, string(line))
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	return nil
+}
+
+func TestSampling(t *testing.T) {
+	sink := &memorySink{}
+	tm, err := New([]Sink{sink}, Options{
+		Level: zapcore.DebugLevel,
+		Sampling: map[string]float64{
+			"root.solwatch":         0.5,
+			"root.solwatch.confirm": 0,
+		},
+	})
+	require.NoError(t, err)
+	tm.rand = func() float64 { return 0.4 }
+
+	logger := tm.WrapLogger(zap.NewNop())
+	logger.Named("root").Info("not sampled")
+	logger.Named("root").Named("solwatch").Info("sampled in")
+	logger.Named("root").Named("solwatch").Named("confirm").Info("sampled out")
+	logger.Named("root").Named("solwatch").Named("confirm").Warn("never sampled")
+	logger.Named("root").Named("solwatcher").Info("other logger")
+
+	require.Len(t, sink.lines, 4)
+	assert.Contains(t, sink.lines[0], "not sampled")
+	assert.Contains(t, sink.lines[1], "sampled in")
+	assert.Contains(t, sink.lines[2], "never sampled")
+	assert.Contains(t, sink.lines[3], "other logger")
+
+	_, err = New(nil, Options{Sampling: map[string]float64{"root": 1.5}})
+	assert.Error(t, err)
+}
+
+func TestRedaction(t *testing.T) {
+	sink := &memorySink{}
+	tm, err := New([]Sink{sink}, Options{
+		Redact: []string{"https://rpc.example.com/v1/s3cr3t", `tok"en`, ""},
+	})
+	require.NoError(t, err)
+
+	logger := tm.WrapLogger(zap.NewNop())
+	logger.Info("dialing https://rpc.example.com/v1/s3cr3t", zap.String("url", "https://rpc.example.com/v1/s3cr3t"))
+	logger.Info("authenticating", zap.String("token", `tok"en`))
+	logger.Debug("below the level")
+
+	require.Len(t, sink.lines, 2)
+	assert.NotContains(t, sink.lines[0], "s3cr3t")
+	assert.Equal(t, 2, strings.Count(sink.lines[0], redacted))
+	assert.NotContains(t, sink.lines[1], `tok\"en`)
+	assert.Contains(t, sink.lines[1], redacted)
+
+	// The lines remain valid JSON.
+	for _, l := range sink.lines {
+		assert.True(t, json.Valid([]byte(l)), l)
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	s, err := NewFileSink(path, 1, 2)
+	require.NoError(t, err)
+	s.maxSize = 100
+
+	line := []byte(strings.Repeat("x", 59))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Write(zapcore.Entry{}, line))
+	}
+	require.NoError(t, s.Close())
+
+	// Each file holds a single line, and only two backups are kept.
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		b, err := ioutil.ReadFile(p)
+		require.NoError(t, err)
+		assert.Equal(t, string(line)+"\n", string(b))
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLokiSink(t *testing.T) {
+	pushes := make(chan lokiPush, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		pushes <- p
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := NewLokiSink(srv.URL, map[string]string{"node_name": "guardian-0"})
+	ts := time.Unix(1660000000, 42)
+	require.NoError(t, s.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: ts}, []byte(`{"message":"a"}`)))
+	require.NoError(t, s.Write(zapcore.Entry{Level: zapcore.WarnLevel, Time: ts}, []byte(`{"message":"b"}`)))
+	require.NoError(t, s.Close())
+
+	p := <-pushes
+	require.Len(t, p.Streams, 2)
+	assert.Equal(t, map[string]string{"node_name": "guardian-0", "level": "info"}, p.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1660000000000000042", `{"message":"a"}`}}, p.Streams[0].Values)
+	assert.Equal(t, "warn", p.Streams[1].Stream["level"])
+}
@@ -34,7 +34,7 @@ type (
 		urlLCD   string
 		contract string
 
-		msgChan chan *common.MessagePublication
+		msgChan chan<- *common.MessagePublication
 
 		// Incoming re-observation requests from the network. Pre-filtered to only
 		// include requests for our chainID.
@@ -93,7 +93,7 @@ func NewWatcher(
 	urlWS string,
 	urlLCD string,
 	contract string,
-	lockEvents chan *common.MessagePublication,
+	lockEvents chan<- *common.MessagePublication,
 	obsvReqC chan *gossipv1.ObservationRequest,
 	readiness readiness.Component,
 	chainID vaa.ChainID) *Watcher {
@@ -110,8 +110,8 @@ func NewWatcher(
 	// Do not add a leading slash
 	latestBlockURL := "blocks/latest"
 
-	// Injective does things slightly differently than terra
-	if chainID == vaa.ChainIDInjective {
+	// Injective and wormchain do things slightly differently than terra
+	if chainID == vaa.ChainIDInjective || chainID == vaa.ChainIDWormchain {
 		latestBlockURL = "cosmos/base/tendermint/v1beta1/blocks/latest"
 	}
 
@@ -210,6 +210,7 @@ func (e *Watcher) Run(ctx context.Context) error {
 				Height:          latestBlock.Int(),
 				ContractAddress: e.contract,
 			})
+			readiness.SetReady(e.readiness)
 		}
 	}()
 
@@ -0,0 +1,68 @@
+// Package consistency describes the documented meaning of the consistency level of messages on each chain, for the
+// conformance tests of the watchers.
+//
+// The consistency level is set by the emitter, and its meaning is specific to each chain: a number of confirmations on
+// EVM chains, a commitment level on Solana, and no meaning on chains with instant finality. Each watcher test runs the
+// cases of its chain against the mock chain server (see mockchain), so a watcher whose interpretation silently diverges
+// from the documented semantics fails.
+package consistency
+
+// Finality is how final a block must be before the messages it contains are observed.
+type Finality string
+
+const (
+	// Instant: the chain has instant finality, messages are observed as soon as they are included.
+	Instant Finality = "instant"
+	// Safe: messages are observed once their block is confirmed by a supermajority, which makes a rollback unlikely but
+	// not impossible.
+	Safe Finality = "safe"
+	// Finalized: messages are observed once their block is finalized.
+	Finalized Finality = "finalized"
+	// Confirmations: messages are observed once enough blocks were built on top of their block.
+	Confirmations Finality = "confirmations"
+)
+
+// Case is the expected interpretation of a consistency level.
+type Case struct {
+	Level    uint8
+	Finality Finality
+	// The number of blocks built on top of the block of the message before it is observed, for Confirmations.
+	Confirmations uint64
+	// The watcher must not observe messages with this level.
+	Rejected bool
+	// The consistency level of the observation, which is the requested level unless the chain ignores it.
+	ObservedLevel uint8
+}
+
+// EVM returns the cases of EVM chains, where the level is a number of confirmations, raised to the minimum number of
+// confirmations of the chain.
+func EVM(minConfirmations uint64) []Case {
+	var cases []Case
+	for _, level := range []uint8{0, 1, 15, 64, 255} {
+		confirmations := uint64(level)
+		if confirmations < minConfirmations {
+			confirmations = minConfirmations
+		}
+		cases = append(cases, Case{Level: level, Finality: Confirmations, Confirmations: confirmations, ObservedLevel: level})
+	}
+	return cases
+}
+
+// Solana returns the cases of Solana, where the level is a commitment level. Other levels are rejected.
+func Solana() []Case {
+	return []Case{
+		{Level: 0, Finality: Safe, ObservedLevel: 0},
+		{Level: 1, Finality: Finalized, ObservedLevel: 1},
+		{Level: 2, Rejected: true},
+		{Level: 32, Rejected: true},
+	}
+}
+
+// Aptos returns the cases of Aptos, which has instant finality. The level is observed as requested.
+func Aptos() []Case {
+	var cases []Case
+	for _, level := range []uint8{0, 1, 15, 255} {
+		cases = append(cases, Case{Level: level, Finality: Instant, ObservedLevel: level})
+	}
+	return cases
+}
@@ -0,0 +1,175 @@
+package mockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AptosEvent is an event in the event stream of an account.
+type AptosEvent struct {
+	Version        string          `json:"version"`
+	SequenceNumber string          `json:"sequence_number"`
+	Type           string          `json:"type"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// AptosServer is a mock of the Aptos node REST API, serving the ledger info at /v1, event streams at
+// /v1/accounts/{account}/events/{handle}/{field}, and the resources holding them at
+// /v1/accounts/{account}/resource/{handle}.
+type AptosServer struct {
+	*server
+
+	stateMu     sync.Mutex
+	blockHeight uint64
+	maxPageSize int
+	events      map[string][]AptosEvent
+	// Event counters overriding the number of events of a stream.
+	counters map[string]uint64
+}
+
+// NewAptosServer starts a mock Aptos node at block height 0. It must be closed by the caller.
+func NewAptosServer() *AptosServer {
+	s := &AptosServer{maxPageSize: 100, events: map[string][]AptosEvent{}, counters: map[string]uint64{}}
+	s.server = newServer(s.respond)
+	return s
+}
+
+// SetBlockHeight sets the block height of the ledger info.
+func (s *AptosServer) SetBlockHeight(height uint64) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.blockHeight = height
+}
+
+// SetMaxPageSize sets the maximum number of events returned per page, whatever the requested limit, like the
+// max_events_page_size of fullnodes. It is 100 by default.
+func (s *AptosServer) SetMaxPageSize(size int) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.maxPageSize = size
+}
+
+// AddEvent appends an event with the given data to the event stream of handle in account, with the handle as its type.
+// Events are numbered consecutively from 0.
+func (s *AptosServer) AddEvent(account string, handle string, data interface{}) error {
+	return s.AddTypedEvent(account, handle, handle, data)
+}
+
+// AddTypedEvent appends an event of the given type to the event stream of handle in account.
+func (s *AptosServer) AddTypedEvent(account string, handle string, eventType string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	key := account + "/" + handle
+	seq := len(s.events[key])
+	s.events[key] = append(s.events[key], AptosEvent{
+		Version:        strconv.Itoa(1000 + seq),
+		SequenceNumber: strconv.Itoa(seq),
+		Type:           eventType,
+		Data:           b,
+	})
+	return nil
+}
+
+// SetEventCounter sets the counter of the event handle of the resource handle in account, which is the number of
+// events of the stream unless set. It simulates a node missing events, or a rollback.
+func (s *AptosServer) SetEventCounter(account string, handle string, counter uint64) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.counters[account+"/"+handle] = counter
+}
+
+// AddFixtures adds recorded REST responses.
+func (s *AptosServer) AddFixtures(fixtures []Fixture) error {
+	for _, f := range fixtures {
+		if f.Path == "" {
+			return fmt.Errorf("REST fixture without path")
+		}
+		s.addFixture(f.Path, f.Response)
+	}
+	return nil
+}
+
+func (s *AptosServer) respond(w http.ResponseWriter, r *http.Request) {
+	s.countRequest(r.URL.Path)
+
+	if body, ok := s.replay(r.URL.RequestURI()); ok {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return
+	}
+
+	if r.URL.Path == "/v1" {
+		s.stateMu.Lock()
+		height := s.blockHeight
+		s.stateMu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]string{
+			"chain_id":       "4",
+			"ledger_version": strconv.FormatUint(height*10, 10),
+			"block_height":   strconv.FormatUint(height, 10),
+		})
+		return
+	}
+
+	// /v1/accounts/{account}/resource/{handle}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/accounts/"), "/")
+	if len(parts) == 3 && parts[1] == "resource" {
+		s.stateMu.Lock()
+		key := parts[0] + "/" + parts[2]
+		counter, ok := s.counters[key]
+		if !ok {
+			counter = uint64(len(s.events[key]))
+		}
+		s.stateMu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"type": parts[2],
+			"data": map[string]interface{}{
+				"event": map[string]interface{}{
+					"counter": strconv.FormatUint(counter, 10),
+					"guid":    map[string]interface{}{"id": map[string]string{"addr": parts[0], "creation_num": "2"}},
+				},
+			},
+		})
+		return
+	}
+
+	// /v1/accounts/{account}/events/{handle}/{field}
+	if len(parts) != 4 || parts[1] != "events" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+		return
+	}
+
+	s.stateMu.Lock()
+	events := s.events[parts[0]+"/"+parts[2]]
+	maxPageSize := s.maxPageSize
+	s.stateMu.Unlock()
+
+	q := r.URL.Query()
+	limit := 25
+	if v := q.Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	var start int
+	if v := q.Get("start"); v != "" {
+		start, _ = strconv.Atoi(v)
+	} else if len(events) > limit {
+		// Without a start, the latest events are returned.
+		start = len(events) - limit
+	}
+
+	page := []AptosEvent{}
+	for i := start; i < len(events) && len(page) < limit; i++ {
+		page = append(page, events[i])
+	}
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,152 @@
+package mockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// JSONRPCHandler computes the result of a JSON-RPC method from its parameters.
+type JSONRPCHandler func(params json.RawMessage) (interface{}, error)
+
+// JSONRPCServer is a mock JSON-RPC 2.0 server over HTTP, including batch requests.
+type JSONRPCServer struct {
+	*server
+
+	handlersMu sync.Mutex
+	handlers   map[string]JSONRPCHandler
+}
+
+type jsonRPCRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+// NewJSONRPCServer starts a JSON-RPC server without any methods. It must be closed by the caller.
+func NewJSONRPCServer() *JSONRPCServer {
+	s := &JSONRPCServer{handlers: map[string]JSONRPCHandler{}}
+	s.server = newServer(s.respond)
+	return s
+}
+
+// Handle sets the handler of a method. Recorded fixtures of the method take precedence.
+func (s *JSONRPCServer) Handle(method string, h JSONRPCHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[method] = h
+}
+
+// SetResult makes a method always return result.
+func (s *JSONRPCServer) SetResult(method string, result interface{}) {
+	s.Handle(method, func(json.RawMessage) (interface{}, error) { return result, nil })
+}
+
+// AddFixtures adds recorded responses of JSON-RPC methods.
+func (s *JSONRPCServer) AddFixtures(fixtures []Fixture) error {
+	for _, f := range fixtures {
+		if f.Method == "" {
+			return fmt.Errorf("JSON-RPC fixture without method")
+		}
+		s.addFixture(f.Method, f.Response)
+	}
+	return nil
+}
+
+func (s *JSONRPCServer) respond(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var batch []jsonRPCRequest
+	if err := json.Unmarshal(body, &batch); err == nil {
+		responses := make([]jsonRPCResponse, 0, len(batch))
+		for _, req := range batch {
+			responses = append(responses, s.call(req))
+		}
+		writeJSON(w, http.StatusOK, responses)
+		return
+	}
+
+	var req jsonRPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusOK, jsonRPCResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &jsonRPCError{Code: -32700, Message: "parse error"}})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.call(req))
+}
+
+func (s *JSONRPCServer) call(req jsonRPCRequest) jsonRPCResponse {
+	s.countRequest(req.Method)
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+
+	if result, ok := s.replay(req.Method); ok {
+		resp.Result = result
+		return resp
+	}
+
+	s.handlersMu.Lock()
+	h, ok := s.handlers[req.Method]
+	s.handlersMu.Unlock()
+	if !ok {
+		resp.Error = &jsonRPCError{Code: -32601, Message: fmt.Sprintf("method %s not found", req.Method)}
+		return resp
+	}
+
+	result, err := h(req.Params)
+	if err != nil {
+		resp.Error = &jsonRPCError{Code: -32000, Message: err.Error()}
+		return resp
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &jsonRPCError{Code: -32603, Message: err.Error()}
+		return resp
+	}
+	resp.Result = b
+	return resp
+}
+
+// NewEVMServer starts a mock EVM JSON-RPC server for the given chain ID, at block height 0. It must be closed by the
+// caller.
+func NewEVMServer(chainID uint64) *JSONRPCServer {
+	s := NewJSONRPCServer()
+	s.SetResult("eth_chainId", fmt.Sprintf("0x%x", chainID))
+	s.SetResult("net_version", fmt.Sprintf("%d", chainID))
+	SetEVMBlockNumber(s, 0)
+	return s
+}
+
+// SetEVMBlockNumber sets the block height returned by eth_blockNumber.
+func SetEVMBlockNumber(s *JSONRPCServer, height uint64) {
+	s.SetResult("eth_blockNumber", fmt.Sprintf("0x%x", height))
+}
+
+// NewSolanaServer starts a mock Solana JSON-RPC server, which is healthy and at slot 0. It must be closed by the caller.
+func NewSolanaServer() *JSONRPCServer {
+	s := NewJSONRPCServer()
+	s.SetResult("getHealth", "ok")
+	SetSolanaSlot(s, 0)
+	return s
+}
+
+// SetSolanaSlot sets the slot returned by getSlot.
+func SetSolanaSlot(s *JSONRPCServer, slot uint64) {
+	s.SetResult("getSlot", slot)
+}
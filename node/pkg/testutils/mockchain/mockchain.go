@@ -0,0 +1,163 @@
+// Package mockchain provides mock RPC servers of the chains watched by the guardian, for watcher unit tests.
+//
+// There are servers for the Aptos REST API (NewAptosServer) and for the JSON-RPC APIs of EVM chains
+// (NewEVMServer) and Solana (NewSolanaServer). Responses are either generated from the state set up by the test, or
+// replayed from recorded fixtures (see LoadFixtures). Every server can inject latency and errors with SetFault and
+// FailNext, to test how watchers recover from misbehaving nodes.
+package mockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Fault is misbehaviour injected into the responses of a server.
+type Fault struct {
+	// Latency is added before responding.
+	Latency time.Duration
+	// Status, if set, is returned instead of the response, with Body as the body.
+	Status int
+	// Body, if set, replaces the response body, e.g. with malformed JSON.
+	Body string
+}
+
+// Fixture is a recorded response. Recorded responses of the same request are replayed in order, and the last one is
+// repeated once all have been replayed.
+type Fixture struct {
+	// JSON-RPC method, for JSON-RPC servers.
+	Method string `json:"method,omitempty"`
+	// Path including the query, for REST servers, e.g. /v1/accounts/0x1/events/0x1::coin::CoinStore/event?limit=1.
+	Path string `json:"path,omitempty"`
+	// Result of the JSON-RPC method, or the body of the REST response.
+	Response json.RawMessage `json:"response"`
+}
+
+// LoadFixtures reads a JSON array of fixtures from a file.
+func LoadFixtures(path string) ([]Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// server is the part common to all mock servers: faults, fixture replay and request counting.
+type server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	fault     Fault
+	failNext  []Fault
+	fixtures  map[string][]json.RawMessage
+	requests  map[string]int
+	respondFn func(w http.ResponseWriter, r *http.Request)
+}
+
+func newServer(respond func(w http.ResponseWriter, r *http.Request)) *server {
+	s := &server{
+		fixtures:  map[string][]json.RawMessage{},
+		requests:  map[string]int{},
+		respondFn: respond,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetFault injects a fault into all responses until it is reset with SetFault(Fault{}).
+func (s *server) SetFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fault = f
+}
+
+// FailNext injects a fault into the next n responses only.
+func (s *server) FailNext(n int, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failNext = append(s.failNext, f)
+	}
+}
+
+// Requests returns how many times a JSON-RPC method or REST path (without the query) was requested.
+func (s *server) Requests(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[key]
+}
+
+func (s *server) addFixture(key string, response json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures[key] = append(s.fixtures[key], response)
+}
+
+// replay returns the next recorded response for key, if there is one.
+func (s *server) replay(key string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	responses := s.fixtures[key]
+	if len(responses) == 0 {
+		return nil, false
+	}
+	if len(responses) > 1 {
+		s.fixtures[key] = responses[1:]
+	}
+	return responses[0], true
+}
+
+func (s *server) countRequest(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[key]++
+}
+
+func (s *server) nextFault() Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failNext) != 0 {
+		f := s.failNext[0]
+		s.failNext = s.failNext[1:]
+		return f
+	}
+	return s.fault
+}
+
+func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f := s.nextFault()
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if f.Status != 0 {
+		w.WriteHeader(f.Status)
+		_, _ = w.Write([]byte(f.Body))
+		return
+	}
+	if f.Body != "" {
+		// The request is still handled, so it is counted and consumes fixtures.
+		s.respondFn(httptest.NewRecorder(), r)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(f.Body))
+		return
+	}
+	s.respondFn(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,113 @@
+package mockchain
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestEVMServer(t *testing.T) {
+	s := NewEVMServer(1)
+	defer s.Close()
+	ctx := context.Background()
+
+	c, err := ethclient.DialContext(ctx, s.URL)
+	require.NoError(t, err)
+	defer c.Close()
+
+	id, err := c.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id.Int64())
+
+	SetEVMBlockNumber(s, 42)
+	height, err := c.BlockNumber(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), height)
+
+	// Fixtures take precedence, and the last one is repeated.
+	fixtures, err := LoadFixtures("testdata/evm.json")
+	require.NoError(t, err)
+	require.NoError(t, s.AddFixtures(fixtures))
+	for _, want := range []uint64{0x10, 0x11, 0x11} {
+		height, err := c.BlockNumber(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, height)
+	}
+	assert.Equal(t, 4, s.Requests("eth_blockNumber"))
+
+	_, err = c.PendingNonceAt(ctx, [20]byte{})
+	assert.Error(t, err, "unknown methods fail")
+}
+
+func TestSolanaServer(t *testing.T) {
+	s := NewSolanaServer()
+	defer s.Close()
+	ctx := context.Background()
+	c := rpc.New(s.URL)
+
+	SetSolanaSlot(s, 1234)
+	slot, err := c.GetSlot(ctx, rpc.CommitmentFinalized)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234), uint64(slot))
+
+	s.FailNext(1, Fault{Status: http.StatusServiceUnavailable})
+	_, err = c.GetSlot(ctx, rpc.CommitmentFinalized)
+	assert.Error(t, err)
+	_, err = c.GetSlot(ctx, rpc.CommitmentFinalized)
+	assert.NoError(t, err)
+
+	s.SetFault(Fault{Body: `{"jsonrpc":`})
+	_, err = c.GetSlot(ctx, rpc.CommitmentFinalized)
+	assert.Error(t, err)
+	s.SetFault(Fault{})
+
+	s.SetFault(Fault{Latency: 50 * time.Millisecond})
+	start := time.Now()
+	_, err = c.GetSlot(ctx, rpc.CommitmentFinalized)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestAptosServer(t *testing.T) {
+	s := NewAptosServer()
+	defer s.Close()
+
+	get := func(path string) gjson.Result {
+		resp, err := http.Get(s.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.True(t, gjson.ValidBytes(b), string(b))
+		return gjson.ParseBytes(b)
+	}
+
+	s.SetBlockHeight(7)
+	assert.Equal(t, uint64(7), get("/v1").Get("block_height").Uint())
+
+	events := "/v1/accounts/0x1/events/0x1::state::WormholeMessageHandle/event"
+	assert.Len(t, get(events).Array(), 0)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.AddEvent("0x1", "0x1::state::WormholeMessageHandle", map[string]int{"sequence": i}))
+	}
+
+	latest := get(events + "?limit=1").Array()
+	require.Len(t, latest, 1)
+	assert.Equal(t, uint64(2), latest[0].Get("sequence_number").Uint())
+
+	page := get(events + "?start=1").Array()
+	require.Len(t, page, 2)
+	assert.Equal(t, uint64(1), page[0].Get("data.sequence").Uint())
+
+	require.NoError(t, s.AddFixtures([]Fixture{{Path: "/v1", Response: []byte(`{"block_height":"99"}`)}}))
+	assert.Equal(t, uint64(99), get("/v1").Get("block_height").Uint())
+	assert.Equal(t, 3, s.Requests(events))
+}
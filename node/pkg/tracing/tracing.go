@@ -0,0 +1,129 @@
+// Package tracing exports OpenTelemetry traces of the journey of each message through the node, from the watcher
+// observing it to the signed VAA being stored, so that latency regressions can be pinpointed per chain.
+//
+// The spans of a message are correlated by its message ID: the ID of its trace is derived from the message ID. This
+// puts the spans recorded by the watchers, the processor and the database in the same trace without passing a context
+// along the channels between them, and the traces of different guardians for the same message share the same ID.
+//
+// Unless Init is called, spans are not recorded and the functions of this package are no-ops.
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/certusone/wormhole/node/pkg/tracing")
+
+type messageIDKey struct{}
+
+// messageIDGenerator derives the trace ID of the spans started with a message ID in their context from the message ID.
+type messageIDGenerator struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newMessageIDGenerator() *messageIDGenerator {
+	var seed int64
+	_ = binary.Read(crand.Reader, binary.LittleEndian, &seed)
+	return &messageIDGenerator{rand: rand.New(rand.NewSource(seed))} // #nosec G404 span IDs need not be unpredictable
+}
+
+// MessageTraceID returns the trace ID of the spans of the given message.
+func MessageTraceID(messageID string) trace.TraceID {
+	var tid trace.TraceID
+	h := sha256.Sum256([]byte(messageID))
+	copy(tid[:], h[:])
+	return tid
+}
+
+func (g *messageIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	if id, ok := ctx.Value(messageIDKey{}).(string); ok {
+		tid = MessageTraceID(id)
+	} else {
+		g.mu.Lock()
+		_, _ = g.rand.Read(tid[:])
+		g.mu.Unlock()
+	}
+	return tid, g.NewSpanID(ctx, tid)
+}
+
+func (g *messageIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var sid trace.SpanID
+	g.mu.Lock()
+	_, _ = g.rand.Read(sid[:])
+	g.mu.Unlock()
+	return sid
+}
+
+func newTracerProvider(sampleRatio float64, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithIDGenerator(newMessageIDGenerator()),
+		// The trace ID of a message is the same on all guardians, and so is the decision to sample it.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Init exports the spans to the OTLP/HTTP endpoint at the given host:port, sampling the given ratio of messages. The
+// attributes identify the node. The returned function flushes the remaining spans and stops the export.
+func Init(ctx context.Context, endpoint string, insecure bool, sampleRatio float64, attrs ...attribute.KeyValue) (func(context.Context) error, error) {
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio must be between 0 and 1, got %v", sampleRatio)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		append([]attribute.KeyValue{semconv.ServiceNameKey.String("guardiand")}, attrs...)...)
+
+	tp := newTracerProvider(sampleRatio, sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func messageContext(chain vaa.ChainID, messageID string, attrs []attribute.KeyValue) (context.Context, trace.SpanStartOption) {
+	ctx := context.WithValue(context.Background(), messageIDKey{}, messageID)
+	return ctx, trace.WithAttributes(append([]attribute.KeyValue{
+		attribute.String("message_id", messageID),
+		attribute.String("emitter_chain", chain.String()),
+	}, attrs...)...)
+}
+
+// Start starts a span in the trace of the given message. The span must be ended by the caller.
+func Start(chain vaa.ChainID, messageID string, name string, attrs ...attribute.KeyValue) trace.Span {
+	ctx, opt := messageContext(chain, messageID, attrs)
+	_, span := tracer.Start(ctx, name, opt)
+	return span
+}
+
+// Record records a span that started and ended at the given times in the trace of the given message. This is useful
+// when the message ID is only known once the work is done, for instance after parsing the message.
+func Record(chain vaa.ChainID, messageID string, name string, start time.Time, end time.Time, attrs ...attribute.KeyValue) {
+	ctx, opt := messageContext(chain, messageID, attrs)
+	_, span := tracer.Start(ctx, name, opt, trace.WithTimestamp(start))
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpansAreCorrelatedByMessageID(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(newTracerProvider(1, sdktrace.WithSpanProcessor(recorder)))
+
+	const id1 = "2/0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585/42"
+	const id2 = "2/0000000000000000000000003ee18b2214aff97000d974cf647e7c347e8fa585/43"
+
+	start := time.Unix(1000, 0)
+	Record(vaa.ChainIDEthereum, id1, "watcher.fetch", start, start.Add(time.Second))
+	Start(vaa.ChainIDEthereum, id1, "processor.sign").End()
+	Start(vaa.ChainIDEthereum, id2, "processor.sign").End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, MessageTraceID(id1), spans[0].SpanContext().TraceID())
+	assert.Equal(t, MessageTraceID(id1), spans[1].SpanContext().TraceID())
+	assert.Equal(t, MessageTraceID(id2), spans[2].SpanContext().TraceID())
+	assert.NotEqual(t, MessageTraceID(id1), MessageTraceID(id2))
+	assert.NotEqual(t, spans[0].SpanContext().SpanID(), spans[1].SpanContext().SpanID())
+
+	assert.Equal(t, "watcher.fetch", spans[0].Name())
+	assert.Equal(t, start, spans[0].StartTime())
+	assert.Equal(t, start.Add(time.Second), spans[0].EndTime())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("message_id", id1))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("emitter_chain", "ethereum"))
+}
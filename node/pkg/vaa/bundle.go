@@ -0,0 +1,165 @@
+package vaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignatureBundleVersion is the version of the signature bundle format.
+const SignatureBundleVersion uint8 = 1
+
+// signatureBundleHeaderLength is the length of a serialized signature bundle without signatures: version, digest,
+// guardian set index and number of signatures.
+const signatureBundleHeaderLength = 1 + 32 + 4 + 1
+
+// SignatureBundle is a set of guardian signatures of a VAA digest, distributed without the VAA body. Archival services
+// use it to add signatures to a VAA they already have, or to replace its signatures with those of a later guardian set,
+// without the body being re-issued.
+//
+// Its binary representation is the version, the digest, the guardian set index, the number of signatures and the
+// signatures, each being the index of the guardian in the set followed by the 65 bytes of the signature, like in a VAA.
+type SignatureBundle struct {
+	// Digest of the VAA body, as returned by SigningMsg
+	Digest common.Hash
+	// GuardianSetIndex is the index of the guardian set the signatures are from
+	GuardianSetIndex uint32
+	// Signatures ordered by guardian index
+	Signatures []*Signature
+}
+
+// NewSignatureBundle returns a bundle of the signatures of a VAA.
+func NewSignatureBundle(v *VAA) *SignatureBundle {
+	b := &SignatureBundle{
+		Digest:           v.SigningMsg(),
+		GuardianSetIndex: v.GuardianSetIndex,
+		Signatures:       make([]*Signature, len(v.Signatures)),
+	}
+	for i, sig := range v.Signatures {
+		b.Signatures[i] = &Signature{Index: sig.Index, Signature: sig.Signature}
+	}
+	return b
+}
+
+// Marshal returns the binary representation of the bundle.
+func (b *SignatureBundle) Marshal() ([]byte, error) {
+	if len(b.Signatures) > 255 {
+		return nil, fmt.Errorf("too many signatures: %d", len(b.Signatures))
+	}
+
+	buf := new(bytes.Buffer)
+	MustWrite(buf, binary.BigEndian, SignatureBundleVersion)
+	buf.Write(b.Digest[:])
+	MustWrite(buf, binary.BigEndian, b.GuardianSetIndex)
+	MustWrite(buf, binary.BigEndian, uint8(len(b.Signatures)))
+	for _, sig := range b.Signatures {
+		MustWrite(buf, binary.BigEndian, sig.Index)
+		buf.Write(sig.Signature[:])
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalSignatureBundle deserializes the binary representation of a signature bundle. Trailing bytes are rejected.
+func UnmarshalSignatureBundle(data []byte) (*SignatureBundle, error) {
+	if len(data) < signatureBundleHeaderLength {
+		return nil, errors.New("signature bundle is too short")
+	}
+	if data[0] != SignatureBundleVersion {
+		return nil, fmt.Errorf("unsupported signature bundle version: %d", data[0])
+	}
+
+	b := &SignatureBundle{}
+	copy(b.Digest[:], data[1:33])
+	b.GuardianSetIndex = binary.BigEndian.Uint32(data[33:37])
+
+	numSignatures := int(data[37])
+	if len(data) != signatureBundleHeaderLength+numSignatures*66 {
+		return nil, fmt.Errorf("signature bundle of %d signatures has an invalid length: %d", numSignatures, len(data))
+	}
+
+	b.Signatures = make([]*Signature, numSignatures)
+	for i := 0; i < numSignatures; i++ {
+		offset := signatureBundleHeaderLength + i*66
+		sig := &Signature{Index: data[offset]}
+		copy(sig.Signature[:], data[offset+1:offset+66])
+		b.Signatures[i] = sig
+	}
+	return b, nil
+}
+
+// Verify checks that the signatures are ordered by guardian index and are by the guardians of the given guardian set,
+// which must be the set of the bundle's GuardianSetIndex. Unlike VAA.VerifySignatures, it returns why a bundle is
+// rejected.
+func (b *SignatureBundle) Verify(addresses []common.Address) error {
+	lastIndex := -1
+	for _, sig := range b.Signatures {
+		if int(sig.Index) >= len(addresses) {
+			return fmt.Errorf("guardian index %d is not in the guardian set of %d guardians", sig.Index, len(addresses))
+		}
+		if int(sig.Index) <= lastIndex {
+			return fmt.Errorf("guardian index %d is out of order or duplicated", sig.Index)
+		}
+		lastIndex = int(sig.Index)
+
+		pubKey, err := crypto.Ecrecover(b.Digest.Bytes(), sig.Signature[:])
+		if err != nil {
+			return fmt.Errorf("invalid signature of guardian %d: %w", sig.Index, err)
+		}
+		if addr := common.BytesToAddress(crypto.Keccak256(pubKey[1:])[12:]); addr != addresses[sig.Index] {
+			return fmt.Errorf("signature of guardian %d is by %s instead of %s", sig.Index, addr, addresses[sig.Index])
+		}
+	}
+	return nil
+}
+
+// MergeSignatureBundle returns a copy of a VAA with the signatures of a bundle of the same digest, verified against the
+// addresses of the bundle's guardian set.
+//
+// If the bundle is from the guardian set of the VAA, the signatures of the guardians the VAA lacks are added to the
+// VAA's. The VAA's signatures are kept for the guardians in both. If the bundle is from another guardian set, it must
+// reach quorum in that set, and replaces the signatures of the VAA.
+func MergeSignatureBundle(v *VAA, b *SignatureBundle, addresses []common.Address) (*VAA, error) {
+	if digest := v.SigningMsg(); b.Digest != digest {
+		return nil, fmt.Errorf("signature bundle is for digest %s, not %s", b.Digest.Hex(), digest.Hex())
+	}
+	if err := b.Verify(addresses); err != nil {
+		return nil, err
+	}
+
+	merged := *v
+	if b.GuardianSetIndex != v.GuardianSetIndex {
+		if !HasQuorum(len(b.Signatures), len(addresses)) {
+			return nil, fmt.Errorf("signature bundle of guardian set %d has %d signatures, less than the quorum of %d",
+				b.GuardianSetIndex, len(b.Signatures), CalculateQuorum(len(addresses)))
+		}
+		merged.GuardianSetIndex = b.GuardianSetIndex
+		merged.Signatures = make([]*Signature, 0, len(b.Signatures))
+		for _, sig := range b.Signatures {
+			merged.Signatures = append(merged.Signatures, &Signature{Index: sig.Index, Signature: sig.Signature})
+		}
+		return &merged, nil
+	}
+
+	byIndex := make(map[uint8]*Signature, len(v.Signatures)+len(b.Signatures))
+	for _, sig := range b.Signatures {
+		byIndex[sig.Index] = sig
+	}
+	for _, sig := range v.Signatures {
+		byIndex[sig.Index] = sig
+	}
+	merged.Signatures = make([]*Signature, 0, len(byIndex))
+	for _, sig := range byIndex {
+		merged.Signatures = append(merged.Signatures, &Signature{Index: sig.Index, Signature: sig.Signature})
+	}
+	sort.Slice(merged.Signatures, func(i, j int) bool { return merged.Signatures[i].Index < merged.Signatures[j].Index })
+
+	if !merged.VerifySignatures(addresses) {
+		return nil, errors.New("the signatures of the VAA are invalid")
+	}
+	return &merged, nil
+}
@@ -0,0 +1,110 @@
+package vaa
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bundleGuardianSet returns the keys and addresses of a guardian set of n guardians.
+func bundleGuardianSet(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		addrs[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addrs
+}
+
+// signedBundle returns a bundle of the signatures of the given guardians.
+func signedBundle(v VAA, guardianSetIndex uint32, keys []*ecdsa.PrivateKey, indexes ...uint8) *SignatureBundle {
+	v.GuardianSetIndex = guardianSetIndex
+	v.Signatures = nil
+	for _, i := range indexes {
+		v.AddSignature(keys[i], i)
+	}
+	return NewSignatureBundle(&v)
+}
+
+func TestSignatureBundleMarshal(t *testing.T) {
+	keys, _ := bundleGuardianSet(t, 3)
+	b := signedBundle(getVaa(), 4, keys, 0, 2)
+
+	data, err := b.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, signatureBundleHeaderLength+2*66, len(data))
+
+	decoded, err := UnmarshalSignatureBundle(data)
+	require.NoError(t, err)
+	assert.Equal(t, b, decoded)
+
+	_, err = UnmarshalSignatureBundle(data[:len(data)-1])
+	assert.Error(t, err)
+	_, err = UnmarshalSignatureBundle(append(data, 0))
+	assert.Error(t, err)
+	data[0] = 2
+	_, err = UnmarshalSignatureBundle(data)
+	assert.Error(t, err)
+}
+
+func TestSignatureBundleVerify(t *testing.T) {
+	keys, addrs := bundleGuardianSet(t, 3)
+	v := getVaa()
+
+	assert.NoError(t, signedBundle(v, 1, keys, 0, 1, 2).Verify(addrs))
+	assert.Error(t, signedBundle(v, 1, keys, 1, 0).Verify(addrs))
+	assert.Error(t, signedBundle(v, 1, keys, 0, 0).Verify(addrs))
+	assert.Error(t, signedBundle(v, 1, keys, 0).Verify(addrs[1:]))
+
+	// A signature at the wrong index.
+	b := signedBundle(v, 1, keys, 0)
+	b.Signatures[0].Index = 1
+	assert.Error(t, b.Verify(addrs))
+}
+
+func TestMergeSignatureBundle(t *testing.T) {
+	keys, addrs := bundleGuardianSet(t, 4)
+	v := getVaa()
+	v.AddSignature(keys[1], 1)
+	v.AddSignature(keys[3], 3)
+
+	// The signatures of the same guardian set are added to those of the VAA.
+	merged, err := MergeSignatureBundle(&v, signedBundle(getVaa(), 1, keys, 0, 1), addrs)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(merged.Signatures))
+	for i, index := range []uint8{0, 1, 3} {
+		assert.Equal(t, index, merged.Signatures[i].Index)
+	}
+	assert.True(t, merged.VerifySignatures(addrs))
+	assert.Equal(t, v.SigningMsg(), merged.SigningMsg())
+	// The VAA is not modified.
+	assert.Equal(t, 2, len(v.Signatures))
+
+	// The bundle must be of the VAA's digest.
+	other := getVaa()
+	other.Sequence = 2
+	_, err = MergeSignatureBundle(&v, signedBundle(other, 1, keys, 0), addrs)
+	assert.Error(t, err)
+
+	// The bundle of another guardian set replaces the signatures, if it reaches quorum in that set.
+	newKeys, newAddrs := bundleGuardianSet(t, 4)
+	_, err = MergeSignatureBundle(&v, signedBundle(getVaa(), 2, newKeys, 0, 1), newAddrs)
+	assert.Error(t, err)
+	merged, err = MergeSignatureBundle(&v, signedBundle(getVaa(), 2, newKeys, 0, 1, 2), newAddrs)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), merged.GuardianSetIndex)
+	assert.Equal(t, 3, len(merged.Signatures))
+	assert.True(t, merged.VerifySignatures(newAddrs))
+
+	// A bundle signed by other guardians is rejected.
+	_, err = MergeSignatureBundle(&v, signedBundle(getVaa(), 1, newKeys, 0), addrs)
+	assert.Error(t, err)
+}
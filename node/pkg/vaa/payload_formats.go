@@ -0,0 +1,286 @@
+package vaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The payload formats of the integrators supported out of the box:
+//   - cctp: the deposits of the Wormhole Circle integration, which burns USDC with CCTP and publishes the transfer.
+//   - ntt: the transfers of the Native Token Transfers managers, sent through the Wormhole transceivers.
+//   - relayer: the delivery instructions of the standard relayer.
+//
+// CCTP deposits and relayer instructions cannot be recognized from their payload alone, so their decoders require the
+// emitters of the integration contracts. NTT messages start with a prefix and are decoded from any emitter by default.
+
+func init() {
+	RegisterPayloadFormat("cctp", newCCTPDecoder)
+	RegisterPayloadFormat("ntt", newNTTDecoder)
+	RegisterPayloadFormat("relayer", newRelayerDecoder)
+}
+
+var errIntegratorPayloadTooShort = errors.New("payload too short")
+
+// integratorPayloadReader reads the fields of a payload, remembering the first error.
+type integratorPayloadReader struct {
+	b   []byte
+	err error
+}
+
+func (r *integratorPayloadReader) next(n int) []byte {
+	if r.err != nil || len(r.b) < n {
+		r.err = errIntegratorPayloadTooShort
+		// Zeroes for the fixed size fields. Variable length fields may claim any length, so they are not allocated.
+		if n > 32 {
+			n = 0
+		}
+		return make([]byte, n)
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *integratorPayloadReader) uint8() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *integratorPayloadReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *integratorPayloadReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *integratorPayloadReader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.next(8))
+}
+
+func (r *integratorPayloadReader) uint256() *big.Int {
+	return new(big.Int).SetBytes(r.next(32))
+}
+
+func (r *integratorPayloadReader) address() Address {
+	var a Address
+	copy(a[:], r.next(32))
+	return a
+}
+
+func (p *DecodedPayload) add(name string, value interface{}) {
+	p.Fields = append(p.Fields, PayloadField{Name: name, Value: fmt.Sprint(value)})
+}
+
+type cctpDecoder struct {
+	emitters payloadEmitterSet
+}
+
+// The chains of the CCTP domains. The recipient chain is left unset for the domains of other chains.
+var cctpDomainChains = map[uint32]ChainID{
+	0: ChainIDEthereum,
+	1: ChainIDAvalanche,
+	5: ChainIDSolana,
+	7: ChainIDPolygon,
+}
+
+func newCCTPDecoder(emitters []PayloadEmitter) (PayloadDecoder, error) {
+	if len(emitters) == 0 {
+		return nil, errors.New("the cctp payload format requires the emitters of the Circle integration contracts")
+	}
+	return &cctpDecoder{emitters: newPayloadEmitterSet(emitters)}, nil
+}
+
+func (d *cctpDecoder) Name() string {
+	return "cctp"
+}
+
+// Deposit: type [1], token [32], amount [32], source domain [4], target domain [4], nonce [8], from address [32],
+// mint recipient [32], payload length [2], payload.
+func (d *cctpDecoder) Decode(emitterChain ChainID, emitterAddress Address, payload []byte) (*DecodedPayload, error) {
+	if !d.emitters.matches(emitterChain, emitterAddress) || len(payload) == 0 || payload[0] != 1 {
+		return nil, nil
+	}
+
+	r := &integratorPayloadReader{b: payload[1:]}
+	token := r.address()
+	amount := r.uint256()
+	sourceDomain := r.uint32()
+	targetDomain := r.uint32()
+	nonce := r.uint64()
+	from := r.address()
+	recipient := r.address()
+	data := r.next(int(r.uint16()))
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.b) != 0 {
+		return nil, fmt.Errorf("%d unexpected trailing bytes", len(r.b))
+	}
+
+	p := &DecodedPayload{
+		Format: d.Name(),
+		Type:   "deposit",
+		Transfer: &DecodedTransfer{
+			Amount:         amount,
+			TokenChain:     emitterChain,
+			TokenAddress:   token,
+			RecipientChain: cctpDomainChains[targetDomain],
+			Recipient:      recipient,
+		},
+	}
+	p.add("token", token)
+	p.add("amount", amount)
+	p.add("sourceDomain", sourceDomain)
+	p.add("targetDomain", targetDomain)
+	p.add("nonce", nonce)
+	p.add("fromAddress", from)
+	p.add("mintRecipient", recipient)
+	p.add("payload", hex.EncodeToString(data))
+	return p, nil
+}
+
+type nttDecoder struct {
+	emitters payloadEmitterSet
+}
+
+var (
+	nttTransceiverPrefix = []byte{0x99, 0x45, 0xff, 0x10}
+	nttTransferPrefix    = []byte{0x99, 0x4e, 0x54, 0x54}
+)
+
+func newNTTDecoder(emitters []PayloadEmitter) (PayloadDecoder, error) {
+	return &nttDecoder{emitters: newPayloadEmitterSet(emitters)}, nil
+}
+
+func (d *nttDecoder) Name() string {
+	return "ntt"
+}
+
+// Transceiver message: prefix [4], source manager [32], recipient manager [32], manager payload length [2], manager
+// payload, transceiver payload length [2], transceiver payload. Manager payload: ID [32], sender [32], payload length
+// [2], payload. Transfer payload: prefix [4], decimals [1], amount [8], source token [32], recipient [32], recipient
+// chain [2].
+func (d *nttDecoder) Decode(emitterChain ChainID, emitterAddress Address, payload []byte) (*DecodedPayload, error) {
+	if !d.emitters.matches(emitterChain, emitterAddress) || !bytes.HasPrefix(payload, nttTransceiverPrefix) {
+		return nil, nil
+	}
+
+	r := &integratorPayloadReader{b: payload[len(nttTransceiverPrefix):]}
+	sourceManager := r.address()
+	recipientManager := r.address()
+	m := &integratorPayloadReader{b: r.next(int(r.uint16()))}
+	r.next(int(r.uint16()))
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	id := m.next(32)
+	sender := m.address()
+	data := m.next(int(m.uint16()))
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	p := &DecodedPayload{Format: d.Name(), Type: "message"}
+	p.add("sourceManager", sourceManager)
+	p.add("recipientManager", recipientManager)
+	p.add("id", hex.EncodeToString(id))
+	p.add("sender", sender)
+	if !bytes.HasPrefix(data, nttTransferPrefix) {
+		p.add("payload", hex.EncodeToString(data))
+		return p, nil
+	}
+
+	t := &integratorPayloadReader{b: data[len(nttTransferPrefix):]}
+	decimals := t.uint8()
+	amount := new(big.Int).SetUint64(t.uint64())
+	token := t.address()
+	recipient := t.address()
+	recipientChain := ChainID(t.uint16())
+	if t.err != nil {
+		return nil, t.err
+	}
+	if decimals > 8 {
+		return nil, fmt.Errorf("invalid decimals %d for a trimmed amount", decimals)
+	}
+
+	p.Type = "transfer"
+	p.Transfer = &DecodedTransfer{
+		Amount:         amount,
+		TokenChain:     emitterChain,
+		TokenAddress:   token,
+		RecipientChain: recipientChain,
+		Recipient:      recipient,
+	}
+	p.add("decimals", decimals)
+	p.add("amount", amount)
+	p.add("sourceToken", token)
+	p.add("recipient", recipient)
+	p.add("recipientChain", recipientChain)
+	return p, nil
+}
+
+type relayerDecoder struct {
+	emitters payloadEmitterSet
+}
+
+func newRelayerDecoder(emitters []PayloadEmitter) (PayloadDecoder, error) {
+	if len(emitters) == 0 {
+		return nil, errors.New("the relayer payload format requires the emitters of the relayer contracts")
+	}
+	return &relayerDecoder{emitters: newPayloadEmitterSet(emitters)}, nil
+}
+
+func (d *relayerDecoder) Name() string {
+	return "relayer"
+}
+
+// Delivery instruction: type [1], target chain [2], target address [32], payload length [4], payload, requested
+// receiver value [32], extra receiver value [32], execution info length [4], execution info, refund chain [2], refund
+// address [32], refund delivery provider [32], source delivery provider [32], sender [32], followed by the message
+// keys, which are not decoded.
+func (d *relayerDecoder) Decode(emitterChain ChainID, emitterAddress Address, payload []byte) (*DecodedPayload, error) {
+	if !d.emitters.matches(emitterChain, emitterAddress) || len(payload) == 0 {
+		return nil, nil
+	}
+
+	p := &DecodedPayload{Format: d.Name()}
+	if payload[0] != 1 {
+		p.Type = fmt.Sprintf("unknown(%d)", payload[0])
+		return p, nil
+	}
+
+	r := &integratorPayloadReader{b: payload[1:]}
+	targetChain := ChainID(r.uint16())
+	targetAddress := r.address()
+	data := r.next(int(r.uint32()))
+	requestedReceiverValue := r.uint256()
+	extraReceiverValue := r.uint256()
+	executionInfo := r.next(int(r.uint32()))
+	refundChain := ChainID(r.uint16())
+	refundAddress := r.address()
+	refundDeliveryProvider := r.address()
+	sourceDeliveryProvider := r.address()
+	sender := r.address()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	p.Type = "delivery"
+	p.add("targetChain", targetChain)
+	p.add("targetAddress", targetAddress)
+	p.add("payload", hex.EncodeToString(data))
+	p.add("requestedReceiverValue", requestedReceiverValue)
+	p.add("extraReceiverValue", extraReceiverValue)
+	p.add("executionInfo", hex.EncodeToString(executionInfo))
+	p.add("refundChain", refundChain)
+	p.add("refundAddress", refundAddress)
+	p.add("refundDeliveryProvider", refundDeliveryProvider)
+	p.add("sourceDeliveryProvider", sourceDeliveryProvider)
+	p.add("sender", sender)
+	return p, nil
+}
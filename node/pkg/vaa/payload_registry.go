@@ -0,0 +1,209 @@
+package vaa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+)
+
+// The payload registry decodes the payloads of integrators built on top of the core bridge, such as CCTP transfers,
+// Native Token Transfers or standard relayer deliveries, so that the spy, the admin commands and the chain governor
+// decode them the same way. Payload formats are registered by name with RegisterPayloadFormat, and a registry is built
+// from a configuration file listing the formats to decode and the emitters they are decoded for.
+
+type (
+	// PayloadEmitter identifies an emitter whose payloads are decoded by a PayloadDecoder.
+	PayloadEmitter struct {
+		Chain   ChainID
+		Address Address
+	}
+
+	// PayloadDecoder decodes the payloads of an integrator.
+	PayloadDecoder interface {
+		// Name returns the name of the payload format, such as "cctp".
+		Name() string
+
+		// Decode returns nil if the message is not in the format of the decoder, and an error if it is but cannot be
+		// decoded.
+		Decode(emitterChain ChainID, emitterAddress Address, payload []byte) (*DecodedPayload, error)
+	}
+
+	// PayloadDecoderFactory creates a decoder for the payloads of the given emitters, or of any emitter if there are none.
+	PayloadDecoderFactory func(emitters []PayloadEmitter) (PayloadDecoder, error)
+
+	// PayloadField is a named value of a DecodedPayload.
+	PayloadField struct {
+		Name  string
+		Value string
+	}
+
+	// DecodedPayload is the description of a payload decoded by a PayloadDecoder.
+	DecodedPayload struct {
+		// Name of the payload format.
+		Format string
+		// Type of the message within the format, such as "transfer".
+		Type string
+		// Fields of the payload, in the order they are encoded.
+		Fields []PayloadField
+		// Set if the message transfers tokens.
+		Transfer *DecodedTransfer
+	}
+
+	// DecodedTransfer is a token transfer decoded from an integrator payload.
+	DecodedTransfer struct {
+		// The amount, with the decimals of the token capped at eight like token bridge amounts.
+		Amount *big.Int
+		// The token, as known on its chain. Integrators which do not wrap tokens send the token of the emitter chain.
+		TokenChain   ChainID
+		TokenAddress Address
+		// The recipient, with an unset chain if it cannot be determined.
+		RecipientChain ChainID
+		Recipient      Address
+	}
+
+	// PayloadRegistry holds the decoders used to decode integrator payloads. A nil registry decodes nothing.
+	PayloadRegistry struct {
+		decoders []PayloadDecoder
+	}
+
+	payloadRegistryConfig struct {
+		Decoders []struct {
+			Format   string `json:"format"`
+			Emitters []struct {
+				Chain   uint16 `json:"chain"`
+				Address string `json:"address"`
+			} `json:"emitters"`
+		} `json:"decoders"`
+	}
+)
+
+var (
+	payloadFormatsMu sync.Mutex
+	payloadFormats   = make(map[string]PayloadDecoderFactory)
+)
+
+// RegisterPayloadFormat makes a payload format available to the registries built from a configuration. It panics if a
+// format is registered twice, and is meant to be called from an init function.
+func RegisterPayloadFormat(name string, factory PayloadDecoderFactory) {
+	payloadFormatsMu.Lock()
+	defer payloadFormatsMu.Unlock()
+	if _, exists := payloadFormats[name]; exists {
+		panic(fmt.Sprintf("payload format %s registered twice", name))
+	}
+	payloadFormats[name] = factory
+}
+
+// PayloadFormats returns the names of the registered payload formats, sorted.
+func PayloadFormats() []string {
+	payloadFormatsMu.Lock()
+	defer payloadFormatsMu.Unlock()
+	names := make([]string, 0, len(payloadFormats))
+	for name := range payloadFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewPayloadDecoder creates a decoder of a registered payload format.
+func NewPayloadDecoder(format string, emitters []PayloadEmitter) (PayloadDecoder, error) {
+	payloadFormatsMu.Lock()
+	factory, exists := payloadFormats[format]
+	payloadFormatsMu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown payload format %q", format)
+	}
+	return factory(emitters)
+}
+
+// NewPayloadRegistry creates an empty registry.
+func NewPayloadRegistry() *PayloadRegistry {
+	return &PayloadRegistry{}
+}
+
+// Register adds a decoder to the registry. Decoders are consulted in the order they were added, and the first one that
+// claims a message decodes it. It must be called before the registry is used.
+func (r *PayloadRegistry) Register(d PayloadDecoder) {
+	r.decoders = append(r.decoders, d)
+}
+
+// Decode returns the payload decoded by the first decoder that claims the message, or nil if there is none.
+func (r *PayloadRegistry) Decode(emitterChain ChainID, emitterAddress Address, payload []byte) (*DecodedPayload, error) {
+	if r == nil {
+		return nil, nil
+	}
+	for _, d := range r.decoders {
+		p, err := d.Decode(emitterChain, emitterAddress, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", d.Name(), err)
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// ParsePayloadRegistry builds a registry from a JSON configuration such as:
+//
+//	{"decoders": [{"format": "cctp", "emitters": [{"chain": 2, "address": "000000000000000000000000aada05bd399372f0b0463744c09113c137636f6a"}]}, {"format": "ntt"}]}
+func ParsePayloadRegistry(b []byte) (*PayloadRegistry, error) {
+	var cfg payloadRegistryConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse payload registry config: %w", err)
+	}
+	if len(cfg.Decoders) == 0 {
+		return nil, errors.New("the payload registry config has no decoders")
+	}
+
+	r := NewPayloadRegistry()
+	for _, c := range cfg.Decoders {
+		var emitters []PayloadEmitter
+		for _, e := range c.Emitters {
+			addr, err := StringToAddress(e.Address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s emitter address %q: %w", c.Format, e.Address, err)
+			}
+			emitters = append(emitters, PayloadEmitter{Chain: ChainID(e.Chain), Address: addr})
+		}
+		d, err := NewPayloadDecoder(c.Format, emitters)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(d)
+	}
+	return r, nil
+}
+
+// LoadPayloadRegistry builds a registry from a JSON configuration file, see ParsePayloadRegistry.
+func LoadPayloadRegistry(path string) (*PayloadRegistry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload registry config: %w", err)
+	}
+	return ParsePayloadRegistry(b)
+}
+
+// payloadEmitterSet matches the emitters given to a decoder factory.
+type payloadEmitterSet map[PayloadEmitter]struct{}
+
+func newPayloadEmitterSet(emitters []PayloadEmitter) payloadEmitterSet {
+	s := make(payloadEmitterSet, len(emitters))
+	for _, e := range emitters {
+		s[e] = struct{}{}
+	}
+	return s
+}
+
+// matches returns true if the set contains the emitter, or is empty.
+func (s payloadEmitterSet) matches(chain ChainID, addr Address) bool {
+	if len(s) == 0 {
+		return true
+	}
+	_, exists := s[PayloadEmitter{Chain: chain, Address: addr}]
+	return exists
+}
@@ -0,0 +1,151 @@
+package vaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testIntegratorEmitter = Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7}
+	testIntegratorToken   = Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8}
+	testIntegratorUser    = Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9}
+)
+
+func testCCTPPayload() []byte {
+	buf := new(bytes.Buffer)
+	MustWrite(buf, binary.BigEndian, uint8(1))
+	buf.Write(testIntegratorToken[:])
+	buf.Write(common.LeftPadBytes(big.NewInt(1000000).Bytes(), 32))
+	MustWrite(buf, binary.BigEndian, uint32(0))
+	MustWrite(buf, binary.BigEndian, uint32(5))
+	MustWrite(buf, binary.BigEndian, uint64(42))
+	buf.Write(testIntegratorUser[:])
+	buf.Write(testIntegratorUser[:])
+	MustWrite(buf, binary.BigEndian, uint16(2))
+	buf.Write([]byte{0xab, 0xcd})
+	return buf.Bytes()
+}
+
+func testNTTPayload() []byte {
+	transfer := new(bytes.Buffer)
+	transfer.Write(nttTransferPrefix)
+	MustWrite(transfer, binary.BigEndian, uint8(8))
+	MustWrite(transfer, binary.BigEndian, uint64(500))
+	transfer.Write(testIntegratorToken[:])
+	transfer.Write(testIntegratorUser[:])
+	MustWrite(transfer, binary.BigEndian, uint16(ChainIDSolana))
+
+	manager := new(bytes.Buffer)
+	manager.Write(make([]byte, 32))
+	manager.Write(testIntegratorUser[:])
+	MustWrite(manager, binary.BigEndian, uint16(transfer.Len()))
+	manager.Write(transfer.Bytes())
+
+	buf := new(bytes.Buffer)
+	buf.Write(nttTransceiverPrefix)
+	buf.Write(testIntegratorEmitter[:])
+	buf.Write(testIntegratorEmitter[:])
+	MustWrite(buf, binary.BigEndian, uint16(manager.Len()))
+	buf.Write(manager.Bytes())
+	MustWrite(buf, binary.BigEndian, uint16(0))
+	return buf.Bytes()
+}
+
+func testDeliveryPayload() []byte {
+	buf := new(bytes.Buffer)
+	MustWrite(buf, binary.BigEndian, uint8(1))
+	MustWrite(buf, binary.BigEndian, uint16(ChainIDPolygon))
+	buf.Write(testIntegratorUser[:])
+	MustWrite(buf, binary.BigEndian, uint32(1))
+	buf.Write([]byte{0x01})
+	buf.Write(make([]byte, 64))
+	MustWrite(buf, binary.BigEndian, uint32(0))
+	MustWrite(buf, binary.BigEndian, uint16(ChainIDEthereum))
+	buf.Write(testIntegratorUser[:])
+	buf.Write(testIntegratorEmitter[:])
+	buf.Write(testIntegratorEmitter[:])
+	buf.Write(testIntegratorUser[:])
+	// No message keys.
+	MustWrite(buf, binary.BigEndian, uint8(0))
+	return buf.Bytes()
+}
+
+func TestPayloadRegistry(t *testing.T) {
+	cfg := `{"decoders": [
+		{"format": "cctp", "emitters": [{"chain": 2, "address": "0000000000000000000000000000000000000000000000000000000000000007"}]},
+		{"format": "ntt"},
+		{"format": "relayer", "emitters": [{"chain": 4, "address": "0000000000000000000000000000000000000000000000000000000000000007"}]}
+	]}`
+	r, err := ParsePayloadRegistry([]byte(cfg))
+	require.NoError(t, err)
+
+	// CCTP deposits are only decoded for the configured emitters.
+	p, err := r.Decode(ChainIDEthereum, testIntegratorEmitter, testCCTPPayload())
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, "cctp", p.Format)
+	assert.Equal(t, "deposit", p.Type)
+	assert.Equal(t, &DecodedTransfer{
+		Amount:         big.NewInt(1000000),
+		TokenChain:     ChainIDEthereum,
+		TokenAddress:   testIntegratorToken,
+		RecipientChain: ChainIDSolana,
+		Recipient:      testIntegratorUser,
+	}, p.Transfer)
+	assert.Contains(t, p.Fields, PayloadField{Name: "payload", Value: "abcd"})
+
+	p, err = r.Decode(ChainIDPolygon, testIntegratorEmitter, testCCTPPayload())
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	_, err = r.Decode(ChainIDEthereum, testIntegratorEmitter, testCCTPPayload()[:100])
+	assert.Error(t, err)
+
+	// NTT transfers are decoded from any emitter.
+	p, err = r.Decode(ChainIDBSC, testIntegratorUser, testNTTPayload())
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, "ntt", p.Format)
+	assert.Equal(t, "transfer", p.Type)
+	assert.Equal(t, &DecodedTransfer{
+		Amount:         big.NewInt(500),
+		TokenChain:     ChainIDBSC,
+		TokenAddress:   testIntegratorToken,
+		RecipientChain: ChainIDSolana,
+		Recipient:      testIntegratorUser,
+	}, p.Transfer)
+
+	// Relayer deliveries are not transfers.
+	p, err = r.Decode(ChainIDBSC, testIntegratorEmitter, testDeliveryPayload())
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, "delivery", p.Type)
+	assert.Nil(t, p.Transfer)
+	assert.Contains(t, p.Fields, PayloadField{Name: "targetChain", Value: "polygon"})
+
+	// A nil registry decodes nothing.
+	p, err = (*PayloadRegistry)(nil).Decode(ChainIDBSC, testIntegratorUser, testNTTPayload())
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestParsePayloadRegistryErrors(t *testing.T) {
+	for _, cfg := range []string{
+		`{}`,
+		`{"decoders": [{"format": "unknown"}]}`,
+		`{"decoders": [{"format": "cctp"}]}`,
+		`{"decoders": [{"format": "ntt", "emitters": [{"chain": 2, "address": "zz"}]}]}`,
+	} {
+		_, err := ParsePayloadRegistry([]byte(cfg))
+		assert.Error(t, err, cfg)
+	}
+
+	assert.Equal(t, []string{"cctp", "ntt", "relayer"}, PayloadFormats())
+	assert.Panics(t, func() { RegisterPayloadFormat("ntt", newNTTDecoder) })
+}
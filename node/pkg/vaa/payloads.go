@@ -3,6 +3,9 @@ package vaa
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -10,6 +13,15 @@ import (
 // CoreModule is the identifier of the Core module (which is used for governance messages)
 var CoreModule = []byte{00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 00, 0x43, 0x6f, 0x72, 0x65}
 
+// AccountantModule is the identifier of the accountant governance messages ("GlobalAccountant", left-padded to 32 bytes)
+var AccountantModule = common.LeftPadBytes([]byte("GlobalAccountant"), 32)
+
+// ContractRegistryModule is the identifier of the contract registry governance messages ("ContractRegistry", left-padded to 32 bytes)
+var ContractRegistryModule = common.LeftPadBytes([]byte("ContractRegistry"), 32)
+
+// NetworkConfigModule is the identifier of the network configuration governance messages ("NetworkConfig", left-padded to 32 bytes)
+var NetworkConfigModule = common.LeftPadBytes([]byte("NetworkConfig"), 32)
+
 type (
 	// BodyContractUpgrade is a governance message to perform a contract upgrade of the core module
 	BodyContractUpgrade struct {
@@ -23,6 +35,19 @@ type (
 		NewIndex uint32
 	}
 
+	// BodySetMessageFee is a governance message to set the fee for publishing a message on the core contract of a chain
+	BodySetMessageFee struct {
+		ChainID    ChainID
+		MessageFee *big.Int
+	}
+
+	// BodyTransferFees is a governance message to transfer the fees collected by the core contract of a chain to a recipient
+	BodyTransferFees struct {
+		ChainID   ChainID
+		Amount    *big.Int
+		Recipient Address
+	}
+
 	// BodyTokenBridgeRegisterChain is a governance message to register a chain on the token bridge
 	BodyTokenBridgeRegisterChain struct {
 		Module         string
@@ -36,8 +61,69 @@ type (
 		TargetChainID ChainID
 		NewContract   Address
 	}
+
+	// BodyContractRegistry is a governance message publishing the addresses of the Wormhole contracts on EVM chains
+	BodyContractRegistry struct {
+		Version uint64
+		Entries []ContractRegistryEntry
+	}
+
+	// ContractRegistryEntry holds the contract addresses of a chain in a BodyContractRegistry
+	ContractRegistryEntry struct {
+		ChainID      ChainID
+		Core         Address
+		TokenBridge  Address
+		CoreCodeHash [32]byte
+	}
+
+	// BodyNetworkConfig is a governance message scheduling the activation of guardian features across the network
+	BodyNetworkConfig struct {
+		// Version of the configuration, which replaces any configuration with a lower version
+		Version  uint64
+		Features []NetworkConfigFeature
+	}
+
+	// NetworkConfigFeature is the activation time of a feature in a BodyNetworkConfig
+	NetworkConfigFeature struct {
+		// Name of the feature, at most 32 bytes
+		Name string
+		// Unix time (in seconds) from which the feature is active
+		ActivationTime uint64
+	}
+
+	// BodyAccountantModifyBalance is a governance message to correct the accountant balance of a token on a chain
+	BodyAccountantModifyBalance struct {
+		// Sequence of the modification, so that it is only applied once
+		Sequence     uint64
+		ChainID      ChainID
+		TokenChain   ChainID
+		TokenAddress Address
+		Kind         ModificationKind
+		Amount       *big.Int
+		// Human readable reason for the modification, at most 32 bytes
+		Reason string
+	}
+
+	// ModificationKind is whether a BodyAccountantModifyBalance adds to or subtracts from the balance
+	ModificationKind uint8
 )
 
+const (
+	ModificationKindAdd      ModificationKind = 1
+	ModificationKindSubtract ModificationKind = 2
+)
+
+func (k ModificationKind) String() string {
+	switch k {
+	case ModificationKindAdd:
+		return "add"
+	case ModificationKindSubtract:
+		return "subtract"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
 func (b BodyContractUpgrade) Serialize() []byte {
 	buf := new(bytes.Buffer)
 
@@ -72,6 +158,37 @@ func (b BodyGuardianSetUpdate) Serialize() []byte {
 	return buf.Bytes()
 }
 
+func (b BodySetMessageFee) Serialize() []byte {
+	buf := new(bytes.Buffer)
+
+	// Module
+	buf.Write(CoreModule)
+	// Action
+	MustWrite(buf, binary.BigEndian, uint8(3))
+	// ChainID
+	MustWrite(buf, binary.BigEndian, uint16(b.ChainID))
+
+	buf.Write(common.LeftPadBytes(b.MessageFee.Bytes(), 32))
+
+	return buf.Bytes()
+}
+
+func (b BodyTransferFees) Serialize() []byte {
+	buf := new(bytes.Buffer)
+
+	// Module
+	buf.Write(CoreModule)
+	// Action
+	MustWrite(buf, binary.BigEndian, uint8(4))
+	// ChainID
+	MustWrite(buf, binary.BigEndian, uint16(b.ChainID))
+
+	buf.Write(common.LeftPadBytes(b.Amount.Bytes(), 32))
+	buf.Write(b.Recipient[:])
+
+	return buf.Bytes()
+}
+
 func (r BodyTokenBridgeRegisterChain) Serialize() []byte {
 	if len(r.Module) > 32 {
 		panic("module longer than 32 byte")
@@ -117,3 +234,138 @@ func (r BodyTokenBridgeUpgradeContract) Serialize() []byte {
 
 	return buf.Bytes()
 }
+
+func (b BodyContractRegistry) Serialize() []byte {
+	if len(b.Entries) > 255 {
+		panic("too many contract registry entries")
+	}
+
+	buf := new(bytes.Buffer)
+
+	// Module
+	buf.Write(ContractRegistryModule)
+	// Action
+	MustWrite(buf, binary.BigEndian, uint8(1))
+	// ChainID - 0 for universal
+	MustWrite(buf, binary.BigEndian, uint16(0))
+
+	MustWrite(buf, binary.BigEndian, b.Version)
+	MustWrite(buf, binary.BigEndian, uint8(len(b.Entries)))
+	for _, e := range b.Entries {
+		MustWrite(buf, binary.BigEndian, e.ChainID)
+		buf.Write(e.Core[:])
+		buf.Write(e.TokenBridge[:])
+		buf.Write(e.CoreCodeHash[:])
+	}
+
+	return buf.Bytes()
+}
+
+func (b BodyNetworkConfig) Serialize() []byte {
+	if len(b.Features) > 255 {
+		panic("too many network config features")
+	}
+
+	buf := new(bytes.Buffer)
+
+	// Module
+	buf.Write(NetworkConfigModule)
+	// Action
+	MustWrite(buf, binary.BigEndian, uint8(1))
+	// ChainID - 0 for universal
+	MustWrite(buf, binary.BigEndian, uint16(0))
+
+	MustWrite(buf, binary.BigEndian, b.Version)
+	MustWrite(buf, binary.BigEndian, uint8(len(b.Features)))
+	for _, f := range b.Features {
+		if len(f.Name) > 32 {
+			panic("feature name longer than 32 byte")
+		}
+		buf.Write(common.LeftPadBytes([]byte(f.Name), 32))
+		MustWrite(buf, binary.BigEndian, f.ActivationTime)
+	}
+
+	return buf.Bytes()
+}
+
+func (b BodyAccountantModifyBalance) Serialize() []byte {
+	if len(b.Reason) > 32 {
+		panic("reason longer than 32 byte")
+	}
+
+	buf := new(bytes.Buffer)
+
+	// Module
+	buf.Write(AccountantModule)
+	// Action
+	MustWrite(buf, binary.BigEndian, uint8(1))
+	// ChainID - 0 for universal
+	MustWrite(buf, binary.BigEndian, uint16(0))
+
+	MustWrite(buf, binary.BigEndian, b.Sequence)
+	MustWrite(buf, binary.BigEndian, b.ChainID)
+	MustWrite(buf, binary.BigEndian, b.TokenChain)
+	buf.Write(b.TokenAddress[:])
+	MustWrite(buf, binary.BigEndian, b.Kind)
+	buf.Write(common.LeftPadBytes(b.Amount.Bytes(), 32))
+	buf.Write(common.LeftPadBytes([]byte(b.Reason), 32))
+
+	return buf.Bytes()
+}
+
+// DeserializeGuardianSetUpdate parses the payload of a BodyGuardianSetUpdate governance message, including its module,
+// action and target chain.
+func DeserializeGuardianSetUpdate(payload []byte) (*BodyGuardianSetUpdate, error) {
+	const headerLength = 32 + 1 + 2 + 4 + 1
+	if len(payload) < headerLength {
+		return nil, fmt.Errorf("invalid length %d, expected at least %d", len(payload), headerLength)
+	}
+	if !bytes.Equal(payload[0:32], CoreModule) || payload[32] != 2 {
+		return nil, errors.New("not a guardian set update message")
+	}
+	if binary.BigEndian.Uint16(payload[33:35]) != 0 {
+		return nil, errors.New("invalid target chain")
+	}
+
+	numKeys := int(payload[39])
+	if length := headerLength + numKeys*common.AddressLength; len(payload) != length {
+		return nil, fmt.Errorf("invalid length %d, expected %d for %d keys", len(payload), length, numKeys)
+	}
+
+	b := &BodyGuardianSetUpdate{
+		Keys:     make([]common.Address, numKeys),
+		NewIndex: binary.BigEndian.Uint32(payload[35:39]),
+	}
+	for i := range b.Keys {
+		copy(b.Keys[i][:], payload[headerLength+i*common.AddressLength:])
+	}
+
+	return b, nil
+}
+
+// DeserializeAccountantModifyBalance parses the payload of a BodyAccountantModifyBalance governance message, including its
+// module, action and target chain.
+func DeserializeAccountantModifyBalance(payload []byte) (*BodyAccountantModifyBalance, error) {
+	const length = 32 + 1 + 2 + 8 + 2 + 2 + 32 + 1 + 32 + 32
+	if len(payload) != length {
+		return nil, fmt.Errorf("invalid length %d, expected %d", len(payload), length)
+	}
+	if !bytes.Equal(payload[0:32], AccountantModule) || payload[32] != 1 {
+		return nil, errors.New("not an accountant modify balance message")
+	}
+	if binary.BigEndian.Uint16(payload[33:35]) != 0 {
+		return nil, errors.New("invalid target chain")
+	}
+
+	b := &BodyAccountantModifyBalance{
+		Sequence:   binary.BigEndian.Uint64(payload[35:43]),
+		ChainID:    ChainID(binary.BigEndian.Uint16(payload[43:45])),
+		TokenChain: ChainID(binary.BigEndian.Uint16(payload[45:47])),
+		Kind:       ModificationKind(payload[79]),
+		Amount:     new(big.Int).SetBytes(payload[80:112]),
+		Reason:     string(bytes.TrimLeft(payload[112:144], "\x00")),
+	}
+	copy(b.TokenAddress[:], payload[47:79])
+
+	return b, nil
+}
@@ -2,10 +2,12 @@ package vaa
 
 import (
 	"encoding/hex"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCoreModule(t *testing.T) {
@@ -67,6 +69,41 @@ func TestBodyGuardianSetUpdateSerialize(t *testing.T) {
 	assert.Equal(t, hex.EncodeToString(serializedBodyGuardianSetUpdate), expected)
 }
 
+func TestDeserializeGuardianSetUpdate(t *testing.T) {
+	keys := []common.Address{
+		common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"),
+		common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaee"),
+	}
+	body := BodyGuardianSetUpdate{Keys: keys, NewIndex: uint32(3)}
+	serialized := body.Serialize()
+
+	parsed, err := DeserializeGuardianSetUpdate(serialized)
+	require.NoError(t, err)
+	assert.Equal(t, &body, parsed)
+
+	_, err = DeserializeGuardianSetUpdate(serialized[:len(serialized)-1])
+	assert.Error(t, err)
+	_, err = DeserializeGuardianSetUpdate(BodyContractUpgrade{ChainID: 1}.Serialize())
+	assert.Error(t, err)
+}
+
+func TestBodySetMessageFeeSerialize(t *testing.T) {
+	bodySetMessageFee := BodySetMessageFee{ChainID: 2, MessageFee: big.NewInt(1000)}
+	expected := "00000000000000000000000000000000000000000000000000000000436f7265030002" + "00000000000000000000000000000000000000000000000000000000000003e8"
+	serializedBodySetMessageFee := bodySetMessageFee.Serialize()
+	assert.Equal(t, hex.EncodeToString(serializedBodySetMessageFee), expected)
+}
+
+func TestBodyTransferFeesSerialize(t *testing.T) {
+	addr := Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4}
+	bodyTransferFees := BodyTransferFees{ChainID: 2, Amount: big.NewInt(1000), Recipient: addr}
+	expected := "00000000000000000000000000000000000000000000000000000000436f7265040002" +
+		"00000000000000000000000000000000000000000000000000000000000003e8" +
+		"0000000000000000000000000000000000000000000000000000000000000004"
+	serializedBodyTransferFees := bodyTransferFees.Serialize()
+	assert.Equal(t, hex.EncodeToString(serializedBodyTransferFees), expected)
+}
+
 func TestBodyTokenBridgeRegisterChainSerialize(t *testing.T) {
 	module := "test"
 	addr := Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4}
@@ -84,3 +121,49 @@ func TestBodyTokenBridgeUpgradeContractSerialize(t *testing.T) {
 	serializedBodyTokenBridgeUpgradeContract := bodyTokenBridgeUpgradeContract.Serialize()
 	assert.Equal(t, hex.EncodeToString(serializedBodyTokenBridgeUpgradeContract), expected)
 }
+
+func TestBodyContractRegistrySerialize(t *testing.T) {
+	var codeHash [32]byte
+	for i := range codeHash {
+		codeHash[i] = 0xab
+	}
+	bodyContractRegistry := BodyContractRegistry{
+		Version: 7,
+		Entries: []ContractRegistryEntry{{ChainID: 2, Core: Address{31: 4}, TokenBridge: Address{31: 5}, CoreCodeHash: codeHash}},
+	}
+	expected := "00000000000000000000000000000000436f6e74726163745265676973747279" + "010000" + "0000000000000007" + "01" +
+		"0002" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"abababababababababababababababababababababababababababababababab"
+	serializedBodyContractRegistry := bodyContractRegistry.Serialize()
+	assert.Equal(t, hex.EncodeToString(serializedBodyContractRegistry), expected)
+}
+
+func TestBodyNetworkConfigSerialize(t *testing.T) {
+	body := BodyNetworkConfig{Version: 3, Features: []NetworkConfigFeature{{Name: "batch", ActivationTime: 1700000000}}}
+	expected := "00000000000000000000000000000000000000" + "4e6574776f726b436f6e666967" + "010000" + "0000000000000003" + "01" +
+		"0000000000000000000000000000000000000000000000000000006261746368" + "000000006553f100"
+	assert.Equal(t, expected, hex.EncodeToString(body.Serialize()))
+}
+
+func TestBodyAccountantModifyBalanceSerialize(t *testing.T) {
+	addr := Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4}
+	body := BodyAccountantModifyBalance{Sequence: 5, ChainID: 2, TokenChain: 1, TokenAddress: addr, Kind: ModificationKindSubtract, Amount: big.NewInt(1000), Reason: "fix"}
+	expected := "00000000000000000000000000000000476c6f62616c4163636f756e74616e74010000" +
+		"0000000000000005" + "0002" + "0001" +
+		"0000000000000000000000000000000000000000000000000000000000000004" + "02" +
+		"00000000000000000000000000000000000000000000000000000000000003e8" +
+		"0000000000000000000000000000000000000000000000000000000000666978"
+	serialized := body.Serialize()
+	assert.Equal(t, expected, hex.EncodeToString(serialized))
+
+	parsed, err := DeserializeAccountantModifyBalance(serialized)
+	require.NoError(t, err)
+	assert.Equal(t, &body, parsed)
+
+	_, err = DeserializeAccountantModifyBalance(serialized[:len(serialized)-1])
+	assert.Error(t, err)
+	_, err = DeserializeAccountantModifyBalance(BodyTransferFees{ChainID: 2, Amount: big.NewInt(1000), Recipient: addr}.Serialize())
+	assert.Error(t, err)
+}
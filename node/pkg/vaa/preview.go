@@ -0,0 +1,303 @@
+package vaa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type (
+	// GovernancePreviewField is a named value of a GovernancePreview.
+	GovernancePreviewField struct {
+		Name  string
+		Value string
+	}
+
+	// GovernancePreview is a canonical human-readable description of a governance VAA and of its digest. In the spirit
+	// of EIP-712, it lets a signer review what the digest commits to rather than an opaque hash: every field that is
+	// part of the digest is listed, and the text only depends on the VAA, so every guardian reviewing the same VAA sees
+	// the same description.
+	GovernancePreview struct {
+		Digest common.Hash
+		// Name of the module, such as "Core" or "TokenBridge".
+		Module string
+		// Name of the action, such as "ContractUpgrade". Unknown actions are named by their number.
+		Action      string
+		TargetChain ChainID
+		// Fields of the action, followed by those of the VAA header, in canonical order.
+		Fields []GovernancePreviewField
+	}
+)
+
+// PreviewGovernanceVAA describes a governance VAA. It returns an error if the VAA is not from the governance emitter, or
+// if the payload of a known action is malformed, including when it has trailing bytes which would not be displayed.
+// The payload of unknown modules and actions is displayed in hex.
+func PreviewGovernanceVAA(v *VAA) (*GovernancePreview, error) {
+	if v.EmitterChain != GovernanceChain || v.EmitterAddress != GovernanceEmitter {
+		return nil, errors.New("not a governance VAA")
+	}
+
+	r := bytes.NewReader(v.Payload)
+
+	module := make([]byte, 32)
+	if n, err := r.Read(module); err != nil || n != 32 {
+		return nil, errors.New("failed to read module")
+	}
+
+	var action uint8
+	if err := binary.Read(r, binary.BigEndian, &action); err != nil {
+		return nil, fmt.Errorf("failed to read action: %w", err)
+	}
+
+	p := &GovernancePreview{Digest: v.SigningMsg(), Module: previewModuleName(module)}
+	if err := binary.Read(r, binary.BigEndian, &p.TargetChain); err != nil {
+		return nil, fmt.Errorf("failed to read target chain: %w", err)
+	}
+
+	if err := p.readAction(r, module, action); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", p.Action, err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid %s payload: %d unexpected trailing bytes", p.Action, r.Len())
+	}
+
+	p.add("Guardian set index", fmt.Sprintf("%d", v.GuardianSetIndex))
+	p.add("Timestamp", fmt.Sprintf("%s (%d)", v.Timestamp.UTC().Format(time.RFC3339), v.Timestamp.Unix()))
+	p.add("Nonce", fmt.Sprintf("%d", v.Nonce))
+	p.add("Sequence", fmt.Sprintf("%d", v.Sequence))
+	p.add("Consistency level", fmt.Sprintf("%d", v.ConsistencyLevel))
+
+	return p, nil
+}
+
+func (p *GovernancePreview) add(name string, value string) {
+	p.Fields = append(p.Fields, GovernancePreviewField{Name: name, Value: value})
+}
+
+// readAction names the action and adds its fields.
+func (p *GovernancePreview) readAction(r *bytes.Reader, module []byte, action uint8) error {
+	isBridge := p.Module == "TokenBridge" || p.Module == "NFTBridge"
+
+	switch {
+	case bytes.Equal(module, CoreModule) && action == 1:
+		p.Action = "ContractUpgrade"
+		return p.readAddress(r, "New contract")
+
+	case bytes.Equal(module, CoreModule) && action == 2:
+		p.Action = "GuardianSetUpgrade"
+		var newIndex uint32
+		if err := binary.Read(r, binary.BigEndian, &newIndex); err != nil {
+			return fmt.Errorf("failed to read new guardian set index: %w", err)
+		}
+		p.add("New guardian set index", fmt.Sprintf("%d", newIndex))
+		var numKeys uint8
+		if err := binary.Read(r, binary.BigEndian, &numKeys); err != nil {
+			return fmt.Errorf("failed to read number of keys: %w", err)
+		}
+		p.add("Number of guardians", fmt.Sprintf("%d", numKeys))
+		for i := 0; i < int(numKeys); i++ {
+			var key common.Address
+			if n, err := r.Read(key[:]); err != nil || n != len(key) {
+				return fmt.Errorf("failed to read key %d", i)
+			}
+			p.add(fmt.Sprintf("Guardian %d", i), key.Hex())
+		}
+		return nil
+
+	case bytes.Equal(module, CoreModule) && action == 3:
+		p.Action = "SetMessageFee"
+		return p.readUint256(r, "Message fee")
+
+	case bytes.Equal(module, CoreModule) && action == 4:
+		p.Action = "TransferFees"
+		if err := p.readUint256(r, "Amount"); err != nil {
+			return err
+		}
+		return p.readAddress(r, "Recipient")
+
+	case isBridge && action == 1:
+		p.Action = "RegisterChain"
+		var chainID ChainID
+		if err := binary.Read(r, binary.BigEndian, &chainID); err != nil {
+			return fmt.Errorf("failed to read chain: %w", err)
+		}
+		p.add("Chain", previewChain(chainID))
+		return p.readAddress(r, "Emitter address")
+
+	case isBridge && action == 2:
+		p.Action = "ContractUpgrade"
+		return p.readAddress(r, "New contract")
+
+	case bytes.Equal(module, ContractRegistryModule) && action == 1:
+		p.Action = "ContractRegistry"
+		var version uint64
+		if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+			return fmt.Errorf("failed to read version: %w", err)
+		}
+		p.add("Version", fmt.Sprintf("%d", version))
+		var numEntries uint8
+		if err := binary.Read(r, binary.BigEndian, &numEntries); err != nil {
+			return fmt.Errorf("failed to read number of entries: %w", err)
+		}
+		for i := 0; i < int(numEntries); i++ {
+			var chainID ChainID
+			if err := binary.Read(r, binary.BigEndian, &chainID); err != nil {
+				return fmt.Errorf("failed to read chain of entry %d: %w", i, err)
+			}
+			name := previewChainName(chainID)
+			if err := p.readAddress(r, name+" core"); err != nil {
+				return err
+			}
+			if err := p.readAddress(r, name+" token bridge"); err != nil {
+				return err
+			}
+			if err := p.readHash(r, name+" core code hash"); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case bytes.Equal(module, NetworkConfigModule) && action == 1:
+		p.Action = "NetworkConfig"
+		var version uint64
+		if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+			return fmt.Errorf("failed to read version: %w", err)
+		}
+		p.add("Version", fmt.Sprintf("%d", version))
+		var numFeatures uint8
+		if err := binary.Read(r, binary.BigEndian, &numFeatures); err != nil {
+			return fmt.Errorf("failed to read number of features: %w", err)
+		}
+		for i := 0; i < int(numFeatures); i++ {
+			name := make([]byte, 32)
+			if n, err := r.Read(name); err != nil || n != len(name) {
+				return fmt.Errorf("failed to read name of feature %d", i)
+			}
+			var activationTime uint64
+			if err := binary.Read(r, binary.BigEndian, &activationTime); err != nil {
+				return fmt.Errorf("failed to read activation time of feature %d: %w", i, err)
+			}
+			p.add("Feature "+string(bytes.TrimLeft(name, "\x00")),
+				fmt.Sprintf("active from %s (%d)", time.Unix(int64(activationTime), 0).UTC().Format(time.RFC3339), activationTime))
+		}
+		return nil
+
+	case bytes.Equal(module, AccountantModule) && action == 1:
+		p.Action = "ModifyBalance"
+		var sequence uint64
+		if err := binary.Read(r, binary.BigEndian, &sequence); err != nil {
+			return fmt.Errorf("failed to read sequence: %w", err)
+		}
+		p.add("Modification sequence", fmt.Sprintf("%d", sequence))
+		var chainID, tokenChain ChainID
+		if err := binary.Read(r, binary.BigEndian, &chainID); err != nil {
+			return fmt.Errorf("failed to read chain: %w", err)
+		}
+		p.add("Chain", previewChain(chainID))
+		if err := binary.Read(r, binary.BigEndian, &tokenChain); err != nil {
+			return fmt.Errorf("failed to read token chain: %w", err)
+		}
+		p.add("Token chain", previewChain(tokenChain))
+		if err := p.readAddress(r, "Token address"); err != nil {
+			return err
+		}
+		var kind ModificationKind
+		if err := binary.Read(r, binary.BigEndian, &kind); err != nil {
+			return fmt.Errorf("failed to read kind: %w", err)
+		}
+		p.add("Kind", kind.String())
+		if err := p.readUint256(r, "Amount"); err != nil {
+			return err
+		}
+		reason := make([]byte, 32)
+		if n, err := r.Read(reason); err != nil || n != len(reason) {
+			return errors.New("failed to read reason")
+		}
+		p.add("Reason", string(bytes.TrimLeft(reason, "\x00")))
+		return nil
+	}
+
+	p.Action = fmt.Sprintf("Unknown(%d)", action)
+	rest := make([]byte, r.Len())
+	_, _ = r.Read(rest)
+	p.add("Payload", "0x"+hex.EncodeToString(rest))
+	return nil
+}
+
+func (p *GovernancePreview) readAddress(r *bytes.Reader, name string) error {
+	var a Address
+	if n, err := r.Read(a[:]); err != nil || n != len(a) {
+		return fmt.Errorf("failed to read %s", strings.ToLower(name))
+	}
+	p.add(name, "0x"+a.String())
+	return nil
+}
+
+func (p *GovernancePreview) readHash(r *bytes.Reader, name string) error {
+	var h common.Hash
+	if n, err := r.Read(h[:]); err != nil || n != len(h) {
+		return fmt.Errorf("failed to read %s", strings.ToLower(name))
+	}
+	p.add(name, h.Hex())
+	return nil
+}
+
+func (p *GovernancePreview) readUint256(r *bytes.Reader, name string) error {
+	b := make([]byte, 32)
+	if n, err := r.Read(b); err != nil || n != len(b) {
+		return fmt.Errorf("failed to read %s", strings.ToLower(name))
+	}
+	p.add(name, new(big.Int).SetBytes(b).String())
+	return nil
+}
+
+// previewModuleName returns the name of a left-padded module, or its hex encoding if it is empty or not printable
+// ASCII.
+func previewModuleName(module []byte) string {
+	name := bytes.TrimLeft(module, "\x00")
+	if len(name) == 0 {
+		return "0x" + hex.EncodeToString(module)
+	}
+	for _, c := range name {
+		if c < 0x21 || c > 0x7e {
+			return "0x" + hex.EncodeToString(module)
+		}
+	}
+	return string(name)
+}
+
+// previewChainName returns the name of a chain, or its ID if it is unknown.
+func previewChainName(c ChainID) string {
+	if strings.HasPrefix(c.String(), "unknown") {
+		return fmt.Sprintf("chain %d", uint16(c))
+	}
+	return c.String()
+}
+
+func previewChain(c ChainID) string {
+	if c == ChainIDUnset {
+		return "all (0)"
+	}
+	return fmt.Sprintf("%s (%d)", previewChainName(c), uint16(c))
+}
+
+// String renders the preview as one "Name: value" line per field, starting with the digest and the action.
+func (p *GovernancePreview) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Wormhole governance VAA\n")
+	fmt.Fprintf(&b, "Digest: %s\n", p.Digest.Hex())
+	fmt.Fprintf(&b, "Module: %s\n", p.Module)
+	fmt.Fprintf(&b, "Action: %s\n", p.Action)
+	fmt.Fprintf(&b, "Target chain: %s\n", previewChain(p.TargetChain))
+	for _, f := range p.Fields {
+		fmt.Fprintf(&b, "%s: %s\n", f.Name, f.Value)
+	}
+	return b.String()
+}
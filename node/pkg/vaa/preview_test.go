@@ -0,0 +1,129 @@
+package vaa
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewGovernanceVAA(t *testing.T) {
+	v := CreateGovernanceVAA(time.Unix(1660000000, 0), 7, 42, 3, BodyContractUpgrade{
+		ChainID:     ChainIDEthereum,
+		NewContract: Address{31: 4},
+	}.Serialize())
+
+	p, err := PreviewGovernanceVAA(v)
+	require.NoError(t, err)
+	assert.Equal(t, v.SigningMsg(), p.Digest)
+	assert.Equal(t, "Core", p.Module)
+	assert.Equal(t, "ContractUpgrade", p.Action)
+	assert.Equal(t, ChainIDEthereum, p.TargetChain)
+	assert.Equal(t, "Wormhole governance VAA\n"+
+		"Digest: "+v.SigningMsg().Hex()+"\n"+
+		"Module: Core\n"+
+		"Action: ContractUpgrade\n"+
+		"Target chain: ethereum (2)\n"+
+		"New contract: 0x0000000000000000000000000000000000000000000000000000000000000004\n"+
+		"Guardian set index: 3\n"+
+		"Timestamp: 2022-08-08T23:06:40Z (1660000000)\n"+
+		"Nonce: 7\n"+
+		"Sequence: 42\n"+
+		"Consistency level: 32\n", p.String())
+}
+
+func TestPreviewGovernanceVAAActions(t *testing.T) {
+	fields := func(payload []byte) (string, string, map[string]string) {
+		t.Helper()
+		p, err := PreviewGovernanceVAA(CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, payload))
+		require.NoError(t, err)
+		m := make(map[string]string)
+		for _, f := range p.Fields {
+			m[f.Name] = f.Value
+		}
+		return p.Module, p.Action, m
+	}
+
+	module, action, f := fields(BodyGuardianSetUpdate{
+		Keys:     []common.Address{common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")},
+		NewIndex: 4,
+	}.Serialize())
+	assert.Equal(t, "Core", module)
+	assert.Equal(t, "GuardianSetUpgrade", action)
+	assert.Equal(t, "4", f["New guardian set index"])
+	assert.Equal(t, "1", f["Number of guardians"])
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", f["Guardian 0"])
+
+	_, action, f = fields(BodySetMessageFee{ChainID: ChainIDSolana, MessageFee: big.NewInt(1000)}.Serialize())
+	assert.Equal(t, "SetMessageFee", action)
+	assert.Equal(t, "1000", f["Message fee"])
+
+	_, action, f = fields(BodyTransferFees{ChainID: ChainIDSolana, Amount: big.NewInt(5), Recipient: Address{31: 1}}.Serialize())
+	assert.Equal(t, "TransferFees", action)
+	assert.Equal(t, "5", f["Amount"])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", f["Recipient"])
+
+	module, action, f = fields(BodyTokenBridgeRegisterChain{Module: "NFTBridge", ChainID: ChainIDAptos, EmitterAddress: Address{31: 1}}.Serialize())
+	assert.Equal(t, "NFTBridge", module)
+	assert.Equal(t, "RegisterChain", action)
+	assert.Equal(t, "aptos (22)", f["Chain"])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", f["Emitter address"])
+
+	module, action, f = fields(BodyTokenBridgeUpgradeContract{Module: "TokenBridge", TargetChainID: ChainIDBSC, NewContract: Address{31: 2}}.Serialize())
+	assert.Equal(t, "TokenBridge", module)
+	assert.Equal(t, "ContractUpgrade", action)
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000002", f["New contract"])
+
+	module, action, f = fields(BodyContractRegistry{Version: 2, Entries: []ContractRegistryEntry{
+		{ChainID: ChainIDBSC, Core: Address{31: 1}, TokenBridge: Address{31: 2}, CoreCodeHash: [32]byte{31: 3}},
+		{ChainID: 999},
+	}}.Serialize())
+	assert.Equal(t, "ContractRegistry", module)
+	assert.Equal(t, "ContractRegistry", action)
+	assert.Equal(t, "2", f["Version"])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", f["bsc core"])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000003", f["bsc core code hash"])
+	assert.Contains(t, f, "chain 999 token bridge")
+
+	module, action, f = fields(BodyNetworkConfig{Version: 4, Features: []NetworkConfigFeature{{Name: "batch", ActivationTime: 1700000000}}}.Serialize())
+	assert.Equal(t, "NetworkConfig", module)
+	assert.Equal(t, "NetworkConfig", action)
+	assert.Equal(t, "4", f["Version"])
+	assert.Equal(t, "active from 2023-11-14T22:13:20Z (1700000000)", f["Feature batch"])
+
+	module, action, f = fields(BodyAccountantModifyBalance{Sequence: 3, ChainID: ChainIDSolana, TokenChain: ChainIDEthereum, TokenAddress: Address{31: 1},
+		Kind: ModificationKindSubtract, Amount: big.NewInt(100), Reason: "invalid transfer"}.Serialize())
+	assert.Equal(t, "GlobalAccountant", module)
+	assert.Equal(t, "ModifyBalance", action)
+	assert.Equal(t, "3", f["Modification sequence"])
+	assert.Equal(t, "solana (1)", f["Chain"])
+	assert.Equal(t, "ethereum (2)", f["Token chain"])
+	assert.Equal(t, "subtract", f["Kind"])
+	assert.Equal(t, "100", f["Amount"])
+	assert.Equal(t, "invalid transfer", f["Reason"])
+
+	// Unknown actions are previewed with their raw payload.
+	module, action, f = fields(append(append(common.LeftPadBytes([]byte("Other"), 32), 9, 0, 0), 0xab))
+	assert.Equal(t, "Other", module)
+	assert.Equal(t, "Unknown(9)", action)
+	assert.Equal(t, "0xab", f["Payload"])
+}
+
+func TestPreviewGovernanceVAAErrors(t *testing.T) {
+	payload := BodyContractUpgrade{ChainID: ChainIDEthereum, NewContract: Address{31: 4}}.Serialize()
+
+	v := CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, payload)
+	v.EmitterAddress = Address{31: 5}
+	_, err := PreviewGovernanceVAA(v)
+	assert.Error(t, err)
+
+	_, err = PreviewGovernanceVAA(CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, payload[:len(payload)-1]))
+	assert.Error(t, err)
+
+	// Trailing bytes would not be displayed.
+	_, err = PreviewGovernanceVAA(CreateGovernanceVAA(time.Unix(0, 0), 1, 1, 0, append(payload, 0)))
+	assert.Error(t, err)
+}
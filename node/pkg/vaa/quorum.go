@@ -0,0 +1,16 @@
+package vaa
+
+// CalculateQuorum returns the minimum number of guardians that need to sign a VAA for a given guardian set, which is
+// more than two thirds of the guardians: 1 of 1, 13 of 19 and 14 of 20. An empty guardian set still requires a
+// signature, so it never reaches quorum.
+//
+// The canonical source is the calculation in the contracts (solana/bridge/src/processor.rs and
+// ethereum/contracts/Wormhole.sol), and this needs to match the implementation in the contracts.
+func CalculateQuorum(numGuardians int) int {
+	return ((numGuardians*10/3)*2)/10 + 1
+}
+
+// HasQuorum returns true if numSignatures signatures reach the quorum of a guardian set of numGuardians guardians.
+func HasQuorum(numSignatures int, numGuardians int) bool {
+	return numGuardians > 0 && numSignatures >= CalculateQuorum(numGuardians)
+}
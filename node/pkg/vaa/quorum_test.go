@@ -0,0 +1,67 @@
+package vaa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateQuorum(t *testing.T) {
+	tests := []struct {
+		have int
+		want int
+	}{
+		{have: 0, want: 1},
+		{have: 1, want: 1},
+		{have: 2, want: 2},
+		{have: 3, want: 3},
+		{have: 4, want: 3},
+		{have: 5, want: 4},
+		{have: 6, want: 5},
+		{have: 7, want: 5},
+		{have: 8, want: 6},
+		{have: 9, want: 7},
+		{have: 10, want: 7},
+		{have: 11, want: 8},
+		{have: 12, want: 9},
+		{have: 18, want: 13},
+		{have: 19, want: 13},
+		{have: 20, want: 14},
+		{have: 21, want: 15},
+		{have: 25, want: 17},
+		{have: 100, want: 67},
+		{have: 255, want: 171},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.have), func(t *testing.T) {
+			assert.Equal(t, tc.want, CalculateQuorum(tc.have))
+		})
+	}
+}
+
+// TestCalculateQuorumIsSupermajority checks all guardian set sizes a VAA can encode: the quorum is the smallest number
+// of guardians which is more than two thirds of the set.
+func TestCalculateQuorumIsSupermajority(t *testing.T) {
+	for n := 1; n <= 255; n++ {
+		quorum := CalculateQuorum(n)
+		assert.Greater(t, 3*quorum, 2*n, "quorum of %d is not a supermajority", n)
+		assert.LessOrEqual(t, 3*(quorum-1), 2*n, "quorum of %d is not minimal", n)
+		assert.LessOrEqual(t, quorum, n, "quorum of %d is unreachable", n)
+	}
+}
+
+func TestHasQuorum(t *testing.T) {
+	assert.True(t, HasQuorum(1, 1))
+	assert.False(t, HasQuorum(0, 1))
+
+	assert.True(t, HasQuorum(13, 19))
+	assert.False(t, HasQuorum(12, 19))
+
+	assert.True(t, HasQuorum(14, 20))
+	assert.False(t, HasQuorum(13, 20))
+
+	// An empty guardian set never has a quorum, whatever the number of signatures.
+	assert.False(t, HasQuorum(0, 0))
+	assert.False(t, HasQuorum(1, 0))
+}
@@ -140,6 +140,8 @@ func (c ChainID) String() string {
 		return "injective"
 	case ChainIDPythNet:
 		return "pythnet"
+	case ChainIDWormchain:
+		return "wormchain"
 	default:
 		return fmt.Sprintf("unknown chain ID: %d", c)
 	}
@@ -195,6 +197,8 @@ func ChainIDFromString(s string) (ChainID, error) {
 		return ChainIDInjective, nil
 	case "pythnet":
 		return ChainIDPythNet, nil
+	case "wormchain":
+		return ChainIDWormchain, nil
 	default:
 		return ChainIDUnset, fmt.Errorf("unknown chain ID: %s", s)
 	}
@@ -246,6 +250,8 @@ const (
 	ChainIDAptos ChainID = 22
 	// ChainIDPythNet is the ChainID of PythNet
 	ChainIDPythNet ChainID = 26
+	// ChainIDWormchain is the ChainID of Wormchain
+	ChainIDWormchain ChainID = 3104
 
 	// ChainIDEthereumRopsten is the ChainID of Ethereum Ropsten
 	ChainIDEthereumRopsten ChainID = 10001
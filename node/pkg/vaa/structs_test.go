@@ -43,6 +43,7 @@ func TestChainIDFromString(t *testing.T) {
 		{input: "neon", output: ChainIDNeon},
 		{input: "terra2", output: ChainIDTerra2},
 		{input: "injective", output: ChainIDInjective},
+		{input: "wormchain", output: ChainIDWormchain},
 		{input: "ethereum-ropsten", output: ChainIDEthereumRopsten},
 
 		{input: "Solana", output: ChainIDSolana},
@@ -64,6 +65,7 @@ func TestChainIDFromString(t *testing.T) {
 		{input: "Neon", output: ChainIDNeon},
 		{input: "Terra2", output: ChainIDTerra2},
 		{input: "Injective", output: ChainIDInjective},
+		{input: "Wormchain", output: ChainIDWormchain},
 		{input: "Ethereum-ropsten", output: ChainIDEthereumRopsten},
 	}
 
@@ -155,6 +157,7 @@ func TestChainId_String(t *testing.T) {
 		{input: 17, output: "neon"},
 		{input: 18, output: "terra2"},
 		{input: 19, output: "injective"},
+		{input: 3104, output: "wormchain"},
 		{input: 10001, output: "ethereum-ropsten"},
 	}
 
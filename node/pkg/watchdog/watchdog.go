@@ -0,0 +1,262 @@
+// Package watchdog monitors the resources used by the process, so that optional load can be shed before the host runs out
+// of them and the kernel kills the node.
+//
+// The memory used by the Go runtime, the number of open file descriptors, the disk usage of the data directory, which holds the database, and the number of
+// goroutines are sampled periodically. Once any of them exceeds its limit, the node is degraded: the services that are not
+// required for the node to observe and sign messages, such as the public RPC and the spy subscriptions, reject new requests
+// and drop the streams they serve. The node recovers once all resources are back below a fraction of their limits, so that
+// it does not flap around a limit.
+//
+// The resources that exceed their limits are flagged in the heartbeats, so other guardians can see that a node is degraded.
+package watchdog
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// The names of the monitored resources, used as metric labels and in the heartbeats.
+const (
+	ResourceMemory     = "memory"
+	ResourceFiles      = "files"
+	ResourceDisk       = "disk"
+	ResourceGoroutines = "goroutines"
+)
+
+// A degraded node recovers once all resources are below this fraction of their limits.
+const recoveryRatio = 0.9
+
+var (
+	resourceUsage = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_watchdog_resource_usage",
+			Help: "Current usage of the resources monitored by the watchdog (bytes for memory and disk)",
+		}, []string{"resource"})
+	resourceLimit = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_watchdog_resource_limit",
+			Help: "Limit of the resources monitored by the watchdog, beyond which the node is degraded",
+		}, []string{"resource"})
+	degradedGauge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "wormhole_watchdog_degraded",
+			Help: "Whether the node is degraded because the resource exceeded its limit",
+		}, []string{"resource"})
+)
+
+// Limits of the monitored resources. A zero limit disables the monitoring of a resource.
+type Limits struct {
+	MemoryBytes uint64
+	OpenFiles   uint64
+	DiskBytes   uint64
+	Goroutines  uint64
+}
+
+func (l Limits) byResource() map[string]uint64 {
+	return map[string]uint64{
+		ResourceMemory:     l.MemoryBytes,
+		ResourceFiles:      l.OpenFiles,
+		ResourceDisk:       l.DiskBytes,
+		ResourceGoroutines: l.Goroutines,
+	}
+}
+
+// Enabled returns true if any resource is monitored.
+func (l Limits) Enabled() bool {
+	return l.MemoryBytes != 0 || l.OpenFiles != 0 || l.DiskBytes != 0 || l.Goroutines != 0
+}
+
+// Watchdog samples the resources of the process and tracks whether the node is degraded.
+type Watchdog struct {
+	logger   *zap.Logger
+	limits   map[string]uint64
+	dataDir  string
+	interval time.Duration
+
+	// Returns the usage of a resource. Replaced in tests.
+	sample func(resource string) (uint64, error)
+
+	mu sync.Mutex
+	// The resources currently exceeding their limits.
+	degraded map[string]bool
+	// Closed when the node becomes degraded, and replaced once it recovers.
+	shed chan struct{}
+}
+
+// New creates a watchdog enforcing the limits. The disk usage is that of the files in dataDir.
+func New(logger *zap.Logger, limits Limits, dataDir string, interval time.Duration) *Watchdog {
+	w := &Watchdog{
+		logger:   logger,
+		limits:   limits.byResource(),
+		dataDir:  dataDir,
+		interval: interval,
+		degraded: make(map[string]bool),
+		shed:     make(chan struct{}),
+	}
+	w.sample = w.sampleResource
+	for resource, limit := range w.limits {
+		if limit != 0 {
+			resourceLimit.WithLabelValues(resource).Set(float64(limit))
+		}
+	}
+	return w
+}
+
+// Runnable returns the supervisor runnable sampling the resources.
+func (w *Watchdog) Runnable() supervisor.Runnable {
+	return func(ctx context.Context) error {
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+
+		for {
+			w.Check()
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// Check samples all monitored resources and updates the degradation state. It is called periodically by the runnable.
+func (w *Watchdog) Check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wasDegraded := len(w.degraded) != 0
+	for resource, limit := range w.limits {
+		if limit == 0 {
+			continue
+		}
+
+		usage, err := w.sample(resource)
+		if err != nil {
+			w.logger.Warn("watchdog: failed to sample resource", zap.String("resource", resource), zap.Error(err))
+			continue
+		}
+		resourceUsage.WithLabelValues(resource).Set(float64(usage))
+
+		if !w.degraded[resource] && usage > limit {
+			w.logger.Warn("watchdog: resource exceeds its limit, shedding optional load",
+				zap.String("resource", resource), zap.Uint64("usage", usage), zap.Uint64("limit", limit))
+			w.degraded[resource] = true
+			degradedGauge.WithLabelValues(resource).Set(1)
+		} else if w.degraded[resource] && float64(usage) < recoveryRatio*float64(limit) {
+			w.logger.Info("watchdog: resource recovered",
+				zap.String("resource", resource), zap.Uint64("usage", usage), zap.Uint64("limit", limit))
+			delete(w.degraded, resource)
+			degradedGauge.WithLabelValues(resource).Set(0)
+		}
+	}
+
+	isDegraded := len(w.degraded) != 0
+	if isDegraded && !wasDegraded {
+		close(w.shed)
+	} else if !isDegraded && wasDegraded {
+		w.shed = make(chan struct{})
+	}
+	p2p.DefaultRegistry.SetDegraded(w.reasonsAlreadyLocked())
+}
+
+// Returns the resources exceeding their limits, sorted by name. Assumes the lock is held.
+func (w *Watchdog) reasonsAlreadyLocked() []string {
+	reasons := make([]string, 0, len(w.degraded))
+	for resource := range w.degraded {
+		reasons = append(reasons, resource)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// Reasons returns the resources exceeding their limits, none if the node is healthy.
+func (w *Watchdog) Reasons() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reasonsAlreadyLocked()
+}
+
+// Degraded returns true if optional load should be shed. It is safe to call on a nil watchdog, which is never degraded.
+func (w *Watchdog) Degraded() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.degraded) != 0
+}
+
+// Shedding returns a channel that is closed once the node is degraded, to drop long-lived streams. It is safe to call on a
+// nil watchdog, which returns a nil channel.
+func (w *Watchdog) Shedding() <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.shed
+}
+
+func (w *Watchdog) sampleResource(resource string) (uint64, error) {
+	switch resource {
+	case ResourceMemory:
+		// The memory obtained from the OS, minus what was returned to it. This approximates the resident set size.
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return m.Sys - m.HeapReleased, nil
+	case ResourceFiles:
+		// Only supported on Linux.
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			return 0, err
+		}
+		return uint64(len(entries)), nil
+	case ResourceDisk:
+		return dirSize(w.dataDir)
+	case ResourceGoroutines:
+		return uint64(runtime.NumGoroutine()), nil
+	}
+	return 0, nil
+}
+
+// dirSize returns the total size of the files in a directory tree.
+func dirSize(path string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may be removed by compactions while walking.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,74 @@
+package watchdog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCheckDegradesAndRecovers(t *testing.T) {
+	w := New(zap.NewNop(), Limits{MemoryBytes: 1000, Goroutines: 100}, "", time.Minute)
+	usage := map[string]uint64{ResourceMemory: 500, ResourceGoroutines: 50}
+	w.sample = func(resource string) (uint64, error) { return usage[resource], nil }
+
+	w.Check()
+	assert.False(t, w.Degraded())
+	shed := w.Shedding()
+
+	usage[ResourceMemory] = 1001
+	usage[ResourceGoroutines] = 101
+	w.Check()
+	assert.True(t, w.Degraded())
+	assert.Equal(t, []string{ResourceGoroutines, ResourceMemory}, w.Reasons())
+	select {
+	case <-shed:
+	default:
+		t.Fatal("streams were not shed")
+	}
+
+	// A resource only recovers once it is well below its limit.
+	usage[ResourceMemory] = 950
+	usage[ResourceGoroutines] = 10
+	w.Check()
+	assert.Equal(t, []string{ResourceMemory}, w.Reasons())
+
+	usage[ResourceMemory] = 800
+	w.Check()
+	assert.False(t, w.Degraded())
+	assert.Empty(t, w.Reasons())
+	select {
+	case <-w.Shedding():
+		t.Fatal("streams are shed after recovering")
+	default:
+	}
+}
+
+func TestDisabledResourcesAreNotSampled(t *testing.T) {
+	w := New(zap.NewNop(), Limits{DiskBytes: 10}, "", time.Minute)
+	w.sample = func(resource string) (uint64, error) {
+		assert.Equal(t, ResourceDisk, resource)
+		return 0, nil
+	}
+	w.Check()
+	assert.False(t, w.Degraded())
+
+	var nilWatchdog *Watchdog
+	assert.False(t, nilWatchdog.Degraded())
+	assert.Nil(t, nilWatchdog.Shedding())
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000001.vlog"), make([]byte, 100), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "000002.sst"), make([]byte, 50), 0600))
+
+	size, err := dirSize(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150), size)
+}
@@ -0,0 +1,228 @@
+// Package watchercontrol allows the chain watchers to be paused, resumed and pointed at a different RPC endpoint at runtime,
+// without restarting guardiand.
+//
+// Each watcher is registered with the controller, which returns a runnable to be started by the supervisor in place of the
+// watcher. That runnable starts the watcher as a child, using the current endpoint, unless the chain is paused. When the state
+// of the chain changes, the runnable exits so the supervisor restarts it, and with it the watcher, using the new state.
+//
+// Paused chains are flagged in the heartbeats, so other guardians can see that a chain is administratively paused.
+// The state is only kept in memory. On restart, all watchers run using the endpoints specified on the command line.
+package watchercontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/p2p"
+	nodev1 "github.com/certusone/wormhole/node/pkg/proto/node/v1"
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+
+	"go.uber.org/zap"
+)
+
+// Factory creates the runnable for a watcher using the specified RPC endpoint.
+type Factory func(rpcURL string) supervisor.Runnable
+
+var errStateChanged = errors.New("watcher state changed, restarting")
+
+// RestartPolicy is used to restart the watchers that die, so that a watcher whose RPC node is down is not restarted in
+// a tight loop. Changing the state of a chain, for instance its endpoint, restarts its watchers immediately.
+var RestartPolicy = supervisor.RestartPolicy{
+	MaxRestarts:   8,
+	BreakDuration: 10 * time.Minute,
+}
+
+type (
+	// The state of the watchers for a chain. Some chains, such as Solana, have more than one watcher.
+	chainEntry struct {
+		chainID vaa.ChainID
+		paused  bool
+
+		// The endpoint is empty for watchers that take more than one endpoint, in which case it cannot be changed.
+		rpcURL string
+
+		// Closed and replaced whenever the state changes.
+		changed chan struct{}
+	}
+
+	Controller struct {
+		mutex  sync.Mutex
+		logger *zap.Logger
+		chains map[vaa.ChainID]*chainEntry
+	}
+)
+
+func NewController(logger *zap.Logger) *Controller {
+	return &Controller{
+		logger: logger,
+		chains: make(map[vaa.ChainID]*chainEntry),
+	}
+}
+
+// Register returns the runnable for a watcher whose endpoint can be changed at runtime. If the watcher is registered again,
+// for instance because its parent runnable was restarted, any endpoint set by the operator is retained.
+func (c *Controller) Register(chainID vaa.ChainID, rpcURL string, factory Factory) supervisor.Runnable {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ce, created := c.getOrCreateAlreadyLocked(chainID)
+	if created {
+		ce.rpcURL = rpcURL
+	}
+	return c.runnable(ce, factory)
+}
+
+// RegisterFixed returns the runnable for a watcher that can be paused and resumed, but whose endpoints cannot be changed.
+func (c *Controller) RegisterFixed(chainID vaa.ChainID, runnable supervisor.Runnable) supervisor.Runnable {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ce, _ := c.getOrCreateAlreadyLocked(chainID)
+	return c.runnable(ce, func(string) supervisor.Runnable { return runnable })
+}
+
+// Returns the entry for a chain, creating it if necessary, and whether it was created. Assumes the lock is held.
+func (c *Controller) getOrCreateAlreadyLocked(chainID vaa.ChainID) (*chainEntry, bool) {
+	ce, exists := c.chains[chainID]
+	if !exists {
+		ce = &chainEntry{chainID: chainID, changed: make(chan struct{})}
+		c.chains[chainID] = ce
+	}
+	return ce, !exists
+}
+
+func (c *Controller) runnable(ce *chainEntry, factory Factory) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		c.mutex.Lock()
+		paused := ce.paused
+		rpcURL := ce.rpcURL
+		changed := ce.changed
+		c.mutex.Unlock()
+
+		if paused {
+			supervisor.Logger(ctx).Info("watcher is paused", zap.Stringer("chain", ce.chainID))
+		} else if err := supervisor.Run(ctx, "watcher", factory(rpcURL), supervisor.WithRestartPolicy(RestartPolicy)); err != nil {
+			return err
+		}
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+			return errStateChanged
+		}
+	}
+}
+
+// Updates the state of a chain and notifies its watchers.
+func (c *Controller) update(chainID vaa.ChainID, f func(ce *chainEntry) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ce, exists := c.chains[chainID]
+	if !exists {
+		return fmt.Errorf("no watcher is running for chain %v", chainID)
+	}
+
+	if err := f(ce); err != nil {
+		return err
+	}
+
+	close(ce.changed)
+	ce.changed = make(chan struct{})
+	p2p.DefaultRegistry.SetPaused(chainID, ce.paused)
+	return nil
+}
+
+// Pause stops the watchers for a chain until it is resumed.
+func (c *Controller) Pause(chainID vaa.ChainID) (string, error) {
+	err := c.update(chainID, func(ce *chainEntry) error {
+		if ce.paused {
+			return fmt.Errorf("chain %v is already paused", chainID)
+		}
+		ce.paused = true
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Info("watchercontrol: paused watcher", zap.Stringer("chain", chainID))
+	return fmt.Sprintf("paused watcher for chain %v", chainID), nil
+}
+
+// Resume restarts the watchers for a paused chain.
+func (c *Controller) Resume(chainID vaa.ChainID) (string, error) {
+	err := c.update(chainID, func(ce *chainEntry) error {
+		if !ce.paused {
+			return fmt.Errorf("chain %v is not paused", chainID)
+		}
+		ce.paused = false
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Info("watchercontrol: resumed watcher", zap.Stringer("chain", chainID))
+	return fmt.Sprintf("resumed watcher for chain %v", chainID), nil
+}
+
+// SetEndpoint restarts the watcher for a chain using a new RPC endpoint. If the chain is paused, the new endpoint is used once it is resumed.
+func (c *Controller) SetEndpoint(chainID vaa.ChainID, rpcURL string) (string, error) {
+	if rpcURL == "" {
+		return "", fmt.Errorf("the endpoint must be specified")
+	}
+
+	err := c.update(chainID, func(ce *chainEntry) error {
+		if ce.rpcURL == "" {
+			return fmt.Errorf("the endpoint of the watcher for chain %v cannot be changed", chainID)
+		}
+		ce.rpcURL = rpcURL
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The endpoint may contain credentials, so it is not logged.
+	c.logger.Info("watchercontrol: changed watcher endpoint", zap.Stringer("chain", chainID))
+	return fmt.Sprintf("changed endpoint of watcher for chain %v", chainID), nil
+}
+
+// IsPaused returns true if the watchers for a chain are paused.
+func (c *Controller) IsPaused(chainID vaa.ChainID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ce, exists := c.chains[chainID]
+	return exists && ce.paused
+}
+
+// Status returns the state of the watchers, sorted by chain.
+func (c *Controller) Status() []*nodev1.WatcherStatusResponse_Entry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	resp := make([]*nodev1.WatcherStatusResponse_Entry, 0, len(c.chains))
+	for _, ce := range c.chains {
+		resp = append(resp, &nodev1.WatcherStatusResponse_Entry{
+			ChainId:              uint32(ce.chainID),
+			Paused:               ce.paused,
+			EndpointConfigurable: ce.rpcURL != "",
+		})
+	}
+
+	sort.SliceStable(resp, func(i, j int) bool {
+		return resp[i].ChainId < resp[j].ChainId
+	})
+
+	return resp
+}
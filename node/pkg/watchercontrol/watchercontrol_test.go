@@ -0,0 +1,116 @@
+package watchercontrol
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/certusone/wormhole/node/pkg/supervisor"
+	"github.com/certusone/wormhole/node/pkg/vaa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Records the endpoint of the running watcher, which is empty while no watcher is running.
+type fakeWatcher struct {
+	mutex  sync.Mutex
+	rpcURL string
+	starts int
+}
+
+func (w *fakeWatcher) factory(rpcURL string) supervisor.Runnable {
+	return func(ctx context.Context) error {
+		w.mutex.Lock()
+		w.rpcURL = rpcURL
+		w.starts++
+		w.mutex.Unlock()
+
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		<-ctx.Done()
+
+		w.mutex.Lock()
+		w.rpcURL = ""
+		w.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (w *fakeWatcher) state() (string, int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.rpcURL, w.starts
+}
+
+func TestPauseResumeAndSetEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewController(zap.NewNop())
+	w := &fakeWatcher{}
+
+	supervisor.New(ctx, zap.NewNop(), func(ctx context.Context) error {
+		if err := supervisor.Run(ctx, "ethwatch", c.Register(vaa.ChainIDEthereum, "ws://original", w.factory)); err != nil {
+			return err
+		}
+		supervisor.Signal(ctx, supervisor.SignalHealthy)
+		<-ctx.Done()
+		return ctx.Err()
+	}, supervisor.WithPropagatePanic)
+
+	waitFor := func(expectedURL string, expectedStarts int) {
+		require.Eventually(t, func() bool {
+			rpcURL, starts := w.state()
+			return rpcURL == expectedURL && starts == expectedStarts
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	waitFor("ws://original", 1)
+
+	_, err := c.Pause(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	assert.True(t, c.IsPaused(vaa.ChainIDEthereum))
+	waitFor("", 1)
+
+	_, err = c.Pause(vaa.ChainIDEthereum)
+	assert.Error(t, err)
+
+	// Changing the endpoint of a paused chain does not resume it.
+	_, err = c.SetEndpoint(vaa.ChainIDEthereum, "ws://replacement")
+	require.NoError(t, err)
+	waitFor("", 1)
+
+	_, err = c.Resume(vaa.ChainIDEthereum)
+	require.NoError(t, err)
+	assert.False(t, c.IsPaused(vaa.ChainIDEthereum))
+	waitFor("ws://replacement", 2)
+
+	_, err = c.SetEndpoint(vaa.ChainIDEthereum, "ws://another")
+	require.NoError(t, err)
+	waitFor("ws://another", 3)
+
+	status := c.Status()
+	require.Equal(t, 1, len(status))
+	assert.Equal(t, uint32(vaa.ChainIDEthereum), status[0].ChainId)
+	assert.False(t, status[0].Paused)
+	assert.True(t, status[0].EndpointConfigurable)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	c := NewController(zap.NewNop())
+	c.RegisterFixed(vaa.ChainIDSolana, func(ctx context.Context) error { return nil })
+
+	_, err := c.Pause(vaa.ChainIDBSC)
+	assert.Error(t, err)
+
+	_, err = c.Resume(vaa.ChainIDSolana)
+	assert.Error(t, err)
+
+	_, err = c.SetEndpoint(vaa.ChainIDSolana, "ws://replacement")
+	assert.Error(t, err)
+
+	status := c.Status()
+	require.Equal(t, 1, len(status))
+	assert.False(t, status[0].EndpointConfigurable)
+}